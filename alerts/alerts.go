@@ -31,6 +31,10 @@ const (
 	severityErrorStr    = "error"
 	severityCriticalStr = "critical"
 
+	// maxResolvedIncidents is the maximum number of resolved incidents the
+	// manager keeps track of, the oldest incidents are dropped first.
+	maxResolvedIncidents = 10000
+
 	webhookModule        = "alerts"
 	webhookEventDismiss  = "dismiss"
 	webhookEventRegister = "register"
@@ -60,11 +64,23 @@ type (
 		Timestamp time.Time      `json:"timestamp"`
 	}
 
+	// An Incident is an alert that is or was active, unlike alerts incidents
+	// are kept around after the alert was dismissed.
+	Incident struct {
+		ID         types.Hash256 `json:"id"`
+		Severity   Severity      `json:"severity"`
+		Registered time.Time     `json:"registered"`
+		Resolved   time.Time     `json:"resolved"` // zero if still active
+	}
+
 	// A Manager manages the host's alerts.
 	Manager struct {
 		mu sync.Mutex
 		// alerts is a map of alert IDs to their current alert.
-		alerts             map[types.Hash256]Alert
+		alerts map[types.Hash256]Alert
+		// resolved contains the incidents of dismissed alerts, ordered by
+		// the time they were resolved.
+		resolved           []Incident
 		webhookBroadcaster webhooks.Broadcaster
 	}
 
@@ -72,11 +88,20 @@ type (
 		Offset   int
 		Limit    int
 		Severity Severity
+
+		// Module only includes alerts registered by the given module, e.g.
+		// "autopilot" or "worker", alerts registered by a worker can also be
+		// filtered by the worker's ID, e.g. "worker.worker".
+		Module string
+
+		// Since only includes alerts registered at or after the given time.
+		Since time.Time
 	}
 
 	AlertsResponse struct {
 		Alerts  []Alert `json:"alerts"`
 		HasMore bool    `json:"hasMore"`
+		Total   int     `json:"total"` // number of alerts matching all filters
 		Totals  struct {
 			Info     int `json:"info"`
 			Warning  int `json:"warning"`
@@ -106,8 +131,12 @@ func RandomAlertID() types.Hash256 {
 	return frand.Entropy256()
 }
 
-func (ar AlertsResponse) Total() int {
-	return ar.Totals.Info + ar.Totals.Warning + ar.Totals.Error + ar.Totals.Critical
+// hasOrigin returns true if the alert was registered by the given module.
+// Origins are namespaced using dots, so an alert with origin "worker.foo"
+// originates from both "worker" and "worker.foo".
+func (a Alert) hasOrigin(module string) bool {
+	origin, _ := a.Data["origin"].(string)
+	return origin == module || strings.HasPrefix(origin, module+".")
 }
 
 // String implements the fmt.Stringer interface.
@@ -181,14 +210,24 @@ func (m *Manager) RegisterAlert(ctx context.Context, alert Alert) error {
 // DismissAlerts implements the Alerter interface.
 func (m *Manager) DismissAlerts(ctx context.Context, ids ...types.Hash256) error {
 	var dismissed []types.Hash256
+	now := time.Now()
 	m.mu.Lock()
 	for _, id := range ids {
-		_, exists := m.alerts[id]
+		a, exists := m.alerts[id]
 		if !exists {
 			continue
 		}
 		delete(m.alerts, id)
 		dismissed = append(dismissed, id)
+		m.resolved = append(m.resolved, Incident{
+			ID:         id,
+			Severity:   a.Severity,
+			Registered: a.Timestamp,
+			Resolved:   now,
+		})
+	}
+	if len(m.resolved) > maxResolvedIncidents {
+		m.resolved = append([]Incident(nil), m.resolved[len(m.resolved)-maxResolvedIncidents:]...)
 	}
 	if len(m.alerts) == 0 {
 		m.alerts = make(map[types.Hash256]Alert) // reclaim memory
@@ -214,14 +253,15 @@ func (m *Manager) Alerts(_ context.Context, opts AlertsOpts) (AlertsResponse, er
 	offset, limit := opts.Offset, opts.Limit
 	resp := AlertsResponse{}
 
-	if offset >= len(m.alerts) {
-		return resp, nil
-	} else if limit == -1 {
-		limit = len(m.alerts)
-	}
-
 	alerts := make([]Alert, 0, len(m.alerts))
 	for _, a := range m.alerts {
+		if opts.Module != "" && !a.hasOrigin(opts.Module) {
+			continue // filter by module
+		} else if !opts.Since.IsZero() && a.Timestamp.Before(opts.Since) {
+			continue // filter by timestamp
+		}
+
+		// NOTE: the totals are not affected by the severity filter
 		if a.Severity == SeverityInfo {
 			resp.Totals.Info++
 		} else if a.Severity == SeverityWarning {
@@ -236,6 +276,13 @@ func (m *Manager) Alerts(_ context.Context, opts AlertsOpts) (AlertsResponse, er
 		}
 		alerts = append(alerts, a)
 	}
+	resp.Total = len(alerts)
+	if offset >= len(alerts) {
+		return resp, nil
+	} else if limit == -1 {
+		limit = len(alerts)
+	}
+
 	sort.Slice(alerts, func(i, j int) bool {
 		return alerts[i].Timestamp.After(alerts[j].Timestamp)
 	})
@@ -248,6 +295,26 @@ func (m *Manager) Alerts(_ context.Context, opts AlertsOpts) (AlertsResponse, er
 	return resp, nil
 }
 
+// Incidents returns the incidents that were active at or after the given time,
+// this includes both active alerts and alerts that were dismissed since.
+func (m *Manager) Incidents(since time.Time) []Incident {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var incidents []Incident
+	for _, a := range m.alerts {
+		incidents = append(incidents, Incident{
+			ID:         a.ID,
+			Severity:   a.Severity,
+			Registered: a.Timestamp,
+		})
+	}
+	for i := len(m.resolved) - 1; i >= 0 && !m.resolved[i].Resolved.Before(since); i-- {
+		incidents = append(incidents, m.resolved[i])
+	}
+	return incidents
+}
+
 func (m *Manager) RegisterWebhookBroadcaster(b webhooks.Broadcaster) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
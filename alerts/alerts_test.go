@@ -13,6 +13,7 @@ import (
 	"go.sia.tech/core/types"
 	"go.sia.tech/renterd/webhooks"
 	"go.uber.org/zap"
+	"lukechampine.com/frand"
 )
 
 type testWebhookStore struct {
@@ -43,11 +44,35 @@ func (s *testWebhookStore) Webhooks(_ context.Context) ([]webhooks.Webhook, erro
 	return nil, nil
 }
 
+func (s *testWebhookStore) AddWebhookDeadLetter(_ context.Context, _ webhooks.DeadLetter) error {
+	return nil
+}
+
+func (s *testWebhookStore) DeleteWebhookDeadLetter(_ context.Context, _ int64) error {
+	return nil
+}
+
+func (s *testWebhookStore) PruneWebhookDeadLetters(_ context.Context, _ time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (s *testWebhookStore) UpdateWebhookDeadLetter(_ context.Context, _ webhooks.DeadLetter) error {
+	return nil
+}
+
+func (s *testWebhookStore) WebhookDeadLetter(_ context.Context, _ int64) (webhooks.DeadLetter, error) {
+	return webhooks.DeadLetter{}, webhooks.ErrDeadLetterNotFound
+}
+
+func (s *testWebhookStore) WebhookDeadLetters(_ context.Context) ([]webhooks.DeadLetter, error) {
+	return nil, nil
+}
+
 var _ webhooks.WebhookStore = (*testWebhookStore)(nil)
 
 func TestWebhooks(t *testing.T) {
 	store := &testWebhookStore{}
-	mgr, err := webhooks.NewManager(store, zap.NewNop())
+	mgr, err := webhooks.NewManager(store, webhooks.DefaultDeadLetterTTL, zap.NewNop())
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -171,3 +196,113 @@ func TestWebhooks(t *testing.T) {
 		t.Fatalf("wrong number of hooks listed: %v != 1", store.listed)
 	}
 }
+
+func TestAlertsFilters(t *testing.T) {
+	m := NewManager()
+
+	// register alerts from different modules at different times
+	now := time.Now()
+	register := func(origin string, severity Severity, timestamp time.Time) {
+		t.Helper()
+		if err := m.RegisterAlert(context.Background(), Alert{
+			ID:        frand.Entropy256(),
+			Severity:  severity,
+			Message:   "test",
+			Data:      map[string]any{"origin": origin},
+			Timestamp: timestamp,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	register("bus", SeverityInfo, now.Add(-time.Hour))
+	register("autopilot", SeverityWarning, now.Add(-time.Hour))
+	register("autopilot", SeverityError, now)
+	register("worker.foo", SeverityError, now)
+	register("worker.bar", SeverityCritical, now)
+	register("workers", SeverityCritical, now)
+
+	tests := []struct {
+		opts     AlertsOpts
+		total    int
+		critical int
+	}{
+		{AlertsOpts{}, 6, 2},
+		{AlertsOpts{Module: "autopilot"}, 2, 0},
+		{AlertsOpts{Module: "worker"}, 2, 1},
+		{AlertsOpts{Module: "worker.foo"}, 1, 0},
+		{AlertsOpts{Since: now}, 4, 2},
+		{AlertsOpts{Module: "autopilot", Since: now}, 1, 0},
+		{AlertsOpts{Severity: SeverityCritical}, 2, 2},
+		{AlertsOpts{Severity: SeverityCritical, Module: "worker"}, 1, 1},
+	}
+	for i, test := range tests {
+		test.opts.Limit = -1
+		resp, err := m.Alerts(context.Background(), test.opts)
+		if err != nil {
+			t.Fatal(err)
+		} else if resp.Total != test.total || len(resp.Alerts) != test.total {
+			t.Fatalf("%d: expected %d alerts, got %d (total %d)", i, test.total, len(resp.Alerts), resp.Total)
+		} else if resp.Totals.Critical != test.critical {
+			t.Fatalf("%d: expected %d critical alerts, got %d", i, test.critical, resp.Totals.Critical)
+		}
+	}
+
+	// assert the total isn't affected by pagination
+	resp, err := m.Alerts(context.Background(), AlertsOpts{Offset: 1, Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	} else if resp.Total != 6 || len(resp.Alerts) != 2 || !resp.HasMore {
+		t.Fatal("unexpected response", resp.Total, len(resp.Alerts), resp.HasMore)
+	}
+
+	// assert an offset beyond the number of alerts still returns the totals
+	resp, err = m.Alerts(context.Background(), AlertsOpts{Offset: 10, Limit: -1})
+	if err != nil {
+		t.Fatal(err)
+	} else if resp.Total != 6 || len(resp.Alerts) != 0 || resp.Totals.Critical != 2 {
+		t.Fatal("unexpected response", resp.Total, len(resp.Alerts), resp.Totals)
+	}
+}
+
+func TestIncidents(t *testing.T) {
+	m := NewManager()
+
+	// register two alerts
+	start := time.Now()
+	ids := []types.Hash256{frand.Entropy256(), frand.Entropy256()}
+	for i, id := range ids {
+		if err := m.RegisterAlert(context.Background(), Alert{
+			ID:        id,
+			Severity:  Severity(i + 1),
+			Message:   "test",
+			Data:      map[string]any{"origin": "test"},
+			Timestamp: start,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if incidents := m.Incidents(start); len(incidents) != 2 {
+		t.Fatalf("expected 2 incidents, got %v", len(incidents))
+	}
+
+	// dismiss the first alert and assert it's still reported as an incident
+	if err := m.DismissAlerts(context.Background(), ids[0]); err != nil {
+		t.Fatal(err)
+	}
+	incidents := m.Incidents(start)
+	if len(incidents) != 2 {
+		t.Fatalf("expected 2 incidents, got %v", len(incidents))
+	}
+	for _, incident := range incidents {
+		if incident.ID == ids[0] && (incident.Resolved.IsZero() || incident.Severity != SeverityInfo) {
+			t.Fatal("unexpected incident", incident)
+		} else if incident.ID == ids[1] && !incident.Resolved.IsZero() {
+			t.Fatal("unexpected incident", incident)
+		}
+	}
+
+	// assert resolved incidents before the given time are omitted
+	if incidents := m.Incidents(time.Now().Add(time.Hour)); len(incidents) != 1 || incidents[0].ID != ids[1] {
+		t.Fatal("unexpected incidents", incidents)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 
+	"go.sia.tech/core/types"
 	"go.sia.tech/renterd/internal/utils"
 )
 
@@ -29,6 +30,11 @@ type (
 		Enabled   bool            `json:"enabled"`
 		Contracts ContractsConfig `json:"contracts"`
 		Hosts     HostsConfig     `json:"hosts"`
+
+		// PriceRenegotiationEnabled indicates whether the autopilot should
+		// renew contracts early when the host's current contract price dropped
+		// significantly below the price the contract was formed at.
+		PriceRenegotiationEnabled bool `json:"priceRenegotiationEnabled"`
 	}
 
 	// ContractsConfig contains all contract settings used in the autopilot.
@@ -40,6 +46,11 @@ type (
 		Upload      uint64 `json:"upload"`
 		Storage     uint64 `json:"storage"`
 		Prune       bool   `json:"prune"`
+
+		// AutoSizeContractSet indicates whether the autopilot should
+		// automatically adjust the amount of contracts to the recommended
+		// contract count for the configured storage.
+		AutoSizeContractSet bool `json:"autoSizeContractSet"`
 	}
 
 	// HostsConfig contains all hosts settings used in the autopilot.
@@ -93,8 +104,12 @@ type (
 		PruningLastStart   TimeRFC3339 `json:"pruningLastStart"`
 		Scanning           bool        `json:"scanning"`
 		ScanningLastStart  TimeRFC3339 `json:"scanningLastStart"`
+		ScannerPaused      bool        `json:"scannerPaused"`
+		NextScanAt         TimeRFC3339 `json:"nextScanAt"`
 		UptimeMS           DurationMS  `json:"uptimeMs"`
 
+		PriceRenegotiationsInitiated uint64 `json:"priceRenegotiationsInitiated"`
+
 		StartTime TimeRFC3339 `json:"startTime"`
 		BuildState
 	}
@@ -127,6 +142,40 @@ type (
 			NotScanned            uint64 `json:"notScanned"`
 		} `json:"unusable"`
 		Recommendation *ConfigRecommendation `json:"recommendation,omitempty"`
+
+		// ContractCount is the recommended amount of contracts for the
+		// evaluated config.
+		ContractCount *ContractCountRecommendation `json:"contractCount,omitempty"`
+	}
+
+	// ContractCountRecommendation is the response type for the
+	// /contract-count-recommendation endpoint, it contains the recommended
+	// amount of contracts along with the inputs used to compute it.
+	ContractCountRecommendation struct {
+		TargetStorage   uint64  `json:"targetStorage"`
+		MinShards       int     `json:"minShards"`
+		TotalShards     int     `json:"totalShards"`
+		Redundancy      float64 `json:"redundancy"`
+		RequiredStorage uint64  `json:"requiredStorage"`
+		AvgContractSize uint64  `json:"avgContractSize"`
+
+		CurrentAmount     uint64 `json:"currentAmount"`
+		RecommendedAmount uint64 `json:"recommendedAmount"`
+	}
+
+	// NetworkDiversityResponse is the response type for the
+	// /network-diversity endpoint, it contains the distribution of the
+	// contracted hosts across subnets.
+	NetworkDiversityResponse struct {
+		Hosts   int                  `json:"hosts"`
+		Unknown int                  `json:"unknown"`
+		Subnets []SubnetDistribution `json:"subnets"`
+	}
+
+	// SubnetDistribution contains the contracted hosts in a subnet.
+	SubnetDistribution struct {
+		Subnet string            `json:"subnet"`
+		Hosts  []types.PublicKey `json:"hosts"`
 	}
 )
 
@@ -2,8 +2,11 @@ package api
 
 import (
 	"errors"
+	"fmt"
 	"regexp"
 	"strings"
+	"sync"
+	"time"
 )
 
 var (
@@ -18,6 +21,10 @@ var (
 	// ErrBucketNotFound is returned when an bucket can't be retrieved from the
 	// database.
 	ErrBucketNotFound = errors.New("bucket not found")
+
+	// ErrPathPolicyViolation is returned when an object path doesn't comply
+	// with the path policy of its bucket.
+	ErrPathPolicyViolation = errors.New("path violates the bucket's path policy")
 )
 
 type (
@@ -28,7 +35,36 @@ type (
 	}
 
 	BucketPolicy struct {
-		PublicReadAccess bool `json:"publicReadAccess"`
+		PublicReadAccess bool       `json:"publicReadAccess"`
+		PathPolicy       PathPolicy `json:"pathPolicy"`
+
+		// Versioning indicates whether previous versions of an object are
+		// kept when it's overwritten or deleted.
+		Versioning bool `json:"versioning"`
+
+		// LifecycleRules are used to automatically remove objects once they
+		// are older than a rule's expiry.
+		LifecycleRules []LifecycleRule `json:"lifecycleRules,omitempty"`
+	}
+
+	// LifecycleRule expires all objects whose key starts with the rule's
+	// prefix once they are older than ExpiryDays days. An empty prefix
+	// matches all objects in the bucket. Disabled rules are kept but not
+	// applied.
+	LifecycleRule struct {
+		Prefix     string `json:"prefix"`
+		ExpiryDays int    `json:"expiryDays"`
+		Disabled   bool   `json:"disabled,omitempty"`
+	}
+
+	// PathPolicy restricts the paths of the objects in a bucket. A path has to
+	// start with one of the allowed prefixes, if any are set, it can't match
+	// any of the denied patterns, which are Go regular expressions, and can't
+	// be longer than the max path length, if set.
+	PathPolicy struct {
+		AllowedPrefixes []string `json:"allowedPrefixes,omitempty"`
+		DeniedPatterns  []string `json:"deniedPatterns,omitempty"`
+		MaxPathLength   int      `json:"maxPathLength,omitempty"`
 	}
 
 	CreateBucketOptions struct {
@@ -49,6 +85,93 @@ type (
 
 var validBucketExp = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,61}[a-z0-9]$`)
 
+// Validate returns an error if the policy is invalid.
+func (bp BucketPolicy) Validate() error {
+	for _, rule := range bp.LifecycleRules {
+		if err := rule.Validate(); err != nil {
+			return err
+		}
+	}
+	return bp.PathPolicy.Validate()
+}
+
+// Validate returns an error if the rule's expiry isn't positive or if its
+// prefix doesn't start with a '/', object keys always do.
+func (lr LifecycleRule) Validate() error {
+	if lr.Prefix != "" && !strings.HasPrefix(lr.Prefix, "/") {
+		return fmt.Errorf("lifecycle rule prefix %q must start with a '/'", lr.Prefix)
+	} else if lr.ExpiryDays <= 0 {
+		return fmt.Errorf("lifecycle rule for prefix %q must have a positive expiry, got %d days", lr.Prefix, lr.ExpiryDays)
+	}
+	return nil
+}
+
+// Expiry returns the duration after which objects matching the rule expire.
+func (lr LifecycleRule) Expiry() time.Duration {
+	return time.Duration(lr.ExpiryDays) * 24 * time.Hour
+}
+
+// Validate returns an error if the max path length is negative or if any of
+// the denied patterns isn't a valid regular expression.
+func (pp PathPolicy) Validate() error {
+	if pp.MaxPathLength < 0 {
+		return errors.New("max path length can't be negative")
+	}
+	for _, pattern := range pp.DeniedPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid denied pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Check returns ErrPathPolicyViolation if the given path doesn't comply with
+// the policy.
+func (pp PathPolicy) Check(path string) error {
+	if pp.MaxPathLength > 0 && len(path) > pp.MaxPathLength {
+		return fmt.Errorf("%w; path exceeds max length of %d", ErrPathPolicyViolation, pp.MaxPathLength)
+	}
+
+	if len(pp.AllowedPrefixes) > 0 {
+		var allowed bool
+		for _, prefix := range pp.AllowedPrefixes {
+			if strings.HasPrefix(path, prefix) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("%w; path doesn't start with any of the allowed prefixes", ErrPathPolicyViolation)
+		}
+	}
+
+	for _, pattern := range pp.DeniedPatterns {
+		exp, err := compileDeniedPattern(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid denied pattern %q: %w", pattern, err)
+		} else if exp.MatchString(path) {
+			return fmt.Errorf("%w; path matches denied pattern %q", ErrPathPolicyViolation, pattern)
+		}
+	}
+	return nil
+}
+
+// deniedPatterns caches the compiled denied patterns of all path policies by
+// their expression so paths can be checked without recompiling them.
+var deniedPatterns sync.Map
+
+func compileDeniedPattern(pattern string) (*regexp.Regexp, error) {
+	if exp, ok := deniedPatterns.Load(pattern); ok {
+		return exp.(*regexp.Regexp), nil
+	}
+	exp, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	deniedPatterns.Store(pattern, exp)
+	return exp, nil
+}
+
 func (req BucketCreateRequest) Validate() error {
 	// make sure the bucket name complies with the restrictions for S3 transfer
 	// acceleration which are the regular S3 conventions with the additional
@@ -58,5 +181,5 @@ func (req BucketCreateRequest) Validate() error {
 		!validBucketExp.MatchString(req.Name) {
 		return errors.New("the bucket name doesn't comply with the S3 bucket naming convention (https://docs.aws.amazon.com/AmazonS3/latest/userguide/bucketnamingrules.html)")
 	}
-	return nil
+	return req.Policy.Validate()
 }
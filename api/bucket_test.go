@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"strings"
 	"testing"
 )
@@ -66,3 +67,64 @@ func TestBucketNameValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestPathPolicy(t *testing.T) {
+	// assert invalid policies are caught
+	if err := (PathPolicy{MaxPathLength: -1}).Validate(); err == nil {
+		t.Fatal("expected error for negative max path length")
+	} else if err := (PathPolicy{DeniedPatterns: []string{"("}}).Validate(); err == nil {
+		t.Fatal("expected error for invalid pattern")
+	} else if err := (BucketCreateRequest{Name: "foo", Policy: BucketPolicy{PathPolicy: PathPolicy{DeniedPatterns: []string{"("}}}}).Validate(); err == nil {
+		t.Fatal("expected error for invalid bucket policy")
+	}
+
+	pp := PathPolicy{
+		AllowedPrefixes: []string{"/data/", "/backups/"},
+		DeniedPatterns:  []string{`\.\.`, `\.tmp$`},
+		MaxPathLength:   20,
+	}
+	if err := pp.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path  string
+		valid bool
+	}{
+		{"/data/foo", true},
+		{"/backups/foo", true},
+		{"/other/foo", false},
+		{"/data/../foo", false},
+		{"/data/foo.tmp", false},
+		{"/data/" + strings.Repeat("x", 15), false},
+	}
+	for _, test := range tests {
+		err := pp.Check(test.path)
+		if test.valid && err != nil {
+			t.Fatalf("%v: unexpected error %v", test.path, err)
+		} else if !test.valid && !errors.Is(err, ErrPathPolicyViolation) {
+			t.Fatalf("%v: expected ErrPathPolicyViolation, got %v", test.path, err)
+		}
+	}
+
+	// an empty policy allows every path
+	if err := (PathPolicy{}).Check("../foo"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLifecycleRuleValidation(t *testing.T) {
+	for _, test := range []struct {
+		rule  LifecycleRule
+		valid bool
+	}{
+		{LifecycleRule{Prefix: "", ExpiryDays: 1}, true},
+		{LifecycleRule{Prefix: "/logs/", ExpiryDays: 1}, true},
+		{LifecycleRule{Prefix: "logs/", ExpiryDays: 1}, false},
+		{LifecycleRule{Prefix: "/logs/", ExpiryDays: 0}, false},
+	} {
+		if err := test.rule.Validate(); (err == nil) != test.valid {
+			t.Fatalf("unexpected result for rule %+v: %v", test.rule, err)
+		}
+	}
+}
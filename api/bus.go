@@ -17,7 +17,14 @@ var (
 	ErrMaxFundAmountExceeded = errors.New("renewal exceeds max fund amount")
 	ErrInvalidDatabase       = errors.New("invalid database type")
 	ErrBackupNotSupported    = errors.New("backups not supported for used database")
+	ErrCompactNotSupported   = errors.New("compaction not supported for used database")
 	ErrExplorerDisabled      = errors.New("explorer is disabled")
+	ErrForkDetected          = errors.New("contract operations are paused, node is following a fork")
+	ErrChainLagging          = errors.New("contract operations are paused, chain subscriber is lagging behind")
+	ErrRecoveryInProgress    = errors.New("metadata recovery is already in progress")
+	ErrVacuumInProgress      = errors.New("vacuum is already in progress")
+	ErrVacuumNotFound        = errors.New("vacuum not found")
+	ErrVacuumNotSupported    = errors.New("vacuum not supported for used database")
 )
 
 type (
@@ -27,16 +34,35 @@ type (
 		LastBlockTime TimeRFC3339 `json:"lastBlockTime"`
 		Synced        bool        `json:"synced"`
 	}
+
+	// ForkStatus describes whether the node follows the canonical chain, the
+	// canonical chain being the longest chain reported by our peers.
+	ForkStatus struct {
+		OnCanonicalChain bool   `json:"onCanonicalChain"`
+		LocalHeight      uint64 `json:"localHeight"`
+		CanonicalHeight  uint64 `json:"canonicalHeight"`
+		DivergenceHeight uint64 `json:"divergenceHeight"`
+	}
 )
 
 type (
 	// UploadParams contains the metadata needed by a worker to upload an object.
 	UploadParams struct {
-		CurrentHeight uint64
-		UploadPacking bool
+		CurrentHeight     uint64
+		UploadPacking     bool
+		RetryPolicy       UploadRetryPolicy
+		ChecksumAlgorithm string
 		GougingParams
 	}
 
+	// PeerLatency contains the measured round-trip time to a syncer peer. If
+	// the peer couldn't be reached, Error is set instead.
+	PeerLatency struct {
+		Addr  string     `json:"addr"`
+		RTT   DurationMS `json:"rtt"`
+		Error string     `json:"error,omitempty"`
+	}
+
 	// GougingParams contains the metadata needed by a worker to perform gouging
 	// checks.
 	GougingParams struct {
@@ -66,12 +92,59 @@ type (
 		Path     string `json:"path"`
 	}
 
+	// MetadataRecoveryStatus is the response type for the
+	// /admin/recover-metadata/status endpoint.
+	MetadataRecoveryStatus struct {
+		Running    bool        `json:"running"`
+		StartedAt  TimeRFC3339 `json:"startedAt"`
+		FinishedAt TimeRFC3339 `json:"finishedAt"`
+		Error      string      `json:"error,omitempty"`
+
+		ContractsTotal   uint64 `json:"contractsTotal"`
+		ContractsScanned uint64 `json:"contractsScanned"`
+		ContractsFailed  uint64 `json:"contractsFailed"`
+		SectorsScanned   uint64 `json:"sectorsScanned"`
+		SectorsMatched   uint64 `json:"sectorsMatched"`
+		ObjectsRecovered uint64 `json:"objectsRecovered"`
+		ObjectsDeleted   uint64 `json:"objectsDeleted"`
+	}
+
+	// VacuumStatus is the response type for the /store/vacuum endpoints.
+	VacuumStatus struct {
+		ID         string      `json:"id"`
+		Running    bool        `json:"running"`
+		StartedAt  TimeRFC3339 `json:"startedAt"`
+		FinishedAt TimeRFC3339 `json:"finishedAt"`
+		Error      string      `json:"error,omitempty"`
+	}
+
 	// BusStateResponse is the response type for the /bus/state endpoint.
 	BusStateResponse struct {
 		StartTime TimeRFC3339 `json:"startTime"`
 		Network   string      `json:"network"`
 		BuildState
 		Explorer ExplorerState `json:"explorer"`
+
+		// ChainLag is the number of blocks the bus is behind the chain
+		// manager's tip.
+		ChainLag uint64 `json:"chainLag"`
+
+		// SymlinksResolved is the number of symlinks that were resolved since
+		// the bus was started.
+		SymlinksResolved uint64 `json:"symlinksResolved"`
+
+		// SlabBufferDefragmentationRuns is the number of times the slab
+		// buffer was defragmented since the bus was started.
+		SlabBufferDefragmentationRuns uint64 `json:"slabBufferDefragmentationRuns"`
+
+		// SlabBufferBytesOptimized is the number of bytes that were moved
+		// between slab buffers by defragmentation since the bus was started.
+		SlabBufferBytesOptimized uint64 `json:"slabBufferBytesOptimized"`
+
+		// UPnPExternalAddr is the external address that is forwarded to the
+		// syncer through UPnP, it's empty if UPnP is disabled or the port
+		// isn't forwarded.
+		UPnPExternalAddr string `json:"upnpExternalAddr,omitempty"`
 	}
 
 	// ExplorerState contains static information about explorer data sources.
@@ -96,8 +169,9 @@ type (
 
 	// UpdateAutopilotRequest is the request type for the /autopilot endpoint.
 	UpdateAutopilotRequest struct {
-		Enabled   *bool            `json:"enabled"`
-		Contracts *ContractsConfig `json:"contracts"`
-		Hosts     *HostsConfig     `json:"hosts"`
+		Enabled                   *bool            `json:"enabled"`
+		Contracts                 *ContractsConfig `json:"contracts"`
+		Hosts                     *HostsConfig     `json:"hosts"`
+		PriceRenegotiationEnabled *bool            `json:"priceRenegotiationEnabled"`
 	}
 )
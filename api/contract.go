@@ -2,6 +2,8 @@ package api
 
 import (
 	"errors"
+	"math/big"
+	"time"
 
 	rhpv2 "go.sia.tech/core/rhp/v2"
 	"go.sia.tech/core/types"
@@ -16,26 +18,70 @@ const (
 	ContractStateFailed   = "failed"
 )
 
+const (
+	// WebhookModuleContract is the webhook module of contract related events.
+	WebhookModuleContract = "contract"
+
+	// WebhookEventContractStateChange is broadcast when a chain update changes
+	// the state of a contract, its payload is an EventContractStateChange.
+	WebhookEventContractStateChange = "statechange"
+)
+
 const (
 	ContractUsabilityBad  = "bad"
 	ContractUsabilityGood = "good"
 )
 
+const (
+	// ContractSortByHealth sorts contracts by their health score, the least
+	// healthy contracts first.
+	ContractSortByHealth = "health"
+)
+
 const (
 	ContractArchivalReasonHostPruned = "hostpruned"
 	ContractArchivalReasonRemoved    = "removed"
 	ContractArchivalReasonRenewed    = "renewed"
 )
 
+const (
+	// ContractProofConfirmationDepth is the number of blocks that need to be
+	// mined on top of a contract's resolution before the contract is
+	// considered finalized.
+	ContractProofConfirmationDepth = 144
+)
+
 var (
+	// ErrArchivalDataLoss is returned when archiving contracts would render
+	// objects inaccessible because their data isn't stored on any other
+	// contracts.
+	ErrArchivalDataLoss = errors.New("archiving contracts would render objects inaccessible")
+
 	// ErrContractNotFound is returned when a contract can't be retrieved from
 	// the database.
 	ErrContractNotFound = errors.New("couldn't find contract")
+
+	// ErrContractRenewalCooldown is returned when a contract renewal is
+	// attempted while another renewal of the same contract was attempted
+	// recently.
+	ErrContractRenewalCooldown = errors.New("contract renewal is in cooldown")
+
+	// ErrStorageProofNotFound is returned when no storage proof for a
+	// contract was found on chain.
+	ErrStorageProofNotFound = errors.New("couldn't find storage proof")
 )
 
 type ContractState string
 
 type (
+	// EventContractStateChange is the payload of the contract state change
+	// webhook event.
+	EventContractStateChange struct {
+		ContractID types.FileContractID `json:"contractID"`
+		OldState   ContractState        `json:"oldState"`
+		NewState   ContractState        `json:"newState"`
+	}
+
 	// ContractSize contains information about the size of the contract and
 	// about how much of the contract data can be pruned.
 	ContractSize struct {
@@ -49,25 +95,38 @@ type (
 		HostKey types.PublicKey      `json:"hostKey"`
 		V2      bool                 `json:"v2"`
 
-		ProofHeight    uint64               `json:"proofHeight"`
-		RenewedFrom    types.FileContractID `json:"renewedFrom"`
-		RevisionHeight uint64               `json:"revisionHeight"`
-		RevisionNumber uint64               `json:"revisionNumber"`
-		Size           uint64               `json:"size"`
-		StartHeight    uint64               `json:"startHeight"`
-		State          string               `json:"state"`
-		Usability      string               `json:"usability"`
-		WindowStart    uint64               `json:"windowStart"`
-		WindowEnd      uint64               `json:"windowEnd"`
+		ProofConfirmations uint64               `json:"proofConfirmations"`
+		ProofHeight        uint64               `json:"proofHeight"`
+		RenewedFrom        types.FileContractID `json:"renewedFrom"`
+		RevisionHeight     uint64               `json:"revisionHeight"`
+		RevisionNumber     uint64               `json:"revisionNumber"`
+		Size               uint64               `json:"size"`
+		StartHeight        uint64               `json:"startHeight"`
+		State              string               `json:"state"`
+		Usability          string               `json:"usability"`
+		WindowStart        uint64               `json:"windowStart"`
+		WindowEnd          uint64               `json:"windowEnd"`
 
 		// costs & spending
 		ContractPrice      types.Currency   `json:"contractPrice"`
 		InitialRenterFunds types.Currency   `json:"initialRenterFunds"`
 		Spending           ContractSpending `json:"spending"`
 
+		// prices the contract was formed or renewed at, they are zero for
+		// contracts formed before the prices were recorded
+		StoragePrice  types.Currency `json:"storagePrice"`
+		UploadPrice   types.Currency `json:"uploadPrice"`
+		DownloadPrice types.Currency `json:"downloadPrice"`
+
 		// following fields are only set on archived contracts
 		ArchivalReason string               `json:"archivalReason,omitempty"`
 		RenewedTo      types.FileContractID `json:"renewedTo,omitempty"`
+
+		// following fields are computed by the bus when the contract is
+		// fetched and are not persisted
+		BlocksUntilExpiry   int64      `json:"blocksUntilExpiry"`
+		EstimatedExpiryTime *time.Time `json:"estimatedExpiryTime,omitempty"`
+		HealthScore         float64    `json:"healthScore"`
 	}
 
 	// ContractPrunableData wraps a contract's size information with its id.
@@ -82,6 +141,13 @@ type (
 		FundAccount types.Currency `json:"fundAccount"`
 		SectorRoots types.Currency `json:"sectorRoots"`
 		Uploads     types.Currency `json:"uploads"`
+
+		// fees paid per RPC type, these are a breakdown of the costs of
+		// the individual RPCs and partially paid from ephemeral accounts,
+		// so they are not part of the total
+		AccountFundingFees types.Currency `json:"accountFundingFees"`
+		PriceTableFees     types.Currency `json:"priceTableFees"`
+		SectorUploadFees   types.Currency `json:"sectorUploadFees"`
 	}
 
 	ContractSpendingRecord struct {
@@ -127,6 +193,12 @@ type (
 		RenterAddress  types.Address   `json:"renterAddress"`
 	}
 
+	// FormationQueueDepth is the response type for the GET
+	// /contracts/formations endpoint, it contains the number of contract
+	// negotiations per host that are waiting for another negotiation with the
+	// same host to finish.
+	FormationQueueDepth map[types.PublicKey]uint64
+
 	// ContractKeepaliveRequest is the request type for the /contract/:id/keepalive
 	// endpoint.
 	ContractKeepaliveRequest struct {
@@ -149,6 +221,18 @@ type (
 		Error        string `json:"error,omitempty"`
 	}
 
+	// ContractStorageProof is the response type for the
+	// /contract/:id/storage-proof endpoint, it contains the transaction that
+	// contains the contract's storage proof and the block it was included in.
+	// Depending on the contract's version either Transaction or V2Transaction
+	// is set.
+	ContractStorageProof struct {
+		BlockHeight   uint64               `json:"blockHeight"`
+		BlockID       types.BlockID        `json:"blockID"`
+		Transaction   *types.Transaction   `json:"transaction,omitempty"`
+		V2Transaction *types.V2Transaction `json:"v2Transaction,omitempty"`
+	}
+
 	// ContractAcquireRequest is the request type for the /contract/:id/release
 	// endpoint.
 	ContractReleaseRequest struct {
@@ -177,6 +261,10 @@ type (
 
 	ContractsOpts struct {
 		FilterMode string `json:"filterMode"`
+
+		// SortBy is applied by the bus after computing the health scores,
+		// it's ignored by the store.
+		SortBy string `json:"sortBy"`
 	}
 )
 
@@ -191,6 +279,9 @@ func (x ContractSpending) Add(y ContractSpending) (z ContractSpending) {
 	z.FundAccount = x.FundAccount.Add(y.FundAccount)
 	z.Deletions = x.Deletions.Add(y.Deletions)
 	z.SectorRoots = x.SectorRoots.Add(y.SectorRoots)
+	z.AccountFundingFees = x.AccountFundingFees.Add(y.AccountFundingFees)
+	z.PriceTableFees = x.PriceTableFees.Add(y.PriceTableFees)
+	z.SectorUploadFees = x.SectorUploadFees.Add(y.SectorUploadFees)
 	return
 }
 
@@ -198,10 +289,59 @@ func (cm ContractMetadata) EndHeight() uint64 {
 	return cm.WindowStart
 }
 
+// WithExpiry returns a copy of the contract metadata with the number of blocks
+// until the contract's end height and an estimate of the time at which it is
+// reached, given the current block height and the network's block interval.
+func (cm ContractMetadata) WithExpiry(bh uint64, blockInterval time.Duration, now time.Time) ContractMetadata {
+	cm.BlocksUntilExpiry = int64(cm.EndHeight()) - int64(bh)
+	expiry := now.Add(time.Duration(cm.BlocksUntilExpiry) * blockInterval)
+	cm.EstimatedExpiryTime = &expiry
+	return cm
+}
+
+// WithHealthScore returns a copy of the contract metadata with its health
+// score set, given the current block height. The score is a value between 0
+// and 1 and is the average of three factors: the fraction of the renter funds
+// that remains, whether the remaining funds last until the contract expires
+// at the current spending rate and the fraction of the contract's duration
+// that remains.
+func (cm ContractMetadata) WithHealthScore(bh uint64) ContractMetadata {
+	// remaining capacity
+	capacity := 1.0
+	if !cm.InitialRenterFunds.IsZero() {
+		spent, _ := new(big.Rat).SetFrac(cm.Spending.Total().Big(), cm.InitialRenterFunds.Big()).Float64()
+		capacity = 1 - min(spent, 1)
+	}
+
+	// remaining duration
+	var duration float64
+	if end := cm.EndHeight(); end > cm.StartHeight && bh < end {
+		duration = float64(end-max(bh, cm.StartHeight)) / float64(end-cm.StartHeight)
+	}
+
+	// spending ratio, the remaining funds relative to the funds that are
+	// projected to be spent before the contract expires
+	spending := 1.0
+	if elapsed := 1 - duration; elapsed > 0 && capacity < 1 {
+		if projected := (1 - capacity) / elapsed * duration; projected > capacity {
+			spending = capacity / projected
+		}
+	}
+
+	cm.HealthScore = (capacity + spending + duration) / 3
+	return cm
+}
+
 func (cm ContractMetadata) IsGood() bool {
 	return cm.Usability == ContractUsabilityGood
 }
 
+// IsFinalized returns true if the contract was resolved and its resolution
+// received enough confirmations to be considered safe from reorgs.
+func (cm ContractMetadata) IsFinalized() bool {
+	return cm.ProofConfirmations >= ContractProofConfirmationDepth
+}
+
 type (
 	Revision struct {
 		ContractID      types.FileContractID `json:"contractID"`
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"go.sia.tech/core/types"
+)
+
+func TestContractMetadataWithExpiry(t *testing.T) {
+	now := time.Now()
+	cm := ContractMetadata{WindowStart: 100, WindowEnd: 244}
+
+	// assert the expiry is estimated relative to the end height
+	md := cm.WithExpiry(40, 10*time.Minute, now)
+	if md.BlocksUntilExpiry != 60 {
+		t.Fatal("unexpected blocks until expiry", md.BlocksUntilExpiry)
+	} else if md.EstimatedExpiryTime == nil || !md.EstimatedExpiryTime.Equal(now.Add(10*time.Hour)) {
+		t.Fatal("unexpected expiry time", md.EstimatedExpiryTime)
+	}
+
+	// assert expired contracts have a negative countdown
+	md = cm.WithExpiry(110, 10*time.Minute, now)
+	if md.BlocksUntilExpiry != -10 {
+		t.Fatal("unexpected blocks until expiry", md.BlocksUntilExpiry)
+	} else if !md.EstimatedExpiryTime.Equal(now.Add(-100 * time.Minute)) {
+		t.Fatal("unexpected expiry time", md.EstimatedExpiryTime)
+	}
+
+	// assert the original metadata is unchanged
+	if cm.EstimatedExpiryTime != nil || cm.BlocksUntilExpiry != 0 {
+		t.Fatal("metadata was modified")
+	}
+}
+
+func TestContractMetadataWithHealthScore(t *testing.T) {
+	cm := ContractMetadata{
+		StartHeight:        100,
+		WindowStart:        200,
+		InitialRenterFunds: types.Siacoins(100),
+	}
+	assertScore := func(md ContractMetadata, expected float64) {
+		t.Helper()
+		if math.Abs(md.HealthScore-expected) > 1e-9 {
+			t.Fatalf("unexpected health score %v, expected %v", md.HealthScore, expected)
+		}
+	}
+
+	// assert a new contract is perfectly healthy
+	assertScore(cm.WithHealthScore(100), 1)
+
+	// assert a contract that spent half its funds halfway through its
+	// duration is on track
+	cm.Spending.Uploads = types.Siacoins(50)
+	assertScore(cm.WithHealthScore(150), (0.5+1+0.5)/3)
+
+	// assert a contract that spends faster than it should is penalized, after
+	// a quarter of its duration it's projected to spend 150SC more
+	assertScore(cm.WithHealthScore(125), (0.5+0.5/1.5+0.75)/3)
+
+	// assert a contract that ran out of funds is penalized unless it expired
+	cm.Spending.Uploads = types.Siacoins(100)
+	assertScore(cm.WithHealthScore(150), 0.5/3)
+	assertScore(cm.WithHealthScore(200), 1.0/3)
+
+	// assert the original metadata is unchanged
+	if cm.HealthScore != 0 {
+		t.Fatal("metadata was modified")
+	}
+}
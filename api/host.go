@@ -1,6 +1,7 @@
 package api
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -26,12 +27,16 @@ const (
 	UsabilityFilterModeAll      = "all"
 	UsabilityFilterModeUsable   = "usable"
 	UsabilityFilterModeUnusable = "unusable"
+	UsabilityFilterModeRHP3     = "rhp3"
 )
 
 var (
 	// ErrHostNotFound is returned when a host can't be retrieved from the
 	// database.
 	ErrHostNotFound = errors.New("host doesn't exist in hostdb")
+
+	// ErrInvalidHostCursor is returned when a host cursor can't be decoded.
+	ErrInvalidHostCursor = errors.New("invalid host cursor")
 )
 
 var (
@@ -61,7 +66,7 @@ type (
 
 	// HostsRequest is the request type for the /api/bus/hosts endpoint.
 	HostsRequest struct {
-		Offset          int               `json:"offset"`
+		Cursor          string            `json:"cursor"`
 		Limit           int               `json:"limit"`
 		FilterMode      string            `json:"filterMode"`
 		UsabilityMode   string            `json:"usabilityMode"`
@@ -91,12 +96,12 @@ type (
 type (
 	HostOptions struct {
 		AddressContains string
+		Cursor          string
 		FilterMode      string
 		UsabilityMode   string
 		KeyIn           []types.PublicKey
 		Limit           int
 		MaxLastScan     TimeRFC3339
-		Offset          int
 	}
 )
 
@@ -111,6 +116,7 @@ type (
 		V2Settings        rhp4.HostSettings  `json:"v2Settings,omitempty"`
 		Interactions      HostInteractions   `json:"interactions"`
 		Scanned           bool               `json:"scanned"`
+		SiaMuxReachable   bool               `json:"siaMuxReachable"`
 		Blocked           bool               `json:"blocked"`
 		Checks            HostChecks         `json:"checks,omitempty"`
 		StoredData        uint64             `json:"storedData"`
@@ -136,6 +142,15 @@ type (
 		FailedInteractions     float64 `json:"failedInteractions"`
 	}
 
+	// HostBenchmark is the result of benchmarking a host by uploading a
+	// sector to it and downloading it again.
+	HostBenchmark struct {
+		Timestamp    time.Time  `json:"timestamp"`
+		UploadMBps   float64    `json:"uploadMBps"`
+		DownloadMBps float64    `json:"downloadMBps"`
+		TTFB         DurationMS `json:"ttfb"`
+	}
+
 	HostScan struct {
 		HostKey    types.PublicKey      `json:"hostKey"`
 		PriceTable rhpv3.HostPriceTable `json:"priceTable,omitempty"`
@@ -143,6 +158,24 @@ type (
 		V2Settings rhp4.HostSettings    `json:"v2Settings,omitempty"`
 		Success    bool                 `json:"success"`
 		Timestamp  time.Time            `json:"timestamp"`
+
+		// Latency is the time it took to scan the host and Error is the
+		// reason a failed scan failed.
+		Latency DurationMS `json:"latency"`
+		Error   string     `json:"error,omitempty"`
+
+		// ResolvedAddresses are the IP addresses the host's address resolved
+		// to when it was scanned.
+		ResolvedAddresses []string `json:"resolvedAddresses,omitempty"`
+
+		// SiaMuxReachable indicates whether the host's SiaMux port accepted
+		// connections, it is nil if the port wasn't checked during the scan.
+		SiaMuxReachable *bool `json:"siaMuxReachable,omitempty"`
+
+		// RPCLatency is the round-trip time of a minimal RPC with the host,
+		// measured after a successful scan. Unlike Latency, it doesn't include
+		// the time it takes to connect to the host.
+		RPCLatency DurationMS `json:"rpcLatency,omitempty"`
 	}
 
 	HostPriceTable struct {
@@ -161,6 +194,37 @@ type (
 		GougingBreakdown   HostGougingBreakdown   `json:"gougingBreakdown"`
 		ScoreBreakdown     HostScoreBreakdown     `json:"scoreBreakdown"`
 		UsabilityBreakdown HostUsabilityBreakdown `json:"usabilityBreakdown"`
+
+		// FormationBackoffCount is the number of consecutive failed contract
+		// formations with the host, the autopilot backs off exponentially
+		// before attempting to form another contract with the host.
+		FormationBackoffCount uint64 `json:"formationBackoffCount"`
+
+		// UnstablePricing indicates the host changed its upload or download
+		// price by a large amount within a short period of time, the host's
+		// price score is lowered while it is flagged.
+		UnstablePricing bool `json:"unstablePricing"`
+
+		// GeoScore is a multiplier between 0 and 1 that penalizes hosts
+		// located in a country that already holds more than the allowed
+		// fraction of our contracts, it's 1 if geo-scoring is disabled or the
+		// host's location is unknown.
+		GeoScore float64 `json:"geoScore"`
+
+		// AllocatedStorage is the amount of data stored with the host across
+		// all active contracts, AllocatedStoragePct expresses it as a
+		// percentage of the host's total storage. Both are computed from the
+		// contracts when the host is fetched and are not persisted.
+		AllocatedStorage    uint64  `json:"allocatedStorage"`
+		AllocatedStoragePct float64 `json:"allocatedStoragePct"`
+	}
+
+	// HostPriceChange describes the upload and download price of a host after
+	// a price change was observed during a scan.
+	HostPriceChange struct {
+		Timestamp     time.Time      `json:"timestamp"`
+		UploadPrice   types.Currency `json:"uploadPrice"`
+		DownloadPrice types.Currency `json:"downloadPrice"`
 	}
 
 	HostGougingBreakdown struct {
@@ -193,6 +257,12 @@ type (
 	}
 )
 
+// IsZero returns true if the host hasn't been checked yet. Unchecked hosts
+// have a neutral GeoScore of 1.
+func (hc HostChecks) IsZero() bool {
+	return hc == HostChecks{} || hc == HostChecks{GeoScore: 1}
+}
+
 func (hc HostChecks) MarshalJSON() ([]byte, error) {
 	type check HostChecks
 	return json.Marshal(struct {
@@ -326,3 +396,21 @@ func (ub HostUsabilityBreakdown) UnusableReasons() []string {
 	}
 	return reasons
 }
+
+// NewHostCursor returns a cursor that can be passed to the hosts endpoint to
+// fetch the hosts following the host with the given key. Hosts are sorted by
+// their public key, so the cursor is usually created from the last host of the
+// previous page.
+func NewHostCursor(hk types.PublicKey) string {
+	return base64.RawURLEncoding.EncodeToString(hk[:])
+}
+
+// ParseHostCursor decodes a cursor created by NewHostCursor.
+func ParseHostCursor(cursor string) (hk types.PublicKey, _ error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(b) != len(hk) {
+		return types.PublicKey{}, fmt.Errorf("%w: %q", ErrInvalidHostCursor, cursor)
+	}
+	copy(hk[:], b)
+	return hk, nil
+}
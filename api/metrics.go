@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/object"
 )
 
 var (
@@ -17,6 +18,7 @@ const (
 	MetricContract      = "contract"
 	MetricContractPrune = "contractprune"
 	MetricPerformance   = "performance"
+	MetricSlabDownload  = "slabdownload"
 	MetricWallet        = "wallet"
 )
 
@@ -66,6 +68,20 @@ type (
 		HostVersion string
 	}
 
+	// SlabDownloadMetric records the duration and outcome of downloading a
+	// single slab.
+	SlabDownloadMetric struct {
+		Timestamp TimeRFC3339 `json:"timestamp"`
+
+		SlabKeyHash types.Hash256 `json:"slabKeyHash"`
+		Duration    DurationMS    `json:"duration"`
+		Success     bool          `json:"success"`
+	}
+
+	SlabDownloadMetricsQueryOpts struct {
+		SlabKeyHash types.Hash256
+	}
+
 	WalletMetric struct {
 		Timestamp TimeRFC3339 `json:"timestamp"`
 
@@ -76,6 +92,40 @@ type (
 	}
 
 	WalletMetricsQueryOpts struct{}
+
+	// MetricsSummary contains the most recent value of every metric type and
+	// is the response type for the /metrics/summary endpoint.
+	MetricsSummary struct {
+		Contract      ContractMetricsSummary      `json:"contract"`
+		ContractPrune ContractPruneMetricsSummary `json:"contractPrune"`
+		Wallet        *WalletMetric               `json:"wallet,omitempty"`
+		Objects       ObjectsStatsResponse        `json:"objects"`
+	}
+
+	// ContractMetricsSummary is the sum of the most recent contract metric of
+	// every active contract with recorded metrics.
+	ContractMetricsSummary struct {
+		Timestamp TimeRFC3339 `json:"timestamp"`
+		Contracts uint64      `json:"contracts"`
+
+		RemainingCollateral types.Currency `json:"remainingCollateral"`
+		RemainingFunds      types.Currency `json:"remainingFunds"`
+
+		DeleteSpending      types.Currency `json:"deleteSpending"`
+		FundAccountSpending types.Currency `json:"fundAccountSpending"`
+		SectorRootsSpending types.Currency `json:"sectorRootsSpending"`
+		UploadSpending      types.Currency `json:"uploadSpending"`
+	}
+
+	// ContractPruneMetricsSummary is the sum of the most recent contract prune
+	// metric of every active contract with recorded prune metrics.
+	ContractPruneMetricsSummary struct {
+		Timestamp TimeRFC3339 `json:"timestamp"`
+		Contracts uint64      `json:"contracts"`
+
+		Pruned    uint64 `json:"pruned"`
+		Remaining uint64 `json:"remaining"`
+	}
 )
 
 type (
@@ -86,4 +136,16 @@ type (
 	ContractMetricRequestPUT struct {
 		Metrics []ContractMetric `json:"metrics"`
 	}
+
+	SlabDownloadMetricRequestPUT struct {
+		Metrics []SlabDownloadMetric `json:"metrics"`
+	}
 )
+
+// SlabKeyHash returns the hash under which the download metrics of the slab
+// with the given key are recorded, it prevents the key itself from ending up in
+// the metrics database.
+func SlabKeyHash(key object.EncryptionKey) types.Hash256 {
+	b, _ := key.MarshalBinary()
+	return types.HashBytes(b)
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"errors"
+	"time"
 
 	"go.sia.tech/renterd/object"
 )
@@ -54,6 +55,7 @@ type (
 		DisableClientSideEncryption bool
 		MimeType                    string
 		Metadata                    ObjectUserMetadata
+		TTL                         time.Duration
 	}
 
 	CompleteMultipartOptions struct {
@@ -95,12 +97,21 @@ type (
 		MimeType                    string             `json:"mimeType"`
 		Metadata                    ObjectUserMetadata `json:"metadata"`
 		DisableClientSideEncryption bool               `json:"disableClientSideEncryption"`
+		TTL                         DurationMS         `json:"ttl,omitempty"`
 	}
 
 	MultipartCreateResponse struct {
 		UploadID string `json:"uploadID"`
 	}
 
+	MultipartExpireRequest struct {
+		OlderThan DurationMS `json:"olderThan"`
+	}
+
+	MultipartExpireResponse struct {
+		Expired int `json:"expired"`
+	}
+
 	MultipartListPartsRequest struct {
 		Bucket           string `json:"bucket"`
 		Key              string `json:"key"`
@@ -10,12 +10,18 @@ import (
 	"path/filepath"
 	"strings"
 
+	"go.sia.tech/core/types"
 	"go.sia.tech/renterd/object"
 )
 
 const (
 	ObjectMetadataPrefix = "X-Sia-Meta-"
 
+	// ObjectMetadataSymlink is the metadata key under which the target of a
+	// symlink is stored. The key is reserved and can't be set through user
+	// metadata.
+	ObjectMetadataSymlink = "sia-symlink"
+
 	ObjectsRenameModeSingle = "single"
 	ObjectsRenameModeMulti  = "multi"
 
@@ -25,6 +31,16 @@ const (
 
 	SortDirAsc  = "asc"
 	SortDirDesc = "desc"
+
+	// MaxObjectTags is the maximum number of tags an object can have, the
+	// limits on tags match the ones enforced by S3.
+	MaxObjectTags = 10
+
+	// MaxObjectTagKeyLength is the maximum length of an object tag's key.
+	MaxObjectTagKeyLength = 128
+
+	// MaxObjectTagValueLength is the maximum length of an object tag's value.
+	MaxObjectTagValueLength = 256
 )
 
 var (
@@ -36,6 +52,11 @@ var (
 	// database.
 	ErrObjectNotFound = errors.New("object not found")
 
+	// ErrPreconditionFailed is returned when an object operation is
+	// conditional and its precondition wasn't met, e.g. the source of a copy
+	// doesn't have the expected ETag.
+	ErrPreconditionFailed = errors.New("precondition failed")
+
 	// ErrObjectCorrupted is returned if we were unable to retrieve the object
 	// from the database.
 	ErrObjectCorrupted = errors.New("object corrupted")
@@ -55,12 +76,34 @@ var (
 	// ErrUnsupportedDelimiter is returned when an unsupported delimiter is
 	// provided.
 	ErrUnsupportedDelimiter = errors.New("unsupported delimiter")
+
+	// ErrInvalidObjectTags is returned when the tags of an object exceed the
+	// limits on object tags.
+	ErrInvalidObjectTags = errors.New("invalid object tags")
+
+	// ErrMaxSymlinkDepthExceeded is returned when resolving a symlink requires
+	// following more symlinks than allowed, which is usually caused by a cycle.
+	ErrMaxSymlinkDepthExceeded = errors.New("max symlink depth exceeded")
+
+	// ErrReservedMetadataKey is returned when user metadata contains a key
+	// that is reserved for internal use.
+	ErrReservedMetadataKey = errors.New("metadata key is reserved")
+
+	// ErrSymlinkCycle is returned when creating a symlink would create a cycle.
+	ErrSymlinkCycle = errors.New("symlink would create a cycle")
 )
 
 type (
 	// Object wraps an object.Object with its metadata.
 	Object struct {
-		Metadata ObjectUserMetadata `json:"metadata,omitempty"`
+		Metadata  ObjectUserMetadata `json:"metadata,omitempty"`
+		VersionID string             `json:"versionID,omitempty"`
+
+		// ContractIDs contains the contracts that hold the object's sectors,
+		// it's only populated if requested since it requires fetching the
+		// object's slabs.
+		ContractIDs []types.FileContractID `json:"contractIDs,omitempty"`
+
 		ObjectMetadata
 		*object.Object
 	}
@@ -76,6 +119,10 @@ type (
 		MimeType string      `json:"mimeType,omitempty"`
 	}
 
+	// ObjectTags contains user-defined key-value tags of an object. Unlike
+	// user metadata, tags can be updated without re-uploading the object.
+	ObjectTags map[string]string
+
 	// ObjectUserMetadata contains user-defined metadata about an object and can
 	// be provided through `X-Sia-Meta-` meta headers.
 	//
@@ -85,6 +132,18 @@ type (
 	// well
 	ObjectUserMetadata map[string]string
 
+	// ObjectVersion describes a version of an object in a bucket with
+	// versioning enabled. A delete marker is the version created by deleting
+	// an object, it has no data.
+	ObjectVersion struct {
+		VersionID      string      `json:"versionID"`
+		ETag           string      `json:"eTag,omitempty"`
+		IsDeleteMarker bool        `json:"isDeleteMarker"`
+		IsLatest       bool        `json:"isLatest"`
+		ModTime        TimeRFC3339 `json:"modTime"`
+		Size           int64       `json:"size"`
+	}
+
 	// GetObjectResponse is the response type for the GET /worker/object endpoint.
 	GetObjectResponse struct {
 		Content io.ReadCloser `json:"content"`
@@ -106,6 +165,10 @@ type (
 		HasMore    bool             `json:"hasMore"`
 		NextMarker string           `json:"nextMarker"`
 		Objects    []ObjectMetadata `json:"objects"`
+
+		// Tags contains the tags of the listed objects by key if requested,
+		// objects without tags are omitted.
+		Tags map[string]ObjectTags `json:"tags,omitempty"`
 	}
 
 	// ObjectsRemoveRequest is the request type for the /bus/objects/remove endpoint.
@@ -114,6 +177,12 @@ type (
 		Prefix string `json:"prefix"`
 	}
 
+	// ObjectsRemoveResponse is the response type for the DELETE /bus/objects
+	// endpoint.
+	ObjectsRemoveResponse struct {
+		Removed int `json:"removed"`
+	}
+
 	// ObjectsRenameRequest is the request type for the /bus/objects/rename endpoint.
 	ObjectsRenameRequest struct {
 		Bucket string `json:"bucket"`
@@ -123,6 +192,14 @@ type (
 		Mode   string `json:"mode"`
 	}
 
+	// ObjectsSymlinkRequest is the request type for the /bus/objects/symlink
+	// endpoint.
+	ObjectsSymlinkRequest struct {
+		Bucket     string `json:"bucket"`
+		TargetPath string `json:"targetPath"`
+		LinkPath   string `json:"linkPath"`
+	}
+
 	ObjectsStatsOpts struct {
 		Bucket string
 	}
@@ -137,6 +214,14 @@ type (
 		TotalSectorsSize           uint64  `json:"totalSectorsSize"`           // uploaded size of all objects
 		TotalUploadedSize          uint64  `json:"totalUploadedSize"`          // uploaded size of all objects including redundant sectors
 	}
+
+	// HostStorageStats is the response type for the
+	// /bus/stats/objects/perhost endpoint, it contains the number of sectors
+	// and their size stored on a single host.
+	HostStorageStats struct {
+		NumSectors       uint64 `json:"numSectors"`       // number of sectors stored on the host
+		TotalSectorsSize uint64 `json:"totalSectorsSize"` // size of all sectors stored on the host
+	}
 )
 
 func ExtractObjectUserMetadataFrom(metadata map[string]string) ObjectUserMetadata {
@@ -149,6 +234,24 @@ func ExtractObjectUserMetadataFrom(metadata map[string]string) ObjectUserMetadat
 	return oum
 }
 
+// Validate returns an error if the user metadata contains a key that is
+// reserved for internal use.
+func (oum ObjectUserMetadata) Validate() error {
+	for k := range oum {
+		if strings.EqualFold(k, ObjectMetadataSymlink) {
+			return fmt.Errorf("%w: '%s'", ErrReservedMetadataKey, k)
+		}
+	}
+	return nil
+}
+
+// SymlinkTarget returns the key of the object the symlink points to, the
+// returned bool is false if the object is not a symlink.
+func (o Object) SymlinkTarget() (string, bool) {
+	target, ok := o.Metadata[ObjectMetadataSymlink]
+	return target, ok
+}
+
 // ContentType returns the object's MimeType for use in the 'Content-Type'
 // header, if the object's mime type is empty we try and deduce it from the
 // extension in the object's name.
@@ -170,6 +273,10 @@ type (
 		ETag     string
 		MimeType string
 		Metadata ObjectUserMetadata
+
+		// IfNotExists prevents an existing object from being overwritten,
+		// ErrObjectExists is returned instead.
+		IfNotExists bool
 	}
 
 	// AddObjectRequest is the request type for the /bus/object/*key endpoint.
@@ -185,6 +292,10 @@ type (
 	CopyObjectOptions struct {
 		MimeType string
 		Metadata ObjectUserMetadata
+
+		// IfSourceETagMatch, if set, causes the copy to fail with
+		// ErrPreconditionFailed unless the source object has the given ETag.
+		IfSourceETagMatch string
 	}
 
 	// CopyObjectsRequest is the request type for the /bus/objects/copy endpoint.
@@ -197,23 +308,31 @@ type (
 
 		MimeType string             `json:"mimeType"`
 		Metadata ObjectUserMetadata `json:"metadata"`
+
+		IfSourceETagMatch string `json:"ifSourceETagMatch,omitempty"`
 	}
 
 	HeadObjectOptions struct {
-		Range *DownloadRange
+		IgnoreSymlinks bool
+		Range          *DownloadRange
 	}
 
 	DownloadObjectOptions struct {
-		Range *DownloadRange
+		IgnoreSymlinks bool
+		Range          *DownloadRange
 	}
 
 	GetObjectOptions struct {
-		OnlyMetadata bool
+		OnlyMetadata     bool
+		IgnoreSymlinks   bool
+		IncludeContracts bool
+		VersionID        string
 	}
 
 	ListObjectOptions struct {
 		Bucket            string
 		Delimiter         string
+		IncludeTags       bool
 		Limit             int
 		Marker            string
 		SortBy            string
@@ -268,6 +387,12 @@ func (opts UploadMultipartUploadPartOptions) Apply(values url.Values) {
 		values.Set("totalshards", fmt.Sprint(opts.TotalShards))
 	}
 }
+func (opts DownloadObjectOptions) Apply(values url.Values) {
+	if opts.IgnoreSymlinks {
+		values.Set("followsymlinks", "false")
+	}
+}
+
 func (opts DownloadObjectOptions) ApplyHeaders(h http.Header) {
 	if opts.Range != nil {
 		if opts.Range.Length == -1 {
@@ -279,6 +404,9 @@ func (opts DownloadObjectOptions) ApplyHeaders(h http.Header) {
 }
 
 func (opts HeadObjectOptions) Apply(values url.Values) {
+	if opts.IgnoreSymlinks {
+		values.Set("followsymlinks", "false")
+	}
 }
 
 func (opts HeadObjectOptions) ApplyHeaders(h http.Header) {
@@ -295,6 +423,15 @@ func (opts GetObjectOptions) Apply(values url.Values) {
 	if opts.OnlyMetadata {
 		values.Set("onlymetadata", "true")
 	}
+	if opts.IgnoreSymlinks {
+		values.Set("followsymlinks", "false")
+	}
+	if opts.IncludeContracts {
+		values.Set("includecontracts", "true")
+	}
+	if opts.VersionID != "" {
+		values.Set("versionid", opts.VersionID)
+	}
 }
 
 func (opts ListObjectOptions) Apply(values url.Values) {
@@ -304,6 +441,9 @@ func (opts ListObjectOptions) Apply(values url.Values) {
 	if opts.Delimiter != "" {
 		values.Set("delimiter", opts.Delimiter)
 	}
+	if opts.IncludeTags {
+		values.Set("includetags", "true")
+	}
 	if opts.Limit != 0 {
 		values.Set("limit", fmt.Sprint(opts.Limit))
 	}
@@ -324,6 +464,23 @@ func (opts ListObjectOptions) Apply(values url.Values) {
 	}
 }
 
+// Validate returns an error if the tags exceed the limits on object tags.
+func (tags ObjectTags) Validate() error {
+	if len(tags) > MaxObjectTags {
+		return fmt.Errorf("%w: an object can have at most %d tags", ErrInvalidObjectTags, MaxObjectTags)
+	}
+	for k, v := range tags {
+		if k == "" {
+			return fmt.Errorf("%w: tag key can't be empty", ErrInvalidObjectTags)
+		} else if len(k) > MaxObjectTagKeyLength {
+			return fmt.Errorf("%w: tag key '%s' exceeds %d characters", ErrInvalidObjectTags, k, MaxObjectTagKeyLength)
+		} else if len(v) > MaxObjectTagValueLength {
+			return fmt.Errorf("%w: value of tag '%s' exceeds %d characters", ErrInvalidObjectTags, k, MaxObjectTagValueLength)
+		}
+	}
+	return nil
+}
+
 func FormatETag(eTag string) string {
 	return fmt.Sprintf("%q", eTag)
 }
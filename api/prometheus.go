@@ -36,6 +36,27 @@ func (c ConsensusState) PrometheusMetric() (metrics []prometheus.Metric) {
 	}
 }
 
+func (fs ForkStatus) PrometheusMetric() (metrics []prometheus.Metric) {
+	return []prometheus.Metric{
+		{
+			Name:  "renterd_consensus_fork_status_on_canonical_chain",
+			Value: boolToFloat(fs.OnCanonicalChain),
+		},
+		{
+			Name:  "renterd_consensus_fork_status_local_height",
+			Value: float64(fs.LocalHeight),
+		},
+		{
+			Name:  "renterd_consensus_fork_status_canonical_height",
+			Value: float64(fs.CanonicalHeight),
+		},
+		{
+			Name:  "renterd_consensus_fork_status_divergence_height",
+			Value: float64(fs.DivergenceHeight),
+		},
+	}
+}
+
 func (asr AutopilotStateResponse) PrometheusMetric() (metrics []prometheus.Metric) {
 	labels := map[string]any{
 		"version":    asr.Version,
@@ -84,6 +105,16 @@ func (asr AutopilotStateResponse) PrometheusMetric() (metrics []prometheus.Metri
 			Labels: labels,
 			Value:  float64(time.Time(asr.ScanningLastStart).Unix()),
 		},
+		{
+			Name:   "renterd_autopilot_state_scannerpaused",
+			Labels: labels,
+			Value:  boolToFloat(asr.ScannerPaused),
+		},
+		{
+			Name:   "renterd_autopilot_state_pricerenegotiationsinitiated",
+			Labels: labels,
+			Value:  float64(asr.PriceRenegotiationsInitiated),
+		},
 	}
 }
 
@@ -585,7 +616,24 @@ func (sr BusStateResponse) PrometheusMetric() (metrics []prometheus.Metric) {
 				"build_time": sr.BuildTime.String(),
 			},
 			Value: 1,
-		}}
+		},
+		{
+			Name:  "renterd_chain_subscriber_lag_blocks",
+			Value: float64(sr.ChainLag),
+		},
+		{
+			Name:  "renterd_slab_buffer_defragmentation_runs",
+			Value: float64(sr.SlabBufferDefragmentationRuns),
+		},
+		{
+			Name:  "renterd_slab_buffer_bytes_optimized",
+			Value: float64(sr.SlabBufferBytesOptimized),
+		},
+		{
+			Name:  "renterd_symlinks_resolved",
+			Value: float64(sr.SymlinksResolved),
+		},
+	}
 }
 
 func (os ObjectsStatsResponse) PrometheusMetric() (metrics []prometheus.Metric) {
@@ -738,8 +786,57 @@ func (m DownloadStatsResponse) PrometheusMetric() (metrics []prometheus.Metric)
 		}}
 }
 
+func (m UploadLatencyResponse) PrometheusMetric() (metrics []prometheus.Metric) {
+	for _, stage := range []struct {
+		name    string
+		latency UploadStageLatency
+	}{
+		{"slab_packing", m.SlabPacking},
+		{"read", m.Read},
+		{"encryption", m.Encryption},
+		{"erasure_coding", m.ErasureCoding},
+		{"sector_upload", m.SectorUpload},
+		{"metadata_commit", m.MetadataCommit},
+	} {
+		for _, p := range []struct {
+			quantile string
+			value    float64
+		}{
+			{"0.5", stage.latency.P50},
+			{"0.95", stage.latency.P95},
+			{"0.99", stage.latency.P99},
+		} {
+			metrics = append(metrics, prometheus.Metric{
+				Name: "renterd_worker_upload_stage_latency_ms",
+				Labels: map[string]any{
+					"stage":    stage.name,
+					"quantile": p.quantile,
+				},
+				Value: p.value,
+			})
+		}
+	}
+	return
+}
+
 func (m UploadStatsResponse) PrometheusMetric() (metrics []prometheus.Metric) {
-	return []prometheus.Metric{
+	preflightFailures := make(map[string]uint64)
+	for _, us := range m.UploadersStats {
+		for reason, n := range us.PreflightFailures {
+			preflightFailures[reason] += n
+		}
+	}
+	for reason, n := range preflightFailures {
+		metrics = append(metrics, prometheus.Metric{
+			Name: "renterd_worker_stats_preflightfailures",
+			Labels: map[string]any{
+				"reason": reason,
+			},
+			Value: float64(n),
+		})
+	}
+
+	return append(metrics, []prometheus.Metric{
 		{
 			Name:  "renterd_worker_stats_avgslabuploadspeedmbps",
 			Value: m.AvgSlabUploadSpeedMBPS,
@@ -755,7 +852,7 @@ func (m UploadStatsResponse) PrometheusMetric() (metrics []prometheus.Metric) {
 		{
 			Name:  "renterd_worker_stats_numuploaders",
 			Value: float64(m.NumUploaders),
-		}}
+		}}...)
 }
 
 // AllowListResp represents multiple `typex.PublicKey`s.  Its prometheus
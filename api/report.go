@@ -0,0 +1,129 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.sia.tech/core/types"
+)
+
+// DefaultHealthReportPeriod is the period covered by a health report if no
+// period is specified.
+const DefaultHealthReportPeriod = ReportPeriod(7 * 24 * time.Hour)
+
+// ErrInvalidReportPeriod is returned when the period of a report is not
+// positive.
+var ErrInvalidReportPeriod = errors.New("report period must be positive")
+
+type (
+	// ReportPeriod is a duration that can be expressed in days, e.g. "7d", on
+	// top of the units supported by time.ParseDuration.
+	ReportPeriod time.Duration
+
+	// HealthReport summarises the health of the node over a period of time.
+	HealthReport struct {
+		GeneratedAt TimeRFC3339 `json:"generatedAt"`
+		Start       TimeRFC3339 `json:"start"`
+		Period      DurationMS  `json:"period"`
+
+		Storage   HealthReportStorage   `json:"storage"`
+		Contracts HealthReportContracts `json:"contracts"`
+		Hosts     HealthReportHosts     `json:"hosts"`
+		Wallet    HealthReportWallet    `json:"wallet"`
+		Incidents HealthReportIncidents `json:"incidents"`
+	}
+
+	// HealthReportStorage contains the stored data at the time the report
+	// was generated.
+	HealthReportStorage struct {
+		NumObjects        uint64  `json:"numObjects"`
+		MinHealth         float64 `json:"minHealth"`
+		TotalObjectsSize  uint64  `json:"totalObjectsSize"`
+		TotalSectorsSize  uint64  `json:"totalSectorsSize"`
+		TotalUploadedSize uint64  `json:"totalUploadedSize"`
+	}
+
+	// HealthReportContracts contains the active contracts and the amount
+	// spent on them.
+	HealthReportContracts struct {
+		Active         uint64         `json:"active"`
+		Good           uint64         `json:"good"`
+		TotalSize      uint64         `json:"totalSize"`
+		Prunable       uint64         `json:"prunable"`
+		UploadSpending types.Currency `json:"uploadSpending"`
+		TotalSpending  types.Currency `json:"totalSpending"`
+	}
+
+	// HealthReportHosts contains the number of hosts we have contracts with,
+	// the number of usable hosts and the hosts we spent the most on.
+	HealthReportHosts struct {
+		Used     uint64                  `json:"used"`
+		Usable   uint64                  `json:"usable"`
+		TopHosts []HealthReportHostSpend `json:"topHosts"`
+	}
+
+	// HealthReportHostSpend contains the amount spent on the active contracts
+	// with a host within the report's period.
+	HealthReportHostSpend struct {
+		HostKey  types.PublicKey `json:"hostKey"`
+		Spending types.Currency  `json:"spending"`
+	}
+
+	// HealthReportWallet contains the confirmed wallet balance at the start of
+	// the report's period and at the time the report was generated.
+	HealthReportWallet struct {
+		StartBalance   types.Currency `json:"startBalance"`
+		CurrentBalance types.Currency `json:"currentBalance"`
+	}
+
+	// HealthReportIncidents contains the number of alerts per severity that
+	// were active within the report's period, including alerts that were
+	// dismissed since.
+	HealthReportIncidents struct {
+		Info     int `json:"info"`
+		Warning  int `json:"warning"`
+		Error    int `json:"error"`
+		Critical int `json:"critical"`
+	}
+)
+
+// String implements fmt.Stringer.
+func (p ReportPeriod) String() string {
+	d := time.Duration(p)
+	if d > 0 && d%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	}
+	return d.String()
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (p ReportPeriod) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (p *ReportPeriod) UnmarshalText(b []byte) error {
+	s := string(b)
+	var d time.Duration
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.ParseUint(days, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid period %q: %w", s, err)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		d, err = time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("invalid period %q: %w", s, err)
+		}
+	}
+	if d <= 0 {
+		return ErrInvalidReportPeriod
+	}
+	*p = ReportPeriod(d)
+	return nil
+}
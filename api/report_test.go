@@ -0,0 +1,46 @@
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReportPeriod(t *testing.T) {
+	tests := []struct {
+		input  string
+		period time.Duration
+		str    string
+		err    bool
+	}{
+		{"7d", 7 * 24 * time.Hour, "7d", false},
+		{"1d", 24 * time.Hour, "1d", false},
+		{"12h", 12 * time.Hour, "12h0m0s", false},
+		{"48h", 48 * time.Hour, "2d", false},
+		{"0d", 0, "", true},
+		{"-1h", 0, "", true},
+		{"d", 0, "", true},
+		{"foo", 0, "", true},
+	}
+	for _, test := range tests {
+		var p ReportPeriod
+		err := p.UnmarshalText([]byte(test.input))
+		if test.err {
+			if err == nil {
+				t.Fatalf("%q: expected error", test.input)
+			}
+			continue
+		} else if err != nil {
+			t.Fatalf("%q: unexpected error: %v", test.input, err)
+		} else if time.Duration(p) != test.period {
+			t.Fatalf("%q: expected %v, got %v", test.input, test.period, time.Duration(p))
+		} else if p.String() != test.str {
+			t.Fatalf("%q: expected %q, got %q", test.input, test.str, p.String())
+		}
+	}
+
+	var p ReportPeriod
+	if err := p.UnmarshalText([]byte("0d")); !errors.Is(err, ErrInvalidReportPeriod) {
+		t.Fatalf("expected ErrInvalidReportPeriod, got %v", err)
+	}
+}
@@ -3,10 +3,12 @@ package api
 import (
 	"errors"
 	"fmt"
+	"math"
 	"time"
 
 	rhpv2 "go.sia.tech/core/rhp/v2"
 	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/object"
 )
 
 const (
@@ -115,6 +117,15 @@ type (
 		MinMaxEphemeralAccountBalance types.Currency `json:"minMaxEphemeralAccountBalance"`
 	}
 
+	// GougingValidationResult is the response type for the
+	// /settings/gouging/validate endpoint, it splits the scanned hosts into
+	// hosts that pass and hosts that fail the gouging checks of the validated
+	// settings.
+	GougingValidationResult struct {
+		PassingHosts []types.PublicKey `json:"passingHosts"`
+		FailingHosts []types.PublicKey `json:"failingHosts"`
+	}
+
 	// PinnedSettings holds the configuration for pinning certain settings to a
 	// specific currency (e.g., USD). It uses the configured explorer to fetch
 	// the current exchange rate, allowing users to set prices in USD instead of
@@ -134,13 +145,34 @@ type (
 
 	// UploadSettings contains various settings related to uploads.
 	UploadSettings struct {
-		Packing    UploadPackingSettings `json:"packing"`
-		Redundancy RedundancySettings    `json:"redundancy"`
+		Packing     UploadPackingSettings `json:"packing"`
+		Redundancy  RedundancySettings    `json:"redundancy"`
+		RetryPolicy UploadRetryPolicy     `json:"retryPolicy"`
+
+		// ChecksumAlgorithm is an optional algorithm used to checksum the
+		// shards of newly uploaded slabs, see object.ChecksumAlgorithmBLAKE3.
+		// By default shards are only verified against their Merkle roots.
+		ChecksumAlgorithm string `json:"checksumAlgorithm,omitempty"`
 	}
 
 	UploadPackingSettings struct {
 		Enabled               bool  `json:"enabled"`
 		SlabBufferMaxSizeSoft int64 `json:"slabBufferMaxSizeSoft"`
+
+		// FlushAfter is the age after which incomplete slab buffers are
+		// flushed, making them available for upload even though they are not
+		// full, zero disables flushing.
+		FlushAfter DurationMS `json:"flushAfter"`
+	}
+
+	// UploadRetryPolicy determines how often and how fast the upload of a
+	// shard is retried after it failed. A MaxAttempts of zero means a shard
+	// is retried on every available host and an InitialBackoff of zero means
+	// it is retried immediately.
+	UploadRetryPolicy struct {
+		MaxAttempts    int        `json:"maxAttempts"`
+		InitialBackoff DurationMS `json:"initialBackoff"`
+		MaxBackoff     DurationMS `json:"maxBackoff"`
 	}
 
 	RedundancySettings struct {
@@ -223,10 +255,42 @@ func (gs GougingSettings) Validate() error {
 func (us UploadSettings) Validate() error {
 	if us.Packing.Enabled && us.Packing.SlabBufferMaxSizeSoft <= 0 {
 		return errors.New("SlabBufferMaxSizeSoft must be greater than zero when upload packing is enabled")
+	} else if us.Packing.FlushAfter < 0 {
+		return errors.New("FlushAfter can't be negative")
+	} else if err := us.RetryPolicy.Validate(); err != nil {
+		return err
+	} else if err := object.ValidateChecksumAlgorithm(us.ChecksumAlgorithm); err != nil {
+		return err
 	}
 	return us.Redundancy.Validate()
 }
 
+// Backoff returns the time to wait before retrying the upload of a shard that
+// failed the given number of times. The backoff doubles with every failure
+// and is capped at MaxBackoff if set.
+func (rp UploadRetryPolicy) Backoff(failures int) time.Duration {
+	backoff := time.Duration(rp.InitialBackoff)
+	for i := 1; i < failures && backoff < math.MaxInt64/2; i++ {
+		backoff *= 2
+	}
+	if rp.MaxBackoff > 0 && backoff > time.Duration(rp.MaxBackoff) {
+		backoff = time.Duration(rp.MaxBackoff)
+	}
+	return backoff
+}
+
+// Validate returns an error if the retry policy is not considered valid.
+func (rp UploadRetryPolicy) Validate() error {
+	if rp.MaxAttempts < 0 {
+		return errors.New("MaxAttempts can't be negative")
+	} else if rp.InitialBackoff < 0 || rp.MaxBackoff < 0 {
+		return errors.New("InitialBackoff and MaxBackoff can't be negative")
+	} else if rp.MaxBackoff > 0 && rp.InitialBackoff > rp.MaxBackoff {
+		return errors.New("InitialBackoff can't be greater than MaxBackoff")
+	}
+	return nil
+}
+
 // Redundancy returns the effective storage redundancy of the
 // RedundancySettings.
 func (rs RedundancySettings) Redundancy() float64 {
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUploadRetryPolicyBackoff(t *testing.T) {
+	rp := UploadRetryPolicy{
+		InitialBackoff: DurationMS(100 * time.Millisecond),
+		MaxBackoff:     DurationMS(time.Second),
+	}
+	tests := []struct {
+		failures int
+		backoff  time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{100, time.Second},
+	}
+	for _, test := range tests {
+		if backoff := rp.Backoff(test.failures); backoff != test.backoff {
+			t.Fatalf("expected backoff %v after %d failures, got %v", test.backoff, test.failures, backoff)
+		}
+	}
+
+	// without a max backoff the backoff shouldn't overflow
+	rp.MaxBackoff = 0
+	if backoff := rp.Backoff(100); backoff <= 0 {
+		t.Fatal("unexpected backoff", backoff)
+	}
+
+	// without an initial backoff uploads are retried immediately
+	rp.InitialBackoff = 0
+	if backoff := rp.Backoff(10); backoff != 0 {
+		t.Fatal("unexpected backoff", backoff)
+	}
+}
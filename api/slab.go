@@ -1,10 +1,23 @@
 package api
 
 import (
+	"time"
+
 	"go.sia.tech/core/types"
 	"go.sia.tech/renterd/object"
 )
 
+const (
+	// WebhookModuleSlabBuffer is the webhook module of slab buffer related
+	// events.
+	WebhookModuleSlabBuffer = "slabbuffer"
+
+	// WebhookEventSlabBufferFlush is broadcast when incomplete slab buffers
+	// were flushed because they are older than the upload packing settings'
+	// FlushAfter, its payload is an EventSlabBufferFlush.
+	WebhookEventSlabBufferFlush = "flush"
+)
+
 type (
 	PackedSlab struct {
 		BufferID      uint                 `json:"bufferID"`
@@ -12,6 +25,16 @@ type (
 		EncryptionKey object.EncryptionKey `json:"encryptionKey"`
 	}
 
+	// EventSlabBufferFlush is the payload of the slab buffer flush webhook
+	// event, it contains the number of buffers of a redundancy configuration
+	// that were flushed and are ready to be uploaded.
+	EventSlabBufferFlush struct {
+		MinShards   uint8     `json:"minShards"`
+		TotalShards uint8     `json:"totalShards"`
+		Buffers     int       `json:"buffers"`
+		Timestamp   time.Time `json:"timestamp"`
+	}
+
 	SlabBuffer struct {
 		Complete bool   `json:"complete"` // whether the slab buffer is complete and ready to upload
 		Filename string `json:"filename"` // name of the buffer on disk
@@ -23,11 +46,18 @@ type (
 	UnhealthySlab struct {
 		EncryptionKey object.EncryptionKey `json:"encryptionKey"`
 		Health        float64              `json:"health"`
+
+		// Priority is the unix timestamp at which an object referencing the
+		// slab was last accessed, slabs with a higher priority are migrated
+		// first.
+		Priority float64 `json:"priority"`
 	}
 
 	UploadedPackedSlab struct {
-		BufferID uint
-		Shards   []UploadedSector
+		BufferID          uint
+		Shards            []UploadedSector
+		ChecksumAlgorithm string
+		Checksums         []types.Hash256
 	}
 
 	UploadedSector struct {
@@ -46,6 +76,10 @@ type (
 	MigrationSlabsRequest struct {
 		HealthCutoff float64 `json:"healthCutoff"`
 		Limit        int     `json:"limit"`
+
+		// MaxLastFailure causes slabs whose migration failed within the
+		// given duration to be skipped, zero disables the filter.
+		MaxLastFailure DurationMS `json:"maxLastFailure"`
 	}
 
 	PackedSlabsRequestGET struct {
@@ -9,6 +9,15 @@ import (
 	"go.sia.tech/coreutils/wallet"
 )
 
+const (
+	// WebhookModuleWallet is the webhook module of wallet related events.
+	WebhookModuleWallet = "wallet"
+
+	// WebhookEventWalletEvent is broadcast for every wallet event that is
+	// applied to the wallet, its payload is the wallet.Event.
+	WebhookEventWalletEvent = "event"
+)
+
 type (
 	// A SiacoinElement is a SiacoinOutput along with its ID.
 	SiacoinElement struct {
@@ -30,6 +39,26 @@ type (
 )
 
 type (
+	// WalletEventsOpts contains the options for fetching wallet events. Zero
+	// values disable the corresponding filter.
+	WalletEventsOpts struct {
+		Offset int
+		Limit  int
+
+		// Since and Until limit the events to the ones with a timestamp in
+		// the range [Since, Until).
+		Since time.Time
+		Until time.Time
+
+		// MinAmount limits the events to the ones with an inflow or outflow
+		// of at least MinAmount.
+		MinAmount types.Currency
+
+		// Type limits the events to the ones of the given type, see the
+		// wallet.EventType constants.
+		Type string
+	}
+
 	// WalletFundRequest is the request type for the /wallet/fund endpoint.
 	WalletFundRequest struct {
 		Transaction        types.Transaction `json:"transaction"`
@@ -51,6 +80,20 @@ type (
 		Outputs int            `json:"outputs"`
 	}
 
+	// WalletSweepDustRequest is the request type for the /wallet/sweepdust
+	// endpoint.
+	WalletSweepDustRequest struct {
+		Threshold types.Currency `json:"threshold"`
+	}
+
+	// WalletSweepDustResponse is the response type for the /wallet/sweepdust
+	// endpoint. If sweeping failed after some transactions were submitted,
+	// Error is set alongside their IDs.
+	WalletSweepDustResponse struct {
+		TransactionIDs []types.TransactionID `json:"transactionIDs"`
+		Error          string                `json:"error,omitempty"`
+	}
+
 	// WalletResponse is the response type for the /wallet endpoint.
 	WalletResponse struct {
 		wallet.Balance
@@ -88,3 +131,27 @@ func WalletTransactionsWithOffset(offset int) WalletTransactionsOption {
 		q.Set("offset", fmt.Sprint(offset))
 	}
 }
+
+func WalletTransactionsWithSince(since time.Time) WalletTransactionsOption {
+	return func(q url.Values) {
+		q.Set("since", since.Format(time.RFC3339))
+	}
+}
+
+func WalletTransactionsWithUntil(until time.Time) WalletTransactionsOption {
+	return func(q url.Values) {
+		q.Set("until", until.Format(time.RFC3339))
+	}
+}
+
+func WalletTransactionsWithMinAmount(minAmount types.Currency) WalletTransactionsOption {
+	return func(q url.Values) {
+		q.Set("minAmount", minAmount.ExactString())
+	}
+}
+
+func WalletTransactionsWithType(txnType string) WalletTransactionsOption {
+	return func(q url.Values) {
+		q.Set("txnType", txnType)
+	}
+}
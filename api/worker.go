@@ -55,11 +55,49 @@ type (
 		LockID uint64 `json:"lockID"`
 	}
 
+	// HostBenchmarkResult is the response type for the
+	// /host/:hostkey/benchmark endpoint. It contains the upload and download
+	// throughput of a single sector probe and the time it took for the first
+	// byte of the sector to arrive.
+	HostBenchmarkResult struct {
+		UploadMBps   float64    `json:"uploadMBps"`
+		DownloadMBps float64    `json:"downloadMBps"`
+		TTFB         DurationMS `json:"ttfb"`
+	}
+
+	// HostBandwidthLimits contains the bandwidth limits for connections with
+	// a host in bytes per second, a limit of 0 means unlimited. It's the
+	// request type for the /hosts/:hostkey/bandwidth endpoint.
+	HostBandwidthLimits struct {
+		Upload   uint64 `json:"upload"`
+		Download uint64 `json:"download"`
+	}
+
+	// DialerCacheStats contains the stats of the worker dialer's cache of
+	// resolved host IPs.
+	DialerCacheStats struct {
+		Entries   int        `json:"entries"`
+		Hits      uint64     `json:"hits"`
+		Misses    uint64     `json:"misses"`
+		Evictions uint64     `json:"evictions"`
+		TTL       DurationMS `json:"ttl"`
+	}
+
 	MemoryResponse struct {
 		Download memory.Status `json:"download"`
 		Upload   memory.Status `json:"upload"`
 	}
 
+	// SectorRepairStatus is the response type for the /worker/repair/status
+	// endpoint, it contains the progress of the worker's sector verification.
+	SectorRepairStatus struct {
+		Enabled          bool        `json:"enabled"`
+		LastVerification TimeRFC3339 `json:"lastVerification"`
+		SectorsVerified  uint64      `json:"sectorsVerified"`  // sectors that passed verification
+		SectorsCorrupted uint64      `json:"sectorsCorrupted"` // sectors that were lost or corrupted
+		SectorsFailed    uint64      `json:"sectorsFailed"`    // sectors that couldn't be downloaded
+	}
+
 	// RHPFormResponse is the response type for the /rhp/form endpoint.
 	RHPFormResponse struct {
 		ContractID     types.FileContractID   `json:"contractID"`
@@ -113,8 +151,9 @@ type (
 		UploadersStats         []UploaderStats `json:"uploadersStats"`
 	}
 	UploaderStats struct {
-		HostKey                  types.PublicKey `json:"hostKey"`
-		AvgSectorUploadSpeedMBPS float64         `json:"avgSectorUploadSpeedMbps"`
+		HostKey                  types.PublicKey   `json:"hostKey"`
+		AvgSectorUploadSpeedMBPS float64           `json:"avgSectorUploadSpeedMbps"`
+		PreflightFailures        map[string]uint64 `json:"preflightFailures,omitempty"`
 	}
 
 	// WorkerStateResponse is the response type for the /worker/state endpoint.
@@ -126,10 +165,54 @@ type (
 
 	UploadObjectResponse struct {
 		ETag string `json:"etag"`
+
+		// Telemetry is only set when uploading through the worker directly,
+		// the HTTP endpoint only returns the ETag header.
+		Telemetry UploadStageTelemetry `json:"-"`
 	}
 
 	UploadMultipartUploadPartResponse struct {
 		ETag string `json:"etag"`
+
+		// Telemetry is only set when uploading through the worker directly,
+		// the HTTP endpoint only returns the ETag header.
+		Telemetry UploadStageTelemetry `json:"-"`
+	}
+
+	// UploadStageTelemetry contains the time spent in each stage of the upload
+	// pipeline. ReadMs is the time spent reading the data from the request
+	// body, which depends on the client rather than the worker. Slabs are
+	// erasure coded and uploaded concurrently, the time spent in those stages
+	// is the cumulative time across all slabs.
+	// SectorUploadRetries is the number of times the upload of a shard was
+	// retried after it failed.
+	UploadStageTelemetry struct {
+		SlabPackingMs       int64  `json:"slabPackingMs"`
+		ReadMs              int64  `json:"readMs"`
+		EncryptionMs        int64  `json:"encryptionMs"`
+		ErasureCodingMs     int64  `json:"erasureCodingMs"`
+		SectorUploadMs      int64  `json:"sectorUploadMs"`
+		MetadataCommitMs    int64  `json:"metadataCommitMs"`
+		SectorUploadRetries uint64 `json:"sectorUploadRetries"`
+	}
+
+	// UploadLatencyResponse is the response type for the /upload/latency
+	// endpoint.
+	UploadLatencyResponse struct {
+		SlabPacking    UploadStageLatency `json:"slabPacking"`
+		Read           UploadStageLatency `json:"read"`
+		Encryption     UploadStageLatency `json:"encryption"`
+		ErasureCoding  UploadStageLatency `json:"erasureCoding"`
+		SectorUpload   UploadStageLatency `json:"sectorUpload"`
+		MetadataCommit UploadStageLatency `json:"metadataCommit"`
+	}
+
+	// UploadStageLatency contains the latency percentiles of an upload stage
+	// in milliseconds.
+	UploadStageLatency struct {
+		P50 float64 `json:"p50"`
+		P95 float64 `json:"p95"`
+		P99 float64 `json:"p99"`
 	}
 )
 
@@ -7,11 +7,13 @@ import (
 
 	"go.sia.tech/core/types"
 	"go.sia.tech/renterd/alerts"
+	"go.sia.tech/renterd/api"
 )
 
 var (
-	alertLowBalanceID = alerts.RandomAlertID() // constant until restarted
-	alertPruningID    = alerts.RandomAlertID() // constant until restarted
+	alertContractExpiringID = alerts.RandomAlertID() // constant until restarted
+	alertLowBalanceID       = alerts.RandomAlertID() // constant until restarted
+	alertPruningID          = alerts.RandomAlertID() // constant until restarted
 )
 
 func (ap *Autopilot) RegisterAlert(ctx context.Context, a alerts.Alert) {
@@ -41,6 +43,22 @@ func newAccountLowBalanceAlert(address types.Address, balance, initialFunding ty
 	}
 }
 
+func newContractExpiringAlert(c api.ContractMetadata) alerts.Alert {
+	return alerts.Alert{
+		ID:       alerts.IDForContract(alertContractExpiringID, c.ID),
+		Severity: alerts.SeverityWarning,
+		Message:  "Contract is about to expire",
+		Data: map[string]interface{}{
+			"contractID":        c.ID.String(),
+			"hostKey":           c.HostKey.String(),
+			"windowEnd":         c.WindowEnd,
+			"blocksUntilExpiry": c.BlocksUntilExpiry,
+			"hint":              "The contract is about to expire without having been renewed. Once it expires, the data stored on it is lost unless it is migrated to other hosts. Check the autopilot's logs for renewal failures.",
+		},
+		Timestamp: time.Now(),
+	}
+}
+
 func newContractPruningFailedAlert(hk types.PublicKey, version, release string, fcid types.FileContractID, err error) alerts.Alert {
 	return alerts.Alert{
 		ID:       alerts.IDForContract(alertPruningID, fcid),
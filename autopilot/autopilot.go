@@ -50,6 +50,7 @@ type Bus interface {
 	BroadcastContract(ctx context.Context, fcid types.FileContractID) (types.TransactionID, error)
 	Contract(ctx context.Context, id types.FileContractID) (api.ContractMetadata, error)
 	Contracts(ctx context.Context, opts api.ContractsOpts) (contracts []api.ContractMetadata, err error)
+	ContractsExpiring(ctx context.Context, blocks uint64) (contracts []api.ContractMetadata, err error)
 	FileContractTax(ctx context.Context, payout types.Currency) (types.Currency, error)
 	FormContract(ctx context.Context, renterAddress types.Address, renterFunds types.Currency, hostKey types.PublicKey, hostCollateral types.Currency, endHeight uint64) (api.ContractMetadata, error)
 	ContractRevision(ctx context.Context, fcid types.FileContractID) (api.Revision, error)
@@ -62,12 +63,14 @@ type Bus interface {
 
 	// hostdb
 	Host(ctx context.Context, hostKey types.PublicKey) (api.Host, error)
+	HostPriceHistory(ctx context.Context, since time.Time) (map[types.PublicKey][]api.HostPriceChange, error)
 	Hosts(ctx context.Context, opts api.HostOptions) ([]api.Host, error)
 	RemoveOfflineHosts(ctx context.Context, maxConsecutiveScanFailures uint64, maxDowntime time.Duration) (uint64, error)
-	UpdateHostCheck(ctx context.Context, hostKey types.PublicKey, hostCheck api.HostChecks) error
+	UpdateHostChecks(ctx context.Context, checks map[types.PublicKey]api.HostChecks) error
 
 	// metrics
 	RecordContractPruneMetric(ctx context.Context, metrics ...api.ContractPruneMetric) error
+	RecordSlabDownloadMetric(ctx context.Context, metrics ...api.SlabDownloadMetric) error
 
 	// buckets
 	ListBuckets(ctx context.Context) ([]api.Bucket, error)
@@ -93,9 +96,11 @@ type Bus interface {
 	Objects(ctx context.Context, prefix string, opts api.ListObjectOptions) (resp api.ObjectsResponse, err error)
 	RefreshHealth(ctx context.Context) error
 	Slab(ctx context.Context, key object.EncryptionKey) (object.Slab, error)
-	SlabsForMigration(ctx context.Context, healthCutoff float64, limit int) ([]api.UnhealthySlab, error)
+	RecordSlabFailure(ctx context.Context, key object.EncryptionKey) error
+	SlabsForMigration(ctx context.Context, healthCutoff float64, maxLastFailure time.Duration, limit int) ([]api.UnhealthySlab, error)
 	DeleteHostSector(ctx context.Context, hk types.PublicKey, root types.Hash256) error
 	FetchPartialSlab(ctx context.Context, key object.EncryptionKey, offset, length uint32) ([]byte, error)
+	FindAlternativeContracts(ctx context.Context, root types.Hash256) ([]types.FileContractID, error)
 
 	// scanner
 	ScanHost(ctx context.Context, hostKey types.PublicKey, timeout time.Duration) (resp api.HostScanResponse, err error)
@@ -127,8 +132,11 @@ type Autopilot struct {
 	m migrator.Migrator
 	s scanner.Scanner
 
-	tickerDuration time.Duration
-	wg             sync.WaitGroup
+	geoIP *contractor.GeoIPDB
+
+	contractExpiryAlertThreshold uint64
+	tickerDuration               time.Duration
+	wg                           sync.WaitGroup
 
 	startStopMu       sync.Mutex
 	startTime         time.Time
@@ -141,6 +149,7 @@ type Autopilot struct {
 	pruning          bool
 	pruningLastStart time.Time
 	pruningAlertIDs  map[types.FileContractID]types.Hash256
+	expiryAlertIDs   map[types.FileContractID]types.Hash256
 
 	maintenanceTxnIDs []types.TransactionID
 }
@@ -158,9 +167,11 @@ func New(cfg config.Autopilot, masterKey utils.MasterKey, bus Bus, logger *zap.L
 		shutdownCtx:       ctx,
 		shutdownCtxCancel: cancel,
 
-		tickerDuration: cfg.Heartbeat,
+		contractExpiryAlertThreshold: cfg.ContractExpiryAlertThreshold,
+		tickerDuration:               cfg.Heartbeat,
 
 		pruningAlertIDs: make(map[types.FileContractID]types.Hash256),
+		expiryAlertIDs:  make(map[types.FileContractID]types.Hash256),
 	}
 
 	// create scanner
@@ -169,11 +180,24 @@ func New(cfg config.Autopilot, masterKey utils.MasterKey, bus Bus, logger *zap.L
 		return
 	}
 
+	// open the GeoIP database, geo-scoring is disabled if no path is set
+	var geo contractor.GeoLocator
+	if cfg.GeoIPDatabasePath != "" {
+		if cfg.MaxFractionPerCountry <= 0 || cfg.MaxFractionPerCountry > 1 {
+			return nil, fmt.Errorf("max fraction per country must be in (0, 1], got %v", cfg.MaxFractionPerCountry)
+		}
+		ap.geoIP, err = contractor.OpenGeoIPDB(cfg.GeoIPDatabasePath)
+		if err != nil {
+			return nil, err
+		}
+		geo = ap.geoIP
+	}
+
 	// create contractor
-	ap.c = contractor.New(bus, bus, cfg.RevisionSubmissionBuffer, cfg.RevisionBroadcastInterval, cfg.AllowRedundantHostIPs, logger)
+	ap.c = contractor.New(bus, bus, cfg.RevisionSubmissionBuffer, cfg.RevisionBroadcastInterval, cfg.AllowRedundantHostIPs, geo, cfg.MaxFractionPerCountry, logger)
 
 	// create migrator
-	ap.m, err = migrator.New(ctx, masterKey, ap.alerts, bus, bus, cfg.MigratorHealthCutoff, cfg.MigratorNumThreads, cfg.MigratorDownloadMaxOverdrive, cfg.MigratorUploadMaxOverdrive, cfg.MigratorDownloadOverdriveTimeout, cfg.MigratorUploadOverdriveTimeout, cfg.MigratorAccountsRefillInterval, logger)
+	ap.m, err = migrator.New(ctx, masterKey, ap.alerts, bus, bus, cfg.MigratorHealthCutoff, cfg.MigratorFailureCooldown, cfg.MigratorMaxAttempts, cfg.MigratorNumThreads, cfg.MigratorDownloadMaxOverdrive, cfg.MigratorUploadMaxOverdrive, cfg.MigratorDownloadOverdriveTimeout, cfg.MigratorUploadOverdriveTimeout, cfg.MigratorAccountsRefillInterval, logger)
 	if err != nil {
 		return nil, err
 	}
@@ -184,9 +208,13 @@ func New(cfg config.Autopilot, masterKey utils.MasterKey, bus Bus, logger *zap.L
 // Handler returns an HTTP handler that serves the autopilot api.
 func (ap *Autopilot) Handler() http.Handler {
 	return jape.Mux(map[string]jape.Handler{
-		"POST   /config/evaluate": ap.configEvaluateHandlerPOST,
-		"GET    /state":           ap.stateHandlerGET,
-		"POST   /trigger":         ap.triggerHandlerPOST,
+		"POST   /config/evaluate":               ap.configEvaluateHandlerPOST,
+		"GET    /contract-count-recommendation": ap.contractCountRecommendationHandlerGET,
+		"GET    /network-diversity":             ap.networkDiversityHandlerGET,
+		"POST   /scanner/pause":                 ap.scannerPauseHandlerPOST,
+		"POST   /scanner/resume":                ap.scannerResumeHandlerPOST,
+		"GET    /state":                         ap.stateHandlerGET,
+		"POST   /trigger":                       ap.triggerHandlerPOST,
 	})
 }
 
@@ -223,9 +251,65 @@ func (ap *Autopilot) configEvaluateHandlerPOST(jc jape.Context) {
 		jc.Error(err, http.StatusInternalServerError)
 		return
 	}
+
+	// recommend a contract count for the config
+	cc, err := ap.contractCountRecommendation(ctx, reqCfg.Contracts, rs)
+	if jc.Check("failed to compute contract count recommendation", err) != nil {
+		return
+	}
+	res.ContractCount = &cc
 	jc.Encode(res)
 }
 
+func (ap *Autopilot) contractCountRecommendationHandlerGET(jc jape.Context) {
+	ctx := jc.Request.Context()
+
+	// fetch config
+	cfg, err := ap.bus.AutopilotConfig(ctx)
+	if jc.Check("failed to fetch autopilot config", err) != nil {
+		return
+	}
+
+	// fetch upload settings
+	us, err := ap.bus.UploadSettings(ctx)
+	if jc.Check("failed to fetch upload settings", err) != nil {
+		return
+	}
+
+	cc, err := ap.contractCountRecommendation(ctx, cfg.Contracts, us.Redundancy)
+	if jc.Check("failed to compute contract count recommendation", err) != nil {
+		return
+	}
+	jc.Encode(cc)
+}
+
+func (ap *Autopilot) networkDiversityHandlerGET(jc jape.Context) {
+	ctx := jc.Request.Context()
+
+	// fetch active contracts
+	contracts, err := ap.bus.Contracts(ctx, api.ContractsOpts{FilterMode: api.ContractFilterModeActive})
+	if jc.Check("failed to fetch contracts", err) != nil {
+		return
+	}
+	used := make(map[types.PublicKey]struct{})
+	for _, c := range contracts {
+		used[c.HostKey] = struct{}{}
+	}
+
+	// fetch hosts
+	hosts, err := ap.bus.Hosts(ctx, api.HostOptions{})
+	if jc.Check("failed to fetch hosts", err) != nil {
+		return
+	}
+	var contracted []api.Host
+	for _, h := range hosts {
+		if _, ok := used[h.PublicKey]; ok {
+			contracted = append(contracted, h)
+		}
+	}
+	jc.Encode(contractor.NetworkDiversity(ctx, contracted))
+}
+
 func (ap *Autopilot) Run() {
 	ap.startStopMu.Lock()
 	if ap.isRunning() {
@@ -281,10 +365,16 @@ func (ap *Autopilot) Shutdown(ctx context.Context) error {
 		ap.shutdownCtxCancel()
 		close(ap.triggerChan)
 		ap.wg.Wait()
-		ap.m.Stop()
+		ap.m.Stop(ctx)
 		ap.s.Shutdown(ctx)
 		ap.startTime = time.Time{}
 	}
+	if ap.geoIP != nil {
+		if err := ap.geoIP.Close(); err != nil {
+			return fmt.Errorf("failed to close GeoIP database: %w", err)
+		}
+		ap.geoIP = nil
+	}
 	return nil
 }
 
@@ -477,6 +567,9 @@ func (ap *Autopilot) performMaintenance(forceScan bool, tickerFired chan struct{
 	}
 	maintenanceSuccess := err == nil
 
+	// alert about contracts that are about to expire
+	ap.checkExpiringContracts()
+
 	// upon success, notify the migrator. The health of slabs might have
 	// changed.
 	if maintenanceSuccess && setChanged {
@@ -634,6 +727,14 @@ func (ap *Autopilot) triggerHandlerPOST(jc jape.Context) {
 	})
 }
 
+func (ap *Autopilot) scannerPauseHandlerPOST(jc jape.Context) {
+	ap.s.Pause()
+}
+
+func (ap *Autopilot) scannerResumeHandlerPOST(jc jape.Context) {
+	ap.s.Resume()
+}
+
 func (ap *Autopilot) stateHandlerGET(jc jape.Context) {
 	ap.mu.Lock()
 	pruning, pLastStart := ap.pruning, ap.pruningLastStart // TODO: move to a 'pruner' type
@@ -655,8 +756,12 @@ func (ap *Autopilot) stateHandlerGET(jc jape.Context) {
 		PruningLastStart:   api.TimeRFC3339(pLastStart),
 		Scanning:           scanning,
 		ScanningLastStart:  api.TimeRFC3339(sLastStart),
+		ScannerPaused:      ap.s.Paused(),
+		NextScanAt:         api.TimeRFC3339(ap.s.NextScan()),
 		UptimeMS:           api.DurationMS(ap.Uptime()),
 
+		PriceRenegotiationsInitiated: ap.c.PriceRenegotiationsInitiated(),
+
 		StartTime: api.TimeRFC3339(ap.StartTime()),
 		BuildState: api.BuildState{
 			Version:   build.Version(),
@@ -707,6 +812,17 @@ func (ap *Autopilot) buildState(ctx context.Context) (*contractor.MaintenanceSta
 	}
 	address := wi.Address
 
+	// adjust the amount of contracts to the recommended amount
+	if apCfg.Contracts.AutoSizeContractSet {
+		cc, err := ap.contractCountRecommendation(ctx, apCfg.Contracts, us.Redundancy)
+		if err != nil {
+			return nil, fmt.Errorf("could not compute contract count recommendation, err: %v", err)
+		} else if cc.RecommendedAmount > 0 && cc.RecommendedAmount != apCfg.Contracts.Amount {
+			ap.logger.Infow("adjusting amount of contracts to the recommended amount", "amount", apCfg.Contracts.Amount, "recommended", cc.RecommendedAmount)
+			apCfg.Contracts.Amount = cc.RecommendedAmount
+		}
+	}
+
 	// no need to try and form contracts if wallet is completely empty
 	skipContractFormations := wi.Confirmed.IsZero() && wi.Unconfirmed.IsZero()
 	if skipContractFormations {
@@ -723,3 +839,14 @@ func (ap *Autopilot) buildState(ctx context.Context) (*contractor.MaintenanceSta
 		SkipContractFormations: skipContractFormations,
 	}, nil
 }
+
+// contractCountRecommendation computes the recommended amount of contracts for
+// the given contracts config, the average contract size is derived from our
+// active contracts.
+func (ap *Autopilot) contractCountRecommendation(ctx context.Context, cfg api.ContractsConfig, rs api.RedundancySettings) (api.ContractCountRecommendation, error) {
+	contracts, err := ap.bus.Contracts(ctx, api.ContractsOpts{FilterMode: api.ContractFilterModeActive})
+	if err != nil {
+		return api.ContractCountRecommendation{}, fmt.Errorf("failed to fetch contracts: %w", err)
+	}
+	return contractor.RecommendContractCount(cfg, rs, contracts), nil
+}
@@ -20,6 +20,16 @@ func NewClient(addr, password string) *Client {
 	}}
 }
 
+// PauseScanner pauses the host scanner, interrupting an ongoing scan.
+func (c *Client) PauseScanner(ctx context.Context) error {
+	return c.c.WithContext(ctx).POST("/scanner/pause", nil, nil)
+}
+
+// ResumeScanner resumes a paused host scanner.
+func (c *Client) ResumeScanner(ctx context.Context) error {
+	return c.c.WithContext(ctx).POST("/scanner/resume", nil, nil)
+}
+
 // State returns the current state of the autopilot.
 func (c *Client) State() (state api.AutopilotStateResponse, err error) {
 	err = c.c.GET("/state", &state)
@@ -33,6 +43,20 @@ func (c *Client) Trigger(forceScan bool) (_ bool, err error) {
 	return resp.Triggered, err
 }
 
+// ContractCountRecommendation returns the recommended amount of contracts for
+// the current autopilot config and redundancy settings.
+func (c *Client) ContractCountRecommendation(ctx context.Context) (resp api.ContractCountRecommendation, err error) {
+	err = c.c.WithContext(ctx).GET("/contract-count-recommendation", &resp)
+	return
+}
+
+// NetworkDiversity returns the distribution of the contracted hosts across
+// subnets.
+func (c *Client) NetworkDiversity(ctx context.Context) (resp api.NetworkDiversityResponse, err error) {
+	err = c.c.WithContext(ctx).GET("/network-diversity", &resp)
+	return
+}
+
 // EvaluateConfig evaluates an autopilot config using the given gouging and
 // redundancy settings.
 func (c *Client) EvaluateConfig(ctx context.Context, cfg api.AutopilotConfig, gs api.GougingSettings, rs api.RedundancySettings) (resp api.ConfigEvaluationResponse, err error) {
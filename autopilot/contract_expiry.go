@@ -0,0 +1,48 @@
+package autopilot
+
+import (
+	"context"
+	"time"
+
+	"go.sia.tech/core/types"
+)
+
+// checkExpiringContracts registers an alert for every contract that expires
+// within the configured threshold and dismisses the alerts of contracts that
+// no longer do, e.g. because they were renewed in the meantime.
+func (ap *Autopilot) checkExpiringContracts() {
+	if ap.contractExpiryAlertThreshold == 0 {
+		return // disabled
+	}
+
+	// use a sane timeout
+	ctx, cancel := context.WithTimeout(ap.shutdownCtx, time.Minute)
+	defer cancel()
+
+	// fetch expiring contracts
+	contracts, err := ap.bus.ContractsExpiring(ctx, ap.contractExpiryAlertThreshold)
+	if err != nil {
+		ap.logger.Errorf("failed to fetch expiring contracts: %v", err)
+		return
+	}
+
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+
+	// register alerts for expiring contracts
+	expiring := make(map[types.FileContractID]struct{})
+	for _, c := range contracts {
+		alert := newContractExpiringAlert(c)
+		ap.RegisterAlert(ctx, alert)
+		ap.expiryAlertIDs[c.ID] = alert.ID // store id to dismiss stale alerts
+		expiring[c.ID] = struct{}{}
+	}
+
+	// dismiss alerts for contracts that are no longer expiring
+	for fcid, alertID := range ap.expiryAlertIDs {
+		if _, ok := expiring[fcid]; !ok {
+			ap.DismissAlert(ctx, alertID)
+			delete(ap.expiryAlertIDs, fcid)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package contractor
 
 import (
+	"fmt"
 	"time"
 
 	"go.sia.tech/core/types"
@@ -13,6 +14,10 @@ const (
 	// register the lost sectors alert. A value of 0.01 means that we register
 	// the alert if the host lost 1% (or more) of its stored data.
 	alertLostSectorsThresholdPct = 0.01
+
+	// maxStorageConcentrationPct defines the percentage of our total stored
+	// data a single host can hold before we register an alert.
+	maxStorageConcentrationPct = 50
 )
 
 var (
@@ -20,7 +25,10 @@ var (
 	alertContractMaintenanceSkippedID = alerts.RandomAlertID() // constant until restarted
 	alertContractUsabilityUpdated     = alerts.RandomAlertID() // constant until restarted
 	alertLostSectorsID                = alerts.RandomAlertID() // constant until restarted
+	alertNetworkDiversityID           = alerts.RandomAlertID() // constant until restarted
 	alertRenewalFailedID              = alerts.RandomAlertID() // constant until restarted
+	alertStorageConcentrationID       = alerts.RandomAlertID() // constant until restarted
+	alertUnstablePricingID            = alerts.RandomAlertID() // constant until restarted
 )
 
 func newContractRenewalFailedAlert(contract api.ContractMetadata, ourFault bool, err error) alerts.Alert {
@@ -59,6 +67,48 @@ func newLostSectorsAlert(hk types.PublicKey, version, release string, lostSector
 	}
 }
 
+func newNetworkDiversityAlert(nd api.NetworkDiversityResponse) alerts.Alert {
+	return alerts.Alert{
+		ID:       alertNetworkDiversityID,
+		Severity: alerts.SeverityWarning,
+		Message:  "Contracted hosts are spread across too few subnets",
+		Data: map[string]interface{}{
+			"hosts":   nd.Hosts,
+			"subnets": len(nd.Subnets),
+			"hint":    fmt.Sprintf("Hosts in the same subnet are likely to go offline together, the contracted hosts should be spread across at least %d subnets.", minDistinctSubnets),
+		},
+		Timestamp: time.Now(),
+	}
+}
+
+func newStorageConcentrationAlert(hk types.PublicKey, storedData, totalStored uint64) alerts.Alert {
+	return alerts.Alert{
+		ID:       alerts.IDForHost(alertStorageConcentrationID, hk),
+		Severity: alerts.SeverityWarning,
+		Message:  "Host holds a large share of the stored data",
+		Data: map[string]interface{}{
+			"hostKey":     hk.String(),
+			"storedData":  storedData,
+			"totalStored": totalStored,
+			"hint":        fmt.Sprintf("The host holds more than %d%% of the data stored across all hosts. Losing this host would affect a large part of the data, consider increasing the number of contracts.", maxStorageConcentrationPct),
+		},
+		Timestamp: time.Now(),
+	}
+}
+
+func newUnstablePricingAlert(hk types.PublicKey) alerts.Alert {
+	return alerts.Alert{
+		ID:       alerts.IDForHost(alertUnstablePricingID, hk),
+		Severity: alerts.SeverityWarning,
+		Message:  "Host has unstable pricing",
+		Data: map[string]interface{}{
+			"hostKey": hk.String(),
+			"hint":    fmt.Sprintf("The host changed its upload or download price by more than %d%% within %v. The host's score is lowered until its prices stabilize, consider blocking the host if this keeps happening.", maxPriceChangePct, priceChangeWindow),
+		},
+		Timestamp: time.Now(),
+	}
+}
+
 func newContractMaintenanceSkippedAlert(reason string) alerts.Alert {
 	return alerts.Alert{
 		ID:       alertContractMaintenanceSkippedID,
@@ -74,3 +124,13 @@ func newContractMaintenanceSkippedAlert(reason string) alerts.Alert {
 func registerLostSectorsAlert(dataLost, dataStored uint64) bool {
 	return dataLost > 0 && float64(dataLost) >= float64(dataStored)*alertLostSectorsThresholdPct
 }
+
+func registerStorageConcentrationAlert(storedData, totalStored uint64) bool {
+	return totalStored > 0 && float64(storedData) > float64(totalStored)*maxStorageConcentrationPct/100
+}
+
+func registerNetworkDiversityAlert(nd api.NetworkDiversityResponse) bool {
+	// only alert if some of the hosts share a subnet, otherwise adding more
+	// contracts is the only way to improve the diversity
+	return len(nd.Subnets) < minDistinctSubnets && len(nd.Subnets) < nd.Hosts-nd.Unknown
+}
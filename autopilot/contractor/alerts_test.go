@@ -24,3 +24,21 @@ func TestRegisterLostSectorsAlert(t *testing.T) {
 		}
 	}
 }
+
+func TestRegisterStorageConcentrationAlert(t *testing.T) {
+	for _, tc := range []struct {
+		storedData  uint64
+		totalStored uint64
+		expected    bool
+	}{
+		{0, 0, false},
+		{0, rhpv2.SectorSize, false},
+		{rhpv2.SectorSize, rhpv2.SectorSize, true},
+		{rhpv2.SectorSize, 2 * rhpv2.SectorSize, false}, // exactly 50%
+		{rhpv2.SectorSize + 1, 2 * rhpv2.SectorSize, true},
+	} {
+		if result := registerStorageConcentrationAlert(tc.storedData, tc.totalStored); result != tc.expected {
+			t.Fatalf("unexpected result for storedData=%d, totalStored=%d: %v", tc.storedData, tc.totalStored, result)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package contractor
+
+import (
+	"time"
+
+	"go.sia.tech/core/types"
+)
+
+const (
+	// formationBackoffMin is the amount of time we wait before attempting to
+	// form a contract with a host after the first failed formation
+	formationBackoffMin = time.Minute
+
+	// formationBackoffMax is the maximum amount of time we wait before
+	// attempting to form a contract with a host that keeps failing
+	formationBackoffMax = time.Hour
+)
+
+type (
+	formationBackoffs map[types.PublicKey]formationBackoff
+
+	formationBackoff struct {
+		count        uint64
+		backoffUntil time.Time
+	}
+)
+
+// Count returns the number of consecutive failed formations with the host.
+func (fb formationBackoffs) Count(hk types.PublicKey) uint64 {
+	return fb[hk].count
+}
+
+// InBackoff returns true if we should not attempt to form a contract with the
+// host at the given time.
+func (fb formationBackoffs) InBackoff(hk types.PublicKey, now time.Time) bool {
+	b, ok := fb[hk]
+	return ok && now.Before(b.backoffUntil)
+}
+
+// RecordFailure registers a failed formation with the host and backs off from
+// it based on the number of consecutive failures, including this one.
+func (fb formationBackoffs) RecordFailure(hk types.PublicKey, now time.Time) time.Time {
+	b := fb[hk]
+	b.count++
+	b.backoffUntil = now.Add(formationBackoffDuration(b.count))
+
+	fb[hk] = b
+	return b.backoffUntil
+}
+
+// RecordSuccess clears the backoff for the host.
+func (fb formationBackoffs) RecordSuccess(hk types.PublicKey) {
+	delete(fb, hk)
+}
+
+// formationBackoffDuration returns the backoff after the given number of
+// consecutive failed formations, it starts at one minute after the first
+// failure and doubles with every failure after that up to an hour.
+func formationBackoffDuration(failures uint64) time.Duration {
+	if failures == 0 {
+		return 0
+	}
+	backoff := formationBackoffMin
+	for attempt := uint64(2); attempt <= failures && backoff < formationBackoffMax; attempt++ {
+		backoff *= 2
+	}
+	return min(backoff, formationBackoffMax)
+}
@@ -0,0 +1,56 @@
+package contractor
+
+import (
+	"math"
+
+	"go.sia.tech/renterd/api"
+)
+
+const (
+	// defaultAvgContractSize is the average contract size used to compute the
+	// recommended contract count when we don't have any contracts that store
+	// data yet.
+	defaultAvgContractSize = 250e9 // 250 GB
+)
+
+// RecommendContractCount computes the amount of contracts required to store
+// the configured amount of data using the given redundancy settings, assuming
+// every contract eventually stores as much data as the average of the given
+// contracts. The recommendation is never lower than the total number of
+// shards, since every shard of a slab has to be stored on a different host.
+func RecommendContractCount(cfg api.ContractsConfig, rs api.RedundancySettings, contracts []api.ContractMetadata) api.ContractCountRecommendation {
+	resp := api.ContractCountRecommendation{
+		TargetStorage:   cfg.Storage,
+		MinShards:       rs.MinShards,
+		TotalShards:     rs.TotalShards,
+		AvgContractSize: avgContractSize(contracts),
+		CurrentAmount:   cfg.Amount,
+	}
+	if rs.MinShards <= 0 || rs.TotalShards <= 0 {
+		return resp
+	}
+
+	// recommendedAmount = ceil(targetStorage * totalShards / minShards / avgContractSize)
+	resp.Redundancy = rs.Redundancy()
+	required := math.Ceil(float64(cfg.Storage) * float64(rs.TotalShards) / float64(rs.MinShards))
+	resp.RequiredStorage = uint64(required)
+	resp.RecommendedAmount = uint64(math.Ceil(required / float64(resp.AvgContractSize)))
+	if resp.RecommendedAmount < uint64(rs.TotalShards) {
+		resp.RecommendedAmount = uint64(rs.TotalShards)
+	}
+	return resp
+}
+
+// avgContractSize returns the average size of the given contracts, it returns
+// the default average contract size if there are no contracts or none of them
+// store any data.
+func avgContractSize(contracts []api.ContractMetadata) uint64 {
+	var total uint64
+	for _, c := range contracts {
+		total += c.Size
+	}
+	if len(contracts) == 0 || total/uint64(len(contracts)) == 0 {
+		return defaultAvgContractSize
+	}
+	return total / uint64(len(contracts))
+}
@@ -0,0 +1,60 @@
+package contractor
+
+import (
+	"testing"
+
+	"go.sia.tech/renterd/api"
+)
+
+func TestRecommendContractCount(t *testing.T) {
+	rs := api.RedundancySettings{MinShards: 10, TotalShards: 30}
+	cfg := api.ContractsConfig{Amount: 50, Storage: 4e12}
+
+	// without contracts the default average contract size is used, 4TB at 3x
+	// redundancy requires 12TB which is 48 contracts of 250GB
+	cc := RecommendContractCount(cfg, rs, nil)
+	if cc.RequiredStorage != 12e12 {
+		t.Fatal("unexpected required storage", cc.RequiredStorage)
+	} else if cc.AvgContractSize != defaultAvgContractSize {
+		t.Fatal("unexpected average contract size", cc.AvgContractSize)
+	} else if cc.RecommendedAmount != 48 {
+		t.Fatal("unexpected recommendation", cc.RecommendedAmount)
+	} else if cc.CurrentAmount != 50 {
+		t.Fatal("unexpected current amount", cc.CurrentAmount)
+	} else if cc.Redundancy != 3 {
+		t.Fatal("unexpected redundancy", cc.Redundancy)
+	}
+
+	// empty contracts fall back to the default average contract size
+	contracts := []api.ContractMetadata{{Size: 0}, {Size: 0}}
+	if cc := RecommendContractCount(cfg, rs, contracts); cc.AvgContractSize != defaultAvgContractSize {
+		t.Fatal("unexpected average contract size", cc.AvgContractSize)
+	}
+
+	// contracts smaller than the default raise the recommendation
+	contracts = []api.ContractMetadata{{Size: 100e9}, {Size: 200e9}}
+	if cc := RecommendContractCount(cfg, rs, contracts); cc.AvgContractSize != 150e9 {
+		t.Fatal("unexpected average contract size", cc.AvgContractSize)
+	} else if cc.RecommendedAmount != 80 {
+		t.Fatal("unexpected recommendation", cc.RecommendedAmount)
+	}
+
+	// larger contracts lower the recommendation, rounding up
+	contracts = []api.ContractMetadata{{Size: 200e9}, {Size: 400e9}}
+	if cc := RecommendContractCount(cfg, rs, contracts); cc.AvgContractSize != 300e9 {
+		t.Fatal("unexpected average contract size", cc.AvgContractSize)
+	} else if cc.RecommendedAmount != 40 {
+		t.Fatal("unexpected recommendation", cc.RecommendedAmount)
+	}
+
+	// the recommendation is never lower than the total number of shards
+	cfg.Storage = 1e9
+	if cc := RecommendContractCount(cfg, rs, nil); cc.RecommendedAmount != 30 {
+		t.Fatal("unexpected recommendation", cc.RecommendedAmount)
+	}
+
+	// invalid redundancy settings result in no recommendation
+	if cc := RecommendContractCount(cfg, api.RedundancySettings{}, nil); cc.RecommendedAmount != 0 {
+		t.Fatal("unexpected recommendation", cc.RecommendedAmount)
+	}
+}
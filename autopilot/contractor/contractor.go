@@ -9,6 +9,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/montanaflynn/stats"
@@ -40,11 +41,27 @@ const (
 	// punishing a contract for not being able to refresh
 	failedRefreshForgivenessPeriod = 24 * time.Hour
 
+	// maxAllocatedStoragePct is the percentage of a host's total storage we
+	// allocate through our contracts before we stop refreshing contracts with
+	// that host to avoid over-committing storage to a single host, it's below
+	// 100% since the host also needs room for its own metadata and other
+	// renters
+	maxAllocatedStoragePct = 90
+
+	// minContractAge is the minimum number of blocks that need to pass since
+	// the formation of a contract before we consider renegotiating its price
+	minContractAge = 144 * 7 // 1 week
+
 	// minAllowedScoreLeeway is a factor by which a host can be under the lowest
 	// score found in a random sample of scores before being considered not
 	// usable.
 	minAllowedScoreLeeway = 500
 
+	// renegotiationThresholdPct is the percentage by which one of the host's
+	// current prices has to be lower than the price the contract was formed
+	// at before we renew the contract to renegotiate its price
+	renegotiationThresholdPct = 20
+
 	// targetBlockTime is the average block time of the Sia network
 	targetBlockTime = 10 * time.Minute
 
@@ -77,9 +94,10 @@ type Bus interface {
 	ContractRevision(ctx context.Context, fcid types.FileContractID) (api.Revision, error)
 	RenewContract(ctx context.Context, fcid types.FileContractID, endHeight uint64, renterFunds, minNewCollateral types.Currency, expectedNewStorage uint64) (api.ContractMetadata, error)
 	Host(ctx context.Context, hostKey types.PublicKey) (api.Host, error)
+	HostPriceHistory(ctx context.Context, since time.Time) (map[types.PublicKey][]api.HostPriceChange, error)
 	Hosts(ctx context.Context, opts api.HostOptions) ([]api.Host, error)
 	UpdateContractUsability(ctx context.Context, contractID types.FileContractID, usability string) (err error)
-	UpdateHostCheck(ctx context.Context, hostKey types.PublicKey, hostCheck api.HostChecks) error
+	UpdateHostChecks(ctx context.Context, checks map[types.PublicKey]api.HostChecks) error
 }
 
 type HostScanner interface {
@@ -110,12 +128,17 @@ type (
 		logger  *zap.SugaredLogger
 
 		allowRedundantHostIPs bool
+		geo                   geoScorer
 
 		revisionBroadcastInterval time.Duration
 		revisionLastBroadcast     map[types.FileContractID]time.Time
 		revisionSubmissionBuffer  uint64
 
 		firstRefreshFailure map[types.FileContractID]time.Time
+		formationBackoffs   formationBackoffs
+		hostScores          *HostScoreCache
+
+		priceRenegotiations atomic.Uint64
 	}
 
 	scoredHost struct {
@@ -125,7 +148,7 @@ type (
 	}
 )
 
-func New(bus Bus, alerter alerts.Alerter, revisionSubmissionBuffer uint64, revisionBroadcastInterval time.Duration, allowRedundantHostIPs bool, logger *zap.Logger) *Contractor {
+func New(bus Bus, alerter alerts.Alerter, revisionSubmissionBuffer uint64, revisionBroadcastInterval time.Duration, allowRedundantHostIPs bool, geo GeoLocator, maxFractionPerCountry float64, logger *zap.Logger) *Contractor {
 	logger = logger.Named("contractor")
 	return &Contractor{
 		bus:     bus,
@@ -134,17 +157,30 @@ func New(bus Bus, alerter alerts.Alerter, revisionSubmissionBuffer uint64, revis
 		logger:  logger.Sugar(),
 
 		allowRedundantHostIPs: allowRedundantHostIPs,
+		geo: geoScorer{
+			locator:               geo,
+			maxFractionPerCountry: maxFractionPerCountry,
+		},
 
 		revisionBroadcastInterval: revisionBroadcastInterval,
 		revisionLastBroadcast:     make(map[types.FileContractID]time.Time),
 		revisionSubmissionBuffer:  revisionSubmissionBuffer,
 
 		firstRefreshFailure: make(map[types.FileContractID]time.Time),
+		formationBackoffs:   make(formationBackoffs),
+		hostScores:          NewHostScoreCache(),
 	}
 }
 
 func (c *Contractor) PerformContractMaintenance(ctx context.Context, state *MaintenanceState) (bool, error) {
-	return performContractMaintenance(newMaintenanceCtx(ctx, state), c.alerter, c.bus, c.churn, c, c, c, c.allowRedundantHostIPs, c.logger)
+	return performContractMaintenance(newMaintenanceCtx(ctx, state), c.alerter, c.bus, c.churn, c.formationBackoffs, c.hostScores, &c.priceRenegotiations, c, c, c, c.allowRedundantHostIPs, c.geo, c.logger)
+}
+
+// PriceRenegotiationsInitiated returns the number of renewals that were
+// performed to renegotiate a contract's price since the contractor was
+// created. Renewals that failed are not counted.
+func (c *Contractor) PriceRenegotiationsInitiated() uint64 {
+	return c.priceRenegotiations.Load()
 }
 
 func (c *Contractor) formContract(ctx *mCtx, hs HostScanner, host api.Host, minInitialContractFunds types.Currency, logger *zap.SugaredLogger) (cm api.ContractMetadata, proceed bool, err error) {
@@ -436,6 +472,12 @@ func (c *Contractor) refreshFundingEstimate(contract contract, logger *zap.Sugar
 }
 
 func (c *Contractor) shouldArchive(contract contract, bh uint64, n consensus.Network) (err error) {
+	// resolved contracts are only archived once their resolution is
+	// finalized, otherwise a reorg might revert the resolution
+	if contract.ProofConfirmations > 0 && !contract.IsFinalized() {
+		return nil
+	}
+
 	if bh > contract.EndHeight()-c.revisionSubmissionBuffer {
 		return errContractExpired
 	} else if contract.Revision != nil && contract.Revision.RevisionNumber == math.MaxUint64 {
@@ -685,7 +727,7 @@ func renterFundsToExpectedStorageV2(renterFunds types.Currency, duration uint64,
 // need it and marking contracts that should no longer be used as bad. The
 // host filter is updated to contain all hosts that we keep contracts with. If a
 // contract is refreshed or renewed, the 'remainingFunds' are adjusted.
-func performContractChecks(ctx *mCtx, alerter alerts.Alerter, bus Bus, churn accumulatedChurn, cc contractChecker, cr contractReviser, hf hostFilter, logger *zap.SugaredLogger) (uint64, error) {
+func performContractChecks(ctx *mCtx, alerter alerts.Alerter, bus Bus, churn accumulatedChurn, renegotiations *atomic.Uint64, cc contractChecker, cr contractReviser, hf hostFilter, logger *zap.SugaredLogger) (uint64, error) {
 	// fetch network
 	network, err := bus.ConsensusNetwork(ctx)
 	if err != nil {
@@ -728,7 +770,10 @@ func performContractChecks(ctx *mCtx, alerter alerts.Alerter, bus Bus, churn acc
 	// necessary and filtering out contracts that should no longer be used
 	logger.With("contracts", len(contracts)).Info("checking existing contracts")
 
-	var renewed, refreshed, wasGood uint64
+	// keep track of contracts to archive
+	toArchive := make(map[types.FileContractID]string)
+
+	var renewed, refreshed, renegotiated, wasGood uint64
 	for _, c := range contracts {
 		cm := c.ContractMetadata
 		if cm.IsGood() {
@@ -755,11 +800,8 @@ func performContractChecks(ctx *mCtx, alerter alerts.Alerter, bus Bus, churn acc
 
 		// check if contract is ready to be archived.
 		if reason := cc.shouldArchive(c, cs.BlockHeight, network); reason != nil {
-			if err := bus.ArchiveContracts(ctx, map[types.FileContractID]string{c.ID: reason.Error()}); err != nil {
-				logger.With(zap.Error(err)).Error("failed to archive contract")
-			} else {
-				logger.With("reason", reason).Info("successfully archived contract")
-			}
+			logger.With("reason", reason).Debug("contract is ready to be archived")
+			toArchive[c.ID] = reason.Error()
 			continue
 		}
 
@@ -789,7 +831,7 @@ func performContractChecks(ctx *mCtx, alerter alerts.Alerter, bus Bus, churn acc
 		}
 
 		// get check
-		if host.Checks == (api.HostChecks{}) {
+		if host.Checks.IsZero() {
 			logger.Warn("missing host check")
 			updateUsability(ctx, host, cm, api.ContractUsabilityBad, api.ErrUsabilityHostCheckNotFound.Error())
 			continue
@@ -821,6 +863,26 @@ func performContractChecks(ctx *mCtx, alerter alerts.Alerter, bus Bus, churn acc
 		// check if contract is usable
 		usable, needsRefresh, needsRenew, reasons := cc.isUsableContract(ctx.AutopilotConfig(), c, cs.BlockHeight)
 
+		// renew the contract early if the host's prices dropped significantly,
+		// but only if the renewal extends the contract
+		var renegotiate bool
+		if !needsRenew && !needsRefresh &&
+			ctx.AutopilotConfig().PriceRenegotiationEnabled &&
+			ctx.EndHeight(cs.BlockHeight) > c.ProofHeight &&
+			shouldRenegotiatePrice(c, host, cs.BlockHeight) {
+			reasons = append(reasons, errContractPriceDropped.Error())
+			needsRenew = true
+			renegotiate = true
+		}
+
+		// don't refresh the contract if we already allocated most of the
+		// host's storage, the contract is kept but its usability remains
+		// unchanged
+		if needsRefresh && isStorageFullyAllocated(host) {
+			reasons = append(reasons, errHostStorageFullyAllocated.Error())
+			needsRefresh = false
+		}
+
 		// extend logger
 		logger = logger.With("usable", usable).
 			With("needsRefresh", needsRefresh).
@@ -847,6 +909,10 @@ func performContractChecks(ctx *mCtx, alerter alerts.Alerter, bus Bus, churn acc
 				cm = renewedContract
 				usable = true
 				renewed++
+				if renegotiate {
+					renegotiated++
+					renegotiations.Add(1)
+				}
 			}
 		} else if needsRefresh {
 			var refreshedContract api.ContractMetadata
@@ -888,6 +954,15 @@ func performContractChecks(ctx *mCtx, alerter alerts.Alerter, bus Bus, churn acc
 		updateUsability(ctx, host, cm, api.ContractUsabilityGood, "contract is usable")
 	}
 
+	// archive contracts
+	if len(toArchive) > 0 {
+		if err := bus.ArchiveContracts(ctx, toArchive); err != nil {
+			logger.With(zap.Error(err)).With("contracts", len(toArchive)).Error("failed to archive contracts")
+		} else {
+			logger.With("contracts", len(toArchive)).Info("successfully archived contracts")
+		}
+	}
+
 	// update churn and register alert
 	if len(updates) > 0 {
 		if !hasAlert(ctx, alerter, alertChurnID, logger) {
@@ -901,6 +976,8 @@ func performContractChecks(ctx *mCtx, alerter alerts.Alerter, bus Bus, churn acc
 	logger.
 		With("refreshed", refreshed).
 		With("renewed", renewed).
+		With("renegotiated", renegotiated).
+		With("archived", len(toArchive)).
 		With("updated", len(updates)).
 		Info("contract checks done")
 	return uint64(len(updates)), nil
@@ -908,7 +985,7 @@ func performContractChecks(ctx *mCtx, alerter alerts.Alerter, bus Bus, churn acc
 
 // performContractFormations forms up to 'wanted' new contracts with hosts. The
 // 'ipFilter' and 'remainingFunds' are updated with every new contract.
-func performContractFormations(ctx *mCtx, bus Bus, cr contractReviser, hf hostFilter, logger *zap.SugaredLogger) (uint64, error) {
+func performContractFormations(ctx *mCtx, bus Bus, fb formationBackoffs, cr contractReviser, hf hostFilter, logger *zap.SugaredLogger) (uint64, error) {
 	wanted := int(ctx.WantedContracts())
 
 	// fetch all active contracts
@@ -943,22 +1020,40 @@ func performContractFormations(ctx *mCtx, bus Bus, cr contractReviser, hf hostFi
 		return 0, fmt.Errorf("failed to fetch good hosts: %w", err)
 	}
 
+	// collect the subnets of the hosts we already have contracts with
+	var contractedHosts []api.Host
+	for _, host := range allHosts {
+		if _, used := usedHosts[host.PublicKey]; used {
+			contractedHosts = append(contractedHosts, host)
+		}
+	}
+	subnets := contractedSubnets(ctx, contractedHosts)
+
 	// filter them
 	var candidates scoredHosts
 	for _, host := range allHosts {
 		logger := logger.With("hostKey", host.PublicKey)
-		if host.Checks == (api.HostChecks{}) {
+		if host.Checks.IsZero() {
 			logger.Warnf("missing host check %v", host.PublicKey)
 			continue
 		}
 		if _, used := usedHosts[host.PublicKey]; used {
 			logger.Debug("host already used")
 			continue
+		} else if fb.InBackoff(host.PublicKey, time.Now()) {
+			logger.Debug("host is in formation backoff")
+			continue
 		} else if score := host.Checks.ScoreBreakdown.Score(); score == 0 {
 			logger.Error("host has a score of 0")
 			continue
 		}
-		candidates = append(candidates, newScoredHost(host, host.Checks.ScoreBreakdown))
+
+		// penalize hosts that share a subnet with a contracted host or are
+		// located in an overrepresented country
+		candidate := newScoredHost(host, host.Checks.ScoreBreakdown)
+		candidate.score *= subnetDiversityScore(ctx, host, subnets)
+		candidate.score *= host.Checks.GeoScore
+		candidates = append(candidates, candidate)
 	}
 	logger = logger.With("candidates", len(candidates))
 
@@ -997,6 +1092,10 @@ func performContractFormations(ctx *mCtx, bus Bus, cr contractReviser, hf hostFi
 
 		_, proceed, err := cr.formContract(ctx, bus, candidate.host, minInitialContractFunds, logger)
 		if err != nil {
+			// back off from the host unless the failure was our fault
+			if proceed {
+				logger = logger.With("backoffUntil", fb.RecordFailure(candidate.host.PublicKey, time.Now()))
+			}
 			logger.With(zap.Error(err)).Error("failed to form contract")
 			continue
 		}
@@ -1004,6 +1103,7 @@ func performContractFormations(ctx *mCtx, bus Bus, cr contractReviser, hf hostFi
 			logger.Error("not proceeding with contract formation")
 			break
 		}
+		fb.RecordSuccess(candidate.host.PublicKey)
 
 		// add new contract and host
 		hf.Add(ctx, candidate.host)
@@ -1016,7 +1116,7 @@ func performContractFormations(ctx *mCtx, bus Bus, cr contractReviser, hf hostFi
 
 // performHostChecks performs scoring and usability checks on all hosts,
 // updating their state in the database.
-func performHostChecks(ctx *mCtx, bus Bus, logger *zap.SugaredLogger) error {
+func performHostChecks(ctx *mCtx, bus Bus, fb formationBackoffs, hsc *HostScoreCache, gs geoScorer, logger *zap.SugaredLogger) error {
 	var usabilityBreakdown unusableHostsBreakdown
 	// fetch all hosts that are not blocked
 	hosts, err := bus.Hosts(ctx, api.HostOptions{})
@@ -1024,33 +1124,66 @@ func performHostChecks(ctx *mCtx, bus Bus, logger *zap.SugaredLogger) error {
 		return fmt.Errorf("failed to fetch all hosts: %w", err)
 	}
 
+	// fetch recent price changes to detect hosts with unstable pricing
+	priceHistory, err := bus.HostPriceHistory(ctx, time.Now().Add(-priceChangeWindow))
+	if err != nil {
+		return fmt.Errorf("failed to fetch host price history: %w", err)
+	}
+
+	// prune scores of hosts that no longer exist
+	hsc.Prune(hosts)
+
 	var scoredHosts []scoredHost
+	unstablePricing := make(map[types.PublicKey]bool)
 	for _, host := range hosts {
-		// score host
-		sb, err := ctx.HostScore(host)
+		// score host, hosts that didn't change since the last iteration are
+		// not re-scored
+		sb, err := hsc.HostScore(ctx, host)
 		if err != nil {
 			logger.With(zap.Error(err)).Info("failed to score host")
 			continue
 		}
+
+		// lower the price score of hosts with unstable pricing
+		if hasUnstablePricing(priceHistory[host.PublicKey]) {
+			unstablePricing[host.PublicKey] = true
+			sb.Prices *= unstablePricingPenalty
+		}
 		scoredHosts = append(scoredHosts, newScoredHost(host, sb))
 	}
 
 	// compute minimum score for usable hosts
 	minScore := calculateMinScore(scoredHosts, ctx.WantedContracts(), logger)
 
+	// compute the geo scores based on the hosts we have contracts with
+	var contracted map[types.PublicKey]struct{}
+	if gs.locator != nil {
+		contracts, err := bus.Contracts(ctx, api.ContractsOpts{FilterMode: api.ContractFilterModeActive})
+		if err != nil {
+			return fmt.Errorf("failed to fetch contracts: %w", err)
+		}
+		contracted = make(map[types.PublicKey]struct{})
+		for _, c := range contracts {
+			contracted[c.HostKey] = struct{}{}
+		}
+	}
+	geoScores := gs.scores(ctx, hosts, contracted, int(ctx.WantedContracts()), logger)
+
 	// run host checks using the latest consensus state
 	cs, err := bus.ConsensusState(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to fetch consensus state: %w", err)
 	}
+	checks := make(map[types.PublicKey]api.HostChecks, len(scoredHosts))
 	for _, h := range scoredHosts {
 		// ignore HostBlockHeight
 		h.host.PriceTable.HostBlockHeight = cs.BlockHeight
 		h.host.V2Settings.Prices.TipHeight = cs.BlockHeight
 		hc := checkHost(ctx.GougingChecker(cs), h, minScore, ctx.Period())
-		if err := bus.UpdateHostCheck(ctx, h.host.PublicKey, *hc); err != nil {
-			return fmt.Errorf("failed to update host check for host %v: %w", h.host.PublicKey, err)
-		}
+		hc.FormationBackoffCount = fb.Count(h.host.PublicKey)
+		hc.UnstablePricing = unstablePricing[h.host.PublicKey]
+		hc.GeoScore = geoScores[h.host.PublicKey]
+		checks[h.host.PublicKey] = *hc
 		usabilityBreakdown.track(hc.UsabilityBreakdown)
 
 		if !hc.UsabilityBreakdown.IsUsable() {
@@ -1060,6 +1193,11 @@ func performHostChecks(ctx *mCtx, bus Bus, logger *zap.SugaredLogger) error {
 		}
 	}
 
+	// update the host checks in a single batch
+	if err := bus.UpdateHostChecks(ctx, checks); err != nil {
+		return fmt.Errorf("failed to update host checks: %w", err)
+	}
+
 	logger.Infow("host checks completed", usabilityBreakdown.keysAndValues()...)
 	return nil
 }
@@ -1101,6 +1239,46 @@ func performPostMaintenanceTasks(ctx *mCtx, bus Bus, alerter alerts.Alerter, cc
 			toDismiss = append(toDismiss, alerts.IDForHost(alertLostSectorsID, h.PublicKey))
 		}
 	}
+
+	// register alerts for used hosts that hold too much of our data
+	var totalStored uint64
+	for _, h := range allHosts {
+		totalStored += h.StoredData
+	}
+	for _, h := range allHosts {
+		if _, used := usedHosts[h.PublicKey]; !used {
+			continue
+		} else if registerStorageConcentrationAlert(h.StoredData, totalStored) {
+			alerter.RegisterAlert(ctx, newStorageConcentrationAlert(h.PublicKey, h.StoredData, totalStored))
+		} else {
+			toDismiss = append(toDismiss, alerts.IDForHost(alertStorageConcentrationID, h.PublicKey))
+		}
+	}
+
+	// register alerts for used hosts with unstable pricing
+	for _, h := range allHosts {
+		if _, used := usedHosts[h.PublicKey]; !used {
+			continue
+		} else if h.Checks.UnstablePricing {
+			alerter.RegisterAlert(ctx, newUnstablePricingAlert(h.PublicKey))
+		} else {
+			toDismiss = append(toDismiss, alerts.IDForHost(alertUnstablePricingID, h.PublicKey))
+		}
+	}
+
+	// register an alert if our hosts are spread across too few subnets
+	var contractedHosts []api.Host
+	for _, h := range allHosts {
+		if _, used := usedHosts[h.PublicKey]; used {
+			contractedHosts = append(contractedHosts, h)
+		}
+	}
+	if nd := NetworkDiversity(ctx, contractedHosts); registerNetworkDiversityAlert(nd) {
+		alerter.RegisterAlert(ctx, newNetworkDiversityAlert(nd))
+	} else {
+		toDismiss = append(toDismiss, alertNetworkDiversityID)
+	}
+
 	if len(toDismiss) > 0 {
 		alerter.DismissAlerts(ctx, toDismiss...)
 	}
@@ -1176,17 +1354,18 @@ func performV2ContractMigration(ctx *mCtx, bus Bus, cr contractReviser, logger *
 	}
 
 	// archive contracts
-	for id := range toArchive {
-		if err := bus.ArchiveContracts(ctx, map[types.FileContractID]string{
-			id: "migrated to v2",
-		}); err != nil {
-			logger.Errorf("failed to archive migrated contract")
-			continue
+	if len(toArchive) > 0 {
+		archive := make(map[types.FileContractID]string, len(toArchive))
+		for id := range toArchive {
+			archive[id] = "migrated to v2"
+		}
+		if err := bus.ArchiveContracts(ctx, archive); err != nil {
+			logger.Errorf("failed to archive migrated contracts: %v", err)
 		}
 	}
 }
 
-func performContractMaintenance(ctx *mCtx, alerter alerts.Alerter, bus Bus, churn accumulatedChurn, cc contractChecker, cr contractReviser, rb revisionBroadcaster, allowRedundantHostIPs bool, logger *zap.SugaredLogger) (bool, error) {
+func performContractMaintenance(ctx *mCtx, alerter alerts.Alerter, bus Bus, churn accumulatedChurn, fb formationBackoffs, hsc *HostScoreCache, renegotiations *atomic.Uint64, cc contractChecker, cr contractReviser, rb revisionBroadcaster, allowRedundantHostIPs bool, gs geoScorer, logger *zap.SugaredLogger) (bool, error) {
 	logger = logger.Named("performContractMaintenance").
 		Named(hex.EncodeToString(frand.Bytes(16))) // uuid for this iteration
 
@@ -1202,7 +1381,7 @@ func performContractMaintenance(ctx *mCtx, alerter alerts.Alerter, bus Bus, chur
 	logger.Infow("performing contract maintenance")
 
 	// STEP 1: perform host checks
-	if err := performHostChecks(ctx, bus, logger); err != nil {
+	if err := performHostChecks(ctx, bus, fb, hsc, gs, logger); err != nil {
 		return false, err
 	}
 
@@ -1211,13 +1390,13 @@ func performContractMaintenance(ctx *mCtx, alerter alerts.Alerter, bus Bus, chur
 
 	// STEP 2: perform contract maintenance
 	hf := newHostFilter(allowRedundantHostIPs, logger)
-	nUpdated, err := performContractChecks(ctx, alerter, bus, churn, cc, cr, hf, logger)
+	nUpdated, err := performContractChecks(ctx, alerter, bus, churn, renegotiations, cc, cr, hf, logger)
 	if err != nil {
 		return false, err
 	}
 
 	// STEP 3: perform contract formation
-	nFormed, err := performContractFormations(ctx, bus, cr, hf, logger)
+	nFormed, err := performContractFormations(ctx, bus, fb, cr, hf, logger)
 	if err != nil {
 		return false, err
 	}
@@ -169,6 +169,19 @@ func TestShouldArchive(t *testing.T) {
 	if err != errContractBeyondV2RequireHeight {
 		t.Fatal("unexpected error", err)
 	}
+	c1.V2 = true
+
+	// resolved but not finalized
+	c1.ProofConfirmations = 1
+	err = c.shouldArchive(c1, 26, n)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	c1.ProofConfirmations = api.ContractProofConfirmationDepth
+	err = c.shouldArchive(c1, 26, n)
+	if err != errContractExpired {
+		t.Fatal("unexpected error", err)
+	}
 }
 
 func TestShouldForgiveFailedRenewal(t *testing.T) {
@@ -197,3 +210,136 @@ func TestShouldForgiveFailedRenewal(t *testing.T) {
 		t.Fatal("expected no failures")
 	}
 }
+
+func TestFormationBackoffs(t *testing.T) {
+	var hk types.PublicKey
+	frand.Read(hk[:])
+	fb := make(formationBackoffs)
+
+	// assert the backoff is based on the current number of failures
+	for failures, expected := range []time.Duration{0, time.Minute, 2 * time.Minute, 4 * time.Minute} {
+		if backoff := formationBackoffDuration(uint64(failures)); backoff != expected {
+			t.Fatalf("expected backoff %v after %d failures, got %v", expected, failures, backoff)
+		}
+	}
+	if backoff := formationBackoffDuration(math.MaxUint64); backoff != formationBackoffMax {
+		t.Fatal("expected backoff to be capped", backoff)
+	}
+
+	// assert the host is not in backoff initially
+	now := time.Now()
+	if fb.InBackoff(hk, now) {
+		t.Fatal("unexpected backoff")
+	} else if fb.Count(hk) != 0 {
+		t.Fatal("unexpected count", fb.Count(hk))
+	}
+
+	// assert the backoff doubles with every failure and is capped at an hour
+	for i, expected := range []time.Duration{
+		time.Minute,
+		2 * time.Minute,
+		4 * time.Minute,
+		8 * time.Minute,
+		16 * time.Minute,
+		32 * time.Minute,
+		time.Hour,
+		time.Hour,
+	} {
+		if until := fb.RecordFailure(hk, now); until != now.Add(expected) {
+			t.Fatalf("%d: unexpected backoff %v != %v", i, until.Sub(now), expected)
+		} else if fb.Count(hk) != uint64(i+1) {
+			t.Fatalf("%d: unexpected count %v", i, fb.Count(hk))
+		}
+	}
+
+	// assert the host is in backoff until the backoff expires
+	if !fb.InBackoff(hk, now.Add(time.Hour-time.Second)) {
+		t.Fatal("expected backoff")
+	} else if fb.InBackoff(hk, now.Add(time.Hour)) {
+		t.Fatal("unexpected backoff")
+	}
+
+	// assert a successful formation clears the backoff
+	fb.RecordSuccess(hk)
+	if fb.InBackoff(hk, now) {
+		t.Fatal("unexpected backoff")
+	} else if fb.Count(hk) != 0 {
+		t.Fatal("unexpected count", fb.Count(hk))
+	}
+}
+
+func TestShouldRenegotiatePrice(t *testing.T) {
+	c := contract{ContractMetadata: api.ContractMetadata{
+		ContractPrice: types.Siacoins(1),
+		StartHeight:   100,
+	}}
+	host := func(price types.Currency) api.Host {
+		var h api.Host
+		h.PriceTable.ContractPrice = price
+		return h
+	}
+	bh := c.StartHeight + minContractAge
+
+	// assert we renegotiate if the price dropped by more than the threshold
+	if !shouldRenegotiatePrice(c, host(types.Siacoins(1).Div64(2)), bh) {
+		t.Fatal("expected renegotiation")
+	}
+
+	// assert we don't renegotiate young contracts
+	if shouldRenegotiatePrice(c, host(types.Siacoins(1).Div64(2)), bh-1) {
+		t.Fatal("unexpected renegotiation")
+	}
+
+	// assert we don't renegotiate if the price dropped by less than the
+	// threshold
+	if shouldRenegotiatePrice(c, host(types.Siacoins(1).Div64(100).Mul64(100-renegotiationThresholdPct)), bh) {
+		t.Fatal("unexpected renegotiation")
+	} else if shouldRenegotiatePrice(c, host(types.Siacoins(2)), bh) {
+		t.Fatal("unexpected renegotiation")
+	}
+
+	// assert we don't renegotiate contracts without a price
+	c.ContractPrice = types.ZeroCurrency
+	if shouldRenegotiatePrice(c, host(types.ZeroCurrency), bh) {
+		t.Fatal("unexpected renegotiation")
+	}
+
+	// assert we renegotiate if the storage or bandwidth prices dropped
+	c.StoragePrice = types.Siacoins(1)
+	c.UploadPrice = types.Siacoins(1)
+	c.DownloadPrice = types.Siacoins(1)
+	prices := func(storage, upload, download types.Currency) api.Host {
+		var h api.Host
+		h.Settings.StoragePrice = storage
+		h.Settings.UploadBandwidthPrice = upload
+		h.Settings.DownloadBandwidthPrice = download
+		return h
+	}
+	half := types.Siacoins(1).Div64(2)
+	if shouldRenegotiatePrice(c, prices(types.Siacoins(1), types.Siacoins(1), types.Siacoins(1)), bh) {
+		t.Fatal("unexpected renegotiation")
+	} else if !shouldRenegotiatePrice(c, prices(half, types.Siacoins(1), types.Siacoins(1)), bh) {
+		t.Fatal("expected renegotiation")
+	} else if !shouldRenegotiatePrice(c, prices(types.Siacoins(1), half, types.Siacoins(1)), bh) {
+		t.Fatal("expected renegotiation")
+	} else if !shouldRenegotiatePrice(c, prices(types.Siacoins(1), types.Siacoins(1), half), bh) {
+		t.Fatal("expected renegotiation")
+	}
+}
+
+func TestIsStorageFullyAllocated(t *testing.T) {
+	host := func(pct float64) api.Host {
+		var h api.Host
+		h.Checks.AllocatedStoragePct = pct
+		return h
+	}
+	if isStorageFullyAllocated(host(0)) {
+		t.Fatal("unexpected")
+	} else if isStorageFullyAllocated(host(maxAllocatedStoragePct - 1)) {
+		t.Fatal("unexpected")
+	} else if !isStorageFullyAllocated(host(maxAllocatedStoragePct)) {
+		t.Fatal("expected storage to be fully allocated")
+	} else if !isStorageFullyAllocated(host(99)) {
+		t.Fatal("expected storage to be fully allocated")
+	}
+}
@@ -0,0 +1,113 @@
+package contractor
+
+import (
+	"context"
+	"net"
+	"sort"
+
+	"go.sia.tech/renterd/api"
+	"go.sia.tech/renterd/internal/utils"
+)
+
+// minDistinctSubnets is the minimum number of distinct subnets our contracted
+// hosts should be spread across before we register a network diversity alert.
+const minDistinctSubnets = 5
+
+// hostSubnets returns the subnets of the host's announced addresses. Private
+// addresses are skipped since they don't tell us anything about the host's
+// network.
+func hostSubnets(ctx context.Context, h api.Host) []string {
+	subnets, err := utils.AddressesToSubnets(hostIPs(ctx, h))
+	if err != nil {
+		return nil
+	}
+	return subnets
+}
+
+// hostIPs resolves the host's announced addresses and returns the unique public
+// IPs. Addresses that fail to resolve are skipped.
+func hostIPs(ctx context.Context, h api.Host) []net.IPAddr {
+	addrs := append([]string{h.NetAddress}, h.V2SiamuxAddresses...)
+
+	seen := make(map[string]struct{})
+	var ips []net.IPAddr
+	for _, addr := range addrs {
+		if addr == "" {
+			continue
+		}
+		resolved, err := utils.ResolveHostIPs(ctx, []string{addr})
+		if err != nil {
+			continue
+		}
+		for _, ip := range resolved {
+			if utils.IsPrivateIP(ip.IP) {
+				continue
+			} else if _, ok := seen[ip.String()]; ok {
+				continue
+			}
+			seen[ip.String()] = struct{}{}
+			ips = append(ips, ip)
+		}
+	}
+	return ips
+}
+
+// subnetDiversityScore returns a multiplier for the host's score that
+// penalizes hosts in the same subnet as a host we already have a contract
+// with. The score is 1/(1+n) where n is the number of contracted hosts in the
+// host's most crowded subnet.
+func subnetDiversityScore(ctx context.Context, h api.Host, contractedSubnets map[string]int) float64 {
+	var n int
+	for _, subnet := range hostSubnets(ctx, h) {
+		if contractedSubnets[subnet] > n {
+			n = contractedSubnets[subnet]
+		}
+	}
+	return 1 / float64(1+n)
+}
+
+// NetworkDiversity returns the distribution of the given hosts across subnets.
+func NetworkDiversity(ctx context.Context, hosts []api.Host) api.NetworkDiversityResponse {
+	resp := api.NetworkDiversityResponse{
+		Hosts:   len(hosts),
+		Subnets: []api.SubnetDistribution{},
+	}
+
+	distribution := make(map[string]*api.SubnetDistribution)
+	for _, h := range hosts {
+		subnets := hostSubnets(ctx, h)
+		if len(subnets) == 0 {
+			resp.Unknown++
+			continue
+		}
+		for _, subnet := range subnets {
+			if _, ok := distribution[subnet]; !ok {
+				distribution[subnet] = &api.SubnetDistribution{Subnet: subnet}
+			}
+			distribution[subnet].Hosts = append(distribution[subnet].Hosts, h.PublicKey)
+		}
+	}
+
+	for _, sd := range distribution {
+		resp.Subnets = append(resp.Subnets, *sd)
+	}
+	sort.Slice(resp.Subnets, func(i, j int) bool {
+		if len(resp.Subnets[i].Hosts) != len(resp.Subnets[j].Hosts) {
+			return len(resp.Subnets[i].Hosts) > len(resp.Subnets[j].Hosts)
+		}
+		return resp.Subnets[i].Subnet < resp.Subnets[j].Subnet
+	})
+	return resp
+}
+
+// contractedSubnets returns the number of hosts per subnet for the given
+// hosts.
+func contractedSubnets(ctx context.Context, hosts []api.Host) map[string]int {
+	subnets := make(map[string]int)
+	for _, h := range hosts {
+		for _, subnet := range hostSubnets(ctx, h) {
+			subnets[subnet]++
+		}
+	}
+	return subnets
+}
@@ -0,0 +1,71 @@
+package contractor
+
+import (
+	"context"
+	"testing"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/api"
+)
+
+func TestNetworkDiversity(t *testing.T) {
+	ctx := context.Background()
+	newHost := func(netAddress string, v2Addrs ...string) api.Host {
+		return api.Host{
+			PublicKey:         types.GeneratePrivateKey().PublicKey(),
+			NetAddress:        netAddress,
+			V2SiamuxAddresses: v2Addrs,
+		}
+	}
+
+	h1 := newHost("1.1.1.1:9982")
+	h2 := newHost("", "1.1.1.2:9984")
+	h3 := newHost("2.2.2.2:9982", "2.2.2.2:9984")
+	h4 := newHost("host.invalid:9982")
+	h5 := newHost("192.168.1.1:9982")
+	h6 := newHost("localhost:9982")
+
+	// assert unresolvable and private addresses are skipped
+	if subnets := hostSubnets(ctx, h1); len(subnets) != 1 || subnets[0] != "1.1.1.0/24" {
+		t.Fatal("unexpected subnets", subnets)
+	} else if subnets := hostSubnets(ctx, h3); len(subnets) != 1 || subnets[0] != "2.2.2.0/24" {
+		t.Fatal("unexpected subnets", subnets)
+	} else if subnets := hostSubnets(ctx, h4); len(subnets) != 0 {
+		t.Fatal("unexpected subnets", subnets)
+	} else if subnets := hostSubnets(ctx, h5); len(subnets) != 0 {
+		t.Fatal("unexpected subnets", subnets)
+	} else if subnets := hostSubnets(ctx, h6); len(subnets) != 0 {
+		t.Fatal("unexpected subnets", subnets)
+	}
+
+	// assert hosts in crowded subnets are penalized
+	contracted := contractedSubnets(ctx, []api.Host{h1, h2, h3})
+	if score := subnetDiversityScore(ctx, newHost("1.1.1.3:9982"), contracted); score != 1.0/3 {
+		t.Fatal("unexpected score", score)
+	} else if score := subnetDiversityScore(ctx, newHost("2.2.2.3:9982"), contracted); score != 0.5 {
+		t.Fatal("unexpected score", score)
+	} else if score := subnetDiversityScore(ctx, newHost("3.3.3.3:9982"), contracted); score != 1 {
+		t.Fatal("unexpected score", score)
+	} else if score := subnetDiversityScore(ctx, h4, contracted); score != 1 {
+		t.Fatal("unexpected score", score)
+	}
+
+	// assert the distribution
+	nd := NetworkDiversity(ctx, []api.Host{h1, h2, h3, h4, h5})
+	if nd.Hosts != 5 || nd.Unknown != 2 || len(nd.Subnets) != 2 {
+		t.Fatalf("unexpected diversity %+v", nd)
+	} else if nd.Subnets[0].Subnet != "1.1.1.0/24" || len(nd.Subnets[0].Hosts) != 2 {
+		t.Fatalf("unexpected subnet %+v", nd.Subnets[0])
+	} else if nd.Subnets[1].Subnet != "2.2.2.0/24" || len(nd.Subnets[1].Hosts) != 1 {
+		t.Fatalf("unexpected subnet %+v", nd.Subnets[1])
+	}
+
+	// assert the alert is only registered if hosts share a subnet
+	if !registerNetworkDiversityAlert(nd) {
+		t.Fatal("expected alert")
+	} else if registerNetworkDiversityAlert(NetworkDiversity(ctx, []api.Host{h1, h3, h4})) {
+		t.Fatal("unexpected alert")
+	} else if registerNetworkDiversityAlert(NetworkDiversity(ctx, nil)) {
+		t.Fatal("unexpected alert")
+	}
+}
@@ -0,0 +1,143 @@
+package contractor
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/api"
+	"go.uber.org/zap"
+)
+
+type (
+	// GeoLocator resolves the country of an IP address, it returns an empty
+	// string if the country is unknown.
+	GeoLocator interface {
+		Country(ip net.IP) (string, error)
+	}
+
+	// GeoIPDB is a GeoLocator backed by a MaxMind-compatible GeoIP database,
+	// e.g. GeoLite2-Country or GeoLite2-City.
+	GeoIPDB struct {
+		r *maxminddb.Reader
+	}
+
+	// geoScorer computes the GeoRedundancy score of hosts, it penalizes hosts
+	// located in a country that already holds more than maxFractionPerCountry
+	// of our contracts.
+	geoScorer struct {
+		locator               GeoLocator
+		maxFractionPerCountry float64
+	}
+)
+
+// OpenGeoIPDB opens the GeoIP database at the given path.
+func OpenGeoIPDB(path string) (*GeoIPDB, error) {
+	r, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GeoIP database '%v': %w", path, err)
+	}
+	return &GeoIPDB{r: r}, nil
+}
+
+// Close closes the database.
+func (db *GeoIPDB) Close() error {
+	return db.r.Close()
+}
+
+// Country implements GeoLocator, it returns the ISO 3166-1 country code of the
+// given IP.
+func (db *GeoIPDB) Country(ip net.IP) (string, error) {
+	var record struct {
+		Country struct {
+			ISOCode string `maxminddb:"iso_code"`
+		} `maxminddb:"country"`
+	}
+	if err := db.r.Lookup(ip, &record); err != nil {
+		return "", err
+	}
+	return record.Country.ISOCode, nil
+}
+
+// hostCountries returns the countries of the IPs the host's announced addresses
+// resolve to.
+func (gs geoScorer) hostCountries(ctx context.Context, h api.Host, logger *zap.SugaredLogger) []string {
+	seen := make(map[string]struct{})
+	var countries []string
+	for _, ip := range hostIPs(ctx, h) {
+		country, err := gs.locator.Country(ip.IP)
+		if err != nil {
+			logger.With(zap.Error(err)).Debugw("failed to locate host", "hostKey", h.PublicKey, "ip", ip.String())
+			continue
+		} else if country == "" {
+			continue
+		} else if _, ok := seen[country]; ok {
+			continue
+		}
+		seen[country] = struct{}{}
+		countries = append(countries, country)
+	}
+	return countries
+}
+
+// scores returns the GeoRedundancy score of every host. The fraction of a
+// host's country is computed as the number of contracted hosts in that
+// country, including the host itself, divided by the number of contracts we
+// want. Hosts in a country whose fraction exceeds maxFractionPerCountry are
+// penalized proportionally, all other hosts have a score of 1.
+func (gs geoScorer) scores(ctx context.Context, hosts []api.Host, contracted map[types.PublicKey]struct{}, wanted int, logger *zap.SugaredLogger) map[types.PublicKey]float64 {
+	scores := make(map[types.PublicKey]float64, len(hosts))
+	if gs.locator == nil {
+		for _, h := range hosts {
+			scores[h.PublicKey] = 1
+		}
+		return scores
+	}
+
+	// locate all hosts and count the contracted hosts per country
+	countries := make(map[types.PublicKey][]string, len(hosts))
+	perCountry := make(map[string]int)
+	for _, h := range hosts {
+		countries[h.PublicKey] = gs.hostCountries(ctx, h, logger)
+		if _, ok := contracted[h.PublicKey]; ok {
+			for _, country := range countries[h.PublicKey] {
+				perCountry[country]++
+			}
+		}
+	}
+
+	total := max(wanted, len(contracted))
+	for _, h := range hosts {
+		_, isContracted := contracted[h.PublicKey]
+		scores[h.PublicKey] = geoRedundancyScore(countries[h.PublicKey], perCountry, isContracted, total, gs.maxFractionPerCountry)
+	}
+	return scores
+}
+
+// geoRedundancyScore returns a multiplier for the score of a host located in
+// the given countries. The host's most crowded country is used to compute its
+// fraction of the contract set, if it exceeds maxFraction the score is
+// maxFraction/fraction.
+func geoRedundancyScore(countries []string, perCountry map[string]int, contracted bool, total int, maxFraction float64) float64 {
+	if total == 0 || maxFraction <= 0 || maxFraction >= 1 {
+		return 1
+	}
+
+	var n int
+	for _, country := range countries {
+		n = max(n, perCountry[country])
+	}
+	if len(countries) == 0 {
+		return 1 // unknown location
+	} else if !contracted {
+		n++ // account for the contract we'd form
+	}
+
+	fraction := float64(n) / float64(total)
+	if fraction <= maxFraction {
+		return 1
+	}
+	return maxFraction / fraction
+}
@@ -0,0 +1,93 @@
+package contractor
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/api"
+	"go.uber.org/zap"
+)
+
+type testGeoLocator map[string]string
+
+func (l testGeoLocator) Country(ip net.IP) (string, error) {
+	if ip.String() == "9.9.9.9" {
+		return "", errors.New("lookup failed")
+	}
+	return l[ip.String()], nil
+}
+
+func TestGeoScores(t *testing.T) {
+	newHost := func(netAddress string) api.Host {
+		return api.Host{
+			PublicKey:  types.GeneratePrivateKey().PublicKey(),
+			NetAddress: netAddress,
+		}
+	}
+
+	locator := testGeoLocator{
+		"1.1.1.1": "DE",
+		"1.1.1.2": "DE",
+		"1.1.1.3": "DE",
+		"2.2.2.2": "US",
+		"2.2.2.3": "US",
+	}
+
+	h1 := newHost("1.1.1.1:9982")
+	h2 := newHost("1.1.1.2:9982")
+	h3 := newHost("1.1.1.3:9982")
+	h4 := newHost("2.2.2.2:9982")
+	h5 := newHost("2.2.2.3:9982")
+	h6 := newHost("3.3.3.3:9982")          // unknown country
+	h7 := newHost("9.9.9.9:9982")          // lookup fails
+	h8 := newHost("host.example.com:9982") // no ip
+	hosts := []api.Host{h1, h2, h3, h4, h5, h6, h7, h8}
+	contracted := map[types.PublicKey]struct{}{
+		h1.PublicKey: {},
+		h2.PublicKey: {},
+		h4.PublicKey: {},
+	}
+
+	// assert all hosts have a score of 1 if geo-scoring is disabled
+	scores := geoScorer{maxFractionPerCountry: 0.5}.scores(context.Background(), hosts, contracted, 4, zap.NewNop().Sugar())
+	for _, h := range hosts {
+		if scores[h.PublicKey] != 1 {
+			t.Fatal("unexpected score", scores[h.PublicKey])
+		}
+	}
+
+	// assert hosts in an overrepresented country are penalized, we want 4
+	// contracts and 2 of them are with hosts in DE so forming another contract
+	// with a host in DE would exceed the max fraction
+	gs := geoScorer{locator: locator, maxFractionPerCountry: 0.5}
+	scores = gs.scores(context.Background(), hosts, contracted, 4, zap.NewNop().Sugar())
+	for _, h := range []api.Host{h1, h2, h4, h5, h6, h7, h8} {
+		if scores[h.PublicKey] != 1 {
+			t.Fatal("unexpected score", h.NetAddress, scores[h.PublicKey])
+		}
+	}
+	if scores[h3.PublicKey] != 0.5/0.75 {
+		t.Fatal("unexpected score", scores[h3.PublicKey])
+	}
+
+	// assert contracted hosts are penalized too once the max fraction is
+	// exceeded
+	scores = gs.scores(context.Background(), hosts, contracted, 3, zap.NewNop().Sugar())
+	if scores[h1.PublicKey] != 0.5/(2.0/3) || scores[h2.PublicKey] != scores[h1.PublicKey] {
+		t.Fatal("unexpected score", scores[h1.PublicKey], scores[h2.PublicKey])
+	} else if scores[h3.PublicKey] != 0.5 {
+		t.Fatal("unexpected score", scores[h3.PublicKey])
+	} else if scores[h4.PublicKey] != 1 {
+		t.Fatal("unexpected score", scores[h4.PublicKey])
+	}
+
+	// assert a max fraction of 1 disables the penalty
+	gs.maxFractionPerCountry = 1
+	scores = gs.scores(context.Background(), hosts, contracted, 3, zap.NewNop().Sugar())
+	if scores[h3.PublicKey] != 1 {
+		t.Fatal("unexpected score", scores[h3.PublicKey])
+	}
+}
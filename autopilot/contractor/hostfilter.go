@@ -20,10 +20,12 @@ var (
 	errContractBeyondV2RequireHeight = errors.New("contract is beyond v2 require height")
 	errContractOutOfCollateral       = errors.New("contract is out of collateral")
 	errContractOutOfFunds            = errors.New("contract is out of funds")
+	errContractPriceDropped          = errors.New("host's contract price dropped")
 	errContractUpForRenewal          = errors.New("contract is up for renewal")
 	errContractRenewed               = errors.New(api.ContractArchivalReasonRenewed)
 	errContractExpired               = errors.New("contract has expired")
 	errContractNotConfirmed          = errors.New("contract hasn't been confirmed on chain in time")
+	errHostStorageFullyAllocated     = errors.New("host's storage is fully allocated")
 )
 
 type unusableHostsBreakdown struct {
@@ -149,6 +151,48 @@ func isUpForRenewal(cfg api.AutopilotConfig, endHeight, blockHeight uint64) (sho
 	return
 }
 
+// isStorageFullyAllocated returns true if the data we store with the host
+// exceeds maxAllocatedStoragePct of the host's total storage.
+func isStorageFullyAllocated(h api.Host) bool {
+	return h.Checks.AllocatedStoragePct >= maxAllocatedStoragePct
+}
+
+// shouldRenegotiatePrice returns true if any of the host's current contract,
+// storage, upload or download prices is more than renegotiationThresholdPct
+// lower than the price the contract was formed at. Prices that weren't recorded
+// when the contract was formed are ignored. Contracts younger than
+// minContractAge are never renegotiated.
+func shouldRenegotiatePrice(c contract, h api.Host, bh uint64) bool {
+	if bh < c.StartHeight+minContractAge {
+		return false
+	}
+
+	var contractPrice, storagePrice, uploadPrice, downloadPrice types.Currency
+	if h.IsV2() {
+		contractPrice = h.V2Settings.Prices.ContractPrice
+		storagePrice = h.V2Settings.Prices.StoragePrice
+		uploadPrice = h.V2Settings.Prices.IngressPrice
+		downloadPrice = h.V2Settings.Prices.EgressPrice
+	} else {
+		contractPrice = h.PriceTable.ContractPrice
+		storagePrice = h.Settings.StoragePrice
+		uploadPrice = h.Settings.UploadBandwidthPrice
+		downloadPrice = h.Settings.DownloadBandwidthPrice
+	}
+
+	dropped := func(negotiated, current types.Currency) bool {
+		if negotiated.IsZero() {
+			return false
+		}
+		threshold := negotiated.Div64(100).Mul64(100 - renegotiationThresholdPct)
+		return current.Cmp(threshold) < 0
+	}
+	return dropped(c.ContractPrice, contractPrice) ||
+		dropped(c.StoragePrice, storagePrice) ||
+		dropped(c.UploadPrice, uploadPrice) ||
+		dropped(c.DownloadPrice, downloadPrice)
+}
+
 // checkHost performs a series of checks on the host.
 func checkHost(gc gouging.Checker, sh scoredHost, minScore float64, period uint64) *api.HostChecks {
 	h := sh.host
@@ -0,0 +1,119 @@
+package contractor
+
+import (
+	"math"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/api"
+)
+
+type (
+	// HostScoreCache caches host scores across contract maintenance
+	// iterations. A host is only re-scored if any of the inputs to its score
+	// changed since it was last scored.
+	HostScoreCache struct {
+		entries map[types.PublicKey]cachedHostScore
+	}
+
+	cachedHostScore struct {
+		inputHash types.Hash256
+		sb        api.HostScoreBreakdown
+	}
+)
+
+// NewHostScoreCache returns an empty host score cache.
+func NewHostScoreCache() *HostScoreCache {
+	return &HostScoreCache{
+		entries: make(map[types.PublicKey]cachedHostScore),
+	}
+}
+
+// HostScore returns the score breakdown for the given host. The cached
+// breakdown is returned if the host's pricing, settings and interactions as
+// well as the autopilot's settings are unchanged. The age and uptime scores
+// depend on the current time and are therefore always recomputed.
+func (c *HostScoreCache) HostScore(ctx *mCtx, h api.Host) (api.HostScoreBreakdown, error) {
+	inputHash := hostScoreInputHash(ctx.AutopilotConfig(), ctx.state.GS, h, ctx.state.RS.Redundancy())
+	if entry, ok := c.entries[h.PublicKey]; ok && entry.inputHash == inputHash {
+		sb := entry.sb
+		sb.Age = ageScore(h)
+		sb.Uptime = clampScore(uptimeScore(h))
+		return sb, nil
+	}
+
+	sb, err := ctx.HostScore(h)
+	if err != nil {
+		delete(c.entries, h.PublicKey)
+		return api.HostScoreBreakdown{}, err
+	}
+	c.entries[h.PublicKey] = cachedHostScore{
+		inputHash: inputHash,
+		sb:        sb,
+	}
+	return sb, nil
+}
+
+// Prune removes the cached scores of all hosts that are not in the given set
+// of hosts.
+func (c *HostScoreCache) Prune(hosts []api.Host) {
+	keep := make(map[types.PublicKey]struct{}, len(hosts))
+	for _, h := range hosts {
+		keep[h.PublicKey] = struct{}{}
+	}
+	for hk := range c.entries {
+		if _, ok := keep[hk]; !ok {
+			delete(c.entries, hk)
+		}
+	}
+}
+
+// hostScoreInputHash computes a hash over all inputs of hostScore that don't
+// depend on the current time. Volatile fields that don't affect the score,
+// such as the price table's UID or the host's block height, are deliberately
+// left out.
+func hostScoreInputHash(cfg api.AutopilotConfig, gs api.GougingSettings, h api.Host, expectedRedundancy float64) types.Hash256 {
+	hasher := types.NewHasher()
+	e := hasher.E
+
+	// autopilot settings
+	e.WriteUint64(cfg.Contracts.Amount)
+	e.WriteUint64(cfg.Contracts.Period)
+	e.WriteUint64(cfg.Contracts.Storage)
+	e.WriteString(cfg.Hosts.MinProtocolVersion)
+	types.V2Currency(gs.MaxDownloadPrice).EncodeTo(e)
+	types.V2Currency(gs.MaxUploadPrice).EncodeTo(e)
+	types.V2Currency(gs.MaxStoragePrice).EncodeTo(e)
+	e.WriteUint64(math.Float64bits(expectedRedundancy))
+
+	// host pricing and settings
+	e.WriteBool(h.IsV2())
+	if h.IsV2() {
+		types.V2Currency(h.V2Settings.Prices.Collateral).EncodeTo(e)
+		types.V2Currency(h.V2Settings.Prices.StoragePrice).EncodeTo(e)
+		types.V2Currency(h.V2Settings.Prices.IngressPrice).EncodeTo(e)
+		types.V2Currency(h.V2Settings.Prices.EgressPrice).EncodeTo(e)
+		types.V2Currency(h.V2Settings.MaxCollateral).EncodeTo(e)
+		e.WriteUint64(h.V2Settings.RemainingStorage)
+		e.Write(h.V2Settings.ProtocolVersion[:])
+	} else {
+		pt := h.PriceTable
+		types.V2Currency(pt.InitBaseCost).EncodeTo(e)
+		types.V2Currency(pt.DownloadBandwidthCost).EncodeTo(e)
+		types.V2Currency(pt.UploadBandwidthCost).EncodeTo(e)
+		types.V2Currency(pt.ReadBaseCost).EncodeTo(e)
+		types.V2Currency(pt.ReadLengthCost).EncodeTo(e)
+		types.V2Currency(pt.WriteBaseCost).EncodeTo(e)
+		types.V2Currency(pt.WriteLengthCost).EncodeTo(e)
+		types.V2Currency(pt.WriteStoreCost).EncodeTo(e)
+		types.V2Currency(pt.CollateralCost).EncodeTo(e)
+		types.V2Currency(pt.MaxCollateral).EncodeTo(e)
+		e.WriteUint64(h.Settings.RemainingStorage)
+		e.WriteString(h.Settings.Version)
+	}
+
+	// host stats
+	e.WriteUint64(h.StoredData)
+	e.WriteUint64(math.Float64bits(h.Interactions.SuccessfulInteractions))
+	e.WriteUint64(math.Float64bits(h.Interactions.FailedInteractions))
+	return hasher.Sum()
+}
@@ -0,0 +1,89 @@
+package contractor
+
+import (
+	"context"
+	"testing"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/api"
+	"go.sia.tech/renterd/internal/test"
+	"lukechampine.com/frand"
+)
+
+func TestHostScoreCache(t *testing.T) {
+	ctx := newMaintenanceCtx(context.Background(), &MaintenanceState{
+		AP: cfg,
+		GS: test.GougingSettings,
+		RS: test.RedundancySettings,
+	})
+	c := NewHostScoreCache()
+
+	// helper to mark the cached score of a host so we can tell whether the
+	// host was re-scored
+	const marker = 0.123
+	mark := func(hk types.PublicKey) {
+		t.Helper()
+		entry, ok := c.entries[hk]
+		if !ok {
+			t.Fatal("expected host to be cached")
+		}
+		entry.sb.Prices = marker
+		c.entries[hk] = entry
+	}
+	assertCached := func(h api.Host, cached bool) {
+		t.Helper()
+		sb, err := c.HostScore(ctx, h)
+		if err != nil {
+			t.Fatal(err)
+		} else if cached && sb.Prices != marker {
+			t.Fatal("expected cached score")
+		} else if !cached && sb.Prices == marker {
+			t.Fatal("expected host to be re-scored")
+		} else if sb.Age != ageScore(h) || sb.Uptime != clampScore(uptimeScore(h)) {
+			t.Fatal("expected age and uptime to be recomputed")
+		}
+	}
+
+	// score a host
+	h := test.NewHost(test.RandomHostKey(), test.NewHostPriceTable(), test.NewHostSettings())
+	sb, err := c.HostScore(ctx, h)
+	if err != nil {
+		t.Fatal(err)
+	} else if sb != hostScore(cfg, test.GougingSettings, h, test.RedundancySettings.Redundancy()) {
+		t.Fatal("unexpected score", sb)
+	}
+
+	// assert the score is cached
+	mark(h.PublicKey)
+	assertCached(h, true)
+
+	// update fields that don't affect the score, the score should be cached
+	h.PriceTable.UID = frand.Entropy128()
+	h.PriceTable.HostBlockHeight++
+	h.Interactions.TotalScans++
+	assertCached(h, true)
+
+	// update the host's pricing, the host should be re-scored
+	h.PriceTable.WriteStoreCost = h.PriceTable.WriteStoreCost.Mul64(2)
+	assertCached(h, false)
+
+	// update the host's interactions, the host should be re-scored
+	mark(h.PublicKey)
+	h.Interactions.FailedInteractions++
+	assertCached(h, false)
+
+	// update the gouging settings, the host should be re-scored
+	mark(h.PublicKey)
+	ctx.state.GS.MaxStoragePrice = ctx.state.GS.MaxStoragePrice.Mul64(2)
+	assertCached(h, false)
+
+	// prune the cache
+	c.Prune([]api.Host{h})
+	if len(c.entries) != 1 {
+		t.Fatal("expected host to remain cached")
+	}
+	c.Prune(nil)
+	if len(c.entries) != 0 {
+		t.Fatal("expected cache to be empty")
+	}
+}
@@ -0,0 +1,58 @@
+package contractor
+
+import (
+	"math/big"
+	"time"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/api"
+)
+
+const (
+	// maxPriceChangePct is the percentage by which a host's upload or
+	// download price can change within priceChangeWindow before we consider
+	// the host's pricing to be unstable.
+	maxPriceChangePct = 50
+
+	// priceChangeWindow is the period of time over which we look at a host's
+	// price changes to determine whether its pricing is unstable.
+	priceChangeWindow = 24 * time.Hour
+
+	// unstablePricingPenalty is the factor by which the price score of a host
+	// with unstable pricing is multiplied.
+	unstablePricingPenalty = 0.5
+)
+
+// hasUnstablePricing returns true if the upload or download price in the given
+// price history changed by more than maxPriceChangePct.
+func hasUnstablePricing(history []api.HostPriceChange) bool {
+	if len(history) < 2 {
+		return false
+	}
+
+	uploadPrices := make([]types.Currency, len(history))
+	downloadPrices := make([]types.Currency, len(history))
+	for i, change := range history {
+		uploadPrices[i] = change.UploadPrice
+		downloadPrices[i] = change.DownloadPrice
+	}
+	return exceedsMaxPriceChange(uploadPrices) || exceedsMaxPriceChange(downloadPrices)
+}
+
+// exceedsMaxPriceChange returns true if the difference between the highest and
+// the lowest price exceeds maxPriceChangePct of the lowest price.
+func exceedsMaxPriceChange(prices []types.Currency) bool {
+	lowest, highest := prices[0], prices[0]
+	for _, p := range prices[1:] {
+		if p.Cmp(lowest) < 0 {
+			lowest = p
+		}
+		if p.Cmp(highest) > 0 {
+			highest = p
+		}
+	}
+
+	change := new(big.Int).Mul(highest.Sub(lowest).Big(), big.NewInt(100))
+	threshold := new(big.Int).Mul(lowest.Big(), big.NewInt(maxPriceChangePct))
+	return change.Cmp(threshold) > 0
+}
@@ -0,0 +1,36 @@
+package contractor
+
+import (
+	"testing"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/api"
+)
+
+func TestHasUnstablePricing(t *testing.T) {
+	change := func(upload, download uint64) api.HostPriceChange {
+		return api.HostPriceChange{
+			UploadPrice:   types.NewCurrency64(upload),
+			DownloadPrice: types.NewCurrency64(download),
+		}
+	}
+
+	for _, tc := range []struct {
+		history  []api.HostPriceChange
+		expected bool
+	}{
+		{nil, false},
+		{[]api.HostPriceChange{change(100, 100)}, false},
+		{[]api.HostPriceChange{change(100, 100), change(150, 100)}, false}, // exactly 50%
+		{[]api.HostPriceChange{change(100, 100), change(151, 100)}, true},
+		{[]api.HostPriceChange{change(100, 100), change(100, 151)}, true},
+		{[]api.HostPriceChange{change(100, 100), change(140, 100), change(90, 100)}, true}, // 55% between min and max
+		{[]api.HostPriceChange{change(100, 100), change(49, 100)}, true},
+		{[]api.HostPriceChange{change(0, 0), change(0, 0)}, false},
+		{[]api.HostPriceChange{change(0, 0), change(1, 0)}, true},
+	} {
+		if result := hasUnstablePricing(tc.history); result != tc.expected {
+			t.Fatalf("unexpected result for history %+v: %v", tc.history, result)
+		}
+	}
+}
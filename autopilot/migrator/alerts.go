@@ -12,7 +12,6 @@ import (
 )
 
 var (
-	alertHealthRefreshID     = alerts.RandomAlertID() // constant until restarted
 	alertMigrationID         = alerts.RandomAlertID() // constant until restarted
 	alertOngoingMigrationsID = alerts.RandomAlertID() // constant until restarted
 )
@@ -71,15 +70,3 @@ func newOngoingMigrationsAlert(n int, estimate time.Duration) alerts.Alert {
 		Data:      data,
 	}
 }
-
-func newRefreshHealthFailedAlert(err error) alerts.Alert {
-	return alerts.Alert{
-		ID:       alertHealthRefreshID,
-		Severity: alerts.SeverityCritical,
-		Message:  "Health refresh failed",
-		Data: map[string]interface{}{
-			"error": err.Error(),
-		},
-		Timestamp: time.Now(),
-	}
-}
@@ -33,6 +33,14 @@ const (
 	// migratorBatchSize is the amount of slabs we fetch for migration from the
 	// slab store at once
 	migratorBatchSize = math.MaxInt // TODO: change once we have a fix for the infinite loop
+
+	// migrationRetryBaseBackoff is the time we wait before retrying a failed
+	// migration for the first time, it doubles with every attempt
+	migrationRetryBaseBackoff = 5 * time.Second
+
+	// migrationRetryMaxBackoff is the maximum time we wait before retrying a
+	// failed migration
+	migrationRetryMaxBackoff = 5 * time.Minute
 )
 
 type (
@@ -44,9 +52,11 @@ type (
 		AddUploadingSectors(ctx context.Context, uID api.UploadID, root []types.Hash256) error
 		AcquireContract(ctx context.Context, fcid types.FileContractID, priority int, d time.Duration) (lockID uint64, err error)
 		ConsensusState(ctx context.Context) (api.ConsensusState, error)
+		Contract(ctx context.Context, id types.FileContractID) (api.ContractMetadata, error)
 		Contracts(ctx context.Context, opts api.ContractsOpts) ([]api.ContractMetadata, error)
 		DeleteHostSector(ctx context.Context, hk types.PublicKey, root types.Hash256) error
 		FetchPartialSlab(ctx context.Context, key object.EncryptionKey, offset, length uint32) ([]byte, error)
+		FindAlternativeContracts(ctx context.Context, root types.Hash256) ([]types.FileContractID, error)
 		FinishUpload(ctx context.Context, uID api.UploadID) error
 		FundAccount(ctx context.Context, account rhpv3.Account, fcid types.FileContractID, amount types.Currency) (types.Currency, error)
 		GougingParams(ctx context.Context) (api.GougingParams, error)
@@ -55,6 +65,7 @@ type (
 		MarkPackedSlabsUploaded(ctx context.Context, slabs []api.UploadedPackedSlab) error
 		Objects(ctx context.Context, prefix string, opts api.ListObjectOptions) (resp api.ObjectsResponse, err error)
 		RecordContractSpending(ctx context.Context, records []api.ContractSpendingRecord) error
+		RecordSlabDownloadMetric(ctx context.Context, metrics ...api.SlabDownloadMetric) error
 		ReleaseContract(ctx context.Context, fcid types.FileContractID, lockID uint64) (err error)
 		RenewedContract(ctx context.Context, renewedFrom types.FileContractID) (api.ContractMetadata, error)
 		Slab(ctx context.Context, key object.EncryptionKey) (object.Slab, error)
@@ -69,13 +80,14 @@ type (
 		Migrate(ctx context.Context)
 		SignalMaintenanceFinished()
 		Status() (bool, time.Time)
-		Stop()
+		Stop(ctx context.Context)
 	}
 
 	SlabStore interface {
 		RefreshHealth(ctx context.Context) error
 		Slab(ctx context.Context, key object.EncryptionKey) (object.Slab, error)
-		SlabsForMigration(ctx context.Context, healthCutoff float64, limit int) ([]api.UnhealthySlab, error)
+		RecordSlabFailure(ctx context.Context, key object.EncryptionKey) error
+		SlabsForMigration(ctx context.Context, healthCutoff float64, maxLastFailure time.Duration, limit int) ([]api.UnhealthySlab, error)
 	}
 )
 
@@ -85,13 +97,16 @@ type (
 		bus    Bus
 		ss     SlabStore
 
-		healthCutoff float64
-		numThreads   uint64
+		healthCutoff    float64
+		failureCooldown time.Duration
+		maxAttempts     uint64
+		numThreads      uint64
 
-		accounts        *accounts.Manager
-		downloadManager *download.Manager
-		uploadManager   *upload.Manager
-		hostManager     hosts.Manager
+		accounts                *accounts.Manager
+		downloadManager         *download.Manager
+		downloadMetricsRecorder download.MetricsRecorder
+		uploadManager           *upload.Manager
+		hostManager             hosts.Manager
 
 		rhp4Client *rhp4.Client
 
@@ -111,15 +126,17 @@ type (
 	}
 )
 
-func New(ctx context.Context, masterKey utils.MasterKey, alerts alerts.Alerter, ss SlabStore, b Bus, healthCutoff float64, numThreads, downloadMaxOverdrive, uploadMaxOverdrive uint64, downloadOverdriveTimeout, uploadOverdriveTimeout, accountsRefillInterval time.Duration, logger *zap.Logger) (*migrator, error) {
+func New(ctx context.Context, masterKey utils.MasterKey, alerts alerts.Alerter, ss SlabStore, b Bus, healthCutoff float64, failureCooldown time.Duration, maxAttempts, numThreads, downloadMaxOverdrive, uploadMaxOverdrive uint64, downloadOverdriveTimeout, uploadOverdriveTimeout, accountsRefillInterval time.Duration, logger *zap.Logger) (*migrator, error) {
 	logger = logger.Named("migrator")
 	m := &migrator{
 		alerts: alerts,
 		bus:    b,
 		ss:     ss,
 
-		healthCutoff: healthCutoff,
-		numThreads:   numThreads,
+		healthCutoff:    healthCutoff,
+		failureCooldown: failureCooldown,
+		maxAttempts:     max(maxAttempts, 1),
+		numThreads:      numThreads,
 
 		signalConsensusNotSynced:  make(chan struct{}, 1),
 		signalMaintenanceFinished: make(chan struct{}, 1),
@@ -143,14 +160,15 @@ func New(ctx context.Context, masterKey utils.MasterKey, alerts alerts.Alerter,
 	m.accounts = am
 
 	// create host manager
-	dialer := rhp.NewFallbackDialer(b, net.Dialer{}, logger)
+	dialer := rhp.NewFallbackDialer(b, net.Dialer{}, rhp.BandwidthConfig{}, rhp.DefaultHostCacheTTL, logger)
 	csr := contracts.NewSpendingRecorder(ctx, b, 5*time.Second, logger)
 	m.hostManager = hosts.NewManager(masterKey, am, csr, dialer, logger)
 	m.rhp4Client = rhp4.New(dialer)
 
 	// create upload & download manager
 	mm := memory.NewManager(math.MaxInt64, logger)
-	m.downloadManager = download.NewManager(ctx, &uk, m.hostManager, mm, b, downloadMaxOverdrive, downloadOverdriveTimeout, logger)
+	m.downloadMetricsRecorder = download.NewMetricsRecorder(ctx, b, 5*time.Second, logger)
+	m.downloadManager = download.NewManager(ctx, &uk, m.hostManager, mm, m.downloadMetricsRecorder, b, downloadMaxOverdrive, downloadOverdriveTimeout, logger)
 	m.uploadManager = upload.NewManager(ctx, &uk, m.hostManager, mm, b, b, b, uploadMaxOverdrive, uploadOverdriveTimeout, logger)
 
 	return m, nil
@@ -176,8 +194,9 @@ func (m *migrator) Migrate(ctx context.Context) {
 	}()
 }
 
-func (m *migrator) Stop() {
+func (m *migrator) Stop(ctx context.Context) {
 	m.wg.Wait()
+	m.downloadMetricsRecorder.Stop(ctx)
 }
 
 func (m *migrator) SignalMaintenanceFinished() {
@@ -212,6 +231,8 @@ func (m *migrator) performMigrations(ctx context.Context) {
 
 	// prepare jobs channel
 	jobs := make(chan api.UnhealthySlab)
+	retries := newMigrationRetries()
+	var inflight sync.WaitGroup
 	var wg sync.WaitGroup
 	defer func() {
 		close(jobs)
@@ -227,8 +248,26 @@ func (m *migrator) performMigrations(ctx context.Context) {
 			// process jobs
 			for j := range jobs {
 				start := time.Now()
-				err := m.migrateSlab(ctx, j.EncryptionKey)
+				attempt := retries.attempts(j.EncryptionKey) + 1
+				retry, err := m.migrateSlabAttempt(ctx, j, attempt)
 				m.statsSlabMigrationSpeedMS.Track(float64(time.Since(start).Milliseconds()))
+
+				// re-queue the slab if it has attempts left
+				if retry {
+					backoff := migrationRetryBackoff(attempt)
+					m.logger.Warnw("slab migration failed, retrying",
+						zap.Error(err),
+						zap.Stringer("slab", j.EncryptionKey),
+						zap.Uint64("attempt", attempt),
+						zap.Duration("backoff", backoff),
+					)
+					retries.schedule(j.EncryptionKey, attempt, time.Now().Add(backoff))
+					inflight.Done()
+					continue
+				}
+				retries.remove(j.EncryptionKey)
+				inflight.Done()
+
 				if utils.IsErr(err, api.ErrConsensusNotSynced) {
 					// interrupt migrations if consensus is not synced
 					select {
@@ -240,6 +279,17 @@ func (m *migrator) performMigrations(ctx context.Context) {
 					m.logger.Errorw("migration failed",
 						zap.Float64("health", j.Health),
 						zap.Stringer("slab", j.EncryptionKey))
+
+					// record the failure to skip the slab for the duration of
+					// the failure cooldown, this prevents us from retrying the
+					// same failing migrations over and over again
+					if utils.IsErr(err, api.ErrSlabNotFound) {
+						continue
+					} else if err := m.ss.RecordSlabFailure(ctx, j.EncryptionKey); err != nil {
+						m.logger.Errorw("failed to record slab failure",
+							zap.Error(err),
+							zap.Stringer("slab", j.EncryptionKey))
+					}
 				}
 			}
 		}()
@@ -255,7 +305,7 @@ func (m *migrator) performMigrations(ctx context.Context) {
 	// helper to update 'toMigrate'
 	updateToMigrate := func() {
 		// fetch slabs for migration
-		toMigrateNew, err := m.ss.SlabsForMigration(ctx, m.healthCutoff, migratorBatchSize)
+		toMigrateNew, err := m.ss.SlabsForMigration(ctx, m.healthCutoff, m.failureCooldown, migratorBatchSize)
 		if err != nil {
 			m.logger.Errorf("failed to fetch slabs for migration, err: %v", err)
 			return
@@ -287,9 +337,12 @@ func (m *migrator) performMigrations(ctx context.Context) {
 			toMigrate = append(toMigrate, *slab)
 		}
 
-		// sort the newly added slabs by health
+		// sort the newly added slabs by priority and health
 		newSlabs := toMigrate[len(toMigrate)-len(migrateNewMap):]
 		sort.Slice(newSlabs, func(i, j int) bool {
+			if newSlabs[i].Priority != newSlabs[j].Priority {
+				return newSlabs[i].Priority > newSlabs[j].Priority
+			}
 			return newSlabs[i].Health < newSlabs[j].Health
 		})
 	}
@@ -301,21 +354,26 @@ func (m *migrator) performMigrations(ctx context.Context) {
 		}
 	}()
 
+	// when woken up to retry failed migrations, the slabs aren't refetched
+	update := true
+
 OUTER:
 	for {
-		// recompute health.
-		start := time.Now()
-		if err := m.ss.RefreshHealth(ctx); err != nil {
-			if err := m.alerts.RegisterAlert(ctx, newRefreshHealthFailedAlert(err)); err != nil {
-				m.logger.Errorf("failed to register alert: %v", err)
-			}
-			m.logger.Errorf("failed to recompute cached health before migration: %v", err)
+		// refresh the health and fetch the slabs to migrate, the refresh goes
+		// through the bus' health refresh scheduler which also refreshes it
+		// periodically, but we don't want to wait for it after contract
+		// maintenance
+		if !update {
+			update = true
 		} else {
-			if err := m.alerts.DismissAlerts(ctx, alertHealthRefreshID); err != nil {
-				m.logger.Errorf("failed to dismiss alert: %v", err)
+			start := time.Now()
+			if err := m.ss.RefreshHealth(ctx); err != nil {
+				m.logger.Errorf("failed to recompute cached health before migration: %v", err)
+			} else {
+				m.logger.Infof("recomputed slab health in %v", time.Since(start))
 			}
-			m.logger.Infof("recomputed slab health in %v", time.Since(start))
 			updateToMigrate()
+			retries.prune(toMigrate)
 		}
 
 		// log the updated list of slabs to migrate
@@ -328,6 +386,11 @@ OUTER:
 
 		var lastRegister time.Time
 		for i, slab := range toMigrate {
+			// skip slabs that are backing off after a failed attempt
+			if !retries.ready(slab.EncryptionKey, time.Now()) {
+				continue
+			}
+
 			if time.Since(lastRegister) > migrationAlertRegisterInterval {
 				// register an alert to notify users about ongoing migrations
 				remaining := len(toMigrate) - i
@@ -336,20 +399,49 @@ OUTER:
 				}
 				lastRegister = time.Now()
 			}
+			inflight.Add(1)
 			select {
 			case <-ctx.Done():
+				inflight.Done()
 				return
 			case <-m.signalConsensusNotSynced:
+				inflight.Done()
 				m.logger.Info("migrations interrupted - consensus is not synced")
 				return
 			case <-m.signalMaintenanceFinished:
+				inflight.Done()
 				m.logger.Info("migrations interrupted - updating slabs for migration")
 				continue OUTER
 			case jobs <- slab:
 			}
 		}
 
-		// all slabs migrated
-		return
+		// wait for the ongoing migrations, failed slabs are re-queued
+		inflight.Wait()
+
+		// only keep the slabs that are waiting to be retried, slabs that were
+		// migrated or ran out of attempts aren't dispatched again until the
+		// next time the slabs are fetched from the bus
+		toMigrate = retries.pending(toMigrate)
+
+		// all slabs migrated if there is nothing left to retry
+		next, ok := retries.next()
+		if !ok {
+			return
+		}
+
+		// wait until the next slab is ready to be retried
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.signalConsensusNotSynced:
+			m.logger.Info("migrations interrupted - consensus is not synced")
+			return
+		case <-m.signalMaintenanceFinished:
+			m.logger.Info("migrations interrupted - updating slabs for migration")
+			continue OUTER
+		case <-time.After(time.Until(next)):
+			update = false
+		}
 	}
 }
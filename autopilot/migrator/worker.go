@@ -3,6 +3,9 @@ package migrator
 import (
 	"context"
 	"fmt"
+	"math/rand"
+	"sync"
+	"time"
 
 	rhpv2 "go.sia.tech/core/rhp/v2"
 
@@ -16,6 +19,21 @@ import (
 	"go.uber.org/zap"
 )
 
+type (
+	// migrationRetries keeps track of slabs whose migration failed, rather
+	// than blocking a worker for the duration of the backoff, failed slabs
+	// are re-queued once their backoff has passed.
+	migrationRetries struct {
+		mu      sync.Mutex
+		retries map[object.EncryptionKey]migrationRetry
+	}
+
+	migrationRetry struct {
+		attempts  uint64
+		notBefore time.Time
+	}
+)
+
 func (m *migrator) migrateSlab(ctx context.Context, key object.EncryptionKey) error {
 	// fetch slab
 	slab, err := m.ss.Slab(ctx, key)
@@ -66,28 +84,39 @@ func (m *migrator) migrateSlab(ctx context.Context, key object.EncryptionKey) er
 		}
 	}
 
-	// migrate the slab and handle alerts
-	err = m.migrate(ctx, slab, dlHosts, ulHosts, up.CurrentHeight)
-	if err != nil && !utils.IsErr(err, api.ErrSlabNotFound) {
-		var objects []api.ObjectMetadata
-		if res, err := m.bus.Objects(ctx, "", api.ListObjectOptions{SlabEncryptionKey: slab.EncryptionKey}); err != nil {
-			m.logger.Errorf("failed to list objects for slab key; %v", err)
-		} else {
-			objects = res.Objects
-		}
-		m.alerts.RegisterAlert(ctx, newMigrationFailedAlert(slab.EncryptionKey, slab.Health, objects, err))
-	} else if err == nil {
+	// migrate the slab
+	return m.migrate(ctx, slab, dlHosts, ulHosts, up.CurrentHeight)
+}
+
+// migrateSlabAttempt performs a single migration attempt for the given slab.
+// If the attempt fails and the slab has attempts left, retry is true and the
+// slab should be re-queued once its backoff has passed. If the migration still
+// fails after the maximum number of attempts an alert is registered.
+func (m *migrator) migrateSlabAttempt(ctx context.Context, slab api.UnhealthySlab, attempt uint64) (retry bool, err error) {
+	err = m.migrateSlab(ctx, slab.EncryptionKey)
+	if err == nil {
 		m.alerts.DismissAlerts(ctx, alerts.IDForSlab(alertMigrationID, slab.EncryptionKey))
+		return false, nil
+	} else if utils.IsErr(err, api.ErrSlabNotFound) || utils.IsErr(err, api.ErrConsensusNotSynced) {
+		return false, err
+	} else if attempt < m.maxAttempts {
+		return true, err
 	}
 
-	if err != nil {
-		m.logger.Errorw("failed to migrate slab",
-			zap.Error(err),
-			zap.Stringer("slab", slab.EncryptionKey),
-		)
-		return err
+	var objects []api.ObjectMetadata
+	if res, err := m.bus.Objects(ctx, "", api.ListObjectOptions{SlabEncryptionKey: slab.EncryptionKey}); err != nil {
+		m.logger.Errorf("failed to list objects for slab key; %v", err)
+	} else {
+		objects = res.Objects
 	}
-	return nil
+	m.alerts.RegisterAlert(ctx, newMigrationFailedAlert(slab.EncryptionKey, slab.Health, objects, err))
+
+	m.logger.Errorw("failed to migrate slab",
+		zap.Error(err),
+		zap.Stringer("slab", slab.EncryptionKey),
+		zap.Uint64("attempts", attempt),
+	)
+	return false, err
 }
 
 func (m *migrator) migrate(ctx context.Context, s object.Slab, dlHosts []api.HostInfo, ulHosts []upload.HostInfo, bh uint64) error {
@@ -174,6 +203,13 @@ SHARDS:
 	}
 	s.Encrypt(shards)
 
+	// verify the reconstructed shards if the slab was uploaded with checksums
+	if s.ChecksumAlgorithm != "" {
+		if err := object.VerifySlab(s, s.ChecksumAlgorithm, shards); err != nil {
+			return fmt.Errorf("failed to verify slab for migration: %w", err)
+		}
+	}
+
 	// filter it down to the shards we need to migrate
 	for i, si := range shardIndices {
 		shards[i] = shards[si]
@@ -208,3 +244,94 @@ SHARDS:
 
 	return nil
 }
+
+// migrationRetryBackoff returns the time to wait before retrying a migration
+// after the given attempt failed. The backoff doubles with every attempt, is
+// capped at migrationRetryMaxBackoff and is jittered by up to 50% to avoid
+// retrying many slabs at once.
+func migrationRetryBackoff(attempt uint64) time.Duration {
+	backoff := migrationRetryMaxBackoff
+	if attempt < 32 {
+		backoff = min(migrationRetryBaseBackoff<<(attempt-1), migrationRetryMaxBackoff)
+	}
+	return backoff + time.Duration(rand.Int63n(int64(backoff/2)+1))
+}
+
+// newMigrationRetries creates an empty set of migration retries.
+func newMigrationRetries() *migrationRetries {
+	return &migrationRetries{
+		retries: make(map[object.EncryptionKey]migrationRetry),
+	}
+}
+
+// attempts returns the number of failed attempts for the given slab.
+func (mr *migrationRetries) attempts(key object.EncryptionKey) uint64 {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	return mr.retries[key].attempts
+}
+
+// schedule records a failed attempt for the given slab, the slab isn't ready
+// to be migrated again before the given time.
+func (mr *migrationRetries) schedule(key object.EncryptionKey, attempts uint64, notBefore time.Time) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	mr.retries[key] = migrationRetry{attempts: attempts, notBefore: notBefore}
+}
+
+// remove removes the given slab.
+func (mr *migrationRetries) remove(key object.EncryptionKey) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	delete(mr.retries, key)
+}
+
+// ready returns whether the given slab is ready to be migrated.
+func (mr *migrationRetries) ready(key object.EncryptionKey, now time.Time) bool {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	r, ok := mr.retries[key]
+	return !ok || !now.Before(r.notBefore)
+}
+
+// pending returns the slabs that are waiting to be retried.
+func (mr *migrationRetries) pending(slabs []api.UnhealthySlab) []api.UnhealthySlab {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	var pending []api.UnhealthySlab
+	for _, slab := range slabs {
+		if _, ok := mr.retries[slab.EncryptionKey]; ok {
+			pending = append(pending, slab)
+		}
+	}
+	return pending
+}
+
+// next returns the earliest time at which a slab is ready to be retried, ok
+// is false if there are no retries.
+func (mr *migrationRetries) next() (next time.Time, ok bool) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	for _, r := range mr.retries {
+		if !ok || r.notBefore.Before(next) {
+			next, ok = r.notBefore, true
+		}
+	}
+	return
+}
+
+// prune removes the retries of all slabs that aren't in the given list of
+// slabs.
+func (mr *migrationRetries) prune(slabs []api.UnhealthySlab) {
+	keep := make(map[object.EncryptionKey]struct{}, len(slabs))
+	for _, slab := range slabs {
+		keep[slab.EncryptionKey] = struct{}{}
+	}
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	for key := range mr.retries {
+		if _, ok := keep[key]; !ok {
+			delete(mr.retries, key)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package migrator
+
+import (
+	"testing"
+	"time"
+
+	"go.sia.tech/renterd/api"
+	"go.sia.tech/renterd/object"
+)
+
+func TestMigrationRetriesPending(t *testing.T) {
+	migrated := api.UnhealthySlab{EncryptionKey: object.GenerateEncryptionKey(object.EncryptionKeyTypeSalted)}
+	exhausted := api.UnhealthySlab{EncryptionKey: object.GenerateEncryptionKey(object.EncryptionKeyTypeSalted)}
+	failed := api.UnhealthySlab{EncryptionKey: object.GenerateEncryptionKey(object.EncryptionKeyTypeSalted)}
+	slabs := []api.UnhealthySlab{migrated, exhausted, failed}
+
+	// the first slab migrates, the second one runs out of attempts and the
+	// third one is waiting to be retried
+	mr := newMigrationRetries()
+	mr.schedule(exhausted.EncryptionKey, 1, time.Now())
+	mr.remove(exhausted.EncryptionKey)
+	mr.schedule(failed.EncryptionKey, 1, time.Now().Add(time.Hour))
+
+	// assert only the failed slab is dispatched again
+	pending := mr.pending(slabs)
+	if len(pending) != 1 || pending[0].EncryptionKey != failed.EncryptionKey {
+		t.Fatal("unexpected pending slabs", pending)
+	} else if mr.ready(failed.EncryptionKey, time.Now()) {
+		t.Fatal("slab shouldn't be ready yet")
+	} else if !mr.ready(failed.EncryptionKey, time.Now().Add(time.Hour)) {
+		t.Fatal("slab should be ready")
+	} else if n := mr.attempts(failed.EncryptionKey); n != 1 {
+		t.Fatal("unexpected attempts", n)
+	}
+
+	// once the retry succeeds there is nothing left to dispatch
+	mr.remove(failed.EncryptionKey)
+	if pending := mr.pending(pending); len(pending) != 0 {
+		t.Fatal("unexpected pending slabs", pending)
+	} else if _, ok := mr.next(); ok {
+		t.Fatal("unexpected retry")
+	}
+}
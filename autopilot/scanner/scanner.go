@@ -28,6 +28,10 @@ type (
 	}
 
 	Scanner interface {
+		NextScan() time.Time
+		Pause()
+		Paused() bool
+		Resume()
 		Scan(ctx context.Context, hs HostScanner, force bool)
 		Shutdown(ctx context.Context) error
 		Status() (bool, time.Time)
@@ -57,6 +61,7 @@ type (
 		scanningLastStart time.Time
 
 		interruptChan chan struct{}
+		paused        atomic.Bool
 	}
 
 	scanJob struct {
@@ -111,7 +116,13 @@ func (s *scanner) Scan(ctx context.Context, hs HostScanner, force bool) {
 		defer s.wg.Done()
 
 		scanned := s.scanHosts(ctx, hs, cutoff)
-		removed := s.removeOfflineHosts(ctx)
+
+		// don't remove offline hosts if the scan was paused, the scan might
+		// not have reached all hosts
+		var removed uint64
+		if !s.Paused() {
+			removed = s.removeOfflineHosts(ctx)
+		}
 
 		s.mu.Lock()
 		defer s.mu.Unlock()
@@ -126,6 +137,41 @@ func (s *scanner) Scan(ctx context.Context, hs HostScanner, force bool) {
 	}()
 }
 
+// NextScan returns the earliest time at which the next host scan can be
+// started, it returns the zero time if the scanner is paused.
+func (s *scanner) NextScan() time.Time {
+	if s.Paused() {
+		return time.Time{}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.scanningLastStart.IsZero() {
+		return time.Now()
+	}
+	return s.scanningLastStart.Add(s.scanInterval)
+}
+
+// Pause pauses the scanner, an ongoing scan is interrupted and no new scans
+// are started until the scanner is resumed.
+func (s *scanner) Pause() {
+	if !s.paused.Swap(true) {
+		s.logger.Info("scanner paused")
+	}
+}
+
+// Paused returns whether the scanner is paused.
+func (s *scanner) Paused() bool {
+	return s.paused.Load()
+}
+
+// Resume resumes a paused scanner, allowing new scans to be started.
+func (s *scanner) Resume() {
+	if s.paused.Swap(false) {
+		s.logger.Info("scanner resumed")
+	}
+}
+
 func (s *scanner) Shutdown(ctx context.Context) error {
 	waitChan := make(chan struct{})
 	go func() {
@@ -188,6 +234,9 @@ func (s *scanner) scanHosts(ctx context.Context, hs HostScanner, cutoff time.Tim
 			return
 		default:
 		}
+		if s.isInterrupted() {
+			break
+		}
 
 		// synchronisation vars
 		jobs := make(chan scanJob)
@@ -211,7 +260,6 @@ func (s *scanner) scanHosts(ctx context.Context, hs HostScanner, cutoff time.Tim
 		// fetch batch
 		hosts, err := s.hs.Hosts(ctx, api.HostOptions{
 			MaxLastScan: api.TimeRFC3339(cutoff),
-			Offset:      0,
 			Limit:       s.scanBatchSize,
 		})
 		if err != nil {
@@ -244,6 +292,10 @@ func (s *scanner) scanHosts(ctx context.Context, hs HostScanner, cutoff time.Tim
 }
 
 func (s *scanner) isInterrupted() bool {
+	if s.Paused() {
+		return true
+	}
+
 	select {
 	case <-s.interruptChan:
 		return true
@@ -291,7 +343,7 @@ func (s *scanner) removeOfflineHosts(ctx context.Context) (removed uint64) {
 }
 
 func (s *scanner) canSkipScan(force bool) bool {
-	if s.isShutdown() {
+	if s.isShutdown() || s.Paused() {
 		return true
 	}
 
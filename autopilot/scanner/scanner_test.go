@@ -29,7 +29,7 @@ type mockHostStore struct {
 func (hs *mockHostStore) Hosts(ctx context.Context, opts api.HostOptions) ([]api.Host, error) {
 	hs.mu.Lock()
 	defer hs.mu.Unlock()
-	hs.scans = append(hs.scans, fmt.Sprintf("%d-%d", opts.Offset, opts.Offset+opts.Limit))
+	hs.scans = append(hs.scans, fmt.Sprint(opts.Limit))
 
 	var hosts []api.Host
 	for _, host := range hs.hosts {
@@ -39,17 +39,12 @@ func (hs *mockHostStore) Hosts(ctx context.Context, opts api.HostOptions) ([]api
 		hosts = append(hosts, host)
 	}
 
-	start := opts.Offset
-	if start > len(hosts) {
-		return nil, nil
-	}
-
-	end := opts.Offset + opts.Limit
+	end := opts.Limit
 	if end > len(hosts) {
 		end = len(hosts)
 	}
 
-	return hosts[start:end], nil
+	return hosts[:end], nil
 }
 
 func (hs *mockHostStore) RemoveOfflineHosts(ctx context.Context, maxConsecutiveScanFailures uint64, maxDowntime time.Duration) (uint64, error) {
@@ -147,7 +142,7 @@ func TestScanner(t *testing.T) {
 	// assert the scanner made 3 batch reqs
 	if scans, _ := hs.state(); len(scans) != 3 {
 		t.Fatalf("unexpected number of requests, %v != 3", len(scans))
-	} else if scans[0] != "0-40" || scans[1] != "0-40" || scans[2] != "0-40" {
+	} else if scans[0] != "40" || scans[1] != "40" || scans[2] != "40" {
 		t.Fatalf("unexpected requests, %v", scans)
 	}
 
@@ -179,3 +174,64 @@ func TestScanner(t *testing.T) {
 		t.Fatalf("unexpected removals, %v", removals)
 	}
 }
+
+func TestScannerPauseResume(t *testing.T) {
+	// create mock store
+	hs := &mockHostStore{hosts: test.NewHosts(100)}
+
+	// create test scanner
+	s, err := New(hs, testBatchSize, testNumThreads, time.Minute, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Shutdown(context.Background())
+
+	// assert the next scan can start right away
+	if s.Paused() {
+		t.Fatal("unexpected")
+	} else if s.NextScan().After(time.Now()) {
+		t.Fatal("unexpected next scan", s.NextScan())
+	}
+
+	// initiate a host scan using a worker that blocks
+	b := &mockHostScanner{
+		blockChan: make(chan struct{}),
+		hs:        hs,
+	}
+	s.Scan(context.Background(), b, false)
+
+	// pause the scanner and unblock the worker
+	s.Pause()
+	close(b.blockChan)
+	time.Sleep(time.Second)
+
+	// assert the scan was interrupted
+	if scanning, _ := s.Status(); scanning {
+		t.Fatal("unexpected")
+	} else if b.scanCount >= 100 {
+		t.Fatalf("expected scan to be interrupted, scanned %v hosts", b.scanCount)
+	} else if !s.Paused() {
+		t.Fatal("expected scanner to be paused")
+	} else if !s.NextScan().IsZero() {
+		t.Fatal("expected next scan to be zero", s.NextScan())
+	}
+
+	// assert a forced scan is skipped while paused
+	s.Scan(context.Background(), b, true)
+	if scanning, _ := s.Status(); scanning {
+		t.Fatal("unexpected")
+	}
+
+	// resume the scanner and assert a forced scan scans all hosts
+	s.Resume()
+	b.scanCount = 0
+	s.Scan(context.Background(), b, true)
+	time.Sleep(time.Second)
+	if scanning, _ := s.Status(); scanning {
+		t.Fatal("unexpected")
+	} else if b.scanCount != 100 {
+		t.Fatalf("unexpected number of scans, %v != 100", b.scanCount)
+	} else if next := s.NextScan(); next.Before(time.Now()) {
+		t.Fatal("unexpected next scan", next)
+	}
+}
@@ -1,8 +1,5 @@
 package bus
 
-// TODOs:
-// - add UPNP support
-
 import (
 	"context"
 	"errors"
@@ -11,6 +8,8 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"go.sia.tech/core/consensus"
@@ -45,6 +44,14 @@ const (
 	defaultWalletRecordMetricInterval = 5 * time.Minute
 	defaultPinUpdateInterval          = 5 * time.Minute
 	defaultPinRateWindow              = 6 * time.Hour
+	defaultForkDetectionInterval      = 10 * time.Minute
+	defaultUPnPRenewInterval          = 10 * time.Minute
+	defaultMaxSymlinkDepth            = 8
+	defaultContractsExpiringBlocks    = 144 // ~1 day
+	defaultContractRenewalCooldown    = time.Minute
+	defaultEventStreamKeepAlive       = 15 * time.Second
+	defaultSlabBufferFlushInterval    = time.Minute
+	peerPingTimeout                   = 5 * time.Second
 
 	lockingPriorityPruning   = 20
 	lockingPriorityFunding   = 40
@@ -72,6 +79,7 @@ func NewClient(addr, password string) *Client {
 type (
 	AlertManager interface {
 		alerts.Alerter
+		Incidents(since time.Time) []alerts.Incident
 		RegisterWebhookBroadcaster(b webhooks.Broadcaster)
 	}
 
@@ -80,11 +88,13 @@ type (
 		AddPoolTransactions(txns []types.Transaction) (bool, error)
 		AddV2PoolTransactions(basis types.ChainIndex, txns []types.V2Transaction) (known bool, err error)
 		Block(id types.BlockID) (types.Block, bool)
+		History() ([32]types.BlockID, error)
 		OnReorg(fn func(types.ChainIndex)) (cancel func())
 		PoolTransaction(txid types.TransactionID) (types.Transaction, bool)
 		PoolTransactions() []types.Transaction
 		V2PoolTransactions() []types.V2Transaction
 		RecommendedFee() types.Currency
+		State(id types.BlockID) (consensus.State, bool)
 		Tip() types.ChainIndex
 		TipState() consensus.State
 		UnconfirmedParents(txn types.Transaction) []types.Transaction
@@ -94,12 +104,15 @@ type (
 
 	ContractLocker interface {
 		Acquire(ctx context.Context, priority int, id types.FileContractID, d time.Duration) (uint64, error)
+		ClearContractRenewalCooldown(id types.FileContractID)
+		ContractRenewalCooldown(ctx context.Context, id types.FileContractID, d time.Duration) error
 		KeepAlive(id types.FileContractID, lockID uint64, d time.Duration) error
 		Release(id types.FileContractID, lockID uint64) error
 	}
 
 	ChainSubscriber interface {
 		ChainIndex(context.Context) (types.ChainIndex, error)
+		Lag() uint64
 		Shutdown(context.Context) error
 	}
 
@@ -119,11 +132,22 @@ type (
 		StartUpload(uID api.UploadID) error
 	}
 
+	ForkDetector interface {
+		OnCanonicalChain() bool
+		Shutdown(context.Context) error
+		Status() api.ForkStatus
+	}
+
 	PinManager interface {
 		Shutdown(context.Context) error
 		TriggerUpdate()
 	}
 
+	UPnPManager interface {
+		ExternalAddr() string
+		Shutdown(context.Context) error
+	}
+
 	Syncer interface {
 		Addr() string
 		BroadcastHeader(h types.BlockHeader)
@@ -132,6 +156,7 @@ type (
 		BroadcastV2TransactionSet(index types.ChainIndex, txns []types.V2Transaction)
 		Connect(ctx context.Context, addr string) (*syncer.Peer, error)
 		Peers() []*syncer.Peer
+		PingPeer(ctx context.Context, addr string) (time.Duration, error)
 	}
 
 	Wallet interface {
@@ -146,18 +171,22 @@ type (
 		SignTransaction(txn *types.Transaction, toSign []types.Hash256, cf types.CoveredFields)
 		SignV2Inputs(txn *types.V2Transaction, toSign []int)
 		SpendableOutputs() ([]types.SiacoinElement, error)
+		SweepDust(ctx context.Context, threshold types.Currency) ([]types.TransactionID, error)
 		Tip() types.ChainIndex
 		UnconfirmedEvents() ([]wallet.Event, error)
+		UnlockConditions() types.UnlockConditions
 		UpdateChainState(tx wallet.UpdateTx, reverted []chain.RevertUpdate, applied []chain.ApplyUpdate) error
-		Events(offset, limit int) ([]wallet.Event, error)
 	}
 
 	WebhooksManager interface {
 		webhooks.Broadcaster
+		DeadLetters(context.Context) ([]webhooks.DeadLetter, error)
 		Delete(context.Context, webhooks.Webhook) error
 		Info() ([]webhooks.Webhook, []webhooks.WebhookQueueInfo)
 		Register(context.Context, webhooks.Webhook) error
+		RetryDeadLetter(context.Context, int64) error
 		Shutdown(context.Context) error
+		Subscribe(module, event string) (<-chan webhooks.Event, func())
 	}
 
 	// Store is a collection of stores used by the bus.
@@ -166,10 +195,14 @@ type (
 		AutopilotStore
 		BackupStore
 		ChainStore
+		CompactStore
 		HostStore
 		MetadataStore
 		MetricsStore
+		RecoveryStore
 		SettingStore
+		VacuumStore
+		WalletStore
 	}
 
 	// AccountStore persists information about accounts. Since accounts
@@ -192,25 +225,43 @@ type (
 		Backup(ctx context.Context, dbID, dst string) error
 	}
 
+	// CompactStore is the interface of a store that can be compacted.
+	CompactStore interface {
+		Compact(ctx context.Context) error
+	}
+
+	// VacuumStore is the interface of a store that can be vacuumed.
+	VacuumStore interface {
+		Vacuum(ctx context.Context) error
+		VacuumSupported() bool
+	}
+
 	// A ChainStore stores information about the chain.
 	ChainStore interface {
 		ChainIndex(ctx context.Context) (types.ChainIndex, error)
 		FileContractElement(ctx context.Context, fcid types.FileContractID) (types.V2FileContractElement, error)
 		ProcessChainUpdate(ctx context.Context, applyFn func(sql.ChainUpdateTx) error) error
+		StorageProof(ctx context.Context, fcid types.FileContractID) (api.ContractStorageProof, error)
 	}
 
 	// A HostStore stores information about hosts.
 	HostStore interface {
+		DeleteHost(ctx context.Context, hk types.PublicKey) error
 		Host(ctx context.Context, hostKey types.PublicKey) (api.Host, error)
 		HostAllowlist(ctx context.Context) ([]types.PublicKey, error)
+		HostBenchmarks(ctx context.Context, hk types.PublicKey, limit int) ([]api.HostBenchmark, error)
 		HostBlocklist(ctx context.Context) ([]string, error)
+		HostPriceHistory(ctx context.Context, since time.Time) (map[types.PublicKey][]api.HostPriceChange, error)
+		HostScanHistory(ctx context.Context, hk types.PublicKey, limit int) ([]api.HostScan, error)
 		Hosts(ctx context.Context, opts api.HostOptions) ([]api.Host, error)
+		RecordHostBenchmark(ctx context.Context, hk types.PublicKey, benchmark api.HostBenchmark) error
 		RecordHostScans(ctx context.Context, scans []api.HostScan) error
 		RemoveOfflineHosts(ctx context.Context, maxConsecutiveScanFailures uint64, maxDowntime time.Duration) (uint64, error)
 		ResetLostSectors(ctx context.Context, hk types.PublicKey) error
 		UpdateHostAllowlistEntries(ctx context.Context, add, remove []types.PublicKey, clear bool) error
 		UpdateHostBlocklistEntries(ctx context.Context, add, remove []string, clear bool) error
 		UpdateHostCheck(ctx context.Context, hk types.PublicKey, check api.HostChecks) error
+		UpdateHostChecks(ctx context.Context, checks map[types.PublicKey]api.HostChecks) error
 		UsableHosts(ctx context.Context) ([]sql.HostInfo, error)
 	}
 
@@ -220,9 +271,10 @@ type (
 		AncestorContracts(ctx context.Context, fcid types.FileContractID, minStartHeight uint64) ([]api.ContractMetadata, error)
 		ArchiveContract(ctx context.Context, id types.FileContractID, reason string) error
 		ArchiveContracts(ctx context.Context, toArchive map[types.FileContractID]string) error
-		ArchiveAllContracts(ctx context.Context, reason string) error
+		ArchiveAllContracts(ctx context.Context, reason string, safe bool) error
 		Contract(ctx context.Context, id types.FileContractID) (api.ContractMetadata, error)
 		Contracts(ctx context.Context, opts api.ContractsOpts) ([]api.ContractMetadata, error)
+		ContractsExpiring(ctx context.Context, withinBlocks uint64) ([]api.ContractMetadata, error)
 		RecordContractSpending(ctx context.Context, records []api.ContractSpendingRecord) error
 		PutContract(ctx context.Context, c api.ContractMetadata) error
 		RenewedContract(ctx context.Context, renewedFrom types.FileContractID) (api.ContractMetadata, error)
@@ -232,8 +284,10 @@ type (
 		ContractSizes(ctx context.Context) (map[types.FileContractID]api.ContractSize, error)
 		ContractSize(ctx context.Context, id types.FileContractID) (api.ContractSize, error)
 		PrunableContractRoots(ctx context.Context, id types.FileContractID, roots []types.Hash256) ([]uint64, error)
+		SlabBufferDefragStats() (runs, bytesOptimized uint64)
 
 		DeleteHostSector(ctx context.Context, hk types.PublicKey, root types.Hash256) (int, error)
+		FindAlternativeContracts(ctx context.Context, root types.Hash256) ([]types.FileContractID, error)
 
 		Bucket(_ context.Context, bucketName string) (api.Bucket, error)
 		Buckets(_ context.Context) ([]api.Bucket, error)
@@ -241,21 +295,31 @@ type (
 		DeleteBucket(_ context.Context, bucketName string) error
 		UpdateBucketPolicy(ctx context.Context, bucketName string, policy api.BucketPolicy) error
 
-		CopyObject(ctx context.Context, srcBucket, dstBucket, srcKey, dstKey, mimeType string, metadata api.ObjectUserMetadata) (api.ObjectMetadata, error)
+		CopyObject(ctx context.Context, srcBucket, dstBucket, srcKey, dstKey, mimeType string, metadata api.ObjectUserMetadata, ifSourceETagMatch string) (api.ObjectMetadata, error)
 		Object(ctx context.Context, bucketName, key string) (api.Object, error)
 		Objects(ctx context.Context, bucketName, prefix, substring, delim, sortBy, sortDir, marker string, limit int, slabEncryptionKey object.EncryptionKey) (api.ObjectsResponse, error)
 		ObjectMetadata(ctx context.Context, bucketName, key string) (api.Object, error)
 		ObjectsStats(ctx context.Context, opts api.ObjectsStatsOpts) (api.ObjectsStatsResponse, error)
+		ObjectsStatsPerHost(ctx context.Context, opts api.ObjectsStatsOpts) (map[types.PublicKey]api.HostStorageStats, error)
+		ObjectTags(ctx context.Context, bucketName, key string) (api.ObjectTags, error)
+		ObjectsTags(ctx context.Context, bucketName string, keys []string) (map[string]api.ObjectTags, error)
+		ObjectVersion(ctx context.Context, bucketName, key, versionID string) (api.Object, error)
+		ObjectVersions(ctx context.Context, bucketName, key string) ([]api.ObjectVersion, error)
 		RemoveObject(ctx context.Context, bucketName, key string) error
+		RemoveObjectVersion(ctx context.Context, bucketName, key, versionID string) error
 		RemoveObjects(ctx context.Context, bucketName, prefix string) error
+		RemoveObjectsGlob(ctx context.Context, bucketName, glob string) (int, error)
+		ApplyLifecycleRules(ctx context.Context, bucketName string) (int, error)
 		RenameObject(ctx context.Context, bucketName, from, to string, force bool) error
 		RenameObjects(ctx context.Context, bucketName, from, to string, force bool) error
-		UpdateObject(ctx context.Context, bucketName, key, ETag, mimeType string, metadata api.ObjectUserMetadata, o object.Object) error
+		UpdateObject(ctx context.Context, bucketName, key, ETag, mimeType string, metadata api.ObjectUserMetadata, o object.Object, ifNotExists bool) error
+		UpdateObjectTags(ctx context.Context, bucketName, key string, tags api.ObjectTags) error
 
 		AbortMultipartUpload(ctx context.Context, bucketName, key string, uploadID string) (err error)
 		AddMultipartPart(ctx context.Context, bucketName, key, eTag, uploadID string, partNumber int, slices []object.SlabSlice) (err error)
 		CompleteMultipartUpload(ctx context.Context, bucketName, key, uploadID string, parts []api.MultipartCompletedPart, opts api.CompleteMultipartOptions) (_ api.MultipartCompleteResponse, err error)
-		CreateMultipartUpload(ctx context.Context, bucketName, key string, ec object.EncryptionKey, mimeType string, metadata api.ObjectUserMetadata) (api.MultipartCreateResponse, error)
+		CreateMultipartUpload(ctx context.Context, bucketName, key string, ec object.EncryptionKey, mimeType string, metadata api.ObjectUserMetadata, ttl time.Duration) (api.MultipartCreateResponse, error)
+		ExpireMultipartUploads(ctx context.Context, olderThan time.Duration) (int, error)
 		MultipartUpload(ctx context.Context, uploadID string) (resp api.MultipartUpload, _ error)
 		MultipartUploads(ctx context.Context, bucketName, prefix, keyMarker, uploadIDMarker string, maxUploads int) (resp api.MultipartListUploadsResponse, _ error)
 		MultipartUploadParts(ctx context.Context, bucketName, object string, uploadID string, marker int, limit int64) (resp api.MultipartListPartsResponse, _ error)
@@ -263,12 +327,14 @@ type (
 		MarkPackedSlabsUploaded(ctx context.Context, slabs []api.UploadedPackedSlab) error
 		PackedSlabsForUpload(ctx context.Context, lockingDuration time.Duration, minShards, totalShards uint8, limit int) ([]api.PackedSlab, error)
 		SlabBuffers(ctx context.Context) ([]api.SlabBuffer, error)
+		FlushSlabBuffers(ctx context.Context, createdBefore time.Time) ([]api.EventSlabBufferFlush, error)
 
 		AddPartialSlab(ctx context.Context, data []byte, minShards, totalShards uint8) (slabs []object.SlabSlice, bufferSize int64, err error)
 		FetchPartialSlab(ctx context.Context, key object.EncryptionKey, offset, length uint32) ([]byte, error)
 		Slab(ctx context.Context, key object.EncryptionKey) (object.Slab, error)
 		RefreshHealth(ctx context.Context) error
-		UnhealthySlabs(ctx context.Context, healthCutoff float64, limit int) ([]api.UnhealthySlab, error)
+		RecordSlabFailure(ctx context.Context, key object.EncryptionKey) error
+		UnhealthySlabs(ctx context.Context, healthCutoff float64, maxLastFailure time.Duration, limit int) ([]api.UnhealthySlab, error)
 		UpdateSlab(ctx context.Context, key object.EncryptionKey, sectors []api.UploadedSector) error
 	}
 
@@ -278,14 +344,24 @@ type (
 		RecordContractPruneMetric(ctx context.Context, metrics ...api.ContractPruneMetric) error
 
 		ContractMetrics(ctx context.Context, start time.Time, n uint64, interval time.Duration, opts api.ContractMetricsQueryOpts) ([]api.ContractMetric, error)
+		LatestContractMetrics(ctx context.Context, before time.Time) ([]api.ContractMetric, error)
 		RecordContractMetric(ctx context.Context, metrics ...api.ContractMetric) error
 
+		SlabDownloadMetrics(ctx context.Context, start time.Time, n uint64, interval time.Duration, opts api.SlabDownloadMetricsQueryOpts) ([]api.SlabDownloadMetric, error)
+		RecordSlabDownloadMetric(ctx context.Context, metrics ...api.SlabDownloadMetric) error
+
 		WalletMetrics(ctx context.Context, start time.Time, n uint64, interval time.Duration, opts api.WalletMetricsQueryOpts) ([]api.WalletMetric, error)
 		RecordWalletMetric(ctx context.Context, metrics ...api.WalletMetric) error
 
+		MetricsSummary(ctx context.Context, fcids []types.FileContractID) (api.MetricsSummary, error)
 		PruneMetrics(ctx context.Context, metric string, cutoff time.Time) error
 	}
 
+	// A RecoveryStore can recover object metadata from contract sector roots.
+	RecoveryStore interface {
+		RecoverObjects(ctx context.Context, roots []types.Hash256) (matched, recovered, deleted uint64, err error)
+	}
+
 	// A SettingStore stores settings.
 	SettingStore interface {
 		GougingSettings(ctx context.Context) (api.GougingSettings, error)
@@ -301,6 +377,11 @@ type (
 		UpdateS3Settings(ctx context.Context, s3as api.S3Settings) error
 	}
 
+	// A WalletStore stores wallet events.
+	WalletStore interface {
+		FilteredWalletEvents(ctx context.Context, opts api.WalletEventsOpts) ([]wallet.Event, error)
+	}
+
 	WalletMetricsRecorder interface {
 		Shutdown(context.Context) error
 	}
@@ -311,24 +392,36 @@ type Bus struct {
 	startTime       time.Time
 	masterKey       utils.MasterKey
 
-	alerts      alerts.Alerter
-	alertMgr    AlertManager
-	pinMgr      PinManager
-	webhooksMgr WebhooksManager
-	cm          ChainManager
-	cs          ChainSubscriber
-	s           Syncer
-	w           Wallet
-	store       Store
+	alerts       alerts.Alerter
+	alertMgr     AlertManager
+	forkDetector ForkDetector
+	pinMgr       PinManager
+	upnpMgr      UPnPManager
+	webhooksMgr  WebhooksManager
+	cm           ChainManager
+	cs           ChainSubscriber
+	s            Syncer
+	w            Wallet
+	store        Store
 
 	rhp2Client *rhp2.Client
 	rhp3Client *rhp3.Client
 	rhp4Client *rhp4.Client
 
 	contractLocker        ContractLocker
+	formations            *ibus.FormationLimiter
 	explorer              *ibus.Explorer
 	sectors               UploadingSectorsCache
 	walletMetricsRecorder WalletMetricsRecorder
+	healthRefresher       *ibus.HealthRefreshScheduler
+	slabBufferFlusher     *ibus.SlabBufferFlusher
+
+	recovery *metadataRecovery
+	vacuums  *storeVacuums
+	reports  *healthReports
+
+	maxSymlinkDepth  uint64
+	symlinksResolved *atomic.Uint64
 
 	logger *zap.SugaredLogger
 }
@@ -336,12 +429,15 @@ type Bus struct {
 // New returns a new Bus
 func New(ctx context.Context, cfg config.Bus, masterKey [32]byte, am AlertManager, wm WebhooksManager, cm ChainManager, s Syncer, w Wallet, store Store, explorerURL string, l *zap.Logger) (_ *Bus, err error) {
 	l = l.Named("bus")
-	dialer := rhp.NewFallbackDialer(store, net.Dialer{}, l)
+	dialer := rhp.NewFallbackDialer(store, net.Dialer{}, rhp.BandwidthConfig{}, rhp.DefaultHostCacheTTL, l)
 
 	b := &Bus{
 		allowPrivateIPs: cfg.AllowPrivateIPs,
 		startTime:       time.Now(),
 		masterKey:       masterKey,
+		maxSymlinkDepth: cfg.MaxSymlinkDepth,
+
+		symlinksResolved: new(atomic.Uint64),
 
 		s:        s,
 		cm:       cm,
@@ -368,19 +464,61 @@ func New(ctx context.Context, cfg config.Bus, masterKey [32]byte, am AlertManage
 	// create contract locker
 	b.contractLocker = ibus.NewContractLocker()
 
+	// apply default symlink depth
+	if b.maxSymlinkDepth == 0 {
+		b.maxSymlinkDepth = defaultMaxSymlinkDepth
+	}
+
+	// create formation limiter
+	b.formations = ibus.NewFormationLimiter(cfg.MaxConcurrentFormations)
+
 	// create sectors cache
 	b.sectors = ibus.NewSectorsCache()
 
+	// create metadata recovery tracker
+	b.recovery = new(metadataRecovery)
+
+	// create store vacuum tracker
+	b.vacuums = &storeVacuums{jobs: make(map[string]*api.VacuumStatus)}
+
+	// create health report cache
+	b.reports = &healthReports{reports: make(map[api.ReportPeriod]api.HealthReport)}
+
 	// create pin manager
 	b.pinMgr = ibus.NewPinManager(b.alerts, b.explorer, store, defaultPinUpdateInterval, defaultPinRateWindow, l)
 
+	// create fork detector
+	b.forkDetector = ibus.NewForkDetector(b.alerts, cm, s, cfg.ForkDetectionDepth, defaultForkDetectionInterval, l)
+
+	// create upnp manager
+	if cfg.UPnPEnabled {
+		b.upnpMgr, err = ibus.NewUPnPManager(ibus.DiscoverUPnPRouter, s.Addr(), defaultUPnPRenewInterval, l)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create upnp manager: %w", err)
+		}
+	}
+
 	// create chain subscriber
 	announcementMaxAge := time.Duration(cfg.AnnouncementMaxAgeHours) * time.Hour
-	b.cs = ibus.NewChainSubscriber(wm, cm, store, b.s, w, announcementMaxAge, l)
+	b.cs, err = ibus.NewChainSubscriber(b.alerts, wm, cm, store, b.s, w, announcementMaxAge, cfg.MaxChainLag, l)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chain subscriber: %w", err)
+	}
 
 	// create wallet metrics recorder
 	b.walletMetricsRecorder = ibus.NewWalletMetricRecorder(store, w, defaultWalletRecordMetricInterval, l)
 
+	// create health refresh scheduler
+	if cfg.HealthRefreshMinInterval <= 0 || cfg.HealthRefreshMinInterval > cfg.HealthRefreshMaxInterval {
+		return nil, fmt.Errorf("invalid health refresh interval, min interval %v must be positive and not exceed max interval %v", cfg.HealthRefreshMinInterval, cfg.HealthRefreshMaxInterval)
+	} else if cfg.HealthRefreshCutoff <= 0 || cfg.HealthRefreshCutoff > 1 {
+		return nil, fmt.Errorf("invalid health refresh cutoff %v, must be in (0, 1]", cfg.HealthRefreshCutoff)
+	}
+	b.healthRefresher = ibus.NewHealthRefreshScheduler(b.alerts, store, cfg.HealthRefreshCutoff, cfg.HealthRefreshMinInterval, cfg.HealthRefreshMaxInterval, l)
+
+	// create slab buffer flusher
+	b.slabBufferFlusher = ibus.NewSlabBufferFlusher(store, wm, defaultSlabBufferFlushInterval, l)
+
 	return b, nil
 }
 
@@ -391,6 +529,9 @@ func (b *Bus) Handler() http.Handler {
 		"POST   /accounts":      b.accountsHandlerPOST,
 		"POST   /accounts/fund": b.accountsFundHandler,
 
+		"POST   /admin/recover-metadata":        b.adminRecoverMetadataHandlerPOST,
+		"GET    /admin/recover-metadata/status": b.adminRecoverMetadataStatusHandlerGET,
+
 		"GET    /alerts":          b.handleGETAlerts,
 		"POST   /alerts/dismiss":  b.handlePOSTAlertsDismiss,
 		"POST   /alerts/register": b.handlePOSTAlertsRegister,
@@ -398,13 +539,15 @@ func (b *Bus) Handler() http.Handler {
 		"GET    /autopilot": b.autopilotHandlerGET,
 		"PUT    /autopilot": b.autopilotHandlerPUT,
 
-		"GET    /buckets":             b.bucketsHandlerGET,
-		"POST   /buckets":             b.bucketsHandlerPOST,
-		"PUT    /bucket/:name/policy": b.bucketsHandlerPolicyPUT,
-		"DELETE /bucket/:name":        b.bucketHandlerDELETE,
-		"GET    /bucket/:name":        b.bucketHandlerGET,
+		"GET    /buckets":                      b.bucketsHandlerGET,
+		"POST   /buckets":                      b.bucketsHandlerPOST,
+		"PUT    /bucket/:name/policy":          b.bucketsHandlerPolicyPUT,
+		"DELETE /bucket/:name":                 b.bucketHandlerDELETE,
+		"GET    /bucket/:name":                 b.bucketHandlerGET,
+		"POST   /bucket/:name/lifecycle/apply": b.bucketLifecycleApplyHandlerPOST,
 
 		"POST   /consensus/acceptblock":        b.consensusAcceptBlock,
+		"GET    /consensus/fork-status":        b.consensusForkStatusHandler,
 		"GET    /consensus/network":            b.consensusNetworkHandler,
 		"GET    /consensus/siafundfee/:payout": b.consensusPayoutContractTaxHandlerGET,
 		"GET    /consensus/state":              b.consensusStateHandler,
@@ -413,54 +556,67 @@ func (b *Bus) Handler() http.Handler {
 		"GET    /contracts":             b.contractsHandlerGET,
 		"DELETE /contracts/all":         b.contractsAllHandlerDELETE,
 		"POST   /contracts/archive":     b.contractsArchiveHandlerPOST,
+		"GET    /contracts/expiring":    b.contractsExpiringHandlerGET,
 		"POST   /contracts/form":        b.contractsFormHandler,
+		"GET    /contracts/formations":  b.contractsFormationsHandlerGET,
 		"GET    /contracts/prunable":    b.contractsPrunableDataHandlerGET,
 		"GET    /contracts/renewed/:id": b.contractsRenewedIDHandlerGET,
 		"POST   /contracts/spending":    b.contractsSpendingHandlerPOST,
 
-		"GET    /contract/:id":           b.contractIDHandlerGET,
-		"DELETE /contract/:id":           b.contractIDHandlerDELETE,
-		"POST   /contract/:id/acquire":   b.contractAcquireHandlerPOST,
-		"GET    /contract/:id/ancestors": b.contractIDAncestorsHandler,
-		"POST   /contract/:id/broadcast": b.contractIDBroadcastHandler,
-		"POST   /contract/:id/keepalive": b.contractKeepaliveHandlerPOST,
-		"GET    /contract/:id/revision":  b.contractLatestRevisionHandlerGET,
-		"POST   /contract/:id/prune":     b.contractPruneHandlerPOST,
-		"POST   /contract/:id/renew":     b.contractIDRenewHandlerPOST,
-		"POST   /contract/:id/release":   b.contractReleaseHandlerPOST,
-		"GET    /contract/:id/roots":     b.contractIDRootsHandlerGET,
-		"GET    /contract/:id/size":      b.contractSizeHandlerGET,
-		"PUT    /contract/:id/usability": b.contractUsabilityHandlerPUT,
-
-		"GET    /hosts":           b.hostsHandlerGET,
-		"POST   /hosts":           b.hostsHandlerPOST,
-		"GET    /hosts/allowlist": b.hostsAllowlistHandlerGET,
-		"PUT    /hosts/allowlist": b.hostsAllowlistHandlerPUT,
-		"GET    /hosts/blocklist": b.hostsBlocklistHandlerGET,
-		"PUT    /hosts/blocklist": b.hostsBlocklistHandlerPUT,
-		"POST   /hosts/remove":    b.hostsRemoveHandlerPOST,
-
+		"GET    /contract/:id":               b.contractIDHandlerGET,
+		"DELETE /contract/:id":               b.contractIDHandlerDELETE,
+		"POST   /contract/:id/acquire":       b.contractAcquireHandlerPOST,
+		"GET    /contract/:id/ancestors":     b.contractIDAncestorsHandler,
+		"POST   /contract/:id/broadcast":     b.contractIDBroadcastHandler,
+		"POST   /contract/:id/keepalive":     b.contractKeepaliveHandlerPOST,
+		"GET    /contract/:id/revision":      b.contractLatestRevisionHandlerGET,
+		"POST   /contract/:id/prune":         b.contractPruneHandlerPOST,
+		"POST   /contract/:id/renew":         b.contractIDRenewHandlerPOST,
+		"POST   /contract/:id/release":       b.contractReleaseHandlerPOST,
+		"GET    /contract/:id/roots":         b.contractIDRootsHandlerGET,
+		"GET    /contract/:id/size":          b.contractSizeHandlerGET,
+		"GET    /contract/:id/storage-proof": b.contractStorageProofHandlerGET,
+		"PUT    /contract/:id/usability":     b.contractUsabilityHandlerPUT,
+
+		"GET    /hosts":               b.hostsHandlerGET,
+		"POST   /hosts":               b.hostsHandlerPOST,
+		"GET    /hosts/allowlist":     b.hostsAllowlistHandlerGET,
+		"PUT    /hosts/allowlist":     b.hostsAllowlistHandlerPUT,
+		"GET    /hosts/blocklist":     b.hostsBlocklistHandlerGET,
+		"PUT    /hosts/blocklist":     b.hostsBlocklistHandlerPUT,
+		"PUT    /hosts/checks":        b.hostsChecksHandlerPUT,
+		"GET    /hosts/price-history": b.hostsPriceHistoryHandlerGET,
+		"POST   /hosts/remove":        b.hostsRemoveHandlerPOST,
+
+		"DELETE /host/:hostkey":                  b.hostsPubkeyHandlerDELETE,
 		"GET    /host/:hostkey":                  b.hostsPubkeyHandlerGET,
+		"GET    /host/:hostkey/benchmarks":       b.hostsBenchmarksHandlerGET,
+		"POST   /host/:hostkey/benchmarks":       b.hostsBenchmarksHandlerPOST,
 		"PUT    /host/:hostkey/check":            b.hostsCheckHandlerPUT,
+		"GET    /host/:hostkey/history":          b.hostsHistoryHandlerGET,
 		"POST   /host/:hostkey/resetlostsectors": b.hostsResetLostSectorsPOST,
 		"POST   /host/:hostkey/scan":             b.hostsScanHandlerPOST,
 
-		"PUT    /metric/:key": b.metricsHandlerPUT,
-		"GET    /metric/:key": b.metricsHandlerGET,
-		"DELETE /metric/:key": b.metricsHandlerDELETE,
+		"PUT    /metric/:key":     b.metricsHandlerPUT,
+		"GET    /metric/:key":     b.metricsHandlerGET,
+		"DELETE /metric/:key":     b.metricsHandlerDELETE,
+		"GET    /metrics/summary": b.metricsSummaryHandlerGET,
 
 		"POST   /multipart/create":      b.multipartHandlerCreatePOST,
 		"POST   /multipart/abort":       b.multipartHandlerAbortPOST,
 		"POST   /multipart/complete":    b.multipartHandlerCompletePOST,
+		"POST   /multipart/expire":      b.multipartHandlerExpirePOST,
 		"PUT    /multipart/part":        b.multipartHandlerUploadPartPUT,
 		"GET    /multipart/upload/:id":  b.multipartHandlerUploadGET,
 		"POST   /multipart/listuploads": b.multipartHandlerListUploadsPOST,
 		"POST   /multipart/listparts":   b.multipartHandlerListPartsPOST,
 
+		"DELETE /objects":         b.objectsHandlerDELETE,
 		"GET    /objects/*prefix": b.objectsHandlerGET,
 		"POST   /objects/copy":    b.objectsCopyHandlerPOST,
 		"POST   /objects/remove":  b.objectsRemoveHandlerPOST,
 		"POST   /objects/rename":  b.objectsRenameHandlerPOST,
+		"POST   /objects/symlink": b.objectsSymlinkHandlerPOST,
 
 		"GET    /object/*key": b.objectHandlerGET,
 		"PUT    /object/*key": b.objectHandlerPUT,
@@ -469,20 +625,25 @@ func (b *Bus) Handler() http.Handler {
 		"GET    /params/gouging": b.paramsHandlerGougingGET,
 		"GET    /params/upload":  b.paramsHandlerUploadGET,
 
-		"DELETE /sectors/:hostkey/:root": b.sectorsHostRootHandlerDELETE,
+		"GET    /reports/health": b.reportsHealthHandlerGET,
+
+		"DELETE /sectors/:hostkey/:root":  b.sectorsHostRootHandlerDELETE,
+		"GET    /sectors/:root/contracts": b.sectorsRootContractsHandlerGET,
 
-		"GET    /settings/gouging": b.settingsGougingHandlerGET,
-		"PUT    /settings/gouging": b.settingsGougingHandlerPUT,
-		"GET    /settings/pinned":  b.settingsPinnedHandlerGET,
-		"PUT    /settings/pinned":  b.settingsPinnedHandlerPUT,
-		"GET    /settings/s3":      b.settingsS3HandlerGET,
-		"PUT    /settings/s3":      b.settingsS3HandlerPUT,
-		"GET    /settings/upload":  b.settingsUploadHandlerGET,
-		"PUT    /settings/upload":  b.settingsUploadHandlerPUT,
+		"GET    /settings/gouging":          b.settingsGougingHandlerGET,
+		"PUT    /settings/gouging":          b.settingsGougingHandlerPUT,
+		"POST   /settings/gouging/validate": b.settingsGougingValidateHandlerPOST,
+		"GET    /settings/pinned":           b.settingsPinnedHandlerGET,
+		"PUT    /settings/pinned":           b.settingsPinnedHandlerPUT,
+		"GET    /settings/s3":               b.settingsS3HandlerGET,
+		"PUT    /settings/s3":               b.settingsS3HandlerPUT,
+		"GET    /settings/upload":           b.settingsUploadHandlerGET,
+		"PUT    /settings/upload":           b.settingsUploadHandlerPUT,
 
-		"GET    /slabbuffers":      b.slabbuffersHandlerGET,
-		"POST   /slabbuffer/done":  b.packedSlabsHandlerDonePOST,
-		"POST   /slabbuffer/fetch": b.packedSlabsHandlerFetchPOST,
+		"GET    /slabbuffers":               b.slabbuffersHandlerGET,
+		"GET    /slabbuffers/events/stream": b.slabbuffersEventsStreamHandler,
+		"POST   /slabbuffer/done":           b.packedSlabsHandlerDonePOST,
+		"POST   /slabbuffer/fetch":          b.packedSlabsHandlerFetchPOST,
 
 		"POST   /slabs/migration":     b.slabsMigrationHandlerPOST,
 		"GET    /slabs/partial/:key":  b.slabsPartialHandlerGET,
@@ -490,17 +651,27 @@ func (b *Bus) Handler() http.Handler {
 		"POST   /slabs/refreshhealth": b.slabsRefreshHealthHandlerPOST,
 		"GET    /slab/:key":           b.slabHandlerGET,
 		"PUT    /slab/:key":           b.slabHandlerPUT,
+		"POST   /slab/:key/failure":   b.slabFailureHandlerPOST,
 
 		"GET    /state": b.stateHandlerGET,
 
-		"GET    /stats/objects": b.objectsStatshandlerGET,
+		"GET    /stats/objects":         b.objectsStatshandlerGET,
+		"GET    /stats/objects/perhost": b.objectsStatsPerHostHandlerGET,
+
+		"POST   /store/compact":    b.storeCompactHandlerPOST,
+		"POST   /store/vacuum":     b.storeVacuumHandlerPOST,
+		"GET    /store/vacuum/:id": b.storeVacuumHandlerGET,
 
-		"GET    /syncer/address": b.syncerAddrHandler,
-		"POST   /syncer/connect": b.syncerConnectHandler,
-		"GET    /syncer/peers":   b.syncerPeersHandler,
+		"GET    /syncer/address":       b.syncerAddrHandler,
+		"POST   /syncer/connect":       b.syncerConnectHandler,
+		"GET    /syncer/peers":         b.syncerPeersHandler,
+		"GET    /syncer/peers/latency": b.syncerPeersLatencyHandler,
 
 		"POST /system/sqlite3/backup": b.postSystemSQLite3BackupHandler,
 
+		"GET    /tags/*key": b.tagsHandlerGET,
+		"PUT    /tags/*key": b.tagsHandlerPUT,
+
 		"GET    /txpool/recommendedfee": b.txpoolFeeHandler,
 		"GET    /txpool/transactions":   b.txpoolTransactionsHandler,
 		"POST   /txpool/broadcast":      b.txpoolBroadcastHandler,
@@ -509,25 +680,42 @@ func (b *Bus) Handler() http.Handler {
 		"DELETE /upload/:id":        b.uploadFinishedHandlerDELETE,
 		"POST   /upload/:id/sector": b.uploadAddSectorHandlerPOST,
 
-		"GET  /wallet":              b.walletHandler,
-		"GET  /wallet/events":       b.walletEventsHandler,
-		"GET  /wallet/pending":      b.walletPendingHandler,
-		"POST /wallet/redistribute": b.walletRedistributeHandler,
-		"POST /wallet/send":         b.walletSendSiacoinsHandler,
-
-		"GET    /webhooks":        b.webhookHandlerGet,
-		"POST   /webhooks":        b.webhookHandlerPost,
-		"POST   /webhooks/action": b.webhookActionHandlerPost,
-		"POST   /webhook/delete":  b.webhookHandlerDelete,
+		"GET    /versions/*key": b.versionsHandlerGET,
+
+		"GET  /wallet":               b.walletHandler,
+		"POST /wallet/estimatefee":   b.walletEstimateFeeHandler,
+		"GET  /wallet/events":        b.walletEventsHandler,
+		"GET  /wallet/events/stream": b.walletEventsStreamHandler,
+		"GET  /wallet/pending":       b.walletPendingHandler,
+		"POST /wallet/redistribute":  b.walletRedistributeHandler,
+		"POST /wallet/send":          b.walletSendSiacoinsHandler,
+		"POST /wallet/sweepdust":     b.walletSweepDustHandler,
+
+		"GET    /webhooks":                        b.webhookHandlerGet,
+		"POST   /webhooks":                        b.webhookHandlerPost,
+		"POST   /webhooks/action":                 b.webhookActionHandlerPost,
+		"GET    /webhooks/dead-letters":           b.webhookDeadLettersHandlerGET,
+		"POST   /webhooks/dead-letters/:id/retry": b.webhookDeadLetterRetryHandlerPOST,
+		"POST   /webhook/delete":                  b.webhookHandlerDelete,
 	})
 }
 
 // Shutdown shuts down the bus.
 func (b *Bus) Shutdown(ctx context.Context) error {
+	var upnpErr error
+	if b.upnpMgr != nil {
+		upnpErr = b.upnpMgr.Shutdown(ctx)
+	}
 	return errors.Join(
+		b.shutdownMetadataRecovery(ctx),
+		b.shutdownStoreVacuum(ctx),
 		b.walletMetricsRecorder.Shutdown(ctx),
+		b.healthRefresher.Shutdown(ctx),
+		b.slabBufferFlusher.Shutdown(ctx),
 		b.webhooksMgr.Shutdown(ctx),
 		b.pinMgr.Shutdown(ctx),
+		b.forkDetector.Shutdown(ctx),
+		upnpErr,
 		b.cs.Shutdown(ctx),
 	)
 }
@@ -654,6 +842,12 @@ func (b *Bus) broadcastContract(ctx context.Context, fcid types.FileContractID)
 	}
 }
 
+// estimateFee estimates the miner fee of the given transaction using the
+// recommended fee.
+func (b *Bus) estimateFee(txn types.Transaction) types.Currency {
+	return b.cm.RecommendedFee().Mul64(b.cm.TipState().TransactionWeight(txn))
+}
+
 func (b *Bus) formContract(ctx context.Context, hostSettings rhpv2.HostSettings, renterAddress types.Address, renterFunds, hostCollateral types.Currency, hostKey types.PublicKey, hostIP string, endHeight uint64) (api.ContractMetadata, error) {
 	// derive the renter key
 	renterKey := b.masterKey.DeriveContractKey(hostKey)
@@ -664,7 +858,7 @@ func (b *Bus) formContract(ctx context.Context, hostSettings rhpv2.HostSettings,
 	txn := types.Transaction{FileContracts: []types.FileContract{fc}}
 
 	// calculate the miner fee
-	fee := b.cm.RecommendedFee().Mul64(cs.TransactionWeight(txn))
+	fee := b.estimateFee(txn)
 	txn.MinerFees = []types.Currency{fee}
 
 	// fund the transaction
@@ -700,6 +894,9 @@ func (b *Bus) formContract(ctx context.Context, hostSettings rhpv2.HostSettings,
 		WindowEnd:          contract.Revision.WindowEnd,
 		ContractPrice:      contract.Revision.MissedHostPayout().Sub(hostCollateral),
 		InitialRenterFunds: renterFunds,
+		StoragePrice:       hostSettings.StoragePrice,
+		UploadPrice:        hostSettings.UploadBandwidthPrice,
+		DownloadPrice:      hostSettings.DownloadBandwidthPrice,
 		Usability:          api.ContractUsabilityGood,
 		V2:                 false,
 	}, nil
@@ -738,6 +935,9 @@ func (b *Bus) formContractV2(ctx context.Context, hk types.PublicKey, hostIP str
 		WindowEnd:          contract.Revision.ProofHeight + rhpv4.ProofWindow,
 		ContractPrice:      res.Usage.RenterCost(),
 		InitialRenterFunds: renterFunds,
+		StoragePrice:       prices.StoragePrice,
+		UploadPrice:        prices.IngressPrice,
+		DownloadPrice:      prices.EgressPrice,
 		Usability:          api.ContractUsabilityGood,
 		V2:                 true,
 	}, nil
@@ -748,6 +948,120 @@ func (b *Bus) isPassedV2AllowHeight() bool {
 	return cs.Index.Height >= cs.Network.HardforkV2.AllowHeight
 }
 
+// checkPathPolicy returns api.ErrPathPolicyViolation if the path doesn't
+// comply with the path policy of the bucket. Rejected paths are logged for
+// auditing purposes.
+func (b *Bus) checkPathPolicy(ctx context.Context, op, bucket, path string) error {
+	bkt, err := b.store.Bucket(ctx, bucket)
+	if err != nil {
+		return fmt.Errorf("failed to fetch bucket: %w", err)
+	}
+	if err := bkt.Policy.PathPolicy.Check(path); err != nil {
+		b.logger.Named("audit").With(zap.Error(err)).Warnw("rejected object path",
+			"op", op,
+			"bucket", bucket,
+			"path", path)
+		return err
+	}
+	return nil
+}
+
+// checkRenamePathPolicy returns api.ErrPathPolicyViolation if renaming 'from'
+// to 'to' would result in a path that doesn't comply with the path policy of
+// the bucket. When renaming a directory, the new path of every object in it is
+// checked.
+func (b *Bus) checkRenamePathPolicy(ctx context.Context, bucket, from, to string, dir bool) error {
+	if err := b.checkPathPolicy(ctx, "rename object", bucket, to); err != nil || !dir {
+		return err
+	}
+
+	bkt, err := b.store.Bucket(ctx, bucket)
+	if err != nil {
+		return fmt.Errorf("failed to fetch bucket: %w", err)
+	}
+	pp := bkt.Policy.PathPolicy
+	if pp.MaxPathLength == 0 && len(pp.DeniedPatterns) == 0 {
+		return nil // the allowed prefixes are covered by checking 'to'
+	}
+
+	var marker string
+	for {
+		resp, err := b.store.Objects(ctx, bucket, from, "", "", api.ObjectSortByName, api.SortDirAsc, marker, 1000, object.EncryptionKey{})
+		if err != nil {
+			return fmt.Errorf("failed to fetch objects: %w", err)
+		}
+		for _, obj := range resp.Objects {
+			path := to + strings.TrimPrefix(obj.Key, from)
+			if err := pp.Check(path); err != nil {
+				b.logger.Named("audit").With(zap.Error(err)).Warnw("rejected object path",
+					"op", "rename objects",
+					"bucket", bucket,
+					"path", path)
+				return err
+			}
+		}
+		if !resp.HasMore {
+			return nil
+		}
+		marker = resp.NextMarker
+	}
+}
+
+// checkSymlinkCycle follows the symlink chain starting at the given target and
+// returns api.ErrSymlinkCycle if it leads back to the given link. Chains that
+// end in a missing object are allowed.
+func (b *Bus) checkSymlinkCycle(ctx context.Context, bucket, target, link string) error {
+	for depth := uint64(0); ; depth++ {
+		if target == link {
+			return fmt.Errorf("%w; '%s' resolves to itself", api.ErrSymlinkCycle, link)
+		} else if depth == b.maxSymlinkDepth {
+			return fmt.Errorf("%w; failed to resolve '%s'", api.ErrMaxSymlinkDepthExceeded, link)
+		}
+
+		o, err := b.store.ObjectMetadata(ctx, bucket, target)
+		if errors.Is(err, api.ErrObjectNotFound) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to fetch '%s': %w", target, err)
+		}
+
+		var ok bool
+		if target, ok = o.SymlinkTarget(); !ok {
+			return nil
+		}
+	}
+}
+
+// resolveSymlinks follows the symlink chain starting at the given object and
+// returns the object it ends at. Resolving fails if more than maxSymlinkDepth
+// symlinks have to be followed.
+func (b *Bus) resolveSymlinks(o api.Object, fetchObject func(key string) (api.Object, error)) (api.Object, error) {
+	for depth := uint64(0); ; depth++ {
+		target, ok := o.SymlinkTarget()
+		if !ok {
+			return o, nil
+		} else if depth == b.maxSymlinkDepth {
+			return api.Object{}, fmt.Errorf("%w; failed to resolve '%s'", api.ErrMaxSymlinkDepthExceeded, o.ObjectMetadata.Key)
+		}
+
+		var err error
+		o, err = fetchObject(target)
+		if err != nil {
+			return api.Object{}, fmt.Errorf("failed to resolve symlink to '%s': %w", target, err)
+		}
+		b.symlinksResolved.Add(1)
+	}
+}
+
+// withComputedFields sets the fields of the contract metadata that depend on
+// the current chain state.
+func (b *Bus) withComputedFields(c api.ContractMetadata) api.ContractMetadata {
+	cs := b.cm.TipState()
+	return c.
+		WithExpiry(cs.Index.Height, cs.Network.BlockInterval, time.Now()).
+		WithHealthScore(cs.Index.Height)
+}
+
 func (b *Bus) prepareRenew(cs consensus.State, revision types.FileContractRevision, hostAddress, renterAddress types.Address, renterFunds, minNewCollateral types.Currency, endHeight, expectedStorage uint64) rhp3.PrepareRenewFn {
 	return func(pt rhpv3.HostPriceTable) ([]types.Hash256, []types.Transaction, types.Currency, rhp3.DiscardTxnFn, error) {
 		// create the final revision from the provided revision
@@ -825,6 +1139,9 @@ func (b *Bus) renewContractV1(ctx context.Context, cs consensus.State, gp api.Go
 		WindowEnd:          newRevision.Revision.WindowEnd,
 		ContractPrice:      contractPrice,
 		InitialRenterFunds: fundAmount,
+		StoragePrice:       hs.StoragePrice,
+		UploadPrice:        hs.UploadBandwidthPrice,
+		DownloadPrice:      hs.DownloadBandwidthPrice,
 		Usability:          api.ContractUsabilityGood,
 		V2:                 false,
 	}, nil
@@ -905,6 +1222,9 @@ func (b *Bus) renewContractV2(ctx context.Context, cs consensus.State, h api.Hos
 		WindowEnd:          contract.Revision.ExpirationHeight,
 		ContractPrice:      settings.Prices.ContractPrice,
 		InitialRenterFunds: contract.Revision.RenterOutput.Value,
+		StoragePrice:       settings.Prices.StoragePrice,
+		UploadPrice:        settings.Prices.IngressPrice,
+		DownloadPrice:      settings.Prices.EgressPrice,
 		Usability:          api.ContractUsabilityGood,
 		V2:                 true,
 	}, nil
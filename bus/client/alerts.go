@@ -7,6 +7,7 @@ import (
 
 	"go.sia.tech/core/types"
 	"go.sia.tech/renterd/alerts"
+	"go.sia.tech/renterd/api"
 )
 
 // Alerts fetches the active alerts from the bus.
@@ -19,6 +20,12 @@ func (c *Client) Alerts(ctx context.Context, opts alerts.AlertsOpts) (resp alert
 	if opts.Severity != 0 {
 		values.Set("severity", opts.Severity.String())
 	}
+	if opts.Module != "" {
+		values.Set("module", opts.Module)
+	}
+	if !opts.Since.IsZero() {
+		values.Set("since", api.TimeRFC3339(opts.Since).String())
+	}
 	err = c.c.WithContext(ctx).GET("/alerts?"+values.Encode(), &resp)
 	return
 }
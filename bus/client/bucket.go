@@ -38,3 +38,12 @@ func (c *Client) UpdateBucketPolicy(ctx context.Context, bucketName string, poli
 		Policy: policy,
 	})
 }
+
+// ApplyBucketLifecycleRules removes all objects in the bucket that expired
+// according to the bucket's lifecycle rules and returns the number of removed
+// objects.
+func (c *Client) ApplyBucketLifecycleRules(ctx context.Context, bucketName string) (int, error) {
+	var resp api.ObjectsRemoveResponse
+	err := c.c.WithContext(ctx).POST(fmt.Sprintf("/bucket/%s/lifecycle/apply", bucketName), nil, &resp)
+	return resp.Removed, err
+}
@@ -33,6 +33,12 @@ func (c *Client) ConsensusState(ctx context.Context) (resp api.ConsensusState, e
 	return
 }
 
+// ForkStatus returns whether the node is following the canonical chain.
+func (c *Client) ForkStatus(ctx context.Context) (resp api.ForkStatus, err error) {
+	err = c.c.WithContext(ctx).GET("/consensus/fork-status", &resp)
+	return
+}
+
 // FileContractTax asks the bus for the siafund fee that has to be paid for a
 // contract with a given payout.
 func (c *Client) FileContractTax(ctx context.Context, payout types.Currency) (tax types.Currency, err error) {
@@ -58,6 +64,13 @@ func (c *Client) SyncerPeers(ctx context.Context) (resp []string, err error) {
 	return
 }
 
+// SyncerPeersLatency returns the measured round-trip time to each of the
+// syncer's peers.
+func (c *Client) SyncerPeersLatency(ctx context.Context) (resp []api.PeerLatency, err error) {
+	err = c.c.WithContext(ctx).GET("/syncer/peers/latency", &resp)
+	return
+}
+
 // SyncerConnect adds the address as a peer of the syncer.
 func (c *Client) SyncerConnect(ctx context.Context, addr string) (err error) {
 	err = c.c.WithContext(ctx).POST("/syncer/connect", addr, nil)
@@ -31,6 +31,27 @@ func (c *Client) Backup(ctx context.Context, database, dstPath string) (err erro
 	return
 }
 
+// CompactStore truncates the WAL of the bus' databases and rebuilds them to
+// release unused pages.
+func (c *Client) CompactStore(ctx context.Context) (err error) {
+	err = c.c.WithContext(ctx).POST("/store/compact", nil, nil)
+	return
+}
+
+// VacuumStore starts optimizing and rebuilding the bus' databases to reclaim
+// the space of deleted rows, the vacuum runs in the background and its
+// progress can be tracked using VacuumStatus.
+func (c *Client) VacuumStore(ctx context.Context) (resp api.VacuumStatus, err error) {
+	err = c.c.WithContext(ctx).POST("/store/vacuum", nil, &resp)
+	return
+}
+
+// VacuumStatus returns the status of the vacuum with the given id.
+func (c *Client) VacuumStatus(ctx context.Context, id string) (resp api.VacuumStatus, err error) {
+	err = c.c.WithContext(ctx).GET(fmt.Sprintf("/store/vacuum/%s", id), &resp)
+	return
+}
+
 // ScanHost scans a host, returning its current settings and prices.
 func (c *Client) ScanHost(ctx context.Context, hostKey types.PublicKey, timeout time.Duration) (resp api.HostScanResponse, err error) {
 	err = c.c.WithContext(ctx).POST(fmt.Sprintf("/host/%s/scan", hostKey), api.HostScanRequest{
@@ -44,3 +65,18 @@ func (c *Client) State() (state api.BusStateResponse, err error) {
 	err = c.c.GET("/state", &state)
 	return
 }
+
+// MetadataRecoveryStatus returns the progress of the most recent metadata
+// recovery.
+func (c *Client) MetadataRecoveryStatus(ctx context.Context) (resp api.MetadataRecoveryStatus, err error) {
+	err = c.c.WithContext(ctx).GET("/admin/recover-metadata/status", &resp)
+	return
+}
+
+// RecoverMetadata starts recovering object metadata from the sector roots of
+// the active contracts, the recovery runs in the background and its progress
+// can be tracked using MetadataRecoveryStatus.
+func (c *Client) RecoverMetadata(ctx context.Context) (resp api.MetadataRecoveryStatus, err error) {
+	err = c.c.WithContext(ctx).POST("/admin/recover-metadata", nil, &resp)
+	return
+}
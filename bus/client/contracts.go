@@ -67,6 +67,13 @@ func (c *Client) ContractSize(ctx context.Context, contractID types.FileContract
 	return
 }
 
+// ContractStorageProof returns the transaction containing the storage proof
+// of the contract with given id.
+func (c *Client) ContractStorageProof(ctx context.Context, contractID types.FileContractID) (proof api.ContractStorageProof, err error) {
+	err = c.c.WithContext(ctx).GET(fmt.Sprintf("/contract/%s/storage-proof", contractID), &proof)
+	return
+}
+
 // Contracts retrieves contracts from the metadata store. If no filter is set,
 // all contracts are returned.
 func (c *Client) Contracts(ctx context.Context, opts api.ContractsOpts) (contracts []api.ContractMetadata, err error) {
@@ -74,10 +81,20 @@ func (c *Client) Contracts(ctx context.Context, opts api.ContractsOpts) (contrac
 	if opts.FilterMode != "" {
 		values.Set("filtermode", opts.FilterMode)
 	}
+	if opts.SortBy != "" {
+		values.Set("sortby", opts.SortBy)
+	}
 	err = c.c.WithContext(ctx).GET("/contracts?"+values.Encode(), &contracts)
 	return
 }
 
+// ContractsExpiring returns all active contracts that expire within the given
+// number of blocks.
+func (c *Client) ContractsExpiring(ctx context.Context, blocks uint64) (contracts []api.ContractMetadata, err error) {
+	err = c.c.WithContext(ctx).GET(fmt.Sprintf("/contracts/expiring?blocks=%d", blocks), &contracts)
+	return
+}
+
 // DeleteContract deletes the contract with the given ID.
 func (c *Client) DeleteContract(ctx context.Context, id types.FileContractID) (err error) {
 	err = c.c.WithContext(ctx).DELETE(fmt.Sprintf("/contract/%s", id))
@@ -95,9 +112,12 @@ func (c *Client) DeleteContracts(ctx context.Context, ids []types.FileContractID
 	return nil
 }
 
-// DeleteAllContracts deletes all contracts from the bus.
-func (c *Client) DeleteAllContracts(ctx context.Context) (err error) {
-	err = c.c.WithContext(ctx).DELETE("/contracts/all")
+// DeleteAllContracts deletes all contracts from the bus. If safe is true, the
+// contracts are only deleted if that doesn't render any objects inaccessible.
+func (c *Client) DeleteAllContracts(ctx context.Context, safe bool) (err error) {
+	values := url.Values{}
+	values.Set("safe", fmt.Sprint(safe))
+	err = c.c.WithContext(ctx).DELETE("/contracts/all?" + values.Encode())
 	return
 }
 
@@ -113,6 +133,13 @@ func (c *Client) FormContract(ctx context.Context, renterAddress types.Address,
 	return
 }
 
+// FormationQueueDepth returns the number of contract negotiations per host
+// that are waiting for another negotiation with the same host to finish.
+func (c *Client) FormationQueueDepth(ctx context.Context) (depths api.FormationQueueDepth, err error) {
+	err = c.c.WithContext(ctx).GET("/contracts/formations", &depths)
+	return
+}
+
 // KeepaliveContract extends the duration on an already acquired lock on a
 // contract.
 func (c *Client) KeepaliveContract(ctx context.Context, contractID types.FileContractID, lockID uint64, d time.Duration) (err error) {
@@ -0,0 +1,97 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// eventStreamRetryInterval is the time to wait before reconnecting to an event
+// stream.
+const eventStreamRetryInterval = time.Second
+
+// eventStream connects to the event stream at the given path and decodes its
+// events. The connection is re-established whenever the bus closes it, events
+// sent while reconnecting are missed. The returned channel is closed once the
+// context is cancelled.
+func eventStream[T any](ctx context.Context, c *Client, path string) (<-chan T, error) {
+	body, err := c.openEventStream(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan T)
+	go func() {
+		defer close(events)
+		for {
+			_ = readEvents(ctx, body, events)
+
+			// reconnect until the context is cancelled
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(eventStreamRetryInterval):
+				}
+				if body, err = c.openEventStream(ctx, path); err == nil {
+					break
+				}
+			}
+		}
+	}()
+	return events, nil
+}
+
+func (c *Client) openEventStream(ctx context.Context, path string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s%s", c.c.BaseURL, path), http.NoBody)
+	if err != nil {
+		panic(err)
+	}
+	req.SetBasicAuth("", c.c.WithContext(ctx).Password)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	} else if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("%s (status: %d)", strings.TrimSpace(string(msg)), resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+// readEvents reads server-sent events from the given body until it's closed or
+// the context is cancelled.
+func readEvents[T any](ctx context.Context, body io.ReadCloser, events chan<- T) error {
+	defer body.Close()
+
+	s := bufio.NewScanner(body)
+	s.Buffer(nil, 1<<24) // wallet events contain transactions which can be large
+	var data []byte
+	for s.Scan() {
+		line := s.Bytes()
+		switch {
+		case len(line) == 0 && len(data) > 0:
+			// an empty line terminates the event
+			var event T
+			if err := json.Unmarshal(data, &event); err != nil {
+				return fmt.Errorf("failed to decode event: %w", err)
+			}
+			data = data[:0]
+
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		case strings.HasPrefix(string(line), "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(string(line), "data:"), " ")...)
+		}
+	}
+	return s.Err()
+}
@@ -3,12 +3,20 @@ package client
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"time"
 
 	"go.sia.tech/core/types"
 	"go.sia.tech/renterd/api"
 )
 
+// DeleteHost permanently removes a host from the hostdb, its contracts are
+// archived.
+func (c *Client) DeleteHost(ctx context.Context, hostKey types.PublicKey) (err error) {
+	err = c.c.WithContext(ctx).DELETE(fmt.Sprintf("/host/%s", hostKey))
+	return
+}
+
 // Host returns information about a particular host known to the server.
 func (c *Client) Host(ctx context.Context, hostKey types.PublicKey) (h api.Host, err error) {
 	err = c.c.WithContext(ctx).GET(fmt.Sprintf("/host/%s", hostKey), &h)
@@ -18,7 +26,7 @@ func (c *Client) Host(ctx context.Context, hostKey types.PublicKey) (h api.Host,
 // Hosts returns all hosts that match certain search criteria.
 func (c *Client) Hosts(ctx context.Context, opts api.HostOptions) (hosts []api.Host, err error) {
 	err = c.c.WithContext(ctx).POST("/hosts", api.HostsRequest{
-		Offset:          opts.Offset,
+		Cursor:          opts.Cursor,
 		Limit:           opts.Limit,
 		FilterMode:      opts.FilterMode,
 		UsabilityMode:   opts.UsabilityMode,
@@ -41,6 +49,39 @@ func (c *Client) HostBlocklist(ctx context.Context) (blocklist []string, err err
 	return
 }
 
+// HostBenchmarks returns the most recent benchmarks of the given host, ordered
+// from newest to oldest. A limit of -1 returns all benchmarks.
+func (c *Client) HostBenchmarks(ctx context.Context, hostKey types.PublicKey, limit int) (benchmarks []api.HostBenchmark, err error) {
+	values := url.Values{}
+	values.Set("limit", fmt.Sprint(limit))
+	err = c.c.WithContext(ctx).GET(fmt.Sprintf("/host/%s/benchmarks?%s", hostKey, values.Encode()), &benchmarks)
+	return
+}
+
+// RecordHostBenchmark records the result of benchmarking the given host.
+func (c *Client) RecordHostBenchmark(ctx context.Context, hostKey types.PublicKey, benchmark api.HostBenchmark) (err error) {
+	err = c.c.WithContext(ctx).POST(fmt.Sprintf("/host/%s/benchmarks", hostKey), benchmark, nil)
+	return
+}
+
+// HostScanHistory returns the most recent scans of the given host, ordered from
+// newest to oldest. A limit of -1 returns all scans.
+func (c *Client) HostScanHistory(ctx context.Context, hostKey types.PublicKey, limit int) (scans []api.HostScan, err error) {
+	values := url.Values{}
+	values.Set("limit", fmt.Sprint(limit))
+	err = c.c.WithContext(ctx).GET(fmt.Sprintf("/host/%s/history?%s", hostKey, values.Encode()), &scans)
+	return
+}
+
+// HostPriceHistory returns the price changes of all hosts that changed their
+// prices since the given time.
+func (c *Client) HostPriceHistory(ctx context.Context, since time.Time) (history map[types.PublicKey][]api.HostPriceChange, err error) {
+	values := url.Values{}
+	values.Set("since", api.TimeRFC3339(since).String())
+	err = c.c.WithContext(ctx).GET("/hosts/price-history?"+values.Encode(), &history)
+	return
+}
+
 // RemoveOfflineHosts removes all hosts that have been offline for longer than the given max downtime.
 func (c *Client) RemoveOfflineHosts(ctx context.Context, maxConsecutiveScanFailures uint64, maxDowntime time.Duration) (removed uint64, err error) {
 	err = c.c.WithContext(ctx).POST("/hosts/remove", api.HostsRemoveRequest{
@@ -75,6 +116,13 @@ func (c *Client) UpdateHostCheck(ctx context.Context, hostKey types.PublicKey, h
 	return
 }
 
+// UpdateHostChecks updates the given hosts with the most recent checks
+// performed by the autopilot in a single request.
+func (c *Client) UpdateHostChecks(ctx context.Context, checks map[types.PublicKey]api.HostChecks) (err error) {
+	err = c.c.WithContext(ctx).PUT("/hosts/checks", checks)
+	return
+}
+
 // UsableHosts returns a list of hosts that are ready to be used. That means
 // they are deemed usable by the autopilot, they are not gouging, not blocked,
 // not offline, etc.
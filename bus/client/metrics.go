@@ -55,6 +55,22 @@ func (c *Client) ContractPruneMetrics(ctx context.Context, start time.Time, n ui
 	return resp, nil
 }
 
+func (c *Client) SlabDownloadMetrics(ctx context.Context, start time.Time, n uint64, interval time.Duration, opts api.SlabDownloadMetricsQueryOpts) ([]api.SlabDownloadMetric, error) {
+	values := url.Values{}
+	values.Set("start", api.TimeRFC3339(start).String())
+	values.Set("n", fmt.Sprint(n))
+	values.Set("interval", api.DurationMS(interval).String())
+	if opts.SlabKeyHash != (types.Hash256{}) {
+		values.Set("slabkeyhash", opts.SlabKeyHash.String())
+	}
+
+	var resp []api.SlabDownloadMetric
+	if err := c.metric(ctx, api.MetricSlabDownload, values, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
 func (c *Client) WalletMetrics(ctx context.Context, start time.Time, n uint64, interval time.Duration, opts api.WalletMetricsQueryOpts) ([]api.WalletMetric, error) {
 	values := url.Values{}
 	values.Set("start", api.TimeRFC3339(start).String())
@@ -68,10 +84,20 @@ func (c *Client) WalletMetrics(ctx context.Context, start time.Time, n uint64, i
 	return resp, nil
 }
 
+// MetricsSummary returns a summary of the most recent metrics.
+func (c *Client) MetricsSummary(ctx context.Context) (summary api.MetricsSummary, err error) {
+	err = c.c.WithContext(ctx).GET("/metrics/summary", &summary)
+	return
+}
+
 func (c *Client) RecordContractPruneMetric(ctx context.Context, metrics ...api.ContractPruneMetric) error {
 	return c.recordMetric(ctx, api.MetricContractPrune, api.ContractPruneMetricRequestPUT{Metrics: metrics})
 }
 
+func (c *Client) RecordSlabDownloadMetric(ctx context.Context, metrics ...api.SlabDownloadMetric) error {
+	return c.recordMetric(ctx, api.MetricSlabDownload, api.SlabDownloadMetricRequestPUT{Metrics: metrics})
+}
+
 func (c *Client) PruneMetrics(ctx context.Context, metric string, cutoff time.Time) error {
 	values := url.Values{}
 	values.Set("cutoff", api.TimeRFC3339(cutoff).String())
@@ -3,6 +3,7 @@ package client
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"go.sia.tech/renterd/api"
 	"go.sia.tech/renterd/object"
@@ -51,10 +52,20 @@ func (c *Client) CreateMultipartUpload(ctx context.Context, bucket, key string,
 		Key:                         key,
 		MimeType:                    opts.MimeType,
 		Metadata:                    opts.Metadata,
+		TTL:                         api.DurationMS(opts.TTL),
 	}, &resp)
 	return
 }
 
+// ExpireMultipartUploads aborts all multipart uploads whose TTL expired as well
+// as uploads without a TTL that were created more than olderThan ago. An
+// olderThan of 0 only expires uploads with a TTL.
+func (c *Client) ExpireMultipartUploads(ctx context.Context, olderThan time.Duration) (expired int, err error) {
+	var resp api.MultipartExpireResponse
+	err = c.c.WithContext(ctx).POST("/multipart/expire", api.MultipartExpireRequest{OlderThan: api.DurationMS(olderThan)}, &resp)
+	return resp.Expired, err
+}
+
 // MultipartUpload returns information about a specific multipart upload.
 func (c *Client) MultipartUpload(ctx context.Context, uploadID string) (resp api.MultipartUpload, err error) {
 	err = c.c.WithContext(ctx).GET(fmt.Sprintf("/multipart/upload/%s", uploadID), &resp)
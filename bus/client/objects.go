@@ -3,16 +3,25 @@ package client
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"net/url"
 
+	"go.sia.tech/core/types"
+
 	"go.sia.tech/renterd/api"
+	"go.sia.tech/renterd/internal/utils"
 	"go.sia.tech/renterd/object"
 )
 
 // AddObject stores the provided object under the given path.
 func (c *Client) AddObject(ctx context.Context, bucket, path string, o object.Object, opts api.AddObjectOptions) (err error) {
+	values := url.Values{}
+	if opts.IfNotExists {
+		values.Set("ifNotExists", "true")
+	}
+
 	path = api.ObjectKeyEscape(path)
-	err = c.c.WithContext(ctx).PUT(fmt.Sprintf("/object/%s", path), api.AddObjectRequest{
+	err = c.c.WithContext(ctx).PUT(fmt.Sprintf("/object/%s?"+values.Encode(), path), api.AddObjectRequest{
 		Bucket:   bucket,
 		Object:   o,
 		ETag:     opts.ETag,
@@ -32,10 +41,22 @@ func (c *Client) CopyObject(ctx context.Context, srcBucket, dstBucket, srcKey, d
 		DestinationKey:    dstKey,
 		MimeType:          opts.MimeType,
 		Metadata:          opts.Metadata,
+		IfSourceETagMatch: opts.IfSourceETagMatch,
 	}, &om)
 	return
 }
 
+// CreateSymlink creates an object at linkPath that resolves to the object at
+// targetPath when fetched.
+func (c *Client) CreateSymlink(ctx context.Context, bucket, targetPath, linkPath string) (err error) {
+	err = c.c.WithContext(ctx).POST("/objects/symlink", api.ObjectsSymlinkRequest{
+		Bucket:     bucket,
+		TargetPath: targetPath,
+		LinkPath:   linkPath,
+	}, nil)
+	return
+}
+
 // DeleteObject deletes the object with given key.
 func (c *Client) DeleteObject(ctx context.Context, bucket, key string) (err error) {
 	values := url.Values{}
@@ -46,6 +67,18 @@ func (c *Client) DeleteObject(ctx context.Context, bucket, key string) (err erro
 	return
 }
 
+// DeleteObjectVersion permanently deletes the version of an object with the
+// given version ID.
+func (c *Client) DeleteObjectVersion(ctx context.Context, bucket, key, versionID string) (err error) {
+	values := url.Values{}
+	values.Set("bucket", bucket)
+	values.Set("versionid", versionID)
+
+	key = api.ObjectKeyEscape(key)
+	err = c.c.WithContext(ctx).DELETE(fmt.Sprintf("/object/%s?"+values.Encode(), key))
+	return
+}
+
 // RemoveObjects removes objects with given prefix.
 func (c *Client) RemoveObjects(ctx context.Context, bucket, prefix string) (err error) {
 	err = c.c.WithContext(ctx).POST("/objects/remove", api.ObjectsRemoveRequest{
@@ -55,6 +88,32 @@ func (c *Client) RemoveObjects(ctx context.Context, bucket, prefix string) (err
 	return
 }
 
+// RemoveObjectsGlob removes all objects whose key matches the given glob
+// pattern and returns the number of removed objects. A '*' matches any
+// sequence of characters, including '/', and a '?' matches a single
+// character.
+func (c *Client) RemoveObjectsGlob(ctx context.Context, bucket, glob string) (int, error) {
+	c.c.Custom("DELETE", "/objects", nil, (*api.ObjectsRemoveResponse)(nil))
+
+	values := url.Values{}
+	values.Set("bucket", bucket)
+	values.Set("glob", glob)
+	u, err := url.Parse(fmt.Sprintf("%s/objects?%s", c.c.BaseURL, values.Encode()))
+	if err != nil {
+		panic(err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "DELETE", u.String(), http.NoBody)
+	if err != nil {
+		panic(err)
+	}
+	req.SetBasicAuth("", c.c.WithContext(ctx).Password)
+	var resp api.ObjectsRemoveResponse
+	if _, _, err := utils.DoRequest(req, &resp); err != nil {
+		return 0, err
+	}
+	return resp.Removed, nil
+}
+
 // Object returns the object at given key.
 func (c *Client) Object(ctx context.Context, bucket, key string, opts api.GetObjectOptions) (res api.Object, err error) {
 	values := url.Values{}
@@ -68,6 +127,37 @@ func (c *Client) Object(ctx context.Context, bucket, key string, opts api.GetObj
 	return
 }
 
+// ObjectTags returns the tags of the object at given key.
+func (c *Client) ObjectTags(ctx context.Context, bucket, key string) (tags api.ObjectTags, err error) {
+	values := url.Values{}
+	values.Set("bucket", bucket)
+
+	key = api.ObjectKeyEscape(key)
+	err = c.c.WithContext(ctx).GET(fmt.Sprintf("/tags/%s?"+values.Encode(), key), &tags)
+	return
+}
+
+// ObjectVersions returns all versions of the object at given key, ordered
+// from newest to oldest.
+func (c *Client) ObjectVersions(ctx context.Context, bucket, key string) (versions []api.ObjectVersion, err error) {
+	values := url.Values{}
+	values.Set("bucket", bucket)
+
+	key = api.ObjectKeyEscape(key)
+	err = c.c.WithContext(ctx).GET(fmt.Sprintf("/versions/%s?"+values.Encode(), key), &versions)
+	return
+}
+
+// UpdateObjectTags replaces the tags of the object at given key.
+func (c *Client) UpdateObjectTags(ctx context.Context, bucket, key string, tags api.ObjectTags) (err error) {
+	values := url.Values{}
+	values.Set("bucket", bucket)
+
+	key = api.ObjectKeyEscape(key)
+	err = c.c.WithContext(ctx).PUT(fmt.Sprintf("/tags/%s?"+values.Encode(), key), tags)
+	return
+}
+
 // Objects lists objects in the given bucket.
 func (c *Client) Objects(ctx context.Context, prefix string, opts api.ListObjectOptions) (resp api.ObjectsResponse, err error) {
 	values := url.Values{}
@@ -90,6 +180,17 @@ func (c *Client) ObjectsStats(ctx context.Context, opts api.ObjectsStatsOpts) (o
 	return
 }
 
+// ObjectsStatsPerHost returns the number of sectors and their size stored on
+// every host.
+func (c *Client) ObjectsStatsPerHost(ctx context.Context, opts api.ObjectsStatsOpts) (stats map[types.PublicKey]api.HostStorageStats, err error) {
+	values := url.Values{}
+	if opts.Bucket != "" {
+		values.Set("bucket", opts.Bucket)
+	}
+	err = c.c.WithContext(ctx).GET("/stats/objects/perhost?"+values.Encode(), &stats)
+	return
+}
+
 // RenameObject renames a single object.
 func (c *Client) RenameObject(ctx context.Context, bucket, from, to string, force bool) (err error) {
 	return c.renameObjects(ctx, bucket, from, to, api.ObjectsRenameModeSingle, force)
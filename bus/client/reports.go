@@ -0,0 +1,18 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"go.sia.tech/renterd/api"
+)
+
+// HealthReport returns a report on the health of the node over the given
+// period.
+func (c *Client) HealthReport(ctx context.Context, period time.Duration) (report api.HealthReport, err error) {
+	values := url.Values{}
+	values.Set("period", api.ReportPeriod(period).String())
+	err = c.c.WithContext(ctx).GET("/reports/health?"+values.Encode(), &report)
+	return
+}
@@ -11,3 +11,10 @@ import (
 func (c *Client) DeleteHostSector(ctx context.Context, hostKey types.PublicKey, sectorRoot types.Hash256) error {
 	return c.c.WithContext(ctx).DELETE(fmt.Sprintf("/sectors/%s/%s", hostKey, sectorRoot))
 }
+
+// FindAlternativeContracts returns the ids of all active contracts that store
+// the sector with the given root.
+func (c *Client) FindAlternativeContracts(ctx context.Context, sectorRoot types.Hash256) (fcids []types.FileContractID, err error) {
+	err = c.c.WithContext(ctx).GET(fmt.Sprintf("/sectors/%s/contracts", sectorRoot), &fcids)
+	return
+}
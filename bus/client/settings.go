@@ -17,6 +17,13 @@ func (c *Client) UpdateGougingSettings(ctx context.Context, gs api.GougingSettin
 	return c.c.WithContext(ctx).PUT("/settings/gouging", gs)
 }
 
+// ValidateGougingSettings checks the given gouging settings against all
+// scanned hosts without updating them.
+func (c *Client) ValidateGougingSettings(ctx context.Context, gs api.GougingSettings) (res api.GougingValidationResult, err error) {
+	err = c.c.WithContext(ctx).POST("/settings/gouging/validate", gs, &res)
+	return
+}
+
 // PinnedSettings returns the pinned settings.
 func (c *Client) PinnedSettings(ctx context.Context) (ps api.PinnedSettings, err error) {
 	err = c.c.WithContext(ctx).GET("/settings/pinned", &ps)
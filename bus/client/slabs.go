@@ -106,12 +106,29 @@ func (c *Client) SlabBuffers() (buffers []api.SlabBuffer, err error) {
 	return
 }
 
+// SlabBufferFlushEventStream streams the slab buffer flush events of the bus.
+// The connection is re-established whenever the bus closes it, events sent
+// while reconnecting are missed. The returned channel is closed once the
+// context is cancelled.
+func (c *Client) SlabBufferFlushEventStream(ctx context.Context) (<-chan api.EventSlabBufferFlush, error) {
+	c.c.Custom("GET", "/slabbuffers/events/stream", nil, (*[]api.EventSlabBufferFlush)(nil))
+	return eventStream[api.EventSlabBufferFlush](ctx, c, "/slabbuffers/events/stream")
+}
+
+// RecordSlabFailure records that the migration of the slab with the given key
+// failed.
+func (c *Client) RecordSlabFailure(ctx context.Context, key object.EncryptionKey) (err error) {
+	err = c.c.WithContext(ctx).POST(fmt.Sprintf("/slab/%s/failure", key), nil, nil)
+	return
+}
+
 // SlabsForMigration returns up to 'limit' slabs which require migration. A slab
 // needs to be migrated if it has sectors on contracts that are not part of the
-// given 'set'.
-func (c *Client) SlabsForMigration(ctx context.Context, healthCutoff float64, limit int) (slabs []api.UnhealthySlab, err error) {
+// given 'set'. Slabs whose migration failed within 'maxLastFailure' are
+// skipped.
+func (c *Client) SlabsForMigration(ctx context.Context, healthCutoff float64, maxLastFailure time.Duration, limit int) (slabs []api.UnhealthySlab, err error) {
 	var usr api.UnhealthySlabsResponse
-	err = c.c.WithContext(ctx).POST("/slabs/migration", api.MigrationSlabsRequest{HealthCutoff: healthCutoff, Limit: limit}, &usr)
+	err = c.c.WithContext(ctx).POST("/slabs/migration", api.MigrationSlabsRequest{HealthCutoff: healthCutoff, MaxLastFailure: api.DurationMS(maxLastFailure), Limit: limit}, &usr)
 	if err != nil {
 		return
 	}
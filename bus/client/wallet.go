@@ -2,6 +2,7 @@ package client
 
 import (
 	"context"
+	"errors"
 	"net/url"
 
 	"go.sia.tech/core/types"
@@ -9,6 +10,12 @@ import (
 	"go.sia.tech/renterd/api"
 )
 
+// EstimateFee estimates the miner fee of the given transaction.
+func (c *Client) EstimateFee(ctx context.Context, txn types.Transaction) (fee types.Currency, err error) {
+	err = c.c.WithContext(ctx).POST("/wallet/estimatefee", txn, &fee)
+	return
+}
+
 // SendSiacoins is a helper method that sends siacoins to the given address.
 func (c *Client) SendSiacoins(ctx context.Context, addr types.Address, amt types.Currency, useUnconfirmedTxns bool) (txnID types.TransactionID, err error) {
 	err = c.c.WithContext(ctx).POST("/wallet/send", api.WalletSendRequest{
@@ -46,6 +53,19 @@ func (c *Client) WalletRedistribute(ctx context.Context, outputs int, amount typ
 	return
 }
 
+// WalletSweepDust consolidates all spendable wallet outputs with a value below
+// the given threshold into larger outputs. It returns the IDs of the submitted
+// transactions, which are also returned if sweeping failed part way through.
+func (c *Client) WalletSweepDust(ctx context.Context, threshold types.Currency) ([]types.TransactionID, error) {
+	var resp api.WalletSweepDustResponse
+	if err := c.c.WithContext(ctx).POST("/wallet/sweepdust", api.WalletSweepDustRequest{Threshold: threshold}, &resp); err != nil {
+		return nil, err
+	} else if resp.Error != "" {
+		return resp.TransactionIDs, errors.New(resp.Error)
+	}
+	return resp.TransactionIDs, nil
+}
+
 // WalletEvents returns all events relevant to the wallet.
 func (c *Client) WalletEvents(ctx context.Context, opts ...api.WalletTransactionsOption) (resp []wallet.Event, err error) {
 	values := url.Values{}
@@ -55,3 +75,12 @@ func (c *Client) WalletEvents(ctx context.Context, opts ...api.WalletTransaction
 	err = c.c.WithContext(ctx).GET("/wallet/events?"+values.Encode(), &resp)
 	return
 }
+
+// WalletEventStream streams wallet events as they are applied to the wallet.
+// The connection is re-established whenever the bus closes it, events applied
+// while reconnecting are missed. The returned channel is closed once the
+// context is cancelled.
+func (c *Client) WalletEventStream(ctx context.Context) (<-chan wallet.Event, error) {
+	c.c.Custom("GET", "/wallet/events/stream", nil, (*[]wallet.Event)(nil))
+	return eventStream[wallet.Event](ctx, c, "/wallet/events/stream")
+}
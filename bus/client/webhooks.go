@@ -2,6 +2,7 @@ package client
 
 import (
 	"context"
+	"fmt"
 
 	"go.sia.tech/renterd/api"
 	"go.sia.tech/renterd/webhooks"
@@ -13,6 +14,12 @@ func (c *Client) BroadcastAction(ctx context.Context, action webhooks.Event) err
 	return err
 }
 
+// RetryWebhookDeadLetter attempts to redeliver the dead letter with the given
+// id.
+func (c *Client) RetryWebhookDeadLetter(ctx context.Context, id int64) error {
+	return c.c.WithContext(ctx).POST(fmt.Sprintf("/webhooks/dead-letters/%d/retry", id), nil, nil)
+}
+
 // UnregisterWebhook unregisters the given webhook.
 func (c *Client) UnregisterWebhook(ctx context.Context, webhook webhooks.Webhook) error {
 	return c.c.POST("/webhook/delete", webhook, nil)
@@ -29,3 +36,10 @@ func (c *Client) Webhooks(ctx context.Context) (resp api.WebhookResponse, err er
 	err = c.c.WithContext(ctx).GET("/webhooks", &resp)
 	return
 }
+
+// WebhookDeadLetters returns all events that couldn't be delivered to their
+// webhook.
+func (c *Client) WebhookDeadLetters(ctx context.Context) (resp []webhooks.DeadLetter, err error) {
+	err = c.c.WithContext(ctx).GET("/webhooks/dead-letters", &resp)
+	return
+}
@@ -70,57 +70,10 @@ func (b *Bus) pruneContractV1(ctx context.Context, rk types.PrivateKey, cm api.C
 }
 
 func (b *Bus) pruneContractV2(ctx context.Context, rk types.PrivateKey, cm api.ContractMetadata, hostIP string, gc gouging.Checker, pendingUploads map[types.Hash256]struct{}) (api.ContractPruneResponse, error) {
-	signer := ibus.NewFormContractSigner(b.w, rk)
-
-	// get latest revision
-	rev, err := b.rhp4Client.LatestRevision(ctx, cm.HostKey, hostIP, cm.ID)
-	if err != nil {
-		return api.ContractPruneResponse{}, fmt.Errorf("failed to fetch revision for pruning: %w", err)
-	} else if rev.RevisionNumber < cm.RevisionNumber {
-		return api.ContractPruneResponse{}, fmt.Errorf("latest known revision %d is less than contract revision %d", rev.RevisionNumber, cm.RevisionNumber)
-	}
-
-	// get prices
-	settings, err := b.rhp4Client.Settings(ctx, cm.HostKey, hostIP)
-	if err != nil {
-		return api.ContractPruneResponse{}, fmt.Errorf("failed to fetch prices for pruning: %w", err)
-	}
-	prices := settings.Prices
-
-	// make sure they are sane
-	if gb := gc.CheckV2(settings); gb.Gouging() {
-		return api.ContractPruneResponse{}, fmt.Errorf("host for pruning is gouging: %v", gb.String())
-	}
-
 	// fetch all contract roots
-	numsectors := rev.Filesize / rhpv4.SectorSize
-	sectorRoots := make([]types.Hash256, 0, numsectors)
-	var rootsUsage rhpv4.Usage
-	for offset := uint64(0); offset < numsectors; {
-		// calculate the batch size
-		length := uint64(rhpv4.MaxSectorBatchSize)
-		if offset+length > numsectors {
-			length = numsectors - offset
-		}
-
-		// fetch the batch
-		res, err := b.rhp4Client.SectorRoots(ctx, cm.HostKey, hostIP, b.cm.TipState(), prices, signer, cRHP4.ContractRevision{
-			ID:       cm.ID,
-			Revision: rev,
-		}, offset, length)
-		if err != nil {
-			return api.ContractPruneResponse{}, err
-		}
-
-		// update revision since it was revised
-		rev = res.Revision
-
-		// collect roots
-		sectorRoots = append(sectorRoots, res.Roots...)
-		offset += uint64(len(res.Roots))
-
-		// update the cost
-		rootsUsage = rootsUsage.Add(res.Usage)
+	sectorRoots, rev, prices, rootsUsage, err := b.contractRootsV2(ctx, rk, cm, hostIP, gc)
+	if err != nil {
+		return api.ContractPruneResponse{}, err
 	}
 
 	// fetch indices to prune
@@ -183,3 +136,62 @@ func (b *Bus) pruneContractV2(ctx context.Context, rk types.PrivateKey, cm api.C
 		Remaining:    (totalToPrune - uint64(len(toPrune))) * rhpv4.SectorSize,
 	}, nil
 }
+
+// contractRootsV2 fetches all sector roots of the given contract from the host
+// and returns them alongside the contract's latest revision, the host's prices
+// and the cost of fetching the roots.
+func (b *Bus) contractRootsV2(ctx context.Context, rk types.PrivateKey, cm api.ContractMetadata, hostIP string, gc gouging.Checker) ([]types.Hash256, types.V2FileContract, rhpv4.HostPrices, rhpv4.Usage, error) {
+	signer := ibus.NewFormContractSigner(b.w, rk)
+
+	// get latest revision
+	rev, err := b.rhp4Client.LatestRevision(ctx, cm.HostKey, hostIP, cm.ID)
+	if err != nil {
+		return nil, types.V2FileContract{}, rhpv4.HostPrices{}, rhpv4.Usage{}, fmt.Errorf("failed to fetch revision: %w", err)
+	} else if rev.RevisionNumber < cm.RevisionNumber {
+		return nil, types.V2FileContract{}, rhpv4.HostPrices{}, rhpv4.Usage{}, fmt.Errorf("latest known revision %d is less than contract revision %d", rev.RevisionNumber, cm.RevisionNumber)
+	}
+
+	// get prices
+	settings, err := b.rhp4Client.Settings(ctx, cm.HostKey, hostIP)
+	if err != nil {
+		return nil, types.V2FileContract{}, rhpv4.HostPrices{}, rhpv4.Usage{}, fmt.Errorf("failed to fetch prices: %w", err)
+	}
+	prices := settings.Prices
+
+	// make sure they are sane
+	if gb := gc.CheckV2(settings); gb.Gouging() {
+		return nil, types.V2FileContract{}, rhpv4.HostPrices{}, rhpv4.Usage{}, fmt.Errorf("host is gouging: %v", gb.String())
+	}
+
+	// fetch all contract roots
+	numsectors := rev.Filesize / rhpv4.SectorSize
+	sectorRoots := make([]types.Hash256, 0, numsectors)
+	var rootsUsage rhpv4.Usage
+	for offset := uint64(0); offset < numsectors; {
+		// calculate the batch size
+		length := uint64(rhpv4.MaxSectorBatchSize)
+		if offset+length > numsectors {
+			length = numsectors - offset
+		}
+
+		// fetch the batch
+		res, err := b.rhp4Client.SectorRoots(ctx, cm.HostKey, hostIP, b.cm.TipState(), prices, signer, cRHP4.ContractRevision{
+			ID:       cm.ID,
+			Revision: rev,
+		}, offset, length)
+		if err != nil {
+			return nil, types.V2FileContract{}, rhpv4.HostPrices{}, rhpv4.Usage{}, err
+		}
+
+		// update revision since it was revised
+		rev = res.Revision
+
+		// collect roots
+		sectorRoots = append(sectorRoots, res.Roots...)
+		offset += uint64(len(res.Roots))
+
+		// update the cost
+		rootsUsage = rootsUsage.Add(res.Usage)
+	}
+	return sectorRoots, rev, prices, rootsUsage, nil
+}
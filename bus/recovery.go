@@ -0,0 +1,267 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	rhpv4 "go.sia.tech/core/rhp/v4"
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/api"
+	"go.sia.tech/renterd/internal/gouging"
+	"go.uber.org/zap"
+)
+
+const (
+	// recoveryWorkers is the number of contracts whose roots are fetched
+	// from their hosts in parallel
+	recoveryWorkers = 4
+)
+
+type (
+	// metadataRecovery keeps track of the most recent metadata recovery, only
+	// one recovery can run at a time.
+	metadataRecovery struct {
+		mu      sync.Mutex
+		report  *recoveryReport
+		cancel  context.CancelFunc
+		running bool
+		wg      sync.WaitGroup
+	}
+
+	// A recoveryReport tracks the progress of a metadata recovery, it is safe
+	// for concurrent use.
+	recoveryReport struct {
+		mu     sync.Mutex
+		status api.MetadataRecoveryStatus
+	}
+)
+
+// Status returns a snapshot of the recovery's progress.
+func (r *recoveryReport) Status() api.MetadataRecoveryStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status
+}
+
+// Update applies the given function to the recovery's progress.
+func (r *recoveryReport) Update(fn func(*api.MetadataRecoveryStatus)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fn(&r.status)
+}
+
+// startMetadataRecovery starts a metadata recovery in the background, it
+// returns ErrRecoveryInProgress if a recovery is already running.
+func (b *Bus) startMetadataRecovery() error {
+	r := b.recovery
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.running {
+		return api.ErrRecoveryInProgress
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.report = new(recoveryReport)
+	r.report.Update(func(rs *api.MetadataRecoveryStatus) {
+		rs.Running = true
+		rs.StartedAt = api.TimeRFC3339(time.Now())
+	})
+	r.cancel = cancel
+	r.running = true
+
+	report := r.report
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer cancel()
+
+		b.logger.Info("starting metadata recovery")
+		err := b.recoverMetadata(ctx, report)
+		report.Update(func(rs *api.MetadataRecoveryStatus) {
+			rs.Running = false
+			rs.FinishedAt = api.TimeRFC3339(time.Now())
+			if err != nil {
+				rs.Error = err.Error()
+			}
+		})
+		if err != nil {
+			b.logger.Errorw("metadata recovery failed", zap.Error(err))
+		} else {
+			s := report.Status()
+			b.logger.Infow("metadata recovery finished", "contracts", s.ContractsScanned, "failed", s.ContractsFailed, "recovered", s.ObjectsRecovered, "deleted", s.ObjectsDeleted)
+		}
+
+		r.mu.Lock()
+		r.running = false
+		r.mu.Unlock()
+	}()
+	return nil
+}
+
+// recoverMetadata fetches the sector roots of all active contracts from their
+// hosts and matches them back to the slabs that contain them, every object
+// referencing a matched slab is recorded as recovered and objects that were
+// deleted but whose noncurrent versions still reference a matched slab are
+// recorded as deleted. Contracts that fail to scan are skipped and counted in
+// the report, the recovery itself only fails if the contracts can't be
+// fetched or the context is cancelled.
+func (b *Bus) recoverMetadata(ctx context.Context, report *recoveryReport) error {
+	contracts, err := b.store.Contracts(ctx, api.ContractsOpts{FilterMode: api.ContractFilterModeActive})
+	if err != nil {
+		return fmt.Errorf("failed to fetch contracts: %w", err)
+	}
+	report.Update(func(rs *api.MetadataRecoveryStatus) {
+		rs.ContractsTotal = uint64(len(contracts))
+	})
+
+	// create gouging checker
+	gp, err := b.gougingParams(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch gouging parameters: %w", err)
+	}
+	gc := gouging.NewChecker(gp.GougingSettings, gp.ConsensusState)
+
+	contractChan := make(chan api.ContractMetadata)
+	var wg sync.WaitGroup
+	for i := 0; i < recoveryWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range contractChan {
+				if err := b.recoverContract(ctx, c, gc, report); err != nil && !errors.Is(err, context.Canceled) {
+					b.logger.Warnw("failed to recover metadata from contract", "fcid", c.ID, "hk", c.HostKey, zap.Error(err))
+					report.Update(func(rs *api.MetadataRecoveryStatus) { rs.ContractsFailed++ })
+				}
+				report.Update(func(rs *api.MetadataRecoveryStatus) { rs.ContractsScanned++ })
+			}
+		}()
+	}
+
+LOOP:
+	for _, c := range contracts {
+		select {
+		case <-ctx.Done():
+			break LOOP
+		case contractChan <- c:
+		}
+	}
+	close(contractChan)
+	wg.Wait()
+
+	return context.Cause(ctx)
+}
+
+// recoverContract fetches the sector roots of the given contract from its host
+// and matches them against the slabs in the database.
+func (b *Bus) recoverContract(ctx context.Context, c api.ContractMetadata, gc gouging.Checker, report *recoveryReport) error {
+	// fetching the roots revises the contract, so we need to lock it
+	lockID, err := b.contractLocker.Acquire(ctx, lockingPriorityPruning, c.ID, time.Duration(math.MaxInt64))
+	if err != nil {
+		return fmt.Errorf("failed to acquire contract lock: %w", err)
+	}
+	defer func() {
+		if err := b.contractLocker.Release(c.ID, lockID); err != nil {
+			b.logger.Error("failed to release contract lock", zap.Error(err))
+		}
+	}()
+
+	host, err := b.store.Host(ctx, c.HostKey)
+	if err != nil {
+		return fmt.Errorf("failed to fetch host: %w", err)
+	}
+
+	// fetch the roots from the host and record the spending
+	rk := b.masterKey.DeriveContractKey(c.HostKey)
+	var roots []types.Hash256
+	var record api.ContractSpendingRecord
+	if b.isPassedV2AllowHeight() {
+		var rev types.V2FileContract
+		var usage rhpv4.Usage
+		roots, rev, _, usage, err = b.contractRootsV2(ctx, rk, c, host.V2SiamuxAddr(), gc)
+		if err != nil {
+			return fmt.Errorf("failed to fetch contract roots: %w", err)
+		}
+		record = api.ContractSpendingRecord{
+			ContractSpending:  api.ContractSpending{SectorRoots: usage.RenterCost()},
+			ContractID:        c.ID,
+			RevisionNumber:    rev.RevisionNumber,
+			Size:              rev.Filesize,
+			MissedHostPayout:  rev.MissedHostOutput().Value,
+			ValidRenterPayout: rev.RenterOutput.Value,
+		}
+	} else {
+		var rev *types.FileContractRevision
+		var cost types.Currency
+		roots, rev, cost, err = b.rhp2Client.ContractRoots(ctx, rk, gc, host.NetAddress, c.HostKey, c.ID, c.RevisionNumber)
+		if err != nil {
+			return fmt.Errorf("failed to fetch contract roots: %w", err)
+		} else if rev != nil {
+			record = api.ContractSpendingRecord{
+				ContractSpending:  api.ContractSpending{SectorRoots: cost},
+				ContractID:        c.ID,
+				RevisionNumber:    rev.RevisionNumber,
+				Size:              rev.Filesize,
+				MissedHostPayout:  rev.MissedHostPayout(),
+				ValidRenterPayout: rev.ValidRenterPayout(),
+			}
+		}
+	}
+	if !record.SectorRoots.IsZero() {
+		if err := b.store.RecordContractSpending(ctx, []api.ContractSpendingRecord{record}); err != nil {
+			b.logger.Warnw("failed to record contract spending", "fcid", c.ID, zap.Error(err))
+		}
+	}
+
+	// match the roots against the slabs in the database
+	matched, recovered, deleted, err := b.store.RecoverObjects(ctx, roots)
+	report.Update(func(rs *api.MetadataRecoveryStatus) {
+		rs.SectorsScanned += uint64(len(roots))
+		rs.SectorsMatched += matched
+		rs.ObjectsRecovered += recovered
+		rs.ObjectsDeleted += deleted
+	})
+	if err != nil {
+		return fmt.Errorf("failed to recover objects: %w", err)
+	}
+	return nil
+}
+
+// metadataRecoveryStatus returns the progress of the most recent metadata
+// recovery.
+func (b *Bus) metadataRecoveryStatus() api.MetadataRecoveryStatus {
+	r := b.recovery
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.report == nil {
+		return api.MetadataRecoveryStatus{}
+	}
+	return r.report.Status()
+}
+
+// shutdownMetadataRecovery interrupts a running metadata recovery and waits
+// for it to exit.
+func (b *Bus) shutdownMetadataRecovery(ctx context.Context) error {
+	r := b.recovery
+	r.mu.Lock()
+	if r.cancel != nil {
+		r.cancel()
+	}
+	r.mu.Unlock()
+
+	doneChan := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(doneChan)
+	}()
+
+	select {
+	case <-doneChan:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
@@ -0,0 +1,190 @@
+package bus
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/alerts"
+	"go.sia.tech/renterd/api"
+)
+
+const (
+	// healthReportCacheDuration is the amount of time a generated health
+	// report is served from the cache
+	healthReportCacheDuration = time.Hour
+
+	// healthReportCacheSize is the maximum number of cached health reports,
+	// the report that was generated first is evicted when the cache is full
+	healthReportCacheSize = 10
+
+	// healthReportTopHosts is the number of hosts included in the list of
+	// hosts we spent the most on
+	healthReportTopHosts = 5
+)
+
+// healthReports caches the most recently generated health report per period.
+type healthReports struct {
+	mu      sync.Mutex
+	reports map[api.ReportPeriod]api.HealthReport
+}
+
+// healthReport returns the health report for the given period, reports are
+// cached for healthReportCacheDuration. Reports are generated without holding
+// the lock, so concurrent requests for the same period might both generate a
+// report.
+func (b *Bus) healthReport(ctx context.Context, period api.ReportPeriod) (api.HealthReport, error) {
+	c := b.reports
+	c.mu.Lock()
+	report, ok := c.reports[period]
+	c.mu.Unlock()
+	if ok && time.Since(time.Time(report.GeneratedAt)) < healthReportCacheDuration {
+		return report, nil
+	}
+
+	report, err := b.generateHealthReport(ctx, period)
+	if err != nil {
+		return api.HealthReport{}, err
+	}
+	c.add(period, report)
+	return report, nil
+}
+
+// add adds the report to the cache, expired reports are evicted and if the
+// cache is still full the oldest report is evicted.
+func (c *healthReports) add(period api.ReportPeriod, report api.HealthReport) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var oldest api.ReportPeriod
+	for p, r := range c.reports {
+		if time.Since(time.Time(r.GeneratedAt)) >= healthReportCacheDuration {
+			delete(c.reports, p)
+		} else if _, ok := c.reports[oldest]; !ok || time.Time(r.GeneratedAt).Before(time.Time(c.reports[oldest].GeneratedAt)) {
+			oldest = p
+		}
+	}
+	if _, exists := c.reports[period]; !exists && len(c.reports) >= healthReportCacheSize {
+		delete(c.reports, oldest)
+	}
+	c.reports[period] = report
+}
+
+// generateHealthReport generates a health report covering the given period.
+func (b *Bus) generateHealthReport(ctx context.Context, period api.ReportPeriod) (api.HealthReport, error) {
+	now := time.Now()
+	start := now.Add(-time.Duration(period))
+	report := api.HealthReport{
+		GeneratedAt: api.TimeRFC3339(now),
+		Start:       api.TimeRFC3339(start),
+		Period:      api.DurationMS(period),
+	}
+
+	// storage
+	stats, err := b.store.ObjectsStats(ctx, api.ObjectsStatsOpts{})
+	if err != nil {
+		return api.HealthReport{}, fmt.Errorf("failed to fetch object stats: %w", err)
+	}
+	report.Storage = api.HealthReportStorage{
+		NumObjects:        stats.NumObjects,
+		MinHealth:         stats.MinHealth,
+		TotalObjectsSize:  stats.TotalObjectsSize,
+		TotalSectorsSize:  stats.TotalSectorsSize,
+		TotalUploadedSize: stats.TotalUploadedSize,
+	}
+
+	// contracts
+	contracts, err := b.store.Contracts(ctx, api.ContractsOpts{FilterMode: api.ContractFilterModeActive})
+	if err != nil {
+		return api.HealthReport{}, fmt.Errorf("failed to fetch contracts: %w", err)
+	}
+	sizes, err := b.store.ContractSizes(ctx)
+	if err != nil {
+		return api.HealthReport{}, fmt.Errorf("failed to fetch contract sizes: %w", err)
+	}
+
+	// fetch the spending of every contract at the start of the period, only
+	// the spending within the period counts towards the top hosts
+	startMetrics, err := b.store.LatestContractMetrics(ctx, start)
+	if err != nil {
+		return api.HealthReport{}, fmt.Errorf("failed to fetch contract metrics: %w", err)
+	}
+	startSpending := make(map[types.FileContractID]types.Currency, len(startMetrics))
+	for _, m := range startMetrics {
+		startSpending[m.ContractID] = m.UploadSpending.Add(m.FundAccountSpending).Add(m.DeleteSpending).Add(m.SectorRootsSpending)
+	}
+
+	hostsUsed := make(map[types.PublicKey]struct{})
+	spendingPerHost := make(map[types.PublicKey]types.Currency)
+	for _, c := range contracts {
+		hostsUsed[c.HostKey] = struct{}{}
+		report.Contracts.Active++
+		if c.IsGood() {
+			report.Contracts.Good++
+		}
+		report.Contracts.TotalSize += sizes[c.ID].Size
+		report.Contracts.Prunable += sizes[c.ID].Prunable
+		report.Contracts.UploadSpending = report.Contracts.UploadSpending.Add(c.Spending.Uploads)
+		report.Contracts.TotalSpending = report.Contracts.TotalSpending.Add(c.Spending.Total())
+		if total := c.Spending.Total(); total.Cmp(startSpending[c.ID]) > 0 {
+			spendingPerHost[c.HostKey] = spendingPerHost[c.HostKey].Add(total.Sub(startSpending[c.ID]))
+		}
+	}
+
+	// hosts
+	usable, err := b.store.Hosts(ctx, api.HostOptions{
+		FilterMode:    api.HostFilterModeAllowed,
+		UsabilityMode: api.UsabilityFilterModeUsable,
+	})
+	if err != nil {
+		return api.HealthReport{}, fmt.Errorf("failed to fetch usable hosts: %w", err)
+	}
+	report.Hosts.Used = uint64(len(hostsUsed))
+	report.Hosts.Usable = uint64(len(usable))
+	report.Hosts.TopHosts = make([]api.HealthReportHostSpend, 0, len(spendingPerHost))
+	for hk, spending := range spendingPerHost {
+		report.Hosts.TopHosts = append(report.Hosts.TopHosts, api.HealthReportHostSpend{
+			HostKey:  hk,
+			Spending: spending,
+		})
+	}
+	sort.Slice(report.Hosts.TopHosts, func(i, j int) bool {
+		return report.Hosts.TopHosts[i].Spending.Cmp(report.Hosts.TopHosts[j].Spending) > 0
+	})
+	if len(report.Hosts.TopHosts) > healthReportTopHosts {
+		report.Hosts.TopHosts = report.Hosts.TopHosts[:healthReportTopHosts]
+	}
+
+	// wallet, if there is no metric within the period we use the current
+	// balance as the start balance
+	balance, err := b.w.Balance()
+	if err != nil {
+		return api.HealthReport{}, fmt.Errorf("failed to fetch wallet balance: %w", err)
+	}
+	report.Wallet.CurrentBalance = balance.Confirmed
+	report.Wallet.StartBalance = balance.Confirmed
+	metrics, err := b.store.WalletMetrics(ctx, start, 1, time.Duration(period), api.WalletMetricsQueryOpts{})
+	if err != nil {
+		return api.HealthReport{}, fmt.Errorf("failed to fetch wallet metrics: %w", err)
+	} else if len(metrics) > 0 {
+		report.Wallet.StartBalance = metrics[0].Confirmed
+	}
+
+	// incidents, including the ones that were resolved within the period
+	for _, a := range b.alertMgr.Incidents(start) {
+		switch a.Severity {
+		case alerts.SeverityInfo:
+			report.Incidents.Info++
+		case alerts.SeverityWarning:
+			report.Incidents.Warning++
+		case alerts.SeverityError:
+			report.Incidents.Error++
+		case alerts.SeverityCritical:
+			report.Incidents.Critical++
+		}
+	}
+	return report, nil
+}
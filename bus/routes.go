@@ -11,6 +11,7 @@ import (
 	"runtime"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	rhpv2 "go.sia.tech/core/rhp/v2"
@@ -18,6 +19,7 @@ import (
 	rhpv4 "go.sia.tech/core/rhp/v4"
 
 	rhp4utils "go.sia.tech/coreutils/rhp/v4"
+	"go.sia.tech/coreutils/wallet"
 	ibus "go.sia.tech/renterd/internal/bus"
 	"go.sia.tech/renterd/internal/prometheus"
 	rhp3 "go.sia.tech/renterd/internal/rhp/v3"
@@ -151,6 +153,9 @@ func (b *Bus) accountsFundHandler(jc jape.Context) {
 		spending = api.ContractSpendingRecord{
 			ContractSpending: api.ContractSpending{
 				FundAccount: deposit.Add(cost),
+
+				AccountFundingFees: cost,
+				PriceTableFees:     pt.UpdatePriceTableCost,
 			},
 			ContractID:     rev.ParentID,
 			RevisionNumber: rev.RevisionNumber,
@@ -202,6 +207,37 @@ func (b *Bus) syncerPeersHandler(jc jape.Context) {
 	api.WriteResponse(jc, api.SyncerPeersResp(peers))
 }
 
+func (b *Bus) syncerPeersLatencyHandler(jc jape.Context) {
+	// ping all peers in parallel
+	peers := b.s.Peers()
+	latencies := make([]api.PeerLatency, len(peers))
+	var wg sync.WaitGroup
+	for i, p := range peers {
+		latencies[i].Addr = p.Addr()
+		wg.Add(1)
+		go func(pl *api.PeerLatency) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(jc.Request.Context(), peerPingTimeout)
+			defer cancel()
+			if rtt, err := b.s.PingPeer(ctx, pl.Addr); err != nil {
+				pl.Error = err.Error()
+			} else {
+				pl.RTT = api.DurationMS(rtt)
+			}
+		}(&latencies[i])
+	}
+	wg.Wait()
+
+	// sort by latency, unreachable peers last
+	sort.SliceStable(latencies, func(i, j int) bool {
+		if (latencies[i].Error == "") != (latencies[j].Error == "") {
+			return latencies[i].Error == ""
+		}
+		return latencies[i].RTT < latencies[j].RTT
+	})
+	jc.Encode(latencies)
+}
+
 func (b *Bus) syncerConnectHandler(jc jape.Context) {
 	var addr string
 	if jc.Decode(&addr) == nil {
@@ -218,6 +254,10 @@ func (b *Bus) consensusStateHandler(jc jape.Context) {
 	api.WriteResponse(jc, cs)
 }
 
+func (b *Bus) consensusForkStatusHandler(jc jape.Context) {
+	api.WriteResponse(jc, b.forkDetector.Status())
+}
+
 func (b *Bus) consensusNetworkHandler(jc jape.Context) {
 	jc.Encode(b.cm.TipState().Network)
 }
@@ -242,6 +282,41 @@ func (b *Bus) postSystemSQLite3BackupHandler(jc jape.Context) {
 	}
 }
 
+func (b *Bus) storeCompactHandlerPOST(jc jape.Context) {
+	err := b.store.Compact(jc.Request.Context())
+	if errors.Is(err, api.ErrCompactNotSupported) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	} else if jc.Check("failed to compact store", err) != nil {
+		return
+	}
+}
+
+func (b *Bus) storeVacuumHandlerPOST(jc jape.Context) {
+	vs, err := b.startStoreVacuum()
+	if errors.Is(err, api.ErrVacuumNotSupported) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	} else if errors.Is(err, api.ErrVacuumInProgress) {
+		jc.Error(err, http.StatusConflict)
+		return
+	} else if jc.Check("failed to start vacuum", err) != nil {
+		return
+	}
+	jc.ResponseWriter.Header().Set("Content-Type", "application/json")
+	jc.ResponseWriter.WriteHeader(http.StatusAccepted)
+	jc.Encode(vs)
+}
+
+func (b *Bus) storeVacuumHandlerGET(jc jape.Context) {
+	vs, err := b.storeVacuumStatus(jc.PathParam("id"))
+	if errors.Is(err, api.ErrVacuumNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	}
+	jc.Encode(vs)
+}
+
 func (b *Bus) txpoolFeeHandler(jc jape.Context) {
 	api.WriteResponse(jc, api.TxPoolFeeResp{Currency: b.cm.RecommendedFee()})
 }
@@ -298,6 +373,9 @@ func (b *Bus) bucketsHandlerPolicyPUT(jc jape.Context) {
 	if bucket == "" {
 		jc.Error(errors.New("no bucket name provided"), http.StatusBadRequest)
 		return
+	} else if err := req.Policy.Validate(); err != nil {
+		jc.Error(err, http.StatusBadRequest)
+		return
 	}
 
 	err := b.store.UpdateBucketPolicy(jc.Request.Context(), bucket, req.Policy)
@@ -329,6 +407,24 @@ func (b *Bus) bucketHandlerDELETE(jc jape.Context) {
 	jc.Check("failed to delete bucket", err)
 }
 
+func (b *Bus) bucketLifecycleApplyHandlerPOST(jc jape.Context) {
+	var name string
+	if jc.DecodeParam("name", &name) != nil {
+		return
+	} else if name == "" {
+		jc.Error(errors.New("parameter 'name' is required"), http.StatusBadRequest)
+		return
+	}
+	removed, err := b.store.ApplyLifecycleRules(jc.Request.Context(), name)
+	if errors.Is(err, api.ErrBucketNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	} else if jc.Check("failed to apply lifecycle rules", err) != nil {
+		return
+	}
+	jc.Encode(api.ObjectsRemoveResponse{Removed: removed})
+}
+
 func (b *Bus) bucketHandlerGET(jc jape.Context) {
 	var name string
 	if jc.DecodeParam("name", &name) != nil {
@@ -378,7 +474,47 @@ func (b *Bus) walletEventsHandler(jc jape.Context) {
 		return
 	}
 
-	events, err := b.w.Events(offset, limit)
+	var since, until time.Time
+	if jc.DecodeForm("since", (*api.TimeRFC3339)(&since)) != nil {
+		return
+	} else if jc.DecodeForm("until", (*api.TimeRFC3339)(&until)) != nil {
+		return
+	} else if !since.IsZero() && !until.IsZero() && !since.Before(until) {
+		jc.Error(errors.New("'since' must be before 'until'"), http.StatusBadRequest)
+		return
+	}
+
+	var minAmount types.Currency
+	if jc.DecodeForm("minAmount", &minAmount) != nil {
+		return
+	}
+
+	var txnType string
+	if jc.DecodeForm("txnType", &txnType) != nil {
+		return
+	}
+	switch txnType {
+	case "",
+		wallet.EventTypeMinerPayout,
+		wallet.EventTypeFoundationSubsidy,
+		wallet.EventTypeSiafundClaim,
+		wallet.EventTypeV1Transaction,
+		wallet.EventTypeV1ContractResolution,
+		wallet.EventTypeV2Transaction,
+		wallet.EventTypeV2ContractResolution:
+	default:
+		jc.Error(fmt.Errorf("invalid txnType '%v'", txnType), http.StatusBadRequest)
+		return
+	}
+
+	events, err := b.store.FilteredWalletEvents(jc.Request.Context(), api.WalletEventsOpts{
+		Offset:    offset,
+		Limit:     limit,
+		Since:     since,
+		Until:     until,
+		MinAmount: minAmount,
+		Type:      txnType,
+	})
 	if jc.Check("couldn't load events", err) != nil {
 		return
 	}
@@ -394,6 +530,77 @@ func (b *Bus) walletEventsHandler(jc jape.Context) {
 	jc.Encode(events)
 }
 
+func (b *Bus) walletEventsStreamHandler(jc jape.Context) {
+	b.streamEvents(jc, api.WebhookModuleWallet, api.WebhookEventWalletEvent, func(payload interface{}) (string, interface{}, bool) {
+		event, ok := payload.(wallet.Event)
+		if !ok {
+			return "", nil, false
+		}
+		return event.ID.String(), event, true
+	})
+}
+
+// streamEvents subscribes to the given webhook event and writes the events to
+// the response as server-sent events until the request is done or the bus is
+// shutting down. The given function returns the id and data of an event's
+// payload, payloads it doesn't recognise are skipped.
+func (b *Bus) streamEvents(jc jape.Context, module, event string, encode func(payload interface{}) (id string, data interface{}, ok bool)) {
+	flusher, ok := jc.ResponseWriter.(http.Flusher)
+	if !ok {
+		jc.Error(errors.New("streaming is not supported"), http.StatusInternalServerError)
+		return
+	}
+
+	// subscribe to the events before writing the header to not miss any
+	events, unsubscribe := b.webhooksMgr.Subscribe(module, event)
+	defer unsubscribe()
+
+	header := jc.ResponseWriter.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	jc.ResponseWriter.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// periodically send a comment to keep the connection alive
+	t := time.NewTicker(defaultEventStreamKeepAlive)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-jc.Request.Context().Done():
+			return
+		case <-t.C:
+			if _, err := io.WriteString(jc.ResponseWriter, ": keepalive\n\n"); err != nil {
+				return
+			}
+		case e, ok := <-events:
+			if !ok {
+				return // bus is shutting down
+			}
+			id, data, ok := encode(e.Payload)
+			if !ok {
+				b.logger.Errorf("unexpected %s.%s event payload %T", module, event, e.Payload)
+				continue
+			}
+			js, err := json.Marshal(data)
+			if err != nil {
+				b.logger.Errorw(fmt.Sprintf("failed to encode %s.%s event", module, event), "id", id, zap.Error(err))
+				continue
+			}
+			if id != "" {
+				if _, err := fmt.Fprintf(jc.ResponseWriter, "id: %s\n", id); err != nil {
+					return
+				}
+			}
+			if _, err := fmt.Fprintf(jc.ResponseWriter, "data: %s\n\n", js); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
 func (b *Bus) walletSendSiacoinsHandler(jc jape.Context) {
 	var req api.WalletSendRequest
 	if jc.Decode(&req) != nil {
@@ -546,6 +753,40 @@ func (b *Bus) walletRedistributeHandler(jc jape.Context) {
 	jc.Encode(ids)
 }
 
+func (b *Bus) walletSweepDustHandler(jc jape.Context) {
+	var req api.WalletSweepDustRequest
+	if jc.Decode(&req) != nil {
+		return
+	} else if req.Threshold.IsZero() {
+		jc.Error(errors.New("'threshold' has to be greater than zero"), http.StatusBadRequest)
+		return
+	}
+
+	// only fail the request if no transactions were submitted, otherwise the
+	// caller needs their IDs
+	ids, err := b.w.SweepDust(jc.Request.Context(), req.Threshold)
+	if len(ids) == 0 && jc.Check("couldn't sweep dust", err) != nil {
+		return
+	}
+
+	resp := api.WalletSweepDustResponse{TransactionIDs: ids}
+	if resp.TransactionIDs == nil {
+		resp.TransactionIDs = []types.TransactionID{}
+	}
+	if err != nil {
+		resp.Error = fmt.Sprintf("couldn't sweep dust: %v", err)
+	}
+	jc.Encode(resp)
+}
+
+func (b *Bus) walletEstimateFeeHandler(jc jape.Context) {
+	var txn types.Transaction
+	if jc.Decode(&txn) != nil {
+		return
+	}
+	jc.Encode(b.estimateFee(txn))
+}
+
 func (b *Bus) walletPendingHandler(jc jape.Context) {
 	events, err := b.w.UnconfirmedEvents()
 	if jc.Check("couldn't fetch unconfirmed events", err) != nil {
@@ -590,11 +831,12 @@ func (b *Bus) hostsHandlerPOST(jc jape.Context) {
 	switch req.UsabilityMode {
 	case api.UsabilityFilterModeUsable:
 	case api.UsabilityFilterModeUnusable:
+	case api.UsabilityFilterModeRHP3:
 	case api.UsabilityFilterModeAll:
 	case "":
 		req.UsabilityMode = api.UsabilityFilterModeAll
 	default:
-		jc.Error(fmt.Errorf("invalid usability mode: '%v', options are 'usable', 'unusable' or an empty string for no filter", req.UsabilityMode), http.StatusBadRequest)
+		jc.Error(fmt.Errorf("invalid usability mode: '%v', options are 'usable', 'unusable', 'rhp3' or an empty string for no filter", req.UsabilityMode), http.StatusBadRequest)
 		return
 	}
 
@@ -610,10 +852,12 @@ func (b *Bus) hostsHandlerPOST(jc jape.Context) {
 		return
 	}
 
-	// validate the offset and limit
-	if req.Offset < 0 {
-		jc.Error(errors.New("offset must be non-negative"), http.StatusBadRequest)
-		return
+	// validate the cursor and limit
+	if req.Cursor != "" {
+		if _, err := api.ParseHostCursor(req.Cursor); err != nil {
+			jc.Error(err, http.StatusBadRequest)
+			return
+		}
 	}
 	if req.Limit < 0 && req.Limit != -1 {
 		jc.Error(errors.New("limit must be non-negative or equal to -1 to indicate no limit"), http.StatusBadRequest)
@@ -627,11 +871,11 @@ func (b *Bus) hostsHandlerPOST(jc jape.Context) {
 		UsabilityMode:   req.UsabilityMode,
 		AddressContains: req.AddressContains,
 		KeyIn:           req.KeyIn,
-		Offset:          req.Offset,
+		Cursor:          req.Cursor,
 		Limit:           req.Limit,
 		MaxLastScan:     req.MaxLastScan,
 	})
-	if jc.Check(fmt.Sprintf("couldn't fetch hosts %d-%d", req.Offset, req.Offset+req.Limit), err) != nil {
+	if jc.Check("couldn't fetch hosts", err) != nil {
 		return
 	}
 	api.WriteResponse(jc, prometheus.Slice(hosts))
@@ -657,6 +901,19 @@ func (b *Bus) hostsRemoveHandlerPOST(jc jape.Context) {
 	jc.Encode(removed)
 }
 
+func (b *Bus) hostsPubkeyHandlerDELETE(jc jape.Context) {
+	var hostKey types.PublicKey
+	if jc.DecodeParam("hostkey", &hostKey) != nil {
+		return
+	}
+	err := b.store.DeleteHost(jc.Request.Context(), hostKey)
+	if errors.Is(err, api.ErrHostNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	}
+	jc.Check("couldn't delete host", err)
+}
+
 func (b *Bus) hostsPubkeyHandlerGET(jc jape.Context) {
 	var hostKey types.PublicKey
 	if jc.DecodeParam("hostkey", &hostKey) != nil {
@@ -739,6 +996,17 @@ func (b *Bus) contractsSpendingHandlerPOST(jc jape.Context) {
 	}
 }
 
+func (b *Bus) hostsPriceHistoryHandlerGET(jc jape.Context) {
+	var since time.Time
+	if jc.DecodeForm("since", (*api.TimeRFC3339)(&since)) != nil {
+		return
+	}
+	history, err := b.store.HostPriceHistory(jc.Request.Context(), since)
+	if jc.Check("couldn't load host price history", err) == nil {
+		jc.Encode(history)
+	}
+}
+
 func (b *Bus) hostsAllowlistHandlerGET(jc jape.Context) {
 	allowlist, err := b.store.HostAllowlist(jc.Request.Context())
 	if jc.Check("couldn't load allowlist", err) == nil {
@@ -795,12 +1063,46 @@ func (b *Bus) contractsHandlerGET(jc jape.Context) {
 		return
 	}
 
+	var sortBy string
+	if jc.DecodeForm("sortby", &sortBy) != nil {
+		return
+	} else if sortBy != "" && sortBy != api.ContractSortByHealth {
+		jc.Error(fmt.Errorf("invalid sort by '%v', must be one of [health]", sortBy), http.StatusBadRequest)
+		return
+	}
+
 	contracts, err := b.store.Contracts(jc.Request.Context(), api.ContractsOpts{
 		FilterMode: filterMode,
+		SortBy:     sortBy,
 	})
-	if jc.Check("couldn't load contracts", err) == nil {
-		api.WriteResponse(jc, prometheus.Slice(contracts))
+	if jc.Check("couldn't load contracts", err) != nil {
+		return
+	}
+	for i := range contracts {
+		contracts[i] = b.withComputedFields(contracts[i])
+	}
+	if sortBy == api.ContractSortByHealth {
+		sort.SliceStable(contracts, func(i, j int) bool {
+			return contracts[i].HealthScore < contracts[j].HealthScore
+		})
+	}
+	api.WriteResponse(jc, prometheus.Slice(contracts))
+}
+
+func (b *Bus) contractsExpiringHandlerGET(jc jape.Context) {
+	blocks := uint64(defaultContractsExpiringBlocks)
+	if jc.DecodeForm("blocks", &blocks) != nil {
+		return
 	}
+
+	contracts, err := b.store.ContractsExpiring(jc.Request.Context(), blocks)
+	if jc.Check("couldn't load expiring contracts", err) != nil {
+		return
+	}
+	for i := range contracts {
+		contracts[i] = b.withComputedFields(contracts[i])
+	}
+	api.WriteResponse(jc, prometheus.Slice(contracts))
 }
 
 func (b *Bus) contractsRenewedIDHandlerGET(jc jape.Context) {
@@ -811,7 +1113,7 @@ func (b *Bus) contractsRenewedIDHandlerGET(jc jape.Context) {
 
 	md, err := b.store.RenewedContract(jc.Request.Context(), id)
 	if jc.Check("faild to fetch renewed contract", err) == nil {
-		jc.Encode(md)
+		jc.Encode(b.withComputedFields(md))
 	}
 }
 
@@ -1030,6 +1332,22 @@ func (b *Bus) contractSizeHandlerGET(jc jape.Context) {
 	jc.Encode(size)
 }
 
+func (b *Bus) contractStorageProofHandlerGET(jc jape.Context) {
+	var id types.FileContractID
+	if jc.DecodeParam("id", &id) != nil {
+		return
+	}
+
+	proof, err := b.store.StorageProof(jc.Request.Context(), id)
+	if errors.Is(err, api.ErrStorageProofNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	} else if jc.Check("failed to fetch storage proof", err) != nil {
+		return
+	}
+	jc.Encode(proof)
+}
+
 func (b *Bus) contractUsabilityHandlerPUT(jc jape.Context) {
 	var id types.FileContractID
 	if jc.DecodeParam("id", &id) != nil {
@@ -1074,7 +1392,7 @@ func (b *Bus) contractIDHandlerGET(jc jape.Context) {
 	}
 	c, err := b.store.Contract(jc.Request.Context(), id)
 	if jc.Check("couldn't load contract", err) == nil {
-		jc.Encode(c)
+		jc.Encode(b.withComputedFields(c))
 	}
 }
 
@@ -1090,6 +1408,11 @@ func (b *Bus) contractsHandlerPUT(jc jape.Context) {
 }
 
 func (b *Bus) contractIDRenewHandlerPOST(jc jape.Context) {
+	if err := b.contractOperationsPaused(); err != nil {
+		jc.Error(err, http.StatusServiceUnavailable)
+		return
+	}
+
 	// apply pessimistic timeout
 	ctx, cancel := context.WithTimeout(jc.Request.Context(), 15*time.Minute)
 	defer cancel()
@@ -1142,6 +1465,21 @@ func (b *Bus) contractIDRenewHandlerPOST(jc jape.Context) {
 		return
 	}
 
+	// prevent renewing the same contract twice in a short period of time, the
+	// cooldown is cleared if the renewal fails so it can be retried right away
+	if err := b.contractLocker.ContractRenewalCooldown(ctx, c.ID, defaultContractRenewalCooldown); errors.Is(err, api.ErrContractRenewalCooldown) {
+		jc.Error(err, http.StatusTooManyRequests)
+		return
+	} else if jc.Check("failed to check contract renewal cooldown", err) != nil {
+		return
+	}
+	var renewed bool
+	defer func() {
+		if !renewed {
+			b.contractLocker.ClearContractRenewalCooldown(c.ID)
+		}
+	}()
+
 	// acquire contract lock indefinitely and defer the release
 	lockID, err := b.contractLocker.Acquire(ctx, lockingPriorityRenew, c.ID, time.Duration(math.MaxInt64))
 	if jc.Check("failed to acquire contract for renewal", err) != nil {
@@ -1170,9 +1508,11 @@ func (b *Bus) contractIDRenewHandlerPOST(jc jape.Context) {
 
 	// add the renewal
 	metadata, err := b.addRenewal(ctx, contract)
-	if jc.Check("couldn't add renewal", err) == nil {
-		jc.Encode(metadata)
+	if jc.Check("couldn't add renewal", err) != nil {
+		return
 	}
+	renewed = true
+	jc.Encode(metadata)
 }
 
 func (b *Bus) contractIDRootsHandlerGET(jc jape.Context) {
@@ -1196,7 +1536,16 @@ func (b *Bus) contractIDHandlerDELETE(jc jape.Context) {
 }
 
 func (b *Bus) contractsAllHandlerDELETE(jc jape.Context) {
-	jc.Check("couldn't remove contracts", b.store.ArchiveAllContracts(jc.Request.Context(), api.ContractArchivalReasonRemoved))
+	var safe bool
+	if jc.DecodeForm("safe", &safe) != nil {
+		return
+	}
+	err := b.store.ArchiveAllContracts(jc.Request.Context(), api.ContractArchivalReasonRemoved, safe)
+	if errors.Is(err, api.ErrArchivalDataLoss) {
+		jc.Error(err, http.StatusConflict)
+		return
+	}
+	jc.Check("couldn't remove contracts", err)
 }
 
 func (b *Bus) objectHandlerGET(jc jape.Context) {
@@ -1214,17 +1563,52 @@ func (b *Bus) objectHandlerGET(jc jape.Context) {
 		return
 	}
 
+	followSymlinks := true
+	if jc.DecodeForm("followsymlinks", &followSymlinks) != nil {
+		return
+	}
+
+	var versionID string
+	if jc.DecodeForm("versionid", &versionID) != nil {
+		return
+	}
+
+	var includeContracts bool
+	if jc.DecodeForm("includecontracts", &includeContracts) != nil {
+		return
+	}
+
+	// NOTE: the contracts are collected from the object's slabs, so we fetch
+	// the whole object if they were requested
+	fetchObject := func(key string) (api.Object, error) {
+		if onlymetadata && !includeContracts {
+			return b.store.ObjectMetadata(jc.Request.Context(), bucket, key)
+		}
+		return b.store.Object(jc.Request.Context(), bucket, key)
+	}
+
 	var o api.Object
 	var err error
-
-	if onlymetadata {
-		o, err = b.store.ObjectMetadata(jc.Request.Context(), bucket, key)
+	if versionID != "" {
+		o, err = b.store.ObjectVersion(jc.Request.Context(), bucket, key, versionID)
 	} else {
-		o, err = b.store.Object(jc.Request.Context(), bucket, key)
+		o, err = fetchObject(key)
+	}
+	if err == nil && followSymlinks {
+		o, err = b.resolveSymlinks(o, fetchObject)
+	}
+	if err == nil && includeContracts && o.Object != nil {
+		o.ContractIDs = o.Object.Contracts()
+	}
+	if err == nil && onlymetadata {
+		o.Object = nil
 	}
 	if errors.Is(err, api.ErrObjectNotFound) {
 		jc.Error(err, http.StatusNotFound)
 		return
+	} else if errors.Is(err, api.ErrMaxSymlinkDepthExceeded) {
+		jc.Error(err, http.StatusLoopDetected)
+		return
 	} else if jc.Check("couldn't load object", err) != nil {
 		return
 	}
@@ -1260,6 +1644,10 @@ func (b *Bus) objectsHandlerGET(jc jape.Context) {
 	if jc.DecodeForm("slabencryptionkey", &slabEncryptionKey) != nil {
 		return
 	}
+	var includeTags bool
+	if jc.DecodeForm("includetags", &includeTags) != nil {
+		return
+	}
 
 	resp, err := b.store.Objects(jc.Request.Context(), bucket, jc.PathParam("prefix"), substring, delim, sortBy, sortDir, marker, limit, slabEncryptionKey)
 	if errors.Is(err, api.ErrUnsupportedDelimiter) {
@@ -1268,27 +1656,69 @@ func (b *Bus) objectsHandlerGET(jc jape.Context) {
 	} else if jc.Check("failed to query objects", err) != nil {
 		return
 	}
+
+	// add tags if requested
+	if includeTags && len(resp.Objects) > 0 {
+		keys := make([]string, len(resp.Objects))
+		for i, o := range resp.Objects {
+			keys[i] = o.Key
+		}
+		resp.Tags, err = b.store.ObjectsTags(jc.Request.Context(), bucket, keys)
+		if jc.Check("failed to fetch object tags", err) != nil {
+			return
+		}
+	}
 	api.WriteResponse(jc, resp)
 }
 
 func (b *Bus) objectHandlerPUT(jc jape.Context) {
+	var ifNotExists bool
+	if jc.DecodeForm("ifNotExists", &ifNotExists) != nil {
+		return
+	}
 	var aor api.AddObjectRequest
 	if jc.Decode(&aor) != nil {
 		return
 	} else if aor.Bucket == "" {
 		jc.Error(api.ErrBucketMissing, http.StatusBadRequest)
 		return
+	} else if err := aor.Metadata.Validate(); err != nil {
+		jc.Error(err, http.StatusBadRequest)
+		return
 	}
-	jc.Check("couldn't store object", b.store.UpdateObject(jc.Request.Context(), aor.Bucket, jc.PathParam("key"), aor.ETag, aor.MimeType, aor.Metadata, aor.Object))
+	if err := b.checkPathPolicy(jc.Request.Context(), "put object", aor.Bucket, jc.PathParam("key")); errors.Is(err, api.ErrPathPolicyViolation) {
+		jc.Error(err, http.StatusBadRequest)
+		return
+	} else if jc.Check("failed to check path policy", err) != nil {
+		return
+	}
+	err := b.store.UpdateObject(jc.Request.Context(), aor.Bucket, jc.PathParam("key"), aor.ETag, aor.MimeType, aor.Metadata, aor.Object, ifNotExists)
+	if errors.Is(err, api.ErrObjectExists) {
+		jc.Error(err, http.StatusConflict)
+		return
+	}
+	jc.Check("couldn't store object", err)
 }
 
 func (b *Bus) objectsCopyHandlerPOST(jc jape.Context) {
 	var orr api.CopyObjectsRequest
 	if jc.Decode(&orr) != nil {
 		return
+	} else if err := orr.Metadata.Validate(); err != nil {
+		jc.Error(err, http.StatusBadRequest)
+		return
+	}
+	if err := b.checkPathPolicy(jc.Request.Context(), "copy object", orr.DestinationBucket, orr.DestinationKey); errors.Is(err, api.ErrPathPolicyViolation) {
+		jc.Error(err, http.StatusBadRequest)
+		return
+	} else if jc.Check("failed to check path policy", err) != nil {
+		return
 	}
-	om, err := b.store.CopyObject(jc.Request.Context(), orr.SourceBucket, orr.DestinationBucket, orr.SourceKey, orr.DestinationKey, orr.MimeType, orr.Metadata)
-	if jc.Check("couldn't copy object", err) != nil {
+	om, err := b.store.CopyObject(jc.Request.Context(), orr.SourceBucket, orr.DestinationBucket, orr.SourceKey, orr.DestinationKey, orr.MimeType, orr.Metadata, orr.IfSourceETagMatch)
+	if errors.Is(err, api.ErrPreconditionFailed) {
+		jc.Error(err, http.StatusPreconditionFailed)
+		return
+	} else if jc.Check("couldn't copy object", err) != nil {
 		return
 	}
 
@@ -1297,6 +1727,46 @@ func (b *Bus) objectsCopyHandlerPOST(jc jape.Context) {
 	jc.Encode(om)
 }
 
+func (b *Bus) objectsSymlinkHandlerPOST(jc jape.Context) {
+	var osr api.ObjectsSymlinkRequest
+	if jc.Decode(&osr) != nil {
+		return
+	} else if osr.Bucket == "" {
+		jc.Error(api.ErrBucketMissing, http.StatusBadRequest)
+		return
+	} else if osr.TargetPath == "" || osr.LinkPath == "" {
+		jc.Error(errors.New("target and link path must be provided"), http.StatusBadRequest)
+		return
+	} else if osr.TargetPath == osr.LinkPath {
+		jc.Error(errors.New("symlink can't point to itself"), http.StatusBadRequest)
+		return
+	}
+	for _, path := range []string{osr.LinkPath, osr.TargetPath} {
+		if err := b.checkPathPolicy(jc.Request.Context(), "create symlink", osr.Bucket, path); errors.Is(err, api.ErrBucketNotFound) {
+			jc.Error(err, http.StatusNotFound)
+			return
+		} else if errors.Is(err, api.ErrPathPolicyViolation) {
+			jc.Error(err, http.StatusBadRequest)
+			return
+		} else if jc.Check("failed to check path policy", err) != nil {
+			return
+		}
+	}
+
+	// make sure the symlink doesn't create a cycle
+	if err := b.checkSymlinkCycle(jc.Request.Context(), osr.Bucket, osr.TargetPath, osr.LinkPath); errors.Is(err, api.ErrSymlinkCycle) || errors.Is(err, api.ErrMaxSymlinkDepthExceeded) {
+		jc.Error(err, http.StatusBadRequest)
+		return
+	} else if jc.Check("failed to check symlink target", err) != nil {
+		return
+	}
+
+	// symlinks are stored as empty objects with the target in their metadata
+	o := object.NewObject(object.GenerateEncryptionKey(object.EncryptionKeyTypeSalted))
+	metadata := api.ObjectUserMetadata{api.ObjectMetadataSymlink: osr.TargetPath}
+	jc.Check("couldn't store symlink", b.store.UpdateObject(jc.Request.Context(), osr.Bucket, osr.LinkPath, "", "", metadata, o, false))
+}
+
 func (b *Bus) objectsRemoveHandlerPOST(jc jape.Context) {
 	var orr api.ObjectsRemoveRequest
 	if jc.Decode(&orr) != nil {
@@ -1314,6 +1784,27 @@ func (b *Bus) objectsRemoveHandlerPOST(jc jape.Context) {
 	jc.Check("failed to remove objects", b.store.RemoveObjects(jc.Request.Context(), orr.Bucket, orr.Prefix))
 }
 
+func (b *Bus) objectsHandlerDELETE(jc jape.Context) {
+	var bucket, glob string
+	if jc.DecodeForm("bucket", &bucket) != nil {
+		return
+	} else if bucket == "" {
+		jc.Error(api.ErrBucketMissing, http.StatusBadRequest)
+		return
+	} else if jc.DecodeForm("glob", &glob) != nil {
+		return
+	} else if glob == "" {
+		jc.Error(errors.New("glob cannot be empty"), http.StatusBadRequest)
+		return
+	}
+
+	removed, err := b.store.RemoveObjectsGlob(jc.Request.Context(), bucket, glob)
+	if jc.Check("failed to remove objects", err) != nil {
+		return
+	}
+	jc.Encode(api.ObjectsRemoveResponse{Removed: removed})
+}
+
 func (b *Bus) objectsRenameHandlerPOST(jc jape.Context) {
 	var orr api.ObjectsRenameRequest
 	if jc.Decode(&orr) != nil {
@@ -1322,6 +1813,17 @@ func (b *Bus) objectsRenameHandlerPOST(jc jape.Context) {
 		jc.Error(api.ErrBucketMissing, http.StatusBadRequest)
 		return
 	}
+	if orr.Mode == api.ObjectsRenameModeSingle || orr.Mode == api.ObjectsRenameModeMulti {
+		if err := b.checkRenamePathPolicy(jc.Request.Context(), orr.Bucket, orr.From, orr.To, orr.Mode == api.ObjectsRenameModeMulti); errors.Is(err, api.ErrBucketNotFound) {
+			jc.Error(err, http.StatusNotFound)
+			return
+		} else if errors.Is(err, api.ErrPathPolicyViolation) {
+			jc.Error(err, http.StatusBadRequest)
+			return
+		} else if jc.Check("failed to check path policy", err) != nil {
+			return
+		}
+	}
 	if orr.Mode == api.ObjectsRenameModeSingle {
 		// Single object rename.
 		if strings.HasSuffix(orr.From, "/") || strings.HasSuffix(orr.To, "/") {
@@ -1353,7 +1855,17 @@ func (b *Bus) objectHandlerDELETE(jc jape.Context) {
 		jc.Error(api.ErrBucketMissing, http.StatusBadRequest)
 		return
 	}
-	err := b.store.RemoveObject(jc.Request.Context(), bucket, jc.PathParam("key"))
+	var versionID string
+	if jc.DecodeForm("versionid", &versionID) != nil {
+		return
+	}
+
+	var err error
+	if versionID != "" {
+		err = b.store.RemoveObjectVersion(jc.Request.Context(), bucket, jc.PathParam("key"), versionID)
+	} else {
+		err = b.store.RemoveObject(jc.Request.Context(), bucket, jc.PathParam("key"))
+	}
 	if errors.Is(err, api.ErrObjectNotFound) {
 		jc.Error(err, http.StatusNotFound)
 		return
@@ -1361,6 +1873,65 @@ func (b *Bus) objectHandlerDELETE(jc jape.Context) {
 	jc.Check("couldn't delete object", err)
 }
 
+func (b *Bus) versionsHandlerGET(jc jape.Context) {
+	var bucket string
+	if jc.DecodeForm("bucket", &bucket) != nil {
+		return
+	} else if bucket == "" {
+		jc.Error(api.ErrBucketMissing, http.StatusBadRequest)
+		return
+	}
+	versions, err := b.store.ObjectVersions(jc.Request.Context(), bucket, jc.PathParam("key"))
+	if errors.Is(err, api.ErrObjectNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	} else if jc.Check("couldn't fetch object versions", err) != nil {
+		return
+	}
+	jc.Encode(versions)
+}
+
+func (b *Bus) tagsHandlerGET(jc jape.Context) {
+	var bucket string
+	if jc.DecodeForm("bucket", &bucket) != nil {
+		return
+	} else if bucket == "" {
+		jc.Error(api.ErrBucketMissing, http.StatusBadRequest)
+		return
+	}
+	tags, err := b.store.ObjectTags(jc.Request.Context(), bucket, jc.PathParam("key"))
+	if errors.Is(err, api.ErrObjectNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	} else if jc.Check("couldn't fetch object tags", err) != nil {
+		return
+	}
+	jc.Encode(tags)
+}
+
+func (b *Bus) tagsHandlerPUT(jc jape.Context) {
+	var bucket string
+	if jc.DecodeForm("bucket", &bucket) != nil {
+		return
+	} else if bucket == "" {
+		jc.Error(api.ErrBucketMissing, http.StatusBadRequest)
+		return
+	}
+	var tags api.ObjectTags
+	if jc.Decode(&tags) != nil {
+		return
+	} else if err := tags.Validate(); err != nil {
+		jc.Error(err, http.StatusBadRequest)
+		return
+	}
+	err := b.store.UpdateObjectTags(jc.Request.Context(), bucket, jc.PathParam("key"), tags)
+	if errors.Is(err, api.ErrObjectNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	}
+	jc.Check("couldn't update object tags", err)
+}
+
 func (b *Bus) slabbuffersHandlerGET(jc jape.Context) {
 	buffers, err := b.store.SlabBuffers(jc.Request.Context())
 	if jc.Check("couldn't get slab buffers info", err) != nil {
@@ -1369,6 +1940,13 @@ func (b *Bus) slabbuffersHandlerGET(jc jape.Context) {
 	api.WriteResponse(jc, api.SlabBuffersResp(buffers))
 }
 
+func (b *Bus) slabbuffersEventsStreamHandler(jc jape.Context) {
+	b.streamEvents(jc, api.WebhookModuleSlabBuffer, api.WebhookEventSlabBufferFlush, func(payload interface{}) (string, interface{}, bool) {
+		event, ok := payload.(api.EventSlabBufferFlush)
+		return "", event, ok
+	})
+}
+
 func (b *Bus) objectsStatshandlerGET(jc jape.Context) {
 	opts := api.ObjectsStatsOpts{}
 	if jc.DecodeForm("bucket", &opts.Bucket) != nil {
@@ -1381,6 +1959,21 @@ func (b *Bus) objectsStatshandlerGET(jc jape.Context) {
 	jc.Encode(info)
 }
 
+func (b *Bus) objectsStatsPerHostHandlerGET(jc jape.Context) {
+	opts := api.ObjectsStatsOpts{}
+	if jc.DecodeForm("bucket", &opts.Bucket) != nil {
+		return
+	}
+	stats, err := b.store.ObjectsStatsPerHost(jc.Request.Context(), opts)
+	if errors.Is(err, api.ErrBucketNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	} else if jc.Check("couldn't get objects stats per host", err) != nil {
+		return
+	}
+	jc.Encode(stats)
+}
+
 func (b *Bus) packedSlabsHandlerFetchPOST(jc jape.Context) {
 	var psrg api.PackedSlabsRequestGET
 	if jc.Decode(&psrg) != nil {
@@ -1433,6 +2026,60 @@ func (b *Bus) settingsGougingHandlerPUT(jc jape.Context) {
 	}
 }
 
+func (b *Bus) settingsGougingValidateHandlerPOST(jc jape.Context) {
+	var gs api.GougingSettings
+	if jc.Decode(&gs) != nil {
+		return
+	}
+	if err := gs.Validate(); err != nil {
+		jc.Error(fmt.Errorf("couldn't validate gouging settings, error: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	hosts, err := b.store.Hosts(jc.Request.Context(), api.HostOptions{
+		FilterMode:    api.HostFilterModeAllowed,
+		UsabilityMode: api.UsabilityFilterModeAll,
+		Limit:         -1,
+	})
+	if jc.Check("couldn't fetch hosts", err) != nil {
+		return
+	}
+
+	gp, err := b.gougingParams(jc.Request.Context())
+	if jc.Check("could not get gouging parameters", err) != nil {
+		return
+	}
+	gc := gouging.NewChecker(gs, gp.ConsensusState)
+	bh := b.cm.TipState().Index.Height
+
+	res := api.GougingValidationResult{
+		PassingHosts: make([]types.PublicKey, 0),
+		FailingHosts: make([]types.PublicKey, 0),
+	}
+	for _, h := range hosts {
+		if !h.Scanned {
+			continue // no settings to check
+		}
+
+		// ignore height
+		h.V2Settings.Prices.TipHeight = bh
+		h.PriceTable.HostBlockHeight = bh
+
+		var gb api.HostGougingBreakdown
+		if h.IsV2() {
+			gb = gc.CheckV2(h.V2Settings)
+		} else {
+			gb = gc.CheckV1(&h.Settings, &h.PriceTable.HostPriceTable)
+		}
+		if gb.Gouging() {
+			res.FailingHosts = append(res.FailingHosts, h.PublicKey)
+		} else {
+			res.PassingHosts = append(res.PassingHosts, h.PublicKey)
+		}
+	}
+	jc.Encode(res)
+}
+
 func (b *Bus) settingsPinnedHandlerGET(jc jape.Context) {
 	ps, err := b.pinnedSettings(jc.Request.Context())
 	if err != nil {
@@ -1520,6 +2167,18 @@ func (b *Bus) sectorsHostRootHandlerDELETE(jc jape.Context) {
 	}
 }
 
+func (b *Bus) sectorsRootContractsHandlerGET(jc jape.Context) {
+	var root types.Hash256
+	if jc.DecodeParam("root", &root) != nil {
+		return
+	}
+	fcids, err := b.store.FindAlternativeContracts(jc.Request.Context(), root)
+	if jc.Check("failed to fetch contracts", err) != nil {
+		return
+	}
+	jc.Encode(fcids)
+}
+
 func (b *Bus) slabHandlerGET(jc jape.Context) {
 	var key object.EncryptionKey
 	if jc.DecodeParam("key", &key) != nil {
@@ -1574,8 +2233,21 @@ func (b *Bus) slabHandlerPUT(jc jape.Context) {
 	}
 }
 
+func (b *Bus) slabFailureHandlerPOST(jc jape.Context) {
+	var key object.EncryptionKey
+	if jc.DecodeParam("key", &key) != nil {
+		return
+	}
+	err := b.store.RecordSlabFailure(jc.Request.Context(), key)
+	if errors.Is(err, api.ErrSlabNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	}
+	jc.Check("couldn't record slab failure", err)
+}
+
 func (b *Bus) slabsRefreshHealthHandlerPOST(jc jape.Context) {
-	jc.Check("failed to recompute health", b.store.RefreshHealth(jc.Request.Context()))
+	jc.Check("failed to recompute health", b.healthRefresher.Refresh(jc.Request.Context()))
 }
 
 func (b *Bus) slabsMigrationHandlerPOST(jc jape.Context) {
@@ -1584,7 +2256,7 @@ func (b *Bus) slabsMigrationHandlerPOST(jc jape.Context) {
 		return
 	}
 
-	slabs, err := b.store.UnhealthySlabs(jc.Request.Context(), msr.HealthCutoff, msr.Limit)
+	slabs, err := b.store.UnhealthySlabs(jc.Request.Context(), msr.HealthCutoff, time.Duration(msr.MaxLastFailure), msr.Limit)
 	if jc.Check("couldn't fetch slabs for migration", err) != nil {
 		return
 	}
@@ -1710,6 +2382,11 @@ func (b *Bus) autopilotHandlerPUT(jc jape.Context) {
 		cfg.Enabled = *req.Enabled
 	}
 
+	// enable/disable price renegotiation
+	if req.PriceRenegotiationEnabled != nil {
+		cfg.PriceRenegotiationEnabled = *req.PriceRenegotiationEnabled
+	}
+
 	jc.Check("failed to update autopilot config", b.store.UpdateAutopilotConfig(jc.Request.Context(), cfg))
 }
 
@@ -1730,6 +2407,11 @@ func (b *Bus) contractIDAncestorsHandler(jc jape.Context) {
 }
 
 func (b *Bus) contractIDBroadcastHandler(jc jape.Context) {
+	if err := b.contractOperationsPaused(); err != nil {
+		jc.Error(err, http.StatusServiceUnavailable)
+		return
+	}
+
 	var fcid types.FileContractID
 	if jc.DecodeParam("id", &fcid) != nil {
 		return
@@ -1753,22 +2435,50 @@ func (b *Bus) paramsHandlerUploadGET(jc jape.Context) {
 	}
 
 	api.WriteResponse(jc, api.UploadParams{
-		CurrentHeight: b.cm.TipState().Index.Height,
-		GougingParams: gp,
-		UploadPacking: us.Packing.Enabled,
+		CurrentHeight:     b.cm.TipState().Index.Height,
+		GougingParams:     gp,
+		UploadPacking:     us.Packing.Enabled,
+		RetryPolicy:       us.RetryPolicy,
+		ChecksumAlgorithm: us.ChecksumAlgorithm,
 	})
 }
 
+func (b *Bus) reportsHealthHandlerGET(jc jape.Context) {
+	period := api.DefaultHealthReportPeriod
+	if jc.DecodeForm("period", &period) != nil {
+		return
+	}
+	report, err := b.healthReport(jc.Request.Context(), period)
+	if jc.Check("couldn't generate health report", err) == nil {
+		jc.Encode(report)
+	}
+}
+
+// contractOperationsPaused returns an error if contract operations that depend
+// on the current chain state should be paused, which is the case if the node
+// follows a fork or if the chain subscriber is lagging too far behind.
+func (b *Bus) contractOperationsPaused() error {
+	if !b.forkDetector.OnCanonicalChain() {
+		return api.ErrForkDetected
+	} else if b.cs.Lag() > ibus.CriticalChainLag {
+		return api.ErrChainLagging
+	}
+	return nil
+}
+
 func (b *Bus) consensusState(ctx context.Context) (api.ConsensusState, error) {
 	index, err := b.cs.ChainIndex(ctx)
 	if err != nil {
 		return api.ConsensusState{}, err
 	}
 
+	// NOTE: we don't consider ourselves synced if the chain subscriber is
+	// lagging too far behind, this pauses contract maintenance in the
+	// autopilot, e.g. expiry checks, until we've caught up
 	var synced bool
 	block, found := b.cm.Block(index.ID)
 	if found {
-		synced = utils.IsSynced(block)
+		synced = utils.IsSynced(block) && b.cs.Lag() <= ibus.CriticalChainLag
 	}
 
 	return api.ConsensusState{
@@ -1815,6 +2525,16 @@ func (b *Bus) handleGETAlerts(jc jape.Context) {
 		return
 	}
 
+	var module string
+	if jc.DecodeForm("module", &module) != nil {
+		return
+	}
+
+	var since time.Time
+	if jc.DecodeForm("since", (*api.TimeRFC3339)(&since)) != nil {
+		return
+	}
+
 	var offset int
 	if jc.DecodeForm("offset", &offset) != nil {
 		return
@@ -1835,6 +2555,8 @@ func (b *Bus) handleGETAlerts(jc jape.Context) {
 		Offset:   offset,
 		Limit:    limit,
 		Severity: severity,
+		Module:   module,
+		Since:    since,
 	})
 	if jc.Check("failed to fetch alerts", err) != nil {
 		return
@@ -1885,6 +2607,21 @@ func (b *Bus) accountsHandlerPOST(jc jape.Context) {
 	jc.Check("failed to save accounts", b.store.SaveAccounts(jc.Request.Context(), req.Accounts))
 }
 
+func (b *Bus) adminRecoverMetadataHandlerPOST(jc jape.Context) {
+	err := b.startMetadataRecovery()
+	if errors.Is(err, api.ErrRecoveryInProgress) {
+		jc.Error(err, http.StatusConflict)
+		return
+	} else if jc.Check("failed to start metadata recovery", err) != nil {
+		return
+	}
+	jc.Encode(b.metadataRecoveryStatus())
+}
+
+func (b *Bus) adminRecoverMetadataStatusHandlerGET(jc jape.Context) {
+	jc.Encode(b.metadataRecoveryStatus())
+}
+
 func (b *Bus) hostsCheckHandlerPUT(jc jape.Context) {
 	var hk types.PublicKey
 	if jc.DecodeParam("hostkey", &hk) != nil {
@@ -1901,6 +2638,85 @@ func (b *Bus) hostsCheckHandlerPUT(jc jape.Context) {
 	}
 }
 
+func (b *Bus) hostsChecksHandlerPUT(jc jape.Context) {
+	var checks map[types.PublicKey]api.HostChecks
+	if jc.Decode(&checks) != nil {
+		return
+	}
+
+	err := b.store.UpdateHostChecks(jc.Request.Context(), checks)
+	if jc.Check("failed to update host checks", err) != nil {
+		return
+	}
+}
+
+func (b *Bus) hostsBenchmarksHandlerGET(jc jape.Context) {
+	var hk types.PublicKey
+	if jc.DecodeParam("hostkey", &hk) != nil {
+		return
+	}
+	limit := 100
+	if jc.DecodeForm("limit", &limit) != nil {
+		return
+	} else if limit < -1 {
+		jc.Error(api.ErrInvalidLimit, http.StatusBadRequest)
+		return
+	}
+
+	benchmarks, err := b.store.HostBenchmarks(jc.Request.Context(), hk, limit)
+	if errors.Is(err, api.ErrHostNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	} else if jc.Check("failed to fetch host benchmarks", err) != nil {
+		return
+	}
+	jc.Encode(benchmarks)
+}
+
+func (b *Bus) hostsBenchmarksHandlerPOST(jc jape.Context) {
+	var hk types.PublicKey
+	if jc.DecodeParam("hostkey", &hk) != nil {
+		return
+	}
+	var benchmark api.HostBenchmark
+	if jc.Decode(&benchmark) != nil {
+		return
+	} else if benchmark.Timestamp.IsZero() {
+		jc.Error(errors.New("timestamp must be set"), http.StatusBadRequest)
+		return
+	}
+
+	err := b.store.RecordHostBenchmark(jc.Request.Context(), hk, benchmark)
+	if errors.Is(err, api.ErrHostNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	}
+	jc.Check("failed to record host benchmark", err)
+}
+
+func (b *Bus) hostsHistoryHandlerGET(jc jape.Context) {
+	var hk types.PublicKey
+	if jc.DecodeParam("hostkey", &hk) != nil {
+		return
+	}
+	limit := 100
+	if jc.DecodeForm("limit", &limit) != nil {
+		return
+	} else if limit < -1 {
+		jc.Error(api.ErrInvalidLimit, http.StatusBadRequest)
+		return
+	}
+
+	scans, err := b.store.HostScanHistory(jc.Request.Context(), hk, limit)
+	if errors.Is(err, api.ErrHostNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	} else if jc.Check("failed to fetch host scan history", err) != nil {
+		return
+	}
+	jc.Encode(scans)
+}
+
 func (b *Bus) broadcastAction(e webhooks.Event) {
 	log := b.logger.With("event", e.Event).With("module", e.Module)
 	err := b.webhooksMgr.BroadcastAction(context.Background(), e)
@@ -1921,6 +2737,11 @@ func (b *Bus) consensusPayoutContractTaxHandlerGET(jc jape.Context) {
 }
 
 func (b *Bus) stateHandlerGET(jc jape.Context) {
+	defragRuns, bytesOptimized := b.store.SlabBufferDefragStats()
+	var upnpAddr string
+	if b.upnpMgr != nil {
+		upnpAddr = b.upnpMgr.ExternalAddr()
+	}
 	api.WriteResponse(jc, api.BusStateResponse{
 		StartTime: api.TimeRFC3339(b.startTime),
 		BuildState: api.BuildState{
@@ -1933,7 +2754,15 @@ func (b *Bus) stateHandlerGET(jc jape.Context) {
 			Enabled: b.explorer.Enabled(),
 			URL:     b.explorer.BaseURL(),
 		},
-		Network: b.cm.TipState().Network.Name,
+		Network:  b.cm.TipState().Network.Name,
+		ChainLag: b.cs.Lag(),
+
+		SymlinksResolved: b.symlinksResolved.Load(),
+
+		SlabBufferDefragmentationRuns: defragRuns,
+		SlabBufferBytesOptimized:      bytesOptimized,
+
+		UPnPExternalAddr: upnpAddr,
 	})
 }
 
@@ -1971,6 +2800,27 @@ func (b *Bus) webhookActionHandlerPost(jc jape.Context) {
 	b.broadcastAction(action)
 }
 
+func (b *Bus) webhookDeadLettersHandlerGET(jc jape.Context) {
+	dls, err := b.webhooksMgr.DeadLetters(jc.Request.Context())
+	if jc.Check("failed to fetch dead letters", err) != nil {
+		return
+	}
+	jc.Encode(dls)
+}
+
+func (b *Bus) webhookDeadLetterRetryHandlerPOST(jc jape.Context) {
+	var id int64
+	if jc.DecodeParam("id", &id) != nil {
+		return
+	}
+	err := b.webhooksMgr.RetryDeadLetter(jc.Request.Context(), id)
+	if errors.Is(err, webhooks.ErrDeadLetterNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	}
+	jc.Check("failed to retry dead letter", err)
+}
+
 func (b *Bus) webhookHandlerDelete(jc jape.Context) {
 	var wh webhooks.Webhook
 	if jc.Decode(&wh) != nil {
@@ -2026,20 +2876,50 @@ func (b *Bus) metricsHandlerDELETE(jc jape.Context) {
 func (b *Bus) metricsHandlerPUT(jc jape.Context) {
 	jc.Custom((*interface{})(nil), nil)
 
+	// TODO: jape hack - remove once jape can handle decoding multiple different request types
 	key := jc.PathParam("key")
-	if key != api.MetricContractPrune {
+	switch key {
+	case api.MetricContractPrune:
+		var req api.ContractPruneMetricRequestPUT
+		if err := json.NewDecoder(jc.Request.Body).Decode(&req); err != nil {
+			jc.Error(fmt.Errorf("couldn't decode request type (%T): %w", req, err), http.StatusBadRequest)
+			return
+		}
+		jc.Check("failed to record contract prune metric", b.store.RecordContractPruneMetric(jc.Request.Context(), req.Metrics...))
+	case api.MetricSlabDownload:
+		var req api.SlabDownloadMetricRequestPUT
+		if err := json.NewDecoder(jc.Request.Body).Decode(&req); err != nil {
+			jc.Error(fmt.Errorf("couldn't decode request type (%T): %w", req, err), http.StatusBadRequest)
+			return
+		}
+		jc.Check("failed to record slab download metric", b.store.RecordSlabDownloadMetric(jc.Request.Context(), req.Metrics...))
+	default:
 		jc.Error(fmt.Errorf("unknown metric '%s'", key), http.StatusBadRequest)
+	}
+}
+
+func (b *Bus) metricsSummaryHandlerGET(jc jape.Context) {
+	// only active contracts are included in the summary
+	contracts, err := b.store.Contracts(jc.Request.Context(), api.ContractsOpts{FilterMode: api.ContractFilterModeActive})
+	if jc.Check("failed to fetch contracts", err) != nil {
 		return
 	}
+	fcids := make([]types.FileContractID, 0, len(contracts))
+	for _, c := range contracts {
+		fcids = append(fcids, c.ID)
+	}
 
-	// TODO: jape hack - remove once jape can handle decoding multiple different request types
-	var req api.ContractPruneMetricRequestPUT
-	if err := json.NewDecoder(jc.Request.Body).Decode(&req); err != nil {
-		jc.Error(fmt.Errorf("couldn't decode request type (%T): %w", req, err), http.StatusBadRequest)
+	summary, err := b.store.MetricsSummary(jc.Request.Context(), fcids)
+	if jc.Check("failed to fetch metrics summary", err) != nil {
 		return
 	}
 
-	jc.Check("failed to record contract prune metric", b.store.RecordContractPruneMetric(jc.Request.Context(), req.Metrics...))
+	// object stats aren't recorded as metrics, so we add the current stats
+	summary.Objects, err = b.store.ObjectsStats(jc.Request.Context(), api.ObjectsStatsOpts{})
+	if jc.Check("failed to fetch object stats", err) != nil {
+		return
+	}
+	jc.Encode(summary)
 }
 
 func (b *Bus) metricsHandlerGET(jc jape.Context) {
@@ -2095,6 +2975,12 @@ func (b *Bus) metricsHandlerGET(jc jape.Context) {
 			return
 		}
 		metrics, err = b.metrics(jc.Request.Context(), key, start, n, interval, opts)
+	case api.MetricSlabDownload:
+		var opts api.SlabDownloadMetricsQueryOpts
+		if jc.DecodeForm("slabkeyhash", &opts.SlabKeyHash) != nil {
+			return
+		}
+		metrics, err = b.metrics(jc.Request.Context(), key, start, n, interval, opts)
 	case api.MetricWallet:
 		var opts api.WalletMetricsQueryOpts
 		metrics, err = b.metrics(jc.Request.Context(), key, start, n, interval, opts)
@@ -2117,6 +3003,8 @@ func (b *Bus) metrics(ctx context.Context, key string, start time.Time, n uint64
 		return b.store.ContractMetrics(ctx, start, n, interval, opts.(api.ContractMetricsQueryOpts))
 	case api.MetricContractPrune:
 		return b.store.ContractPruneMetrics(ctx, start, n, interval, opts.(api.ContractPruneMetricsQueryOpts))
+	case api.MetricSlabDownload:
+		return b.store.SlabDownloadMetrics(ctx, start, n, interval, opts.(api.SlabDownloadMetricsQueryOpts))
 	case api.MetricWallet:
 		return b.store.WalletMetrics(ctx, start, n, interval, opts.(api.WalletMetricsQueryOpts))
 	}
@@ -2127,6 +3015,15 @@ func (b *Bus) multipartHandlerCreatePOST(jc jape.Context) {
 	var req api.MultipartCreateRequest
 	if jc.Decode(&req) != nil {
 		return
+	} else if err := req.Metadata.Validate(); err != nil {
+		jc.Error(err, http.StatusBadRequest)
+		return
+	}
+	if err := b.checkPathPolicy(jc.Request.Context(), "create multipart upload", req.Bucket, req.Key); errors.Is(err, api.ErrPathPolicyViolation) {
+		jc.Error(err, http.StatusBadRequest)
+		return
+	} else if jc.Check("failed to check path policy", err) != nil {
+		return
 	}
 
 	var key object.EncryptionKey
@@ -2136,7 +3033,7 @@ func (b *Bus) multipartHandlerCreatePOST(jc jape.Context) {
 		key = object.GenerateEncryptionKey(object.EncryptionKeyTypeSalted)
 	}
 
-	resp, err := b.store.CreateMultipartUpload(jc.Request.Context(), req.Bucket, req.Key, key, req.MimeType, req.Metadata)
+	resp, err := b.store.CreateMultipartUpload(jc.Request.Context(), req.Bucket, req.Key, key, req.MimeType, req.Metadata, time.Duration(req.TTL))
 	if jc.Check("failed to create multipart upload", err) != nil {
 		return
 	}
@@ -2154,6 +3051,21 @@ func (b *Bus) multipartHandlerAbortPOST(jc jape.Context) {
 	}
 }
 
+func (b *Bus) multipartHandlerExpirePOST(jc jape.Context) {
+	var req api.MultipartExpireRequest
+	if jc.Decode(&req) != nil {
+		return
+	} else if req.OlderThan < 0 {
+		jc.Error(errors.New("'olderThan' can't be negative"), http.StatusBadRequest)
+		return
+	}
+	n, err := b.store.ExpireMultipartUploads(jc.Request.Context(), time.Duration(req.OlderThan))
+	if jc.Check("failed to expire multipart uploads", err) != nil {
+		return
+	}
+	jc.Encode(api.MultipartExpireResponse{Expired: n})
+}
+
 func (b *Bus) multipartHandlerCompletePOST(jc jape.Context) {
 	var req api.MultipartCompleteRequest
 	if jc.Decode(&req) != nil {
@@ -2224,7 +3136,16 @@ func (b *Bus) multipartHandlerListPartsPOST(jc jape.Context) {
 	jc.Encode(resp)
 }
 
+func (b *Bus) contractsFormationsHandlerGET(jc jape.Context) {
+	jc.Encode(api.FormationQueueDepth(b.formations.FormationQueueDepth()))
+}
+
 func (b *Bus) contractsFormHandler(jc jape.Context) {
+	if err := b.contractOperationsPaused(); err != nil {
+		jc.Error(err, http.StatusServiceUnavailable)
+		return
+	}
+
 	// apply pessimistic timeout
 	ctx, cancel := context.WithTimeout(jc.Request.Context(), 15*time.Minute)
 	defer cancel()
@@ -2262,6 +3183,13 @@ func (b *Bus) contractsFormHandler(jc jape.Context) {
 		return
 	}
 
+	// wait for ongoing negotiations with the host to finish
+	release, err := b.formations.Acquire(ctx, rfr.HostKey)
+	if jc.Check("failed to wait for ongoing contract formations with host", err) != nil {
+		return
+	}
+	defer release()
+
 	// fetch gouging parameters
 	gp, err := b.gougingParams(ctx)
 	if jc.Check("could not get gouging parameters", err) != nil {
@@ -2,6 +2,9 @@ package bus
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net"
 	"time"
 
 	rhpv2 "go.sia.tech/core/rhp/v2"
@@ -13,6 +16,27 @@ import (
 	"go.uber.org/zap"
 )
 
+const (
+	// siamuxDialTimeout is the timeout applied when checking whether a host's
+	// SiaMux port accepts connections
+	siamuxDialTimeout = 10 * time.Second
+)
+
+var errSiaMuxUnreachable = errors.New("siamux port is unreachable")
+
+// checkSiaMuxReachable performs a lightweight connectivity check on the host's
+// SiaMux address by opening and immediately closing a TCP connection.
+func checkSiaMuxReachable(ctx context.Context, addr string) error {
+	ctx, cancel := context.WithTimeout(ctx, siamuxDialTimeout)
+	defer cancel()
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errSiaMuxUnreachable, err)
+	}
+	return conn.Close()
+}
+
 func (b *Bus) scanHostV1(ctx context.Context, timeout time.Duration, hostKey types.PublicKey, hostIP string) (rhpv2.HostSettings, rhpv3.HostPriceTable, time.Duration, error) {
 	logger := b.logger.
 		With("host", hostKey).
@@ -29,6 +53,8 @@ func (b *Bus) scanHostV1(ctx context.Context, timeout time.Duration, hostKey typ
 	}
 
 	// prepare a helper for scanning
+	var siamuxReachable *bool
+	var rpcLatency time.Duration
 	scan := func() (rhpv2.HostSettings, rhpv3.HostPriceTable, time.Duration, error) {
 		// fetch the host settings
 		start := time.Now()
@@ -39,6 +65,17 @@ func (b *Bus) scanHostV1(ctx context.Context, timeout time.Duration, hostKey typ
 			return settings, rhpv3.HostPriceTable{}, time.Since(start), err
 		}
 
+		// check whether the SiaMux port is open, separately from fetching
+		// the price table, hosts might announce a port they don't listen on
+		scanCtx, cancel = timeoutCtx()
+		err = checkSiaMuxReachable(scanCtx, settings.SiamuxAddr())
+		cancel()
+		reachable := err == nil
+		siamuxReachable = &reachable
+		if err != nil {
+			return settings, rhpv3.HostPriceTable{}, time.Since(start), err
+		}
+
 		// fetch the host pricetable
 		scanCtx, cancel = timeoutCtx()
 		pt, err := b.rhp3Client.PriceTableUnpaid(scanCtx, hostKey, settings.SiamuxAddr())
@@ -46,12 +83,22 @@ func (b *Bus) scanHostV1(ctx context.Context, timeout time.Duration, hostKey typ
 		if err != nil {
 			return settings, rhpv3.HostPriceTable{}, time.Since(start), err
 		}
-		return settings, pt.HostPriceTable, time.Since(start), nil
+		elapsed := time.Since(start)
+
+		// probe the RPC latency, a failed probe doesn't fail the scan
+		scanCtx, cancel = timeoutCtx()
+		rpcLatency, err = b.rhp3Client.LatencyProbe(scanCtx, hostKey, settings.SiamuxAddr())
+		cancel()
+		if err != nil {
+			logger.Debugw("failed to probe RPC latency", zap.Error(err))
+		}
+		return settings, pt.HostPriceTable, elapsed, nil
 	}
 
 	// resolve host ip, don't scan if the host is on a private network or if it
 	// resolves to more than two addresses of the same type
-	if err := b.shouldScanAddr(hostIP); err != nil {
+	resolved, err := b.shouldScanAddr(hostIP)
+	if err != nil {
 		return rhpv2.HostSettings{}, rhpv3.HostPriceTable{}, 0, err
 	}
 
@@ -97,9 +144,14 @@ func (b *Bus) scanHostV1(ctx context.Context, timeout time.Duration, hostKey typ
 			// table and the settings succeeded. Right now scanning can't fail
 			// due to a reason that is our fault unless we are offline. If that
 			// changes, we should adjust this code to account for that.
-			Success:   err == nil,
-			Settings:  settings,
-			Timestamp: time.Now(),
+			Success:           err == nil,
+			Settings:          settings,
+			SiaMuxReachable:   siamuxReachable,
+			ResolvedAddresses: resolved,
+			Timestamp:         time.Now(),
+			Latency:           api.DurationMS(duration),
+			RPCLatency:        api.DurationMS(rpcLatency),
+			Error:             errString(err),
 		},
 	})
 	if scanErr != nil {
@@ -136,7 +188,8 @@ func (b *Bus) scanHostV2(ctx context.Context, timeout time.Duration, hostKey typ
 
 	// resolve host ip, don't scan if the host is on a private network or if it
 	// resolves to more than two addresses of the same type
-	if err := b.shouldScanAddr(hostIP); err != nil {
+	resolved, err := b.shouldScanAddr(hostIP)
+	if err != nil {
 		return rhp4.HostSettings{}, 0, err
 	}
 
@@ -181,9 +234,12 @@ func (b *Bus) scanHostV2(ctx context.Context, timeout time.Duration, hostKey typ
 			// Right now scanning can't fail due to a reason that is our fault unless we
 			// are offline. If that changes, we should adjust this code to account for
 			// that.
-			Success:    err == nil,
-			V2Settings: settings,
-			Timestamp:  time.Now(),
+			Success:           err == nil,
+			V2Settings:        settings,
+			ResolvedAddresses: resolved,
+			Timestamp:         time.Now(),
+			Latency:           api.DurationMS(duration),
+			Error:             errString(err),
 		},
 	})
 	if scanErr != nil {
@@ -198,17 +254,28 @@ func (b *Bus) scanHostV2(ctx context.Context, timeout time.Duration, hostKey typ
 // - be resolvable
 // - pass the IP checks we enforce on hosts
 // - not be a private IP if the bus is configured to disallow private IPs
-func (b *Bus) shouldScanAddr(addr string) error {
+// It returns the IP addresses the address resolved to.
+func (b *Bus) shouldScanAddr(addr string) ([]string, error) {
 	resolved, err := utils.ResolveHostIPs(context.Background(), []string{addr})
 	if err != nil {
-		return err
+		return nil, err
 	} else if err := utils.PerformHostIPChecks(resolved); err != nil {
-		return err
+		return nil, err
 	}
+	ips := make([]string, 0, len(resolved))
 	for _, ipAddr := range resolved {
 		if utils.IsPrivateIP(ipAddr.IP) && !b.allowPrivateIPs {
-			return api.ErrHostOnPrivateNetwork
+			return nil, api.ErrHostOnPrivateNetwork
 		}
+		ips = append(ips, ipAddr.IP.String())
+	}
+	return ips, nil
+}
+
+// errString returns the error's message or an empty string if the error is nil.
+func errString(err error) string {
+	if err == nil {
+		return ""
 	}
-	return nil
+	return err.Error()
 }
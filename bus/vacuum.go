@@ -0,0 +1,118 @@
+package bus
+
+import (
+	"context"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"go.sia.tech/renterd/api"
+	"go.uber.org/zap"
+	"lukechampine.com/frand"
+)
+
+// vacuumJobRetention is the amount of time the status of a finished vacuum is
+// kept around.
+const vacuumJobRetention = 24 * time.Hour
+
+// storeVacuums keeps track of the store vacuums started since the bus was
+// started, only one vacuum can run at a time.
+type storeVacuums struct {
+	mu      sync.Mutex
+	jobs    map[string]*api.VacuumStatus
+	cancel  context.CancelFunc
+	running bool
+	wg      sync.WaitGroup
+}
+
+// startStoreVacuum starts vacuuming the store in the background, it returns
+// ErrVacuumNotSupported if the store can't be vacuumed and ErrVacuumInProgress
+// if a vacuum is already running.
+func (b *Bus) startStoreVacuum() (api.VacuumStatus, error) {
+	if !b.store.VacuumSupported() {
+		return api.VacuumStatus{}, api.ErrVacuumNotSupported
+	}
+
+	v := b.vacuums
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.running {
+		return api.VacuumStatus{}, api.ErrVacuumInProgress
+	}
+
+	// prune the status of vacuums that finished a while ago
+	for id, job := range v.jobs {
+		if !job.Running && time.Since(time.Time(job.FinishedAt)) > vacuumJobRetention {
+			delete(v.jobs, id)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &api.VacuumStatus{
+		ID:        hex.EncodeToString(frand.Bytes(8)),
+		Running:   true,
+		StartedAt: api.TimeRFC3339(time.Now()),
+	}
+	v.jobs[job.ID] = job
+	v.cancel = cancel
+	v.running = true
+
+	v.wg.Add(1)
+	go func() {
+		defer v.wg.Done()
+		defer cancel()
+
+		b.logger.Infow("starting store vacuum", "id", job.ID)
+		err := b.store.Vacuum(ctx)
+		if err != nil {
+			b.logger.Errorw("store vacuum failed", "id", job.ID, zap.Error(err))
+		} else {
+			b.logger.Infow("store vacuum finished", "id", job.ID, "duration", time.Since(time.Time(job.StartedAt)))
+		}
+
+		v.mu.Lock()
+		job.Running = false
+		job.FinishedAt = api.TimeRFC3339(time.Now())
+		if err != nil {
+			job.Error = err.Error()
+		}
+		v.running = false
+		v.mu.Unlock()
+	}()
+	return *job, nil
+}
+
+// storeVacuumStatus returns the status of the vacuum with the given id.
+func (b *Bus) storeVacuumStatus(id string) (api.VacuumStatus, error) {
+	v := b.vacuums
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	job, ok := v.jobs[id]
+	if !ok {
+		return api.VacuumStatus{}, api.ErrVacuumNotFound
+	}
+	return *job, nil
+}
+
+// shutdownStoreVacuum interrupts a running vacuum and waits for it to exit.
+func (b *Bus) shutdownStoreVacuum(ctx context.Context) error {
+	v := b.vacuums
+	v.mu.Lock()
+	if v.cancel != nil {
+		v.cancel()
+	}
+	v.mu.Unlock()
+
+	doneChan := make(chan struct{})
+	go func() {
+		v.wg.Wait()
+		close(doneChan)
+	}()
+
+	select {
+	case <-doneChan:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"net/http"
 	"os"
 	"runtime"
 	"strconv"
@@ -40,7 +41,8 @@ var (
 	disableStdin bool
 	enableANSI   = runtime.GOOS != "windows"
 
-	hostBasesStr string
+	corsOriginsStr string
+	hostBasesStr   string
 )
 
 func defaultConfig() config.Config {
@@ -88,6 +90,20 @@ func defaultConfig() config.Config {
 			GatewayAddr:                   ":9981",
 			UsedUTXOExpiry:                24 * time.Hour,
 			SlabBufferCompletionThreshold: 1 << 12,
+			SlabBufferDefragInterval:      24 * time.Hour,
+			LifecycleRulesInterval:        24 * time.Hour,
+			WALCompactionThreshold:        1 << 30, // 1 GiB
+			WebhookDeadLetterTTL:          7 * 24 * time.Hour,
+			ForkDetectionDepth:            6,
+			MaxChainLag:                   10,
+			MaxConcurrentFormations:       1,
+			MaxSymlinkDepth:               8,
+			HealthRefreshMinInterval:      5 * time.Minute,
+			HealthRefreshMaxInterval:      time.Hour,
+			CORS: config.CORS{
+				AllowedMethods: []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete},
+				AllowedHeaders: []string{"Authorization", "Content-Type"},
+			},
 		},
 		Worker: config.Worker{
 			Enabled: true,
@@ -97,6 +113,8 @@ func defaultConfig() config.Config {
 			BusFlushInterval:       5 * time.Second,
 			CacheExpiry:            5 * time.Minute,
 
+			SectorVerificationInterval: 6 * time.Hour,
+
 			DownloadMaxOverdrive:     5,
 			DownloadOverdriveTimeout: 3 * time.Second,
 
@@ -108,10 +126,14 @@ func defaultConfig() config.Config {
 		Autopilot: config.Autopilot{
 			Enabled: true,
 
-			Heartbeat: 30 * time.Minute,
+			ContractExpiryAlertThreshold: 144, // ~1 day
+			Heartbeat:                    30 * time.Minute,
+			MaxFractionPerCountry:        0.5,
 
 			MigratorAccountsRefillInterval:   defaultAccountRefillInterval,
 			MigratorHealthCutoff:             0.75,
+			MigratorFailureCooldown:          time.Hour,
+			MigratorMaxAttempts:              3,
 			MigratorNumThreads:               1,
 			MigratorDownloadMaxOverdrive:     5,
 			MigratorDownloadOverdriveTimeout: 3 * time.Second,
@@ -186,6 +208,13 @@ func sanitizeConfig(cfg *config.Config) error {
 		}
 	}
 
+	// combine allowed CORS origins
+	for _, origin := range strings.Split(corsOriginsStr, ",") {
+		if trimmed := strings.TrimSpace(origin); trimmed != "" {
+			cfg.Bus.CORS.AllowedOrigins = append(cfg.Bus.CORS.AllowedOrigins, trimmed)
+		}
+	}
+
 	// check that the API password is set
 	if cfg.HTTP.Password == "" {
 		if disableStdin {
@@ -278,24 +307,44 @@ func parseCLIFlags(cfg *config.Config) {
 	flag.Uint64Var(&cfg.Bus.AnnouncementMaxAgeHours, "bus.announcementMaxAgeHours", cfg.Bus.AnnouncementMaxAgeHours, "Max age for announcements")
 	flag.BoolVar(&cfg.Bus.Bootstrap, "bus.bootstrap", cfg.Bus.Bootstrap, "Bootstraps gateway and consensus modules")
 	flag.StringVar(&cfg.Bus.GatewayAddr, "bus.gatewayAddr", cfg.Bus.GatewayAddr, "Address for Sia peer connections (overrides with RENTERD_BUS_GATEWAY_ADDR)")
+	flag.BoolVar(&cfg.Bus.UPnPEnabled, "bus.upnp", cfg.Bus.UPnPEnabled, "Forwards the gateway port through UPnP (overrides with RENTERD_BUS_UPNP)")
 	flag.DurationVar(&cfg.Bus.UsedUTXOExpiry, "bus.usedUTXOExpiry", cfg.Bus.UsedUTXOExpiry, "Expiry for used UTXOs in transactions")
 	flag.Int64Var(&cfg.Bus.SlabBufferCompletionThreshold, "bus.slabBufferCompletionThreshold", cfg.Bus.SlabBufferCompletionThreshold, "Threshold for slab buffer upload (overrides with RENTERD_BUS_SLAB_BUFFER_COMPLETION_THRESHOLD)")
+	flag.DurationVar(&cfg.Bus.SlabBufferDefragInterval, "bus.slabBufferDefragInterval", cfg.Bus.SlabBufferDefragInterval, "Interval for merging incomplete slab buffers, 0 disables defragmentation")
+	flag.DurationVar(&cfg.Bus.MultipartUploadExpiry, "bus.multipartUploadExpiry", cfg.Bus.MultipartUploadExpiry, "Duration after which multipart uploads without a TTL are aborted, 0 only aborts uploads with an expired TTL")
+	flag.DurationVar(&cfg.Bus.LifecycleRulesInterval, "bus.lifecycleRulesInterval", cfg.Bus.LifecycleRulesInterval, "Interval for applying the lifecycle rules of all buckets, 0 only applies them on demand")
+	flag.Int64Var(&cfg.Bus.WALCompactionThreshold, "bus.walCompactionThreshold", cfg.Bus.WALCompactionThreshold, "Size of the SQLite WAL in bytes after which it is checkpointed and truncated, 0 disables automatic checkpoints")
+	flag.Uint64Var(&cfg.Bus.ForkDetectionDepth, "bus.forkDetectionDepth", cfg.Bus.ForkDetectionDepth, "Number of blocks the node may follow a fork before contract operations are paused")
+	flag.Uint64Var(&cfg.Bus.MaxChainLag, "bus.maxChainLag", cfg.Bus.MaxChainLag, "Number of blocks the bus may fall behind the chain tip before a warning is registered")
+	flag.Uint64Var(&cfg.Bus.MaxConcurrentFormations, "bus.maxConcurrentFormations", cfg.Bus.MaxConcurrentFormations, "Max number of concurrent contract negotiations per host")
+	flag.Uint64Var(&cfg.Bus.MaxSymlinkDepth, "bus.maxSymlinkDepth", cfg.Bus.MaxSymlinkDepth, "Max number of symlinks followed when resolving an object")
+	flag.DurationVar(&cfg.Bus.HealthRefreshMinInterval, "bus.healthRefreshMinInterval", cfg.Bus.HealthRefreshMinInterval, "Min interval between slab health refreshes, used while unhealthy slabs exist")
+	flag.DurationVar(&cfg.Bus.HealthRefreshMaxInterval, "bus.healthRefreshMaxInterval", cfg.Bus.HealthRefreshMaxInterval, "Max interval between slab health refreshes, used while there are no unhealthy slabs")
+	flag.Float64Var(&cfg.Bus.HealthRefreshCutoff, "bus.healthRefreshCutoff", cfg.Bus.HealthRefreshCutoff, "Health below which slabs are considered unhealthy when scheduling health refreshes, defaults to the autopilot's migrator health cutoff")
+	flag.StringVar(&corsOriginsStr, "bus.cors.allowedOrigins", "", "Comma-separated list of origins that are allowed to access the bus API, '*' allows all origins")
+	flag.DurationVar(&cfg.Bus.WebhookDeadLetterTTL, "bus.webhookDeadLetterTTL", cfg.Bus.WebhookDeadLetterTTL, "Duration after which undeliverable webhook events are purged from the dead letter queue")
 
 	// worker
 	flag.DurationVar(&cfg.Worker.AccountsRefillInterval, "worker.accountRefillInterval", cfg.Worker.AccountsRefillInterval, "Interval for refilling workers' account balances")
 	flag.DurationVar(&cfg.Worker.BusFlushInterval, "worker.busFlushInterval", cfg.Worker.BusFlushInterval, "Interval for flushing data to bus")
 	flag.Uint64Var(&cfg.Worker.DownloadMaxMemory, "worker.downloadMaxMemory", cfg.Worker.DownloadMaxMemory, "Max amount of RAM the worker allocates for slabs when downloading (overrides with RENTERD_WORKER_DOWNLOAD_MAX_MEMORY)")
+	flag.Uint64Var(&cfg.Worker.DownloadBandwidthLimit, "worker.downloadBandwidthLimit", cfg.Worker.DownloadBandwidthLimit, "Default max download bandwidth per host in bytes per second, 0 means unlimited")
 	flag.Uint64Var(&cfg.Worker.DownloadMaxOverdrive, "worker.downloadMaxOverdrive", cfg.Worker.DownloadMaxOverdrive, "Max overdrive workers for downloads")
 	flag.StringVar(&cfg.Worker.ID, "worker.id", cfg.Worker.ID, "Unique ID for worker (overrides with RENTERD_WORKER_ID)")
 	flag.DurationVar(&cfg.Worker.DownloadOverdriveTimeout, "worker.downloadOverdriveTimeout", cfg.Worker.DownloadOverdriveTimeout, "Timeout for overdriving slab downloads")
 	flag.Uint64Var(&cfg.Worker.UploadMaxMemory, "worker.uploadMaxMemory", cfg.Worker.UploadMaxMemory, "Max amount of RAM the worker allocates for slabs when uploading (overrides with RENTERD_WORKER_UPLOAD_MAX_MEMORY)")
+	flag.Uint64Var(&cfg.Worker.UploadBandwidthLimit, "worker.uploadBandwidthLimit", cfg.Worker.UploadBandwidthLimit, "Default max upload bandwidth per host in bytes per second, 0 means unlimited")
 	flag.Uint64Var(&cfg.Worker.UploadMaxOverdrive, "worker.uploadMaxOverdrive", cfg.Worker.UploadMaxOverdrive, "Max overdrive workers for uploads")
 	flag.DurationVar(&cfg.Worker.UploadOverdriveTimeout, "worker.uploadOverdriveTimeout", cfg.Worker.UploadOverdriveTimeout, "Timeout for overdriving slab uploads")
+	flag.DurationVar(&cfg.Worker.SectorVerificationInterval, "worker.sectorVerificationInterval", cfg.Worker.SectorVerificationInterval, "Interval for downloading and verifying a random sample of sectors, 0 disables verification")
 	flag.BoolVar(&cfg.Worker.Enabled, "worker.enabled", cfg.Worker.Enabled, "Enables/disables worker (overrides with RENTERD_WORKER_ENABLED)")
 	flag.BoolVar(&cfg.Worker.AllowUnauthenticatedDownloads, "worker.unauthenticatedDownloads", cfg.Worker.AllowUnauthenticatedDownloads, "Allows unauthenticated downloads (overrides with RENTERD_WORKER_UNAUTHENTICATED_DOWNLOADS)")
 
 	// autopilot
 	flag.DurationVar(&cfg.Autopilot.Heartbeat, "autopilot.heartbeat", cfg.Autopilot.Heartbeat, "Interval for autopilot loop execution")
+	flag.Uint64Var(&cfg.Autopilot.ContractExpiryAlertThreshold, "autopilot.contractExpiryAlertThreshold", cfg.Autopilot.ContractExpiryAlertThreshold, "Number of blocks before a contract's expiry to register an alert, 0 disables the alerts")
+	flag.StringVar(&cfg.Autopilot.GeoIPDatabasePath, "autopilot.geoIPDatabasePath", cfg.Autopilot.GeoIPDatabasePath, "Path to a MaxMind-compatible GeoIP database used to spread contracts across countries, geo-scoring is disabled if empty")
+	flag.Float64Var(&cfg.Autopilot.MaxFractionPerCountry, "autopilot.maxFractionPerCountry", cfg.Autopilot.MaxFractionPerCountry, "Max fraction of contracts with hosts in the same country before hosts in that country are penalized")
 	flag.DurationVar(&cfg.Autopilot.RevisionBroadcastInterval, "autopilot.revisionBroadcastInterval", cfg.Autopilot.RevisionBroadcastInterval, "Interval for broadcasting contract revisions (overrides with RENTERD_AUTOPILOT_REVISION_BROADCAST_INTERVAL)")
 	flag.Uint64Var(&cfg.Autopilot.ScannerBatchSize, "autopilot.scannerBatchSize", cfg.Autopilot.ScannerBatchSize, "Batch size for host scanning")
 	flag.DurationVar(&cfg.Autopilot.ScannerInterval, "autopilot.scannerInterval", cfg.Autopilot.ScannerInterval, "Interval for scanning hosts")
@@ -305,6 +354,8 @@ func parseCLIFlags(cfg *config.Config) {
 
 	flag.DurationVar(&cfg.Autopilot.MigratorAccountsRefillInterval, "autopilot.migratorAccountRefillInterval", cfg.Autopilot.MigratorAccountsRefillInterval, "Interval for refilling migrator' account balances")
 	flag.Float64Var(&cfg.Autopilot.MigratorHealthCutoff, "autopilot.migratorHealthCutoff", cfg.Autopilot.MigratorHealthCutoff, "Threshold for migrating slabs based on health")
+	flag.DurationVar(&cfg.Autopilot.MigratorFailureCooldown, "autopilot.migratorFailureCooldown", cfg.Autopilot.MigratorFailureCooldown, "Time a slab is skipped for after its migration failed, 0 disables the cooldown")
+	flag.Uint64Var(&cfg.Autopilot.MigratorMaxAttempts, "autopilot.migratorMaxAttempts", cfg.Autopilot.MigratorMaxAttempts, "Max attempts to migrate a slab before registering an alert")
 	flag.Uint64Var(&cfg.Autopilot.MigratorNumThreads, "autopilot.migratorNumThreads", cfg.Autopilot.MigratorNumThreads, "Parallel slab migrations per worker (overrides with RENTERD_MIGRATOR_PARALLEL_SLABS_PER_WORKER)")
 	flag.Uint64Var(&cfg.Autopilot.MigratorDownloadMaxOverdrive, "autopilot.migratorDownloadMaxOverdrive", cfg.Autopilot.MigratorDownloadMaxOverdrive, "Max overdrive workers for migration downloads")
 	flag.DurationVar(&cfg.Autopilot.MigratorDownloadOverdriveTimeout, "autopilot.migratorDownloadOverdriveTimeout", cfg.Autopilot.MigratorDownloadOverdriveTimeout, "Timeout for overdriving migration downloads")
@@ -348,6 +399,7 @@ func parseEnvironmentVariables(cfg *config.Config) {
 	parseEnvVar("RENTERD_BUS_REMOTE_ADDR", &cfg.Bus.RemoteAddr)
 	parseEnvVar("RENTERD_BUS_API_PASSWORD", &cfg.Bus.RemotePassword)
 	parseEnvVar("RENTERD_BUS_GATEWAY_ADDR", &cfg.Bus.GatewayAddr)
+	parseEnvVar("RENTERD_BUS_UPNP", &cfg.Bus.UPnPEnabled)
 	parseEnvVar("RENTERD_BUS_SLAB_BUFFER_COMPLETION_THRESHOLD", &cfg.Bus.SlabBufferCompletionThreshold)
 
 	parseEnvVar("RENTERD_DB_URI", &cfg.Database.MySQL.URI)
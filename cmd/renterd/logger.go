@@ -7,10 +7,15 @@ import (
 	"path/filepath"
 
 	"go.sia.tech/renterd/config"
+	"go.sia.tech/renterd/internal/utils"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
+// logStreamBufferSize is the number of log entries kept in memory to be
+// replayed to clients of the log stream.
+const logStreamBufferSize = 1000
+
 func NewLogger(dir, filename string, cfg config.Log) (*zap.Logger, func(context.Context) error, error) {
 	// path
 	path := filepath.Join(dir, filename)
@@ -84,6 +89,16 @@ func NewLogger(dir, filename string, cfg config.Log) (*zap.Logger, func(context.
 	), closeFn, nil
 }
 
+// NewLogSink returns a sink that streams log entries to WebSocket clients, it
+// uses the global log level.
+func NewLogSink(cfg config.Log) (*utils.WebSocketLogSink, error) {
+	level, err := zapcore.ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse log level: %w", err)
+	}
+	return utils.NewWebSocketLogSink(level, jsonEncoder(), logStreamBufferSize), nil
+}
+
 // jsonEncoder returns a zapcore.Encoder that encodes logs as JSON intended for
 // parsing.
 func jsonEncoder() zapcore.Encoder {
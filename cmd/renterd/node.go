@@ -24,6 +24,7 @@ import (
 	"go.sia.tech/renterd/build"
 	"go.sia.tech/renterd/bus"
 	"go.sia.tech/renterd/config"
+	ibus "go.sia.tech/renterd/internal/bus"
 	"go.sia.tech/renterd/internal/utils"
 	"go.sia.tech/renterd/stores"
 	"go.sia.tech/renterd/stores/sql"
@@ -34,6 +35,7 @@ import (
 	"go.sia.tech/renterd/worker/s3"
 	"go.sia.tech/web/renterd"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 	"golang.org/x/crypto/blake2b"
 	"golang.org/x/sys/cpu"
 )
@@ -85,6 +87,15 @@ func newNode(cfg config.Config, network *consensus.Network, genesis types.Block)
 		fn:   closeFn,
 	})
 
+	// tee the logger into a sink that streams log entries to clients
+	logSink, err := NewLogSink(cfg.Log)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create log sink: %w", err)
+	}
+	logger = logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return zapcore.NewTee(core, logSink)
+	}))
+
 	// print network and version
 	logger.Info("renterd", zap.String("version", build.Version()), zap.String("network", network.Name), zap.String("commit", build.Commit()), zap.Time("buildDate", build.BuildTime()))
 	if runtime.GOARCH == "amd64" && !cpu.X86.HasAVX2 {
@@ -110,6 +121,9 @@ func newNode(cfg config.Config, network *consensus.Network, genesis types.Block)
 	// initialise auth handler
 	auth := jape.BasicAuth(cfg.HTTP.Password)
 
+	// serve the live log stream
+	mux.Sub["/debug/logs"] = utils.TreeMux{Handler: auth(logSink.Handler())}
+
 	// generate private key from seed
 	var pk types.PrivateKey
 	if cfg.Seed != "" {
@@ -139,7 +153,8 @@ func newNode(cfg config.Config, network *consensus.Network, genesis types.Block)
 			fn:   shutdownFn,
 		})
 
-		mux.Sub["/api/bus"] = utils.TreeMux{Handler: auth(b.Handler())}
+		cors := utils.CORS(cfg.Bus.CORS.AllowedOrigins, cfg.Bus.CORS.AllowedMethods, cfg.Bus.CORS.AllowedHeaders)
+		mux.Sub["/api/bus"] = utils.TreeMux{Handler: cors(auth(b.Handler()))}
 		busAddr = cfg.HTTP.Address + "/api/bus"
 		busPassword = cfg.HTTP.Password
 
@@ -241,7 +256,7 @@ func newBus(ctx context.Context, cfg config.Config, pk types.PrivateKey, network
 	}
 
 	// create webhooks manager
-	wh, err := webhooks.NewManager(sqlStore, logger)
+	wh, err := webhooks.NewManager(sqlStore, cfg.Bus.WebhookDeadLetterTTL, logger)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -249,6 +264,9 @@ func newBus(ctx context.Context, cfg config.Config, pk types.PrivateKey, network
 	// hookup webhooks <-> alerts
 	alertsMgr.RegisterWebhookBroadcaster(wh)
 
+	// hookup webhooks <-> store events
+	sqlStore.RegisterEventSink(stores.NewWebhookEventSink(wh))
+
 	// create consensus directory
 	consensusDir := filepath.Join(cfg.Directory, "consensus")
 	if err := os.MkdirAll(consensusDir, 0700); err != nil {
@@ -355,8 +373,15 @@ func newBus(ctx context.Context, cfg config.Config, pk types.PrivateKey, network
 		explorerURL = cfg.Explorer.URL
 	}
 
+	// the health refresh scheduler uses the migrator's health cutoff unless
+	// configured otherwise
+	busCfg := cfg.Bus
+	if busCfg.HealthRefreshCutoff == 0 {
+		busCfg.HealthRefreshCutoff = cfg.Autopilot.MigratorHealthCutoff
+	}
+
 	// create bus
-	b, err := bus.New(ctx, cfg.Bus, masterKey, alertsMgr, wh, cm, s, w, sqlStore, explorerURL, logger)
+	b, err := bus.New(ctx, busCfg, masterKey, alertsMgr, wh, cm, ibus.NewPingingSyncer(s), ibus.NewSweepingWallet(w, cm, s), sqlStore, explorerURL, logger)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create bus: %w", err)
 	}
@@ -511,6 +536,10 @@ func buildStoreConfig(am alerts.Alerter, cfg config.Config, pk types.PrivateKey,
 		PartialSlabDir:                partialSlabDir,
 		Migrate:                       true,
 		SlabBufferCompletionThreshold: cfg.Bus.SlabBufferCompletionThreshold,
+		SlabBufferDefragInterval:      cfg.Bus.SlabBufferDefragInterval,
+		MultipartUploadExpiry:         cfg.Bus.MultipartUploadExpiry,
+		LifecycleRulesInterval:        cfg.Bus.LifecycleRulesInterval,
+		WALCompactionThreshold:        cfg.Bus.WALCompactionThreshold,
 		Logger:                        logger,
 		WalletAddress:                 types.StandardUnlockHash(pk.PublicKey()),
 		LongQueryDuration:             cfg.Log.Database.SlowThreshold,
@@ -62,6 +62,29 @@ type (
 		RemotePassword                string        `yaml:"remotePassword,omitempty"`
 		UsedUTXOExpiry                time.Duration `yaml:"usedUtxoExpiry,omitempty"`
 		SlabBufferCompletionThreshold int64         `yaml:"slabBufferCompleionThreshold,omitempty"`
+		SlabBufferDefragInterval      time.Duration `yaml:"slabBufferDefragInterval,omitempty"`
+		MultipartUploadExpiry         time.Duration `yaml:"multipartUploadExpiry,omitempty"`
+		LifecycleRulesInterval        time.Duration `yaml:"lifecycleRulesInterval,omitempty"`
+		WALCompactionThreshold        int64         `yaml:"walCompactionThreshold,omitempty"`
+		WebhookDeadLetterTTL          time.Duration `yaml:"webhookDeadLetterTTL,omitempty"`
+		ForkDetectionDepth            uint64        `yaml:"forkDetectionDepth,omitempty"`
+		MaxChainLag                   uint64        `yaml:"maxChainLag,omitempty"`
+		MaxConcurrentFormations       uint64        `yaml:"maxConcurrentFormations,omitempty"`
+		MaxSymlinkDepth               uint64        `yaml:"maxSymlinkDepth,omitempty"`
+		HealthRefreshMinInterval      time.Duration `yaml:"healthRefreshMinInterval,omitempty"`
+		HealthRefreshMaxInterval      time.Duration `yaml:"healthRefreshMaxInterval,omitempty"`
+		HealthRefreshCutoff           float64       `yaml:"healthRefreshCutoff,omitempty"`
+		UPnPEnabled                   bool          `yaml:"upnpEnabled,omitempty"`
+		CORS                          CORS          `yaml:"cors,omitempty"`
+	}
+
+	// CORS contains the CORS configuration of the bus API. CORS headers are
+	// only set if at least one allowed origin is configured, an origin of "*"
+	// allows all origins.
+	CORS struct {
+		AllowedOrigins []string `yaml:"allowedOrigins,omitempty"`
+		AllowedMethods []string `yaml:"allowedMethods,omitempty"`
+		AllowedHeaders []string `yaml:"allowedHeaders,omitempty"`
 	}
 
 	// LogFile configures the file output of the logger.
@@ -125,17 +148,25 @@ type (
 		UploadMaxOverdrive            uint64        `yaml:"uploadMaxOverdrive,omitempty"`
 		AllowUnauthenticatedDownloads bool          `yaml:"allowUnauthenticatedDownloads,omitempty"`
 		CacheExpiry                   time.Duration `yaml:"cacheExpiry,omitempty"`
+		DownloadBandwidthLimit        uint64        `yaml:"downloadBandwidthLimit,omitempty"`
+		UploadBandwidthLimit          uint64        `yaml:"uploadBandwidthLimit,omitempty"`
+		SectorVerificationInterval    time.Duration `yaml:"sectorVerificationInterval,omitempty"`
 	}
 
 	// Autopilot contains the configuration for an autopilot.
 	Autopilot struct {
 		Enabled                          bool          `yaml:"enabled,omitempty"`
 		AllowRedundantHostIPs            bool          `yaml:"allowRedundantHostIPs,omitempty"`
+		ContractExpiryAlertThreshold     uint64        `yaml:"contractExpiryAlertThreshold,omitempty"`
+		GeoIPDatabasePath                string        `yaml:"geoIPDatabasePath,omitempty"`
 		Heartbeat                        time.Duration `yaml:"heartbeat,omitempty"`
+		MaxFractionPerCountry            float64       `yaml:"maxFractionPerCountry,omitempty"`
 		MigratorAccountsRefillInterval   time.Duration `yaml:"migratorAccountsRefillInterval,omitempty"`
 		MigratorDownloadMaxOverdrive     uint64        `yaml:"migratorDownloadMaxOverdrive,omitempty"`
 		MigratorDownloadOverdriveTimeout time.Duration `yaml:"migratorDownloadOverdriveTimeout,omitempty"`
+		MigratorFailureCooldown          time.Duration `yaml:"migratorFailureCooldown,omitempty"`
 		MigratorHealthCutoff             float64       `yaml:"migratorHealthCutoff,omitempty"`
+		MigratorMaxAttempts              uint64        `yaml:"migratorMaxAttempts,omitempty"`
 		MigratorNumThreads               uint64        `yaml:"migratorNumThreads,omitempty"`
 		MigratorUploadMaxOverdrive       uint64        `yaml:"migratorUploadMaxOverdrive,omitempty"`
 		MigratorUploadOverdriveTimeout   time.Duration `yaml:"migratorUploadOverdriveTimeout,omitempty"`
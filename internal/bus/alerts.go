@@ -5,12 +5,49 @@ import (
 	"time"
 
 	"go.sia.tech/renterd/alerts"
+	"go.sia.tech/renterd/api"
 )
 
 var (
-	alertPricePinningID = alerts.RandomAlertID() // constant until restarted
+	alertChainLagID      = alerts.RandomAlertID() // constant until restarted
+	alertForkDetectedID  = alerts.RandomAlertID() // constant until restarted
+	alertHealthRefreshID = alerts.RandomAlertID() // constant until restarted
+	alertPricePinningID  = alerts.RandomAlertID() // constant until restarted
 )
 
+func newChainLagAlert(severity alerts.Severity, height, lag uint64) alerts.Alert {
+	hint := "The bus is processing blocks slower than they are added to the chain, this might be caused by slow database writes. This is expected while the node performs its initial sync."
+	if severity == alerts.SeverityCritical {
+		hint += fmt.Sprintf(" Contract operations are paused until the lag drops below %d blocks.", CriticalChainLag)
+	}
+	return alerts.Alert{
+		ID:       alertChainLagID,
+		Severity: severity,
+		Message:  "Chain subscriber is lagging behind",
+		Data: map[string]any{
+			"processedHeight": height,
+			"lag":             lag,
+			"hint":            hint,
+		},
+		Timestamp: time.Now(),
+	}
+}
+
+func newForkDetectedAlert(status api.ForkStatus) alerts.Alert {
+	return alerts.Alert{
+		ID:       alertForkDetectedID,
+		Severity: alerts.SeverityCritical,
+		Message:  "Node is following a fork",
+		Data: map[string]any{
+			"localHeight":      status.LocalHeight,
+			"canonicalHeight":  status.CanonicalHeight,
+			"divergenceHeight": status.DivergenceHeight,
+			"hint":             "The node's chain diverged from the chain reported by its peers, this might indicate an eclipse attack. Contract operations are paused until the node is back on the canonical chain.",
+		},
+		Timestamp: time.Now(),
+	}
+}
+
 func newPricePinningFailedAlert(err error) alerts.Alert {
 	return alerts.Alert{
 		ID:       alertPricePinningID,
@@ -23,3 +60,16 @@ func newPricePinningFailedAlert(err error) alerts.Alert {
 		Timestamp: time.Now(),
 	}
 }
+
+func newRefreshHealthFailedAlert(err error) alerts.Alert {
+	return alerts.Alert{
+		ID:       alertHealthRefreshID,
+		Severity: alerts.SeverityCritical,
+		Message:  "Health refresh failed",
+		Data: map[string]any{
+			"error": err.Error(),
+			"hint":  "The health of slabs couldn't be refreshed, slabs that need to be migrated might not be detected until the next successful refresh.",
+		},
+		Timestamp: time.Now(),
+	}
+}
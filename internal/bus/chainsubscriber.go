@@ -13,6 +13,7 @@ import (
 	"go.sia.tech/coreutils/chain"
 	rhp4 "go.sia.tech/coreutils/rhp/v4"
 	"go.sia.tech/coreutils/wallet"
+	"go.sia.tech/renterd/alerts"
 	"go.sia.tech/renterd/api"
 	"go.sia.tech/renterd/stores/sql"
 	"go.sia.tech/renterd/webhooks"
@@ -21,6 +22,11 @@ import (
 
 const (
 	ContractResolutionTxnWeight = 1000
+
+	// CriticalChainLag is the number of blocks the chain subscriber can fall
+	// behind the chain manager's tip before contract operations that depend
+	// on the current block height are paused.
+	CriticalChainLag = 100
 )
 
 const (
@@ -78,6 +84,7 @@ type (
 	}
 
 	chainSubscriber struct {
+		a      alerts.Alerter
 		cm     ChainManager
 		cs     ChainStore
 		s      Syncer
@@ -85,6 +92,7 @@ type (
 		logger *zap.SugaredLogger
 
 		announcementMaxAge time.Duration
+		maxLag             uint64
 		wallet             Wallet
 
 		shutdownCtx       context.Context
@@ -93,16 +101,30 @@ type (
 		wg                sync.WaitGroup
 
 		unsubscribeFn func()
+
+		mu              sync.Mutex
+		processedHeight uint64
+		lagSeverity     alerts.Severity
 	}
 )
 
 // NewChainSubscriber creates a new chain subscriber that will sync with the
-// given chain manager and chain store. The returned subscriber is already
-// running and can be stopped by calling Shutdown.
-func NewChainSubscriber(whm WebhookManager, cm ChainManager, cs ChainStore, s Syncer, w Wallet, announcementMaxAge time.Duration, logger *zap.Logger) *chainSubscriber {
+// given chain manager and chain store. If the subscriber falls more than maxLag
+// blocks behind the chain manager's tip, an alert is registered. The returned
+// subscriber is already running and can be stopped by calling Shutdown.
+func NewChainSubscriber(a alerts.Alerter, whm WebhookManager, cm ChainManager, cs ChainStore, s Syncer, w Wallet, announcementMaxAge time.Duration, maxLag uint64, logger *zap.Logger) (*chainSubscriber, error) {
 	logger = logger.Named("chainsubscriber")
+
+	// fetch the chain index to initialise the processed height, that way the
+	// lag is accurate before the initial sync completes
+	index, err := cs.ChainIndex(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chain index: %w", err)
+	}
+
 	ctx, cancel := context.WithCancelCause(context.Background())
 	subscriber := &chainSubscriber{
+		a:      a,
 		cm:     cm,
 		cs:     cs,
 		s:      s,
@@ -110,15 +132,20 @@ func NewChainSubscriber(whm WebhookManager, cm ChainManager, cs ChainStore, s Sy
 		logger: logger.Sugar(),
 
 		announcementMaxAge: announcementMaxAge,
+		maxLag:             maxLag,
 		wallet:             w,
 
 		shutdownCtx:       ctx,
 		shutdownCtxCancel: cancel,
 		syncSig:           make(chan struct{}, 1),
+
+		processedHeight: index.Height,
 	}
 
-	// start the subscriber
+	// start the subscriber and trigger an initial sync to catch up with the
+	// chain manager
 	subscriber.run()
+	subscriber.syncSig <- struct{}{}
 
 	// trigger a sync on reorgs
 	subscriber.unsubscribeFn = cm.OnReorg(func(ci types.ChainIndex) {
@@ -129,13 +156,26 @@ func NewChainSubscriber(whm WebhookManager, cm ChainManager, cs ChainStore, s Sy
 		}
 	})
 
-	return subscriber
+	return subscriber, nil
 }
 
 func (s *chainSubscriber) ChainIndex(ctx context.Context) (types.ChainIndex, error) {
 	return s.cs.ChainIndex(ctx)
 }
 
+// Lag returns the number of blocks the subscriber is behind the chain
+// manager's tip.
+func (s *chainSubscriber) Lag() uint64 {
+	s.mu.Lock()
+	processed := s.processedHeight
+	s.mu.Unlock()
+
+	if tip := s.cm.Tip().Height; tip > processed {
+		return tip - processed
+	}
+	return 0
+}
+
 func (s *chainSubscriber) Shutdown(ctx context.Context) error {
 	// cancel shutdown context
 	s.shutdownCtxCancel(errClosed)
@@ -241,11 +281,29 @@ func (s *chainSubscriber) applyChainUpdate(tx sql.ChainUpdateTx, cau chain.Apply
 		return fmt.Errorf("failed to insert v2 file contract elements: %w", err)
 	}
 
+	// record storage proofs
+	err = forEachStorageProof(tx, b, func(fcid types.FileContractID, txn *types.Transaction, v2Txn *types.V2Transaction) error {
+		return tx.RecordStorageProof(fcid, api.ContractStorageProof{
+			BlockHeight:   cau.State.Index.Height,
+			BlockID:       cau.State.Index.ID,
+			Transaction:   txn,
+			V2Transaction: v2Txn,
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record storage proofs: %w", err)
+	}
+
 	// update contract proofs
 	if err := tx.UpdateFileContractElementProofs(cau); err != nil {
 		return fmt.Errorf("failed to update file contract element proofs: %w", err)
 	}
 
+	// update proof confirmations of resolved contracts
+	if err := tx.UpdateProofConfirmations(cau.State.Index.Height, api.ContractProofConfirmationDepth); err != nil {
+		return fmt.Errorf("failed to update proof confirmations: %w", err)
+	}
+
 	// broadcast expired file contracts
 	s.broadcastExpiredFileContractResolutions(tx, cau)
 
@@ -255,7 +313,7 @@ func (s *chainSubscriber) applyChainUpdate(tx sql.ChainUpdateTx, cau chain.Apply
 			return fmt.Errorf("failed to prune file contract elements: %w", err)
 		}
 		// mark contracts as failed a prune window after the window end
-		if err := tx.UpdateFailedContracts(cau.State.Index.Height - contractElementPruneWindow); err != nil {
+		if _, err := tx.UpdateFailedContracts(cau.State.Index.Height - contractElementPruneWindow); err != nil {
 			return fmt.Errorf("failed to update failed contracts: %w", err)
 		}
 	}
@@ -309,10 +367,54 @@ func (s *chainSubscriber) revertChainUpdate(tx sql.ChainUpdateTx, cru chain.Reve
 		return fmt.Errorf("failed to remove v2 file contract elements: %w", err)
 	}
 
+	// remove reverted storage proofs
+	err = forEachStorageProof(tx, cru.Block, func(fcid types.FileContractID, _ *types.Transaction, _ *types.V2Transaction) error {
+		return tx.RemoveStorageProof(fcid)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove storage proofs: %w", err)
+	}
+
 	// update contract proofs
 	if err := tx.UpdateFileContractElementProofs(cru); err != nil {
 		return fmt.Errorf("failed to update file contract element proofs: %w", err)
 	}
+
+	// update proof confirmations of resolved contracts
+	if err := tx.UpdateProofConfirmations(cru.State.Index.Height, api.ContractProofConfirmationDepth); err != nil {
+		return fmt.Errorf("failed to update proof confirmations: %w", err)
+	}
+	return nil
+}
+
+// forEachStorageProof calls fn for every transaction in the block that
+// contains a storage proof for one of our contracts.
+func forEachStorageProof(tx sql.ChainUpdateTx, b types.Block, fn func(fcid types.FileContractID, txn *types.Transaction, v2Txn *types.V2Transaction) error) error {
+	for i := range b.Transactions {
+		for _, sp := range b.Transactions[i].StorageProofs {
+			if known, err := tx.IsKnownContract(sp.ParentID); err != nil {
+				return err
+			} else if known {
+				if err := fn(sp.ParentID, &b.Transactions[i], nil); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	v2Txns := b.V2Transactions()
+	for i := range v2Txns {
+		for _, fcr := range v2Txns[i].FileContractResolutions {
+			if _, ok := fcr.Resolution.(*types.V2StorageProof); !ok {
+				continue
+			} else if known, err := tx.IsKnownContract(fcr.Parent.ID); err != nil {
+				return err
+			} else if known {
+				if err := fn(fcr.Parent.ID, nil, &v2Txns[i]); err != nil {
+					return err
+				}
+			}
+		}
+	}
 	return nil
 }
 
@@ -347,6 +449,7 @@ func (s *chainSubscriber) sync() error {
 	}
 	s.logger.Debugw("sync started", "height", index.Height, "block_id", index.ID)
 	sheight := index.Height / syncUpdateFrequency
+	s.updateLag(index)
 
 	// fetch updates until we're caught up
 	var cnt uint64
@@ -366,6 +469,7 @@ func (s *chainSubscriber) sync() error {
 			return fmt.Errorf("failed to process updates: %w", err)
 		}
 		s.logger.Debugw("processed updates successfully", "new_height", index.Height, "new_block_id", index.ID, "ms", time.Since(istart).Milliseconds())
+		s.updateLag(index)
 		cnt++
 	}
 
@@ -378,26 +482,69 @@ func (s *chainSubscriber) sync() error {
 	return nil
 }
 
+// updateLag records the height of the last processed block and registers an
+// alert if the subscriber lags too far behind the chain manager's tip. The
+// alert is only registered when its severity changes to avoid flooding the
+// alerts manager while catching up.
+func (s *chainSubscriber) updateLag(index types.ChainIndex) {
+	s.mu.Lock()
+	s.processedHeight = index.Height
+	s.mu.Unlock()
+
+	lag := s.Lag()
+	var severity alerts.Severity
+	if lag > CriticalChainLag {
+		severity = alerts.SeverityCritical
+	} else if lag > s.maxLag {
+		severity = alerts.SeverityWarning
+	}
+
+	s.mu.Lock()
+	changed := severity != s.lagSeverity
+	s.lagSeverity = severity
+	s.mu.Unlock()
+	if !changed {
+		return
+	}
+
+	if severity == 0 {
+		s.a.DismissAlerts(s.shutdownCtx, alertChainLagID)
+		return
+	}
+	s.logger.Warnw("chain subscriber is lagging behind", "lag", lag, "height", index.Height)
+	s.a.RegisterAlert(s.shutdownCtx, newChainLagAlert(severity, index.Height, lag))
+}
+
 func (s *chainSubscriber) processUpdates(ctx context.Context, crus []chain.RevertUpdate, caus []chain.ApplyUpdate) (index types.ChainIndex, err error) {
+	var walletEvents []wallet.Event
+	var contractEvents []api.EventContractStateChange
 	err = s.cs.ProcessChainUpdate(ctx, func(tx sql.ChainUpdateTx) error {
-		// process wallet updates
-		if err := s.wallet.UpdateChainState(tx, crus, caus); err != nil {
+		// process wallet updates, recording the applied events so they can be
+		// broadcast once the update is committed
+		recorder := &walletEventRecorder{ChainUpdateTx: tx}
+		if err := s.wallet.UpdateChainState(recorder, crus, caus); err != nil {
 			return fmt.Errorf("failed to process wallet updates: %w", err)
 		}
+		walletEvents = recorder.events
+
+		// process contract updates, recording the state transitions so they
+		// can be broadcast once the update is committed
+		contractRecorder := &contractStateRecorder{ChainUpdateTx: tx}
 
 		// process revert updates
 		for _, cru := range crus {
-			if err := s.revertChainUpdate(tx, cru); err != nil {
+			if err := s.revertChainUpdate(contractRecorder, cru); err != nil {
 				return fmt.Errorf("failed to revert chain update: %w", err)
 			}
 		}
 
 		// process apply updates
 		for _, cau := range caus {
-			if err := s.applyChainUpdate(tx, cau); err != nil {
+			if err := s.applyChainUpdate(contractRecorder, cau); err != nil {
 				return fmt.Errorf("failed to apply chain updates: %w", err)
 			}
 		}
+		contractEvents = contractRecorder.events
 
 		// update chain index
 		index = caus[len(caus)-1].State.Index
@@ -406,9 +553,88 @@ func (s *chainSubscriber) processUpdates(ctx context.Context, crus []chain.Rever
 		}
 		return nil
 	})
+	if err != nil {
+		return
+	}
+
+	// broadcast wallet events
+	for _, event := range walletEvents {
+		if err := s.wm.BroadcastAction(ctx, webhooks.Event{
+			Module:  api.WebhookModuleWallet,
+			Event:   api.WebhookEventWalletEvent,
+			Payload: event,
+		}); err != nil {
+			s.logger.Errorw("failed to broadcast wallet event", "id", event.ID, zap.Error(err))
+		}
+	}
+
+	// broadcast contract state changes
+	for _, event := range contractEvents {
+		if err := s.wm.BroadcastAction(ctx, webhooks.Event{
+			Module:  api.WebhookModuleContract,
+			Event:   api.WebhookEventContractStateChange,
+			Payload: event,
+		}); err != nil {
+			s.logger.Errorw("failed to broadcast contract state change", "fcid", event.ContractID, zap.Error(err))
+		}
+	}
 	return
 }
 
+// walletEventRecorder wraps a chain update transaction and records the events
+// of the indices that are applied to the wallet.
+type walletEventRecorder struct {
+	sql.ChainUpdateTx
+	events []wallet.Event
+}
+
+func (r *walletEventRecorder) WalletApplyIndex(index types.ChainIndex, created, spent []types.SiacoinElement, events []wallet.Event, timestamp time.Time) error {
+	if err := r.ChainUpdateTx.WalletApplyIndex(index, created, spent, events, timestamp); err != nil {
+		return err
+	}
+	r.events = append(r.events, events...)
+	return nil
+}
+
+// contractStateRecorder wraps a chain update transaction and records the state
+// transitions of the contracts that are updated.
+type contractStateRecorder struct {
+	sql.ChainUpdateTx
+	events []api.EventContractStateChange
+}
+
+func (r *contractStateRecorder) UpdateContractState(fcid types.FileContractID, state api.ContractState) error {
+	oldState, err := r.ChainUpdateTx.ContractState(fcid)
+	if err != nil {
+		return err
+	} else if err := r.ChainUpdateTx.UpdateContractState(fcid, state); err != nil {
+		return err
+	}
+	if oldState != state {
+		r.events = append(r.events, api.EventContractStateChange{
+			ContractID: fcid,
+			OldState:   oldState,
+			NewState:   state,
+		})
+	}
+	return nil
+}
+
+func (r *contractStateRecorder) UpdateFailedContracts(blockHeight uint64) ([]types.FileContractID, error) {
+	fcids, err := r.ChainUpdateTx.UpdateFailedContracts(blockHeight)
+	if err != nil {
+		return nil, err
+	}
+	for _, fcid := range fcids {
+		r.events = append(r.events, api.EventContractStateChange{
+			ContractID: fcid,
+			OldState:   api.ContractStateActive,
+			NewState:   api.ContractStateFailed,
+		})
+	}
+	return fcids, nil
+}
+
 func (s *chainSubscriber) broadcastExpiredFileContractResolutions(tx sql.ChainUpdateTx, cau chain.ApplyUpdate) {
 	expiredFCEs, err := tx.ExpiredFileContractElements(cau.State.Index.Height)
 	if err != nil {
@@ -552,6 +778,11 @@ func (s *chainSubscriber) revertV1ContractUpdate(tx sql.ChainUpdateTx, fce types
 
 	// reverted storage proof -> 'active'
 	if resolved {
+		// reset proof height
+		if err := tx.UpdateContractProofHeight(fcid, 0); err != nil {
+			return fmt.Errorf("failed to update contract proof height: %w", err)
+		}
+
 		if err := tx.UpdateContractState(fcid, api.ContractStateActive); err != nil {
 			return fmt.Errorf("failed to update contract state: %w", err)
 		}
@@ -0,0 +1,148 @@
+package bus
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/alerts"
+	"go.sia.tech/renterd/api"
+	"go.sia.tech/renterd/stores/sql"
+	"go.uber.org/zap"
+)
+
+type mockTipChainManager struct {
+	ChainManager
+	tip types.ChainIndex
+}
+
+func (cm *mockTipChainManager) Tip() types.ChainIndex { return cm.tip }
+
+func TestChainSubscriberLag(t *testing.T) {
+	a := &mockAlerter{}
+	cm := &mockTipChainManager{}
+	s := &chainSubscriber{
+		a:           a,
+		cm:          cm,
+		maxLag:      10,
+		logger:      zap.NewNop().Sugar(),
+		shutdownCtx: context.Background(),
+	}
+
+	assertAlert := func(severity alerts.Severity) {
+		t.Helper()
+		res, _ := a.Alerts(context.Background(), alerts.AlertsOpts{})
+		if severity == 0 && len(res.Alerts) != 0 {
+			t.Fatalf("expected no alerts, got %d", len(res.Alerts))
+		} else if severity != 0 && (len(res.Alerts) != 1 || res.Alerts[0].Severity != severity) {
+			t.Fatalf("expected one alert with severity %v, got %+v", severity, res.Alerts)
+		}
+	}
+
+	// caught up
+	cm.tip = types.ChainIndex{Height: 100}
+	s.updateLag(types.ChainIndex{Height: 100})
+	if lag := s.Lag(); lag != 0 {
+		t.Fatal("unexpected lag", lag)
+	}
+	assertAlert(0)
+
+	// lagging within the limit
+	s.updateLag(types.ChainIndex{Height: 90})
+	if lag := s.Lag(); lag != 10 {
+		t.Fatal("unexpected lag", lag)
+	}
+	assertAlert(0)
+
+	// lagging beyond the limit
+	s.updateLag(types.ChainIndex{Height: 89})
+	assertAlert(alerts.SeverityWarning)
+
+	// lagging critically
+	cm.tip = types.ChainIndex{Height: 200}
+	s.updateLag(types.ChainIndex{Height: 99})
+	if lag := s.Lag(); lag != 101 {
+		t.Fatal("unexpected lag", lag)
+	}
+	assertAlert(alerts.SeverityCritical)
+
+	// caught up again
+	s.updateLag(types.ChainIndex{Height: 200})
+	assertAlert(0)
+
+	// processed height beyond the tip
+	s.updateLag(types.ChainIndex{Height: 201})
+	if lag := s.Lag(); lag != 0 {
+		t.Fatal("unexpected lag", lag)
+	}
+}
+
+type mockContractStateTx struct {
+	sql.ChainUpdateTx
+	states map[types.FileContractID]api.ContractState
+}
+
+func (tx *mockContractStateTx) ContractState(fcid types.FileContractID) (api.ContractState, error) {
+	return tx.states[fcid], nil
+}
+
+func (tx *mockContractStateTx) UpdateContractState(fcid types.FileContractID, state api.ContractState) error {
+	tx.states[fcid] = state
+	return nil
+}
+
+func (tx *mockContractStateTx) UpdateFailedContracts(blockHeight uint64) (fcids []types.FileContractID, _ error) {
+	for fcid, state := range tx.states {
+		if state == api.ContractStateActive {
+			tx.states[fcid] = api.ContractStateFailed
+			fcids = append(fcids, fcid)
+		}
+	}
+	return
+}
+
+func TestContractStateRecorder(t *testing.T) {
+	fcid := types.FileContractID{1}
+	tx := &mockContractStateTx{states: map[types.FileContractID]api.ContractState{
+		fcid: api.ContractStatePending,
+	}}
+	r := &contractStateRecorder{ChainUpdateTx: tx}
+
+	// transition the contract through its states, updating to the same state
+	// should not record an event
+	for _, state := range []api.ContractState{
+		api.ContractStateActive,
+		api.ContractStateActive,
+		api.ContractStateComplete,
+	} {
+		if err := r.UpdateContractState(fcid, state); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// assert the transitions were recorded
+	expected := []api.EventContractStateChange{
+		{ContractID: fcid, OldState: api.ContractStatePending, NewState: api.ContractStateActive},
+		{ContractID: fcid, OldState: api.ContractStateActive, NewState: api.ContractStateComplete},
+	}
+	if !reflect.DeepEqual(r.events, expected) {
+		t.Fatalf("unexpected events %+v", r.events)
+	} else if tx.states[fcid] != api.ContractStateComplete {
+		t.Fatal("unexpected state", tx.states[fcid])
+	}
+
+	// assert contracts marked as failed are recorded
+	failed := types.FileContractID{2}
+	tx.states[failed] = api.ContractStateActive
+	r.events = nil
+	if _, err := r.UpdateFailedContracts(100); err != nil {
+		t.Fatal(err)
+	}
+	expected = []api.EventContractStateChange{
+		{ContractID: failed, OldState: api.ContractStateActive, NewState: api.ContractStateFailed},
+	}
+	if !reflect.DeepEqual(r.events, expected) {
+		t.Fatalf("unexpected events %+v", r.events)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/api"
 	"lukechampine.com/frand"
 )
 
@@ -47,8 +48,9 @@ func (h *lockCandidatePriorityHeap) Pop() interface{} {
 }
 
 type ContractLocker struct {
-	mu    sync.Mutex
-	locks map[types.FileContractID]*contractLock
+	mu        sync.Mutex
+	locks     map[types.FileContractID]*contractLock
+	cooldowns map[types.FileContractID]time.Time
 }
 
 type contractLock struct {
@@ -67,10 +69,44 @@ type lockCandidate struct {
 
 func NewContractLocker() *ContractLocker {
 	return &ContractLocker{
-		locks: make(map[types.FileContractID]*contractLock),
+		locks:     make(map[types.FileContractID]*contractLock),
+		cooldowns: make(map[types.FileContractID]time.Time),
 	}
 }
 
+// ContractRenewalCooldown puts the contract with the given id in a renewal
+// cooldown for the given duration. If the contract is already in a cooldown
+// because another renewal was attempted within that duration,
+// api.ErrContractRenewalCooldown is returned. The cooldown should be cleared
+// using ClearContractRenewalCooldown if the renewal attempt fails.
+func (l *ContractLocker) ContractRenewalCooldown(_ context.Context, id types.FileContractID, d time.Duration) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if until, ok := l.cooldowns[id]; ok && now.Before(until) {
+		return fmt.Errorf("%w: %v", api.ErrContractRenewalCooldown, until.Sub(now).Round(time.Second))
+	}
+
+	// remove expired cooldowns
+	for fcid, until := range l.cooldowns {
+		if !now.Before(until) {
+			delete(l.cooldowns, fcid)
+		}
+	}
+
+	l.cooldowns[id] = now.Add(d)
+	return nil
+}
+
+// ClearContractRenewalCooldown removes the renewal cooldown of the contract
+// with the given id, allowing a failed renewal to be retried right away.
+func (l *ContractLocker) ClearContractRenewalCooldown(id types.FileContractID) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.cooldowns, id)
+}
+
 func (l *ContractLocker) lockForContractID(id types.FileContractID, create bool) *contractLock {
 	l.mu.Lock()
 	defer l.mu.Unlock()
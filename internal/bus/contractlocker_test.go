@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/api"
 )
 
 // TestContractAcquire is a unit test for contractLocks.Acquire.
@@ -204,3 +205,40 @@ func TestContractRelease(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// TestContractRenewalCooldown is a unit test for
+// ContractLocker.ContractRenewalCooldown.
+func TestContractRenewalCooldown(t *testing.T) {
+	locks := NewContractLocker()
+
+	// first attempt succeeds
+	fcid := types.FileContractID{1}
+	if err := locks.ContractRenewalCooldown(context.Background(), fcid, 100*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	// second attempt within the cooldown fails
+	if err := locks.ContractRenewalCooldown(context.Background(), fcid, 100*time.Millisecond); !errors.Is(err, api.ErrContractRenewalCooldown) {
+		t.Fatal("expected cooldown error", err)
+	}
+
+	// other contracts aren't affected
+	if err := locks.ContractRenewalCooldown(context.Background(), types.FileContractID{2}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	// after the cooldown the contract can be renewed again and the expired
+	// cooldown is removed
+	time.Sleep(100 * time.Millisecond)
+	if err := locks.ContractRenewalCooldown(context.Background(), fcid, 100*time.Millisecond); err != nil {
+		t.Fatal(err)
+	} else if len(locks.cooldowns) != 2 {
+		t.Fatalf("expected 2 cooldowns, got %v", len(locks.cooldowns))
+	}
+
+	// clearing the cooldown allows the contract to be renewed right away
+	locks.ClearContractRenewalCooldown(fcid)
+	if err := locks.ContractRenewalCooldown(context.Background(), fcid, 100*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+}
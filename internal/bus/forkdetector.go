@@ -0,0 +1,262 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.sia.tech/core/consensus"
+	"go.sia.tech/core/types"
+	"go.sia.tech/coreutils/syncer"
+	"go.sia.tech/renterd/alerts"
+	"go.sia.tech/renterd/api"
+	"go.uber.org/zap"
+)
+
+const (
+	// forkDetectionPeerTimeout is the timeout applied when asking a peer for
+	// its chain tip
+	forkDetectionPeerTimeout = 30 * time.Second
+
+	// forkDetectionMinPeers is the minimum number of peers that have to agree
+	// on us following a fork before we act on it
+	forkDetectionMinPeers = 3
+)
+
+type (
+	ForkChainManager interface {
+		History() ([32]types.BlockID, error)
+		State(id types.BlockID) (consensus.State, bool)
+		Tip() types.ChainIndex
+	}
+
+	ForkSyncer interface {
+		Peers() []*syncer.Peer
+	}
+)
+
+type (
+	forkDetector struct {
+		a  alerts.Alerter
+		cm ForkChainManager
+		s  ForkSyncer
+
+		depth          uint64
+		updateInterval time.Duration
+
+		closedChan chan struct{}
+		wg         sync.WaitGroup
+
+		logger *zap.SugaredLogger
+
+		mu     sync.Mutex
+		status api.ForkStatus
+	}
+
+	// peerTip describes the chain tip reported by a peer relative to our
+	// local chain
+	peerTip struct {
+		peer             *syncer.Peer
+		height           uint64
+		divergenceHeight uint64
+		forked           bool
+	}
+)
+
+// NewForkDetector returns a new fork detector, responsible for periodically
+// comparing the local chain tip against the tips reported by our peers. If the
+// majority of our peers, and at least forkDetectionMinPeers, agree that the
+// node follows a fork for more than depth blocks, a critical alert is
+// registered and the peers that fed us the fork are disconnected. The returned
+// fork detector is already running and can be stopped by calling Shutdown.
+func NewForkDetector(alerts alerts.Alerter, cm ForkChainManager, s ForkSyncer, depth uint64, updateInterval time.Duration, l *zap.Logger) *forkDetector {
+	fd := &forkDetector{
+		a:  alerts,
+		cm: cm,
+		s:  s,
+
+		depth:          depth,
+		updateInterval: updateInterval,
+
+		closedChan: make(chan struct{}),
+
+		logger: l.Named("forkdetector").Sugar(),
+
+		status: api.ForkStatus{OnCanonicalChain: true},
+	}
+
+	fd.wg.Add(1)
+	go func() {
+		fd.run()
+		fd.wg.Done()
+	}()
+
+	return fd
+}
+
+// OnCanonicalChain returns false if the node is following a fork deeper than
+// the configured fork detection depth.
+func (fd *forkDetector) OnCanonicalChain() bool {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	return fd.status.OnCanonicalChain
+}
+
+func (fd *forkDetector) Shutdown(ctx context.Context) error {
+	close(fd.closedChan)
+
+	doneChan := make(chan struct{})
+	go func() {
+		fd.wg.Wait()
+		close(doneChan)
+	}()
+
+	select {
+	case <-doneChan:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+// Status returns the result of the most recent fork check.
+func (fd *forkDetector) Status() api.ForkStatus {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	return fd.status
+}
+
+func (fd *forkDetector) run() {
+	t := time.NewTicker(fd.updateInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-fd.closedChan:
+			return
+		case <-t.C:
+		}
+
+		if err := fd.update(); err != nil {
+			fd.logger.Warnw("failed to check for forks", zap.Error(err))
+		}
+	}
+}
+
+func (fd *forkDetector) update() error {
+	history, err := fd.cm.History()
+	if err != nil {
+		return fmt.Errorf("failed to fetch chain history: %w", err)
+	}
+	tip := fd.cm.Tip()
+
+	// ask all peers for their tip
+	var tips []peerTip
+	for _, p := range fd.s.Peers() {
+		pt, err := fd.peerTip(p, tip, history)
+		if err != nil {
+			fd.logger.Debugw("failed to fetch peer tip", "peer", p.String(), zap.Error(err))
+			continue
+		}
+		tips = append(tips, pt)
+	}
+	if len(tips) == 0 {
+		return errors.New("no peer reported its tip")
+	}
+
+	status := computeForkStatus(tip, tips, fd.depth)
+
+	fd.mu.Lock()
+	fd.status = status
+	fd.mu.Unlock()
+
+	if status.OnCanonicalChain {
+		fd.a.DismissAlerts(context.Background(), alertForkDetectedID)
+		return nil
+	}
+
+	// register an alert and disconnect from all peers that don't follow the
+	// canonical chain to break out of a potential eclipse
+	fd.logger.Warnw("node is following a fork", "localHeight", status.LocalHeight, "canonicalHeight", status.CanonicalHeight, "divergenceHeight", status.DivergenceHeight)
+	fd.a.RegisterAlert(context.Background(), newForkDetectedAlert(status))
+	for _, pt := range tips {
+		if !pt.forked {
+			if err := pt.peer.Close(); err != nil {
+				fd.logger.Debugw("failed to disconnect peer", "peer", pt.peer.String(), zap.Error(err))
+			}
+		}
+	}
+	return nil
+}
+
+// computeForkStatus compares the local tip against the tips reported by our
+// peers. We are following a fork if a quorum of peers reported a longer chain
+// that diverged from ours more than depth blocks ago, a single peer's claim is
+// never enough since it's unverified. When on a fork, the reported canonical
+// tip is the most conservative one agreed upon by the quorum.
+func computeForkStatus(tip types.ChainIndex, tips []peerTip, depth uint64) api.ForkStatus {
+	canonical := peerTip{height: tip.Height, divergenceHeight: tip.Height}
+	var forked []peerTip
+	for _, pt := range tips {
+		if pt.forked && pt.height > tip.Height && tip.Height-pt.divergenceHeight > depth {
+			forked = append(forked, pt)
+		} else if !pt.forked && pt.height > canonical.height {
+			canonical = pt
+		}
+	}
+
+	// without a quorum we consider ourselves on the canonical chain
+	if len(forked) < forkDetectionMinPeers || 2*len(forked) <= len(tips) {
+		return api.ForkStatus{
+			OnCanonicalChain: true,
+			LocalHeight:      tip.Height,
+			CanonicalHeight:  canonical.height,
+			DivergenceHeight: canonical.divergenceHeight,
+		}
+	}
+
+	status := api.ForkStatus{
+		OnCanonicalChain: false,
+		LocalHeight:      tip.Height,
+		CanonicalHeight:  forked[0].height,
+		DivergenceHeight: forked[0].divergenceHeight,
+	}
+	for _, pt := range forked[1:] {
+		status.CanonicalHeight = min(status.CanonicalHeight, pt.height)
+		status.DivergenceHeight = max(status.DivergenceHeight, pt.divergenceHeight)
+	}
+	return status
+}
+
+// peerTip asks the peer for the blocks following our chain history, from the
+// response we can derive whether the peer follows our chain and how many
+// blocks it is ahead of us.
+func (fd *forkDetector) peerTip(p *syncer.Peer, tip types.ChainIndex, history [32]types.BlockID) (peerTip, error) {
+	blocks, remaining, err := p.SendV2Blocks(history[:], 1, forkDetectionPeerTimeout)
+	if err != nil {
+		return peerTip{}, err
+	} else if len(blocks) == 0 {
+		// peer has no blocks we don't know about
+		return peerTip{peer: p, height: tip.Height, divergenceHeight: tip.Height}, nil
+	}
+
+	// peer extends our chain
+	parentID := blocks[0].ParentID
+	if parentID == tip.ID {
+		return peerTip{peer: p, height: tip.Height + 1 + remaining, divergenceHeight: tip.Height}, nil
+	}
+
+	// peer follows a different chain, figure out where it diverged
+	parent, ok := fd.cm.State(parentID)
+	if !ok {
+		return peerTip{}, fmt.Errorf("unknown parent block %v", parentID)
+	}
+	return peerTip{
+		peer:             p,
+		height:           parent.Index.Height + 1 + remaining,
+		divergenceHeight: parent.Index.Height,
+		forked:           true,
+	}, nil
+}
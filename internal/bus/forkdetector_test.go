@@ -0,0 +1,79 @@
+package bus
+
+import (
+	"testing"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/api"
+)
+
+func TestComputeForkStatus(t *testing.T) {
+	tip := types.ChainIndex{Height: 100}
+
+	tests := []struct {
+		name string
+		tips []peerTip
+		want api.ForkStatus
+	}{
+		{
+			name: "in sync",
+			tips: []peerTip{{height: 100, divergenceHeight: 100}},
+			want: api.ForkStatus{OnCanonicalChain: true, LocalHeight: 100, CanonicalHeight: 100, DivergenceHeight: 100},
+		},
+		{
+			name: "peer ahead on our chain",
+			tips: []peerTip{{height: 105, divergenceHeight: 100}},
+			want: api.ForkStatus{OnCanonicalChain: true, LocalHeight: 100, CanonicalHeight: 105, DivergenceHeight: 100},
+		},
+		{
+			name: "shallow fork",
+			tips: []peerTip{{height: 101, divergenceHeight: 95, forked: true}},
+			want: api.ForkStatus{OnCanonicalChain: true, LocalHeight: 100, CanonicalHeight: 100, DivergenceHeight: 100},
+		},
+		{
+			name: "deep fork single peer",
+			tips: []peerTip{
+				{height: 110, divergenceHeight: 90, forked: true},
+			},
+			want: api.ForkStatus{OnCanonicalChain: true, LocalHeight: 100, CanonicalHeight: 100, DivergenceHeight: 100},
+		},
+		{
+			name: "deep fork minority",
+			tips: []peerTip{
+				{height: 100, divergenceHeight: 100},
+				{height: 100, divergenceHeight: 100},
+				{height: 100, divergenceHeight: 100},
+				{height: 110, divergenceHeight: 90, forked: true},
+				{height: 110, divergenceHeight: 90, forked: true},
+				{height: 110, divergenceHeight: 90, forked: true},
+			},
+			want: api.ForkStatus{OnCanonicalChain: true, LocalHeight: 100, CanonicalHeight: 100, DivergenceHeight: 100},
+		},
+		{
+			name: "deep fork quorum",
+			tips: []peerTip{
+				{height: 100, divergenceHeight: 100},
+				{height: 110, divergenceHeight: 90, forked: true},
+				{height: 112, divergenceHeight: 88, forked: true},
+				{height: 111, divergenceHeight: 91, forked: true},
+			},
+			want: api.ForkStatus{OnCanonicalChain: false, LocalHeight: 100, CanonicalHeight: 110, DivergenceHeight: 91},
+		},
+		{
+			name: "deep fork with less work",
+			tips: []peerTip{
+				{height: 99, divergenceHeight: 90, forked: true},
+				{height: 99, divergenceHeight: 90, forked: true},
+				{height: 99, divergenceHeight: 90, forked: true},
+			},
+			want: api.ForkStatus{OnCanonicalChain: true, LocalHeight: 100, CanonicalHeight: 100, DivergenceHeight: 100},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := computeForkStatus(tip, test.tips, 6); got != test.want {
+				t.Fatalf("expected %+v, got %+v", test.want, got)
+			}
+		})
+	}
+}
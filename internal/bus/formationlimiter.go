@@ -0,0 +1,101 @@
+package bus
+
+import (
+	"context"
+	"sync"
+
+	"go.sia.tech/core/types"
+)
+
+type (
+	// FormationLimiter limits the number of concurrent contract negotiations
+	// per host. Hosts typically reject concurrent negotiations from the same
+	// renter so forming multiple contracts with the same host at once wastes
+	// negotiations and can get us rate limited.
+	FormationLimiter struct {
+		maxConcurrent uint64
+
+		mu    sync.Mutex
+		hosts map[types.PublicKey]*hostFormations
+	}
+
+	hostFormations struct {
+		sem    chan struct{}
+		queued uint64
+	}
+)
+
+// NewFormationLimiter returns a limiter that allows for maxConcurrent
+// negotiations per host, a value of 0 is treated as 1.
+func NewFormationLimiter(maxConcurrent uint64) *FormationLimiter {
+	if maxConcurrent == 0 {
+		maxConcurrent = 1
+	}
+	return &FormationLimiter{
+		maxConcurrent: maxConcurrent,
+		hosts:         make(map[types.PublicKey]*hostFormations),
+	}
+}
+
+// Acquire blocks until a negotiation with the given host is allowed to start
+// or until the context is closed. The returned function must be called once
+// the negotiation is done.
+func (l *FormationLimiter) Acquire(ctx context.Context, hk types.PublicKey) (func(), error) {
+	l.mu.Lock()
+	hf, ok := l.hosts[hk]
+	if !ok {
+		hf = &hostFormations{sem: make(chan struct{}, l.maxConcurrent)}
+		l.hosts[hk] = hf
+	}
+	hf.queued++
+	l.mu.Unlock()
+
+	var err error
+	select {
+	case hf.sem <- struct{}{}:
+	case <-ctx.Done():
+		err = context.Cause(ctx)
+	}
+
+	l.mu.Lock()
+	hf.queued--
+	l.mu.Unlock()
+	if err != nil {
+		l.prune(hk)
+		return nil, err
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			<-hf.sem
+			l.prune(hk)
+		})
+	}, nil
+}
+
+// FormationQueueDepth returns the number of negotiations per host that are
+// waiting for another negotiation with the same host to finish. Hosts without
+// queued negotiations are omitted.
+func (l *FormationLimiter) FormationQueueDepth() map[types.PublicKey]uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	depths := make(map[types.PublicKey]uint64)
+	for hk, hf := range l.hosts {
+		if hf.queued > 0 {
+			depths[hk] = hf.queued
+		}
+	}
+	return depths
+}
+
+// prune removes the host from the limiter if there are no ongoing or queued
+// negotiations with it.
+func (l *FormationLimiter) prune(hk types.PublicKey) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if hf, ok := l.hosts[hk]; ok && hf.queued == 0 && len(hf.sem) == 0 {
+		delete(l.hosts, hk)
+	}
+}
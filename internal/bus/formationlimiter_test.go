@@ -0,0 +1,72 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.sia.tech/core/types"
+)
+
+func TestFormationLimiter(t *testing.T) {
+	l := NewFormationLimiter(1)
+	hk1, hk2 := types.PublicKey{1}, types.PublicKey{2}
+
+	// acquire a negotiation with both hosts, the second host shouldn't be
+	// blocked by the first one
+	release1, err := l.Acquire(context.Background(), hk1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release2, err := l.Acquire(context.Background(), hk2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release2()
+
+	// a second negotiation with the first host should block
+	acquired := make(chan func())
+	go func() {
+		release, err := l.Acquire(context.Background(), hk1)
+		if err != nil {
+			t.Error(err)
+		}
+		acquired <- release
+	}()
+
+	// wait until it's queued
+	for start := time.Now(); l.FormationQueueDepth()[hk1] != 1; {
+		if time.Since(start) > 10*time.Second {
+			t.Fatal("negotiation wasn't queued")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if depths := l.FormationQueueDepth(); len(depths) != 1 {
+		t.Fatalf("unexpected queue depths %v", depths)
+	}
+
+	// a negotiation that times out while queued should return an error
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.Acquire(ctx, hk1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+
+	// release the first negotiation, the queued one should proceed
+	release1()
+	release1() // no-op
+	select {
+	case release := <-acquired:
+		release()
+	case <-time.After(10 * time.Second):
+		t.Fatal("queued negotiation didn't proceed")
+	}
+
+	// assert the limiter was pruned
+	if depths := l.FormationQueueDepth(); len(depths) != 0 {
+		t.Fatalf("unexpected queue depths %v", depths)
+	} else if len(l.hosts) != 0 {
+		t.Fatalf("expected hosts to be pruned, got %v", len(l.hosts))
+	}
+}
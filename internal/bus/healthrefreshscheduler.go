@@ -0,0 +1,183 @@
+package bus
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.sia.tech/renterd/alerts"
+	"go.sia.tech/renterd/api"
+	"go.uber.org/zap"
+)
+
+type (
+	HealthRefreshStore interface {
+		RefreshHealth(ctx context.Context) error
+		UnhealthySlabs(ctx context.Context, healthCutoff float64, maxLastFailure time.Duration, limit int) ([]api.UnhealthySlab, error)
+	}
+)
+
+type (
+	// HealthRefreshScheduler periodically refreshes the health of all slabs.
+	// The interval adapts to the number of unhealthy slabs, it is halved
+	// whenever slabs below the health cutoff are found and doubled when there
+	// are none, staying between the configured min and max interval. Manual
+	// refreshes, e.g. by the migrator after contract maintenance, go through
+	// the scheduler and reschedule the next refresh.
+	HealthRefreshScheduler struct {
+		alerts alerts.Alerter
+		store  HealthRefreshStore
+
+		healthCutoff float64
+		minInterval  time.Duration
+		maxInterval  time.Duration
+
+		shutdownCtx       context.Context
+		shutdownCtxCancel context.CancelFunc
+		wg                sync.WaitGroup
+
+		logger *zap.SugaredLogger
+
+		refreshMu sync.Mutex    // serializes refreshes
+		resetChan chan struct{} // signals the loop to reschedule
+
+		mu       sync.Mutex
+		interval time.Duration
+	}
+)
+
+// NewHealthRefreshScheduler returns a new health refresh scheduler. The
+// returned scheduler is already running and can be stopped by calling
+// Shutdown.
+func NewHealthRefreshScheduler(alerts alerts.Alerter, store HealthRefreshStore, healthCutoff float64, minInterval, maxInterval time.Duration, l *zap.Logger) *HealthRefreshScheduler {
+	shutdownCtx, shutdownCtxCancel := context.WithCancel(context.Background())
+	hrs := &HealthRefreshScheduler{
+		alerts: alerts,
+		store:  store,
+
+		healthCutoff: healthCutoff,
+		minInterval:  minInterval,
+		maxInterval:  maxInterval,
+
+		shutdownCtx:       shutdownCtx,
+		shutdownCtxCancel: shutdownCtxCancel,
+
+		logger: l.Named("healthrefreshscheduler").Sugar(),
+
+		resetChan: make(chan struct{}, 1),
+
+		interval: minInterval,
+	}
+
+	hrs.wg.Add(1)
+	go func() {
+		hrs.run()
+		hrs.wg.Done()
+	}()
+
+	return hrs
+}
+
+// Interval returns the interval after which the health is refreshed next.
+func (hrs *HealthRefreshScheduler) Interval() time.Duration {
+	hrs.mu.Lock()
+	defer hrs.mu.Unlock()
+	return hrs.interval
+}
+
+// Refresh refreshes the health of all slabs right away and reschedules the
+// next refresh.
+func (hrs *HealthRefreshScheduler) Refresh(ctx context.Context) error {
+	_, err := hrs.refresh(ctx)
+	select {
+	case hrs.resetChan <- struct{}{}:
+	default:
+	}
+	return err
+}
+
+func (hrs *HealthRefreshScheduler) Shutdown(ctx context.Context) error {
+	hrs.shutdownCtxCancel()
+
+	doneChan := make(chan struct{})
+	go func() {
+		hrs.wg.Wait()
+		close(doneChan)
+	}()
+
+	select {
+	case <-doneChan:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+func (hrs *HealthRefreshScheduler) run() {
+	t := time.NewTimer(hrs.Interval())
+	defer t.Stop()
+
+	for {
+		select {
+		case <-hrs.shutdownCtx.Done():
+			return
+		case <-hrs.resetChan:
+			// refreshed manually, reschedule the next refresh
+			if !t.Stop() {
+				select {
+				case <-t.C:
+				default:
+				}
+			}
+			t.Reset(hrs.Interval())
+		case <-t.C:
+			interval, _ := hrs.refresh(hrs.shutdownCtx)
+			t.Reset(interval)
+		}
+	}
+}
+
+// refresh refreshes the health of all slabs, updates the interval depending
+// on whether unhealthy slabs were found and returns the new interval.
+func (hrs *HealthRefreshScheduler) refresh(ctx context.Context) (time.Duration, error) {
+	hrs.refreshMu.Lock()
+	defer hrs.refreshMu.Unlock()
+
+	// refresh the health
+	start := time.Now()
+	if err := hrs.store.RefreshHealth(ctx); err != nil {
+		if ctx.Err() == nil {
+			hrs.logger.Warnw("failed to refresh health", zap.Error(err))
+			if err := hrs.alerts.RegisterAlert(hrs.shutdownCtx, newRefreshHealthFailedAlert(err)); err != nil {
+				hrs.logger.Errorf("failed to register alert: %v", err)
+			}
+		}
+		return hrs.Interval(), err
+	} else if err := hrs.alerts.DismissAlerts(hrs.shutdownCtx, alertHealthRefreshID); err != nil {
+		hrs.logger.Errorf("failed to dismiss alert: %v", err)
+	}
+
+	// check for unhealthy slabs
+	slabs, err := hrs.store.UnhealthySlabs(ctx, hrs.healthCutoff, 0, 1)
+	if err != nil {
+		hrs.logger.Warnw("failed to fetch unhealthy slabs", zap.Error(err))
+		return hrs.Interval(), nil
+	}
+
+	hrs.mu.Lock()
+	defer hrs.mu.Unlock()
+	hrs.interval = nextHealthRefreshInterval(hrs.interval, hrs.minInterval, hrs.maxInterval, len(slabs) > 0)
+	hrs.logger.Debugw("refreshed health", "duration", time.Since(start), "unhealthy", len(slabs) > 0, "interval", hrs.interval)
+	return hrs.interval, nil
+}
+
+// nextHealthRefreshInterval halves the current interval if there are unhealthy
+// slabs and doubles it otherwise, the result is clamped to [min, max].
+func nextHealthRefreshInterval(current, minInterval, maxInterval time.Duration, unhealthy bool) time.Duration {
+	if unhealthy {
+		current /= 2
+	} else {
+		current *= 2
+	}
+	return min(max(current, minInterval), maxInterval)
+}
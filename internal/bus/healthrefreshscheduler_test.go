@@ -0,0 +1,91 @@
+package bus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.sia.tech/renterd/api"
+	"go.uber.org/zap"
+)
+
+type mockHealthRefreshStore struct {
+	refreshed int
+	unhealthy []api.UnhealthySlab
+}
+
+func (s *mockHealthRefreshStore) RefreshHealth(context.Context) error {
+	s.refreshed++
+	return nil
+}
+
+func (s *mockHealthRefreshStore) UnhealthySlabs(_ context.Context, healthCutoff float64, _ time.Duration, limit int) (slabs []api.UnhealthySlab, _ error) {
+	for _, slab := range s.unhealthy {
+		if slab.Health < healthCutoff && len(slabs) < limit {
+			slabs = append(slabs, slab)
+		}
+	}
+	return slabs, nil
+}
+
+func TestNextHealthRefreshInterval(t *testing.T) {
+	const minInterval, maxInterval = time.Minute, time.Hour
+
+	tests := []struct {
+		current   time.Duration
+		unhealthy bool
+		want      time.Duration
+	}{
+		{current: 10 * time.Minute, unhealthy: true, want: 5 * time.Minute},
+		{current: 10 * time.Minute, unhealthy: false, want: 20 * time.Minute},
+		{current: minInterval, unhealthy: true, want: minInterval},
+		{current: 40 * time.Minute, unhealthy: false, want: maxInterval},
+		{current: maxInterval, unhealthy: false, want: maxInterval},
+	}
+	for _, test := range tests {
+		if got := nextHealthRefreshInterval(test.current, minInterval, maxInterval, test.unhealthy); got != test.want {
+			t.Fatalf("current %v, unhealthy %v: expected %v, got %v", test.current, test.unhealthy, test.want, got)
+		}
+	}
+}
+
+func TestHealthRefreshScheduler(t *testing.T) {
+	store := &mockHealthRefreshStore{}
+
+	// create a scheduler, with an interval long enough to not interfere with
+	// the manual refreshes below
+	hrs := NewHealthRefreshScheduler(&mockAlerter{}, store, 0.75, time.Hour, 8*time.Hour, zap.NewNop())
+	defer hrs.Shutdown(context.Background())
+
+	// without unhealthy slabs the interval doubles
+	if interval, _ := hrs.refresh(context.Background()); interval != 2*time.Hour {
+		t.Fatal("unexpected interval", interval)
+	} else if interval, _ := hrs.refresh(context.Background()); interval != 4*time.Hour {
+		t.Fatal("unexpected interval", interval)
+	}
+
+	// slabs above the health cutoff are ignored
+	store.unhealthy = []api.UnhealthySlab{{Health: 0.8}}
+	if interval, _ := hrs.refresh(context.Background()); interval != 8*time.Hour {
+		t.Fatal("unexpected interval", interval)
+	}
+
+	// with unhealthy slabs the interval halves
+	store.unhealthy = []api.UnhealthySlab{{Health: 0.5}}
+	if interval, _ := hrs.refresh(context.Background()); interval != 4*time.Hour {
+		t.Fatal("unexpected interval", interval)
+	} else if hrs.Interval() != interval {
+		t.Fatal("unexpected interval", hrs.Interval())
+	} else if store.refreshed != 4 {
+		t.Fatal("expected health to be refreshed 4 times", store.refreshed)
+	}
+
+	// a manual refresh updates the interval as well
+	if err := hrs.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	} else if hrs.Interval() != 2*time.Hour {
+		t.Fatal("unexpected interval", hrs.Interval())
+	} else if store.refreshed != 5 {
+		t.Fatal("expected health to be refreshed 5 times", store.refreshed)
+	}
+}
@@ -35,8 +35,9 @@ func (ma *mockAlerter) Alerts(ctx context.Context, opts alerts.AlertsOpts) (resp
 func (ma *mockAlerter) RegisterAlert(_ context.Context, a alerts.Alert) error {
 	ma.mu.Lock()
 	defer ma.mu.Unlock()
-	for _, alert := range ma.alerts {
+	for i, alert := range ma.alerts {
 		if alert.ID == a.ID {
+			ma.alerts[i] = a
 			return nil
 		}
 	}
@@ -0,0 +1,119 @@
+package bus
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.sia.tech/renterd/api"
+	"go.sia.tech/renterd/webhooks"
+	"go.uber.org/zap"
+)
+
+type (
+	SlabBufferFlushStore interface {
+		FlushSlabBuffers(ctx context.Context, createdBefore time.Time) ([]api.EventSlabBufferFlush, error)
+		UploadSettings(ctx context.Context) (api.UploadSettings, error)
+	}
+)
+
+type (
+	// SlabBufferFlusher periodically flushes incomplete slab buffers that are
+	// older than the upload packing settings' FlushAfter, making them
+	// available for upload, and broadcasts an event for every shard
+	// configuration with flushed buffers.
+	SlabBufferFlusher struct {
+		store       SlabBufferFlushStore
+		broadcaster webhooks.Broadcaster
+
+		closedChan chan struct{}
+		wg         sync.WaitGroup
+
+		logger *zap.SugaredLogger
+	}
+)
+
+// NewSlabBufferFlusher returns a new slab buffer flusher. The returned flusher
+// is already running and can be stopped by calling Shutdown.
+func NewSlabBufferFlusher(store SlabBufferFlushStore, broadcaster webhooks.Broadcaster, interval time.Duration, l *zap.Logger) *SlabBufferFlusher {
+	sbf := &SlabBufferFlusher{
+		store:       store,
+		broadcaster: broadcaster,
+
+		closedChan: make(chan struct{}),
+
+		logger: l.Named("slabbufferflusher").Sugar(),
+	}
+
+	sbf.wg.Add(1)
+	go func() {
+		sbf.run(interval)
+		sbf.wg.Done()
+	}()
+
+	return sbf
+}
+
+func (sbf *SlabBufferFlusher) Shutdown(ctx context.Context) error {
+	close(sbf.closedChan)
+
+	doneChan := make(chan struct{})
+	go func() {
+		sbf.wg.Wait()
+		close(doneChan)
+	}()
+
+	select {
+	case <-doneChan:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+func (sbf *SlabBufferFlusher) run(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-sbf.closedChan:
+			return
+		case <-t.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		if _, err := sbf.flush(ctx); err != nil {
+			sbf.logger.Warnw("failed to flush slab buffers", zap.Error(err))
+		}
+		cancel()
+	}
+}
+
+// flush flushes all slab buffers older than the configured FlushAfter and
+// broadcasts an event for every shard configuration with flushed buffers.
+func (sbf *SlabBufferFlusher) flush(ctx context.Context) ([]api.EventSlabBufferFlush, error) {
+	us, err := sbf.store.UploadSettings(ctx)
+	if err != nil {
+		return nil, err
+	} else if !us.Packing.Enabled || us.Packing.FlushAfter == 0 {
+		return nil, nil
+	}
+
+	flushed, err := sbf.store.FlushSlabBuffers(ctx, time.Now().Add(-time.Duration(us.Packing.FlushAfter)))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, event := range flushed {
+		sbf.logger.Infow("flushed slab buffers", "minShards", event.MinShards, "totalShards", event.TotalShards, "buffers", event.Buffers)
+		if err := sbf.broadcaster.BroadcastAction(ctx, webhooks.Event{
+			Module:  api.WebhookModuleSlabBuffer,
+			Event:   api.WebhookEventSlabBufferFlush,
+			Payload: event,
+		}); err != nil {
+			sbf.logger.Errorw("failed to broadcast slab buffer flush", zap.Error(err))
+		}
+	}
+	return flushed, nil
+}
@@ -0,0 +1,75 @@
+package bus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.sia.tech/renterd/api"
+	"go.sia.tech/renterd/webhooks"
+	"go.uber.org/zap"
+)
+
+type mockSlabBufferFlushStore struct {
+	us            api.UploadSettings
+	createdBefore time.Time
+}
+
+func (s *mockSlabBufferFlushStore) FlushSlabBuffers(_ context.Context, createdBefore time.Time) ([]api.EventSlabBufferFlush, error) {
+	s.createdBefore = createdBefore
+	return []api.EventSlabBufferFlush{{MinShards: 1, TotalShards: 2, Buffers: 3}}, nil
+}
+
+func (s *mockSlabBufferFlushStore) UploadSettings(context.Context) (api.UploadSettings, error) {
+	return s.us, nil
+}
+
+type mockBroadcaster struct {
+	events []webhooks.Event
+}
+
+func (b *mockBroadcaster) BroadcastAction(_ context.Context, e webhooks.Event) error {
+	b.events = append(b.events, e)
+	return nil
+}
+
+func TestSlabBufferFlusher(t *testing.T) {
+	store := &mockSlabBufferFlushStore{us: api.DefaultUploadSettings("mainnet")}
+	broadcaster := &mockBroadcaster{}
+
+	// create a flusher, with an interval long enough to not interfere with
+	// the manual flushes below
+	sbf := NewSlabBufferFlusher(store, broadcaster, time.Hour, zap.NewNop())
+	defer sbf.Shutdown(context.Background())
+
+	// assert nothing is flushed if FlushAfter isn't set
+	if flushed, err := sbf.flush(context.Background()); err != nil {
+		t.Fatal(err)
+	} else if len(flushed) != 0 || len(broadcaster.events) != 0 {
+		t.Fatal("unexpected flush")
+	}
+
+	// assert nothing is flushed if packing is disabled
+	store.us.Packing.FlushAfter = api.DurationMS(time.Minute)
+	store.us.Packing.Enabled = false
+	if flushed, err := sbf.flush(context.Background()); err != nil {
+		t.Fatal(err)
+	} else if len(flushed) != 0 || len(broadcaster.events) != 0 {
+		t.Fatal("unexpected flush")
+	}
+
+	// assert buffers older than FlushAfter are flushed and an event is
+	// broadcast
+	store.us.Packing.Enabled = true
+	if flushed, err := sbf.flush(context.Background()); err != nil {
+		t.Fatal(err)
+	} else if len(flushed) != 1 {
+		t.Fatal("expected 1 flush event", len(flushed))
+	} else if since := time.Since(store.createdBefore); since < time.Minute || since > 2*time.Minute {
+		t.Fatal("unexpected cutoff", store.createdBefore)
+	} else if len(broadcaster.events) != 1 {
+		t.Fatal("expected 1 event", len(broadcaster.events))
+	} else if e := broadcaster.events[0]; e.Module != api.WebhookModuleSlabBuffer || e.Event != api.WebhookEventSlabBufferFlush {
+		t.Fatal("unexpected event", e)
+	}
+}
@@ -0,0 +1,212 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.sia.tech/core/consensus"
+	"go.sia.tech/core/types"
+	"go.sia.tech/coreutils/wallet"
+)
+
+const (
+	// dustSweepBatchSize is the maximum number of dust outputs consolidated
+	// in a single transaction
+	dustSweepBatchSize = 100
+
+	// bytesPerInput is the estimated size of a signed siacoin input
+	bytesPerInput = 241
+)
+
+// errDustBelowFee is returned when the value of a batch of dust outputs
+// doesn't cover the miner fee of the consolidation transaction.
+var errDustBelowFee = errors.New("dust value doesn't cover the miner fee")
+
+type (
+	SweepChainManager interface {
+		AddPoolTransactions(txns []types.Transaction) (bool, error)
+		AddV2PoolTransactions(basis types.ChainIndex, txns []types.V2Transaction) (known bool, err error)
+		RecommendedFee() types.Currency
+		TipState() consensus.State
+		V2PoolTransactions() []types.V2Transaction
+		V2TransactionSet(basis types.ChainIndex, txn types.V2Transaction) (types.ChainIndex, []types.V2Transaction, error)
+	}
+
+	SweepSyncer interface {
+		BroadcastTransactionSet([]types.Transaction)
+		BroadcastV2TransactionSet(index types.ChainIndex, txns []types.V2Transaction)
+	}
+)
+
+// SweepingWallet wraps a wallet and extends it with the ability to consolidate
+// dust outputs. The wallet only locks outputs that it selects to fund a
+// transaction itself, so funding transactions is serialized with dust sweeps
+// to prevent swept outputs from being used to fund a transaction before they
+// are spent in the pool.
+type SweepingWallet struct {
+	*wallet.SingleAddressWallet
+
+	cm SweepChainManager
+	s  SweepSyncer
+
+	mu sync.Mutex
+}
+
+// NewSweepingWallet returns a new SweepingWallet that wraps the given wallet.
+func NewSweepingWallet(w *wallet.SingleAddressWallet, cm SweepChainManager, s SweepSyncer) *SweepingWallet {
+	return &SweepingWallet{
+		SingleAddressWallet: w,
+		cm:                  cm,
+		s:                   s,
+	}
+}
+
+func (w *SweepingWallet) FundTransaction(txn *types.Transaction, amount types.Currency, useUnconfirmed bool) ([]types.Hash256, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.SingleAddressWallet.FundTransaction(txn, amount, useUnconfirmed)
+}
+
+func (w *SweepingWallet) FundV2Transaction(txn *types.V2Transaction, amount types.Currency, useUnconfirmed bool) (types.ChainIndex, []int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.SingleAddressWallet.FundV2Transaction(txn, amount, useUnconfirmed)
+}
+
+func (w *SweepingWallet) Redistribute(outputs int, amount, feePerByte types.Currency) ([]types.Transaction, []types.Hash256, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.SingleAddressWallet.Redistribute(outputs, amount, feePerByte)
+}
+
+func (w *SweepingWallet) RedistributeV2(outputs int, amount, feePerByte types.Currency) ([]types.V2Transaction, [][]int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.SingleAddressWallet.RedistributeV2(outputs, amount, feePerByte)
+}
+
+// SweepDust consolidates all spendable wallet outputs with a value below the
+// given threshold into outputs sent back to the wallet. Outputs are
+// consolidated in batches of dustSweepBatchSize, batches that aren't worth the
+// miner fee are skipped. It returns the IDs of the submitted transactions, if
+// consolidating a batch fails the IDs of the transactions submitted before are
+// returned alongside the error.
+func (w *SweepingWallet) SweepDust(ctx context.Context, threshold types.Currency) ([]types.TransactionID, error) {
+	// prevent the dust from being used to fund transactions until it's spent
+	// in the pool
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	basis := w.Tip()
+	outputs, err := w.SpendableOutputs()
+	if err != nil {
+		return nil, fmt.Errorf("couldn't fetch spendable outputs: %w", err)
+	}
+
+	// the wallet only filters outputs spent in the v1 pool
+	inPool := make(map[types.SiacoinOutputID]struct{})
+	for _, txn := range w.cm.V2PoolTransactions() {
+		for _, sci := range txn.SiacoinInputs {
+			inPool[sci.Parent.ID] = struct{}{}
+		}
+	}
+
+	// collect dust
+	var dust []types.SiacoinElement
+	for _, sce := range outputs {
+		if _, ok := inPool[sce.ID]; ok {
+			continue
+		} else if sce.SiacoinOutput.Value.Cmp(threshold) < 0 {
+			dust = append(dust, sce)
+		}
+	}
+
+	cs := w.cm.TipState()
+	v2 := cs.Index.Height >= cs.Network.HardforkV2.AllowHeight
+	feePerByte := w.cm.RecommendedFee()
+
+	var ids []types.TransactionID
+	for len(dust) > 1 {
+		if err := ctx.Err(); err != nil {
+			return ids, err
+		}
+
+		batch := dust[:min(len(dust), dustSweepBatchSize)]
+		dust = dust[len(batch):]
+
+		var id types.TransactionID
+		if v2 {
+			id, err = w.sweepDustV2(basis, batch, feePerByte)
+		} else {
+			id, err = w.sweepDustV1(batch, feePerByte)
+		}
+		if errors.Is(err, errDustBelowFee) {
+			continue
+		} else if err != nil {
+			return ids, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (w *SweepingWallet) sweepDustV1(dust []types.SiacoinElement, feePerByte types.Currency) (types.TransactionID, error) {
+	txn := types.Transaction{
+		SiacoinOutputs: []types.SiacoinOutput{{Address: w.Address()}},
+	}
+	fee := feePerByte.Mul64(w.cm.TipState().TransactionWeight(txn) + bytesPerInput*uint64(len(dust)))
+	value, underflow := wallet.SumOutputs(dust).SubWithUnderflow(fee)
+	if underflow || value.IsZero() {
+		return types.TransactionID{}, errDustBelowFee
+	}
+	txn.SiacoinOutputs[0].Value = value
+	txn.MinerFees = []types.Currency{fee}
+
+	uc := w.UnlockConditions()
+	toSign := make([]types.Hash256, 0, len(dust))
+	for _, sce := range dust {
+		txn.SiacoinInputs = append(txn.SiacoinInputs, types.SiacoinInput{
+			ParentID:         sce.ID,
+			UnlockConditions: uc,
+		})
+		toSign = append(toSign, types.Hash256(sce.ID))
+	}
+	w.SignTransaction(&txn, toSign, types.CoveredFields{WholeTransaction: true})
+
+	if _, err := w.cm.AddPoolTransactions([]types.Transaction{txn}); err != nil {
+		return types.TransactionID{}, fmt.Errorf("couldn't add transaction to the pool: %w", err)
+	}
+	w.s.BroadcastTransactionSet([]types.Transaction{txn})
+	return txn.ID(), nil
+}
+
+func (w *SweepingWallet) sweepDustV2(basis types.ChainIndex, dust []types.SiacoinElement, feePerByte types.Currency) (types.TransactionID, error) {
+	txn := types.V2Transaction{
+		SiacoinOutputs: []types.SiacoinOutput{{Address: w.Address()}},
+	}
+	fee := feePerByte.Mul64(w.cm.TipState().V2TransactionWeight(txn) + bytesPerInput*uint64(len(dust)))
+	value, underflow := wallet.SumOutputs(dust).SubWithUnderflow(fee)
+	if underflow || value.IsZero() {
+		return types.TransactionID{}, errDustBelowFee
+	}
+	txn.SiacoinOutputs[0].Value = value
+	txn.MinerFee = fee
+
+	toSign := make([]int, 0, len(dust))
+	for i, sce := range dust {
+		txn.SiacoinInputs = append(txn.SiacoinInputs, types.V2SiacoinInput{Parent: sce})
+		toSign = append(toSign, i)
+	}
+	w.SignV2Inputs(&txn, toSign)
+
+	basis, txnset, err := w.cm.V2TransactionSet(basis, txn)
+	if err != nil {
+		return types.TransactionID{}, fmt.Errorf("couldn't update transaction set: %w", err)
+	} else if _, err := w.cm.AddV2PoolTransactions(basis, txnset); err != nil {
+		return types.TransactionID{}, fmt.Errorf("couldn't add transaction to the pool: %w", err)
+	}
+	w.s.BroadcastV2TransactionSet(basis, txnset)
+	return txn.ID(), nil
+}
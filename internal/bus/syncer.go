@@ -0,0 +1,33 @@
+package bus
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"go.sia.tech/coreutils/syncer"
+)
+
+// PingingSyncer wraps a syncer and extends it with the ability to measure the
+// round-trip time to a peer.
+type PingingSyncer struct {
+	*syncer.Syncer
+}
+
+// NewPingingSyncer returns a new PingingSyncer that wraps the given syncer.
+func NewPingingSyncer(s *syncer.Syncer) *PingingSyncer {
+	return &PingingSyncer{Syncer: s}
+}
+
+// PingPeer measures the round-trip time to the peer with the given address by
+// timing the TCP handshake.
+func (s *PingingSyncer) PingPeer(ctx context.Context, addr string) (time.Duration, error) {
+	var d net.Dialer
+	start := time.Now()
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return 0, err
+	}
+	rtt := time.Since(start)
+	return rtt, conn.Close()
+}
@@ -0,0 +1,30 @@
+package bus
+
+import (
+	"context"
+	"net"
+	"testing"
+)
+
+func TestPingPeer(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	// ping a listening peer
+	s := NewPingingSyncer(nil)
+	if rtt, err := s.PingPeer(context.Background(), l.Addr().String()); err != nil {
+		t.Fatal(err)
+	} else if rtt <= 0 {
+		t.Fatal("unexpected rtt", rtt)
+	}
+
+	// ping a peer that is offline
+	addr := l.Addr().String()
+	l.Close()
+	if _, err := s.PingPeer(context.Background(), addr); err == nil {
+		t.Fatal("expected error")
+	}
+}
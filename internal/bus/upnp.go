@@ -0,0 +1,202 @@
+package bus
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/go-upnp"
+	"go.uber.org/zap"
+)
+
+const (
+	// upnpDescription is the description of the port mapping in the router's
+	// port mapping table
+	upnpDescription = "renterd"
+
+	// upnpDiscoverTimeout is the timeout applied when discovering the router
+	upnpDiscoverTimeout = 30 * time.Second
+)
+
+type (
+	// UPnPRouter is a router that supports forwarding ports through UPnP.
+	UPnPRouter interface {
+		ExternalIP() (string, error)
+		Forward(port uint16, desc string) error
+		Clear(port uint16) error
+	}
+
+	// UPnPDiscoverFn discovers the UPnP enabled router on the local network.
+	UPnPDiscoverFn func(ctx context.Context) (UPnPRouter, error)
+)
+
+type (
+	upnpManager struct {
+		discover      UPnPDiscoverFn
+		port          uint16
+		renewInterval time.Duration
+
+		closedChan chan struct{}
+		wg         sync.WaitGroup
+
+		logger *zap.SugaredLogger
+
+		mu           sync.Mutex
+		router       UPnPRouter
+		forwarded    bool
+		externalAddr string
+	}
+)
+
+// DiscoverUPnPRouter discovers the UPnP enabled router on the local network.
+func DiscoverUPnPRouter(ctx context.Context) (UPnPRouter, error) {
+	igd, err := upnp.DiscoverCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return igd, nil
+}
+
+// NewUPnPManager returns a new UPnP manager, responsible for forwarding the
+// port of the given address on the router and renewing the port mapping on
+// every interval. The returned manager is already running and can be stopped
+// by calling Shutdown, which removes the port mapping.
+func NewUPnPManager(discover UPnPDiscoverFn, addr string, renewInterval time.Duration, l *zap.Logger) (*upnpManager, error) {
+	_, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to split host and port of address '%v': %w", addr, err)
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse port of address '%v': %w", addr, err)
+	}
+
+	um := &upnpManager{
+		discover:      discover,
+		port:          uint16(port),
+		renewInterval: renewInterval,
+
+		closedChan: make(chan struct{}),
+
+		logger: l.Named("upnp").Sugar(),
+	}
+
+	um.wg.Add(1)
+	go func() {
+		um.run()
+		um.wg.Done()
+	}()
+
+	return um, nil
+}
+
+// ExternalAddr returns the address that is forwarded to the local port, it's
+// empty if the port isn't forwarded.
+func (um *upnpManager) ExternalAddr() string {
+	um.mu.Lock()
+	defer um.mu.Unlock()
+	return um.externalAddr
+}
+
+func (um *upnpManager) Shutdown(ctx context.Context) error {
+	close(um.closedChan)
+
+	doneChan := make(chan struct{})
+	go func() {
+		um.wg.Wait()
+		close(doneChan)
+	}()
+
+	select {
+	case <-doneChan:
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+
+	// remove the port mapping
+	um.mu.Lock()
+	defer um.mu.Unlock()
+	if !um.forwarded {
+		return nil
+	} else if err := um.router.Clear(um.port); err != nil {
+		return fmt.Errorf("failed to clear port mapping: %w", err)
+	}
+	um.forwarded = false
+	um.externalAddr = ""
+	return nil
+}
+
+func (um *upnpManager) run() {
+	t := time.NewTicker(um.renewInterval)
+	defer t.Stop()
+
+	for {
+		if err := um.forward(); err != nil {
+			um.logger.Warnw("failed to update port mapping", "port", um.port, zap.Error(err))
+		}
+
+		select {
+		case <-um.closedChan:
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (um *upnpManager) forward() error {
+	um.mu.Lock()
+	router := um.router
+	um.mu.Unlock()
+
+	// discover the router if we haven't yet
+	if router == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), upnpDiscoverTimeout)
+		go func() {
+			select {
+			case <-um.closedChan:
+			case <-ctx.Done():
+			}
+			cancel()
+		}()
+
+		var err error
+		router, err = um.discover(ctx)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to discover router: %w", err)
+		}
+	}
+
+	// (re)lease the port, if that fails we rediscover the router on the next
+	// attempt since it might have changed
+	if err := router.Forward(um.port, upnpDescription); err != nil {
+		um.mu.Lock()
+		um.router = nil
+		um.forwarded = false
+		um.externalAddr = ""
+		um.mu.Unlock()
+		return fmt.Errorf("failed to forward port: %w", err)
+	}
+	um.mu.Lock()
+	um.router = router
+	um.forwarded = true
+	um.mu.Unlock()
+
+	// fetch the external address
+	ip, err := router.ExternalIP()
+	if err != nil {
+		return fmt.Errorf("failed to fetch external ip: %w", err)
+	}
+	externalAddr := net.JoinHostPort(ip, strconv.Itoa(int(um.port)))
+
+	um.mu.Lock()
+	defer um.mu.Unlock()
+	if um.externalAddr != externalAddr {
+		um.logger.Infow("forwarded port", "port", um.port, "externalAddr", externalAddr)
+	}
+	um.externalAddr = externalAddr
+	return nil
+}
@@ -0,0 +1,102 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type mockUPnPRouter struct {
+	mu        sync.Mutex
+	forwarded map[uint16]bool
+	renewals  int
+	failing   bool
+}
+
+func (r *mockUPnPRouter) Clear(port uint16) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.forwarded, port)
+	return nil
+}
+
+func (r *mockUPnPRouter) ExternalIP() (string, error) {
+	return "1.2.3.4", nil
+}
+
+func (r *mockUPnPRouter) Forward(port uint16, desc string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.failing {
+		return errors.New("failed to forward")
+	}
+	r.forwarded[port] = true
+	r.renewals++
+	return nil
+}
+
+func (r *mockUPnPRouter) state() (forwarded bool, renewals int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.forwarded[9981], r.renewals
+}
+
+func TestUPnPManager(t *testing.T) {
+	router := &mockUPnPRouter{forwarded: make(map[uint16]bool)}
+	var discoveries int
+	discover := func(ctx context.Context) (UPnPRouter, error) {
+		discoveries++
+		return router, nil
+	}
+
+	// assert invalid addresses are rejected
+	if _, err := NewUPnPManager(discover, "localhost", time.Hour, zap.NewNop()); err == nil {
+		t.Fatal("expected error")
+	}
+
+	um, err := NewUPnPManager(discover, ":9981", 50*time.Millisecond, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// assert the port gets forwarded and renewed
+	time.Sleep(120 * time.Millisecond)
+	if forwarded, renewals := router.state(); !forwarded {
+		t.Fatal("expected port to be forwarded")
+	} else if renewals < 2 {
+		t.Fatal("expected port mapping to be renewed", renewals)
+	} else if addr := um.ExternalAddr(); addr != "1.2.3.4:9981" {
+		t.Fatal("unexpected external address", addr)
+	}
+
+	// assert the external address is reset if forwarding fails
+	router.mu.Lock()
+	router.failing = true
+	router.mu.Unlock()
+	time.Sleep(100 * time.Millisecond)
+	if addr := um.ExternalAddr(); addr != "" {
+		t.Fatal("expected external address to be reset", addr)
+	}
+
+	// assert the router is rediscovered once forwarding succeeds again
+	router.mu.Lock()
+	router.failing = false
+	router.mu.Unlock()
+	time.Sleep(100 * time.Millisecond)
+	if addr := um.ExternalAddr(); addr != "1.2.3.4:9981" {
+		t.Fatal("unexpected external address", addr)
+	}
+
+	// assert the port mapping is removed on shutdown
+	if err := um.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	} else if forwarded, _ := router.state(); forwarded {
+		t.Fatal("expected port mapping to be removed")
+	} else if discoveries < 2 {
+		t.Fatal("expected router to be rediscovered", discoveries)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -63,9 +64,10 @@ type (
 		signalWorkChan chan struct{}
 		shutdownCtx    context.Context
 
-		mu                  sync.Mutex
-		consecutiveFailures uint64
-		lastRecompute       time.Time
+		mu                         sync.Mutex
+		consecutiveFailures        uint64
+		consecutiveSectorsNotFound uint64
+		lastRecompute              time.Time
 
 		numDownloads uint64
 		queue        []*SectorDownloadReq
@@ -174,6 +176,16 @@ func (d *Downloader) TryRecomputeStats() {
 }
 
 func (d *Downloader) execute(req *SectorDownloadReq) (err error) {
+	// if the host recently failed to serve a sector, check whether it has the
+	// sector before paying for the download
+	if sc, ok := d.host.(host.SectorChecker); ok && d.unreliable() {
+		if hasSector, err := sc.HasSector(req.Ctx, req.Root); err == nil && !hasSector {
+			err = fmt.Errorf("%w: %v", rhp3.ErrSectorNotFound, req.Root)
+			req.fail(err)
+			return err
+		}
+	}
+
 	// download the sector
 	buf := bytes.NewBuffer(make([]byte, 0, req.Length))
 	err = d.host.DownloadSector(req.Ctx, buf, req.Root, req.Offset, req.Length)
@@ -343,9 +355,14 @@ func (d *Downloader) trackFailure(err error) {
 
 	if err == nil {
 		d.consecutiveFailures = 0
+		d.consecutiveSectorsNotFound = 0
 		return
 	}
 
+	if rhp3.IsSectorNotFound(err) {
+		d.consecutiveSectorsNotFound++
+	}
+
 	if utils.IsBalanceInsufficient(err) ||
 		rhp3.IsPriceTableExpired(err) ||
 		rhp3.IsPriceTableNotFound(err) ||
@@ -357,6 +374,14 @@ func (d *Downloader) trackFailure(err error) {
 	d.statsSectorDownloadEstimateInMS.Track(float64(time.Hour.Milliseconds()))
 }
 
+// unreliable returns true if the host failed to serve a sector it was
+// supposed to have since the last successful download.
+func (d *Downloader) unreliable() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.consecutiveSectorsNotFound > 0
+}
+
 func (sr *SectorResponses) Add(resp *SectorDownloadResp) {
 	sr.mu.Lock()
 	defer sr.mu.Unlock()
@@ -3,13 +3,37 @@ package downloader
 import (
 	"context"
 	"errors"
+	"io"
 	"testing"
 	"time"
 
+	"go.sia.tech/core/types"
 	"go.sia.tech/renterd/api"
+	rhp3 "go.sia.tech/renterd/internal/rhp/v3"
 	"go.sia.tech/renterd/internal/test/mocks"
 )
 
+type sectorCheckingHost struct {
+	sectors   map[types.Hash256]bool
+	checks    int
+	downloads int
+}
+
+func (h *sectorCheckingHost) DownloadSector(ctx context.Context, w io.Writer, root types.Hash256, offset, length uint64) error {
+	h.downloads++
+	if !h.sectors[root] {
+		return rhp3.ErrSectorNotFound
+	}
+	return nil
+}
+
+func (h *sectorCheckingHost) HasSector(ctx context.Context, root types.Hash256) (bool, error) {
+	h.checks++
+	return h.sectors[root], nil
+}
+
+func (h *sectorCheckingHost) PublicKey() types.PublicKey { return types.PublicKey{1} }
+
 func TestDownloaderStopped(t *testing.T) {
 	assertErr := func(t *testing.T, req SectorDownloadReq, expected error) {
 		select {
@@ -51,3 +75,54 @@ func TestDownloaderStopped(t *testing.T) {
 		assertErr(t, req, err)
 	})
 }
+
+func TestDownloaderHasSector(t *testing.T) {
+	h := &sectorCheckingHost{sectors: map[types.Hash256]bool{{1}: true}}
+	dl := New(context.Background(), h)
+
+	download := func(root types.Hash256) error {
+		t.Helper()
+		req := &SectorDownloadReq{
+			Ctx:   context.Background(),
+			Root:  root,
+			Resps: NewSectorResponses(),
+		}
+		err := dl.execute(req)
+		dl.trackFailure(err)
+		return err
+	}
+
+	// assert a reliable host isn't asked whether it has the sector
+	if err := download(types.Hash256{1}); err != nil {
+		t.Fatal(err)
+	} else if h.checks != 0 || h.downloads != 1 {
+		t.Fatal("unexpected calls", h.checks, h.downloads)
+	}
+
+	// assert the host is flagged as unreliable after failing to serve a
+	// sector
+	if err := download(types.Hash256{2}); !rhp3.IsSectorNotFound(err) {
+		t.Fatal("unexpected error", err)
+	} else if !dl.unreliable() {
+		t.Fatal("expected host to be unreliable")
+	} else if !dl.Healthy() {
+		t.Fatal("expected host to be healthy")
+	}
+
+	// assert the download is skipped if the host doesn't have the sector
+	if err := download(types.Hash256{2}); !rhp3.IsSectorNotFound(err) {
+		t.Fatal("unexpected error", err)
+	} else if h.checks != 1 || h.downloads != 2 {
+		t.Fatal("unexpected calls", h.checks, h.downloads)
+	}
+
+	// assert the sector is downloaded if the host has it, which resets the
+	// flag
+	if err := download(types.Hash256{1}); err != nil {
+		t.Fatal(err)
+	} else if h.checks != 2 || h.downloads != 3 {
+		t.Fatal("unexpected calls", h.checks, h.downloads)
+	} else if dl.unreliable() {
+		t.Fatal("expected host to be reliable")
+	}
+}
@@ -24,8 +24,10 @@ import (
 )
 
 type ObjectStore interface {
+	Contract(ctx context.Context, id types.FileContractID) (api.ContractMetadata, error)
 	DeleteHostSector(ctx context.Context, hk types.PublicKey, root types.Hash256) error
 	FetchPartialSlab(ctx context.Context, key object.EncryptionKey, offset, length uint32) ([]byte, error)
+	FindAlternativeContracts(ctx context.Context, root types.Hash256) ([]types.FileContractID, error)
 	Slab(ctx context.Context, key object.EncryptionKey) (object.Slab, error)
 }
 
@@ -45,6 +47,7 @@ type (
 	Manager struct {
 		hm        hosts.Manager
 		mm        memory.MemoryManager
+		mr        MetricsRecorder
 		os        ObjectStore
 		uploadKey *utils.UploadKey
 		logger    *zap.SugaredLogger
@@ -79,6 +82,18 @@ type (
 
 		sectors []*sectorInfo
 		errs    utils.HostErrorSet
+
+		// contractHosts maps the contracts we know of to their host
+		contractHosts map[types.FileContractID]types.PublicKey
+
+		// secondaryContracts contains the alternative contracts that hold
+		// the sector with a given root, they are fetched from the object
+		// store when downloading a sector fails
+		secondaryContracts map[types.Hash256][]types.FileContractID
+
+		// lookupDone is signaled when a background lookup of alternative
+		// contracts finishes
+		lookupDone chan struct{}
 	}
 
 	slabDownloadResponse struct {
@@ -92,6 +107,7 @@ type (
 		root     types.Hash256
 		data     []byte
 		hks      []types.PublicKey
+		tried    map[types.PublicKey]struct{}
 		index    int
 		selected int
 	}
@@ -105,21 +121,35 @@ type (
 	}
 )
 
+func (s *sectorInfo) addHost(h types.PublicKey) {
+	if _, tried := s.tried[h]; tried {
+		return
+	}
+	for _, hk := range s.hks {
+		if hk == h {
+			return
+		}
+	}
+	s.hks = append(s.hks, h)
+}
+
 func (s *sectorInfo) selectHost(h types.PublicKey) {
 	for i, hk := range s.hks {
 		if hk == h {
 			s.hks = append(s.hks[:i], s.hks[i+1:]...) // remove the host
+			s.tried[h] = struct{}{}
 			s.selected++
 			break
 		}
 	}
 }
 
-func NewManager(ctx context.Context, uploadKey *utils.UploadKey, hm hosts.Manager, mm memory.MemoryManager, os ObjectStore, maxOverdrive uint64, overdriveTimeout time.Duration, logger *zap.Logger) *Manager {
+func NewManager(ctx context.Context, uploadKey *utils.UploadKey, hm hosts.Manager, mm memory.MemoryManager, mr MetricsRecorder, os ObjectStore, maxOverdrive uint64, overdriveTimeout time.Duration, logger *zap.Logger) *Manager {
 	logger = logger.Named("downloadmanager")
 	return &Manager{
 		hm:        hm,
 		mm:        mm,
+		mr:        mr,
 		os:        os,
 		uploadKey: uploadKey,
 		logger:    logger.Sugar(),
@@ -486,15 +516,20 @@ func (mgr *Manager) newSlabDownload(slice object.SlabSlice) *slabDownload {
 
 	// build sectors
 	var sectors []*sectorInfo
+	contractHosts := make(map[types.FileContractID]types.PublicKey)
 	for sI, s := range slice.Shards {
 		hks := make([]types.PublicKey, 0, len(s.Contracts))
-		for hk := range s.Contracts {
+		for hk, fcids := range s.Contracts {
 			hks = append(hks, hk)
+			for _, fcid := range fcids {
+				contractHosts[fcid] = hk
+			}
 		}
 		sectors = append(sectors, &sectorInfo{
 			root:  s.Root,
 			index: sI,
 			hks:   hks,
+			tried: make(map[types.PublicKey]struct{}),
 		})
 	}
 
@@ -510,6 +545,10 @@ func (mgr *Manager) newSlabDownload(slice object.SlabSlice) *slabDownload {
 
 		sectors: sectors,
 		errs:    make(utils.HostErrorSet),
+
+		contractHosts:      contractHosts,
+		secondaryContracts: make(map[types.Hash256][]types.FileContractID),
+		lookupDone:         make(chan struct{}, 1),
 	}
 }
 
@@ -518,7 +557,19 @@ func (mgr *Manager) downloadSlab(ctx context.Context, slice object.SlabSlice) ([
 	slab := mgr.newSlabDownload(slice)
 
 	// execute download
-	return slab.download(ctx)
+	start := time.Now()
+	shards, err := slab.download(ctx)
+
+	// record the download, unless it was interrupted
+	if ctx.Err() == nil && mgr.shutdownCtx.Err() == nil {
+		mgr.mr.RecordSlabDownload(api.SlabDownloadMetric{
+			Timestamp:   api.TimeRFC3339(start),
+			SlabKeyHash: api.SlabKeyHash(slice.EncryptionKey),
+			Duration:    api.DurationMS(time.Since(start)),
+			Success:     err == nil,
+		})
+	}
+	return shards, err
 }
 
 func (s *slabDownload) overdrive(ctx context.Context, resps *downloader.SectorResponses) (resetTimer func()) {
@@ -628,18 +679,7 @@ func (s *slabDownload) nextRequest(ctx context.Context, resps *downloader.Sector
 			continue
 		}
 		next.selectHost(fastest.PublicKey())
-		return &downloader.SectorDownloadReq{
-			Ctx: ctx,
-
-			Offset: s.offset,
-			Length: s.length,
-			Root:   next.root,
-			Host:   fastest,
-
-			Overdrive:   overdrive,
-			SectorIndex: next.index,
-			Resps:       resps,
-		}
+		return s.newSectorRequest(ctx, resps, next, fastest, overdrive)
 	}
 
 	// we don't know if the download failed at this point so we register an
@@ -648,6 +688,122 @@ func (s *slabDownload) nextRequest(ctx context.Context, resps *downloader.Sector
 	return nil
 }
 
+// lookupAlternatives looks up all contracts that store the sector of a failed
+// request to find hosts we didn't know about when the download was started. The
+// lookup happens in the background, only the first time a sector fails to
+// download, and launches the replacement request when done. The lookup counts
+// as inflight until then. It returns false if no lookup was started.
+func (s *slabDownload) lookupAlternatives(ctx context.Context, resps *downloader.SectorResponses, failed *downloader.SectorDownloadReq) bool {
+	s.mu.Lock()
+	sector := s.sectors[failed.SectorIndex]
+	if _, fetched := s.secondaryContracts[sector.root]; fetched || len(sector.data) > 0 {
+		s.mu.Unlock()
+		return false
+	}
+	s.secondaryContracts[sector.root] = nil
+	s.numInflight++
+	s.mu.Unlock()
+
+	go func() {
+		fcids, err := s.mgr.os.FindAlternativeContracts(ctx, sector.root)
+		if err != nil {
+			s.mgr.logger.Debugw("failed to fetch alternative contracts", "root", sector.root, zap.Error(err))
+		}
+		hks := s.resolveHosts(ctx, fcids)
+
+		s.mu.Lock()
+		s.secondaryContracts[sector.root] = fcids
+		for _, hk := range hks {
+			sector.addHost(hk)
+		}
+		s.mu.Unlock()
+
+		if ctx.Err() == nil {
+			s.launchReplacement(ctx, resps, failed)
+		}
+
+		s.mu.Lock()
+		s.numInflight--
+		s.mu.Unlock()
+
+		select {
+		case s.lookupDone <- struct{}{}:
+		default:
+		}
+	}()
+	return true
+}
+
+// launchReplacement launches a request to replace a failed one, preferably for
+// the same sector on an alternative host.
+func (s *slabDownload) launchReplacement(ctx context.Context, resps *downloader.SectorResponses, failed *downloader.SectorDownloadReq) {
+	req := s.nextAlternativeRequest(ctx, resps, failed)
+	if req == nil {
+		req = s.nextRequest(ctx, resps, failed.Overdrive)
+	}
+	if req != nil {
+		s.launch(req)
+	}
+}
+
+// nextAlternativeRequest returns a request to download the sector of a failed
+// request from another host that stores the same sector.
+func (s *slabDownload) nextAlternativeRequest(ctx context.Context, resps *downloader.SectorResponses, failed *downloader.SectorDownloadReq) *downloader.SectorDownloadReq {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sector := s.sectors[failed.SectorIndex]
+	if len(sector.data) > 0 {
+		return nil
+	}
+	fastest := s.mgr.fastest(sector.hks)
+	if fastest == nil {
+		return nil
+	}
+	sector.selectHost(fastest.PublicKey())
+	return s.newSectorRequest(ctx, resps, sector, fastest, failed.Overdrive)
+}
+
+func (s *slabDownload) newSectorRequest(ctx context.Context, resps *downloader.SectorResponses, sector *sectorInfo, host *downloader.Downloader, overdrive bool) *downloader.SectorDownloadReq {
+	return &downloader.SectorDownloadReq{
+		Ctx: ctx,
+
+		Offset: s.offset,
+		Length: s.length,
+		Root:   sector.root,
+		Host:   host,
+
+		Overdrive:   overdrive,
+		SectorIndex: sector.index,
+		Resps:       resps,
+	}
+}
+
+// resolveHosts returns the hosts of the given contracts, contracts we don't
+// know of yet are fetched from the object store.
+func (s *slabDownload) resolveHosts(ctx context.Context, fcids []types.FileContractID) (hks []types.PublicKey) {
+	for _, fcid := range fcids {
+		s.mu.Lock()
+		hk, known := s.contractHosts[fcid]
+		s.mu.Unlock()
+
+		if !known {
+			c, err := s.mgr.os.Contract(ctx, fcid)
+			if err != nil {
+				s.mgr.logger.Debugw("failed to fetch alternative contract", "fcid", fcid, zap.Error(err))
+				continue
+			}
+			hk = c.HostKey
+
+			s.mu.Lock()
+			s.contractHosts[fcid] = hk
+			s.mu.Unlock()
+		}
+		hks = append(hks, hk)
+	}
+	return
+}
+
 func (s *slabDownload) download(ctx context.Context) ([][]byte, error) {
 	// cancel any sector downloads once the download is done
 	ctx, cancel := context.WithCancel(ctx)
@@ -680,6 +836,7 @@ func (s *slabDownload) download(ctx context.Context) ([][]byte, error) {
 			return nil, context.Cause(ctx)
 		case <-resps.Received():
 			resetOverdrive()
+		case <-s.lookupDone:
 		}
 
 		for {
@@ -696,9 +853,10 @@ func (s *slabDownload) download(ctx context.Context) ([][]byte, error) {
 
 			// handle errors
 			if resp.Err != nil {
-				// launch replacement request
-				if req := s.nextRequest(ctx, resps, resp.Req.Overdrive); req != nil {
-					s.launch(req)
+				// launch replacement request, unless we're looking up
+				// alternative hosts for the sector which launches it for us
+				if !s.lookupAlternatives(ctx, resps, resp.Req) {
+					s.launchReplacement(ctx, resps, resp.Req)
 				}
 
 				// handle lost sectors
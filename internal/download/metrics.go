@@ -0,0 +1,117 @@
+package download
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.sia.tech/renterd/api"
+	"go.uber.org/zap"
+)
+
+// maxBufferedSlabDownloadMetrics is the maximum number of slab download
+// metrics that are buffered before the oldest ones are dropped, it prevents
+// the buffer from growing indefinitely when the bus is unreachable.
+const maxBufferedSlabDownloadMetrics = 10000
+
+var (
+	_ MetricsRecorder = (*slabDownloadRecorder)(nil)
+)
+
+type (
+	MetricsStore interface {
+		RecordSlabDownloadMetric(ctx context.Context, metrics ...api.SlabDownloadMetric) error
+	}
+
+	MetricsRecorder interface {
+		RecordSlabDownload(api.SlabDownloadMetric)
+		Stop(context.Context)
+	}
+
+	slabDownloadRecorder struct {
+		flushInterval time.Duration
+
+		store  MetricsStore
+		logger *zap.SugaredLogger
+
+		mu      sync.Mutex
+		metrics []api.SlabDownloadMetric
+
+		flushCtx   context.Context
+		flushTimer *time.Timer
+	}
+)
+
+func NewMetricsRecorder(ctx context.Context, ms MetricsStore, flushInterval time.Duration, logger *zap.Logger) MetricsRecorder {
+	logger = logger.Named("downloadmetrics")
+	return &slabDownloadRecorder{
+		store:  ms,
+		logger: logger.Sugar(),
+
+		flushCtx:      ctx,
+		flushInterval: flushInterval,
+	}
+}
+
+// RecordSlabDownload stores the given metric until it gets flushed to the bus.
+func (r *slabDownloadRecorder) RecordSlabDownload(m api.SlabDownloadMetric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// record the metric, dropping the oldest one if the buffer is full
+	if len(r.metrics) >= maxBufferedSlabDownloadMetrics {
+		r.metrics = r.metrics[1:]
+	}
+	r.metrics = append(r.metrics, m)
+
+	// schedule flush
+	if r.flushTimer == nil {
+		r.flushTimer = time.AfterFunc(r.flushInterval, r.flush)
+	}
+}
+
+// Stop stops the flush timer and flushes one last time.
+func (r *slabDownloadRecorder) Stop(ctx context.Context) {
+	// stop the flush timer
+	r.mu.Lock()
+	if r.flushTimer != nil {
+		r.flushTimer.Stop()
+	}
+	r.flushCtx = ctx
+	r.mu.Unlock()
+
+	// flush all metrics
+	r.flush()
+
+	// log if we weren't able to flush them
+	r.mu.Lock()
+	if len(r.metrics) > 0 {
+		r.logger.Errorw(fmt.Sprintf("failed to record %d slab download metrics on worker shutdown", len(r.metrics)))
+	}
+	r.mu.Unlock()
+}
+
+func (r *slabDownloadRecorder) flush() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// NOTE: don't bother flushing if the context is cancelled, we can safely
+	// ignore the buffered metrics since we'll flush on shutdown and log in case
+	// we weren't able to flush all metrics to the bus
+	select {
+	case <-r.flushCtx.Done():
+		r.flushTimer = nil
+		return
+	default:
+	}
+
+	if len(r.metrics) > 0 {
+		if err := r.store.RecordSlabDownloadMetric(r.flushCtx, r.metrics...); err != nil {
+			r.logger.Errorw(fmt.Sprintf("failed to record slab download metrics: %v", err))
+		} else {
+			r.metrics = nil
+		}
+	}
+	r.flushTimer = nil
+}
@@ -2,6 +2,8 @@ package host
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
 
 	rhpv2 "go.sia.tech/core/rhp/v2"
@@ -9,14 +11,47 @@ import (
 	"go.sia.tech/renterd/api"
 )
 
+const (
+	PreflightFailureReasonContractMismatch       PreflightFailureReason = "contract_mismatch"
+	PreflightFailureReasonInsufficientCollateral PreflightFailureReason = "insufficient_collateral"
+	PreflightFailureReasonInsufficientFunds      PreflightFailureReason = "insufficient_funds"
+	PreflightFailureReasonInvalidSector          PreflightFailureReason = "invalid_sector"
+	PreflightFailureReasonMaxRevisionReached     PreflightFailureReason = "max_revision_reached"
+	PreflightFailureReasonPriceTableExpired      PreflightFailureReason = "price_table_expired"
+)
+
+// ErrPreflightFailed is returned when a sector upload is rejected before any
+// data is sent to the host.
+var ErrPreflightFailed = errors.New("sector upload pre-flight validation failed")
+
+type (
+	// PreflightFailureReason describes why a sector upload failed its
+	// pre-flight validation.
+	PreflightFailureReason string
+
+	// PreflightError is returned by ValidateSectorUpload, it wraps both
+	// ErrPreflightFailed and the underlying cause.
+	PreflightError struct {
+		Reason PreflightFailureReason
+		Err    error
+	}
+)
+
 type (
 	Downloader interface {
 		DownloadSector(ctx context.Context, w io.Writer, root types.Hash256, offset, length uint64) error
 		PublicKey() types.PublicKey
 	}
 
+	// SectorChecker is implemented by downloaders that can check whether the
+	// host has a sector without downloading it.
+	SectorChecker interface {
+		HasSector(ctx context.Context, root types.Hash256) (bool, error)
+	}
+
 	Uploader interface {
 		UploadSector(context.Context, types.Hash256, *[rhpv2.SectorSize]byte) error
+		ValidateSectorUpload(context.Context, types.FileContractID, *[rhpv2.SectorSize]byte) error
 		PublicKey() types.PublicKey
 	}
 
@@ -29,3 +64,28 @@ type (
 		SyncAccount(ctx context.Context, rev *types.FileContractRevision) error
 	}
 )
+
+// NewPreflightError returns a new pre-flight error for the given reason.
+func NewPreflightError(reason PreflightFailureReason, err error) *PreflightError {
+	return &PreflightError{Reason: reason, Err: err}
+}
+
+func (e *PreflightError) Error() string {
+	return fmt.Sprintf("%v (%v): %v", ErrPreflightFailed, e.Reason, e.Err)
+}
+
+func (e *PreflightError) Unwrap() []error {
+	return []error{ErrPreflightFailed, e.Err}
+}
+
+// ValidateSector performs the pre-flight checks that don't require contacting
+// the host, it ensures the sector is uploaded to the expected contract and that
+// it contains exactly rhpv2.SectorSize bytes.
+func ValidateSector(expected, fcid types.FileContractID, sector *[rhpv2.SectorSize]byte) error {
+	if fcid != expected {
+		return NewPreflightError(PreflightFailureReasonContractMismatch, fmt.Errorf("expected contract %v, got %v", expected, fcid))
+	} else if sector == nil {
+		return NewPreflightError(PreflightFailureReasonInvalidSector, fmt.Errorf("sector must be exactly %d bytes", rhpv2.SectorSize))
+	}
+	return nil
+}
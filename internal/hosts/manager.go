@@ -6,6 +6,7 @@ import (
 	"io"
 	"math"
 	"net"
+	"time"
 
 	"go.sia.tech/core/types"
 	"go.sia.tech/renterd/api"
@@ -28,8 +29,9 @@ import (
 )
 
 var (
-	_ host.Host = (*hostClient)(nil)
-	_ Manager   = (*hostManager)(nil)
+	_ host.Host          = (*hostClient)(nil)
+	_ host.SectorChecker = (*hostDownloadClient)(nil)
+	_ Manager            = (*hostManager)(nil)
 )
 
 type (
@@ -250,6 +252,25 @@ func (c *hostDownloadClient) DownloadSector(ctx context.Context, w io.Writer, ro
 	})
 }
 
+// HasSector checks whether the host has the sector with the given root using
+// the HasSector instruction, which is a lot cheaper than a failed download.
+func (c *hostDownloadClient) HasSector(ctx context.Context, root types.Hash256) (hasSector bool, err error) {
+	err = c.acc.WithWithdrawal(func() (types.Currency, error) {
+		pt, ptc, err := c.pts.Fetch(ctx, c, nil)
+		if err != nil {
+			return types.ZeroCurrency, err
+		}
+
+		var cost types.Currency
+		hasSector, cost, err = c.rhp3.HasSector(ctx, root, c.hi.PublicKey, c.hi.SiamuxAddr, c.acc.ID(), c.acc.Key(), pt.HostPriceTable)
+		if err != nil {
+			return ptc, err
+		}
+		return ptc.Add(cost), nil
+	})
+	return
+}
+
 func (c *hostDownloadClient) PriceTable(ctx context.Context, rev *types.FileContractRevision) (hpt api.HostPriceTable, cost types.Currency, err error) {
 	hpt, err = c.rhp3.PriceTable(ctx, c.hi.PublicKey, c.hi.SiamuxAddr, rhp3.PreparePriceTableAccountPayment(c.acc.Key()))
 	if err == nil {
@@ -289,6 +310,42 @@ func (c *hostUploadClient) PriceTable(ctx context.Context, rev *types.FileContra
 	return
 }
 
+func (c *hostUploadClient) ValidateSectorUpload(ctx context.Context, fcid types.FileContractID, sector *[rhpv2.SectorSize]byte) error {
+	if err := host.ValidateSector(c.fcid, fcid, sector); err != nil {
+		return err
+	}
+
+	// check the contract can still be revised
+	rev, err := c.rhp3.Revision(ctx, c.fcid, c.hi.PublicKey, c.hi.SiamuxAddr)
+	if err != nil {
+		return fmt.Errorf("%w; %w", rhp3.ErrFailedToFetchRevision, err)
+	} else if rev.RevisionNumber == math.MaxUint64 {
+		return host.NewPreflightError(host.PreflightFailureReasonMaxRevisionReached, rhp3.ErrMaxRevisionReached)
+	}
+
+	// check the price table is not expired
+	var hpt api.HostPriceTable
+	if err := c.acc.WithWithdrawal(func() (amount types.Currency, err error) {
+		hpt, amount, err = c.pts.Fetch(ctx, c, nil)
+		return
+	}); err != nil {
+		return err
+	} else if !time.Now().Before(hpt.Expiry) {
+		return host.NewPreflightError(host.PreflightFailureReasonPriceTableExpired, fmt.Errorf("price table expired at %v", hpt.Expiry))
+	}
+
+	// check the contract has enough funds and collateral left
+	cost, collateral, err := rhp3.UploadSectorCost(hpt.HostPriceTable, rev.WindowEnd)
+	if err != nil {
+		return err
+	} else if rev.ValidRenterPayout().Cmp(cost) < 0 {
+		return host.NewPreflightError(host.PreflightFailureReasonInsufficientFunds, fmt.Errorf("remaining renter funds %v are less than the upload cost %v", rev.ValidRenterPayout(), cost))
+	} else if rev.MissedHostPayout().Cmp(collateral) < 0 {
+		return host.NewPreflightError(host.PreflightFailureReasonInsufficientCollateral, fmt.Errorf("remaining host collateral %v is less than the required collateral %v", rev.MissedHostPayout(), collateral))
+	}
+	return nil
+}
+
 func (c *hostUploadClient) UploadSector(ctx context.Context, sectorRoot types.Hash256, sector *[rhpv2.SectorSize]byte) error {
 	rev, err := c.rhp3.Revision(ctx, c.fcid, c.hi.PublicKey, c.hi.SiamuxAddr)
 	if err != nil {
@@ -298,12 +355,14 @@ func (c *hostUploadClient) UploadSector(ctx context.Context, sectorRoot types.Ha
 	}
 
 	var hpt rhpv3.HostPriceTable
+	var ptCost types.Currency
 	if err := c.acc.WithWithdrawal(func() (amount types.Currency, err error) {
 		pt, cost, err := c.pts.Fetch(ctx, c, nil)
 		if err != nil {
 			return types.ZeroCurrency, err
 		}
 		hpt = pt.HostPriceTable
+		ptCost = cost
 
 		gc, err := gouging.CheckerFromContext(ctx)
 		if err != nil {
@@ -322,7 +381,46 @@ func (c *hostUploadClient) UploadSector(ctx context.Context, sectorRoot types.Ha
 		return fmt.Errorf("failed to upload sector: %w", err)
 	}
 
-	c.csr.RecordV1(rev, api.ContractSpending{Uploads: cost})
+	c.csr.RecordV1(rev, api.ContractSpending{
+		Uploads:          cost,
+		PriceTableFees:   ptCost,
+		SectorUploadFees: cost,
+	})
+	return nil
+}
+
+func (c *hostV2UploadClient) ValidateSectorUpload(ctx context.Context, fcid types.FileContractID, sector *[rhpv2.SectorSize]byte) error {
+	if err := host.ValidateSector(c.fcid, fcid, sector); err != nil {
+		return err
+	}
+
+	// check the contract can still be revised
+	fc, err := c.rhp4.LatestRevision(ctx, c.hi.PublicKey, c.hi.V2SiamuxAddr(), c.fcid)
+	if err != nil {
+		return err
+	} else if fc.RevisionNumber == math.MaxUint64 {
+		return host.NewPreflightError(host.PreflightFailureReasonMaxRevisionReached, rhp3.ErrMaxRevisionReached)
+	}
+
+	// check the prices are not expired
+	prices, err := c.pts.Fetch(ctx, c)
+	if err != nil {
+		return err
+	} else if !time.Now().Before(prices.ValidUntil) {
+		return host.NewPreflightError(host.PreflightFailureReasonPriceTableExpired, fmt.Errorf("prices expired at %v", prices.ValidUntil))
+	}
+
+	// check the contract has enough funds and collateral left
+	var duration uint64
+	if fc.ExpirationHeight > prices.TipHeight {
+		duration = fc.ExpirationHeight - prices.TipHeight
+	}
+	usage := prices.RPCAppendSectorsCost(1, duration)
+	if cost := usage.RenterCost(); fc.RenterOutput.Value.Cmp(cost) < 0 {
+		return host.NewPreflightError(host.PreflightFailureReasonInsufficientFunds, fmt.Errorf("remaining renter funds %v are less than the upload cost %v", fc.RenterOutput.Value, cost))
+	} else if collateral := usage.HostRiskedCollateral(); fc.MissedHostValue.Cmp(collateral) < 0 {
+		return host.NewPreflightError(host.PreflightFailureReasonInsufficientCollateral, fmt.Errorf("remaining host collateral %v is less than the required collateral %v", fc.MissedHostValue, collateral))
+	}
 	return nil
 }
 
@@ -354,7 +452,10 @@ func (c *hostV2UploadClient) UploadSector(ctx context.Context, sectorRoot types.
 			return cost, fmt.Errorf("failed to write sector: %w", err)
 		}
 
-		c.csr.RecordV2(rhp.ContractRevision{ID: rev.ID, Revision: res2.Revision}, api.ContractSpending{Uploads: res2.Usage.RenterCost()})
+		c.csr.RecordV2(rhp.ContractRevision{ID: rev.ID, Revision: res2.Revision}, api.ContractSpending{
+			Uploads:          res2.Usage.RenterCost(),
+			SectorUploadFees: cost.Add(res2.Usage.RenterCost()),
+		})
 		return cost, nil
 	})
 }
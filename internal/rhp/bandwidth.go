@@ -0,0 +1,256 @@
+package rhp
+
+import (
+	"context"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/api"
+	"golang.org/x/time/rate"
+)
+
+type (
+	// BandwidthConfig configures the bandwidth limits that are applied to
+	// connections with hosts. Limits in Hosts take precedence over the
+	// default.
+	BandwidthConfig struct {
+		Default api.HostBandwidthLimits
+		Hosts   map[types.PublicKey]api.HostBandwidthLimits
+	}
+
+	// BandwidthLimiter limits the bandwidth of connections with hosts. All
+	// connections to the same host share the same limit, a host's limiter is
+	// evicted once its last connection is closed.
+	BandwidthLimiter struct {
+		mu       sync.Mutex
+		def      api.HostBandwidthLimits
+		limits   map[types.PublicKey]api.HostBandwidthLimits
+		limiters map[types.PublicKey]*hostLimiter
+	}
+
+	hostLimiter struct {
+		upload   *rate.Limiter
+		download *rate.Limiter
+		conns    int // locked by BandwidthLimiter
+	}
+
+	limitedConn struct {
+		net.Conn
+		bl *BandwidthLimiter
+		hk types.PublicKey
+		hl *hostLimiter
+
+		// ctx is cancelled when the connection is closed, it interrupts
+		// reads and writes that are waiting on the limiter
+		ctx       context.Context
+		cancel    context.CancelFunc
+		closeOnce sync.Once
+
+		mu            sync.Mutex
+		readDeadline  time.Time
+		writeDeadline time.Time
+	}
+)
+
+// NewBandwidthLimiter returns a limiter that applies the limits of the given
+// config.
+func NewBandwidthLimiter(cfg BandwidthConfig) *BandwidthLimiter {
+	limits := make(map[types.PublicKey]api.HostBandwidthLimits)
+	for hk, l := range cfg.Hosts {
+		limits[hk] = l
+	}
+	return &BandwidthLimiter{
+		def:      cfg.Default,
+		limits:   limits,
+		limiters: make(map[types.PublicKey]*hostLimiter),
+	}
+}
+
+// Limits returns the limits that apply to connections with the given host.
+func (bl *BandwidthLimiter) Limits(hk types.PublicKey) api.HostBandwidthLimits {
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+	return bl.limitsFor(hk)
+}
+
+// SetLimits updates the limits for the given host, the new limits apply to
+// existing connections as well.
+func (bl *BandwidthLimiter) SetLimits(hk types.PublicKey, limits api.HostBandwidthLimits) {
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+	bl.limits[hk] = limits
+	if hl, ok := bl.limiters[hk]; ok {
+		setLimit(hl.upload, limits.Upload)
+		setLimit(hl.download, limits.Download)
+	}
+}
+
+// Wrap wraps the given connection to a host, the returned connection is
+// limited by the host's limits.
+func (bl *BandwidthLimiter) Wrap(hk types.PublicKey, conn net.Conn) net.Conn {
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+
+	hl, ok := bl.limiters[hk]
+	if !ok {
+		limits := bl.limitsFor(hk)
+		hl = &hostLimiter{
+			upload:   rate.NewLimiter(rate.Inf, 0),
+			download: rate.NewLimiter(rate.Inf, 0),
+		}
+		setLimit(hl.upload, limits.Upload)
+		setLimit(hl.download, limits.Download)
+		bl.limiters[hk] = hl
+	}
+	hl.conns++
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &limitedConn{
+		Conn:   conn,
+		bl:     bl,
+		hk:     hk,
+		hl:     hl,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// release is called when a connection to the given host is closed, the
+// host's limiter is evicted if it has no connections left.
+func (bl *BandwidthLimiter) release(hk types.PublicKey, hl *hostLimiter) {
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+	hl.conns--
+	if hl.conns == 0 && bl.limiters[hk] == hl {
+		delete(bl.limiters, hk)
+	}
+}
+
+func (bl *BandwidthLimiter) limitsFor(hk types.PublicKey) api.HostBandwidthLimits {
+	if l, ok := bl.limits[hk]; ok {
+		return l
+	}
+	return bl.def
+}
+
+// Close implements net.Conn.
+func (c *limitedConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.cancel()
+		c.bl.release(c.hk, c.hl)
+	})
+	return c.Conn.Close()
+}
+
+// SetDeadline implements net.Conn.
+func (c *limitedConn) SetDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.readDeadline, c.writeDeadline = t, t
+	c.mu.Unlock()
+	return c.Conn.SetDeadline(t)
+}
+
+// SetReadDeadline implements net.Conn.
+func (c *limitedConn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.readDeadline = t
+	c.mu.Unlock()
+	return c.Conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline implements net.Conn.
+func (c *limitedConn) SetWriteDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.writeDeadline = t
+	c.mu.Unlock()
+	return c.Conn.SetWriteDeadline(t)
+}
+
+// Read implements net.Conn, reads from the host count towards its download
+// limit.
+func (c *limitedConn) Read(b []byte) (int, error) {
+	b = b[:chunkSize(c.hl.download, len(b))]
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		c.mu.Lock()
+		deadline := c.readDeadline
+		c.mu.Unlock()
+		if err := c.wait(c.hl.download, n, deadline); err != nil {
+			return n, err
+		}
+	}
+	return n, err
+}
+
+// Write implements net.Conn, writes to the host count towards its upload
+// limit.
+func (c *limitedConn) Write(b []byte) (written int, _ error) {
+	for len(b) > 0 {
+		c.mu.Lock()
+		deadline := c.writeDeadline
+		c.mu.Unlock()
+
+		n := chunkSize(c.hl.upload, len(b))
+		if err := c.wait(c.hl.upload, n, deadline); err != nil {
+			return written, err
+		}
+		n, err := c.Conn.Write(b[:n])
+		written += n
+		if err != nil {
+			return written, err
+		}
+		b = b[n:]
+	}
+	return written, nil
+}
+
+// chunkSize returns the number of bytes that can be transferred at once
+// without exceeding the limiter's burst.
+func chunkSize(l *rate.Limiter, n int) int {
+	if l.Limit() == rate.Inf {
+		return n
+	}
+	return min(n, l.Burst())
+}
+
+// wait blocks until the limiter allows n bytes to be transferred. It waits in
+// chunks since the limits might have been lowered since the chunk size was
+// determined. Waiting is interrupted when the connection is closed or the
+// given deadline passes.
+func (c *limitedConn) wait(l *rate.Limiter, n int, deadline time.Time) error {
+	ctx := c.ctx
+	if !deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+
+	for n > 0 {
+		chunk := chunkSize(l, n)
+		if err := l.WaitN(ctx, chunk); err != nil {
+			if c.ctx.Err() != nil {
+				return net.ErrClosed
+			} else if !deadline.IsZero() {
+				return os.ErrDeadlineExceeded
+			}
+			return err
+		}
+		n -= chunk
+	}
+	return nil
+}
+
+// setLimit sets the limiter's limit in bytes per second, 0 means unlimited.
+// The burst matches the limit to allow transferring a second worth of data
+// at once.
+func setLimit(l *rate.Limiter, bps uint64) {
+	if bps == 0 {
+		l.SetLimit(rate.Inf)
+		return
+	}
+	l.SetBurst(int(bps))
+	l.SetLimit(rate.Limit(bps))
+}
@@ -0,0 +1,129 @@
+package rhp
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/api"
+)
+
+func TestBandwidthLimiter(t *testing.T) {
+	hk1, hk2 := types.PublicKey{1}, types.PublicKey{2}
+	bl := NewBandwidthLimiter(BandwidthConfig{
+		Default: api.HostBandwidthLimits{Upload: 1 << 20},
+		Hosts: map[types.PublicKey]api.HostBandwidthLimits{
+			hk1: {Upload: 50_000, Download: 50_000},
+		},
+	})
+
+	// assert host limits take precedence over the default
+	if l := bl.Limits(hk1); l.Upload != 50_000 || l.Download != 50_000 {
+		t.Fatal("unexpected limits", l)
+	} else if l := bl.Limits(hk2); l.Upload != 1<<20 || l.Download != 0 {
+		t.Fatal("unexpected limits", l)
+	}
+
+	// transfer measures how long it takes to write n bytes to the given host
+	// and read them on the other end
+	transfer := func(hk types.PublicKey, n int) time.Duration {
+		t.Helper()
+		c1, c2 := net.Pipe()
+		defer c1.Close()
+		defer c2.Close()
+		conn := bl.Wrap(hk, c1)
+
+		start := time.Now()
+		errCh := make(chan error, 1)
+		go func() {
+			_, err := conn.Write(make([]byte, n))
+			errCh <- err
+		}()
+		if _, err := io.ReadFull(c2, make([]byte, n)); err != nil {
+			t.Fatal(err)
+		} else if err := <-errCh; err != nil {
+			t.Fatal(err)
+		}
+		return time.Since(start)
+	}
+
+	// assert uploads are limited, the first 50kB are allowed immediately so
+	// uploading 100kB takes at least a second
+	if d := transfer(hk1, 100_000); d < 900*time.Millisecond {
+		t.Fatal("upload wasn't limited", d)
+	}
+
+	// assert the limit can be lifted at runtime
+	bl.SetLimits(hk1, api.HostBandwidthLimits{})
+	if d := transfer(hk1, 100_000); d > 500*time.Millisecond {
+		t.Fatal("upload was limited", d)
+	}
+
+	// assert downloads are limited
+	bl.SetLimits(hk2, api.HostBandwidthLimits{Download: 50_000})
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+	conn := bl.Wrap(hk2, c1)
+	go c2.Write(make([]byte, 100_000))
+
+	start := time.Now()
+	if _, err := io.ReadFull(conn, make([]byte, 100_000)); err != nil {
+		t.Fatal(err)
+	} else if d := time.Since(start); d < 900*time.Millisecond {
+		t.Fatal("download wasn't limited", d)
+	}
+}
+
+func TestBandwidthLimiterClose(t *testing.T) {
+	hk := types.PublicKey{1}
+	bl := NewBandwidthLimiter(BandwidthConfig{
+		Default: api.HostBandwidthLimits{Upload: 1000},
+	})
+
+	c1, c2 := net.Pipe()
+	defer c2.Close()
+	go io.Copy(io.Discard, c2)
+	conn := bl.Wrap(hk, c1)
+	if len(bl.limiters) != 1 {
+		t.Fatal("expected limiter")
+	}
+
+	// assert a write that is waiting on the limiter is interrupted when the
+	// connection is closed
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := conn.Write(make([]byte, 1_000_000))
+		errCh <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+	conn.Close()
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, net.ErrClosed) {
+			t.Fatal("unexpected error", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("write wasn't interrupted")
+	}
+
+	// assert the limiter was evicted
+	if len(bl.limiters) != 0 {
+		t.Fatal("expected limiter to be evicted")
+	}
+
+	// assert a write that would exceed the deadline fails
+	c1, c2 = net.Pipe()
+	defer c2.Close()
+	go io.Copy(io.Discard, c2)
+	conn = bl.Wrap(hk, c1)
+	defer conn.Close()
+	conn.SetWriteDeadline(time.Now().Add(100 * time.Millisecond))
+	if _, err := conn.Write(make([]byte, 1_000_000)); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatal("unexpected error", err)
+	}
+}
@@ -5,35 +5,67 @@ import (
 	"fmt"
 	"net"
 	"sync"
+	"time"
 
 	"go.sia.tech/core/types"
 	"go.sia.tech/renterd/api"
 	"go.uber.org/zap"
 )
 
-// Cache to store resolved IPs
-type hostCache struct {
-	mu    sync.RWMutex
-	cache map[string]string // hostname -> IP address
-}
+// DefaultHostCacheTTL is the default time a resolved IP is cached for.
+const DefaultHostCacheTTL = time.Hour
+
+type (
+	// hostCache caches resolved IPs, entries expire after ttl, a ttl of 0
+	// means entries never expire.
+	hostCache struct {
+		ttl time.Duration
+
+		mu        sync.Mutex
+		cache     map[string]hostCacheEntry // hostname -> IP address
+		hits      uint64
+		misses    uint64
+		evictions uint64
+	}
+
+	hostCacheEntry struct {
+		ip     string
+		expiry time.Time
+	}
+)
 
-func newHostCache() *hostCache {
+func newHostCache(ttl time.Duration) *hostCache {
 	return &hostCache{
-		cache: make(map[string]string),
+		ttl:   ttl,
+		cache: make(map[string]hostCacheEntry),
 	}
 }
 
 func (hc *hostCache) Get(hostname string) (string, bool) {
-	hc.mu.RLock()
-	defer hc.mu.RUnlock()
-	ip, ok := hc.cache[hostname]
-	return ip, ok
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	entry, ok := hc.cache[hostname]
+	if ok && hc.expired(entry, time.Now()) {
+		delete(hc.cache, hostname)
+		hc.evictions++
+		ok = false
+	}
+	if ok {
+		hc.hits++
+	} else {
+		hc.misses++
+	}
+	return entry.ip, ok
 }
 
 func (hc *hostCache) Set(hostname, ip string) {
 	hc.mu.Lock()
 	defer hc.mu.Unlock()
-	hc.cache[hostname] = ip
+	entry := hostCacheEntry{ip: ip}
+	if hc.ttl > 0 {
+		entry.expiry = time.Now().Add(hc.ttl)
+	}
+	hc.cache[hostname] = entry
 }
 
 func (hc *hostCache) Delete(hostname string) {
@@ -42,6 +74,36 @@ func (hc *hostCache) Delete(hostname string) {
 	delete(hc.cache, hostname)
 }
 
+// Stats returns the stats of the cache.
+func (hc *hostCache) Stats() api.DialerCacheStats {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	return api.DialerCacheStats{
+		Entries:   len(hc.cache),
+		Hits:      hc.hits,
+		Misses:    hc.misses,
+		Evictions: hc.evictions,
+		TTL:       api.DurationMS(hc.ttl),
+	}
+}
+
+// Sweep removes all expired entries from the cache.
+func (hc *hostCache) Sweep() {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	now := time.Now()
+	for hostname, entry := range hc.cache {
+		if hc.expired(entry, now) {
+			delete(hc.cache, hostname)
+			hc.evictions++
+		}
+	}
+}
+
+func (hc *hostCache) expired(entry hostCacheEntry, now time.Time) bool {
+	return !entry.expiry.IsZero() && !now.Before(entry.expiry)
+}
+
 type DialerBus interface {
 	Host(ctx context.Context, hostKey types.PublicKey) (api.Host, error)
 }
@@ -50,22 +112,66 @@ type DialerBus interface {
 type FallbackDialer struct {
 	cache *hostCache
 
-	bus    DialerBus
-	logger *zap.SugaredLogger
-	dialer net.Dialer
+	bus       DialerBus
+	logger    *zap.SugaredLogger
+	dialer    net.Dialer
+	bandwidth *BandwidthLimiter
 }
 
-func NewFallbackDialer(bus DialerBus, dialer net.Dialer, logger *zap.Logger) *FallbackDialer {
+// NewFallbackDialer returns a new FallbackDialer, resolved IPs are cached for
+// the given TTL, a TTL of 0 caches them indefinitely.
+func NewFallbackDialer(bus DialerBus, dialer net.Dialer, bwCfg BandwidthConfig, cacheTTL time.Duration, logger *zap.Logger) *FallbackDialer {
 	return &FallbackDialer{
-		cache: newHostCache(),
+		cache: newHostCache(cacheTTL),
 
-		bus:    bus,
-		logger: logger.Sugar().Named("fallbackdialer"),
-		dialer: dialer,
+		bus:       bus,
+		logger:    logger.Sugar().Named("fallbackdialer"),
+		dialer:    dialer,
+		bandwidth: NewBandwidthLimiter(bwCfg),
+	}
+}
+
+// BandwidthLimiter returns the limiter that is applied to the connections
+// returned by the dialer.
+func (d *FallbackDialer) BandwidthLimiter() *BandwidthLimiter {
+	return d.bandwidth
+}
+
+// CacheStats returns the stats of the dialer's cache of resolved IPs.
+func (d *FallbackDialer) CacheStats() api.DialerCacheStats {
+	return d.cache.Stats()
+}
+
+// SweepCache removes expired entries from the dialer's cache of resolved IPs
+// every TTL/2 until the context is closed. It's a no-op if the cache doesn't
+// expire entries.
+func (d *FallbackDialer) SweepCache(ctx context.Context) {
+	if d.cache.ttl <= 0 {
+		return
+	}
+
+	t := time.NewTicker(d.cache.ttl / 2)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		d.cache.Sweep()
 	}
 }
 
 func (d *FallbackDialer) Dial(ctx context.Context, hk types.PublicKey, address string) (net.Conn, error) {
+	conn, err := d.dial(ctx, hk, address)
+	if err != nil {
+		return nil, err
+	}
+	return d.bandwidth.Wrap(hk, conn), nil
+}
+
+func (d *FallbackDialer) dial(ctx context.Context, hk types.PublicKey, address string) (net.Conn, error) {
 	host, port, err := net.SplitHostPort(address)
 	if err != nil {
 		return nil, fmt.Errorf("failed to split host and port of host address '%v': %w", address, err)
@@ -75,7 +181,9 @@ func (d *FallbackDialer) Dial(ctx context.Context, hk types.PublicKey, address s
 	// Dial and cache the resolved IP if dial successful
 	conn, err := d.dialer.DialContext(ctx, "tcp", address)
 	if err == nil {
-		d.cache.Set(host, conn.RemoteAddr().String())
+		if ip, _, err := net.SplitHostPort(conn.RemoteAddr().String()); err == nil {
+			d.cache.Set(host, ip)
+		}
 		return conn, nil
 	}
 
@@ -89,5 +197,15 @@ func (d *FallbackDialer) Dial(ctx context.Context, hk types.PublicKey, address s
 		// Delete the cache if the cached IP doesn't work
 		d.cache.Delete(host)
 	}
+
+	// If the host's SiaMux port was found to be unreachable, fall back to its
+	// net address
+	if h, err := d.bus.Host(ctx, hk); err == nil && !h.SiaMuxReachable && h.NetAddress != "" && h.NetAddress != address {
+		logger.Debug("SiaMux port unreachable, falling back to net address", zap.String("netAddress", h.NetAddress))
+		conn, err := d.dialer.DialContext(ctx, "tcp", h.NetAddress)
+		if err == nil {
+			return conn, nil
+		}
+	}
 	return nil, fmt.Errorf("failed to dial %s with all methods", address)
 }
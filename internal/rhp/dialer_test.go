@@ -0,0 +1,139 @@
+package rhp
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/api"
+	"go.uber.org/zap"
+)
+
+type mockDialerBus struct {
+	host api.Host
+}
+
+func (b *mockDialerBus) Host(ctx context.Context, hostKey types.PublicKey) (api.Host, error) {
+	return b.host, nil
+}
+
+func TestFallbackDialerSiaMuxUnreachable(t *testing.T) {
+	// start a listener for the host's net address
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	// grab an address nobody listens on for the SiaMux port
+	unused, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	siamuxAddr := unused.Addr().String()
+	unused.Close()
+
+	bus := &mockDialerBus{host: api.Host{NetAddress: l.Addr().String(), SiaMuxReachable: true}}
+	d := NewFallbackDialer(bus, net.Dialer{}, BandwidthConfig{}, DefaultHostCacheTTL, zap.NewNop())
+
+	// assert dialing fails if the SiaMux port is considered reachable
+	if _, err := d.Dial(context.Background(), types.PublicKey{1}, siamuxAddr); err == nil {
+		t.Fatal("expected dial to fail")
+	}
+
+	// assert we fall back to the net address if it's unreachable
+	bus.host.SiaMuxReachable = false
+	conn, err := d.Dial(context.Background(), types.PublicKey{1}, siamuxAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if conn.RemoteAddr().String() != l.Addr().String() {
+		t.Fatal("unexpected remote address", conn.RemoteAddr())
+	}
+}
+
+func TestHostCacheTTL(t *testing.T) {
+	hc := newHostCache(time.Hour)
+
+	// assert a fresh entry is returned
+	hc.Set("foo.com", "1.1.1.1")
+	if ip, ok := hc.Get("foo.com"); !ok || ip != "1.1.1.1" {
+		t.Fatal("unexpected", ip, ok)
+	}
+
+	// expire the entry and assert it's evicted on read
+	hc.cache["foo.com"] = hostCacheEntry{ip: "1.1.1.1", expiry: time.Now().Add(-time.Second)}
+	if _, ok := hc.Get("foo.com"); ok {
+		t.Fatal("expected entry to be expired")
+	} else if len(hc.cache) != 0 {
+		t.Fatal("expected entry to be evicted")
+	}
+
+	// add an expired and a fresh entry and assert sweeping only removes the
+	// expired one
+	hc.Set("bar.com", "2.2.2.2")
+	hc.cache["baz.com"] = hostCacheEntry{ip: "3.3.3.3", expiry: time.Now().Add(-time.Second)}
+	hc.Sweep()
+	if _, ok := hc.cache["baz.com"]; ok {
+		t.Fatal("expected entry to be swept")
+	} else if _, ok := hc.cache["bar.com"]; !ok {
+		t.Fatal("expected entry to remain")
+	}
+
+	// assert the stats
+	stats := hc.Stats()
+	if stats.Entries != 1 || stats.Hits != 1 || stats.Misses != 1 || stats.Evictions != 2 || stats.TTL != api.DurationMS(time.Hour) {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	// assert entries never expire without a TTL
+	hc = newHostCache(0)
+	hc.Set("foo.com", "1.1.1.1")
+	if hc.cache["foo.com"].expiry != (time.Time{}) {
+		t.Fatal("expected no expiry")
+	}
+}
+
+func TestFallbackDialerCachesIP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	_, port, _ := net.SplitHostPort(l.Addr().String())
+	d := NewFallbackDialer(&mockDialerBus{}, net.Dialer{}, BandwidthConfig{}, DefaultHostCacheTTL, zap.NewNop())
+	conn, err := d.Dial(context.Background(), types.PublicKey{1}, net.JoinHostPort("localhost", port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	// assert the resolved IP is cached without the port
+	if ip, ok := d.cache.Get("localhost"); !ok {
+		t.Fatal("expected IP to be cached")
+	} else if net.ParseIP(ip) == nil {
+		t.Fatalf("expected cached value to be an IP, got %v", ip)
+	}
+}
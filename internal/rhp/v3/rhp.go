@@ -7,6 +7,7 @@ import (
 	"io"
 	"math"
 	"net"
+	"time"
 
 	rhpv2 "go.sia.tech/core/rhp/v2"
 	rhpv3 "go.sia.tech/core/rhp/v3"
@@ -60,6 +61,10 @@ var (
 	// sector.
 	ErrSectorNotFound = errors.New("sector not found")
 
+	// ErrInvalidProof is returned when the data returned by a host doesn't
+	// match the Merkle root of the requested sector.
+	ErrInvalidProof = errors.New("proof verification failed")
+
 	// errTransport is used to wrap rpc errors caused by the transport.
 	errTransport = errors.New("transport error")
 
@@ -98,6 +103,7 @@ func IsClosedStream(err error) bool {
 	return utils.IsErr(err, mux.ErrClosedStream) || utils.IsErr(err, net.ErrClosed)
 }
 func IsInsufficientFunds(err error) bool  { return utils.IsErr(err, errInsufficientFunds) }
+func IsInvalidProof(err error) bool       { return utils.IsErr(err, ErrInvalidProof) }
 func IsPriceTableExpired(err error) bool  { return utils.IsErr(err, errPriceTableExpired) }
 func IsPriceTableNotFound(err error) bool { return utils.IsErr(err, errPriceTableNotFound) }
 func IsSectorNotFound(err error) bool {
@@ -190,6 +196,15 @@ func (c *Client) PriceTableUnpaid(ctx context.Context, hk types.PublicKey, siamu
 	return
 }
 
+// LatencyProbe returns the round-trip time of a minimal RPC with the host.
+func (c *Client) LatencyProbe(ctx context.Context, hk types.PublicKey, siamuxAddr string) (latency time.Duration, err error) {
+	err = c.tpool.withTransport(ctx, hk, siamuxAddr, func(ctx context.Context, t *transportV3) error {
+		latency, err = RPCLatencyProbe(ctx, t)
+		return err
+	})
+	return
+}
+
 func (c *Client) ReadSector(ctx context.Context, offset, length uint64, root types.Hash256, w io.Writer, hk types.PublicKey, siamuxAddr string, accID rhpv3.Account, accKey types.PrivateKey, pt rhpv3.HostPriceTable) (types.Currency, error) {
 	var amount types.Currency
 	err := c.tpool.withTransport(ctx, hk, siamuxAddr, func(ctx context.Context, t *transportV3) error {
@@ -211,6 +226,31 @@ func (c *Client) ReadSector(ctx context.Context, offset, length uint64, root typ
 	return amount, err
 }
 
+// HasSector checks whether the host has the sector with the given root without
+// downloading it, it's paid for using the given ephemeral account.
+func (c *Client) HasSector(ctx context.Context, root types.Hash256, hk types.PublicKey, siamuxAddr string, accID rhpv3.Account, accKey types.PrivateKey, pt rhpv3.HostPriceTable) (bool, types.Currency, error) {
+	var amount types.Currency
+	var hasSector bool
+	err := c.tpool.withTransport(ctx, hk, siamuxAddr, func(ctx context.Context, t *transportV3) error {
+		cost, err := hasSectorCost(pt)
+		if err != nil {
+			return err
+		}
+
+		amount = cost // pessimistic cost estimate in case rpc fails
+		payment := rhpv3.PayByEphemeralAccount(accID, cost, pt.HostBlockHeight+defaultWithdrawalExpiryBlocks, accKey)
+		var refund types.Currency
+		hasSector, cost, refund, err = rpcHasSector(ctx, t, pt, &payment, root)
+		if err != nil {
+			return err
+		}
+
+		amount = cost.Sub(refund)
+		return nil
+	})
+	return hasSector, amount, err
+}
+
 func (c *Client) Revision(ctx context.Context, fcid types.FileContractID, hk types.PublicKey, siamuxAddr string) (rev types.FileContractRevision, err error) {
 	return rev, c.tpool.withTransport(ctx, hk, siamuxAddr, func(ctx context.Context, t *transportV3) error {
 		rev, err = rpcLatestRevision(ctx, t, fcid)
@@ -280,6 +320,29 @@ func readSectorCost(pt rhpv3.HostPriceTable, length uint64) (types.Currency, err
 	return cost.Div64(10), nil
 }
 
+// hasSectorCost returns an overestimate for the cost of checking whether a
+// host has a sector
+func hasSectorCost(pt rhpv3.HostPriceTable) (types.Currency, error) {
+	rc := pt.BaseCost()
+	rc = rc.Add(pt.HasSectorCost())
+	rc = padBandwidth(pt, rc)
+	cost, _ := rc.Total()
+
+	// overestimate the cost by 10%
+	cost, overflow := cost.Mul64WithOverflow(11)
+	if overflow {
+		return types.ZeroCurrency, errors.New("overflow occurred while adding leeway to has sector cost")
+	}
+	return cost.Div64(10), nil
+}
+
+// UploadSectorCost returns an overestimate for the cost of uploading a sector
+// to a host and the collateral the host is expected to risk
+func UploadSectorCost(pt rhpv3.HostPriceTable, windowEnd uint64) (cost, collateral types.Currency, err error) {
+	cost, collateral, _, err = uploadSectorCost(pt, windowEnd)
+	return
+}
+
 // uploadSectorCost returns an overestimate for the cost of uploading a sector
 // to a host
 func uploadSectorCost(pt rhpv3.HostPriceTable, windowEnd uint64) (cost, collateral, storage types.Currency, _ error) {
@@ -189,19 +189,75 @@ func rpcReadSector(ctx context.Context, t *transportV3, w io.Writer, pt rhpv3.Ho
 	cost = resp.TotalCost
 
 	// verify proof
-	proofStart := uint64(offset) / rhpv2.LeafSize
-	proofEnd := uint64(offset+length) / rhpv2.LeafSize
-	verifier := rhpv2.NewRangeProofVerifier(proofStart, proofEnd)
-	_, err = verifier.ReadFrom(bytes.NewReader(resp.Output))
+	if err = VerifyRangeProof(resp.Output, resp.Proof, offset, length, merkleRoot); err != nil {
+		return
+	}
+
+	_, err = w.Write(resp.Output)
+	return
+}
+
+// VerifyRangeProof verifies that data is the range [offset, offset+length) of
+// the sector with the given root, ErrInvalidProof is returned if it isn't.
+func VerifyRangeProof(data []byte, proof []types.Hash256, offset, length uint64, root types.Hash256) error {
+	verifier := rhpv2.NewRangeProofVerifier(offset/rhpv2.LeafSize, (offset+length)/rhpv2.LeafSize)
+	if _, err := verifier.ReadFrom(bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("failed to read proof: %w", err)
+	} else if !verifier.Verify(proof, root) {
+		return ErrInvalidProof
+	}
+	return nil
+}
+
+// rpcHasSector calls the ExecuteProgram RPC with a HasSector instruction.
+func rpcHasSector(ctx context.Context, t *transportV3, pt rhpv3.HostPriceTable, payment rhpv3.PaymentMethod, merkleRoot types.Hash256) (hasSector bool, cost, refund types.Currency, err error) {
+	defer utils.WrapErr(ctx, "HasSector", &err)
+	s, err := t.DialStream(ctx)
 	if err != nil {
-		err = fmt.Errorf("failed to read proof: %w", err)
+		return false, types.ZeroCurrency, types.ZeroCurrency, err
+	}
+	defer s.Close()
+
+	var buf bytes.Buffer
+	e := types.NewEncoder(&buf)
+	merkleRoot.EncodeTo(e)
+	e.Flush()
+
+	req := rhpv3.RPCExecuteProgramRequest{
+		FileContractID: types.FileContractID{},
+		Program: []rhpv3.Instruction{&rhpv3.InstrHasSector{
+			MerkleRootOffset: 0,
+		}},
+		ProgramData: buf.Bytes(),
+	}
+
+	var cancellationToken types.Specifier
+	var resp rhpv3.RPCExecuteProgramResponse
+	if err = s.WriteRequest(rhpv3.RPCExecuteProgramID, &pt.UID); err != nil {
+		return
+	} else if err = processPayment(s, payment); err != nil {
+		return
+	} else if err = s.WriteResponse(&req); err != nil {
+		return
+	} else if err = s.ReadResponse(&cancellationToken, 16); err != nil {
 		return
-	} else if !verifier.Verify(resp.Proof, merkleRoot) {
-		err = errors.New("proof verification failed")
+	} else if err = s.ReadResponse(&resp, defaultRPCResponseMaxSize); err != nil {
 		return
 	}
 
-	_, err = w.Write(resp.Output)
+	// check response error
+	if err = resp.Error; err != nil {
+		refund = resp.FailureRefund
+		return
+	}
+	cost = resp.TotalCost
+
+	// the output is a single byte indicating whether the host has the sector
+	if len(resp.Output) != 1 {
+		err = fmt.Errorf("unexpected output length %d", len(resp.Output))
+		return
+	}
+	hasSector = resp.Output[0] == 1
 	return
 }
 
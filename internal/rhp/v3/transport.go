@@ -8,6 +8,7 @@ import (
 
 	rhpv3 "go.sia.tech/core/rhp/v3"
 	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/internal/utils"
 )
 
 type transportPoolV3 struct {
@@ -170,3 +171,26 @@ func (s *streamV3) Close() error {
 	s.cancel()
 	return s.Stream.Close()
 }
+
+// RPCLatencyProbe measures the round-trip time of a minimal RPC on the given
+// transport by requesting the host's price table without paying for it. The
+// response is discarded and the time it takes to dial the transport is not
+// included in the measurement.
+func RPCLatencyProbe(ctx context.Context, t *transportV3) (_ time.Duration, err error) {
+	defer utils.WrapErr(ctx, "LatencyProbe", &err)
+
+	s, err := t.DialStream(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer s.Close()
+
+	start := time.Now()
+	var ptr rhpv3.RPCUpdatePriceTableResponse
+	if err := s.WriteRequest(rhpv3.RPCUpdatePriceTableID, nil); err != nil {
+		return 0, fmt.Errorf("couldn't send RPCUpdatePriceTableID: %w", err)
+	} else if err := s.ReadResponse(&ptr, maxPriceTableSize); err != nil {
+		return 0, fmt.Errorf("couldn't read RPCUpdatePriceTableResponse: %w", err)
+	}
+	return time.Since(start), nil
+}
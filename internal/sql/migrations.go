@@ -543,6 +543,144 @@ var (
 					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00034_v2", log)
 				},
 			},
+			{
+				ID: "00035_webhook_dead_letters",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00035_webhook_dead_letters", log)
+				},
+			},
+			{
+				ID: "00036_host_checks_formation_backoff",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00036_host_checks_formation_backoff", log)
+				},
+			},
+			{
+				ID: "00037_contract_proof_confirmations",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00037_contract_proof_confirmations", log)
+				},
+			},
+			{
+				ID: "00038_autopilot_config_auto_size",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00038_autopilot_config_auto_size", log)
+				},
+			},
+			{
+				ID: "00039_host_siamux_reachable",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00039_host_siamux_reachable", log)
+				},
+			},
+			{
+				ID: "00040_autopilot_config_price_renegotiation",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00040_autopilot_config_price_renegotiation", log)
+				},
+			},
+			{
+				ID: "00041_contract_sectors_contract_id_index",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00041_contract_sectors_contract_id_index", log)
+				},
+			},
+			{
+				ID: "00042_recovered_objects",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00042_recovered_objects", log)
+				},
+			},
+			{
+				ID: "00043_contract_fees",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00043_contract_fees", log)
+				},
+			},
+			{
+				ID: "00044_host_price_history",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00044_host_price_history", log)
+				},
+			},
+			{
+				ID: "00045_contract_storage_proofs",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00045_contract_storage_proofs", log)
+				},
+			},
+			{
+				ID: "00046_object_last_accessed",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00046_object_last_accessed", log)
+				},
+			},
+			{
+				ID: "00047_object_tags",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00047_object_tags", log)
+				},
+			},
+			{
+				ID: "00048_object_versions",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00048_object_versions", log)
+				},
+			},
+			{
+				ID: "00049_host_checks_geo_score",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00049_host_checks_geo_score", log)
+				},
+			},
+			{
+				ID: "00050_slabs_last_failure",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00050_slabs_last_failure", log)
+				},
+			},
+			{
+				ID: "00051_host_scans",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00051_host_scans", log)
+				},
+			},
+			{
+				ID: "00052_multipart_upload_expiry",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00052_multipart_upload_expiry", log)
+				},
+			},
+			{
+				ID: "00053_slab_checksums",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00053_slab_checksums", log)
+				},
+			},
+			{
+				ID: "00054_host_blocklist_cidrs",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00054_host_blocklist_cidrs", log)
+				},
+			},
+			{
+				ID: "00055_host_scans_rpc_latency",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00055_host_scans_rpc_latency", log)
+				},
+			},
+			{
+				ID: "00056_contract_prices",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00056_contract_prices", log)
+				},
+			},
+			{
+				ID: "00057_host_benchmarks",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00057_host_benchmarks", log)
+				},
+			},
 		}
 	}
 	MetricsMigrations = func(ctx context.Context, migrationsFs embed.FS, log *zap.SugaredLogger) []Migration {
@@ -582,6 +720,12 @@ var (
 					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00005_remove_contract_sets", log)
 				},
 			},
+			{
+				ID: "00006_slab_download_metrics",
+				Migrate: func(tx Tx) error {
+					return performMigration(ctx, tx, migrationsFs, dbIdentifier, "00006_slab_download_metrics", log)
+				},
+			},
 		}
 	}
 )
@@ -69,4 +69,12 @@ func TestAutopilot(t *testing.T) {
 	if ap.Enabled {
 		t.Fatal("autopilot should be disabled")
 	}
+
+	// assert we can enable price renegotiation
+	tt.OK(b.UpdateAutopilotConfig(context.Background(), client.WithPriceRenegotiationEnabled(true)))
+	ap, err = b.AutopilotConfig(context.Background())
+	tt.OK(err)
+	if !ap.PriceRenegotiationEnabled {
+		t.Fatal("price renegotiation should be enabled")
+	}
 }
@@ -31,6 +31,7 @@ import (
 	"go.sia.tech/renterd/bus"
 	"go.sia.tech/renterd/bus/client"
 	"go.sia.tech/renterd/config"
+	ibus "go.sia.tech/renterd/internal/bus"
 	"go.sia.tech/renterd/internal/test"
 	"go.sia.tech/renterd/internal/utils"
 	"go.sia.tech/renterd/stores"
@@ -539,7 +540,7 @@ func newTestBus(ctx context.Context, cm *chain.Manager, genesisBlock types.Block
 	}
 
 	// create webhooks manager
-	wh, err := webhooks.NewManager(sqlStore, logger)
+	wh, err := webhooks.NewManager(sqlStore, cfg.WebhookDeadLetterTTL, logger)
 	if err != nil {
 		return nil, nil, nil, nil, err
 	}
@@ -547,6 +548,9 @@ func newTestBus(ctx context.Context, cm *chain.Manager, genesisBlock types.Block
 	// hookup webhooks <-> alerts
 	alertsMgr.RegisterWebhookBroadcaster(wh)
 
+	// hookup webhooks <-> store events
+	sqlStore.RegisterEventSink(stores.NewWebhookEventSink(wh))
+
 	// create consensus directory
 	consensusDir := filepath.Join(dir, "consensus")
 	if err := os.MkdirAll(consensusDir, 0700); err != nil {
@@ -603,7 +607,7 @@ func newTestBus(ctx context.Context, cm *chain.Manager, genesisBlock types.Block
 	masterKey := blake2b.Sum256(append([]byte("worker"), pk...))
 
 	// create bus
-	b, err := bus.New(ctx, cfg, masterKey, alertsMgr, wh, cm, s, w, sqlStore, "", logger)
+	b, err := bus.New(ctx, cfg, masterKey, alertsMgr, wh, cm, ibus.NewPingingSyncer(s), ibus.NewSweepingWallet(w, cm, s), sqlStore, "", logger)
 	if err != nil {
 		return nil, nil, nil, nil, err
 	}
@@ -986,6 +990,13 @@ func testBusCfg() config.Bus {
 		GatewayAddr:                   "127.0.0.1:0",
 		UsedUTXOExpiry:                time.Minute,
 		SlabBufferCompletionThreshold: 0,
+		ForkDetectionDepth:            6,
+		MaxChainLag:                   10,
+		MaxConcurrentFormations:       1,
+		MaxSymlinkDepth:               8,
+		HealthRefreshMinInterval:      100 * time.Millisecond,
+		HealthRefreshMaxInterval:      time.Second,
+		HealthRefreshCutoff:           0.99,
 	}
 }
 
@@ -1025,6 +1036,7 @@ func testApCfg() config.Autopilot {
 
 		MigratorAccountsRefillInterval:   10 * time.Millisecond,
 		MigratorHealthCutoff:             0.99,
+		MigratorMaxAttempts:              1,
 		MigratorNumThreads:               1,
 		MigratorDownloadMaxOverdrive:     5,
 		MigratorDownloadOverdriveTimeout: 500 * time.Millisecond,
@@ -640,6 +640,137 @@ func TestObjectsRename(t *testing.T) {
 	}
 }
 
+// TestObjectsSymlink is an integration test that verifies symlinks resolve to
+// their target and that symlink cycles are detected.
+func TestObjectsSymlink(t *testing.T) {
+	// create a test cluster
+	cluster := newTestCluster(t, testClusterOptions{
+		hosts: test.RedundancySettings.TotalShards,
+	})
+	defer cluster.Shutdown()
+
+	b := cluster.Bus
+	w := cluster.Worker
+	tt := cluster.tt
+
+	// upload an object and create a chain of symlinks pointing to it
+	data := frand.Bytes(128)
+	tt.OKAll(w.UploadObject(context.Background(), bytes.NewReader(data), testBucket, "/target", api.UploadObjectOptions{}))
+	tt.OK(b.CreateSymlink(context.Background(), testBucket, "/target", "/link1"))
+	tt.OK(b.CreateSymlink(context.Background(), testBucket, "/link1", "/link2"))
+
+	// assert downloading the symlink returns the target's content
+	var buf bytes.Buffer
+	tt.OK(w.DownloadObject(context.Background(), &buf, testBucket, "/link2", api.DownloadObjectOptions{}))
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Fatal("unexpected data")
+	}
+
+	// assert the symlink itself is returned if we don't follow symlinks
+	o, err := b.Object(context.Background(), testBucket, "/link2", api.GetObjectOptions{IgnoreSymlinks: true})
+	tt.OK(err)
+	if target, ok := o.SymlinkTarget(); !ok || target != "/link1" {
+		t.Fatalf("unexpected symlink target %q", target)
+	} else if o.Size != 0 {
+		t.Fatalf("unexpected size %v", o.Size)
+	}
+
+	// assert resolutions are tracked
+	state, err := b.State()
+	tt.OK(err)
+	if state.SymlinksResolved < 2 {
+		t.Fatalf("expected at least 2 resolved symlinks, got %v", state.SymlinksResolved)
+	}
+
+	// assert the symlink metadata key can't be set by the user
+	_, err = w.UploadObject(context.Background(), bytes.NewReader(nil), testBucket, "/fake", api.UploadObjectOptions{
+		Metadata: api.ObjectUserMetadata{api.ObjectMetadataSymlink: "/target"},
+	})
+	if !utils.IsErr(err, api.ErrReservedMetadataKey) {
+		t.Fatalf("expected ErrReservedMetadataKey, got %v", err)
+	}
+
+	// assert cycles are rejected on creation
+	tt.OK(b.CreateSymlink(context.Background(), testBucket, "/cycle2", "/cycle1"))
+	err = b.CreateSymlink(context.Background(), testBucket, "/cycle1", "/cycle2")
+	if !utils.IsErr(err, api.ErrSymlinkCycle) {
+		t.Fatalf("expected ErrSymlinkCycle, got %v", err)
+	}
+
+	// assert cycles created by renaming a symlink are detected on resolution
+	tt.OK(b.CreateSymlink(context.Background(), testBucket, "/cycle1", "/cycle3"))
+	tt.OK(b.RenameObject(context.Background(), testBucket, "/cycle3", "/cycle2", true))
+	_, err = b.Object(context.Background(), testBucket, "/cycle1", api.GetObjectOptions{})
+	if !utils.IsErr(err, api.ErrMaxSymlinkDepthExceeded) {
+		t.Fatalf("expected ErrMaxSymlinkDepthExceeded, got %v", err)
+	}
+
+	// assert dangling symlinks aren't found
+	tt.OK(b.CreateSymlink(context.Background(), testBucket, "/missing", "/dangling"))
+	_, err = b.Object(context.Background(), testBucket, "/dangling", api.GetObjectOptions{})
+	if !utils.IsErr(err, api.ErrObjectNotFound) {
+		t.Fatalf("expected ErrObjectNotFound, got %v", err)
+	}
+}
+
+// TestObjectsPathPolicy is an integration test that verifies the path policy
+// of a bucket is enforced.
+func TestObjectsPathPolicy(t *testing.T) {
+	// create a test cluster
+	cluster := newTestCluster(t, testClusterOptions{
+		hosts: test.RedundancySettings.TotalShards,
+	})
+	defer cluster.Shutdown()
+
+	b := cluster.Bus
+	w := cluster.Worker
+	tt := cluster.tt
+
+	// assert invalid policies are rejected
+	err := b.UpdateBucketPolicy(context.Background(), testBucket, api.BucketPolicy{
+		PathPolicy: api.PathPolicy{DeniedPatterns: []string{"("}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "invalid denied pattern") {
+		t.Fatal("expected invalid pattern error, got", err)
+	}
+
+	// update the policy
+	tt.OK(b.UpdateBucketPolicy(context.Background(), testBucket, api.BucketPolicy{
+		PathPolicy: api.PathPolicy{
+			AllowedPrefixes: []string{"/data/"},
+			DeniedPatterns:  []string{`\.\.`},
+		},
+	}))
+
+	// upload an object that complies with the policy
+	tt.OKAll(w.UploadObject(context.Background(), bytes.NewReader(nil), testBucket, "/data/foo", api.UploadObjectOptions{}))
+
+	// assert uploads, copies and symlinks that violate the policy fail
+	_, err = w.UploadObject(context.Background(), bytes.NewReader(nil), testBucket, "/other/foo", api.UploadObjectOptions{})
+	if !utils.IsErr(err, api.ErrPathPolicyViolation) {
+		t.Fatal("expected ErrPathPolicyViolation, got", err)
+	}
+	_, err = b.CopyObject(context.Background(), testBucket, testBucket, "/data/foo", "/data/../foo", api.CopyObjectOptions{})
+	if !utils.IsErr(err, api.ErrPathPolicyViolation) {
+		t.Fatal("expected ErrPathPolicyViolation, got", err)
+	}
+	err = b.CreateSymlink(context.Background(), testBucket, "/data/foo", "/other/link")
+	if !utils.IsErr(err, api.ErrPathPolicyViolation) {
+		t.Fatal("expected ErrPathPolicyViolation, got", err)
+	}
+	err = b.RenameObject(context.Background(), testBucket, "/data/foo", "/other/foo", false)
+	if !utils.IsErr(err, api.ErrPathPolicyViolation) {
+		t.Fatal("expected ErrPathPolicyViolation, got", err)
+	}
+	err = b.RenameObjects(context.Background(), testBucket, "/data/", "/data/../", false)
+	if !utils.IsErr(err, api.ErrPathPolicyViolation) {
+		t.Fatal("expected ErrPathPolicyViolation, got", err)
+	}
+
+	// assert copies that comply with the policy succeed
+	tt.OKAll(b.CopyObject(context.Background(), testBucket, testBucket, "/data/foo", "/data/bar", api.CopyObjectOptions{}))
+}
+
 // TestUploadDownloadEmpty is an integration test that verifies empty objects
 // can be uploaded and download correctly.
 func TestUploadDownloadEmpty(t *testing.T) {
@@ -717,6 +848,23 @@ func TestUploadDownloadBasic(t *testing.T) {
 		}
 	}
 
+	// contracts are only included if requested
+	if resp.ContractIDs != nil {
+		t.Fatal("expected no contracts", resp.ContractIDs)
+	}
+	for _, onlyMetadata := range []bool{false, true} {
+		resp, err := cluster.Bus.Object(context.Background(), testBucket, path, api.GetObjectOptions{
+			IncludeContracts: true,
+			OnlyMetadata:     onlyMetadata,
+		})
+		tt.OK(err)
+		if len(resp.ContractIDs) != test.RedundancySettings.TotalShards {
+			t.Fatalf("expected %d contracts, got %d", test.RedundancySettings.TotalShards, len(resp.ContractIDs))
+		} else if onlyMetadata && resp.Object != nil {
+			t.Fatal("expected only metadata")
+		}
+	}
+
 	// download data
 	var buffer bytes.Buffer
 	tt.OK(w.DownloadObject(context.Background(), &buffer, testBucket, path, api.DownloadObjectOptions{}))
@@ -1191,6 +1339,14 @@ func TestParallelUpload(t *testing.T) {
 		t.Fatal("wrong number of objects", len(resp.Objects))
 	}
 
+	// Upload a temporary object and delete it using a glob pattern.
+	tt.OKAll(w.UploadObject(context.Background(), bytes.NewReader([]byte("data")), testBucket, "/foo.tmp", api.UploadObjectOptions{}))
+	if n, err := cluster.Bus.RemoveObjectsGlob(context.Background(), testBucket, "*.tmp"); err != nil {
+		t.Fatal(err)
+	} else if n != 1 {
+		t.Fatal("unexpected number of removed objects", n)
+	}
+
 	// Delete all objects under /dir/.
 	if err := cluster.Bus.RemoveObjects(context.Background(), testBucket, "/dir/"); err != nil {
 		t.Fatal(err)
@@ -1408,11 +1564,12 @@ func TestContractArchival(t *testing.T) {
 	// remove the host
 	cluster.RemoveHost(cluster.hosts[0])
 
-	// mine until the contract is archived
+	// mine until the contract is archived, the resolution needs to be
+	// finalized before the contract is archived
 	endHeight := contracts[0].WindowEnd
 	cs, err := cluster.Bus.ConsensusState(context.Background())
 	tt.OK(err)
-	cluster.MineBlocks(endHeight - cs.BlockHeight + 1)
+	cluster.MineBlocks(endHeight - cs.BlockHeight + 1 + api.ContractProofConfirmationDepth)
 
 	// check that we have 0 contracts
 	tt.Retry(100, 100*time.Millisecond, func() error {
@@ -1536,6 +1693,32 @@ func TestWalletEvents(t *testing.T) {
 	}
 }
 
+func TestWalletEventStream(t *testing.T) {
+	cluster := newTestCluster(t, clusterOptsDefault)
+	defer cluster.Shutdown()
+	tt := cluster.tt
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := cluster.Bus.WalletEventStream(ctx)
+	tt.OK(err)
+
+	// mine a block, the payout should be streamed
+	cluster.MineBlocks(1)
+	select {
+	case event := <-events:
+		if event.Type != wallet.EventTypeMinerPayout {
+			t.Fatal("unexpected event type", event.Type)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("no event received")
+	}
+
+	// assert the channel is closed once the context is cancelled
+	cancel()
+	for range events {
+	}
+}
+
 func TestUploadPacking(t *testing.T) {
 	// sanity check the default settings
 	if test.AutopilotConfig.Contracts.Amount < uint64(test.RedundancySettings.MinShards) {
@@ -1769,6 +1952,23 @@ func TestWallet(t *testing.T) {
 		t.Fatal("wallet address should be set")
 	}
 
+	// Estimate the fee of a transaction, it should match the recommended fee
+	// times the transaction's weight.
+	txn := types.Transaction{
+		SiacoinOutputs: []types.SiacoinOutput{{Address: types.Address{1, 2, 3}, Value: types.Siacoins(1)}},
+	}
+	var buf bytes.Buffer
+	enc := types.NewEncoder(&buf)
+	txn.EncodeTo(enc)
+	tt.OK(enc.Flush())
+	recommendedFee, err := b.RecommendedFee(context.Background())
+	tt.OK(err)
+	estimatedFee, err := b.EstimateFee(context.Background(), txn)
+	tt.OK(err)
+	if expected := recommendedFee.Mul64(uint64(buf.Len())); !estimatedFee.Equals(expected) {
+		t.Fatalf("unexpected fee estimate %v != %v", estimatedFee, expected)
+	}
+
 	// Send 1 SC to an address outside our wallet.
 	sendAmt := types.HastingsPerSiacoin
 	_, err = b.SendSiacoins(context.Background(), types.Address{1, 2, 3}, sendAmt, false)
@@ -2036,8 +2236,10 @@ func TestAlerts(t *testing.T) {
 	for severity := alerts.SeverityInfo; severity <= alerts.SeverityCritical; severity++ {
 		ar, err = b.Alerts(context.Background(), alerts.AlertsOpts{Severity: severity})
 		tt.OK(err)
-		if ar.Total() != 32 {
-			t.Fatal("expected 32 alerts", ar.Total())
+		if total := ar.Totals.Info + ar.Totals.Warning + ar.Totals.Error + ar.Totals.Critical; total != 32 {
+			t.Fatal("expected 32 alerts", total)
+		} else if ar.Total != len(ar.Alerts) {
+			t.Fatalf("expected total to match the number of alerts, %v != %v", ar.Total, len(ar.Alerts))
 		} else if ar.Totals.Info != 3 {
 			t.Fatal("expected 3 info alerts", ar.Totals.Info)
 		} else if ar.Totals.Warning != 6 {
@@ -2736,10 +2938,11 @@ func TestConsensusResync(t *testing.T) {
 		MaxDownloadPrice: types.NewCurrency64(1),
 	})
 
-	// let them expire - we don't check for errors when mining since a few blocks
+	// let them expire and mine past the proof confirmation depth for them to
+	// get archived - we don't check for errors when mining since a few blocks
 	// might be invalid due to a race when broadcasting revisions while mining
 	// blocks rapidly
-	for i := 0; i < int(2*test.AutopilotConfig.Contracts.Period); i++ {
+	for i := 0; i < int(2*test.AutopilotConfig.Contracts.Period+api.ContractProofConfirmationDepth); i++ {
 		b, ok := coreutils.MineBlock(cluster.cm, types.Address{}, 5*time.Second)
 		if !ok {
 			continue
@@ -3171,3 +3374,172 @@ func TestDefaultSettingsUploadDownload(t *testing.T) {
 		t.Fatal("expected access denied error")
 	}
 }
+
+func TestHostBenchmark(t *testing.T) {
+	cluster := newTestCluster(t, testClusterOptions{
+		hosts: 1,
+	})
+	defer cluster.Shutdown()
+
+	b := cluster.Bus
+	w := cluster.Worker
+	tt := cluster.tt
+
+	// wait for the contract to be formed
+	var hk types.PublicKey
+	tt.Retry(100, 100*time.Millisecond, func() error {
+		contracts, err := b.Contracts(context.Background(), api.ContractsOpts{FilterMode: api.ContractFilterModeGood})
+		tt.OK(err)
+		if len(contracts) != 1 {
+			return fmt.Errorf("expected 1 contract, got %v", len(contracts))
+		}
+		hk = contracts[0].HostKey
+		return nil
+	})
+
+	h, err := b.Host(context.Background(), hk)
+	tt.OK(err)
+
+	// benchmark the host
+	res, err := w.BenchmarkHost(context.Background(), hk)
+	tt.OK(err)
+	if res.UploadMBps <= 0 || res.DownloadMBps <= 0 {
+		t.Fatal("unexpected throughput", res.UploadMBps, res.DownloadMBps)
+	} else if res.TTFB <= 0 {
+		t.Fatal("unexpected ttfb", res.TTFB)
+	}
+
+	// assert the benchmark was recorded
+	benchmarks, err := b.HostBenchmarks(context.Background(), hk, -1)
+	tt.OK(err)
+	if len(benchmarks) != 1 {
+		t.Fatalf("expected 1 benchmark, got %v", len(benchmarks))
+	} else if bm := benchmarks[0]; bm.UploadMBps != res.UploadMBps || bm.DownloadMBps != res.DownloadMBps || bm.TTFB != res.TTFB {
+		t.Fatalf("unexpected benchmark %+v", bm)
+	}
+
+	// assert the benchmark wasn't recorded as a scan
+	updated, err := b.Host(context.Background(), hk)
+	tt.OK(err)
+	if updated.Interactions.TotalScans != h.Interactions.TotalScans {
+		t.Fatalf("expected %v scans, got %v", h.Interactions.TotalScans, updated.Interactions.TotalScans)
+	}
+
+	// benchmarking an unknown host should fail
+	_, err = w.BenchmarkHost(context.Background(), types.GeneratePrivateKey().PublicKey())
+	tt.AssertIs(err, api.ErrHostNotFound)
+}
+
+func TestWalletSweepDust(t *testing.T) {
+	cluster := newTestCluster(t, clusterOptsDefault)
+	defer cluster.Shutdown()
+	b := cluster.Bus
+	tt := cluster.tt
+
+	// assert the threshold is validated
+	_, err := b.WalletSweepDust(context.Background(), types.ZeroCurrency)
+	tt.AssertContains(err, "'threshold' has to be greater than zero")
+
+	wr, err := b.Wallet(context.Background())
+	tt.OK(err)
+
+	// create some dust by sending small amounts to ourselves
+	for i := 0; i < 3; i++ {
+		tt.OKAll(b.SendSiacoins(context.Background(), wr.Address, types.Siacoins(1), false))
+	}
+	cluster.MineBlocks(1)
+	cluster.sync()
+
+	wr, err = b.Wallet(context.Background())
+	tt.OK(err)
+
+	// sweep the dust
+	ids, err := b.WalletSweepDust(context.Background(), types.Siacoins(2))
+	tt.OK(err)
+	if len(ids) != 1 {
+		t.Fatalf("expected 1 transaction, got %v", len(ids))
+	}
+	cluster.MineBlocks(1)
+	cluster.sync()
+
+	// assert we only paid the fee
+	tt.Retry(100, 100*time.Millisecond, func() error {
+		after, err := b.Wallet(context.Background())
+		tt.OK(err)
+		if !after.Unconfirmed.IsZero() {
+			return errors.New("wallet should not have unconfirmed balance")
+		} else if after.Confirmed.Add(types.Siacoins(1)).Cmp(wr.Confirmed) < 0 {
+			return fmt.Errorf("unexpected balance %v, expected close to %v", after.Confirmed, wr.Confirmed)
+		}
+		return nil
+	})
+
+	// assert there's no dust left
+	ids, err = b.WalletSweepDust(context.Background(), types.Siacoins(2))
+	tt.OK(err)
+	if len(ids) != 0 {
+		t.Fatalf("expected no transactions, got %v", len(ids))
+	}
+}
+
+func TestSyncerPeersLatency(t *testing.T) {
+	cluster := newTestCluster(t, testClusterOptions{
+		hosts: 1,
+	})
+	defer cluster.Shutdown()
+	cluster.WaitForPeers()
+
+	// assert every peer was pinged successfully
+	latencies, err := cluster.Bus.SyncerPeersLatency(context.Background())
+	cluster.tt.OK(err)
+	if len(latencies) == 0 {
+		t.Fatal("expected at least one peer")
+	}
+	for _, pl := range latencies {
+		if pl.Error != "" {
+			t.Fatalf("failed to ping peer %v: %v", pl.Addr, pl.Error)
+		}
+	}
+}
+
+func TestStoreVacuum(t *testing.T) {
+	cluster := newTestCluster(t, testClusterOptions{})
+	defer cluster.Shutdown()
+	b := cluster.Bus
+	tt := cluster.tt
+
+	// vacuum is only supported by SQLite, MySQL rejects it right away
+	if mysqlCfg := config.MySQLConfigFromEnv(); mysqlCfg.URI != "" {
+		if _, err := b.VacuumStore(context.Background()); !utils.IsErr(err, api.ErrVacuumNotSupported) {
+			t.Fatal("expected ErrVacuumNotSupported", err)
+		}
+		return
+	}
+
+	// start a vacuum
+	vs, err := b.VacuumStore(context.Background())
+	tt.OK(err)
+	if vs.ID == "" {
+		t.Fatal("expected vacuum id")
+	}
+
+	// poll until it's done
+	tt.Retry(100, 100*time.Millisecond, func() error {
+		vs, err = b.VacuumStatus(context.Background(), vs.ID)
+		tt.OK(err)
+		if vs.Running {
+			return errors.New("vacuum still running")
+		}
+		return nil
+	})
+	if vs.Error != "" {
+		t.Fatal("vacuum failed", vs.Error)
+	} else if time.Time(vs.FinishedAt).IsZero() {
+		t.Fatal("expected finish time to be set")
+	}
+
+	// assert unknown ids are rejected
+	if _, err := b.VacuumStatus(context.Background(), "unknown"); !utils.IsErr(err, api.ErrVacuumNotFound) {
+		t.Fatal("expected ErrVacuumNotFound", err)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	rhpv2 "go.sia.tech/core/rhp/v2"
+	"go.sia.tech/core/types"
 	"go.sia.tech/renterd/api"
 	"go.sia.tech/renterd/bus/client"
 	"go.sia.tech/renterd/internal/test"
@@ -143,6 +144,46 @@ func TestGouging(t *testing.T) {
 	})
 }
 
+func TestGougingValidate(t *testing.T) {
+	// create a new test cluster
+	cluster := newTestCluster(t, testClusterOptions{hosts: 3})
+	defer cluster.Shutdown()
+
+	b := cluster.Bus
+	tt := cluster.tt
+
+	// assert all hosts pass the current gouging settings
+	gs, err := b.GougingSettings(context.Background())
+	tt.OK(err)
+	res, err := b.ValidateGougingSettings(context.Background(), gs)
+	tt.OK(err)
+	if len(res.PassingHosts) != 3 || len(res.FailingHosts) != 0 {
+		t.Fatalf("unexpected result, %d passing, %d failing", len(res.PassingHosts), len(res.FailingHosts))
+	}
+
+	// assert all hosts fail settings with a max storage price that is too low
+	updated := gs
+	updated.MaxStoragePrice = types.NewCurrency64(1)
+	res, err = b.ValidateGougingSettings(context.Background(), updated)
+	tt.OK(err)
+	if len(res.PassingHosts) != 0 || len(res.FailingHosts) != 3 {
+		t.Fatalf("unexpected result, %d passing, %d failing", len(res.PassingHosts), len(res.FailingHosts))
+	}
+
+	// assert the settings were not persisted
+	if current, err := b.GougingSettings(context.Background()); err != nil {
+		t.Fatal(err)
+	} else if current != gs {
+		t.Fatal("gouging settings were updated")
+	}
+
+	// assert invalid settings are rejected
+	updated.HostBlockHeightLeeway = 0
+	if _, err := b.ValidateGougingSettings(context.Background(), updated); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
 func TestHostMinVersion(t *testing.T) {
 	// create a new test cluster
 	n := int(test.AutopilotConfig.Contracts.Amount)
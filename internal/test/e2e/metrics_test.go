@@ -62,6 +62,15 @@ func TestMetrics(t *testing.T) {
 			return errors.New("no contract prune metrics")
 		}
 
+		// check slab download metrics
+		sdm, err := b.SlabDownloadMetrics(context.Background(), start, 10, time.Minute, api.SlabDownloadMetricsQueryOpts{})
+		tt.OK(err)
+		if len(sdm) == 0 {
+			return errors.New("no slab download metrics")
+		} else if !sdm[0].Success {
+			return errors.New("expected successful slab download")
+		}
+
 		// check wallet metrics
 		wm, err := b.WalletMetrics(context.Background(), start, 10, time.Minute, api.WalletMetricsQueryOpts{})
 		tt.OK(err)
@@ -71,6 +80,24 @@ func TestMetrics(t *testing.T) {
 		return nil
 	})
 
+	// assert the health report covers the active contracts
+	report, err := b.HealthReport(context.Background(), 24*time.Hour)
+	tt.OK(err)
+	if report.Contracts.Active == 0 {
+		t.Fatal("expected active contracts")
+	} else if report.Hosts.Used == 0 {
+		t.Fatal("expected used hosts")
+	} else if len(report.Hosts.TopHosts) == 0 || len(report.Hosts.TopHosts) > 5 {
+		t.Fatalf("unexpected number of top hosts, %v", len(report.Hosts.TopHosts))
+	}
+
+	// assert the report is cached
+	cached, err := b.HealthReport(context.Background(), 24*time.Hour)
+	tt.OK(err)
+	if !time.Time(cached.GeneratedAt).Equal(time.Time(report.GeneratedAt)) {
+		t.Fatal("expected cached report")
+	}
+
 	// assert pruning works
 	if err := cluster.Bus.PruneMetrics(context.Background(), api.MetricContract, time.Now()); err != nil {
 		t.Fatal(err)
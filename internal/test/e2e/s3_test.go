@@ -122,6 +122,12 @@ func TestS3Basic(t *testing.T) {
 		t.Fatal("expected correct ETag to be set")
 	}
 
+	// copy it conditionally, the copy fails if the source's ETag doesn't match
+	_, err = cluster.S3.CopyObject(bucket, bucket2, objPath, objPath+"conditional", putObjectOptions{copySourceIfMatch: `"wrong"`})
+	tt.AssertContains(err, "PreconditionFailed")
+	tt.AssertContains(err, "412")
+	tt.OKAll(cluster.S3.CopyObject(bucket, bucket2, objPath, objPath+"conditional", putObjectOptions{copySourceIfMatch: uploadInfo.etag}))
+
 	// get copied object
 	obj, err = cluster.S3.GetObject(bucket2, objPath, getObjectOptions{})
 	tt.OK(err)
@@ -277,6 +283,171 @@ func TestS3ObjectMetadata(t *testing.T) {
 	}, head.metadata)
 }
 
+func TestS3ObjectTagging(t *testing.T) {
+	cluster := newTestCluster(t, testClusterOptions{
+		hosts: test.RedundancySettings.TotalShards,
+	})
+	defer cluster.Shutdown()
+	tt := cluster.tt
+
+	// add object to the bucket
+	_, err := cluster.S3.PutObject(testBucket, t.Name(), bytes.NewReader([]byte(t.Name())), putObjectOptions{})
+	tt.OK(err)
+
+	// assert the object has no tags
+	tags, err := cluster.S3.GetObjectTagging(testBucket, t.Name())
+	tt.OK(err)
+	if len(tags) != 0 {
+		t.Fatal("unexpected tags", tags)
+	}
+
+	// tag the object
+	want := map[string]string{"project": "renterd", "cost-center": "42"}
+	tt.OK(cluster.S3.PutObjectTagging(testBucket, t.Name(), want))
+
+	// assert the tags are returned
+	tags, err = cluster.S3.GetObjectTagging(testBucket, t.Name())
+	tt.OK(err)
+	if !cmp.Equal(tags, want) {
+		t.Fatal("unexpected tags", cmp.Diff(tags, want))
+	}
+
+	// assert the tags are included when listing objects through the bus
+	resp, err := cluster.Bus.Objects(context.Background(), "", api.ListObjectOptions{Bucket: testBucket, IncludeTags: true})
+	tt.OK(err)
+	if len(resp.Objects) != 1 || !cmp.Equal(map[string]string(resp.Tags[resp.Objects[0].Key]), want) {
+		t.Fatal("unexpected tags", resp.Tags)
+	}
+
+	// assert too many tags are rejected
+	tooMany := make(map[string]string)
+	for i := 0; i <= api.MaxObjectTags; i++ {
+		tooMany[fmt.Sprint(i)] = "v"
+	}
+	if err := cluster.S3.PutObjectTagging(testBucket, t.Name(), tooMany); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// delete the tags, the object should still exist
+	tt.OK(cluster.S3.DeleteObjectTagging(testBucket, t.Name()))
+	tags, err = cluster.S3.GetObjectTagging(testBucket, t.Name())
+	tt.OK(err)
+	if len(tags) != 0 {
+		t.Fatal("unexpected tags", tags)
+	}
+	_, err = cluster.S3.HeadObject(testBucket, t.Name())
+	tt.OK(err)
+
+	// assert tagging a missing object fails
+	if err := cluster.S3.PutObjectTagging(testBucket, "missing", want); err == nil || !strings.Contains(err.Error(), string(gofakes3.ErrNoSuchKey)) {
+		t.Fatal("unexpected error", err)
+	}
+}
+
+func TestS3BucketLifecycle(t *testing.T) {
+	cluster := newTestCluster(t, testClusterOptions{})
+	defer cluster.Shutdown()
+	tt := cluster.tt
+
+	// assert the bucket has no lifecycle rules
+	rules, err := cluster.S3.GetBucketLifecycleConfiguration(testBucket)
+	tt.OK(err)
+	if len(rules) != 0 {
+		t.Fatal("unexpected rules", rules)
+	}
+
+	// configure lifecycle rules
+	want := map[string]int64{"logs/": 1, "": 30}
+	tt.OK(cluster.S3.PutBucketLifecycleConfiguration(testBucket, want))
+
+	// assert the rules are returned
+	rules, err = cluster.S3.GetBucketLifecycleConfiguration(testBucket)
+	tt.OK(err)
+	if !cmp.Equal(rules, want) {
+		t.Fatal("unexpected rules", cmp.Diff(rules, want))
+	}
+
+	// assert the rules were added to the bucket policy
+	b, err := cluster.Bus.Bucket(context.Background(), testBucket)
+	tt.OK(err)
+	if len(b.Policy.LifecycleRules) != 2 {
+		t.Fatal("unexpected rules", b.Policy.LifecycleRules)
+	}
+
+	// assert the rules can be applied through the bus
+	removed, err := cluster.Bus.ApplyBucketLifecycleRules(context.Background(), testBucket)
+	tt.OK(err)
+	if removed != 0 {
+		t.Fatal("unexpected number of removed objects", removed)
+	}
+
+	// assert invalid rules are rejected
+	if err := cluster.S3.PutBucketLifecycleConfiguration(testBucket, map[string]int64{"": 0}); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// delete the rules
+	tt.OK(cluster.S3.DeleteBucketLifecycle(testBucket))
+	rules, err = cluster.S3.GetBucketLifecycleConfiguration(testBucket)
+	tt.OK(err)
+	if len(rules) != 0 {
+		t.Fatal("unexpected rules", rules)
+	}
+}
+
+func TestS3ObjectVersioning(t *testing.T) {
+	cluster := newTestCluster(t, testClusterOptions{
+		hosts: test.RedundancySettings.TotalShards,
+	})
+	defer cluster.Shutdown()
+	tt := cluster.tt
+
+	// assert uploads to a bucket without versioning have no version
+	res, err := cluster.S3.PutObject(testBucket, t.Name(), bytes.NewReader([]byte("v1")), putObjectOptions{})
+	tt.OK(err)
+	if res.versionID != "" {
+		t.Fatal("unexpected version", res.versionID)
+	}
+
+	// enable versioning
+	tt.OK(cluster.Bus.UpdateBucketPolicy(context.Background(), testBucket, api.BucketPolicy{Versioning: true}))
+
+	// overwrite the object twice
+	var versionIDs []string
+	for _, data := range []string{"v2", "v3"} {
+		res, err := cluster.S3.PutObject(testBucket, t.Name(), bytes.NewReader([]byte(data)), putObjectOptions{})
+		tt.OK(err)
+		if res.versionID == "" {
+			t.Fatal("expected version")
+		}
+		versionIDs = append(versionIDs, res.versionID)
+	}
+
+	// assert all versions are kept
+	versions, err := cluster.Bus.ObjectVersions(context.Background(), testBucket, "/"+t.Name())
+	tt.OK(err)
+	if len(versions) != 3 {
+		t.Fatal("unexpected number of versions", len(versions))
+	} else if versions[0].VersionID != versionIDs[1] || versions[1].VersionID != versionIDs[0] {
+		t.Fatal("unexpected versions", versions)
+	}
+
+	// assert the previous version can be fetched
+	obj, err := cluster.Bus.Object(context.Background(), testBucket, "/"+t.Name(), api.GetObjectOptions{VersionID: versionIDs[0]})
+	tt.OK(err)
+	if obj.VersionID != versionIDs[0] || obj.Size != 2 {
+		t.Fatal("unexpected object", obj.VersionID, obj.Size)
+	}
+
+	// delete the object and assert a delete marker is added
+	tt.OK(cluster.S3.DeleteObject(testBucket, t.Name()))
+	versions, err = cluster.Bus.ObjectVersions(context.Background(), testBucket, "/"+t.Name())
+	tt.OK(err)
+	if len(versions) != 4 || !versions[0].IsDeleteMarker {
+		t.Fatal("unexpected versions", versions)
+	}
+}
+
 func TestS3Authentication(t *testing.T) {
 	cluster := newTestCluster(t, clusterOptsDefault)
 	defer cluster.Shutdown()
@@ -510,6 +681,31 @@ func TestS3List(t *testing.T) {
 	}
 }
 
+func TestS3MultipartUploadTTL(t *testing.T) {
+	cluster := newTestCluster(t, testClusterOptions{
+		hosts: test.RedundancySettings.TotalShards,
+	})
+	defer cluster.Shutdown()
+	tt := cluster.tt
+
+	// start a multipart upload with a TTL and one without
+	tt.OKAll(cluster.S3.NewMultipartUpload(testBucket, "foo", putObjectOptions{multipartTTL: time.Second}))
+	tt.OKAll(cluster.S3.NewMultipartUpload(testBucket, "bar", putObjectOptions{}))
+
+	// assert only the upload with the TTL is expired
+	time.Sleep(time.Second)
+	expired, err := cluster.Bus.ExpireMultipartUploads(context.Background(), 0)
+	tt.OK(err)
+	if expired != 1 {
+		t.Fatalf("expected 1 expired upload, got %v", expired)
+	}
+	uploads, err := cluster.S3.ListMultipartUploads(testBucket)
+	tt.OK(err)
+	if len(uploads) != 1 || uploads[0].key != "bar" {
+		t.Fatalf("unexpected uploads %+v", uploads)
+	}
+}
+
 func TestS3MultipartUploads(t *testing.T) {
 	cluster := newTestCluster(t, testClusterOptions{
 		hosts:         test.RedundancySettings.TotalShards,
@@ -78,14 +78,17 @@ type (
 	}
 
 	putObjectOptions struct {
-		metadata map[string]string
+		metadata          map[string]string
+		copySourceIfMatch string
+		multipartTTL      time.Duration
 	}
 
 	putObjectPartOptions struct {
 	}
 
 	putObjectResponse struct {
-		etag string
+		etag      string
+		versionID string
 	}
 
 	putObjectPartResponse struct {
@@ -153,6 +156,9 @@ func (c *s3TestClient) CopyObject(srcBucket, dstBucket, srcKey, dstKey string, o
 		}
 		input.SetMetadata(md)
 	}
+	if opts.copySourceIfMatch != "" {
+		input.SetCopySourceIfMatch(opts.copySourceIfMatch)
+	}
 	resp, err := c.s3.CopyObject(&input)
 	if err != nil {
 		return copyObjectResponse{}, err
@@ -177,6 +183,13 @@ func (c *s3TestClient) DeleteBucket(bucket string) error {
 	return err
 }
 
+func (c *s3TestClient) DeleteBucketLifecycle(bucket string) error {
+	var input s3aws.DeleteBucketLifecycleInput
+	input.SetBucket(bucket)
+	_, err := c.s3.DeleteBucketLifecycle(&input)
+	return err
+}
+
 func (c *s3TestClient) DeleteObject(bucket, objKey string) error {
 	var input s3aws.DeleteObjectInput
 	input.SetBucket(bucket)
@@ -185,6 +198,28 @@ func (c *s3TestClient) DeleteObject(bucket, objKey string) error {
 	return err
 }
 
+func (c *s3TestClient) DeleteObjectTagging(bucket, objKey string) error {
+	var input s3aws.DeleteObjectTaggingInput
+	input.SetBucket(bucket)
+	input.SetKey(objKey)
+	_, err := c.s3.DeleteObjectTagging(&input)
+	return err
+}
+
+func (c *s3TestClient) GetBucketLifecycleConfiguration(bucket string) (map[string]int64, error) {
+	var input s3aws.GetBucketLifecycleConfigurationInput
+	input.SetBucket(bucket)
+	resp, err := c.s3.GetBucketLifecycleConfiguration(&input)
+	if err != nil {
+		return nil, err
+	}
+	rules := make(map[string]int64)
+	for _, rule := range resp.Rules {
+		rules[*rule.Filter.Prefix] = *rule.Expiration.Days
+	}
+	return rules, nil
+}
+
 func (c *s3TestClient) GetObject(bucket, objKey string, opts getObjectOptions) (getObjectResponse, error) {
 	var input s3aws.GetObjectInput
 	input.SetBucket(bucket)
@@ -214,6 +249,21 @@ func (c *s3TestClient) GetObject(bucket, objKey string, opts getObjectOptions) (
 	}, nil
 }
 
+func (c *s3TestClient) GetObjectTagging(bucket, objKey string) (map[string]string, error) {
+	var input s3aws.GetObjectTaggingInput
+	input.SetBucket(bucket)
+	input.SetKey(objKey)
+	resp, err := c.s3.GetObjectTagging(&input)
+	if err != nil {
+		return nil, err
+	}
+	tags := make(map[string]string)
+	for _, tag := range resp.TagSet {
+		tags[*tag.Key] = *tag.Value
+	}
+	return tags, nil
+}
+
 func (c *s3TestClient) HeadBucket(bucket string) error {
 	var input s3aws.HeadBucketInput
 	input.SetBucket(bucket)
@@ -368,13 +418,32 @@ func (c *s3TestClient) NewMultipartUpload(bucket, objKey string, opts putObjectO
 		}
 		input.SetMetadata(md)
 	}
-	resp, err := c.s3.CreateMultipartUpload(&input)
-	if err != nil {
+	req, resp := c.s3.CreateMultipartUploadRequest(&input)
+	if opts.multipartTTL > 0 {
+		req.HTTPRequest.Header.Set("X-Amz-Sia-Multipart-Upload-Ttl", fmt.Sprint(int(opts.multipartTTL.Seconds())))
+	}
+	if err := req.Send(); err != nil {
 		return "", err
 	}
 	return *resp.UploadId, nil
 }
 
+func (c *s3TestClient) PutBucketLifecycleConfiguration(bucket string, rules map[string]int64) error {
+	var input s3aws.PutBucketLifecycleConfigurationInput
+	input.SetBucket(bucket)
+	cfg := &s3aws.BucketLifecycleConfiguration{Rules: []*s3aws.LifecycleRule{}}
+	for prefix, days := range rules {
+		cfg.Rules = append(cfg.Rules, &s3aws.LifecycleRule{
+			Filter:     &s3aws.LifecycleRuleFilter{Prefix: aws.String(prefix)},
+			Status:     aws.String(s3aws.ExpirationStatusEnabled),
+			Expiration: &s3aws.LifecycleExpiration{Days: aws.Int64(days)},
+		})
+	}
+	input.SetLifecycleConfiguration(cfg)
+	_, err := c.s3.PutBucketLifecycleConfiguration(&input)
+	return err
+}
+
 func (c *s3TestClient) PutObject(bucket, objKey string, body io.ReadSeeker, opts putObjectOptions) (putObjectResponse, error) {
 	contentLength, err := body.Seek(0, io.SeekEnd)
 	if err != nil {
@@ -401,11 +470,29 @@ func (c *s3TestClient) PutObject(bucket, objKey string, body io.ReadSeeker, opts
 	if err != nil {
 		return putObjectResponse{}, err
 	}
+	var versionID string
+	if resp.VersionId != nil {
+		versionID = *resp.VersionId
+	}
 	return putObjectResponse{
-		etag: *resp.ETag,
+		etag:      *resp.ETag,
+		versionID: versionID,
 	}, nil
 }
 
+func (c *s3TestClient) PutObjectTagging(bucket, objKey string, tags map[string]string) error {
+	var input s3aws.PutObjectTaggingInput
+	input.SetBucket(bucket)
+	input.SetKey(objKey)
+	tagging := &s3aws.Tagging{TagSet: []*s3aws.Tag{}}
+	for k, v := range tags {
+		tagging.TagSet = append(tagging.TagSet, &s3aws.Tag{Key: aws.String(k), Value: aws.String(v)})
+	}
+	input.SetTagging(tagging)
+	_, err := c.s3.PutObjectTagging(&input)
+	return err
+}
+
 func (c *s3TestClient) PutObjectPart(bucket, objKey, uploadID string, partNum int64, body io.ReadSeeker, opts putObjectPartOptions) (putObjectPartResponse, error) {
 	contentLength, err := body.Seek(0, io.SeekEnd)
 	if err != nil {
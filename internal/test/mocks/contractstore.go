@@ -50,8 +50,20 @@ func (*ContractStore) ContractSize(context.Context, types.FileContractID) (api.C
 	return api.ContractSize{}, nil
 }
 
-func (*ContractStore) ContractRoots(context.Context, types.FileContractID) ([]types.Hash256, error) {
-	return nil, nil
+func (cs *ContractStore) ContractRoots(_ context.Context, fcid types.FileContractID) (roots []types.Hash256, _ error) {
+	cs.mu.Lock()
+	c, ok := cs.contracts[fcid]
+	cs.mu.Unlock()
+	if !ok {
+		return nil, api.ErrContractNotFound
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for root := range c.sectors {
+		roots = append(roots, root)
+	}
+	return roots, nil
 }
 
 func (cs *ContractStore) Contracts(context.Context, api.ContractsOpts) (metadatas []api.ContractMetadata, _ error) {
@@ -63,6 +75,17 @@ func (cs *ContractStore) Contracts(context.Context, api.ContractsOpts) (metadata
 	return
 }
 
+func (cs *ContractStore) FindAlternativeContracts(_ context.Context, root types.Hash256) (fcids []types.FileContractID, _ error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	for fcid, c := range cs.contracts {
+		if _, found := c.Sector(root); found {
+			fcids = append(fcids, fcid)
+		}
+	}
+	return
+}
+
 func (cs *ContractStore) AddContract(hk types.PublicKey) *Contract {
 	cs.mu.Lock()
 	defer cs.mu.Unlock()
@@ -35,7 +35,7 @@ func (hs *HostStore) Host(ctx context.Context, hostKey types.PublicKey) (api.Hos
 	return h.hi, nil
 }
 
-func (hs *HostStore) RecordHostScans(ctx context.Context, scans []api.HostScan) error {
+func (hs *HostStore) RecordHostBenchmark(ctx context.Context, hostKey types.PublicKey, benchmark api.HostBenchmark) error {
 	return nil
 }
 
@@ -102,6 +102,10 @@ func (h *Host) UploadSector(ctx context.Context, sectorRoot types.Hash256, secto
 	return errors.New("implement when needed")
 }
 
+func (h *Host) ValidateSectorUpload(ctx context.Context, fcid types.FileContractID, sector *[rhpv2.SectorSize]byte) error {
+	return nil
+}
+
 func (h *Host) PriceTable(ctx context.Context, rev *types.FileContractRevision) (api.HostPriceTable, types.Currency, error) {
 	return h.HostPriceTable(), types.NewCurrency64(1), nil
 }
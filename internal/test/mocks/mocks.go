@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"log"
+	"sync"
 
 	rhpv3 "go.sia.tech/core/rhp/v3"
 	rhpv4 "go.sia.tech/core/rhp/v4"
@@ -60,6 +61,7 @@ type busMock struct {
 	*ContractLocker
 	*ContractStore
 	*HostStore
+	*metricsStoreMock
 	*ObjectStore
 	*settingStoreMock
 	*syncerMock
@@ -76,6 +78,7 @@ func NewBus(cs *ContractStore, hs *HostStore, os *ObjectStore) *busMock {
 		ContractLocker:         NewContractLocker(),
 		ContractStore:          cs,
 		HostStore:              hs,
+		metricsStoreMock:       &metricsStoreMock{},
 		ObjectStore:            os,
 		settingStoreMock:       &settingStoreMock{},
 		syncerMock:             &syncerMock{},
@@ -126,8 +129,22 @@ func (h *Host) PublicKey() types.PublicKey {
 }
 
 type (
-	Memory        struct{}
-	MemoryManager struct{ memBlockChan chan struct{} }
+	Memory struct {
+		mm        *MemoryManager
+		remaining uint64
+	}
+
+	MemoryManager struct {
+		memBlockChan chan struct{}
+
+		// capacity is the maximum amount of memory that can be acquired at
+		// the same time, zero means there is no limit
+		capacity uint64
+
+		mu          sync.Mutex
+		inFlight    uint64
+		releaseChan chan struct{}
+	}
 )
 
 func NewMemoryManager() *MemoryManager {
@@ -136,6 +153,16 @@ func NewMemoryManager() *MemoryManager {
 	return mm
 }
 
+// NewMemoryManagerWithCapacity returns a memory manager that blocks
+// AcquireMemory until enough memory was released for the in-flight memory to
+// stay within the given capacity, simulating memory pressure.
+func NewMemoryManagerWithCapacity(capacity uint64) *MemoryManager {
+	mm := NewMemoryManager()
+	mm.capacity = capacity
+	mm.releaseChan = make(chan struct{})
+	return mm
+}
+
 func (mm *MemoryManager) Block() func() {
 	select {
 	case <-mm.memBlockChan:
@@ -147,18 +174,80 @@ func (mm *MemoryManager) Block() func() {
 	return func() { close(blockChan) }
 }
 
-func (m *Memory) Release()           {}
-func (m *Memory) ReleaseSome(uint64) {}
+func (m *Memory) Release() {
+	if m.mm != nil {
+		m.mm.release(m.remaining)
+		m.remaining = 0
+	}
+}
+
+func (m *Memory) ReleaseSome(amt uint64) {
+	if m.mm != nil {
+		if amt > m.remaining {
+			panic("releasing more memory than remaining")
+		}
+		m.mm.release(amt)
+		m.remaining -= amt
+	}
+}
 
 func (mm *MemoryManager) Limit(amt uint64) (memory.MemoryManager, error) {
 	return mm, nil
 }
 
-func (mm *MemoryManager) Status() memory.Status { return memory.Status{} }
+func (mm *MemoryManager) Status() memory.Status {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	if mm.capacity == 0 {
+		return memory.Status{}
+	}
+	return memory.Status{
+		Available: mm.capacity - mm.inFlight,
+		Total:     mm.capacity,
+	}
+}
 
 func (mm *MemoryManager) AcquireMemory(ctx context.Context, amt uint64) memory.Memory {
 	<-mm.memBlockChan
-	return &Memory{}
+	if mm.capacity == 0 {
+		return &Memory{}
+	} else if amt > mm.capacity {
+		return nil
+	}
+
+	for {
+		mm.mu.Lock()
+		if mm.inFlight+amt <= mm.capacity {
+			mm.inFlight += amt
+			mm.mu.Unlock()
+			return &Memory{mm: mm, remaining: amt}
+		}
+		releaseChan := mm.releaseChan
+		mm.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-releaseChan:
+		}
+	}
+}
+
+func (mm *MemoryManager) release(amt uint64) {
+	if amt == 0 {
+		return
+	}
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	mm.inFlight -= amt
+	close(mm.releaseChan)
+	mm.releaseChan = make(chan struct{})
+}
+
+type metricsStoreMock struct{}
+
+func (*metricsStoreMock) RecordSlabDownloadMetric(context.Context, ...api.SlabDownloadMetric) error {
+	return nil
 }
 
 type settingStoreMock struct{}
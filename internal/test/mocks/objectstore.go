@@ -307,6 +307,15 @@ func (os *ObjectStore) RemoveObjects(ctx context.Context, bucket, prefix string)
 	return nil
 }
 
+func (os *ObjectStore) SlabBufferFlushEventStream(ctx context.Context) (<-chan api.EventSlabBufferFlush, error) {
+	events := make(chan api.EventSlabBufferFlush)
+	go func() {
+		<-ctx.Done()
+		close(events)
+	}()
+	return events, nil
+}
+
 func (os *ObjectStore) totalSlabBufferSize() (total int) {
 	for _, p := range os.partials {
 		if time.Now().After(p.lockedUntil) {
@@ -0,0 +1,58 @@
+package upload
+
+import (
+	"go.sia.tech/renterd/api"
+	"go.sia.tech/renterd/internal/utils"
+)
+
+type (
+	// stageStats tracks the time spent in each stage of the upload pipeline
+	// across uploads.
+	stageStats struct {
+		slabPacking    *utils.DataPoints
+		read           *utils.DataPoints
+		encryption     *utils.DataPoints
+		erasureCoding  *utils.DataPoints
+		sectorUpload   *utils.DataPoints
+		metadataCommit *utils.DataPoints
+	}
+)
+
+func newStageStats() *stageStats {
+	return &stageStats{
+		slabPacking:    utils.NewDataPoints(0),
+		read:           utils.NewDataPoints(0),
+		encryption:     utils.NewDataPoints(0),
+		erasureCoding:  utils.NewDataPoints(0),
+		sectorUpload:   utils.NewDataPoints(0),
+		metadataCommit: utils.NewDataPoints(0),
+	}
+}
+
+func (s *stageStats) Latency() api.UploadLatencyResponse {
+	return api.UploadLatencyResponse{
+		SlabPacking:    stageLatency(s.slabPacking),
+		Read:           stageLatency(s.read),
+		Encryption:     stageLatency(s.encryption),
+		ErasureCoding:  stageLatency(s.erasureCoding),
+		SectorUpload:   stageLatency(s.sectorUpload),
+		MetadataCommit: stageLatency(s.metadataCommit),
+	}
+}
+
+func (s *stageStats) Track(t api.UploadStageTelemetry) {
+	s.slabPacking.Track(float64(t.SlabPackingMs))
+	s.read.Track(float64(t.ReadMs))
+	s.encryption.Track(float64(t.EncryptionMs))
+	s.erasureCoding.Track(float64(t.ErasureCodingMs))
+	s.sectorUpload.Track(float64(t.SectorUploadMs))
+	s.metadataCommit.Track(float64(t.MetadataCommitMs))
+}
+
+func stageLatency(dp *utils.DataPoints) api.UploadStageLatency {
+	return api.UploadStageLatency{
+		P50: dp.PercentileOrZero(50),
+		P95: dp.PercentileOrZero(95),
+		P99: dp.PercentileOrZero(99),
+	}
+}
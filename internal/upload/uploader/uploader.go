@@ -90,6 +90,7 @@ type (
 		// stats related field
 		consecutiveFailures uint64
 		lastRecompute       time.Time
+		preflightFailures   map[host.PreflightFailureReason]uint64
 
 		statsSectorUploadEstimateInMS    *utils.DataPoints
 		statsSectorUploadSpeedBytesPerMS *utils.DataPoints
@@ -109,6 +110,7 @@ func New(ctx context.Context, cl locking.ContractLocker, cs ContractStore, hm ho
 		signalNewUpload: make(chan struct{}, 1),
 
 		// stats
+		preflightFailures:                make(map[host.PreflightFailureReason]uint64),
 		statsSectorUploadEstimateInMS:    utils.NewDataPoints(10 * time.Minute),
 		statsSectorUploadSpeedBytesPerMS: utils.NewDataPoints(0),
 
@@ -142,6 +144,18 @@ func (u *Uploader) Healthy() bool {
 	return u.consecutiveFailures == 0
 }
 
+// PreflightFailures returns the number of sector uploads that failed their
+// pre-flight validation, keyed by the reason they failed.
+func (u *Uploader) PreflightFailures() map[host.PreflightFailureReason]uint64 {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	failures := make(map[host.PreflightFailureReason]uint64, len(u.preflightFailures))
+	for reason, n := range u.preflightFailures {
+		failures[reason] = n
+	}
+	return failures
+}
+
 func (u *Uploader) PublicKey() types.PublicKey {
 	return u.hk
 }
@@ -191,6 +205,13 @@ outer:
 			start := time.Now()
 			duration, err := u.execute(req)
 			elapsed := time.Since(start)
+
+			// track pre-flight failures separately
+			var pe *host.PreflightError
+			if errors.As(err, &pe) {
+				u.trackPreflightFailure(pe.Reason)
+			}
+
 			if errors.Is(err, rhp3.ErrMaxRevisionReached) {
 				if u.tryRefresh(req.Ctx) {
 					u.Enqueue(req)
@@ -241,10 +262,11 @@ func handleSectorUpload(uploadErr error, uploadDuration, totalDuration time.Dura
 		return true, false, float64(ms), float64(rhpv2.SectorSize / ms)
 	}
 
-	// upload failed because we weren't able to create a payment, in this case
-	// we want to punish the host but only to ensure we stop using it, meaning
-	// we don't increment consecutive failures
-	if utils.IsErr(uploadErr, rhp3.ErrFailedToCreatePayment) {
+	// upload failed because we weren't able to create a payment or because it
+	// failed pre-flight validation, in this case we want to punish the host but
+	// only to ensure we stop using it, meaning we don't increment consecutive
+	// failures
+	if utils.IsErr(uploadErr, rhp3.ErrFailedToCreatePayment) || utils.IsErr(uploadErr, host.ErrPreflightFailed) {
 		return false, false, float64(time.Hour.Milliseconds()), 0
 	}
 
@@ -349,6 +371,11 @@ func (u *Uploader) execute(req *SectorUploadReq) (_ time.Duration, err error) {
 	ctx, cancel := context.WithTimeout(req.Ctx, sectorUploadTimeout)
 	defer cancel()
 
+	// validate the upload, no need to send any data if it's bound to fail
+	if err := host.ValidateSectorUpload(ctx, fcid, req.Data); err != nil {
+		return 0, fmt.Errorf("failed to validate sector upload to contract %v; %w", fcid, err)
+	}
+
 	// upload the sector
 	start := time.Now()
 	err = host.UploadSector(ctx, req.Root, req.Data)
@@ -390,6 +417,12 @@ func (u *Uploader) trackConsecutiveFailures(success, failure bool) {
 	}
 }
 
+func (u *Uploader) trackPreflightFailure(reason host.PreflightFailureReason) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.preflightFailures[reason]++
+}
+
 func (u *Uploader) trackSectorUploadStats(uploadEstimateMS, uploadSpeedBytesPerMS float64) {
 	u.mu.Lock()
 	defer u.mu.Unlock()
@@ -10,6 +10,7 @@ import (
 	rhpv2 "go.sia.tech/core/rhp/v2"
 	"go.sia.tech/core/types"
 	"go.sia.tech/renterd/api"
+	"go.sia.tech/renterd/internal/host"
 	rhp3 "go.sia.tech/renterd/internal/rhp/v3"
 	"go.sia.tech/renterd/internal/test/mocks"
 	"go.uber.org/zap"
@@ -50,6 +51,8 @@ func TestHandleSectorUpload(t *testing.T) {
 	regular := false
 
 	errHostError := errors.New("some host error")
+	errPreflight := host.NewPreflightError(host.PreflightFailureReasonPriceTableExpired, errors.New("expired"))
+	errPreflightMaxRevision := host.NewPreflightError(host.PreflightFailureReasonMaxRevisionReached, rhp3.ErrMaxRevisionReached)
 	errSectorUploadFinishedAndDial := fmt.Errorf("%w;%w", rhp3.ErrDialTransport, ErrSectorUploadFinished)
 
 	cases := []struct {
@@ -72,6 +75,7 @@ func TestHandleSectorUpload(t *testing.T) {
 		// renewed contract case
 		{rhp3.ErrMaxRevisionReached, 0, ms, regular, false, false, 0, 0},
 		{rhp3.ErrMaxRevisionReached, 0, ms, overdrive, false, false, 0, 0},
+		{errPreflightMaxRevision, 0, ms, regular, false, false, 0, 0},
 
 		// context canceled case
 		{context.Canceled, 0, ms, regular, false, false, 0, 0},
@@ -87,6 +91,10 @@ func TestHandleSectorUpload(t *testing.T) {
 		{rhp3.ErrFailedToCreatePayment, 0, ms, regular, false, false, 3600000, 0},
 		{rhp3.ErrFailedToCreatePayment, 0, ms, overdrive, false, false, 3600000, 0},
 
+		// pre-flight failure case
+		{errPreflight, 0, ms, regular, false, false, 3600000, 0},
+		{errPreflight, 0, ms, overdrive, false, false, 3600000, 0},
+
 		// host failure
 		{errHostError, ms, ms, regular, false, true, 3600000, 0},
 		{errHostError, ms, ms, overdrive, false, true, 3600000, 0},
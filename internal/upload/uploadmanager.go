@@ -10,12 +10,14 @@ import (
 	"math"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	rhpv2 "go.sia.tech/core/rhp/v2"
 
 	"go.sia.tech/core/types"
 	"go.sia.tech/renterd/api"
+	"go.sia.tech/renterd/internal/host"
 	"go.sia.tech/renterd/internal/hosts"
 	"go.sia.tech/renterd/internal/memory"
 	"go.sia.tech/renterd/internal/upload/uploader"
@@ -76,6 +78,7 @@ type (
 
 		statsOverdrivePct              *utils.DataPoints
 		statsSlabUploadSpeedBytesPerMS *utils.DataPoints
+		statsStages                    *stageStats
 
 		shutdownCtx context.Context
 
@@ -88,6 +91,7 @@ type (
 		AvgOverdrivePct        float64
 		HealthyUploaders       uint64
 		NumUploaders           uint64
+		PreflightFailures      map[types.PublicKey]map[host.PreflightFailureReason]uint64
 		UploadSpeedsMBPS       map[types.PublicKey]float64
 	}
 )
@@ -98,6 +102,10 @@ type (
 		allowed     map[types.PublicKey]struct{}
 		os          ObjectStore
 		shutdownCtx context.Context
+
+		checksumAlgorithm string
+		retryPolicy       api.UploadRetryPolicy
+		numRetries        atomic.Uint64
 	}
 
 	uploadedSector struct {
@@ -135,11 +143,15 @@ type (
 		slab  object.SlabSlice
 		index int
 		err   error
+
+		encodingDuration time.Duration
+		uploadDuration   time.Duration
 	}
 
 	sectorUpload struct {
-		index int
-		root  types.Hash256
+		index       int
+		root        types.Hash256
+		numFailures int
 
 		ctx    context.Context
 		cancel context.CancelCauseFunc
@@ -166,6 +178,7 @@ func NewManager(ctx context.Context, uploadKey *utils.UploadKey, hm hosts.Manage
 
 		statsOverdrivePct:              utils.NewDataPoints(0),
 		statsSlabUploadSpeedBytesPerMS: utils.NewDataPoints(0),
+		statsStages:                    newStageStats(),
 
 		shutdownCtx: ctx,
 
@@ -177,6 +190,12 @@ func (mgr *Manager) AcquireMemory(ctx context.Context, amt uint64) memory.Memory
 	return mgr.mm.AcquireMemory(ctx, amt)
 }
 
+// Latency returns the latency percentiles of every stage in the upload
+// pipeline.
+func (mgr *Manager) Latency() api.UploadLatencyResponse {
+	return mgr.statsStages.Latency()
+}
+
 func (mgr *Manager) MemoryStatus() memory.Status {
 	return mgr.mm.Status()
 }
@@ -187,8 +206,10 @@ func (mgr *Manager) Stats() Stats {
 
 	var numHealthy uint64
 	speeds := make(map[types.PublicKey]float64)
+	preflightFailures := make(map[types.PublicKey]map[host.PreflightFailureReason]uint64)
 	for _, u := range mgr.uploaders {
 		speeds[u.PublicKey()] = u.AvgUploadSpeedBytesPerMS() * 0.008
+		preflightFailures[u.PublicKey()] = u.PreflightFailures()
 		if u.Healthy() {
 			numHealthy++
 		}
@@ -200,6 +221,7 @@ func (mgr *Manager) Stats() Stats {
 		AvgOverdrivePct:        mgr.statsOverdrivePct.Average(),
 		HealthyUploaders:       numHealthy,
 		NumUploaders:           uint64(len(speeds)),
+		PreflightFailures:      preflightFailures,
 		UploadSpeedsMBPS:       speeds,
 	}
 }
@@ -212,7 +234,7 @@ func (mgr *Manager) Stop() {
 	}
 }
 
-func (mgr *Manager) Upload(ctx context.Context, r io.Reader, hosts []HostInfo, up Parameters) (bufferSizeLimitReached bool, eTag string, err error) {
+func (mgr *Manager) Upload(ctx context.Context, r io.Reader, hosts []HostInfo, up Parameters) (bufferSizeLimitReached bool, eTag string, telemetry api.UploadStageTelemetry, err error) {
 	// cancel all in-flight requests when the upload is done
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
@@ -231,18 +253,25 @@ func (mgr *Manager) Upload(ctx context.Context, r io.Reader, hosts []HostInfo, u
 		Key:    mgr.uploadKey,
 	})
 	if err != nil {
-		return false, "", err
+		return false, "", api.UploadStageTelemetry{}, err
+	}
+
+	// validate the checksum algorithm
+	if err := object.ValidateChecksumAlgorithm(up.ChecksumAlgorithm); err != nil {
+		return false, "", api.UploadStageTelemetry{}, err
 	}
 
 	// create the upload
 	upload, err := mgr.newUpload(up.RS.TotalShards, hosts, up.BH)
 	if err != nil {
-		return false, "", err
+		return false, "", api.UploadStageTelemetry{}, err
 	}
+	upload.checksumAlgorithm = up.ChecksumAlgorithm
+	upload.retryPolicy = up.RetryPolicy
 
 	// track the upload in the bus
 	if err := mgr.os.TrackUpload(ctx, upload.id); err != nil {
-		return false, "", fmt.Errorf("failed to track upload '%v', err: %w", upload.id, err)
+		return false, "", api.UploadStageTelemetry{}, fmt.Errorf("failed to track upload '%v', err: %w", upload.id, err)
 	}
 
 	// defer a function that finishes the upload
@@ -265,8 +294,14 @@ func (mgr *Manager) Upload(ctx context.Context, r io.Reader, hosts []HostInfo, u
 	slabSize := up.RS.SlabSize()
 	var partialSlab []byte
 
-	// launch uploads in a separate goroutine
+	// launch uploads in a separate goroutine, the time spent reading and
+	// encrypting the data is reported back through readDurationsChan
+	type readDurations struct {
+		read, encryption time.Duration
+	}
+	readDurationsChan := make(chan readDurations, 1)
 	go func() {
+		var durations readDurations
 		var slabIndex int
 		for {
 			select {
@@ -282,11 +317,18 @@ func (mgr *Manager) Upload(ctx context.Context, r io.Reader, hosts []HostInfo, u
 				return // interrupted
 			}
 
-			// read next slab's data
+			// read next slab's data, the data is read from the underlying
+			// reader and encrypted separately to measure both stages
 			data := make([]byte, slabSizeNoRedundancy)
-			length, err := io.ReadFull(io.LimitReader(cr, int64(slabSizeNoRedundancy)), data)
+			readStart := time.Now()
+			length, err := io.ReadFull(cr.R, data)
+			durations.read += time.Since(readStart)
+			encryptionStart := time.Now()
+			cr.S.XORKeyStream(data[:length], data[:length])
+			durations.encryption += time.Since(encryptionStart)
 			if err == io.EOF {
 				mem.Release()
+				readDurationsChan <- durations
 
 				// no more data to upload, notify main thread of the number of
 				// slabs to wait for
@@ -335,14 +377,16 @@ func (mgr *Manager) Upload(ctx context.Context, r io.Reader, hosts []HostInfo, u
 	for len(responses) < numSlabs {
 		select {
 		case <-mgr.shutdownCtx.Done():
-			return false, "", ErrShuttingDown
+			return false, "", api.UploadStageTelemetry{}, ErrShuttingDown
 		case <-ctx.Done():
-			return false, "", ErrUploadCancelled
+			return false, "", api.UploadStageTelemetry{}, ErrUploadCancelled
 		case numSlabs = <-numSlabsChan:
 		case res := <-respChan:
 			if res.err != nil {
-				return false, "", res.err
+				return false, "", api.UploadStageTelemetry{}, res.err
 			}
+			telemetry.ErasureCodingMs += res.encodingDuration.Milliseconds()
+			telemetry.SectorUploadMs += res.uploadDuration.Milliseconds()
 			responses = append(responses, res)
 		}
 	}
@@ -359,35 +403,44 @@ func (mgr *Manager) Upload(ctx context.Context, r io.Reader, hosts []HostInfo, u
 
 	// compute etag
 	eTag = hex.EncodeToString(hasher.Sum(nil))
+	durations := <-readDurationsChan
+	telemetry.ReadMs = durations.read.Milliseconds()
+	telemetry.EncryptionMs = durations.encryption.Milliseconds()
 
 	// add partial slabs
 	if len(partialSlab) > 0 {
 		var pss []object.SlabSlice
+		start := time.Now()
 		pss, bufferSizeLimitReached, err = mgr.os.AddPartialSlab(ctx, partialSlab, uint8(up.RS.MinShards), uint8(up.RS.TotalShards))
 		if err != nil {
-			return false, "", err
+			return false, "", api.UploadStageTelemetry{}, err
 		}
 		o.Slabs = append(o.Slabs, pss...)
+		telemetry.SlabPackingMs = time.Since(start).Milliseconds()
 	}
 
+	commitStart := time.Now()
 	if up.Multipart {
 		// persist the part
 		err = mgr.os.AddMultipartPart(ctx, up.Bucket, up.Key, eTag, up.UploadID, up.PartNumber, o.Slabs)
 		if err != nil {
-			return bufferSizeLimitReached, "", fmt.Errorf("couldn't add multi part: %w", err)
+			return bufferSizeLimitReached, "", api.UploadStageTelemetry{}, fmt.Errorf("couldn't add multi part: %w", err)
 		}
 	} else {
 		// persist the object
 		err = mgr.os.AddObject(ctx, up.Bucket, up.Key, o, api.AddObjectOptions{MimeType: up.MimeType, ETag: eTag, Metadata: up.Metadata})
 		if err != nil {
-			return bufferSizeLimitReached, "", fmt.Errorf("couldn't add object: %w", err)
+			return bufferSizeLimitReached, "", api.UploadStageTelemetry{}, fmt.Errorf("couldn't add object: %w", err)
 		}
 	}
 
+	telemetry.MetadataCommitMs = time.Since(commitStart).Milliseconds()
+	telemetry.SectorUploadRetries = upload.numRetries.Load()
+	mgr.statsStages.Track(telemetry)
 	return
 }
 
-func (mgr *Manager) UploadPackedSlab(ctx context.Context, rs api.RedundancySettings, ps api.PackedSlab, mem memory.Memory, hosts []HostInfo, bh uint64) (err error) {
+func (mgr *Manager) UploadPackedSlab(ctx context.Context, rs api.RedundancySettings, ps api.PackedSlab, mem memory.Memory, hosts []HostInfo, bh uint64, checksumAlgorithm string) (err error) {
 	// cancel all in-flight requests when the upload is done
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
@@ -395,6 +448,12 @@ func (mgr *Manager) UploadPackedSlab(ctx context.Context, rs api.RedundancySetti
 	// build the shards
 	shards := encryptPartialSlab(ps.Data, ps.EncryptionKey, uint8(rs.MinShards), uint8(rs.TotalShards))
 
+	// compute the checksums
+	checksums, err := object.ComputeChecksums(checksumAlgorithm, shards)
+	if err != nil {
+		return err
+	}
+
 	// create the upload
 	upload, err := mgr.newUpload(len(shards), hosts, bh)
 	if err != nil {
@@ -435,7 +494,12 @@ func (mgr *Manager) UploadPackedSlab(ctx context.Context, rs api.RedundancySetti
 	mgr.statsOverdrivePct.Track(overdrivePct)
 
 	// mark packed slab as uploaded
-	slab := api.UploadedPackedSlab{BufferID: ps.BufferID, Shards: sectors}
+	slab := api.UploadedPackedSlab{
+		BufferID:          ps.BufferID,
+		Shards:            sectors,
+		ChecksumAlgorithm: checksumAlgorithm,
+		Checksums:         checksums,
+	}
 	err = mgr.os.MarkPackedSlabsUploaded(ctx, []api.UploadedPackedSlab{slab})
 	if err != nil {
 		return fmt.Errorf("couldn't mark packed slabs uploaded, err: %v", err)
@@ -652,12 +716,21 @@ func (u *upload) uploadSlab(ctx context.Context, rs api.RedundancySettings, data
 	}
 
 	// create the shards
+	start := time.Now()
 	shards := make([][]byte, rs.TotalShards)
 	resp.slab.Slab.Encode(data, shards)
 	resp.slab.Slab.Encrypt(shards)
+	resp.encodingDuration = time.Since(start)
+
+	// compute the checksums, the algorithm was validated when the upload was
+	// created
+	resp.slab.Slab.ChecksumAlgorithm = u.checksumAlgorithm
+	resp.slab.Slab.Checksums, _ = object.ComputeChecksums(u.checksumAlgorithm, shards)
 
 	// upload the shards
+	start = time.Now()
 	uploaded, uploadSpeed, overdrivePct, err := u.uploadShards(ctx, shards, candidates, mem, maxOverdrive, overdriveTimeout)
+	resp.uploadDuration = time.Since(start)
 
 	// build the sectors
 	var sectors []object.Sector
@@ -719,6 +792,10 @@ func (u *upload) uploadShards(ctx context.Context, shards [][]byte, candidates [
 	// create a request buffer
 	var buffer []*uploader.SectorUploadReq
 
+	// create a channel for failed requests that are retried after a backoff
+	retryChan := make(chan *uploader.SectorUploadReq)
+	var numBackingOff int
+
 	// start the timer after the upload has started
 	// newSlabUpload is quite slow due to computing the sector roots
 	start := time.Now()
@@ -727,12 +804,21 @@ func (u *upload) uploadShards(ctx context.Context, shards [][]byte, candidates [
 	var used bool
 	var done bool
 loop:
-	for slab.numInflight > 0 && !done {
+	for (slab.numInflight > 0 || numBackingOff > 0) && !done {
 		select {
 		case <-u.shutdownCtx.Done():
 			return nil, 0, 0, ErrShuttingDown
 		case <-ctx.Done():
 			return nil, 0, 0, context.Cause(ctx)
+		case req := <-retryChan:
+			// relaunch the request unless an overdrive uploaded the sector
+			// while we were backing off
+			numBackingOff--
+			if !slab.sectors[req.Idx].isUploaded() {
+				if err := slab.launch(req); err != nil {
+					buffer = append(buffer, req)
+				}
+			}
 		case resp := <-respChan:
 			// receive the response
 			used, done = slab.receive(resp)
@@ -740,14 +826,30 @@ loop:
 				break loop
 			}
 
-			// relaunch non-overdrive uploads
+			// relaunch non-overdrive uploads unless they ran out of attempts,
+			// in which case only an overdrive can still upload the sector
 			if resp.Err != nil && !resp.Req.Overdrive {
-				if err := slab.launch(resp.Req); err != nil {
-					// a failure to relaunch non-overdrive uploads is bad, but
-					// we need to keep them around because an overdrive upload
-					// might've been redundant, in which case we can re-use the
-					// host to launch this request
-					buffer = append(buffer, resp.Req)
+				sector := slab.sectors[resp.Req.Idx]
+				sector.numFailures++
+				if u.retryPolicy.MaxAttempts == 0 || sector.numFailures < u.retryPolicy.MaxAttempts {
+					u.numRetries.Add(1)
+					if backoff := u.retryPolicy.Backoff(sector.numFailures); backoff > 0 {
+						// relaunch the request once the backoff expired
+						numBackingOff++
+						req := resp.Req
+						time.AfterFunc(backoff, func() {
+							select {
+							case <-ctx.Done():
+							case retryChan <- req:
+							}
+						})
+					} else if err := slab.launch(resp.Req); err != nil {
+						// a failure to relaunch non-overdrive uploads is bad,
+						// but we need to keep them around because an overdrive
+						// upload might've been redundant, in which case we can
+						// re-use the host to launch this request
+						buffer = append(buffer, resp.Req)
+					}
 				}
 			} else if resp.Err == nil && !used {
 				if len(buffer) > 0 {
@@ -65,3 +65,36 @@ func TestRefreshUploaders(t *testing.T) {
 		t.Fatalf("unexpected number of uploaders, %v != 0", len(ul.uploaders))
 	}
 }
+
+func TestStageStats(t *testing.T) {
+	s := newStageStats()
+
+	// assert latency is zero without data points
+	if latency := s.Latency(); latency != (api.UploadLatencyResponse{}) {
+		t.Fatal("unexpected latency", latency)
+	}
+
+	// track telemetry for 100 uploads
+	for i := 1; i <= 100; i++ {
+		s.Track(api.UploadStageTelemetry{
+			SlabPackingMs:    int64(i),
+			ReadMs:           int64(6 * i),
+			EncryptionMs:     int64(2 * i),
+			ErasureCodingMs:  int64(3 * i),
+			SectorUploadMs:   int64(4 * i),
+			MetadataCommitMs: int64(5 * i),
+		})
+	}
+
+	// assert percentiles
+	latency := s.Latency()
+	if latency.SlabPacking != (api.UploadStageLatency{P50: 50, P95: 95, P99: 99}) {
+		t.Fatal("unexpected slab packing latency", latency.SlabPacking)
+	} else if latency.MetadataCommit != (api.UploadStageLatency{P50: 250, P95: 475, P99: 495}) {
+		t.Fatal("unexpected metadata commit latency", latency.MetadataCommit)
+	} else if latency.SectorUpload.P50 != 200 {
+		t.Fatal("unexpected sector upload latency", latency.SectorUpload)
+	} else if latency.Read.P50 != 300 || latency.Encryption.P50 != 100 {
+		t.Fatal("unexpected read or encryption latency", latency.Read, latency.Encryption)
+	}
+}
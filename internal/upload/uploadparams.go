@@ -21,6 +21,9 @@ type Parameters struct {
 	Packing  bool
 	MimeType string
 
+	RetryPolicy       api.UploadRetryPolicy
+	ChecksumAlgorithm string
+
 	Metadata api.ObjectUserMetadata
 }
 
@@ -44,6 +47,12 @@ func WithBlockHeight(bh uint64) Option {
 	}
 }
 
+func WithChecksumAlgorithm(algorithm string) Option {
+	return func(up *Parameters) {
+		up.ChecksumAlgorithm = algorithm
+	}
+}
+
 func WithCustomKey(ec object.EncryptionKey) Option {
 	return func(up *Parameters) {
 		up.EC = ec
@@ -74,6 +83,12 @@ func WithPartNumber(partNumber int) Option {
 	}
 }
 
+func WithRetryPolicy(rp api.UploadRetryPolicy) Option {
+	return func(up *Parameters) {
+		up.RetryPolicy = rp
+	}
+}
+
 func WithUploadID(uploadID string) Option {
 	return func(up *Parameters) {
 		up.UploadID = uploadID
@@ -0,0 +1,299 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// logStreamMaxClients is the maximum number of clients that can stream
+	// logs at the same time.
+	logStreamMaxClients = 5
+
+	// logStreamClientBuffer is the number of log entries buffered per client,
+	// entries are dropped for clients that can't keep up.
+	logStreamClientBuffer = 256
+)
+
+// ErrTooManyLogStreams is returned when the maximum number of concurrent log
+// stream clients is reached.
+var ErrTooManyLogStreams = errors.New("too many log stream clients")
+
+type (
+	// WebSocketLogSink is a zapcore.Core that keeps the most recent log
+	// entries in memory and broadcasts every entry to all clients connected
+	// to its handler. Entries are only encoded once they are sent to a
+	// client, so logging at a verbose level is cheap while nobody's
+	// listening. Fields that marshal themselves are therefore marshaled
+	// when the entry is encoded rather than when it's logged.
+	WebSocketLogSink struct {
+		zapcore.LevelEnabler
+		enc    zapcore.Encoder
+		stream *logStream
+	}
+
+	logStream struct {
+		mu      sync.Mutex
+		entries []*logEntry // ring buffer
+		next    int
+		full    bool
+		clients map[*logClient]struct{}
+	}
+
+	logClient struct {
+		level  zapcore.Level
+		module string
+		ch     chan []byte
+	}
+
+	logEntry struct {
+		level  zapcore.Level
+		module string
+
+		enc    zapcore.Encoder
+		ent    zapcore.Entry
+		fields []zapcore.Field
+		line   []byte // encoded lazily
+	}
+)
+
+var _ zapcore.Core = (*WebSocketLogSink)(nil)
+
+// NewWebSocketLogSink returns a sink that encodes entries using the given
+// encoder and keeps the last bufferSize entries around to replay them to new
+// clients.
+func NewWebSocketLogSink(enabler zapcore.LevelEnabler, enc zapcore.Encoder, bufferSize int) *WebSocketLogSink {
+	if bufferSize < 1 {
+		panic("buffer size must be positive") // developer error
+	}
+	return &WebSocketLogSink{
+		LevelEnabler: enabler,
+		enc:          enc,
+		stream: &logStream{
+			entries: make([]*logEntry, bufferSize),
+			clients: make(map[*logClient]struct{}),
+		},
+	}
+}
+
+// With implements zapcore.Core.
+func (s *WebSocketLogSink) With(fields []zapcore.Field) zapcore.Core {
+	enc := s.enc.Clone()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	return &WebSocketLogSink{
+		LevelEnabler: s.LevelEnabler,
+		enc:          enc,
+		stream:       s.stream,
+	}
+}
+
+// Check implements zapcore.Core.
+func (s *WebSocketLogSink) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if s.Enabled(ent.Level) {
+		return ce.AddCore(ent, s)
+	}
+	return ce
+}
+
+// Write implements zapcore.Core.
+func (s *WebSocketLogSink) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	s.stream.publish(&logEntry{
+		level:  ent.Level,
+		module: ent.LoggerName,
+		enc:    s.enc,
+		ent:    ent,
+		fields: append([]zapcore.Field(nil), fields...),
+	})
+	return nil
+}
+
+// Sync implements zapcore.Core.
+func (s *WebSocketLogSink) Sync() error { return nil }
+
+// Handler returns a handler that upgrades the connection to a WebSocket and
+// streams log entries to the client, one JSON encoded entry per message. The
+// entries can be filtered using the 'level' and 'module' query parameters,
+// the 'since' parameter replays the last N matching entries from the buffer
+// before live entries are streamed.
+func (s *WebSocketLogSink) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		} else if err := checkSameOrigin(req); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+
+		q := req.URL.Query()
+		level := zapcore.DebugLevel
+		if q.Has("level") {
+			var err error
+			level, err = zapcore.ParseLevel(q.Get("level"))
+			if err != nil {
+				http.Error(w, fmt.Sprintf("invalid level: %v", err), http.StatusBadRequest)
+				return
+			}
+		}
+		var since int
+		if q.Has("since") {
+			var err error
+			since, err = strconv.Atoi(q.Get("since"))
+			if err != nil || since < 0 {
+				http.Error(w, "invalid since, must be a non-negative integer", http.StatusBadRequest)
+				return
+			}
+		}
+
+		c, replay, err := s.stream.subscribe(level, q.Get("module"), since)
+		if errors.Is(err, ErrTooManyLogStreams) {
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		}
+		defer s.stream.unsubscribe(c)
+
+		websocket.Server{Handler: func(conn *websocket.Conn) {
+			// the client isn't expected to send anything, we read from the
+			// connection to detect when it's closed
+			done := make(chan struct{})
+			go func() {
+				io.Copy(io.Discard, conn)
+				close(done)
+			}()
+
+			for _, line := range replay {
+				if err := websocket.Message.Send(conn, string(line)); err != nil {
+					return
+				}
+			}
+			for {
+				select {
+				case <-done:
+					return
+				case <-req.Context().Done():
+					return
+				case line := <-c.ch:
+					if err := websocket.Message.Send(conn, string(line)); err != nil {
+						return
+					}
+				}
+			}
+		}}.ServeHTTP(w, req)
+	})
+}
+
+// checkSameOrigin rejects cross-origin requests, browsers don't apply the
+// same-origin policy to WebSockets so any page could otherwise stream the logs
+// of a node the browser is authenticated with. Requests without an Origin
+// header don't come from a browser and are allowed.
+func checkSameOrigin(req *http.Request) error {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return fmt.Errorf("invalid origin %q: %w", origin, err)
+	} else if !strings.EqualFold(u.Host, req.Host) {
+		return fmt.Errorf("cross-origin request from %q not allowed", origin)
+	}
+	return nil
+}
+
+// encode returns the encoded entry, the entry is encoded the first time it's
+// sent to a client. Entries that fail to encode are dropped.
+func (e *logEntry) encode() []byte {
+	if e.enc == nil {
+		return e.line
+	}
+	buf, err := e.enc.EncodeEntry(e.ent, e.fields)
+	if err == nil {
+		e.line = append([]byte(nil), buf.Bytes()...)
+		buf.Free()
+	}
+	e.enc, e.fields = nil, nil // no longer needed
+	return e.line
+}
+
+func (c *logClient) matches(e *logEntry) bool {
+	if e.level < c.level {
+		return false
+	}
+	return c.module == "" || e.module == c.module || strings.HasPrefix(e.module, c.module+".")
+}
+
+func (ls *logStream) publish(e *logEntry) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	ls.entries[ls.next] = e
+	ls.next = (ls.next + 1) % len(ls.entries)
+	if ls.next == 0 {
+		ls.full = true
+	}
+
+	for c := range ls.clients {
+		if !c.matches(e) {
+			continue
+		}
+		line := e.encode()
+		if line == nil {
+			return
+		}
+		select {
+		case c.ch <- line:
+		default: // drop entry for slow clients
+		}
+	}
+}
+
+func (ls *logStream) subscribe(level zapcore.Level, module string, since int) (*logClient, [][]byte, error) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	if len(ls.clients) >= logStreamMaxClients {
+		return nil, nil, ErrTooManyLogStreams
+	}
+	c := &logClient{
+		level:  level,
+		module: module,
+		ch:     make(chan []byte, logStreamClientBuffer),
+	}
+	ls.clients[c] = struct{}{}
+
+	// collect the last 'since' matching entries, oldest first
+	var replay [][]byte
+	n := ls.next
+	if ls.full {
+		n = len(ls.entries)
+	}
+	for i := 1; i <= n && len(replay) < since; i++ {
+		e := ls.entries[(ls.next-i+len(ls.entries))%len(ls.entries)]
+		if !c.matches(e) {
+			continue
+		} else if line := e.encode(); line != nil {
+			replay = append(replay, line)
+		}
+	}
+	for i, j := 0, len(replay)-1; i < j; i, j = i+1, j-1 {
+		replay[i], replay[j] = replay[j], replay[i]
+	}
+	return c, replay, nil
+}
+
+func (ls *logStream) unsubscribe(c *logClient) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	delete(ls.clients, c)
+}
@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/net/websocket"
+)
+
+func TestWebSocketLogSink(t *testing.T) {
+	sink := NewWebSocketLogSink(zapcore.DebugLevel, zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), 3)
+	srv := httptest.NewServer(sink.Handler())
+	defer srv.Close()
+
+	logger := zap.New(sink)
+	worker, bus := logger.Named("worker"), logger.Named("bus")
+
+	dial := func(query string) *websocket.Conn {
+		t.Helper()
+		conn, err := websocket.Dial(strings.Replace(srv.URL, "http", "ws", 1)+"?"+query, "", srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return conn
+	}
+	receive := func(conn *websocket.Conn) (entry struct {
+		Level  string `json:"level"`
+		Logger string `json:"logger"`
+		Msg    string `json:"msg"`
+	}) {
+		t.Helper()
+		var line string
+		if err := websocket.Message.Receive(conn, &line); err != nil {
+			t.Fatal(err)
+		} else if !strings.HasSuffix(line, "\n") {
+			t.Fatalf("expected entry to be terminated by a newline, got %q", line)
+		} else if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	// log more entries than fit in the buffer
+	worker.Debug("1")
+	worker.Named("uploads").Warn("2")
+	bus.Warn("3")
+	worker.Info("4")
+
+	// assert the last matching entries are replayed oldest first, the first
+	// entry was evicted from the buffer
+	conn := dial("since=10&module=worker")
+	defer conn.Close()
+	if e := receive(conn); e.Msg != "2" || e.Logger != "worker.uploads" || e.Level != "warn" {
+		t.Fatal("unexpected entry", e)
+	} else if e := receive(conn); e.Msg != "4" {
+		t.Fatal("unexpected entry", e)
+	}
+
+	// assert live entries are filtered by level and module
+	conn2 := dial("level=warn")
+	defer conn2.Close()
+	worker.Info("5")
+	bus.Error("6")
+	if e := receive(conn); e.Msg != "5" {
+		t.Fatal("unexpected entry", e)
+	} else if e := receive(conn2); e.Msg != "6" || e.Logger != "bus" {
+		t.Fatal("unexpected entry", e)
+	}
+
+	// assert the number of clients is limited
+	for i := 0; i < logStreamMaxClients-2; i++ {
+		defer dial("").Close()
+	}
+	if resp, err := http.Get(srv.URL); err != nil {
+		t.Fatal(err)
+	} else if resp.Body.Close(); resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatal("unexpected status", resp.StatusCode)
+	}
+
+	// assert cross-origin requests are rejected
+	if _, err := websocket.Dial(strings.Replace(srv.URL, "http", "ws", 1), "", "http://example.com"); err == nil {
+		t.Fatal("expected cross-origin request to be rejected")
+	}
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req.Header.Set("Origin", "http://example.com")
+	if resp, err := http.DefaultClient.Do(req); err != nil {
+		t.Fatal(err)
+	} else if resp.Body.Close(); resp.StatusCode != http.StatusForbidden {
+		t.Fatal("unexpected status", resp.StatusCode)
+	}
+
+	// assert invalid parameters are rejected
+	if resp, err := http.Get(srv.URL + "?level=foo"); err != nil {
+		t.Fatal(err)
+	} else if resp.Body.Close(); resp.StatusCode != http.StatusBadRequest {
+		t.Fatal("unexpected status", resp.StatusCode)
+	}
+}
@@ -54,6 +54,18 @@ func (a *DataPoints) P90() float64 {
 	return a.p90
 }
 
+// PercentileOrZero returns the given percentile of the tracked data points or
+// zero if there are no data points.
+func (a *DataPoints) PercentileOrZero(percent float64) float64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	p, err := a.Percentile(percent)
+	if err != nil {
+		return 0
+	}
+	return p
+}
+
 func (a *DataPoints) Recompute() {
 	a.mu.Lock()
 	defer a.mu.Unlock()
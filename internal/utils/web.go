@@ -53,6 +53,60 @@ func Auth(password string, unauthenticatedDownloads bool) func(http.Handler) htt
 	}
 }
 
+// CORS returns a middleware that sets the CORS headers on responses to
+// requests from allowed origins. Preflight requests are answered with a 204
+// and never reach the wrapped handler, so the middleware should be the
+// outermost layer. If no origins are allowed, the handler is returned as is.
+func CORS(allowedOrigins, allowedMethods, allowedHeaders []string) func(http.Handler) http.Handler {
+	allowAll := false
+	origins := make(map[string]struct{})
+	for _, origin := range allowedOrigins {
+		if origin == "*" {
+			allowAll = true
+		}
+		origins[origin] = struct{}{}
+	}
+	methods := strings.Join(allowedMethods, ", ")
+	headers := strings.Join(allowedHeaders, ", ")
+
+	return func(h http.Handler) http.Handler {
+		if len(origins) == 0 {
+			return h
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			origin := req.Header.Get("Origin")
+			if origin == "" {
+				h.ServeHTTP(w, req)
+				return
+			}
+
+			// the allowed origin depends on the request's origin
+			w.Header().Add("Vary", "Origin")
+
+			_, allowed := origins[origin]
+			allowed = allowed || allowAll
+			preflight := req.Method == http.MethodOptions && req.Header.Get("Access-Control-Request-Method") != ""
+			if !allowed {
+				if preflight {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				h.ServeHTTP(w, req)
+				return
+			}
+
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			if preflight {
+				w.Header().Set("Access-Control-Allow-Methods", methods)
+				w.Header().Set("Access-Control-Allow-Headers", headers)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			h.ServeHTTP(w, req)
+		})
+	}
+}
+
 func ListenTCP(addr string, logger *zap.Logger) (net.Listener, error) {
 	l, err := net.Listen("tcp", addr)
 	if IsErr(err, errors.New("no such host")) && strings.Contains(addr, "localhost") {
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCORS(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	methods := []string{http.MethodGet, http.MethodPost}
+	headers := []string{"Authorization", "Content-Type"}
+
+	do := func(h http.Handler, method, origin string, preflight bool) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, "/state", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		if preflight {
+			req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// assert no headers are set if no origins are configured
+	h := CORS(nil, methods, headers)(next)
+	if rec := do(h, http.MethodGet, "http://localhost:3000", false); rec.Code != http.StatusOK {
+		t.Fatal("unexpected status", rec.Code)
+	} else if rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatal("unexpected header")
+	}
+
+	// assert explicitly allowed origins are reflected
+	h = CORS([]string{"http://localhost:3000"}, methods, headers)(next)
+	if rec := do(h, http.MethodGet, "http://localhost:3000", false); rec.Code != http.StatusOK {
+		t.Fatal("unexpected status", rec.Code)
+	} else if origin := rec.Header().Get("Access-Control-Allow-Origin"); origin != "http://localhost:3000" {
+		t.Fatal("unexpected origin", origin)
+	}
+
+	// assert other origins are not
+	if rec := do(h, http.MethodGet, "http://example.com", false); rec.Code != http.StatusOK {
+		t.Fatal("unexpected status", rec.Code)
+	} else if rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatal("unexpected header")
+	}
+
+	// assert preflight requests are handled
+	if rec := do(h, http.MethodOptions, "http://localhost:3000", true); rec.Code != http.StatusNoContent {
+		t.Fatal("unexpected status", rec.Code)
+	} else if got := rec.Header().Get("Access-Control-Allow-Methods"); got != "GET, POST" {
+		t.Fatal("unexpected methods", got)
+	} else if got := rec.Header().Get("Access-Control-Allow-Headers"); got != "Authorization, Content-Type" {
+		t.Fatal("unexpected headers", got)
+	}
+	if rec := do(h, http.MethodOptions, "http://example.com", true); rec.Code != http.StatusForbidden {
+		t.Fatal("unexpected status", rec.Code)
+	}
+
+	// assert wildcards allow all origins
+	h = CORS([]string{"*"}, methods, headers)(next)
+	if rec := do(h, http.MethodOptions, "http://example.com", true); rec.Code != http.StatusNoContent {
+		t.Fatal("unexpected status", rec.Code)
+	} else if origin := rec.Header().Get("Access-Control-Allow-Origin"); origin != "http://example.com" {
+		t.Fatal("unexpected origin", origin)
+	}
+}
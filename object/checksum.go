@@ -0,0 +1,81 @@
+package object
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/zeebo/blake3"
+	rhpv2 "go.sia.tech/core/rhp/v2"
+	"go.sia.tech/core/types"
+)
+
+// ChecksumAlgorithmBLAKE3 is an optional checksum algorithm for slabs. Slabs
+// without a checksum algorithm are verified using the BLAKE2b Merkle roots of
+// their shards.
+const ChecksumAlgorithmBLAKE3 = "blake3"
+
+// ErrChecksumMismatch is returned by VerifySlab if a shard doesn't match its
+// checksum.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// ValidateChecksumAlgorithm returns an error if the given checksum algorithm
+// is not supported.
+func ValidateChecksumAlgorithm(algorithm string) error {
+	switch algorithm {
+	case "", ChecksumAlgorithmBLAKE3:
+		return nil
+	default:
+		return fmt.Errorf("unknown checksum algorithm '%s'", algorithm)
+	}
+}
+
+// ComputeChecksums computes the checksums of the given shards using the given
+// algorithm. It returns nil if no algorithm is given since the shards are then
+// verified against their Merkle roots.
+func ComputeChecksums(algorithm string, shards [][]byte) ([]types.Hash256, error) {
+	switch algorithm {
+	case "":
+		return nil, nil
+	case ChecksumAlgorithmBLAKE3:
+		checksums := make([]types.Hash256, len(shards))
+		for i, shard := range shards {
+			checksums[i] = blake3.Sum256(shard)
+		}
+		return checksums, nil
+	default:
+		return nil, ValidateChecksumAlgorithm(algorithm)
+	}
+}
+
+// VerifySlab verifies the given sectors of a slab using the given checksum
+// algorithm. The sectors are expected to be encrypted and ordered like the
+// slab's shards. If no algorithm is given, the sectors are verified against
+// the Merkle roots of the slab's shards.
+func VerifySlab(slab Slab, algorithm string, sectors [][]byte) error {
+	if len(sectors) != len(slab.Shards) {
+		return fmt.Errorf("expected %d sectors, got %d", len(slab.Shards), len(sectors))
+	}
+
+	switch algorithm {
+	case "":
+		for i, sector := range sectors {
+			if len(sector) != rhpv2.SectorSize {
+				return fmt.Errorf("sector %d has invalid length %d", i, len(sector))
+			} else if rhpv2.SectorRoot((*[rhpv2.SectorSize]byte)(sector)) != slab.Shards[i].Root {
+				return fmt.Errorf("%w: sector %d", ErrChecksumMismatch, i)
+			}
+		}
+	case ChecksumAlgorithmBLAKE3:
+		if len(slab.Checksums) != len(sectors) {
+			return fmt.Errorf("expected %d checksums, got %d", len(sectors), len(slab.Checksums))
+		}
+		for i, sector := range sectors {
+			if types.Hash256(blake3.Sum256(sector)) != slab.Checksums[i] {
+				return fmt.Errorf("%w: sector %d", ErrChecksumMismatch, i)
+			}
+		}
+	default:
+		return ValidateChecksumAlgorithm(algorithm)
+	}
+	return nil
+}
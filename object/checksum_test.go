@@ -0,0 +1,66 @@
+package object
+
+import (
+	"errors"
+	"testing"
+
+	rhpv2 "go.sia.tech/core/rhp/v2"
+	"lukechampine.com/frand"
+)
+
+func TestVerifySlab(t *testing.T) {
+	// create a 2-of-4 slab
+	s := Slab{MinShards: 2, Shards: make([]Sector, 4)}
+	shards := make([][]byte, len(s.Shards))
+	s.Encode(frand.Bytes(2*rhpv2.SectorSize), shards)
+	for i := range shards {
+		s.Shards[i].Root = rhpv2.SectorRoot((*[rhpv2.SectorSize]byte)(shards[i]))
+	}
+
+	// slabs without a checksum algorithm are verified against their roots
+	if err := VerifySlab(s, "", shards); err != nil {
+		t.Fatal(err)
+	}
+
+	// compute the BLAKE3 checksums
+	checksums, err := ComputeChecksums(ChecksumAlgorithmBLAKE3, shards)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(checksums) != len(shards) {
+		t.Fatal("unexpected number of checksums", len(checksums))
+	}
+	s.ChecksumAlgorithm = ChecksumAlgorithmBLAKE3
+	s.Checksums = checksums
+	if err := VerifySlab(s, ChecksumAlgorithmBLAKE3, shards); err != nil {
+		t.Fatal(err)
+	}
+
+	// corrupt a shard, both algorithms should catch it
+	shards[1][0] ^= 1
+	if err := VerifySlab(s, "", shards); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatal("unexpected error", err)
+	} else if err := VerifySlab(s, ChecksumAlgorithmBLAKE3, shards); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatal("unexpected error", err)
+	}
+	shards[1][0] ^= 1
+
+	// missing checksums are an error
+	s.Checksums = s.Checksums[:1]
+	if err := VerifySlab(s, ChecksumAlgorithmBLAKE3, shards); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// unknown algorithms are an error
+	if err := VerifySlab(s, "sha256", shards); err == nil {
+		t.Fatal("expected error")
+	} else if _, err := ComputeChecksums("sha256", shards); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// no checksums are computed without an algorithm
+	if checksums, err := ComputeChecksums("", shards); err != nil {
+		t.Fatal(err)
+	} else if checksums != nil {
+		t.Fatal("expected no checksums")
+	}
+}
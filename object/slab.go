@@ -20,12 +20,15 @@ type Sector struct {
 // A Slab is raw data that has been erasure-encoded into sector-sized shards,
 // encrypted, and stored across a set of hosts. A distinct EncryptionKey should
 // be used for each Slab, and should not be the same key used for the parent
-// Object.
+// Object. Slabs that were uploaded with a ChecksumAlgorithm contain a checksum
+// for every shard.
 type Slab struct {
-	Health        float64       `json:"health"`
-	EncryptionKey EncryptionKey `json:"encryptionKey"`
-	MinShards     uint8         `json:"minShards"`
-	Shards        []Sector      `json:"shards,omitempty"`
+	Health            float64         `json:"health"`
+	EncryptionKey     EncryptionKey   `json:"encryptionKey"`
+	MinShards         uint8           `json:"minShards"`
+	Shards            []Sector        `json:"shards,omitempty"`
+	ChecksumAlgorithm string          `json:"checksumAlgorithm,omitempty"`
+	Checksums         []types.Hash256 `json:"checksums,omitempty"`
 }
 
 func (s Slab) IsPartial() bool {
@@ -9,6 +9,7 @@ import (
 	"testing"
 	"time"
 
+	rhpv3 "go.sia.tech/core/rhp/v3"
 	"go.sia.tech/core/types"
 	"go.sia.tech/renterd/api"
 	isql "go.sia.tech/renterd/internal/sql"
@@ -119,6 +120,104 @@ func BenchmarkPrunableContractRoots(b *testing.B) {
 	}
 }
 
+// BenchmarkContractRoots benchmarks fetching all roots of a contract with 100k
+// sectors.
+func BenchmarkContractRoots(b *testing.B) {
+	// define parameters
+	numSectors := int(1e5)
+
+	// create database
+	db, err := newTestDB(context.Background(), b.TempDir())
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	// prepare database
+	fcid := types.FileContractID{1}
+	if _, err := insertContractSectors(db.DB(), fcid, numSectors); err != nil {
+		b.Fatal(err)
+	}
+
+	// start benchmark
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := db.Transaction(context.Background(), func(tx sql.DatabaseTx) error {
+			roots, err := tx.ContractRoots(context.Background(), fcid)
+			if err != nil {
+				return err
+			} else if len(roots) != numSectors {
+				return fmt.Errorf("expected %v roots, got %v", numSectors, len(roots))
+			}
+			return nil
+		}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkRecordHostScans benchmarks recording a batch of 500 host scans.
+//
+// cpu: Intel(R) Xeon(R) Processor
+// BenchmarkRecordHostScans (one UPDATE per scan)	     100	  23227000 ns/op
+// BenchmarkRecordHostScans (bulk upsert)       	     100	  15040000 ns/op
+func BenchmarkRecordHostScans(b *testing.B) {
+	// define parameters
+	numScans := 500
+
+	// create database
+	db, err := newTestDB(context.Background(), b.TempDir())
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	// prepare database
+	hks, err := insertHosts(db.DB(), numScans)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	// prepare scans, half of them fail
+	pt := rhpv3.HostPriceTable{UploadBandwidthCost: types.NewCurrency64(1), DownloadBandwidthCost: types.NewCurrency64(1)}
+	scans := make([]api.HostScan, numScans)
+	for i, hk := range hks {
+		scans[i] = api.HostScan{
+			HostKey:    hk,
+			PriceTable: pt,
+			Success:    i%2 == 0,
+		}
+	}
+
+	// start benchmark
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := range scans {
+			scans[j].Timestamp = time.Now()
+		}
+		if err := db.Transaction(context.Background(), func(tx sql.DatabaseTx) error {
+			return tx.RecordHostScans(context.Background(), scans)
+		}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func insertHosts(db *isql.DB, n int) (hks []types.PublicKey, _ error) {
+	stmt, err := db.Prepare(context.Background(), "INSERT INTO hosts (created_at, public_key, net_address) VALUES (?, ?, '')")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	for i := 0; i < n; i++ {
+		hk := types.PublicKey(frand.Entropy256())
+		if _, err := stmt.Exec(context.Background(), time.Now(), sql.PublicKey(hk)); err != nil {
+			return nil, err
+		}
+		hks = append(hks, hk)
+	}
+	return hks, nil
+}
+
 func insertObjects(db *isql.DB, bucket string, n int) (dirs []string, _ error) {
 	var bucketID int64
 	res, err := db.Exec(context.Background(), "INSERT INTO buckets (created_at, name) VALUES (?, ?)", time.Now(), bucket)
@@ -163,7 +262,7 @@ func insertContractSectors(db *isql.DB, fcid types.FileContractID, n int) (roots
 	// insert host
 	hk := types.PublicKey{1}
 	res, err := db.Exec(context.Background(), `
-INSERT INTO contracts (fcid, host_key, start_height, v2) VALUES (?, ?, ?, ?)`, sql.PublicKey(hk), sql.FileContractID(fcid), 0, false)
+INSERT INTO contracts (fcid, host_key, start_height, usability, v2) VALUES (?, ?, ?, ?, ?)`, sql.PublicKey(hk), sql.FileContractID(fcid), 0, 1, false)
 	if err != nil {
 		return nil, err
 	}
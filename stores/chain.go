@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/api"
 	"go.sia.tech/renterd/stores/sql"
 )
 
@@ -24,6 +25,16 @@ func (s *SQLStore) FileContractElement(ctx context.Context, fcid types.FileContr
 	return
 }
 
+// StorageProof returns the transaction containing the storage proof of the
+// given contract.
+func (s *SQLStore) StorageProof(ctx context.Context, fcid types.FileContractID) (proof api.ContractStorageProof, err error) {
+	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+		proof, err = tx.StorageProof(ctx, fcid)
+		return err
+	})
+	return
+}
+
 // ProcessChainUpdate returns a callback function that process a chain update
 // inside a transaction.
 func (s *SQLStore) ProcessChainUpdate(ctx context.Context, applyFn func(sql.ChainUpdateTx) error) error {
@@ -124,7 +124,13 @@ func TestProcessChainUpdate(t *testing.T) {
 
 	// assert update failed contracts is successful
 	if err := ss.ProcessChainUpdate(context.Background(), func(tx sql.ChainUpdateTx) error {
-		return tx.UpdateFailedContracts(we + 1)
+		fcids, err := tx.UpdateFailedContracts(we + 1)
+		if err != nil {
+			return err
+		} else if len(fcids) != 1 || fcids[0] != fcid {
+			return fmt.Errorf("unexpected failed contracts %v", fcids)
+		}
+		return nil
 	}); err != nil {
 		t.Fatal("unexpected error", err)
 	}
@@ -490,3 +496,157 @@ func TestContractElements(t *testing.T) {
 		t.Fatal("unexpected error", err)
 	}
 }
+
+func TestProofConfirmations(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add test host and contract
+	hks, err := ss.addTestHosts(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fcids, _, err := ss.addTestContracts(hks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fcid := fcids[0]
+
+	const depth = 3
+
+	update := func(fn func(tx sql.ChainUpdateTx) error) {
+		t.Helper()
+		if err := ss.ProcessChainUpdate(context.Background(), fn); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assertConfirmations := func(expected uint64) {
+		t.Helper()
+		if c, err := ss.Contract(context.Background(), fcid); err != nil {
+			t.Fatal(err)
+		} else if c.ProofConfirmations != expected {
+			t.Fatalf("expected %d confirmations, got %d", expected, c.ProofConfirmations)
+		}
+	}
+
+	// unresolved contracts have no confirmations
+	update(func(tx sql.ChainUpdateTx) error { return tx.UpdateProofConfirmations(10, depth) })
+	assertConfirmations(0)
+
+	// resolve the contract at height 10
+	update(func(tx sql.ChainUpdateTx) error {
+		if err := tx.UpdateContractProofHeight(fcid, 10); err != nil {
+			return err
+		}
+		return tx.UpdateProofConfirmations(10, depth)
+	})
+	assertConfirmations(1)
+
+	// apply blocks, updates are idempotent
+	for i := 0; i < 2; i++ {
+		update(func(tx sql.ChainUpdateTx) error { return tx.UpdateProofConfirmations(11, depth) })
+		assertConfirmations(2)
+	}
+
+	// revert a block
+	update(func(tx sql.ChainUpdateTx) error { return tx.UpdateProofConfirmations(10, depth) })
+	assertConfirmations(1)
+
+	// apply blocks until the contract is finalized, after which the
+	// confirmations are no longer updated
+	for bh := uint64(11); bh <= 15; bh++ {
+		update(func(tx sql.ChainUpdateTx) error { return tx.UpdateProofConfirmations(bh, depth) })
+	}
+	assertConfirmations(depth)
+
+	// revert the resolution
+	update(func(tx sql.ChainUpdateTx) error { return tx.UpdateContractProofHeight(fcid, 0) })
+	assertConfirmations(0)
+}
+
+func TestStorageProofs(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add test hosts and contracts
+	hks, err := ss.addTestHosts(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fcids, _, err := ss.addTestContracts(hks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fcid1, fcid2 := fcids[0], fcids[1]
+
+	update := func(fn func(tx sql.ChainUpdateTx) error) {
+		t.Helper()
+		if err := ss.ProcessChainUpdate(context.Background(), fn); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// no proof was submitted yet
+	if _, err := ss.StorageProof(context.Background(), fcid1); !errors.Is(err, api.ErrStorageProofNotFound) {
+		t.Fatal("expected ErrStorageProofNotFound, got", err)
+	}
+
+	// record a v1 proof for the first contract and a v2 proof for the second
+	v1Proof := api.ContractStorageProof{
+		BlockHeight: 10,
+		BlockID:     types.BlockID{1},
+		Transaction: &types.Transaction{
+			StorageProofs: []types.StorageProof{{ParentID: fcid1}},
+		},
+	}
+	v2Proof := api.ContractStorageProof{
+		BlockHeight: 11,
+		BlockID:     types.BlockID{2},
+		V2Transaction: &types.V2Transaction{
+			MinerFee: types.Siacoins(1),
+		},
+	}
+	update(func(tx sql.ChainUpdateTx) error {
+		if err := tx.RecordStorageProof(fcid1, v1Proof); err != nil {
+			return err
+		}
+		return tx.RecordStorageProof(fcid2, v2Proof)
+	})
+
+	assertProof := func(fcid types.FileContractID, expected api.ContractStorageProof) {
+		t.Helper()
+		proof, err := ss.StorageProof(context.Background(), fcid)
+		if err != nil {
+			t.Fatal(err)
+		} else if proof.BlockHeight != expected.BlockHeight || proof.BlockID != expected.BlockID {
+			t.Fatalf("unexpected block %v %v", proof.BlockHeight, proof.BlockID)
+		} else if (proof.Transaction == nil) != (expected.Transaction == nil) || (proof.V2Transaction == nil) != (expected.V2Transaction == nil) {
+			t.Fatal("unexpected transaction type")
+		} else if proof.Transaction != nil && proof.Transaction.ID() != expected.Transaction.ID() {
+			t.Fatal("unexpected transaction")
+		} else if proof.V2Transaction != nil && proof.V2Transaction.ID() != expected.V2Transaction.ID() {
+			t.Fatal("unexpected v2 transaction")
+		}
+	}
+	assertProof(fcid1, v1Proof)
+	assertProof(fcid2, v2Proof)
+
+	// recording a proof again replaces the previous one
+	v1Proof.BlockHeight, v1Proof.BlockID = 12, types.BlockID{3}
+	update(func(tx sql.ChainUpdateTx) error { return tx.RecordStorageProof(fcid1, v1Proof) })
+	assertProof(fcid1, v1Proof)
+
+	// revert the first proof
+	update(func(tx sql.ChainUpdateTx) error { return tx.RemoveStorageProof(fcid1) })
+	if _, err := ss.StorageProof(context.Background(), fcid1); !errors.Is(err, api.ErrStorageProofNotFound) {
+		t.Fatal("expected ErrStorageProofNotFound, got", err)
+	}
+	assertProof(fcid2, v2Proof)
+
+	// resetting the chain state removes all proofs
+	if err := ss.ResetChainState(context.Background()); err != nil {
+		t.Fatal(err)
+	} else if _, err := ss.StorageProof(context.Background(), fcid2); !errors.Is(err, api.ErrStorageProofNotFound) {
+		t.Fatal("expected ErrStorageProofNotFound, got", err)
+	}
+}
@@ -0,0 +1,110 @@
+package stores
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.sia.tech/renterd/api"
+	"go.uber.org/zap"
+)
+
+// walSizeCheckInterval is the interval at which the size of the WAL is checked
+// to decide whether it needs to be checkpointed.
+const walSizeCheckInterval = 10 * time.Minute
+
+// compactableDB is implemented by databases that use a WAL which can be
+// compacted, i.e. SQLite databases.
+type compactableDB interface {
+	CheckpointWAL(ctx context.Context) error
+	Compact(ctx context.Context) error
+	Vacuum(ctx context.Context) error
+	WALSize(ctx context.Context) (int64, error)
+}
+
+// Compact truncates the WAL of the main and metrics database and rebuilds both
+// databases to release unused pages.
+func (s *SQLStore) Compact(ctx context.Context) error {
+	dbs := s.compactableDBs()
+	if len(dbs) == 0 {
+		return api.ErrCompactNotSupported
+	}
+	for name, db := range dbs {
+		if err := db.Compact(ctx); err != nil {
+			return fmt.Errorf("failed to compact %v database: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Vacuum optimizes the main and metrics database and rebuilds them to reclaim
+// the space left behind by deleted rows.
+func (s *SQLStore) Vacuum(ctx context.Context) error {
+	dbs := s.compactableDBs()
+	if len(dbs) == 0 {
+		return api.ErrVacuumNotSupported
+	}
+	for name, db := range dbs {
+		if err := db.Vacuum(ctx); err != nil {
+			return fmt.Errorf("failed to vacuum %v database: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// VacuumSupported returns whether any of the databases can be vacuumed.
+func (s *SQLStore) VacuumSupported() bool {
+	return len(s.compactableDBs()) > 0
+}
+
+func (s *SQLStore) compactableDBs() map[string]compactableDB {
+	dbs := make(map[string]compactableDB)
+	if db, ok := s.db.(compactableDB); ok {
+		dbs["main"] = db
+	}
+	if db, ok := s.dbMetrics.(compactableDB); ok {
+		dbs["metrics"] = db
+	}
+	return dbs
+}
+
+// compactLoop periodically checkpoints and truncates the WAL of all databases
+// whose WAL exceeds the given threshold. Rebuilding the database is too
+// expensive to run unattended and is left to Compact and Vacuum.
+func (s *SQLStore) compactLoop(threshold int64) {
+	dbs := s.compactableDBs()
+	if len(dbs) == 0 {
+		return
+	}
+
+	t := time.NewTicker(walSizeCheckInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+		case <-s.shutdownCtx.Done():
+			return
+		}
+
+		for name, db := range dbs {
+			size, err := db.WALSize(s.shutdownCtx)
+			if err != nil {
+				s.logger.Errorw("failed to fetch WAL size", "db", name, zap.Error(err))
+				continue
+			} else if size <= threshold {
+				continue
+			}
+
+			start := time.Now()
+			if err := db.CheckpointWAL(s.shutdownCtx); errors.Is(err, context.Canceled) {
+				return
+			} else if err != nil {
+				s.logger.Errorw("failed to checkpoint WAL", "db", name, zap.Error(err))
+			} else {
+				s.logger.Infow("checkpointed WAL", "db", name, "walSize", size, "elapsed", time.Since(start))
+			}
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package stores
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/api"
+	"go.sia.tech/renterd/config"
+)
+
+func TestCompact(t *testing.T) {
+	if config.MySQLConfigFromEnv().URI != "" {
+		t.Skip("compaction is only supported by SQLite")
+	}
+	ss := newTestSQLStore(t, testSQLStoreConfig{persistent: true})
+	defer ss.Close()
+
+	// helper to fetch the WAL sizes
+	walSizes := func() (sizes []int64) {
+		t.Helper()
+		for _, db := range ss.compactableDBs() {
+			size, err := db.WALSize(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			sizes = append(sizes, size)
+		}
+		if len(sizes) != 2 {
+			t.Fatalf("expected 2 compactable databases, got %v", len(sizes))
+		}
+		return
+	}
+
+	// write to both databases
+	if err := ss.CreateBucket(context.Background(), "foo", api.BucketPolicy{}); err != nil {
+		t.Fatal(err)
+	} else if err := ss.RecordWalletMetric(context.Background(), api.WalletMetric{
+		Timestamp: api.TimeRFC3339(time.Now()),
+		Confirmed: types.Siacoins(1),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// assert both WALs aren't empty
+	for _, size := range walSizes() {
+		if size == 0 {
+			t.Fatal("expected WAL to be non-empty")
+		}
+	}
+
+	// checkpoint the WALs and assert they were truncated
+	for name, db := range ss.compactableDBs() {
+		if err := db.CheckpointWAL(context.Background()); err != nil {
+			t.Fatal(err)
+		} else if size, err := db.WALSize(context.Background()); err != nil {
+			t.Fatal(err)
+		} else if size != 0 {
+			t.Fatalf("expected %v WAL to be truncated, got %v bytes", name, size)
+		}
+	}
+
+	// write to the main database again
+	if err := ss.CreateBucket(context.Background(), "bar", api.BucketPolicy{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// compact the store
+	if err := ss.Compact(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// assert both WALs were truncated
+	for _, size := range walSizes() {
+		if size != 0 {
+			t.Fatalf("expected WAL to be truncated, got %v bytes", size)
+		}
+	}
+
+	// assert the data is still there
+	if _, err := ss.Bucket(context.Background(), "foo"); err != nil {
+		t.Fatal(err)
+	} else if metrics, err := ss.WalletMetrics(context.Background(), time.UnixMilli(0), 1, time.Since(time.UnixMilli(0))+time.Hour, api.WalletMetricsQueryOpts{}); err != nil {
+		t.Fatal(err)
+	} else if len(metrics) != 1 {
+		t.Fatalf("expected 1 metric, got %v", len(metrics))
+	}
+}
+
+func TestVacuum(t *testing.T) {
+	if config.MySQLConfigFromEnv().URI != "" {
+		t.Skip("vacuum is only supported by SQLite")
+	}
+	ss := newTestSQLStore(t, testSQLStoreConfig{persistent: true})
+	defer ss.Close()
+
+	// add and remove a bucket to leave behind free pages
+	if err := ss.CreateBucket(context.Background(), "foo", api.BucketPolicy{}); err != nil {
+		t.Fatal(err)
+	} else if err := ss.CreateBucket(context.Background(), "bar", api.BucketPolicy{}); err != nil {
+		t.Fatal(err)
+	} else if err := ss.DeleteBucket(context.Background(), "bar"); err != nil {
+		t.Fatal(err)
+	}
+
+	// vacuum the store
+	if !ss.VacuumSupported() {
+		t.Fatal("expected vacuum to be supported")
+	} else if err := ss.Vacuum(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// assert both WALs were truncated
+	for name, db := range ss.compactableDBs() {
+		if size, err := db.WALSize(context.Background()); err != nil {
+			t.Fatal(err)
+		} else if size != 0 {
+			t.Fatalf("expected %v WAL to be truncated, got %v bytes", name, size)
+		}
+	}
+
+	// assert the data is still there
+	if _, err := ss.Bucket(context.Background(), "foo"); err != nil {
+		t.Fatal(err)
+	} else if _, err := ss.Bucket(context.Background(), "bar"); !errors.Is(err, api.ErrBucketNotFound) {
+		t.Fatal("expected ErrBucketNotFound", err)
+	}
+}
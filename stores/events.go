@@ -0,0 +1,291 @@
+package stores
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/api"
+	sql "go.sia.tech/renterd/stores/sql"
+	"go.sia.tech/renterd/webhooks"
+	"go.uber.org/zap"
+)
+
+const (
+	webhookModuleObject = "object"
+
+	webhookEventAdd     = "add"
+	webhookEventArchive = "archive"
+	webhookEventCreate  = "create"
+	webhookEventDelete  = "delete"
+	webhookEventRename  = "rename"
+)
+
+type (
+	// An Event describes a mutation of the metadata store, events are
+	// published to the registered EventSink after the mutation was committed.
+	Event interface {
+		isEvent()
+	}
+
+	// An EventSink receives the events emitted by the store in the order in
+	// which the mutations were committed.
+	EventSink interface {
+		Publish(ctx context.Context, e Event) error
+	}
+
+	// ObjectCreatedEvent is emitted when an object was created or overwritten.
+	ObjectCreatedEvent struct {
+		Bucket    string    `json:"bucket"`
+		Key       string    `json:"key"`
+		ETag      string    `json:"eTag"`
+		Timestamp time.Time `json:"timestamp"`
+	}
+
+	// ObjectDeletedEvent is emitted when an object was deleted. If Prefix is
+	// set, all objects with that prefix were deleted. If Glob is set, all
+	// objects matching that glob pattern were deleted. If Expired is set, the
+	// objects with that prefix that expired according to a lifecycle rule
+	// were deleted, an empty prefix matches all objects in the bucket.
+	ObjectDeletedEvent struct {
+		Bucket    string    `json:"bucket"`
+		Key       string    `json:"key,omitempty"`
+		Prefix    string    `json:"prefix,omitempty"`
+		Glob      string    `json:"glob,omitempty"`
+		Expired   bool      `json:"expired,omitempty"`
+		Timestamp time.Time `json:"timestamp"`
+	}
+
+	// ObjectRenamedEvent is emitted when an object was renamed. If Prefix and
+	// NewPrefix are set, all objects with that prefix were moved to the new
+	// prefix.
+	ObjectRenamedEvent struct {
+		Bucket    string    `json:"bucket"`
+		Key       string    `json:"key,omitempty"`
+		NewKey    string    `json:"newKey,omitempty"`
+		Prefix    string    `json:"prefix,omitempty"`
+		NewPrefix string    `json:"newPrefix,omitempty"`
+		Timestamp time.Time `json:"timestamp"`
+	}
+
+	// ContractAddedEvent is emitted when a contract was added, either through
+	// formation or renewal.
+	ContractAddedEvent struct {
+		Contract  api.ContractMetadata `json:"contract"`
+		Timestamp time.Time            `json:"timestamp"`
+	}
+
+	// ContractArchivedEvent is emitted when a contract was archived.
+	ContractArchivedEvent struct {
+		ContractID types.FileContractID `json:"contractID"`
+		Reason     string               `json:"reason"`
+		Timestamp  time.Time            `json:"timestamp"`
+	}
+)
+
+func (ObjectCreatedEvent) isEvent()    {}
+func (ObjectDeletedEvent) isEvent()    {}
+func (ObjectRenamedEvent) isEvent()    {}
+func (ContractAddedEvent) isEvent()    {}
+func (ContractArchivedEvent) isEvent() {}
+
+// ChannelEventSink is an EventSink that writes all events to a channel, it
+// blocks until the event was received or the context is done.
+type ChannelEventSink chan Event
+
+// Publish implements the EventSink interface.
+func (c ChannelEventSink) Publish(ctx context.Context, e Event) error {
+	select {
+	case c <- e:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+// WebhookEventSink is an EventSink that broadcasts all events as webhook
+// events.
+type WebhookEventSink struct {
+	b webhooks.Broadcaster
+}
+
+// NewWebhookEventSink returns an EventSink that broadcasts events using the
+// given broadcaster.
+func NewWebhookEventSink(b webhooks.Broadcaster) *WebhookEventSink {
+	return &WebhookEventSink{b: b}
+}
+
+// Publish implements the EventSink interface.
+func (s *WebhookEventSink) Publish(ctx context.Context, e Event) error {
+	var module, event string
+	switch e.(type) {
+	case ObjectCreatedEvent:
+		module, event = webhookModuleObject, webhookEventCreate
+	case ObjectDeletedEvent:
+		module, event = webhookModuleObject, webhookEventDelete
+	case ObjectRenamedEvent:
+		module, event = webhookModuleObject, webhookEventRename
+	case ContractAddedEvent:
+		module, event = api.WebhookModuleContract, webhookEventAdd
+	case ContractArchivedEvent:
+		module, event = api.WebhookModuleContract, webhookEventArchive
+	default:
+		return fmt.Errorf("unknown event type %T", e)
+	}
+	return s.b.BroadcastAction(ctx, webhooks.Event{
+		Module:  module,
+		Event:   event,
+		Payload: e,
+	})
+}
+
+// RegisterEventSink registers a sink that receives an event for every mutation
+// of the metadata store.
+func (s *SQLStore) RegisterEventSink(sink EventSink) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.eventSink = sink
+}
+
+// eventQueue orders the events emitted by the store by the commit order of the
+// mutations that emitted them. A slot in the queue is reserved at the end of a
+// transaction, while the transaction still holds its locks, and filled with
+// the events once the transaction was committed. Events are only delivered
+// once all previously reserved slots were filled.
+type eventQueue struct {
+	wakeChan chan struct{}
+
+	mu    sync.Mutex
+	head  uint64
+	next  uint64
+	slots map[uint64][]Event
+}
+
+func newEventQueue() *eventQueue {
+	return &eventQueue{
+		wakeChan: make(chan struct{}, 1),
+		slots:    make(map[uint64][]Event),
+	}
+}
+
+// reserve reserves the next slot in the queue.
+func (q *eventQueue) reserve() uint64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	seq := q.next
+	q.next++
+	return seq
+}
+
+// fill fills a reserved slot, a slot filled without events is skipped.
+func (q *eventQueue) fill(seq uint64, events []Event) {
+	q.mu.Lock()
+	q.slots[seq] = events
+	q.mu.Unlock()
+
+	select {
+	case q.wakeChan <- struct{}{}:
+	default:
+	}
+}
+
+// pop removes the events of all consecutive filled slots from the queue and
+// returns them in order.
+func (q *eventQueue) pop() (events []Event) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for {
+		slot, ok := q.slots[q.head]
+		if !ok {
+			return
+		}
+		delete(q.slots, q.head)
+		q.head++
+		events = append(events, slot...)
+	}
+}
+
+// publishEventsLoop publishes the queued events to the registered sink one by
+// one, failing to publish an event is logged but doesn't fail the operation
+// that emitted it since the mutation was already committed. A sink that panics
+// is treated the same way so it can't stop the loop.
+func (s *SQLStore) publishEventsLoop() {
+	for {
+		select {
+		case <-s.shutdownCtx.Done():
+			return
+		case <-s.events.wakeChan:
+		}
+
+		for _, e := range s.events.pop() {
+			s.mu.Lock()
+			sink := s.eventSink
+			s.mu.Unlock()
+			if sink == nil {
+				continue
+			} else if err := s.publishEvent(sink, e); err != nil {
+				s.logger.Warnw("failed to publish event", "event", fmt.Sprintf("%T", e), zap.Error(err))
+			}
+		}
+	}
+}
+
+// publishEvent publishes a single event to the given sink, a panic in the sink
+// is recovered and returned as an error.
+func (s *SQLStore) publishEvent(sink EventSink, e Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic while publishing event: %v\n%s", r, debug.Stack())
+		}
+	}()
+	return sink.Publish(s.shutdownCtx, e)
+}
+
+// publishEvents queues the given events for mutations that aren't performed in
+// a single transaction, it must be called after the last transaction of the
+// mutation was committed.
+func (s *SQLStore) publishEvents(events ...Event) {
+	s.fillEventSlot(s.events.reserve(), events)
+}
+
+// fillEventSlot fills the reserved slot with the given events, events emitted
+// while no sink is registered are dropped.
+func (s *SQLStore) fillEventSlot(seq uint64, events []Event) {
+	s.mu.Lock()
+	if s.eventSink == nil {
+		events = nil
+	}
+	s.mu.Unlock()
+	s.events.fill(seq, events)
+}
+
+// transactionWithEvents executes fn in a transaction and queues the events it
+// returns once the transaction was committed.
+func (s *SQLStore) transactionWithEvents(ctx context.Context, fn func(tx sql.DatabaseTx) ([]Event, error)) error {
+	var seq uint64
+	var reserved bool
+	var events []Event
+	err := s.db.Transaction(ctx, func(tx sql.DatabaseTx) (err error) {
+		// skip the slot of a previous attempt that failed to commit
+		if reserved {
+			s.events.fill(seq, nil)
+			reserved = false
+		}
+
+		events, err = fn(tx)
+		if err == nil && len(events) > 0 {
+			seq, reserved = s.events.reserve(), true
+		}
+		return
+	})
+	if reserved {
+		if err != nil {
+			events = nil
+		}
+		s.fillEventSlot(seq, events)
+	}
+	return err
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"time"
 
 	"go.sia.tech/core/types"
@@ -22,7 +23,6 @@ func (s *SQLStore) Host(ctx context.Context, hostKey types.PublicKey) (api.Host,
 		FilterMode:      api.HostFilterModeAll,
 		UsabilityMode:   api.UsabilityFilterModeAll,
 		KeyIn:           []types.PublicKey{hostKey},
-		Offset:          0,
 		Limit:           1,
 	})
 	if err != nil {
@@ -34,12 +34,58 @@ func (s *SQLStore) Host(ctx context.Context, hostKey types.PublicKey) (api.Host,
 	}
 }
 
+// DeleteHost permanently removes a host from the database. Its active
+// contracts are archived and its sectors are removed in the same transaction,
+// so a failure doesn't leave the host with archived contracts behind.
+func (s *SQLStore) DeleteHost(ctx context.Context, hk types.PublicKey) error {
+	err := s.transactionWithEvents(ctx, func(tx sql.DatabaseTx) ([]Event, error) {
+		contracts, err := tx.Contracts(ctx, api.ContractsOpts{FilterMode: api.ContractFilterModeActive})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch contracts: %w", err)
+		}
+		var fcids []types.FileContractID
+		for _, c := range contracts {
+			if c.HostKey == hk {
+				fcids = append(fcids, c.ID)
+			}
+		}
+
+		// invalidate the health of the affected slabs and archive the
+		// contracts
+		var events []Event
+		if len(fcids) > 0 {
+			if _, err := tx.InvalidateSlabHealthByFCID(ctx, fcids, math.MaxInt64); err != nil {
+				return nil, fmt.Errorf("failed to invalidate slab health: %w", err)
+			}
+			for _, fcid := range fcids {
+				if err := tx.ArchiveContract(ctx, fcid, api.ContractArchivalReasonHostPruned); err != nil {
+					return nil, fmt.Errorf("failed to archive contract %v: %w", fcid, err)
+				}
+				events = append(events, ContractArchivedEvent{ContractID: fcid, Reason: api.ContractArchivalReasonHostPruned, Timestamp: time.Now()})
+			}
+		}
+
+		// delete the host
+		return events, tx.DeleteHost(ctx, hk)
+	})
+	if errors.Is(err, api.ErrHostNotFound) {
+		return fmt.Errorf("%w %v", api.ErrHostNotFound, hk)
+	}
+	return err
+}
+
 func (s *SQLStore) UpdateHostCheck(ctx context.Context, hk types.PublicKey, hc api.HostChecks) (err error) {
 	return s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
 		return tx.UpdateHostCheck(ctx, hk, hc)
 	})
 }
 
+func (s *SQLStore) UpdateHostChecks(ctx context.Context, checks map[types.PublicKey]api.HostChecks) error {
+	return s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+		return tx.UpdateHostChecks(ctx, checks)
+	})
+}
+
 func (s *SQLStore) ResetLostSectors(ctx context.Context, hk types.PublicKey) error {
 	return s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
 		return tx.ResetLostSectors(ctx, hk)
@@ -60,10 +106,19 @@ func (s *SQLStore) RemoveOfflineHosts(ctx context.Context, minRecentFailures uin
 	if maxDowntime < 0 {
 		return 0, ErrNegativeMaxDowntime
 	}
-	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
-		n, err := tx.RemoveOfflineHosts(ctx, minRecentFailures, maxDowntime)
+	err = s.transactionWithEvents(ctx, func(tx sql.DatabaseTx) ([]Event, error) {
+		n, fcids, err := tx.RemoveOfflineHosts(ctx, minRecentFailures, maxDowntime)
+		if err != nil {
+			return nil, err
+		}
 		removed = uint64(n)
-		return err
+
+		now := time.Now()
+		events := make([]Event, 0, len(fcids))
+		for _, fcid := range fcids {
+			events = append(events, ContractArchivedEvent{ContractID: fcid, Reason: api.ContractArchivalReasonHostPruned, Timestamp: now})
+		}
+		return events, nil
 	})
 	return
 }
@@ -104,6 +159,41 @@ func (s *SQLStore) HostBlocklist(ctx context.Context) (blocklist []string, err e
 	return
 }
 
+// HostBenchmarks returns the most recent benchmarks of the given host.
+func (s *SQLStore) HostBenchmarks(ctx context.Context, hk types.PublicKey, limit int) (benchmarks []api.HostBenchmark, err error) {
+	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+		benchmarks, err = tx.HostBenchmarks(ctx, hk, limit)
+		return err
+	})
+	return
+}
+
+// RecordHostBenchmark adds a benchmark to the given host's benchmark history.
+func (s *SQLStore) RecordHostBenchmark(ctx context.Context, hk types.PublicKey, benchmark api.HostBenchmark) error {
+	return s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+		return tx.RecordHostBenchmark(ctx, hk, benchmark)
+	})
+}
+
+// HostScanHistory returns the most recent scans of the given host.
+func (s *SQLStore) HostScanHistory(ctx context.Context, hk types.PublicKey, limit int) (scans []api.HostScan, err error) {
+	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+		scans, err = tx.HostScanHistory(ctx, hk, limit)
+		return err
+	})
+	return
+}
+
+// HostPriceHistory returns the price changes of all hosts that changed their
+// prices since the given time.
+func (s *SQLStore) HostPriceHistory(ctx context.Context, since time.Time) (history map[types.PublicKey][]api.HostPriceChange, err error) {
+	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+		history, err = tx.HostPriceHistory(ctx, since)
+		return err
+	})
+	return
+}
+
 func (s *SQLStore) RecordHostScans(ctx context.Context, scans []api.HostScan) error {
 	return s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
 		return tx.RecordHostScans(ctx, scans)
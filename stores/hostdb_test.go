@@ -15,6 +15,7 @@ import (
 	"go.sia.tech/renterd/api"
 	"go.sia.tech/renterd/internal/gouging"
 	"go.sia.tech/renterd/internal/test"
+	"go.sia.tech/renterd/object"
 	sql "go.sia.tech/renterd/stores/sql"
 )
 
@@ -44,7 +45,6 @@ func TestSQLHostDB(t *testing.T) {
 		UsabilityMode:   api.UsabilityFilterModeAll,
 		AddressContains: "",
 		KeyIn:           nil,
-		Offset:          0,
 		Limit:           -1,
 	})
 	if err != nil {
@@ -76,7 +76,6 @@ func TestSQLHostDB(t *testing.T) {
 		UsabilityMode:   api.UsabilityFilterModeAll,
 		AddressContains: "",
 		KeyIn:           nil,
-		Offset:          0,
 		Limit:           -1,
 	})
 	if err != nil {
@@ -143,7 +142,6 @@ func TestHosts(t *testing.T) {
 		UsabilityMode:   api.UsabilityFilterModeAll,
 		AddressContains: "",
 		KeyIn:           nil,
-		Offset:          0,
 		Limit:           -1,
 	})
 	if err != nil {
@@ -152,18 +150,17 @@ func TestHosts(t *testing.T) {
 		t.Fatal("unexpected")
 	}
 
-	// assert offset & limit are taken into account
+	// assert cursor & limit are taken into account
 	his, err = ss.Hosts(context.Background(), api.HostOptions{
 		FilterMode:      api.HostFilterModeAll,
 		UsabilityMode:   api.UsabilityFilterModeAll,
 		AddressContains: "",
 		KeyIn:           nil,
-		Offset:          0,
 		Limit:           1,
 	})
 	if err != nil {
 		t.Fatal(err)
-	} else if len(his) != 1 {
+	} else if len(his) != 1 || his[0].PublicKey != hk1 {
 		t.Fatal("unexpected")
 	}
 	his, err = ss.Hosts(context.Background(), api.HostOptions{
@@ -171,12 +168,12 @@ func TestHosts(t *testing.T) {
 		UsabilityMode:   api.UsabilityFilterModeAll,
 		AddressContains: "",
 		KeyIn:           nil,
-		Offset:          1,
+		Cursor:          api.NewHostCursor(his[0].PublicKey),
 		Limit:           2,
 	})
 	if err != nil {
 		t.Fatal(err)
-	} else if len(his) != 2 {
+	} else if len(his) != 2 || his[0].PublicKey != hk2 || his[1].PublicKey != hk3 {
 		t.Fatal("unexpected")
 	}
 	his, err = ss.Hosts(context.Background(), api.HostOptions{
@@ -184,7 +181,7 @@ func TestHosts(t *testing.T) {
 		UsabilityMode:   api.UsabilityFilterModeAll,
 		AddressContains: "",
 		KeyIn:           nil,
-		Offset:          3,
+		Cursor:          api.NewHostCursor(his[1].PublicKey),
 		Limit:           1,
 	})
 	if err != nil {
@@ -193,13 +190,22 @@ func TestHosts(t *testing.T) {
 		t.Fatal("unexpected")
 	}
 
+	// assert an invalid cursor is rejected
+	if _, err := ss.Hosts(context.Background(), api.HostOptions{
+		FilterMode:    api.HostFilterModeAll,
+		UsabilityMode: api.UsabilityFilterModeAll,
+		Cursor:        "invalid",
+		Limit:         -1,
+	}); !errors.Is(err, api.ErrInvalidHostCursor) {
+		t.Fatal("unexpected error", err)
+	}
+
 	// assert address and key filters are taken into account
 	if hosts, err := ss.Hosts(ctx, api.HostOptions{
 		FilterMode:      api.HostFilterModeAll,
 		UsabilityMode:   api.UsabilityFilterModeAll,
 		AddressContains: "com:1001",
 		KeyIn:           nil,
-		Offset:          0,
 		Limit:           -1,
 	}); err != nil || len(hosts) != 1 {
 		t.Fatal("unexpected", len(hosts), err)
@@ -209,7 +215,6 @@ func TestHosts(t *testing.T) {
 		UsabilityMode:   api.UsabilityFilterModeAll,
 		AddressContains: "",
 		KeyIn:           []types.PublicKey{hk2, hk3},
-		Offset:          0,
 		Limit:           -1,
 	}); err != nil || len(hosts) != 2 {
 		t.Fatal("unexpected", len(hosts), err)
@@ -219,7 +224,6 @@ func TestHosts(t *testing.T) {
 		UsabilityMode:   api.UsabilityFilterModeAll,
 		AddressContains: "com:1002",
 		KeyIn:           []types.PublicKey{hk2, hk3},
-		Offset:          0,
 		Limit:           -1,
 	}); err != nil || len(hosts) != 1 {
 		t.Fatal("unexpected", len(hosts), err)
@@ -229,7 +233,6 @@ func TestHosts(t *testing.T) {
 		UsabilityMode:   api.UsabilityFilterModeAll,
 		AddressContains: "com:1002",
 		KeyIn:           []types.PublicKey{hk1},
-		Offset:          0,
 		Limit:           -1,
 	}); err != nil || len(hosts) != 0 {
 		t.Fatal("unexpected", len(hosts), err)
@@ -245,7 +248,6 @@ func TestHosts(t *testing.T) {
 		UsabilityMode:   api.UsabilityFilterModeAll,
 		AddressContains: "",
 		KeyIn:           nil,
-		Offset:          0,
 		Limit:           -1,
 	})
 	if err != nil {
@@ -260,7 +262,6 @@ func TestHosts(t *testing.T) {
 		UsabilityMode:   api.UsabilityFilterModeAll,
 		AddressContains: "",
 		KeyIn:           nil,
-		Offset:          0,
 		Limit:           -1,
 	})
 	if err != nil {
@@ -304,7 +305,6 @@ func TestHosts(t *testing.T) {
 		UsabilityMode:   api.UsabilityFilterModeAll,
 		AddressContains: "",
 		KeyIn:           nil,
-		Offset:          0,
 		Limit:           -1,
 	})
 	if err != nil {
@@ -326,7 +326,6 @@ func TestHosts(t *testing.T) {
 		FilterMode:      api.HostFilterModeAll,
 		AddressContains: "",
 		KeyIn:           nil,
-		Offset:          0,
 		Limit:           -1,
 	}
 
@@ -378,6 +377,82 @@ func TestHosts(t *testing.T) {
 	if cnt := checkCount(); cnt != 1 {
 		t.Fatal("unexpected", cnt)
 	}
+
+	// assert the cursor is stable when hosts are added before it
+	his, err = ss.Hosts(context.Background(), api.HostOptions{
+		FilterMode:    api.HostFilterModeAll,
+		UsabilityMode: api.UsabilityFilterModeAll,
+		Limit:         1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(his) != 1 || his[0].PublicKey != hk2 {
+		t.Fatal("unexpected")
+	} else if err := ss.addTestHost(types.PublicKey{0}); err != nil {
+		t.Fatal(err)
+	}
+	his, err = ss.Hosts(context.Background(), api.HostOptions{
+		FilterMode:    api.HostFilterModeAll,
+		UsabilityMode: api.UsabilityFilterModeAll,
+		Cursor:        api.NewHostCursor(his[0].PublicKey),
+		Limit:         2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(his) != 1 || his[0].PublicKey != hk3 {
+		t.Fatal("unexpected")
+	}
+}
+
+func TestHostAllocatedStorage(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+	ctx := context.Background()
+
+	// add a host with 4 sectors of total storage
+	hks, err := ss.addTestHosts(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hk := hks[0]
+	settings := rhpv2.HostSettings{TotalStorage: 4 * rhpv2.SectorSize}
+	if err := ss.RecordHostScans(ctx, []api.HostScan{newTestScan(hk, time.Now(), settings, rhpv3.HostPriceTable{}, true)}); err != nil {
+		t.Fatal(err)
+	}
+
+	// add a contract storing a single sector
+	c := newTestContract(types.FileContractID{1}, hk)
+	c.Size = rhpv2.SectorSize
+	if err := ss.PutContract(ctx, c); err != nil {
+		t.Fatal(err)
+	}
+
+	// assert the allocated storage is not set without a host check
+	if h, err := ss.Host(ctx, hk); err != nil {
+		t.Fatal(err)
+	} else if h.Checks.AllocatedStorage != 0 || h.Checks.AllocatedStoragePct != 0 {
+		t.Fatal("unexpected", h.Checks)
+	}
+
+	// add a host check and assert the allocated storage is computed
+	if err := ss.UpdateHostCheck(ctx, hk, newTestHostCheck()); err != nil {
+		t.Fatal(err)
+	}
+	if h, err := ss.Host(ctx, hk); err != nil {
+		t.Fatal(err)
+	} else if h.Checks.AllocatedStorage != rhpv2.SectorSize || h.Checks.AllocatedStoragePct != 25 {
+		t.Fatal("unexpected", h.Checks.AllocatedStorage, h.Checks.AllocatedStoragePct)
+	}
+
+	// archive the contract and assert the storage is no longer allocated
+	if err := ss.ArchiveContract(ctx, c.ID, api.ContractArchivalReasonRemoved); err != nil {
+		t.Fatal(err)
+	}
+	if h, err := ss.Host(ctx, hk); err != nil {
+		t.Fatal(err)
+	} else if h.Checks.AllocatedStorage != 0 || h.Checks.AllocatedStoragePct != 0 {
+		t.Fatal("unexpected", h.Checks.AllocatedStorage, h.Checks.AllocatedStoragePct)
+	}
 }
 
 func TestUsableHosts(t *testing.T) {
@@ -523,6 +598,75 @@ func TestUsableHosts(t *testing.T) {
 	assertNumUsableHosts(0)
 }
 
+func TestHostSiaMuxReachable(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+	ctx := context.Background()
+
+	// add a usable host
+	hk := types.PublicKey{1}
+	if err := ss.addCustomTestHost(hk, "foo.com:1000"); err != nil {
+		t.Fatal(err)
+	} else if err := ss.UpdateHostCheck(ctx, hk, newTestHostCheck()); err != nil {
+		t.Fatal(err)
+	} else if _, err := ss.addTestContract(types.FileContractID{1}, hk); err != nil {
+		t.Fatal(err)
+	}
+
+	recordScan := func(success bool, reachable *bool) {
+		t.Helper()
+		scan := newTestScan(hk, time.Now(), test.NewHostSettings(), test.NewHostPriceTable(), success)
+		scan.SiaMuxReachable = reachable
+		if err := ss.RecordHostScans(ctx, []api.HostScan{scan}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assertReachable := func(reachable bool, siamuxAddr string) {
+		t.Helper()
+		if h, err := ss.Host(ctx, hk); err != nil {
+			t.Fatal(err)
+		} else if h.SiaMuxReachable != reachable {
+			t.Fatalf("expected reachable to be %v", reachable)
+		}
+
+		hosts, err := ss.Hosts(ctx, api.HostOptions{FilterMode: api.HostFilterModeAll, UsabilityMode: api.UsabilityFilterModeRHP3, Limit: -1})
+		if err != nil {
+			t.Fatal(err)
+		} else if reachable && len(hosts) != 1 {
+			t.Fatal("expected host to be returned", len(hosts))
+		} else if !reachable && len(hosts) != 0 {
+			t.Fatal("expected host to be filtered", len(hosts))
+		}
+
+		usable, err := ss.UsableHosts(ctx)
+		if err != nil {
+			t.Fatal(err)
+		} else if len(usable) != 1 {
+			t.Fatal("unexpected number of usable hosts", len(usable))
+		} else if usable[0].SiamuxAddr != siamuxAddr {
+			t.Fatalf("expected siamux address %v, got %v", siamuxAddr, usable[0].SiamuxAddr)
+		}
+	}
+
+	reachable, unreachable := true, false
+
+	// scan that didn't check the siamux port
+	recordScan(true, nil)
+	assertReachable(false, "foo.com:9983")
+
+	// unreachable siamux port falls back to the net address
+	recordScan(true, &unreachable)
+	assertReachable(false, "foo.com:1000")
+
+	// scan that didn't check the port doesn't overwrite the measurement
+	recordScan(true, nil)
+	assertReachable(false, "foo.com:1000")
+
+	// reachable siamux port
+	recordScan(false, &reachable)
+	assertReachable(true, "foo.com:9983")
+}
+
 // TestRecordScan is a test for recording scans.
 func TestRecordScan(t *testing.T) {
 	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
@@ -754,6 +898,117 @@ func TestRemoveHosts(t *testing.T) {
 	}
 }
 
+func TestDeleteHost(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add two hosts with a contract each
+	hks, err := ss.addTestHosts(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hk1, hk2 := hks[0], hks[1]
+	fcids, _, err := ss.addTestContracts(hks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fcid1, fcid2 := fcids[0], fcids[1]
+
+	// renew the first host's contract
+	fcid3 := types.FileContractID{3}
+	if err := ss.renewTestContract(hk1, fcid1, fcid3, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// add an object with a sector on both hosts
+	if _, err := ss.addTestObject(t.Name(), object.Object{
+		Key: object.GenerateEncryptionKey(object.EncryptionKeyTypeSalted),
+		Slabs: []object.SlabSlice{
+			{
+				Slab: object.Slab{
+					EncryptionKey: object.GenerateEncryptionKey(object.EncryptionKeyTypeSalted),
+					MinShards:     1,
+					Shards: []object.Sector{
+						newTestShard(hk1, fcid3, types.Hash256{1}),
+						newTestShard(hk2, fcid2, types.Hash256{2}),
+					},
+				},
+			},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	} else if n := ss.Count("host_sectors"); n != 2 {
+		t.Fatal("unexpected number of host sectors", n)
+	} else if err := ss.RefreshHealth(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// register a sink
+	sink := make(ChannelEventSink, 10)
+	ss.RegisterEventSink(sink)
+
+	// delete the first host
+	if err := ss.DeleteHost(context.Background(), hk1); err != nil {
+		t.Fatal(err)
+	} else if _, err := ss.Host(context.Background(), hk1); !errors.Is(err, api.ErrHostNotFound) {
+		t.Fatal("expected host to be deleted", err)
+	} else if err := ss.DeleteHost(context.Background(), hk1); !errors.Is(err, api.ErrHostNotFound) {
+		t.Fatal("expected ErrHostNotFound", err)
+	}
+
+	// assert the active contract got archived
+	archived, err := ss.Contracts(context.Background(), api.ContractsOpts{FilterMode: api.ContractFilterModeArchived})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(archived) != 2 {
+		t.Fatal("unexpected number of archived contracts", len(archived))
+	}
+	for _, c := range archived {
+		if c.HostKey != hk1 {
+			t.Fatal("expected host key to be preserved")
+		} else if c.ID == fcid3 && c.ArchivalReason != api.ContractArchivalReasonHostPruned {
+			t.Fatal("unexpected archival reason", c.ArchivalReason)
+		}
+	}
+
+	// assert an event was published for the archived contract
+	select {
+	case e := <-sink:
+		if e, ok := e.(ContractArchivedEvent); !ok || e.ContractID != fcid3 || e.Reason != api.ContractArchivalReasonHostPruned {
+			t.Fatal("unexpected event", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected contract archived event")
+	}
+
+	// assert the health of the slab was invalidated
+	var validUntil int64
+	if err := ss.DB().QueryRow(context.Background(), "SELECT health_valid_until FROM slabs").Scan(&validUntil); err != nil {
+		t.Fatal(err)
+	} else if validUntil != 0 {
+		t.Fatal("expected slab health to be invalidated", validUntil)
+	}
+
+	// assert none of the contracts reference the deleted host
+	var n int
+	if err := ss.DB().QueryRow(context.Background(), "SELECT COUNT(*) FROM contracts WHERE host_key = ? AND host_id IS NOT NULL", sql.PublicKey(hk1)).Scan(&n); err != nil {
+		t.Fatal(err)
+	} else if n != 0 {
+		t.Fatal("expected contracts to be unlinked from the host", n)
+	}
+
+	// assert only the sector of the second host remains
+	if n := ss.Count("host_sectors"); n != 1 {
+		t.Fatal("unexpected number of host sectors", n)
+	} else if roots, err := ss.ContractRoots(context.Background(), fcid2); err != nil {
+		t.Fatal(err)
+	} else if len(roots) != 1 {
+		t.Fatal("unexpected number of roots", len(roots))
+	} else if _, err := ss.Host(context.Background(), hk2); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestSQLHostAllowlist(t *testing.T) {
 	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
 	defer ss.Close()
@@ -776,7 +1031,6 @@ func TestSQLHostAllowlist(t *testing.T) {
 			UsabilityMode:   api.UsabilityFilterModeAll,
 			AddressContains: "",
 			KeyIn:           nil,
-			Offset:          0,
 			Limit:           -1,
 		})
 		if err != nil {
@@ -863,7 +1117,6 @@ func TestSQLHostAllowlist(t *testing.T) {
 			UsabilityMode:   api.UsabilityFilterModeAll,
 			AddressContains: "",
 			KeyIn:           nil,
-			Offset:          0,
 			Limit:           -1,
 		})
 		if err != nil {
@@ -877,7 +1130,6 @@ func TestSQLHostAllowlist(t *testing.T) {
 			UsabilityMode:   api.UsabilityFilterModeAll,
 			AddressContains: "",
 			KeyIn:           nil,
-			Offset:          0,
 			Limit:           -1,
 		})
 		if err != nil {
@@ -891,7 +1143,6 @@ func TestSQLHostAllowlist(t *testing.T) {
 			UsabilityMode:   api.UsabilityFilterModeAll,
 			AddressContains: "",
 			KeyIn:           nil,
-			Offset:          0,
 			Limit:           -1,
 		})
 		if err != nil {
@@ -910,7 +1161,7 @@ func TestSQLHostAllowlist(t *testing.T) {
 	}
 
 	// remove host 1
-	if err := ss.DeleteHost(hk1); err != nil {
+	if err := ss.DeleteHost(context.Background(), hk1); err != nil {
 		t.Fatal(err)
 	}
 	if numHosts() != 0 {
@@ -972,7 +1223,6 @@ func TestSQLHostBlocklist(t *testing.T) {
 			UsabilityMode:   api.UsabilityFilterModeAll,
 			AddressContains: "",
 			KeyIn:           nil,
-			Offset:          0,
 			Limit:           -1,
 		})
 		if err != nil {
@@ -1093,7 +1343,7 @@ func TestSQLHostBlocklist(t *testing.T) {
 	}
 
 	// delete host 2 and assert the delete cascaded properly
-	if err = ss.DeleteHost(hk2); err != nil {
+	if err = ss.DeleteHost(context.Background(), hk2); err != nil {
 		t.Fatal(err)
 	}
 	if numHosts() != 2 {
@@ -1230,6 +1480,247 @@ func TestSQLHostBlocklistBasic(t *testing.T) {
 	}
 }
 
+func TestSQLHostBlocklistCIDR(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	ctx := context.Background()
+
+	isBlocked := func(hk types.PublicKey) bool {
+		t.Helper()
+		host, err := ss.Host(ctx, hk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return host.Blocked
+	}
+
+	// add three hosts, two of which are in the same subnet
+	hk1 := types.GeneratePrivateKey().PublicKey()
+	if err := ss.addCustomTestHost(hk1, "1.2.3.4:1000"); err != nil {
+		t.Fatal(err)
+	}
+	hk2 := types.GeneratePrivateKey().PublicKey()
+	if err := ss.addCustomTestHost(hk2, "1.2.200.1:2000"); err != nil {
+		t.Fatal(err)
+	}
+	hk3 := types.GeneratePrivateKey().PublicKey()
+	if err := ss.addCustomTestHost(hk3, "5.6.7.8:3000"); err != nil {
+		t.Fatal(err)
+	}
+
+	// block the subnet, the entry should be normalised
+	if err := ss.UpdateHostBlocklistEntries(ctx, []string{"1.2.3.4/16"}, nil, false); err != nil {
+		t.Fatal(err)
+	} else if bl, err := ss.HostBlocklist(ctx); err != nil {
+		t.Fatal(err)
+	} else if len(bl) != 1 || bl[0] != "1.2.0.0/16" {
+		t.Fatal("unexpected blocklist", bl)
+	} else if n := ss.Count("host_blocklist_cidr_hosts"); n != 2 {
+		t.Fatalf("unexpected number of entries in join table, %v != 2", n)
+	}
+
+	// assert hosts in the subnet are blocked
+	if !isBlocked(hk1) || !isBlocked(hk2) || isBlocked(hk3) {
+		t.Fatal("unexpected host is blocked", isBlocked(hk1), isBlocked(hk2), isBlocked(hk3))
+	}
+
+	// assert blocked hosts are filtered out
+	hosts, err := ss.Hosts(ctx, api.HostOptions{
+		FilterMode:    api.HostFilterModeAllowed,
+		UsabilityMode: api.UsabilityFilterModeAll,
+		Limit:         -1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(hosts) != 1 || hosts[0].PublicKey != hk3 {
+		t.Fatal("unexpected hosts", hosts)
+	}
+
+	// reannounce host 3 within the subnet and host 1 outside of it
+	if err := ss.addCustomTestHost(hk3, "1.2.5.6:3000"); err != nil {
+		t.Fatal(err)
+	} else if err := ss.addCustomTestHost(hk1, "4.3.2.1:1000"); err != nil {
+		t.Fatal(err)
+	} else if isBlocked(hk1) || !isBlocked(hk2) || !isBlocked(hk3) {
+		t.Fatal("unexpected host is blocked", isBlocked(hk1), isBlocked(hk2), isBlocked(hk3))
+	}
+
+	// add a host that announced a hostname, it's blocked once a scan resolves
+	// its address to an IP within the subnet
+	hk4 := types.GeneratePrivateKey().PublicKey()
+	if err := ss.addCustomTestHost(hk4, "host.com:4000"); err != nil {
+		t.Fatal(err)
+	} else if isBlocked(hk4) {
+		t.Fatal("expected host to not be blocked")
+	}
+	scan := newTestScan(hk4, time.Now(), rhpv2.HostSettings{}, rhpv3.HostPriceTable{}, true)
+	scan.ResolvedAddresses = []string{"1.2.7.8"}
+	if err := ss.RecordHostScans(ctx, []api.HostScan{scan}); err != nil {
+		t.Fatal(err)
+	} else if !isBlocked(hk4) {
+		t.Fatal("expected host to be blocked")
+	}
+
+	// assert the resolved address is unblocked if it changes
+	scan.ResolvedAddresses = []string{"4.3.2.1"}
+	if err := ss.RecordHostScans(ctx, []api.HostScan{scan}); err != nil {
+		t.Fatal(err)
+	} else if isBlocked(hk4) {
+		t.Fatal("expected host to be unblocked")
+	}
+
+	// assert the resolved address is considered when blocking a new subnet
+	if err := ss.UpdateHostBlocklistEntries(ctx, []string{"4.3.0.0/16"}, nil, false); err != nil {
+		t.Fatal(err)
+	} else if !isBlocked(hk4) {
+		t.Fatal("expected host to be blocked")
+	} else if err := ss.UpdateHostBlocklistEntries(ctx, nil, []string{"4.3.0.0/16"}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// remove the subnet using its original notation
+	if err := ss.UpdateHostBlocklistEntries(ctx, nil, []string{"1.2.3.4/16"}, false); err != nil {
+		t.Fatal(err)
+	} else if isBlocked(hk1) || isBlocked(hk2) || isBlocked(hk3) || isBlocked(hk4) {
+		t.Fatal("unexpected host is blocked", isBlocked(hk1), isBlocked(hk2), isBlocked(hk3), isBlocked(hk4))
+	} else if n := ss.Count("host_blocklist_cidr_hosts"); n != 0 {
+		t.Fatalf("unexpected number of entries in join table, %v != 0", n)
+	}
+
+	// add the subnet again and clear the blocklist
+	if err := ss.UpdateHostBlocklistEntries(ctx, []string{"1.2.0.0/16"}, nil, false); err != nil {
+		t.Fatal(err)
+	} else if !isBlocked(hk2) {
+		t.Fatal("expected host to be blocked")
+	} else if err := ss.UpdateHostBlocklistEntries(ctx, nil, nil, true); err != nil {
+		t.Fatal(err)
+	} else if isBlocked(hk2) {
+		t.Fatal("expected host to be unblocked")
+	} else if n := ss.Count("host_blocklist_cidrs"); n != 0 {
+		t.Fatalf("unexpected number of CIDRs, %v != 0", n)
+	}
+}
+
+func TestHostPriceHistory(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+	ctx := context.Background()
+
+	hks, err := ss.addTestHosts(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hk1, hk2 := hks[0], hks[1]
+
+	// record a couple of scans, only price changes should be recorded
+	pt := test.NewHostPriceTable()
+	now := time.Now().Round(time.Millisecond)
+	scan := func(hk types.PublicKey, ts time.Time, pt rhpv3.HostPriceTable) {
+		t.Helper()
+		if err := ss.RecordHostScans(ctx, []api.HostScan{newTestScan(hk, ts, test.NewHostSettings(), pt, true)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	scan(hk1, now.Add(-3*time.Hour), pt)
+	scan(hk1, now.Add(-2*time.Hour), pt)
+	pt2 := pt
+	pt2.UploadBandwidthCost = pt.UploadBandwidthCost.Mul64(2)
+	scan(hk1, now.Add(-time.Hour), pt2)
+	scan(hk2, now.Add(-3*time.Hour), pt)
+	scan(hk2, now.Add(-time.Hour), pt)
+
+	// assert only hk1 has changes within the last 90 minutes and the change
+	// before that is included
+	history, err := ss.HostPriceHistory(ctx, now.Add(-90*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	} else if len(history) != 1 {
+		t.Fatal("unexpected history", history)
+	} else if changes := history[hk1]; len(changes) != 2 {
+		t.Fatal("unexpected changes", changes)
+	} else if !changes[0].Timestamp.Equal(now.Add(-3*time.Hour)) || !changes[0].UploadPrice.Equals(pt.UploadBandwidthCost) {
+		t.Fatal("unexpected change", changes[0])
+	} else if !changes[1].Timestamp.Equal(now.Add(-time.Hour)) || !changes[1].UploadPrice.Equals(pt2.UploadBandwidthCost) || !changes[1].DownloadPrice.Equals(pt.DownloadBandwidthCost) {
+		t.Fatal("unexpected change", changes[1])
+	}
+
+	// assert no hosts changed their prices within the last 30 minutes
+	if history, err := ss.HostPriceHistory(ctx, now.Add(-30*time.Minute)); err != nil {
+		t.Fatal(err)
+	} else if len(history) != 0 {
+		t.Fatal("unexpected history", history)
+	}
+}
+
+func TestHostScanHistory(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+	ctx := context.Background()
+
+	hks, err := ss.addTestHosts(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hk1, hk2 := hks[0], hks[1]
+
+	// assert unknown hosts return an error
+	if _, err := ss.HostScanHistory(ctx, types.PublicKey{9}, -1); !errors.Is(err, api.ErrHostNotFound) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// record a successful and a failed scan for hk1 and a scan for hk2
+	now := time.Now().Round(time.Millisecond)
+	pt := test.NewHostPriceTable()
+	settings := test.NewHostSettings()
+	success := newTestScan(hk1, now.Add(-time.Hour), settings, pt, true)
+	success.Latency = api.DurationMS(time.Second)
+	success.RPCLatency = api.DurationMS(100 * time.Millisecond)
+	failure := newTestScan(hk1, now, settings, pt, false)
+	failure.Error = "host unreachable"
+	if err := ss.RecordHostScans(ctx, []api.HostScan{success, failure, newTestScan(hk2, now, settings, pt, true)}); err != nil {
+		t.Fatal(err)
+	}
+
+	// assert the history is ordered from newest to oldest
+	scans, err := ss.HostScanHistory(ctx, hk1, -1)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(scans) != 2 {
+		t.Fatalf("expected 2 scans, got %v", len(scans))
+	}
+
+	// assert the failed scan has an error but no settings
+	if s := scans[0]; s.Success || s.Error != "host unreachable" || !s.Timestamp.Equal(now) {
+		t.Fatalf("unexpected scan %+v", s)
+	} else if s.Settings != (rhpv2.HostSettings{}) || s.PriceTable != (rhpv3.HostPriceTable{}) {
+		t.Fatal("expected no settings for failed scan")
+	}
+
+	// assert the successful scan has a latency, settings and price table
+	if s := scans[1]; !s.Success || s.Error != "" || s.Latency != api.DurationMS(time.Second) || s.RPCLatency != api.DurationMS(100*time.Millisecond) || !s.Timestamp.Equal(now.Add(-time.Hour)) {
+		t.Fatalf("unexpected scan %+v", s)
+	} else if s.Settings.NetAddress != settings.NetAddress || s.PriceTable.UID != pt.UID {
+		t.Fatal("unexpected settings or price table")
+	}
+
+	// assert the limit is applied
+	if scans, err := ss.HostScanHistory(ctx, hk1, 1); err != nil {
+		t.Fatal(err)
+	} else if len(scans) != 1 || !scans[0].Timestamp.Equal(now) {
+		t.Fatal("unexpected scans", scans)
+	}
+
+	// assert scans older than the retention are pruned
+	if err := ss.RecordHostScans(ctx, []api.HostScan{newTestScan(hk1, now.Add(7*24*time.Hour), settings, pt, true)}); err != nil {
+		t.Fatal(err)
+	} else if scans, err := ss.HostScanHistory(ctx, hk1, -1); err != nil {
+		t.Fatal(err)
+	} else if len(scans) != 2 {
+		t.Fatalf("expected 2 scans, got %v", len(scans))
+	}
+}
+
 // newTestScan returns a host interaction with given parameters.
 func newTestScan(hk types.PublicKey, scanTime time.Time, settings rhpv2.HostSettings, pt rhpv3.HostPriceTable, success bool) api.HostScan {
 	return api.HostScan{
@@ -1241,6 +1732,76 @@ func newTestScan(hk types.PublicKey, scanTime time.Time, settings rhpv2.HostSett
 	}
 }
 
+func TestUpdateHostChecks(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+	ctx := context.Background()
+
+	hks, err := ss.addTestHosts(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hk1, hk2, hk3 := hks[0], hks[1], hks[2]
+
+	// update the checks of two hosts in a single batch
+	hc1 := newTestHostCheck()
+	hc1.ScoreBreakdown.Age = .11
+	hc2 := newTestHostCheck()
+	hc2.ScoreBreakdown.Age = .22
+	if err := ss.UpdateHostChecks(ctx, map[types.PublicKey]api.HostChecks{
+		hk1: hc1,
+		hk2: hc2,
+	}); err != nil {
+		t.Fatal(err)
+	} else if cnt := ss.Count("host_checks"); cnt != 2 {
+		t.Fatal("unexpected number of host checks", cnt)
+	}
+
+	// assert the checks were stored
+	assertChecks := func(hk types.PublicKey, expected api.HostChecks) {
+		t.Helper()
+		h, err := ss.Host(ctx, hk)
+		if err != nil {
+			t.Fatal(err)
+		} else if h.Checks != expected {
+			t.Fatalf("unexpected checks for host %v: %+v", hk, h.Checks)
+		}
+	}
+	assertChecks(hk1, hc1)
+	assertChecks(hk2, hc2)
+
+	// update an existing check and add a new one
+	hc1.UsabilityBreakdown.Offline = true
+	hc3 := newTestHostCheck()
+	if err := ss.UpdateHostChecks(ctx, map[types.PublicKey]api.HostChecks{
+		hk1: hc1,
+		hk3: hc3,
+	}); err != nil {
+		t.Fatal(err)
+	} else if cnt := ss.Count("host_checks"); cnt != 3 {
+		t.Fatal("unexpected number of host checks", cnt)
+	}
+	assertChecks(hk1, hc1)
+	assertChecks(hk2, hc2)
+	assertChecks(hk3, hc3)
+
+	// assert a batch containing an unknown host is rejected as a whole
+	hc2.UsabilityBreakdown.Gouging = true
+	if err := ss.UpdateHostChecks(ctx, map[types.PublicKey]api.HostChecks{
+		hk2:                hc2,
+		types.PublicKey{9}: newTestHostCheck(),
+	}); err == nil {
+		t.Fatal("expected error")
+	}
+	hc2.UsabilityBreakdown.Gouging = false
+	assertChecks(hk2, hc2)
+
+	// assert an empty batch is a no-op
+	if err := ss.UpdateHostChecks(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func newTestHostCheck() api.HostChecks {
 	return api.HostChecks{
 
@@ -1270,6 +1831,9 @@ func newTestHostCheck() api.HostChecks {
 			NotAnnounced:          false,
 			NotCompletingScan:     false,
 		},
+		FormationBackoffCount: 2,
+		UnstablePricing:       true,
+		GeoScore:              .8,
 	}
 }
 
@@ -1308,7 +1872,57 @@ func (s *testSQLStore) announceHost(hk types.PublicKey, na string) error {
 	})
 }
 
-func (s *testSQLStore) DeleteHost(hk types.PublicKey) error {
-	_, err := s.DB().Exec(context.Background(), "DELETE FROM hosts WHERE public_key = ?", sql.PublicKey(hk))
-	return err
+func TestHostBenchmarks(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+	ctx := context.Background()
+
+	hks, err := ss.addTestHosts(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hk := hks[0]
+
+	// assert unknown hosts return an error
+	if err := ss.RecordHostBenchmark(ctx, types.PublicKey{9}, api.HostBenchmark{Timestamp: time.Now()}); !errors.Is(err, api.ErrHostNotFound) {
+		t.Fatal("unexpected error", err)
+	} else if _, err := ss.HostBenchmarks(ctx, types.PublicKey{9}, -1); !errors.Is(err, api.ErrHostNotFound) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// record two benchmarks
+	now := time.Now().Round(time.Millisecond)
+	b1 := api.HostBenchmark{Timestamp: now.Add(-time.Hour), UploadMBps: 1.5, DownloadMBps: 2.5, TTFB: api.DurationMS(time.Second)}
+	b2 := api.HostBenchmark{Timestamp: now, UploadMBps: 3, DownloadMBps: 4, TTFB: api.DurationMS(time.Millisecond)}
+	for _, b := range []api.HostBenchmark{b1, b2} {
+		if err := ss.RecordHostBenchmark(ctx, hk, b); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// assert the benchmarks are ordered from newest to oldest
+	benchmarks, err := ss.HostBenchmarks(ctx, hk, -1)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(benchmarks) != 2 {
+		t.Fatalf("expected 2 benchmarks, got %v", len(benchmarks))
+	} else if b := benchmarks[0]; !b.Timestamp.Equal(b2.Timestamp) || b.UploadMBps != b2.UploadMBps || b.DownloadMBps != b2.DownloadMBps || b.TTFB != b2.TTFB {
+		t.Fatalf("unexpected benchmark %+v", b)
+	} else if b := benchmarks[1]; !b.Timestamp.Equal(b1.Timestamp) || b.UploadMBps != b1.UploadMBps || b.DownloadMBps != b1.DownloadMBps || b.TTFB != b1.TTFB {
+		t.Fatalf("unexpected benchmark %+v", b)
+	}
+
+	// assert the limit is applied
+	if benchmarks, err := ss.HostBenchmarks(ctx, hk, 1); err != nil {
+		t.Fatal(err)
+	} else if len(benchmarks) != 1 || !benchmarks[0].Timestamp.Equal(now) {
+		t.Fatalf("unexpected benchmarks %+v", benchmarks)
+	}
+
+	// assert the benchmark isn't recorded as a scan
+	if h, err := ss.Host(ctx, hk); err != nil {
+		t.Fatal(err)
+	} else if h.Interactions.TotalScans != 0 {
+		t.Fatal("unexpected scans", h.Interactions.TotalScans)
+	}
 }
@@ -0,0 +1,116 @@
+package stores
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	sql "go.sia.tech/renterd/stores/sql"
+	"go.uber.org/zap"
+)
+
+// ApplyLifecycleRules removes all objects in the bucket that expired according
+// to the bucket's enabled lifecycle rules and returns the number of removed
+// objects.
+// Objects are removed in batches to avoid holding a write lock for too long.
+// If versioning is enabled on the bucket, expired objects are kept as
+// noncurrent versions and a delete marker is added instead.
+func (s *SQLStore) ApplyLifecycleRules(ctx context.Context, bucket string) (int, error) {
+	b, err := s.Bucket(ctx, bucket)
+	if err != nil {
+		return 0, err
+	}
+
+	var removed int64
+	var events []Event
+	for _, rule := range b.Policy.LifecycleRules {
+		if rule.Disabled {
+			continue
+		}
+
+		var ruleRemoved int64
+		createdBefore := time.Now().Add(-rule.Expiry())
+		batchSizeIdx := 0
+		for {
+			start := time.Now()
+			var deleted int64
+			if err := s.db.Transaction(ctx, func(tx sql.DatabaseTx) (err error) {
+				if !b.Policy.Versioning {
+					deleted, err = tx.DeleteExpiredObjects(ctx, bucket, rule.Prefix, createdBefore, objectDeleteBatchSizes[batchSizeIdx])
+					return err
+				}
+				keys, err := tx.ExpiredObjects(ctx, bucket, rule.Prefix, createdBefore, objectDeleteBatchSizes[batchSizeIdx])
+				if err != nil {
+					return err
+				}
+				deleted = int64(len(keys))
+				return archiveObjects(ctx, tx, bucket, keys)
+			}); err != nil {
+				return int(removed + ruleRemoved), fmt.Errorf("failed to delete expired objects: %w", err)
+			} else if deleted == 0 {
+				break // nothing more to delete
+			}
+			ruleRemoved += deleted
+
+			// increase the batch size if deletion was faster than the threshold
+			if time.Since(start) < batchDurationThreshold && batchSizeIdx < len(objectDeleteBatchSizes)-1 {
+				batchSizeIdx++
+			}
+		}
+		if ruleRemoved > 0 {
+			removed += ruleRemoved
+			events = append(events, ObjectDeletedEvent{Bucket: bucket, Prefix: rule.Prefix, Expired: true, Timestamp: time.Now()})
+		}
+	}
+	if removed > 0 {
+		if !b.Policy.Versioning {
+			s.triggerSlabPruning()
+		}
+		s.publishEvents(events...)
+	}
+	return int(removed), nil
+}
+
+// applyLifecycleRulesLoop applies the lifecycle rules of all buckets on
+// startup and periodically thereafter, applying them on startup ensures that
+// restarting more often than the interval doesn't prevent objects from
+// expiring.
+func (s *SQLStore) applyLifecycleRulesLoop(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		s.applyAllLifecycleRules()
+
+		select {
+		case <-t.C:
+		case <-s.shutdownCtx.Done():
+			return
+		}
+	}
+}
+
+// applyAllLifecycleRules applies the lifecycle rules of all buckets.
+func (s *SQLStore) applyAllLifecycleRules() {
+	buckets, err := s.Buckets(s.shutdownCtx)
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			s.logger.Errorw("failed to fetch buckets", zap.Error(err))
+		}
+		return
+	}
+	for _, b := range buckets {
+		if len(b.Policy.LifecycleRules) == 0 {
+			continue
+		}
+		n, err := s.ApplyLifecycleRules(s.shutdownCtx, b.Name)
+		if errors.Is(err, context.Canceled) {
+			return
+		} else if err != nil {
+			s.logger.Errorw("failed to apply lifecycle rules", "bucket", b.Name, zap.Error(err))
+		} else if n > 0 {
+			s.logger.Infow("removed expired objects", "bucket", b.Name, "removed", n)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package stores
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.sia.tech/renterd/api"
+)
+
+func TestApplyLifecycleRules(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+	ctx := context.Background()
+
+	// create a bucket that expires objects in 'logs/' after a day and all
+	// other objects after a week, the rule for 'tmp/' is disabled
+	const bucket = "lifecycle"
+	if err := ss.CreateBucket(ctx, bucket, api.BucketPolicy{
+		LifecycleRules: []api.LifecycleRule{
+			{Prefix: "/logs/", ExpiryDays: 1},
+			{Prefix: "/tmp/", ExpiryDays: 1, Disabled: true},
+			{Prefix: "", ExpiryDays: 7},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// register a sink
+	sink := make(ChannelEventSink, 10)
+	ss.RegisterEventSink(sink)
+
+	// add objects with varying ages
+	for key, age := range map[string]time.Duration{
+		"/logs/old":   48 * time.Hour,
+		"/logs/new":   time.Hour,
+		"/data/old":   8 * 24 * time.Hour,
+		"/data/young": 48 * time.Hour,
+		"/tmp/old":    48 * time.Hour,
+	} {
+		if err := ss.UpdateObject(ctx, bucket, key, testETag, testMimeType, testMetadata, newTestObject(1), false); err != nil {
+			t.Fatal(err)
+		} else if _, err := ss.DB().Exec(ctx, "UPDATE objects SET created_at = ? WHERE object_id = ?", time.Now().Add(-age), key); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// apply the rules
+	if n, err := ss.ApplyLifecycleRules(ctx, bucket); err != nil {
+		t.Fatal(err)
+	} else if n != 2 {
+		t.Fatalf("expected 2 removed objects, got %v", n)
+	}
+
+	// assert the expired objects were removed
+	for key, exists := range map[string]bool{
+		"/logs/old":   false,
+		"/logs/new":   true,
+		"/data/old":   false,
+		"/data/young": true,
+		"/tmp/old":    true,
+	} {
+		_, err := ss.Object(ctx, bucket, key)
+		if exists && err != nil {
+			t.Fatal(err)
+		} else if !exists && !errors.Is(err, api.ErrObjectNotFound) {
+			t.Fatalf("expected %v to be removed, got %v", key, err)
+		}
+	}
+
+	// assert an event was published for every rule that removed objects
+	var deleted []ObjectDeletedEvent
+	for len(deleted) < 2 {
+		select {
+		case e := <-sink:
+			if e, ok := e.(ObjectDeletedEvent); ok {
+				deleted = append(deleted, e)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("expected object deleted events", deleted)
+		}
+	}
+	if deleted[0].Prefix != "/logs/" || !deleted[0].Expired {
+		t.Fatal("unexpected event", deleted[0])
+	} else if deleted[1].Prefix != "" || !deleted[1].Expired {
+		t.Fatal("unexpected event", deleted[1])
+	}
+
+	// applying the rules again is a no-op
+	if n, err := ss.ApplyLifecycleRules(ctx, bucket); err != nil {
+		t.Fatal(err)
+	} else if n != 0 {
+		t.Fatalf("expected 0 removed objects, got %v", n)
+	}
+
+	// create a versioned bucket that expires all objects after a day
+	const versionedBucket = "lifecycle-versioned"
+	if err := ss.CreateBucket(ctx, versionedBucket, api.BucketPolicy{
+		Versioning:     true,
+		LifecycleRules: []api.LifecycleRule{{Prefix: "/", ExpiryDays: 1}},
+	}); err != nil {
+		t.Fatal(err)
+	} else if err := ss.UpdateObject(ctx, versionedBucket, "/old", testETag, testMimeType, testMetadata, newTestObject(1), false); err != nil {
+		t.Fatal(err)
+	} else if _, err := ss.DB().Exec(ctx, "UPDATE objects SET created_at = ? WHERE object_id = ?", time.Now().Add(-48*time.Hour), "/old"); err != nil {
+		t.Fatal(err)
+	}
+
+	// assert the expired object is archived rather than removed
+	if n, err := ss.ApplyLifecycleRules(ctx, versionedBucket); err != nil {
+		t.Fatal(err)
+	} else if n != 1 {
+		t.Fatalf("expected 1 removed object, got %v", n)
+	} else if _, err := ss.Object(ctx, versionedBucket, "/old"); !errors.Is(err, api.ErrObjectNotFound) {
+		t.Fatal("expected ErrObjectNotFound", err)
+	} else if versions, err := ss.ObjectVersions(ctx, versionedBucket, "/old"); err != nil {
+		t.Fatal(err)
+	} else if len(versions) != 2 || !versions[0].IsDeleteMarker || versions[1].IsDeleteMarker {
+		t.Fatalf("expected a delete marker and a noncurrent version, got %+v", versions)
+	}
+
+	// unknown buckets are rejected
+	if _, err := ss.ApplyLifecycleRules(ctx, "unknown"); !errors.Is(err, api.ErrBucketNotFound) {
+		t.Fatal("expected ErrBucketNotFound", err)
+	}
+}
@@ -18,6 +18,10 @@ import (
 )
 
 const (
+	// archivalMaxLostObjects is the maximum number of objects listed in the
+	// error returned when a safe archival would render objects inaccessible.
+	archivalMaxLostObjects = 10
+
 	// batchDurationThreshold is the upper bound for the duration of a batch
 	// operation on the database. As long as we are below the threshold, we
 	// increase the batch size.
@@ -88,29 +92,88 @@ func (s *SQLStore) ObjectsStats(ctx context.Context, opts api.ObjectsStatsOpts)
 	return resp, err
 }
 
+// ObjectsStatsPerHost returns the number of sectors and their size stored on
+// every host, optionally filtered by bucket.
+func (s *SQLStore) ObjectsStatsPerHost(ctx context.Context, opts api.ObjectsStatsOpts) (stats map[types.PublicKey]api.HostStorageStats, _ error) {
+	err := s.db.Transaction(ctx, func(tx sql.DatabaseTx) (err error) {
+		stats, err = tx.ObjectsStatsPerHost(ctx, opts)
+		return
+	})
+	return stats, err
+}
+
 func (s *SQLStore) SlabBuffers(ctx context.Context) ([]api.SlabBuffer, error) {
 	return s.slabBufferMgr.SlabBuffers(), nil
 }
 
+// DefragSlabBuffer merges incomplete slab buffers into other incomplete
+// buffers with enough space left and returns the number of merged buffers.
+func (s *SQLStore) DefragSlabBuffer(ctx context.Context) (int, error) {
+	merged, moved, err := s.slabBufferMgr.DefragSlabBuffer(ctx)
+	s.slabBufferDefragRuns.Add(1)
+	s.slabBufferBytesOptimized.Add(uint64(moved))
+	return merged, err
+}
+
+// FlushSlabBuffers marks all incomplete slab buffers that were created before
+// the given time as complete so they are handed out by PackedSlabsForUpload.
+func (s *SQLStore) FlushSlabBuffers(ctx context.Context, createdBefore time.Time) ([]api.EventSlabBufferFlush, error) {
+	return s.slabBufferMgr.FlushBuffers(createdBefore), nil
+}
+
+// SlabBufferDefragStats returns the number of slab buffer defragmentation runs
+// and the number of bytes that were moved between buffers since the store was
+// created.
+func (s *SQLStore) SlabBufferDefragStats() (runs, bytesOptimized uint64) {
+	return s.slabBufferDefragRuns.Load(), s.slabBufferBytesOptimized.Load()
+}
+
+func (s *SQLStore) defragSlabBufferLoop(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+		case <-s.shutdownCtx.Done():
+			return
+		}
+
+		if n, err := s.DefragSlabBuffer(s.shutdownCtx); err != nil {
+			s.logger.Errorw("slab buffer defragmentation failed", zap.Error(err))
+		} else if n > 0 {
+			s.logger.Infow("defragmented slab buffer", "merged", n)
+		}
+	}
+}
+
 func (s *SQLStore) AddRenewal(ctx context.Context, c api.ContractMetadata) error {
-	return s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+	return s.transactionWithEvents(ctx, func(tx sql.DatabaseTx) ([]Event, error) {
 		// fetch renewed contract
 		renewed, err := tx.Contract(ctx, c.RenewedFrom)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		// insert renewal by updating the renewed contract
 		err = tx.UpdateContract(ctx, c.RenewedFrom, c)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		// reinsert renewed contract
 		renewed.ArchivalReason = api.ContractArchivalReasonRenewed
 		renewed.RenewedTo = c.ID
 		renewed.Usability = api.ContractUsabilityBad
-		return tx.PutContract(ctx, renewed)
+		if err := tx.PutContract(ctx, renewed); err != nil {
+			return nil, err
+		}
+
+		now := time.Now()
+		return []Event{
+			ContractArchivedEvent{ContractID: c.RenewedFrom, Reason: api.ContractArchivalReasonRenewed, Timestamp: now},
+			ContractAddedEvent{Contract: c, Timestamp: now},
+		}, nil
 	})
 }
 
@@ -140,8 +203,11 @@ func (s *SQLStore) ArchiveContracts(ctx context.Context, toArchive map[types.Fil
 
 		// archive the contract but don't interrupt the process if one contract
 		// fails
-		if err := s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
-			return tx.ArchiveContract(ctx, fcid, reason)
+		if err := s.transactionWithEvents(ctx, func(tx sql.DatabaseTx) ([]Event, error) {
+			if err := tx.ArchiveContract(ctx, fcid, reason); err != nil {
+				return nil, err
+			}
+			return []Event{ContractArchivedEvent{ContractID: fcid, Reason: reason, Timestamp: time.Now()}}, nil
 		}); err != nil {
 			errs = append(errs, fmt.Sprintf("%v: %v", fcid, err))
 			continue
@@ -153,16 +219,64 @@ func (s *SQLStore) ArchiveContracts(ctx context.Context, toArchive map[types.Fil
 	return nil
 }
 
-func (s *SQLStore) ArchiveAllContracts(ctx context.Context, reason string) error {
-	contracts, err := s.Contracts(ctx, api.ContractsOpts{})
-	if err != nil {
-		return fmt.Errorf("failed to fetch contracts: %w", err)
-	}
-	toArchive := make(map[types.FileContractID]string)
-	for _, c := range contracts {
-		toArchive[c.ID] = reason
+// ArchiveAllContracts archives all active contracts. If safe is true, the
+// contracts are only archived if doing so doesn't render any objects
+// inaccessible. The check and the archival happen in the same transaction to
+// make sure no objects become inaccessible in between.
+func (s *SQLStore) ArchiveAllContracts(ctx context.Context, reason string, safe bool) error {
+	if !safe {
+		contracts, err := s.Contracts(ctx, api.ContractsOpts{})
+		if err != nil {
+			return fmt.Errorf("failed to fetch contracts: %w", err)
+		}
+		toArchive := make(map[types.FileContractID]string)
+		for _, c := range contracts {
+			toArchive[c.ID] = reason
+		}
+		return s.ArchiveContracts(ctx, toArchive)
 	}
-	return s.ArchiveContracts(ctx, toArchive)
+
+	return s.transactionWithEvents(ctx, func(tx sql.DatabaseTx) ([]Event, error) {
+		contracts, err := tx.Contracts(ctx, api.ContractsOpts{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch contracts: %w", err)
+		}
+		fcids := make([]types.FileContractID, 0, len(contracts))
+		for _, c := range contracts {
+			fcids = append(fcids, c.ID)
+		}
+
+		// check whether archiving the contracts renders any objects
+		// inaccessible
+		lost, err := tx.ObjectsLostOnArchival(ctx, fcids, archivalMaxLostObjects+1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check for objects lost on archival: %w", err)
+		} else if len(lost) > 0 {
+			paths := make([]string, 0, len(lost))
+			for i, om := range lost {
+				if i == archivalMaxLostObjects {
+					paths = append(paths, "...")
+					break
+				}
+				paths = append(paths, fmt.Sprintf("%s/%s", om.Bucket, strings.TrimPrefix(om.Key, "/")))
+			}
+			return nil, fmt.Errorf("%w: %s", api.ErrArchivalDataLoss, strings.Join(paths, ", "))
+		}
+
+		// invalidate the health of the affected slabs and archive the
+		// contracts
+		if _, err := tx.InvalidateSlabHealthByFCID(ctx, fcids, math.MaxInt64); err != nil {
+			return nil, fmt.Errorf("failed to invalidate slab health: %w", err)
+		}
+		events := make([]Event, 0, len(fcids))
+		for _, fcid := range fcids {
+			if err := tx.ArchiveContract(ctx, fcid, reason); err != nil {
+				return nil, fmt.Errorf("failed to archive contract %v: %w", fcid, err)
+			}
+			events = append(events, ContractArchivedEvent{ContractID: fcid, Reason: reason, Timestamp: time.Now()})
+		}
+		return events, nil
+	})
 }
 
 func (s *SQLStore) Contract(ctx context.Context, id types.FileContractID) (cm api.ContractMetadata, err error) {
@@ -182,6 +296,15 @@ func (s *SQLStore) Contracts(ctx context.Context, opts api.ContractsOpts) ([]api
 	return contracts, err
 }
 
+func (s *SQLStore) ContractsExpiring(ctx context.Context, withinBlocks uint64) ([]api.ContractMetadata, error) {
+	var contracts []api.ContractMetadata
+	err := s.db.Transaction(ctx, func(tx sql.DatabaseTx) (err error) {
+		contracts, err = tx.ContractsExpiring(ctx, withinBlocks)
+		return
+	})
+	return contracts, err
+}
+
 func (s *SQLStore) ContractRoots(ctx context.Context, id types.FileContractID) (roots []types.Hash256, err error) {
 	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
 		roots, err = tx.ContractRoots(ctx, id)
@@ -207,8 +330,11 @@ func (s *SQLStore) ContractSize(ctx context.Context, id types.FileContractID) (c
 }
 
 func (s *SQLStore) PutContract(ctx context.Context, c api.ContractMetadata) error {
-	return s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
-		return tx.PutContract(ctx, c)
+	return s.transactionWithEvents(ctx, func(tx sql.DatabaseTx) ([]Event, error) {
+		if err := tx.PutContract(ctx, c); err != nil {
+			return nil, err
+		}
+		return []Event{ContractAddedEvent{Contract: c, Timestamp: time.Now()}}, nil
 	})
 }
 
@@ -307,6 +433,15 @@ func (s *SQLStore) RecordContractSpending(ctx context.Context, records []api.Con
 			if !newSpending.SectorRoots.IsZero() {
 				updates.SectorRoots = m.SectorRootsSpending
 			}
+			if !newSpending.AccountFundingFees.IsZero() {
+				updates.AccountFundingFees = contract.Spending.AccountFundingFees.Add(newSpending.AccountFundingFees)
+			}
+			if !newSpending.PriceTableFees.IsZero() {
+				updates.PriceTableFees = contract.Spending.PriceTableFees.Add(newSpending.PriceTableFees)
+			}
+			if !newSpending.SectorUploadFees.IsZero() {
+				updates.SectorUploadFees = contract.Spending.SectorUploadFees.Add(newSpending.SectorUploadFees)
+			}
 			return tx.RecordContractSpending(ctx, fcid, latestValues[fcid].revision, latestValues[fcid].size, updates)
 		})
 		if err != nil {
@@ -322,23 +457,69 @@ func (s *SQLStore) RecordContractSpending(ctx context.Context, records []api.Con
 }
 
 func (s *SQLStore) RenameObject(ctx context.Context, bucket, keyOld, keyNew string, force bool) error {
-	return s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+	return s.transactionWithEvents(ctx, func(tx sql.DatabaseTx) ([]Event, error) {
+		// make sure an existing object at the destination is archived
+		// rather than deleted in versioned buckets
+		var versioned bool
+		if force && keyOld != keyNew {
+			_, v, err := replaceObject(ctx, tx, bucket, keyNew)
+			if err != nil && !errors.Is(err, api.ErrBucketNotFound) {
+				return nil, fmt.Errorf("RenameObject: failed to replace object: %w", err)
+			}
+			versioned = v
+		} else if b, err := tx.Bucket(ctx, bucket); err == nil {
+			versioned = b.Policy.Versioning
+		}
 		err := tx.RenameObject(ctx, bucket, keyOld, keyNew, force)
 		if err != nil {
-			return err
+			return nil, err
+		}
+
+		// the object's history moves along with it
+		if versioned && keyOld != keyNew {
+			if err := tx.RenameObjectVersions(ctx, bucket, keyOld, keyNew); err != nil {
+				return nil, err
+			}
 		}
 		s.triggerSlabPruning()
-		return nil
+		return []Event{ObjectRenamedEvent{Bucket: bucket, Key: keyOld, NewKey: keyNew, Timestamp: time.Now()}}, nil
 	})
 }
 
+// RenameObjects renames all objects with the given prefix to the new prefix. If
+// versioning is enabled on the bucket, overwritten objects are archived rather
+// than deleted and the history of the renamed objects is moved along with
+// them.
 func (s *SQLStore) RenameObjects(ctx context.Context, bucket, prefixOld, prefixNew string, force bool) error {
-	return s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+	return s.transactionWithEvents(ctx, func(tx sql.DatabaseTx) ([]Event, error) {
+		var keys []string
+		if b, err := tx.Bucket(ctx, bucket); err == nil && b.Policy.Versioning && prefixOld != prefixNew {
+			keys, err = tx.ObjectKeys(ctx, bucket, prefixOld, math.MaxInt64)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// archive the objects that would be overwritten
+		if force {
+			for _, key := range keys {
+				if _, err := tx.ArchiveObject(ctx, bucket, prefixNew+strings.TrimPrefix(key, prefixOld)); err != nil {
+					return nil, fmt.Errorf("RenameObjects: failed to archive object: %w", err)
+				}
+			}
+		}
 		if err := tx.RenameObjects(ctx, bucket, prefixOld, prefixNew, force); err != nil {
-			return err
+			return nil, err
+		}
+
+		// move the history of the renamed objects
+		for _, key := range keys {
+			if err := tx.RenameObjectVersions(ctx, bucket, key, prefixNew+strings.TrimPrefix(key, prefixOld)); err != nil {
+				return nil, err
+			}
 		}
 		s.triggerSlabPruning()
-		return nil
+		return []Event{ObjectRenamedEvent{Bucket: bucket, Prefix: prefixOld, NewPrefix: prefixNew, Timestamp: time.Now()}}, nil
 	})
 }
 
@@ -350,16 +531,35 @@ func (s *SQLStore) AddPartialSlab(ctx context.Context, data []byte, minShards, t
 	return s.slabBufferMgr.AddPartialSlab(ctx, data, minShards, totalShards)
 }
 
-func (s *SQLStore) CopyObject(ctx context.Context, srcBucket, dstBucket, srcPath, dstPath, mimeType string, metadata api.ObjectUserMetadata) (om api.ObjectMetadata, err error) {
-	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+// CopyObject copies an object, overwriting the destination if it exists. The
+// copy is performed in a single transaction so a failed copy leaves the
+// destination untouched.
+func (s *SQLStore) CopyObject(ctx context.Context, srcBucket, dstBucket, srcPath, dstPath, mimeType string, metadata api.ObjectUserMetadata, ifSourceETagMatch string) (om api.ObjectMetadata, err error) {
+	err = s.transactionWithEvents(ctx, func(tx sql.DatabaseTx) ([]Event, error) {
+		if ifSourceETagMatch != "" {
+			src, err := tx.ObjectMetadata(ctx, srcBucket, srcPath)
+			if err != nil {
+				return nil, err
+			} else if src.ETag != ifSourceETagMatch {
+				return nil, fmt.Errorf("%w: source object has ETag '%s'", api.ErrPreconditionFailed, src.ETag)
+			}
+		}
+		var versioned bool
 		if srcBucket != dstBucket || srcPath != dstPath {
-			_, err = tx.DeleteObject(ctx, dstBucket, dstPath)
+			_, versioned, err = replaceObject(ctx, tx, dstBucket, dstPath)
 			if err != nil {
-				return fmt.Errorf("CopyObject: failed to delete object: %w", err)
+				return nil, fmt.Errorf("CopyObject: failed to replace object: %w", err)
 			}
 		}
 		om, err = tx.CopyObject(ctx, srcBucket, dstBucket, srcPath, dstPath, mimeType, metadata)
-		return err
+		if err != nil {
+			return nil, err
+		} else if versioned {
+			if _, err := tx.NewObjectVersion(ctx, dstBucket, dstPath); err != nil {
+				return nil, fmt.Errorf("failed to version object: %w", err)
+			}
+		}
+		return []Event{ObjectCreatedEvent{Bucket: dstBucket, Key: dstPath, ETag: om.ETag, Timestamp: time.Now()}}, nil
 	})
 	return
 }
@@ -372,7 +572,20 @@ func (s *SQLStore) DeleteHostSector(ctx context.Context, hk types.PublicKey, roo
 	return
 }
 
-func (s *SQLStore) UpdateObject(ctx context.Context, bucket, key, eTag, mimeType string, metadata api.ObjectUserMetadata, o object.Object) error {
+// FindAlternativeContracts returns the ids of all active contracts that store
+// the sector with the given root.
+func (s *SQLStore) FindAlternativeContracts(ctx context.Context, root types.Hash256) (fcids []types.FileContractID, err error) {
+	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+		fcids, err = tx.FindAlternativeContracts(ctx, root)
+		return err
+	})
+	return
+}
+
+// UpdateObject stores the given object, replacing any existing object with the
+// same key. If ifNotExists is true, the object is only stored if no object
+// with the same key exists yet, otherwise api.ErrObjectExists is returned.
+func (s *SQLStore) UpdateObject(ctx context.Context, bucket, key, eTag, mimeType string, metadata api.ObjectUserMetadata, o object.Object, ifNotExists bool) error {
 	// Sanity check input.
 	for _, s := range o.Slabs {
 		for i, shard := range s.Shards {
@@ -385,7 +598,7 @@ func (s *SQLStore) UpdateObject(ctx context.Context, bucket, key, eTag, mimeType
 
 	// UpdateObject is ACID.
 	var prune bool
-	err := s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+	err := s.transactionWithEvents(ctx, func(tx sql.DatabaseTx) ([]Event, error) {
 		// Try to delete. We want to get rid of the object and its slices if it
 		// exists.
 		//
@@ -396,18 +609,42 @@ func (s *SQLStore) UpdateObject(ctx context.Context, bucket, key, eTag, mimeType
 		// NOTE: the metadata is not deleted because this delete will cascade,
 		// if we stop recreating the object we have to make sure to delete the
 		// object's metadata before trying to recreate it
-		var err error
-		prune, err = tx.DeleteObject(ctx, bucket, key)
+		//
+		// NOTE: if versioning is enabled on the bucket, the object is archived
+		// instead so it remains available as a noncurrent version
+		//
+		// NOTE: if ifNotExists is set, nothing is deleted and the insert below
+		// fails if the object already exists
+		b, err := tx.Bucket(ctx, bucket)
 		if err != nil {
-			return fmt.Errorf("UpdateObject: failed to delete object: %w", err)
+			return nil, err
+		} else if !ifNotExists && b.Policy.Versioning {
+			_, err = tx.ArchiveObject(ctx, bucket, key)
+			if err != nil {
+				return nil, fmt.Errorf("UpdateObject: failed to archive object: %w", err)
+			}
+		} else if !ifNotExists {
+			prune, err = tx.DeleteObject(ctx, bucket, key)
+			if err != nil {
+				return nil, fmt.Errorf("UpdateObject: failed to delete object: %w", err)
+			}
 		}
 
 		// Insert a new object.
-		err = tx.InsertObject(ctx, bucket, key, o, mimeType, eTag, metadata)
-		if err != nil {
-			return fmt.Errorf("failed to insert object: %w", err)
+		err = tx.InsertObject(ctx, bucket, key, o, mimeType, eTag, metadata, ifNotExists)
+		if errors.Is(err, api.ErrObjectExists) {
+			return nil, err
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to insert object: %w", err)
 		}
-		return nil
+
+		// Version it if necessary.
+		if b.Policy.Versioning {
+			if _, err := tx.NewObjectVersion(ctx, bucket, key); err != nil {
+				return nil, fmt.Errorf("failed to version object: %w", err)
+			}
+		}
+		return []Event{ObjectCreatedEvent{Bucket: bucket, Key: key, ETag: eTag, Timestamp: time.Now()}}, nil
 	})
 	if err != nil {
 		return err
@@ -418,22 +655,103 @@ func (s *SQLStore) UpdateObject(ctx context.Context, bucket, key, eTag, mimeType
 	return nil
 }
 
+// RemoveObject removes the object with the given key. If versioning is
+// enabled on the bucket, the object is kept as a noncurrent version and a
+// delete marker is added instead.
 func (s *SQLStore) RemoveObject(ctx context.Context, bucket, key string) error {
-	var prune bool
-	err := s.db.Transaction(ctx, func(tx sql.DatabaseTx) (err error) {
-		prune, err = tx.DeleteObject(ctx, bucket, key)
-		return
+	var deleted, prune bool
+	err := s.transactionWithEvents(ctx, func(tx sql.DatabaseTx) ([]Event, error) {
+		b, err := tx.Bucket(ctx, bucket)
+		if errors.Is(err, api.ErrBucketNotFound) {
+			return nil, nil // object doesn't exist either
+		} else if err != nil {
+			return nil, err
+		} else if !b.Policy.Versioning {
+			deleted, err = tx.DeleteObject(ctx, bucket, key)
+			prune = deleted
+		} else if deleted, err = tx.ArchiveObject(ctx, bucket, key); err == nil && deleted {
+			_, err = tx.InsertDeleteMarker(ctx, bucket, key)
+		}
+		if err != nil || !deleted {
+			return nil, err
+		}
+		return []Event{ObjectDeletedEvent{Bucket: bucket, Key: key, Timestamp: time.Now()}}, nil
 	})
 	if err != nil {
 		return fmt.Errorf("RemoveObject: failed to delete object: %w", err)
-	} else if !prune {
+	} else if !deleted {
 		return fmt.Errorf("%w: key: %s", api.ErrObjectNotFound, key)
+	} else if prune {
+		s.triggerSlabPruning()
+	}
+	return nil
+}
+
+// RemoveObjectVersion permanently removes the version of an object with the
+// given version ID, regardless of whether it's the current version, a
+// noncurrent version or a delete marker.
+func (s *SQLStore) RemoveObjectVersion(ctx context.Context, bucket, key, versionID string) error {
+	var deleted bool
+	err := s.transactionWithEvents(ctx, func(tx sql.DatabaseTx) (_ []Event, err error) {
+		deleted, err = tx.DeleteObjectVersion(ctx, bucket, key, versionID)
+		if err != nil || !deleted {
+			return nil, err
+		}
+		return []Event{ObjectDeletedEvent{Bucket: bucket, Key: key, Timestamp: time.Now()}}, nil
+	})
+	if err != nil {
+		return fmt.Errorf("RemoveObjectVersion: failed to delete object version: %w", err)
+	} else if !deleted {
+		return fmt.Errorf("%w: key: %s, version: %s", api.ErrObjectNotFound, key, versionID)
 	}
 	s.triggerSlabPruning()
 	return nil
 }
 
+// replaceObject makes room for a new object with the given key. If versioning
+// is enabled on the bucket, the existing object is archived and versioned is
+// true, in which case the new object has to be versioned once it's inserted.
+// Otherwise the existing object is deleted and prune indicates whether it
+// existed.
+func replaceObject(ctx context.Context, tx sql.DatabaseTx, bucket, key string) (prune, versioned bool, err error) {
+	b, err := tx.Bucket(ctx, bucket)
+	if err != nil {
+		return false, false, err
+	} else if b.Policy.Versioning {
+		_, err = tx.ArchiveObject(ctx, bucket, key)
+		return false, true, err
+	}
+	prune, err = tx.DeleteObject(ctx, bucket, key)
+	return prune, false, err
+}
+
+// archiveObjects archives the current version of the objects with the given
+// keys and adds a delete marker for each of them, it's used instead of
+// deleting objects in buckets with versioning enabled.
+func archiveObjects(ctx context.Context, tx sql.DatabaseTx, bucket string, keys []string) error {
+	for _, key := range keys {
+		if archived, err := tx.ArchiveObject(ctx, bucket, key); err != nil {
+			return fmt.Errorf("failed to archive object %q: %w", key, err)
+		} else if !archived {
+			continue
+		} else if _, err := tx.InsertDeleteMarker(ctx, bucket, key); err != nil {
+			return fmt.Errorf("failed to insert delete marker for object %q: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// RemoveObjects removes all objects whose key starts with the given prefix. If
+// versioning is enabled on the bucket, the objects are kept as noncurrent
+// versions and a delete marker is added for each of them instead.
 func (s *SQLStore) RemoveObjects(ctx context.Context, bucket, prefix string) error {
+	b, err := s.Bucket(ctx, bucket)
+	if errors.Is(err, api.ErrBucketNotFound) {
+		return fmt.Errorf("%w: prefix: %s", api.ErrObjectNotFound, prefix)
+	} else if err != nil {
+		return err
+	}
+
 	var prune bool
 	batchSizeIdx := 0
 	for {
@@ -441,8 +759,16 @@ func (s *SQLStore) RemoveObjects(ctx context.Context, bucket, prefix string) err
 		var done bool
 		var duration time.Duration
 		if err := s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
-			deleted, err := tx.DeleteObjects(ctx, bucket, prefix, objectDeleteBatchSizes[batchSizeIdx])
-			if err != nil {
+			var deleted bool
+			if b.Policy.Versioning {
+				keys, err := tx.ObjectKeys(ctx, bucket, prefix, objectDeleteBatchSizes[batchSizeIdx])
+				if err != nil {
+					return err
+				} else if err := archiveObjects(ctx, tx, bucket, keys); err != nil {
+					return err
+				}
+				deleted = len(keys) > 0
+			} else if deleted, err = tx.DeleteObjects(ctx, bucket, prefix, objectDeleteBatchSizes[batchSizeIdx]); err != nil {
 				return err
 			}
 			prune = prune || deleted
@@ -462,11 +788,63 @@ func (s *SQLStore) RemoveObjects(ctx context.Context, bucket, prefix string) err
 	}
 	if !prune {
 		return fmt.Errorf("%w: prefix: %s", api.ErrObjectNotFound, prefix)
+	} else if !b.Policy.Versioning {
+		s.triggerSlabPruning()
 	}
-	s.triggerSlabPruning()
+	s.publishEvents(ObjectDeletedEvent{Bucket: bucket, Prefix: prefix, Timestamp: time.Now()})
 	return nil
 }
 
+// RemoveObjectsGlob removes all objects in the bucket whose key matches the
+// given glob pattern and returns the number of removed objects. Objects are
+// removed in batches to avoid holding a write lock for too long. If versioning
+// is enabled on the bucket, the objects are kept as noncurrent versions and a
+// delete marker is added for each of them instead.
+func (s *SQLStore) RemoveObjectsGlob(ctx context.Context, bucket, glob string) (int, error) {
+	b, err := s.Bucket(ctx, bucket)
+	if errors.Is(err, api.ErrBucketNotFound) {
+		return 0, nil // objects don't exist either
+	} else if err != nil {
+		return 0, err
+	}
+
+	var removed int64
+	batchSizeIdx := 0
+	for {
+		start := time.Now()
+		var deleted int64
+		if err := s.db.Transaction(ctx, func(tx sql.DatabaseTx) (err error) {
+			if !b.Policy.Versioning {
+				deleted, err = tx.DeleteObjectsGlob(ctx, bucket, glob, objectDeleteBatchSizes[batchSizeIdx])
+				return err
+			}
+			keys, err := tx.ObjectKeysGlob(ctx, bucket, glob, objectDeleteBatchSizes[batchSizeIdx])
+			if err != nil {
+				return err
+			}
+			deleted = int64(len(keys))
+			return archiveObjects(ctx, tx, bucket, keys)
+		}); err != nil {
+			return int(removed), fmt.Errorf("failed to delete objects: %w", err)
+		} else if deleted == 0 {
+			break // nothing more to delete
+		}
+		removed += deleted
+
+		// increase the batch size if deletion was faster than the threshold
+		if time.Since(start) < batchDurationThreshold && batchSizeIdx < len(objectDeleteBatchSizes)-1 {
+			batchSizeIdx++
+		}
+	}
+	if removed > 0 {
+		if !b.Policy.Versioning {
+			s.triggerSlabPruning()
+		}
+		s.publishEvents(ObjectDeletedEvent{Bucket: bucket, Glob: glob, Timestamp: time.Now()})
+	}
+	return int(removed), nil
+}
+
 func (s *SQLStore) Slab(ctx context.Context, key object.EncryptionKey) (slab object.Slab, err error) {
 	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
 		slab, err = tx.Slab(ctx, key)
@@ -504,15 +882,24 @@ func (s *SQLStore) RefreshHealth(ctx context.Context) error {
 	}
 }
 
+// RecordSlabFailure records that the migration of the slab with the given key
+// failed.
+func (s *SQLStore) RecordSlabFailure(ctx context.Context, key object.EncryptionKey) error {
+	return s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+		return tx.RecordSlabFailure(ctx, key)
+	})
+}
+
 // UnhealthySlabs returns up to 'limit' slabs that do not reach full redundancy.
 // These slabs need to be migrated to good contracts so they are restored to
-// full health.
-func (s *SQLStore) UnhealthySlabs(ctx context.Context, healthCutoff float64, limit int) (slabs []api.UnhealthySlab, err error) {
+// full health. Slabs that failed to migrate within 'maxLastFailure' are
+// skipped, a zero duration disables that filter.
+func (s *SQLStore) UnhealthySlabs(ctx context.Context, healthCutoff float64, maxLastFailure time.Duration, limit int) (slabs []api.UnhealthySlab, err error) {
 	if limit <= -1 {
 		limit = math.MaxInt
 	}
 	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
-		slabs, err = tx.UnhealthySlabs(ctx, healthCutoff, limit)
+		slabs, err = tx.UnhealthySlabs(ctx, healthCutoff, maxLastFailure, limit)
 		return err
 	})
 	return
@@ -527,6 +914,51 @@ func (s *SQLStore) ObjectMetadata(ctx context.Context, bucket, key string) (obj
 	return
 }
 
+// ObjectTags returns the tags of the object with given key.
+func (s *SQLStore) ObjectTags(ctx context.Context, bucket, key string) (tags api.ObjectTags, err error) {
+	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+		tags, err = tx.ObjectTags(ctx, bucket, key)
+		return err
+	})
+	return
+}
+
+// ObjectVersion returns the version of an object with given version ID.
+func (s *SQLStore) ObjectVersion(ctx context.Context, bucket, key, versionID string) (obj api.Object, err error) {
+	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+		obj, err = tx.ObjectVersion(ctx, bucket, key, versionID)
+		return err
+	})
+	return
+}
+
+// ObjectVersions returns all versions of the object with given key, ordered
+// from newest to oldest.
+func (s *SQLStore) ObjectVersions(ctx context.Context, bucket, key string) (versions []api.ObjectVersion, err error) {
+	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+		versions, err = tx.ObjectVersions(ctx, bucket, key)
+		return err
+	})
+	return
+}
+
+// ObjectsTags returns the tags of the objects with given keys, objects without
+// tags are omitted from the result.
+func (s *SQLStore) ObjectsTags(ctx context.Context, bucket string, keys []string) (tags map[string]api.ObjectTags, err error) {
+	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+		tags, err = tx.ObjectsTags(ctx, bucket, keys)
+		return err
+	})
+	return
+}
+
+// UpdateObjectTags replaces the tags of the object with given key.
+func (s *SQLStore) UpdateObjectTags(ctx context.Context, bucket, key string, tags api.ObjectTags) error {
+	return s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+		return tx.UpdateObjectTags(ctx, bucket, key, tags)
+	})
+}
+
 // PackedSlabsForUpload returns up to 'limit' packed slabs that are ready for
 // uploading. They are locked for 'lockingDuration' time before being handed out
 // again.
@@ -38,7 +38,7 @@ func (s *testSQLStore) InsertSlab(slab object.Slab) {
 			},
 		},
 	}
-	err := s.UpdateObject(context.Background(), testBucket, "/"+hex.EncodeToString(frand.Bytes(16)), "", "", api.ObjectUserMetadata{}, obj)
+	err := s.UpdateObject(context.Background(), testBucket, "/"+hex.EncodeToString(frand.Bytes(16)), "", "", api.ObjectUserMetadata{}, obj, false)
 	if err != nil {
 		s.t.Fatal(err)
 	}
@@ -87,7 +87,7 @@ func (s *SQLStore) UpdateObjectBlocking(ctx context.Context, bucket, path, eTag,
 		ts = time.Now()
 		time.Sleep(time.Millisecond)
 	}
-	if err := s.UpdateObject(ctx, bucket, path, eTag, mimeType, metadata, o); err != nil {
+	if err := s.UpdateObject(ctx, bucket, path, eTag, mimeType, metadata, o, false); err != nil {
 		return err
 	}
 	return s.waitForPruneLoop(ts)
@@ -342,7 +342,314 @@ func TestObjectMetadata(t *testing.T) {
 	}
 }
 
+func TestObjectTags(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add two objects
+	for _, key := range []string{"/foo", "/bar"} {
+		if _, err := ss.addTestObject(key, newTestObject(1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// assert tagging a missing object fails
+	if err := ss.UpdateObjectTags(context.Background(), testBucket, "/baz", api.ObjectTags{"k": "v"}); !errors.Is(err, api.ErrObjectNotFound) {
+		t.Fatal("unexpected error", err)
+	} else if _, err := ss.ObjectTags(context.Background(), testBucket, "/baz"); !errors.Is(err, api.ErrObjectNotFound) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// tag an object and assert the tags are returned
+	tags := api.ObjectTags{"foo": "bar", "baz": "qux"}
+	if err := ss.UpdateObjectTags(context.Background(), testBucket, "/foo", tags); err != nil {
+		t.Fatal(err)
+	} else if got, err := ss.ObjectTags(context.Background(), testBucket, "/foo"); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(got, tags) {
+		t.Fatal("unexpected tags", got)
+	}
+
+	// assert tags are replaced
+	tags = api.ObjectTags{"foo": "updated"}
+	if err := ss.UpdateObjectTags(context.Background(), testBucket, "/foo", tags); err != nil {
+		t.Fatal(err)
+	} else if got, err := ss.ObjectTags(context.Background(), testBucket, "/foo"); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(got, tags) {
+		t.Fatal("unexpected tags", got)
+	}
+
+	// assert tags are fetched in bulk, objects without tags are omitted
+	if got, err := ss.ObjectsTags(context.Background(), testBucket, []string{"/foo", "/bar"}); err != nil {
+		t.Fatal(err)
+	} else if len(got) != 1 || !reflect.DeepEqual(got["/foo"], tags) {
+		t.Fatal("unexpected tags", got)
+	}
+
+	// assert tags CASCADE on object delete
+	if err := ss.RemoveObjectBlocking(context.Background(), testBucket, "/foo"); err != nil {
+		t.Fatal(err)
+	} else if cnt := ss.Count("object_tags"); cnt != 0 {
+		t.Fatal("unexpected number of tags", cnt)
+	}
+}
+
+func TestObjectVersioning(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// create a bucket with versioning enabled
+	bucket := "versioned"
+	if err := ss.CreateBucket(context.Background(), bucket, api.BucketPolicy{Versioning: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	// upload the same object twice, no slabs are pruned so we don't block
+	for _, eTag := range []string{"etag1", "etag2"} {
+		if err := ss.UpdateObject(context.Background(), bucket, "/foo", eTag, testMimeType, testMetadata, newTestObject(1), false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// assert both versions are returned, newest first
+	versions, err := ss.ObjectVersions(context.Background(), bucket, "/foo")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(versions) != 2 {
+		t.Fatal("unexpected number of versions", len(versions))
+	} else if !versions[0].IsLatest || versions[0].ETag != "etag2" || versions[0].VersionID == "" {
+		t.Fatal("unexpected version", versions[0])
+	} else if versions[1].IsLatest || versions[1].ETag != "etag1" || versions[1].VersionID == "" {
+		t.Fatal("unexpected version", versions[1])
+	}
+
+	// assert the current object is the latest version
+	if obj, err := ss.Object(context.Background(), bucket, "/foo"); err != nil {
+		t.Fatal(err)
+	} else if obj.VersionID != versions[0].VersionID || obj.ETag != "etag2" {
+		t.Fatal("unexpected object", obj.VersionID, obj.ETag)
+	}
+
+	// assert the noncurrent version can be fetched
+	if obj, err := ss.ObjectVersion(context.Background(), bucket, "/foo", versions[1].VersionID); err != nil {
+		t.Fatal(err)
+	} else if obj.ObjectMetadata.Key != "/foo" || obj.ETag != "etag1" || obj.VersionID != versions[1].VersionID || len(obj.Slabs) != 1 {
+		t.Fatal("unexpected object", obj.ObjectMetadata.Key, obj.ETag, obj.VersionID)
+	}
+
+	// assert noncurrent versions are neither listed nor counted
+	if resp, err := ss.Objects(context.Background(), bucket, "", "", "", "", "", "", -1, object.EncryptionKey{}); err != nil {
+		t.Fatal(err)
+	} else if len(resp.Objects) != 1 {
+		t.Fatal("unexpected number of objects", len(resp.Objects))
+	} else if stats, err := ss.ObjectsStats(context.Background(), api.ObjectsStatsOpts{Bucket: bucket}); err != nil {
+		t.Fatal(err)
+	} else if stats.NumObjects != 1 {
+		t.Fatal("unexpected number of objects", stats.NumObjects)
+	}
+
+	// remove the object and assert a delete marker is added
+	if err := ss.RemoveObject(context.Background(), bucket, "/foo"); err != nil {
+		t.Fatal(err)
+	} else if _, err := ss.Object(context.Background(), bucket, "/foo"); !errors.Is(err, api.ErrObjectNotFound) {
+		t.Fatal("unexpected error", err)
+	} else if versions, err = ss.ObjectVersions(context.Background(), bucket, "/foo"); err != nil {
+		t.Fatal(err)
+	} else if len(versions) != 3 {
+		t.Fatal("unexpected number of versions", len(versions))
+	} else if !versions[0].IsDeleteMarker || !versions[0].IsLatest {
+		t.Fatal("expected latest version to be a delete marker", versions[0])
+	} else if _, err := ss.ObjectVersion(context.Background(), bucket, "/foo", versions[0].VersionID); !errors.Is(err, api.ErrObjectNotFound) {
+		t.Fatal("unexpected error", err)
+	} else if obj, err := ss.ObjectVersion(context.Background(), bucket, "/foo", versions[1].VersionID); err != nil {
+		t.Fatal(err)
+	} else if obj.ETag != "etag2" {
+		t.Fatal("unexpected object", obj.ETag)
+	}
+
+	// delete the delete marker and assert the previous version is restored
+	if err := ss.RemoveObjectVersion(context.Background(), bucket, "/foo", versions[0].VersionID); err != nil {
+		t.Fatal(err)
+	} else if obj, err := ss.Object(context.Background(), bucket, "/foo"); err != nil {
+		t.Fatal(err)
+	} else if obj.ETag != "etag2" || obj.VersionID != versions[1].VersionID {
+		t.Fatal("unexpected object", obj.ETag, obj.VersionID)
+	}
+
+	// delete the noncurrent version
+	if err := ss.RemoveObjectVersion(context.Background(), bucket, "/foo", versions[2].VersionID); err != nil {
+		t.Fatal(err)
+	} else if err := ss.RemoveObjectVersion(context.Background(), bucket, "/foo", versions[2].VersionID); !errors.Is(err, api.ErrObjectNotFound) {
+		t.Fatal("unexpected error", err)
+	} else if versions, err = ss.ObjectVersions(context.Background(), bucket, "/foo"); err != nil {
+		t.Fatal(err)
+	} else if len(versions) != 1 || versions[0].ETag != "etag2" {
+		t.Fatal("unexpected versions", versions)
+	}
+
+	// assert overwriting an object through a copy or a forced rename keeps
+	// the previous version
+	if _, err := ss.CopyObject(context.Background(), bucket, bucket, "/foo", "/bar", testMimeType, testMetadata, ""); err != nil {
+		t.Fatal(err)
+	} else if _, err := ss.CopyObject(context.Background(), bucket, bucket, "/foo", "/bar", testMimeType, testMetadata, ""); err != nil {
+		t.Fatal(err)
+	} else if err := ss.UpdateObject(context.Background(), bucket, "/baz", "etag3", testMimeType, testMetadata, newTestObject(1), false); err != nil {
+		t.Fatal(err)
+	} else if err := ss.RenameObject(context.Background(), bucket, "/baz", "/bar", true); err != nil {
+		t.Fatal(err)
+	} else if versions, err = ss.ObjectVersions(context.Background(), bucket, "/bar"); err != nil {
+		t.Fatal(err)
+	} else if len(versions) != 3 || versions[0].ETag != "etag3" {
+		t.Fatal("unexpected versions", versions)
+	}
+
+	// assert removing objects by prefix adds delete markers
+	if err := ss.RemoveObjects(context.Background(), bucket, "/b"); err != nil {
+		t.Fatal(err)
+	} else if _, err := ss.Object(context.Background(), bucket, "/bar"); !errors.Is(err, api.ErrObjectNotFound) {
+		t.Fatal("unexpected error", err)
+	} else if versions, err = ss.ObjectVersions(context.Background(), bucket, "/bar"); err != nil {
+		t.Fatal(err)
+	} else if len(versions) != 4 || !versions[0].IsDeleteMarker {
+		t.Fatal("unexpected versions", versions)
+	} else if err := ss.RemoveObject(context.Background(), bucket, "/foo"); err != nil {
+		t.Fatal(err)
+	}
+
+	// assert the bucket can be deleted and its versions are removed with it
+	if err := ss.DeleteBucket(context.Background(), bucket); err != nil {
+		t.Fatal(err)
+	} else if n := ss.Count("objects"); n != 0 {
+		t.Fatal("unexpected number of objects", n)
+	} else if n := ss.Count("object_versions"); n != 0 {
+		t.Fatal("unexpected number of versions", n)
+	}
+}
+
+func TestUpdateObjectIfNotExists(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add an object
+	if err := ss.UpdateObject(context.Background(), testBucket, "/foo", "etag1", testMimeType, testMetadata, newTestObject(1), true); err != nil {
+		t.Fatal(err)
+	}
+
+	// adding it again should fail
+	err := ss.UpdateObject(context.Background(), testBucket, "/foo", "etag2", testMimeType, testMetadata, newTestObject(1), true)
+	if !errors.Is(err, api.ErrObjectExists) {
+		t.Fatal("expected ErrObjectExists", err)
+	}
+
+	// assert the object wasn't overwritten
+	if obj, err := ss.Object(context.Background(), testBucket, "/foo"); err != nil {
+		t.Fatal(err)
+	} else if obj.ETag != "etag1" {
+		t.Fatal("unexpected etag", obj.ETag)
+	}
+
+	// the same key in another bucket should work
+	if err := ss.CreateBucket(context.Background(), "other", api.BucketPolicy{}); err != nil {
+		t.Fatal(err)
+	} else if err := ss.UpdateObject(context.Background(), "other", "/foo", "etag1", testMimeType, testMetadata, newTestObject(1), true); err != nil {
+		t.Fatal(err)
+	}
+
+	// overwriting the object without the option should work
+	if err := ss.UpdateObject(context.Background(), testBucket, "/foo", "etag2", testMimeType, testMetadata, newTestObject(1), false); err != nil {
+		t.Fatal(err)
+	} else if obj, err := ss.Object(context.Background(), testBucket, "/foo"); err != nil {
+		t.Fatal(err)
+	} else if obj.ETag != "etag2" {
+		t.Fatal("unexpected etag", obj.ETag)
+	}
+}
+
 // TestSQLContractStore tests SQLContractStore functionality.
+func TestRemoveObjectsGlob(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add some objects
+	keys := []string{"/a.tmp", "/dir/b.tmp", "/c.TMP", "/d.tmp.bak", "/e_tmp", "/f%tmp", "/ab.txt", "/[x].tmp"}
+	for _, key := range keys {
+		if _, err := ss.addTestObject(key, newTestObject(1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assertKeys := func(want ...string) {
+		t.Helper()
+		resp, err := ss.Objects(context.Background(), testBucket, "", "", "", "", "", "", -1, object.EncryptionKey{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make(map[string]struct{})
+		for _, o := range resp.Objects {
+			got[o.Key] = struct{}{}
+		}
+		if len(got) != len(want) {
+			t.Fatalf("unexpected keys %v, want %v", got, want)
+		}
+		for _, key := range want {
+			if _, ok := got[key]; !ok {
+				t.Fatalf("missing key %v", key)
+			}
+		}
+	}
+
+	removeGlob := func(glob string, want int) {
+		t.Helper()
+		if n, err := ss.RemoveObjectsGlob(context.Background(), testBucket, glob); err != nil {
+			t.Fatal(err)
+		} else if n != want {
+			t.Fatalf("expected %d objects to be removed, got %d", want, n)
+		}
+	}
+
+	// assert '_' and '%' are matched literally
+	removeGlob("/e_tmp", 1)
+	removeGlob("/f%tmp", 1)
+	removeGlob("/g_tmp", 0)
+	assertKeys("/a.tmp", "/dir/b.tmp", "/c.TMP", "/d.tmp.bak", "/ab.txt", "/[x].tmp")
+
+	// assert '?' matches a single character
+	removeGlob("/?.txt", 0)
+	removeGlob("/a?.txt", 1)
+
+	// assert '*' matches across directories and the match is case-sensitive
+	removeGlob("*.tmp", 3)
+	assertKeys("/c.TMP", "/d.tmp.bak")
+
+	// assert nothing is removed from other buckets
+	if err := ss.CreateBucket(context.Background(), "other", api.BucketPolicy{}); err != nil {
+		t.Fatal(err)
+	} else if n, err := ss.RemoveObjectsGlob(context.Background(), "other", "*"); err != nil {
+		t.Fatal(err)
+	} else if n != 0 {
+		t.Fatal("unexpected number of removed objects", n)
+	}
+	assertKeys("/c.TMP", "/d.tmp.bak")
+
+	// assert objects in versioned buckets are archived
+	if err := ss.CreateBucket(context.Background(), "versioned", api.BucketPolicy{Versioning: true}); err != nil {
+		t.Fatal(err)
+	} else if err := ss.UpdateObject(context.Background(), "versioned", "/a.tmp", testETag, testMimeType, testMetadata, newTestObject(1), false); err != nil {
+		t.Fatal(err)
+	} else if n, err := ss.RemoveObjectsGlob(context.Background(), "versioned", "*.tmp"); err != nil {
+		t.Fatal(err)
+	} else if n != 1 {
+		t.Fatal("unexpected number of removed objects", n)
+	} else if _, err := ss.Object(context.Background(), "versioned", "/a.tmp"); !errors.Is(err, api.ErrObjectNotFound) {
+		t.Fatal("unexpected error", err)
+	} else if versions, err := ss.ObjectVersions(context.Background(), "versioned", "/a.tmp"); err != nil {
+		t.Fatal(err)
+	} else if len(versions) != 2 || !versions[0].IsDeleteMarker {
+		t.Fatal("unexpected versions", versions)
+	}
+}
+
 func TestSQLContractStore(t *testing.T) {
 	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
 	defer ss.Close()
@@ -668,6 +975,63 @@ func TestArchiveContracts(t *testing.T) {
 	}
 }
 
+func TestContractsExpiring(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add 3 hosts
+	hks, err := ss.addTestHosts(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// add 3 contracts
+	fcids, _, err := ss.addTestContracts(hks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// update their window ends
+	for i, fcid := range fcids {
+		if _, err := ss.DB().Exec(context.Background(), "UPDATE contracts SET window_end = ? WHERE fcid = ?", (i+1)*100, sql.FileContractID(fcid)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// assert helper
+	assertExpiring := func(blocks uint64, expected ...types.FileContractID) {
+		t.Helper()
+		contracts, err := ss.ContractsExpiring(context.Background(), blocks)
+		if err != nil {
+			t.Fatal(err)
+		} else if len(contracts) != len(expected) {
+			t.Fatalf("expected %d contracts, got %d", len(expected), len(contracts))
+		}
+		for i, c := range contracts {
+			if c.ID != expected[i] {
+				t.Fatalf("unexpected contract at index %d: %v != %v", i, c.ID, expected[i])
+			}
+		}
+	}
+
+	// assert the threshold is applied
+	assertExpiring(99)
+	assertExpiring(100, fcids[0])
+	assertExpiring(250, fcids[0], fcids[1])
+
+	// assert the threshold is relative to the chain tip
+	if _, err := ss.DB().Exec(context.Background(), "UPDATE consensus_infos SET height = ?", 150); err != nil {
+		t.Fatal(err)
+	}
+	assertExpiring(100, fcids[0], fcids[1])
+
+	// assert archived contracts are ignored
+	if err := ss.ArchiveContracts(context.Background(), map[types.FileContractID]string{fcids[0]: "foo"}); err != nil {
+		t.Fatal(err)
+	}
+	assertExpiring(100, fcids[1])
+}
+
 func newTestContract(fcid types.FileContractID, hk types.PublicKey) api.ContractMetadata {
 	return api.ContractMetadata{
 		ID:                 fcid,
@@ -1578,7 +1942,7 @@ func TestUnhealthySlabs(t *testing.T) {
 	if err := ss.RefreshHealth(context.Background()); err != nil {
 		t.Fatal(err)
 	}
-	slabs, err := ss.UnhealthySlabs(context.Background(), 0.99, -1)
+	slabs, err := ss.UnhealthySlabs(context.Background(), 0.99, 0, -1)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -1586,11 +1950,17 @@ func TestUnhealthySlabs(t *testing.T) {
 		t.Fatalf("unexpected amount of slabs to migrate, %v!=4", len(slabs))
 	}
 
+	// all slabs belong to the same object so they share the same priority
+	priority := slabs[0].Priority
+	if priority == 0 {
+		t.Fatal("expected slabs to have a priority")
+	}
+
 	expected := []api.UnhealthySlab{
-		{EncryptionKey: obj.Slabs[2].EncryptionKey, Health: 0},
-		{EncryptionKey: obj.Slabs[4].EncryptionKey, Health: 0},
-		{EncryptionKey: obj.Slabs[1].EncryptionKey, Health: 0.5},
-		{EncryptionKey: obj.Slabs[3].EncryptionKey, Health: 0.5},
+		{EncryptionKey: obj.Slabs[2].EncryptionKey, Health: 0, Priority: priority},
+		{EncryptionKey: obj.Slabs[4].EncryptionKey, Health: 0, Priority: priority},
+		{EncryptionKey: obj.Slabs[1].EncryptionKey, Health: 0.5, Priority: priority},
+		{EncryptionKey: obj.Slabs[3].EncryptionKey, Health: 0.5, Priority: priority},
 	}
 	if !reflect.DeepEqual(slabs, expected) {
 		t.Fatal("slabs are not returned in the correct order")
@@ -1599,7 +1969,7 @@ func TestUnhealthySlabs(t *testing.T) {
 	if err := ss.RefreshHealth(context.Background()); err != nil {
 		t.Fatal(err)
 	}
-	slabs, err = ss.UnhealthySlabs(context.Background(), 0.49, -1)
+	slabs, err = ss.UnhealthySlabs(context.Background(), 0.49, 0, -1)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -1608,12 +1978,161 @@ func TestUnhealthySlabs(t *testing.T) {
 	}
 
 	expected = []api.UnhealthySlab{
-		{EncryptionKey: obj.Slabs[2].EncryptionKey, Health: 0},
-		{EncryptionKey: obj.Slabs[4].EncryptionKey, Health: 0},
+		{EncryptionKey: obj.Slabs[2].EncryptionKey, Health: 0, Priority: priority},
+		{EncryptionKey: obj.Slabs[4].EncryptionKey, Health: 0, Priority: priority},
 	}
 	if !reflect.DeepEqual(slabs, expected) {
 		t.Fatal("slabs are not returned in the correct order", slabs, expected)
 	}
+
+	// record a failed migration for one of the slabs
+	if err := ss.RecordSlabFailure(context.Background(), obj.Slabs[2].EncryptionKey); err != nil {
+		t.Fatal(err)
+	} else if err := ss.RecordSlabFailure(context.Background(), object.GenerateEncryptionKey(object.EncryptionKeyTypeSalted)); !errors.Is(err, api.ErrSlabNotFound) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// assert the slab is skipped if it failed recently
+	slabs, err = ss.UnhealthySlabs(context.Background(), 0.49, time.Hour, -1)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(slabs) != 1 || slabs[0].EncryptionKey.String() != obj.Slabs[4].EncryptionKey.String() {
+		t.Fatal("expected failed slab to be skipped", slabs)
+	}
+
+	// assert it's returned without the filter
+	slabs, err = ss.UnhealthySlabs(context.Background(), 0.49, 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(slabs) != 2 {
+		t.Fatalf("unexpected amount of slabs to migrate, %v!=2", len(slabs))
+	}
+}
+
+func TestUnhealthySlabsPriority(t *testing.T) {
+	// create db
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add 3 hosts
+	hks, err := ss.addTestHosts(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hk1, hk2, hk3 := hks[0], hks[1], hks[2]
+
+	// add 3 contracts
+	fcids, _, err := ss.addTestContracts(hks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fcid1, fcid2, fcid3 := fcids[0], fcids[1], fcids[2]
+
+	// mark the 3rd one as bad
+	if err := ss.UpdateContractUsability(context.Background(), fcid3, api.ContractUsabilityBad); err != nil {
+		t.Fatal(err)
+	}
+
+	// add two objects with an unhealthy slab, the first one being less healthy
+	newObject := func(shards ...object.Sector) object.Object {
+		return object.Object{
+			Key: object.GenerateEncryptionKey(object.EncryptionKeyTypeSalted),
+			Slabs: []object.SlabSlice{
+				{
+					Slab: object.Slab{
+						EncryptionKey: object.GenerateEncryptionKey(object.EncryptionKeyTypeSalted),
+						MinShards:     1,
+						Shards:        shards,
+					},
+				},
+			},
+		}
+	}
+	obj1 := newObject(
+		newTestShard(hk1, fcid1, types.Hash256{1}),
+		newTestShard(hk3, fcid3, types.Hash256{2}),
+		newTestShard(hk3, fcid3, types.Hash256{3}),
+	)
+	obj2 := newObject(
+		newTestShard(hk1, fcid1, types.Hash256{4}),
+		newTestShard(hk2, fcid2, types.Hash256{5}),
+		newTestShard(hk3, fcid3, types.Hash256{6}),
+	)
+	for i, obj := range []object.Object{obj1, obj2} {
+		if err := ss.UpdateObjectBlocking(context.Background(), testBucket, fmt.Sprintf("/obj%d", i+1), testETag, testMimeType, testMetadata, obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ss.RefreshHealth(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// assert neither object was accessed so the slabs are sorted by health
+	slabs, err := ss.UnhealthySlabs(context.Background(), 0.99, 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []api.UnhealthySlab{
+		{EncryptionKey: obj1.Slabs[0].EncryptionKey, Health: 0},
+		{EncryptionKey: obj2.Slabs[0].EncryptionKey, Health: 0.5},
+	}
+	if !reflect.DeepEqual(slabs, expected) {
+		t.Fatal("unexpected slabs", slabs, expected)
+	}
+
+	// access the second object
+	if _, err := ss.Object(context.Background(), testBucket, "/obj2"); err != nil {
+		t.Fatal(err)
+	}
+
+	// assert its slab is now returned first
+	slabs, err = ss.UnhealthySlabs(context.Background(), 0.99, 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(slabs) != 2 {
+		t.Fatalf("unexpected amount of slabs to migrate, %v!=2", len(slabs))
+	} else if slabs[0].EncryptionKey.String() != obj2.Slabs[0].EncryptionKey.String() || slabs[0].Priority == 0 {
+		t.Fatal("expected slab of accessed object to be prioritized", slabs[0])
+	} else if slabs[1].EncryptionKey.String() != obj1.Slabs[0].EncryptionKey.String() || slabs[1].Priority != 0 {
+		t.Fatal("unexpected slab", slabs[1])
+	}
+
+	// assert the limit is applied before sorting by priority
+	slabs, err = ss.UnhealthySlabs(context.Background(), 0.99, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(slabs) != 1 || slabs[0].EncryptionKey.String() != obj1.Slabs[0].EncryptionKey.String() {
+		t.Fatal("expected least healthy slab to be returned", slabs)
+	}
+
+	// assert accessing the object again doesn't update the access time
+	lastAccessed := func() (la int64) {
+		t.Helper()
+		if err := ss.DB().QueryRow(context.Background(), "SELECT last_accessed FROM objects WHERE object_id = ?", "/obj2").Scan(&la); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	if _, err := ss.DB().Exec(context.Background(), "UPDATE objects SET last_accessed = last_accessed - 60 WHERE object_id = ?", "/obj2"); err != nil {
+		t.Fatal(err)
+	}
+	before := lastAccessed()
+	if _, err := ss.Object(context.Background(), testBucket, "/obj2"); err != nil {
+		t.Fatal(err)
+	} else if lastAccessed() != before {
+		t.Fatal("expected last accessed time to be unchanged")
+	}
+
+	// assert it's updated once the interval passed
+	if _, err := ss.DB().Exec(context.Background(), "UPDATE objects SET last_accessed = ? WHERE object_id = ?", time.Now().Add(-2*time.Hour).Unix(), "/obj2"); err != nil {
+		t.Fatal(err)
+	}
+	before = lastAccessed()
+	if _, err := ss.Object(context.Background(), testBucket, "/obj2"); err != nil {
+		t.Fatal(err)
+	} else if lastAccessed() <= before {
+		t.Fatal("expected last accessed time to be updated")
+	}
 }
 
 func TestUnhealthySlabsNegHealth(t *testing.T) {
@@ -1661,7 +2180,7 @@ func TestUnhealthySlabsNegHealth(t *testing.T) {
 	if err := ss.RefreshHealth(context.Background()); err != nil {
 		t.Fatal(err)
 	}
-	slabs, err := ss.UnhealthySlabs(context.Background(), 0.99, -1)
+	slabs, err := ss.UnhealthySlabs(context.Background(), 0.99, 0, -1)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -1712,7 +2231,7 @@ func TestUnhealthySlabsNoContracts(t *testing.T) {
 	if err := ss.RefreshHealth(context.Background()); err != nil {
 		t.Fatal(err)
 	}
-	slabs, err := ss.UnhealthySlabs(context.Background(), 0.99, -1)
+	slabs, err := ss.UnhealthySlabs(context.Background(), 0.99, 0, -1)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -1734,7 +2253,7 @@ func TestUnhealthySlabsNoContracts(t *testing.T) {
 	if err := ss.RefreshHealth(context.Background()); err != nil {
 		t.Fatal(err)
 	}
-	slabs, err = ss.UnhealthySlabs(context.Background(), 0.99, -1)
+	slabs, err = ss.UnhealthySlabs(context.Background(), 0.99, 0, -1)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -1804,7 +2323,7 @@ func TestUnhealthySlabsNoRedundancy(t *testing.T) {
 	if err := ss.RefreshHealth(context.Background()); err != nil {
 		t.Fatal(err)
 	}
-	slabs, err := ss.UnhealthySlabs(context.Background(), 0.99, -1)
+	slabs, err := ss.UnhealthySlabs(context.Background(), 0.99, 0, -1)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -1813,7 +2332,7 @@ func TestUnhealthySlabsNoRedundancy(t *testing.T) {
 	}
 
 	expected := []api.UnhealthySlab{
-		{EncryptionKey: obj.Slabs[1].Slab.EncryptionKey, Health: -1},
+		{EncryptionKey: obj.Slabs[1].Slab.EncryptionKey, Health: -1, Priority: slabs[0].Priority},
 	}
 	if !reflect.DeepEqual(slabs, expected) {
 		t.Fatal("slabs are not returned in the correct order")
@@ -1978,7 +2497,7 @@ func TestUpdateSlab(t *testing.T) {
 	if err := ss.RefreshHealth(context.Background()); err != nil {
 		t.Fatal(err)
 	}
-	toMigrate, err := ss.UnhealthySlabs(ctx, 0.99, -1)
+	toMigrate, err := ss.UnhealthySlabs(ctx, 0.99, 0, -1)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -2023,7 +2542,7 @@ func TestUpdateSlab(t *testing.T) {
 	if err := ss.RefreshHealth(context.Background()); err != nil {
 		t.Fatal(err)
 	}
-	toMigrate, err = ss.UnhealthySlabs(ctx, 0.99, -1)
+	toMigrate, err = ss.UnhealthySlabs(ctx, 0.99, 0, -1)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -2040,6 +2559,39 @@ func TestUpdateSlab(t *testing.T) {
 	}
 }
 
+func TestSlabChecksums(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add an object with two slabs, only the first one has checksums
+	obj := newTestObject(2)
+	slab := &obj.Slabs[0].Slab
+	slab.ChecksumAlgorithm = object.ChecksumAlgorithmBLAKE3
+	slab.Checksums = make([]types.Hash256, len(slab.Shards))
+	for i := range slab.Checksums {
+		slab.Checksums[i] = frand.Entropy256()
+	}
+	if _, err := ss.addTestObject("foo", obj); err != nil {
+		t.Fatal(err)
+	}
+
+	// assert the checksums were persisted
+	if s, err := ss.Slab(context.Background(), obj.Slabs[0].EncryptionKey); err != nil {
+		t.Fatal(err)
+	} else if s.ChecksumAlgorithm != object.ChecksumAlgorithmBLAKE3 {
+		t.Fatal("unexpected checksum algorithm", s.ChecksumAlgorithm)
+	} else if !reflect.DeepEqual(s.Checksums, slab.Checksums) {
+		t.Fatal("unexpected checksums")
+	}
+
+	// assert the slab without checksums is unaffected
+	if s, err := ss.Slab(context.Background(), obj.Slabs[1].EncryptionKey); err != nil {
+		t.Fatal(err)
+	} else if s.ChecksumAlgorithm != "" || s.Checksums != nil {
+		t.Fatal("unexpected checksums", s.ChecksumAlgorithm, s.Checksums)
+	}
+}
+
 func newTestObject(slabs int) object.Object {
 	obj := object.Object{}
 
@@ -2100,6 +2652,10 @@ func TestRecordContractSpending(t *testing.T) {
 		FundAccount: types.Siacoins(2),
 		Deletions:   types.Siacoins(3),
 		SectorRoots: types.Siacoins(4),
+
+		AccountFundingFees: types.Siacoins(5),
+		PriceTableFees:     types.Siacoins(6),
+		SectorUploadFees:   types.Siacoins(7),
 	}
 	err = ss.RecordContractSpending(context.Background(), []api.ContractSpendingRecord{
 		// non-existent contract
@@ -2256,6 +2812,53 @@ func TestRenameObjects(t *testing.T) {
 	}
 }
 
+// TestRenameObjectsVersioned asserts that renaming objects in a versioned
+// bucket archives overwritten objects and moves the history of the renamed
+// objects.
+func TestRenameObjectsVersioned(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+	ctx := context.Background()
+
+	bucket := "versioned"
+	if err := ss.CreateBucket(ctx, bucket, api.BucketPolicy{Versioning: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	// upload two versions of the source and one of the destination
+	for _, key := range []string{"/src/a", "/src/a", "/dst/a"} {
+		if err := ss.UpdateObject(ctx, bucket, key, testETag, testMimeType, testMetadata, newTestObject(1), false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assertVersions := func(key string, n int) {
+		t.Helper()
+		versions, err := ss.ObjectVersions(ctx, bucket, key)
+		if n == 0 && !errors.Is(err, api.ErrObjectNotFound) {
+			t.Fatal("expected no versions", err, versions)
+		} else if n > 0 && err != nil {
+			t.Fatal(err)
+		} else if len(versions) != n {
+			t.Fatalf("expected %d versions, got %d", n, len(versions))
+		}
+	}
+
+	// rename the source onto the destination
+	if err := ss.RenameObjects(ctx, bucket, "/src/", "/dst/", true); err != nil {
+		t.Fatal(err)
+	}
+	assertVersions("/src/a", 0)
+	assertVersions("/dst/a", 3)
+
+	// rename a single object
+	if err := ss.RenameObject(ctx, bucket, "/dst/a", "/b", false); err != nil {
+		t.Fatal(err)
+	}
+	assertVersions("/dst/a", 0)
+	assertVersions("/b", 3)
+}
+
 func TestRenameObjectsRegression(t *testing.T) {
 	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
 	defer ss.Close()
@@ -2464,6 +3067,75 @@ func TestObjectsStats(t *testing.T) {
 	}
 }
 
+func TestObjectsStatsPerHost(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// fetch stats on clean database
+	stats, err := ss.ObjectsStatsPerHost(context.Background(), api.ObjectsStatsOpts{})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(stats) != 0 {
+		t.Fatal("unexpected stats", stats)
+	}
+
+	// create a few objects and keep track of the sectors per host
+	expected := make(map[types.PublicKey]api.HostStorageStats)
+	for i := 0; i < 2; i++ {
+		obj := newTestObject(1)
+		for _, slab := range obj.Slabs {
+			for _, s := range slab.Shards {
+				for hpk, fcids := range s.Contracts {
+					if err := ss.addTestHost(hpk); err != nil {
+						t.Fatal(err)
+					}
+					for _, fcid := range fcids {
+						if _, err := ss.addTestContract(fcid, hpk); err != nil {
+							t.Fatal(err)
+						}
+					}
+					hs := expected[hpk]
+					hs.NumSectors++
+					hs.TotalSectorsSize += rhpv2.SectorSize
+					expected[hpk] = hs
+				}
+			}
+		}
+
+		key := "/" + hex.EncodeToString(frand.Bytes(32))
+		if _, err := ss.addTestObject(key, obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// assert the stats match
+	for _, opts := range []api.ObjectsStatsOpts{
+		{},                   // any bucket
+		{Bucket: testBucket}, // specific bucket
+	} {
+		stats, err := ss.ObjectsStatsPerHost(context.Background(), opts)
+		if err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(stats, expected) {
+			t.Fatal("unexpected stats", stats, expected)
+		}
+	}
+
+	// assert other buckets have no stats
+	if err := ss.CreateBucket(context.Background(), "other", api.BucketPolicy{}); err != nil {
+		t.Fatal(err)
+	} else if stats, err := ss.ObjectsStatsPerHost(context.Background(), api.ObjectsStatsOpts{Bucket: "other"}); err != nil {
+		t.Fatal(err)
+	} else if len(stats) != 0 {
+		t.Fatal("unexpected stats", stats)
+	}
+
+	// assert unknown buckets return an error
+	if _, err := ss.ObjectsStatsPerHost(context.Background(), api.ObjectsStatsOpts{Bucket: "unknown"}); !errors.Is(err, api.ErrBucketNotFound) {
+		t.Fatal("unexpected error", err)
+	}
+}
+
 func TestPartialSlab(t *testing.T) {
 	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
 	defer ss.Close()
@@ -2901,7 +3573,7 @@ func TestContractSizes(t *testing.T) {
 	}
 
 	// archive all contracts
-	if err := ss.ArchiveAllContracts(context.Background(), t.Name()); err != nil {
+	if err := ss.ArchiveAllContracts(context.Background(), t.Name(), false); err != nil {
 		t.Fatal(err)
 	}
 
@@ -2911,6 +3583,81 @@ func TestContractSizes(t *testing.T) {
 	}
 }
 
+func TestArchiveAllContractsSafe(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// create 2 hosts with a contract each
+	hks, err := ss.addTestHosts(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fcids, _, err := ss.addTestContracts(hks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// add an object that is stored on both contracts and one that is only
+	// stored on the first one
+	newObject := func(shards []object.Sector) object.Object {
+		return object.Object{
+			Key: object.GenerateEncryptionKey(object.EncryptionKeyTypeSalted),
+			Slabs: []object.SlabSlice{{
+				Slab: object.Slab{
+					EncryptionKey: object.GenerateEncryptionKey(object.EncryptionKeyTypeSalted),
+					MinShards:     1,
+					Shards:        shards,
+				},
+				Length: 1,
+			}},
+		}
+	}
+	if _, err := ss.addTestObject("/both", newObject([]object.Sector{
+		newTestShard(hks[0], fcids[0], types.Hash256{1}),
+		newTestShard(hks[1], fcids[1], types.Hash256{2}),
+	})); err != nil {
+		t.Fatal(err)
+	} else if _, err := ss.addTestObject("/first", newObject(newTestShards(hks[0], fcids[0], types.Hash256{3}))); err != nil {
+		t.Fatal(err)
+	}
+
+	// assert archiving the first contract only loses the second object
+	var lost []api.ObjectMetadata
+	if err := ss.db.Transaction(context.Background(), func(tx sql.DatabaseTx) (err error) {
+		lost, err = tx.ObjectsLostOnArchival(context.Background(), fcids[:1], 10)
+		return
+	}); err != nil {
+		t.Fatal(err)
+	} else if len(lost) != 1 || lost[0].Bucket != testBucket || lost[0].Key != "/first" {
+		t.Fatal("unexpected objects", lost)
+	}
+
+	// assert a safe archival of all contracts fails
+	err = ss.ArchiveAllContracts(context.Background(), t.Name(), true)
+	if !errors.Is(err, api.ErrArchivalDataLoss) {
+		t.Fatal("unexpected error", err)
+	} else if !strings.Contains(err.Error(), testBucket+"/both") || !strings.Contains(err.Error(), testBucket+"/first") {
+		t.Fatal("expected error to list the objects", err)
+	} else if contracts, err := ss.Contracts(context.Background(), api.ContractsOpts{}); err != nil {
+		t.Fatal(err)
+	} else if len(contracts) != 2 {
+		t.Fatal("expected contracts to remain active", len(contracts))
+	}
+
+	// remove the objects and assert a safe archival succeeds
+	if err := ss.RemoveObjectBlocking(context.Background(), testBucket, "/both"); err != nil {
+		t.Fatal(err)
+	} else if err := ss.RemoveObjectBlocking(context.Background(), testBucket, "/first"); err != nil {
+		t.Fatal(err)
+	} else if err := ss.ArchiveAllContracts(context.Background(), t.Name(), true); err != nil {
+		t.Fatal(err)
+	} else if contracts, err := ss.Contracts(context.Background(), api.ContractsOpts{}); err != nil {
+		t.Fatal(err)
+	} else if len(contracts) != 0 {
+		t.Fatal("expected no active contracts", len(contracts))
+	}
+}
+
 func TestObjectsBySlabKey(t *testing.T) {
 	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
 	defer ss.Close()
@@ -3021,7 +3768,7 @@ func TestBucketObjects(t *testing.T) {
 
 	// Adding an object to a bucket that doesn't exist shouldn't work.
 	obj := newTestObject(1)
-	err := ss.UpdateObject(context.Background(), "unknown-bucket", "/foo", testETag, testMimeType, testMetadata, obj)
+	err := ss.UpdateObject(context.Background(), "unknown-bucket", "/foo", testETag, testMimeType, testMetadata, obj, false)
 	if !errors.Is(err, api.ErrBucketNotFound) {
 		t.Fatal("expected ErrBucketNotFound", err)
 	}
@@ -3052,7 +3799,7 @@ func TestBucketObjects(t *testing.T) {
 		obj := newTestObject(frand.Intn(9) + 1)
 		obj.Slabs = obj.Slabs[:1]
 		obj.Slabs[0].Length = uint32(o.size)
-		err := ss.UpdateObject(ctx, o.bucket, o.path, testETag, testMimeType, testMetadata, obj)
+		err := ss.UpdateObject(ctx, o.bucket, o.path, testETag, testMimeType, testMetadata, obj, false)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -3204,13 +3951,13 @@ func TestCopyObject(t *testing.T) {
 
 	// Create one object.
 	obj := newTestObject(1)
-	err := ss.UpdateObject(ctx, "src", "/foo", testETag, testMimeType, testMetadata, obj)
+	err := ss.UpdateObject(ctx, "src", "/foo", testETag, testMimeType, testMetadata, obj, false)
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	// Copy it within the same bucket.
-	if om, err := ss.CopyObject(ctx, "src", "src", "/foo", "/bar", "", nil); err != nil {
+	if om, err := ss.CopyObject(ctx, "src", "src", "/foo", "/bar", "", nil, ""); err != nil {
 		t.Fatal(err)
 	} else if resp, err := ss.Objects(ctx, "src", "/", "", "", "", "", "", -1, object.EncryptionKey{}); err != nil {
 		t.Fatal(err)
@@ -3223,7 +3970,7 @@ func TestCopyObject(t *testing.T) {
 	}
 
 	// Copy it cross buckets.
-	if om, err := ss.CopyObject(ctx, "src", "dst", "/foo", "/bar", "", nil); err != nil {
+	if om, err := ss.CopyObject(ctx, "src", "dst", "/foo", "/bar", "", nil, ""); err != nil {
 		t.Fatal(err)
 	} else if resp, err := ss.Objects(ctx, "dst", "/", "", "", "", "", "", -1, object.EncryptionKey{}); err != nil {
 		t.Fatal(err)
@@ -3234,6 +3981,27 @@ func TestCopyObject(t *testing.T) {
 	} else if om.ModTime.IsZero() {
 		t.Fatal("expected mod time to be set")
 	}
+
+	// Copy a missing object over the existing one, the copy happens in a
+	// single transaction so the deletion of the destination is rolled back.
+	if _, err := ss.CopyObject(ctx, "src", "dst", "/missing", "/bar", "", nil, ""); !errors.Is(err, api.ErrObjectNotFound) {
+		t.Fatal("unexpected error", err)
+	} else if _, err := ss.Object(ctx, "dst", "/bar"); err != nil {
+		t.Fatal("expected destination object to be intact", err)
+	} else if n := ss.Count("slices"); n != 3 {
+		t.Fatal("unexpected number of slices", n)
+	}
+
+	// Copy it conditionally, a mismatching ETag fails the copy.
+	if _, err := ss.CopyObject(ctx, "src", "dst", "/foo", "/baz", "", nil, "wrong"); !errors.Is(err, api.ErrPreconditionFailed) {
+		t.Fatal("unexpected error", err)
+	} else if _, err := ss.Object(ctx, "dst", "/baz"); !errors.Is(err, api.ErrObjectNotFound) {
+		t.Fatal("expected object to not be copied", err)
+	} else if _, err := ss.CopyObject(ctx, "src", "dst", "/foo", "/baz", "", nil, testETag); err != nil {
+		t.Fatal(err)
+	} else if _, err := ss.Object(ctx, "dst", "/baz"); err != nil {
+		t.Fatal("expected object to be copied", err)
+	}
 }
 
 func TestMarkSlabUploadedAfterRenew(t *testing.T) {
@@ -3764,7 +4532,7 @@ func TestSlabHealthInvalidation(t *testing.T) {
 				newTestShard(hks[3], fcids[3], types.Hash256{3}),
 			},
 		}}},
-	})
+	}, false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -3909,7 +4677,7 @@ func TestRenewedContract(t *testing.T) {
 	if err := ss.RefreshHealth(context.Background()); err != nil {
 		t.Fatal(err)
 	}
-	if slabs, err := ss.UnhealthySlabs(context.Background(), 0.99, 10); err != nil {
+	if slabs, err := ss.UnhealthySlabs(context.Background(), 0.99, 0, 10); err != nil {
 		t.Fatal(err)
 	} else if len(slabs) > 0 {
 		t.Fatal("shouldn't return any slabs", len(slabs))
@@ -3953,7 +4721,7 @@ func TestRenewedContract(t *testing.T) {
 	if err := ss.RefreshHealth(context.Background()); err != nil {
 		t.Fatal(err)
 	}
-	if slabs, err := ss.UnhealthySlabs(context.Background(), 0.99, 10); err != nil {
+	if slabs, err := ss.UnhealthySlabs(context.Background(), 0.99, 0, 10); err != nil {
 		t.Fatal(err)
 	} else if len(slabs) > 0 {
 		t.Fatal("shouldn't return any slabs", len(slabs))
@@ -4628,7 +5396,7 @@ func TestUpdateObjectParallel(t *testing.T) {
 			}
 
 			// update the object
-			if err := ss.UpdateObject(context.Background(), testBucket, name, testETag, testMimeType, testMetadata, obj); err != nil {
+			if err := ss.UpdateObject(context.Background(), testBucket, name, testETag, testMimeType, testMetadata, obj, false); err != nil {
 				t.Error(err)
 				return
 			}
@@ -4728,6 +5496,10 @@ func TestPutContract(t *testing.T) {
 			FundAccount: types.NewCurrency64(28),
 			SectorRoots: types.NewCurrency64(29),
 			Uploads:     types.NewCurrency64(30),
+
+			AccountFundingFees: types.NewCurrency64(32),
+			PriceTableFees:     types.NewCurrency64(33),
+			SectorUploadFees:   types.NewCurrency64(34),
 		},
 
 		ArchivalReason: api.ContractArchivalReasonRemoved,
@@ -4872,3 +5644,464 @@ func TestHostSectors(t *testing.T) {
 		t.Fatal("expected updated at to change")
 	}
 }
+
+func TestEventSink(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// register a sink
+	sink := make(ChannelEventSink, 10)
+	ss.RegisterEventSink(sink)
+
+	// assert the next event matches the expected one, ignoring timestamps
+	assertEvent := func(expected Event) {
+		t.Helper()
+		var e Event
+		select {
+		case e = <-sink:
+		case <-time.After(time.Second):
+			t.Fatal("expected event", expected)
+		}
+		switch e := e.(type) {
+		case ObjectCreatedEvent:
+			e.Timestamp = time.Time{}
+			if e != expected {
+				t.Fatal("unexpected event", e)
+			}
+		case ObjectDeletedEvent:
+			e.Timestamp = time.Time{}
+			if e != expected {
+				t.Fatal("unexpected event", e)
+			}
+		case ObjectRenamedEvent:
+			e.Timestamp = time.Time{}
+			if e != expected {
+				t.Fatal("unexpected event", e)
+			}
+		case ContractAddedEvent:
+			if e.Contract.ID != expected.(ContractAddedEvent).Contract.ID {
+				t.Fatal("unexpected event", e)
+			}
+		case ContractArchivedEvent:
+			e.Timestamp = time.Time{}
+			if e != expected {
+				t.Fatal("unexpected event", e)
+			}
+		default:
+			t.Fatalf("unexpected event type %T", e)
+		}
+	}
+
+	// add a host and a contract
+	hks, err := ss.addTestHosts(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fcids, _, err := ss.addTestContracts(hks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEvent(ContractAddedEvent{Contract: api.ContractMetadata{ID: fcids[0]}})
+
+	// add an object and remove it again
+	if _, err := ss.addTestObject("foo", newTestObject(1)); err != nil {
+		t.Fatal(err)
+	}
+	assertEvent(ObjectCreatedEvent{Bucket: testBucket, Key: "foo", ETag: testETag})
+
+	// rename it, both individually and by prefix
+	if err := ss.RenameObject(context.Background(), testBucket, "foo", "/dir/foo", false); err != nil {
+		t.Fatal(err)
+	}
+	assertEvent(ObjectRenamedEvent{Bucket: testBucket, Key: "foo", NewKey: "/dir/foo"})
+	if err := ss.RenameObjects(context.Background(), testBucket, "/dir/", "/other/", false); err != nil {
+		t.Fatal(err)
+	}
+	assertEvent(ObjectRenamedEvent{Bucket: testBucket, Prefix: "/dir/", NewPrefix: "/other/"})
+
+	// remove it
+	if err := ss.RemoveObjectBlocking(context.Background(), testBucket, "/other/foo"); err != nil {
+		t.Fatal(err)
+	}
+	assertEvent(ObjectDeletedEvent{Bucket: testBucket, Key: "/other/foo"})
+
+	// archive the contract
+	if err := ss.ArchiveContract(context.Background(), fcids[0], "bar"); err != nil {
+		t.Fatal(err)
+	}
+	assertEvent(ContractArchivedEvent{ContractID: fcids[0], Reason: "bar"})
+
+	// add another host with a contract and remove it as an offline host
+	hks, err = ss.addTestHosts(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fcids, _, err = ss.addTestContracts(hks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEvent(ContractAddedEvent{Contract: api.ContractMetadata{ID: fcids[0]}})
+	if removed, err := ss.RemoveOfflineHosts(context.Background(), 0, 0); err != nil {
+		t.Fatal(err)
+	} else if removed != 2 {
+		t.Fatal("expected both hosts to be removed", removed)
+	}
+	assertEvent(ContractArchivedEvent{ContractID: fcids[0], Reason: api.ContractArchivalReasonHostPruned})
+
+	// assert no other events were published
+	select {
+	case e := <-sink:
+		t.Fatal("unexpected event", e)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestEventQueue(t *testing.T) {
+	q := newEventQueue()
+
+	// reserve three slots and fill them out of order
+	first, second, third := q.reserve(), q.reserve(), q.reserve()
+	q.fill(second, []Event{ObjectDeletedEvent{Key: "second"}})
+	if events := q.pop(); len(events) != 0 {
+		t.Fatal("expected no events before the first slot is filled", events)
+	}
+	q.fill(third, nil)
+	q.fill(first, []Event{ObjectCreatedEvent{Key: "first"}})
+
+	// assert the events are popped in the order the slots were reserved and
+	// the empty slot is skipped
+	events := q.pop()
+	if len(events) != 2 {
+		t.Fatal("unexpected number of events", len(events))
+	} else if e, ok := events[0].(ObjectCreatedEvent); !ok || e.Key != "first" {
+		t.Fatal("unexpected event", events[0])
+	} else if e, ok := events[1].(ObjectDeletedEvent); !ok || e.Key != "second" {
+		t.Fatal("unexpected event", events[1])
+	}
+
+	// assert the next slot is delivered right away
+	q.fill(q.reserve(), []Event{ObjectDeletedEvent{Key: "fourth"}})
+	if events := q.pop(); len(events) != 1 {
+		t.Fatal("unexpected number of events", len(events))
+	}
+}
+
+func TestFindAlternativeContracts(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// create 2 hosts with a contract each
+	hks, err := ss.addTestHosts(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fcids, _, err := ss.addTestContracts(hks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// create a slab with one sector that is stored by both contracts
+	root := types.Hash256{1, 2, 3}
+	ss.InsertSlab(object.Slab{
+		EncryptionKey: object.GenerateEncryptionKey(object.EncryptionKeyTypeSalted),
+		MinShards:     1,
+		Shards: []object.Sector{
+			{
+				Contracts: map[types.PublicKey][]types.FileContractID{
+					hks[0]: {fcids[0]},
+					hks[1]: {fcids[1]},
+				},
+				Root: root,
+			},
+		},
+	})
+
+	// assert both contracts are returned
+	alternatives, err := ss.FindAlternativeContracts(context.Background(), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alternatives) != 2 || alternatives[0] == alternatives[1] {
+		t.Fatal("unexpected contracts", alternatives)
+	}
+	for _, fcid := range alternatives {
+		if fcid != fcids[0] && fcid != fcids[1] {
+			t.Fatal("unexpected contract", fcid)
+		}
+	}
+
+	// archive one contract and assert it's no longer returned
+	if err := ss.ArchiveContract(context.Background(), fcids[0], "foo"); err != nil {
+		t.Fatal(err)
+	}
+	alternatives, err = ss.FindAlternativeContracts(context.Background(), root)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(alternatives) != 1 || alternatives[0] != fcids[1] {
+		t.Fatal("unexpected contracts", alternatives)
+	}
+
+	// assert unknown sectors have no contracts
+	alternatives, err = ss.FindAlternativeContracts(context.Background(), types.Hash256{4, 5, 6})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(alternatives) != 0 {
+		t.Fatal("unexpected contracts", alternatives)
+	}
+}
+
+func TestContractRootsSectorRemoval(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// create 2 hosts with a contract each
+	hks, err := ss.addTestHosts(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fcids, _, err := ss.addTestContracts(hks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// create a slab with two sectors, one of which is stored by both contracts
+	root1, root2 := types.Hash256{1}, types.Hash256{2}
+	ss.InsertSlab(object.Slab{
+		EncryptionKey: object.GenerateEncryptionKey(object.EncryptionKeyTypeSalted),
+		MinShards:     1,
+		Shards: []object.Sector{
+			{
+				Contracts: map[types.PublicKey][]types.FileContractID{
+					hks[0]: {fcids[0]},
+					hks[1]: {fcids[1]},
+				},
+				Root: root1,
+			},
+			{
+				Contracts: map[types.PublicKey][]types.FileContractID{
+					hks[0]: {fcids[0]},
+				},
+				Root: root2,
+			},
+		},
+	})
+
+	// assert the contract roots are returned
+	if roots, err := ss.ContractRoots(context.Background(), fcids[0]); err != nil {
+		t.Fatal(err)
+	} else if len(roots) != 2 || roots[0] != root1 || roots[1] != root2 {
+		t.Fatal("unexpected roots", roots)
+	}
+
+	// remove the first sector from the first host
+	if _, err := ss.DeleteHostSector(context.Background(), hks[0], root1); err != nil {
+		t.Fatal(err)
+	}
+	if roots, err := ss.ContractRoots(context.Background(), fcids[0]); err != nil {
+		t.Fatal(err)
+	} else if len(roots) != 1 || roots[0] != root2 {
+		t.Fatal("unexpected roots", roots)
+	} else if roots, err := ss.ContractRoots(context.Background(), fcids[1]); err != nil {
+		t.Fatal(err)
+	} else if len(roots) != 1 || roots[0] != root1 {
+		t.Fatal("unexpected roots", roots)
+	}
+
+	// archive the first contract and assert its sectors are removed
+	if err := ss.ArchiveContract(context.Background(), fcids[0], "foo"); err != nil {
+		t.Fatal(err)
+	}
+	if roots, err := ss.ContractRoots(context.Background(), fcids[0]); err != nil {
+		t.Fatal(err)
+	} else if len(roots) != 0 {
+		t.Fatal("unexpected roots", roots)
+	}
+}
+
+func TestRecoverObjects(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// create 2 hosts with a contract each
+	hks, err := ss.addTestHosts(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fcids, _, err := ss.addTestContracts(hks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// create an object spread over both contracts and one that is only stored
+	// by the second contract
+	newSlab := func(shards ...object.Sector) object.SlabSlice {
+		return object.SlabSlice{
+			Slab: object.Slab{
+				EncryptionKey: object.GenerateEncryptionKey(object.EncryptionKeyTypeSalted),
+				MinShards:     1,
+				Shards:        shards,
+			},
+			Length: rhpv2.SectorSize,
+		}
+	}
+	if _, err := ss.addTestObject("foo", object.Object{
+		Key: object.GenerateEncryptionKey(object.EncryptionKeyTypeSalted),
+		Slabs: []object.SlabSlice{newSlab(
+			newTestShard(hks[0], fcids[0], types.Hash256{1}),
+			newTestShard(hks[1], fcids[1], types.Hash256{2}),
+		)},
+	}); err != nil {
+		t.Fatal(err)
+	} else if _, err := ss.addTestObject("bar", object.Object{
+		Key:   object.GenerateEncryptionKey(object.EncryptionKeyTypeSalted),
+		Slabs: []object.SlabSlice{newSlab(newTestShard(hks[1], fcids[1], types.Hash256{3}))},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// recover the objects from the roots reported by the hosts, the last root
+	// is unknown
+	roots := []types.Hash256{{1}, {2}, {3}, {4}}
+	if matched, recovered, deleted, err := ss.RecoverObjects(context.Background(), roots); err != nil {
+		t.Fatal(err)
+	} else if matched != 3 || recovered != 2 || deleted != 0 {
+		t.Fatal("unexpected result", matched, recovered, deleted)
+	} else if n := ss.Count("recovered_objects"); n != 2 {
+		t.Fatal("unexpected number of recovered objects", n)
+	}
+
+	// recover again, the objects should not be recorded twice
+	if matched, recovered, deleted, err := ss.RecoverObjects(context.Background(), roots); err != nil {
+		t.Fatal(err)
+	} else if matched != 3 || recovered != 0 || deleted != 0 {
+		t.Fatal("unexpected result", matched, recovered, deleted)
+	} else if n := ss.Count("recovered_objects"); n != 2 {
+		t.Fatal("unexpected number of recovered objects", n)
+	}
+
+	// upload an object to a versioned bucket and delete it, its noncurrent
+	// version still references the slab
+	if err := ss.CreateBucket(context.Background(), "versioned", api.BucketPolicy{Versioning: true}); err != nil {
+		t.Fatal(err)
+	} else if err := ss.UpdateObjectBlocking(context.Background(), "versioned", "baz", testETag, testMimeType, testMetadata, object.Object{
+		Key:   object.GenerateEncryptionKey(object.EncryptionKeyTypeSalted),
+		Slabs: []object.SlabSlice{newSlab(newTestShard(hks[0], fcids[0], types.Hash256{5}))},
+	}); err != nil {
+		t.Fatal(err)
+	} else if err := ss.RemoveObject(context.Background(), "versioned", "baz"); err != nil {
+		t.Fatal(err)
+	}
+
+	// assert the deleted object is reported
+	if matched, recovered, deleted, err := ss.RecoverObjects(context.Background(), []types.Hash256{{5}}); err != nil {
+		t.Fatal(err)
+	} else if matched != 1 || recovered != 0 || deleted != 1 {
+		t.Fatal("unexpected result", matched, recovered, deleted)
+	}
+	var isDeleted bool
+	if err := ss.DB().QueryRow(context.Background(), "SELECT deleted FROM recovered_objects WHERE object_key = ?", "baz").Scan(&isDeleted); err != nil {
+		t.Fatal(err)
+	} else if !isDeleted {
+		t.Fatal("expected object to be recorded as deleted")
+	}
+}
+
+func TestPutArchivedContract(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// create 2 hosts with a contract each
+	hks, err := ss.addTestHosts(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fcids, _, err := ss.addTestContracts(hks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// create a slab with a sector stored by both contracts
+	ss.InsertSlab(object.Slab{
+		EncryptionKey: object.GenerateEncryptionKey(object.EncryptionKeyTypeSalted),
+		MinShards:     1,
+		Shards: []object.Sector{
+			{
+				Contracts: map[types.PublicKey][]types.FileContractID{
+					hks[0]: {fcids[0]},
+					hks[1]: {fcids[1]},
+				},
+				Root: types.Hash256{1},
+			},
+		},
+	})
+	if n := ss.Count("contract_sectors"); n != 2 {
+		t.Fatal("unexpected number of contract sectors", n)
+	}
+
+	// store the first contract as archived
+	c, err := ss.Contract(context.Background(), fcids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.ArchivalReason = api.ContractArchivalReasonRemoved
+	if err := ss.PutContract(context.Background(), c); err != nil {
+		t.Fatal(err)
+	}
+
+	// assert the association is removed but the sector is kept
+	if n := ss.Count("contract_sectors"); n != 1 {
+		t.Fatal("unexpected number of contract sectors", n)
+	} else if n := ss.Count("sectors"); n != 1 {
+		t.Fatal("unexpected number of sectors", n)
+	} else if roots, err := ss.ContractRoots(context.Background(), fcids[0]); err != nil {
+		t.Fatal(err)
+	} else if len(roots) != 0 {
+		t.Fatal("unexpected roots", roots)
+	}
+}
+
+// panickingEventSink panics when publishing the first event and forwards all
+// other events to the wrapped sink.
+type panickingEventSink struct {
+	ChannelEventSink
+
+	mu       sync.Mutex
+	panicked bool
+}
+
+func (s *panickingEventSink) Publish(ctx context.Context, e Event) error {
+	s.mu.Lock()
+	panicked := s.panicked
+	s.panicked = true
+	s.mu.Unlock()
+	if !panicked {
+		panic("publish failed")
+	}
+	return s.ChannelEventSink.Publish(ctx, e)
+}
+
+func TestEventSinkPanic(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// register a sink that panics on the first event
+	sink := &panickingEventSink{ChannelEventSink: make(ChannelEventSink, 10)}
+	ss.RegisterEventSink(sink)
+
+	// add two objects
+	if _, err := ss.addTestObject("foo", newTestObject(1)); err != nil {
+		t.Fatal(err)
+	} else if _, err := ss.addTestObject("bar", newTestObject(1)); err != nil {
+		t.Fatal(err)
+	}
+
+	// assert the event of the second object is still published
+	select {
+	case e := <-sink.ChannelEventSink:
+		if e, ok := e.(ObjectCreatedEvent); !ok || e.Key != "bar" {
+			t.Fatal("unexpected event", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected event")
+	}
+}
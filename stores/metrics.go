@@ -4,10 +4,16 @@ import (
 	"context"
 	"time"
 
+	"go.sia.tech/core/types"
 	"go.sia.tech/renterd/api"
 	sql "go.sia.tech/renterd/stores/sql"
 )
 
+// slabDownloadMetricsRetention is the duration for which slab download metrics
+// are kept, a metric is recorded for every slab that is downloaded so older
+// metrics are pruned whenever new ones are recorded.
+const slabDownloadMetricsRetention = 7 * 24 * time.Hour
+
 func (s *SQLStore) ContractMetrics(ctx context.Context, start time.Time, n uint64, interval time.Duration, opts api.ContractMetricsQueryOpts) (metrics []api.ContractMetric, err error) {
 	err = s.dbMetrics.Transaction(ctx, func(tx sql.MetricsDatabaseTx) (txErr error) {
 		metrics, txErr = tx.ContractMetrics(ctx, start, n, interval, opts)
@@ -24,6 +30,16 @@ func (s *SQLStore) ContractPruneMetrics(ctx context.Context, start time.Time, n
 	return
 }
 
+// LatestContractMetrics returns the most recent contract metric of every
+// contract that was recorded before the given time.
+func (s *SQLStore) LatestContractMetrics(ctx context.Context, before time.Time) (metrics []api.ContractMetric, err error) {
+	err = s.dbMetrics.Transaction(ctx, func(tx sql.MetricsDatabaseTx) (txErr error) {
+		metrics, txErr = tx.LatestContractMetrics(ctx, before)
+		return
+	})
+	return
+}
+
 func (s *SQLStore) RecordContractMetric(ctx context.Context, metrics ...api.ContractMetric) error {
 	return s.dbMetrics.Transaction(ctx, func(tx sql.MetricsDatabaseTx) error {
 		return tx.RecordContractMetric(ctx, metrics...)
@@ -36,12 +52,29 @@ func (s *SQLStore) RecordContractPruneMetric(ctx context.Context, metrics ...api
 	})
 }
 
+func (s *SQLStore) RecordSlabDownloadMetric(ctx context.Context, metrics ...api.SlabDownloadMetric) error {
+	return s.dbMetrics.Transaction(ctx, func(tx sql.MetricsDatabaseTx) error {
+		if err := tx.RecordSlabDownloadMetric(ctx, metrics...); err != nil {
+			return err
+		}
+		return tx.PruneMetrics(ctx, api.MetricSlabDownload, time.Now().Add(-slabDownloadMetricsRetention))
+	})
+}
+
 func (s *SQLStore) RecordWalletMetric(ctx context.Context, metrics ...api.WalletMetric) error {
 	return s.dbMetrics.Transaction(ctx, func(tx sql.MetricsDatabaseTx) error {
 		return tx.RecordWalletMetric(ctx, metrics...)
 	})
 }
 
+func (s *SQLStore) SlabDownloadMetrics(ctx context.Context, start time.Time, n uint64, interval time.Duration, opts api.SlabDownloadMetricsQueryOpts) (metrics []api.SlabDownloadMetric, err error) {
+	err = s.dbMetrics.Transaction(ctx, func(tx sql.MetricsDatabaseTx) (txErr error) {
+		metrics, txErr = tx.SlabDownloadMetrics(ctx, start, n, interval, opts)
+		return
+	})
+	return
+}
+
 func (s *SQLStore) WalletMetrics(ctx context.Context, start time.Time, n uint64, interval time.Duration, opts api.WalletMetricsQueryOpts) (metrics []api.WalletMetric, err error) {
 	err = s.dbMetrics.Transaction(ctx, func(tx sql.MetricsDatabaseTx) (txErr error) {
 		metrics, txErr = tx.WalletMetrics(ctx, start, n, interval, opts)
@@ -50,6 +83,14 @@ func (s *SQLStore) WalletMetrics(ctx context.Context, start time.Time, n uint64,
 	return
 }
 
+func (s *SQLStore) MetricsSummary(ctx context.Context, fcids []types.FileContractID) (summary api.MetricsSummary, err error) {
+	err = s.dbMetrics.Transaction(ctx, func(tx sql.MetricsDatabaseTx) (txErr error) {
+		summary, txErr = tx.MetricsSummary(ctx, fcids)
+		return
+	})
+	return
+}
+
 func (s *SQLStore) PruneMetrics(ctx context.Context, metric string, cutoff time.Time) error {
 	return s.dbMetrics.Transaction(ctx, func(tx sql.MetricsDatabaseTx) error {
 		return tx.PruneMetrics(ctx, metric, cutoff)
@@ -11,6 +11,7 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"go.sia.tech/core/types"
 	"go.sia.tech/renterd/api"
+	"go.sia.tech/renterd/object"
 	"go.sia.tech/renterd/stores/sql"
 	"lukechampine.com/frand"
 )
@@ -235,6 +236,46 @@ func TestContractPruneMetrics(t *testing.T) {
 	}
 }
 
+func TestContractPruneMetricsAccumulate(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// record two metrics for the same contract within the same 5' window and
+	// one in the next window
+	start := time.Now().Truncate(5 * time.Minute)
+	for i, ts := range []time.Time{start, start.Add(time.Minute), start.Add(5 * time.Minute)} {
+		if err := ss.RecordContractPruneMetric(context.Background(), api.ContractPruneMetric{
+			Timestamp:  api.TimeRFC3339(ts),
+			ContractID: types.FileContractID{1},
+			HostKey:    types.PublicKey{1},
+			Pruned:     10,
+			Remaining:  uint64(100 - i),
+			Duration:   time.Second,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// assert the metrics in the first window were accumulated
+	var n int
+	if err := ss.DBMetrics().QueryRow(context.Background(), "SELECT COUNT(*) FROM contract_prunes").Scan(&n); err != nil {
+		t.Fatal(err)
+	} else if n != 2 {
+		t.Fatalf("expected 2 metrics, got %v", n)
+	}
+
+	metrics, err := ss.ContractPruneMetrics(context.Background(), start, 2, 5*time.Minute, api.ContractPruneMetricsQueryOpts{})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(metrics) != 2 {
+		t.Fatalf("expected 2 metrics, got %v", len(metrics))
+	} else if m := metrics[0]; m.Pruned != 20 || m.Remaining != 99 || m.Duration != 2*time.Second {
+		t.Fatalf("unexpected metric, %+v", m)
+	} else if m := metrics[1]; m.Pruned != 10 || m.Remaining != 98 || m.Duration != time.Second {
+		t.Fatalf("unexpected metric, %+v", m)
+	}
+}
+
 func TestNormaliseTimestamp(t *testing.T) {
 	tests := []struct {
 		start    time.Time
@@ -275,6 +316,7 @@ func TestWalletMetrics(t *testing.T) {
 
 	// Create metrics to query.
 	times := []time.Time{time.UnixMilli(3), time.UnixMilli(1), time.UnixMilli(2)}
+	recorded := make(map[int64]api.WalletMetric)
 	for _, recordedTime := range times {
 		metric := api.WalletMetric{
 			Timestamp:   api.TimeRFC3339(recordedTime),
@@ -286,6 +328,7 @@ func TestWalletMetrics(t *testing.T) {
 		if err := ss.RecordWalletMetric(context.Background(), metric); err != nil {
 			t.Fatal(err)
 		}
+		recorded[recordedTime.UnixMilli()] = metric
 	}
 
 	// Fetch all metrcis
@@ -300,6 +343,13 @@ func TestWalletMetrics(t *testing.T) {
 		t.Fatalf("expected metrics to be sorted by time, %+v", metrics)
 	}
 
+	// Assert the balances were stored in the right columns
+	for _, m := range metrics {
+		if expected := recorded[m.Timestamp.Std().UnixMilli()]; !cmp.Equal(m, expected, cmp.Comparer(api.CompareTimeRFC3339)) {
+			t.Fatal("unexpected metric", cmp.Diff(m, expected, cmp.Comparer(api.CompareTimeRFC3339)))
+		}
+	}
+
 	// Prune metrics
 	if err := ss.PruneMetrics(context.Background(), api.MetricWallet, time.UnixMilli(3)); err != nil {
 		t.Fatal(err)
@@ -310,6 +360,74 @@ func TestWalletMetrics(t *testing.T) {
 	}
 }
 
+func TestSlabDownloadMetrics(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// create metrics to query, the first two for the same slab
+	start := time.Now().Truncate(time.Millisecond)
+	hash1 := api.SlabKeyHash(object.GenerateEncryptionKey(object.EncryptionKeyTypeSalted))
+	hash2 := api.SlabKeyHash(object.GenerateEncryptionKey(object.EncryptionKeyTypeSalted))
+	recorded := []api.SlabDownloadMetric{
+		{Timestamp: api.TimeRFC3339(start), SlabKeyHash: hash1, Duration: api.DurationMS(time.Second), Success: true},
+		{Timestamp: api.TimeRFC3339(start.Add(time.Millisecond)), SlabKeyHash: hash1, Duration: api.DurationMS(2 * time.Second), Success: false},
+		{Timestamp: api.TimeRFC3339(start.Add(2 * time.Millisecond)), SlabKeyHash: hash2, Duration: api.DurationMS(3 * time.Second), Success: true},
+	}
+	if err := ss.RecordSlabDownloadMetric(context.Background(), recorded...); err != nil {
+		t.Fatal(err)
+	}
+
+	// fetch all metrics
+	metrics, err := ss.SlabDownloadMetrics(context.Background(), start, 3, time.Millisecond, api.SlabDownloadMetricsQueryOpts{})
+	if err != nil {
+		t.Fatal(err)
+	} else if !cmp.Equal(metrics, recorded, cmp.Comparer(api.CompareTimeRFC3339)) {
+		t.Fatal(cmp.Diff(metrics, recorded, cmp.Comparer(api.CompareTimeRFC3339)))
+	}
+
+	// assert downloads within the same interval are all returned
+	metrics, err = ss.SlabDownloadMetrics(context.Background(), start, 1, 3*time.Millisecond, api.SlabDownloadMetricsQueryOpts{})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(metrics) != 3 {
+		t.Fatalf("expected 3 metrics, got %v", len(metrics))
+	}
+
+	// filter by slab
+	metrics, err = ss.SlabDownloadMetrics(context.Background(), start, 3, time.Millisecond, api.SlabDownloadMetricsQueryOpts{SlabKeyHash: hash1})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(metrics) != 2 {
+		t.Fatalf("expected 2 metrics, got %v", len(metrics))
+	} else if metrics[0].Success != true || metrics[1].Success != false {
+		t.Fatalf("unexpected metrics %+v", metrics)
+	}
+
+	// prune metrics
+	if err := ss.PruneMetrics(context.Background(), api.MetricSlabDownload, start.Add(2*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	} else if metrics, err := ss.SlabDownloadMetrics(context.Background(), start, 3, time.Millisecond, api.SlabDownloadMetricsQueryOpts{}); err != nil {
+		t.Fatal(err)
+	} else if len(metrics) != 1 {
+		t.Fatalf("expected 1 metric, got %v", len(metrics))
+	}
+
+	// assert metrics outside of the retention window are pruned when
+	// recording new ones
+	if err := ss.RecordSlabDownloadMetric(context.Background(), api.SlabDownloadMetric{
+		Timestamp:   api.TimeRFC3339(start.Add(-slabDownloadMetricsRetention - time.Hour)),
+		SlabKeyHash: hash1,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	if err := ss.DBMetrics().QueryRow(context.Background(), "SELECT COUNT(*) FROM slab_downloads").Scan(&n); err != nil {
+		t.Fatal(err)
+	} else if n != 1 {
+		t.Fatalf("expected 1 metric, got %v", n)
+	}
+}
+
 func normaliseTimestamp(start time.Time, interval time.Duration, t sql.UnixTimeMS) sql.UnixTimeMS {
 	startMS := start.UnixMilli()
 	toNormaliseMS := time.Time(t).UnixMilli()
@@ -320,3 +438,126 @@ func normaliseTimestamp(start time.Time, interval time.Duration, t sql.UnixTimeM
 	normalizedMS := (toNormaliseMS-startMS)/intervalMS*intervalMS + start.UnixMilli()
 	return sql.UnixTimeMS(time.UnixMilli(normalizedMS))
 }
+
+func TestMetricsSummary(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// assert an empty summary is returned if no metrics were recorded
+	fcid1, fcid2, fcid3 := types.FileContractID{1}, types.FileContractID{2}, types.FileContractID{3}
+	active := []types.FileContractID{fcid1, fcid2}
+	summary, err := ss.MetricsSummary(context.Background(), active)
+	if err != nil {
+		t.Fatal(err)
+	} else if summary.Contract.Contracts != 0 || summary.ContractPrune.Contracts != 0 || summary.Wallet != nil {
+		t.Fatalf("unexpected summary %+v", summary)
+	}
+
+	// record two metrics for two contracts each and a metric for a contract
+	// that is no longer active
+	for _, m := range []api.ContractMetric{
+		{Timestamp: api.TimeRFC3339(time.UnixMilli(1)), ContractID: fcid1, RemainingFunds: types.NewCurrency64(100), UploadSpending: types.NewCurrency64(1)},
+		{Timestamp: api.TimeRFC3339(time.UnixMilli(2)), ContractID: fcid1, RemainingFunds: types.NewCurrency64(90), UploadSpending: types.NewCurrency64(2)},
+		{Timestamp: api.TimeRFC3339(time.UnixMilli(1)), ContractID: fcid2, RemainingFunds: types.NewCurrency64(50), UploadSpending: types.NewCurrency64(3)},
+		{Timestamp: api.TimeRFC3339(time.UnixMilli(3)), ContractID: fcid2, RemainingFunds: types.NewCurrency64(40), UploadSpending: types.NewCurrency64(4)},
+		{Timestamp: api.TimeRFC3339(time.UnixMilli(4)), ContractID: fcid3, RemainingFunds: types.NewCurrency64(1000), UploadSpending: types.NewCurrency64(1000)},
+	} {
+		if err := ss.RecordContractMetric(context.Background(), m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, m := range []api.ContractPruneMetric{
+		{Timestamp: api.TimeRFC3339(time.UnixMilli(1)), ContractID: fcid1, Pruned: 10, Remaining: 5},
+		{Timestamp: api.TimeRFC3339(time.UnixMilli(4)), ContractID: fcid1, Pruned: 1, Remaining: 4},
+		{Timestamp: api.TimeRFC3339(time.UnixMilli(2)), ContractID: fcid2, Pruned: 2, Remaining: 3},
+		{Timestamp: api.TimeRFC3339(time.UnixMilli(5)), ContractID: fcid3, Pruned: 100, Remaining: 100},
+	} {
+		if err := ss.RecordContractPruneMetric(context.Background(), m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// record two wallet metrics
+	wm := api.WalletMetric{
+		Timestamp: api.TimeRFC3339(time.UnixMilli(5)),
+		Confirmed: types.NewCurrency(1, 2),
+		Spendable: types.NewCurrency(3, 4),
+		Immature:  types.NewCurrency(5, 6),
+	}
+	if err := ss.RecordWalletMetric(context.Background(), api.WalletMetric{Timestamp: api.TimeRFC3339(time.UnixMilli(1))}, wm); err != nil {
+		t.Fatal(err)
+	}
+
+	// assert the summary only contains the latest metrics of active contracts
+	summary, err = ss.MetricsSummary(context.Background(), active)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cs := summary.Contract; cs.Contracts != 2 {
+		t.Fatalf("expected 2 contracts, got %v", cs.Contracts)
+	} else if !cs.Timestamp.Std().Equal(time.UnixMilli(3)) {
+		t.Fatalf("unexpected timestamp %v", cs.Timestamp)
+	} else if !cs.RemainingFunds.Equals(types.NewCurrency64(130)) {
+		t.Fatalf("unexpected remaining funds %v", cs.RemainingFunds)
+	} else if !cs.UploadSpending.Equals(types.NewCurrency64(6)) {
+		t.Fatalf("unexpected upload spending %v", cs.UploadSpending)
+	}
+	// NOTE: both prune metrics of fcid1 fall within the same window, so they
+	// were accumulated into a single metric
+	if ps := summary.ContractPrune; ps.Contracts != 2 {
+		t.Fatalf("expected 2 contracts, got %v", ps.Contracts)
+	} else if !ps.Timestamp.Std().Equal(time.UnixMilli(4)) {
+		t.Fatalf("unexpected timestamp %v", ps.Timestamp)
+	} else if ps.Pruned != 13 || ps.Remaining != 7 {
+		t.Fatalf("unexpected pruned/remaining %v/%v", ps.Pruned, ps.Remaining)
+	}
+	if summary.Wallet == nil {
+		t.Fatal("expected wallet metric")
+	} else if !cmp.Equal(*summary.Wallet, wm, cmp.Comparer(api.CompareTimeRFC3339)) {
+		t.Fatal("unexpected wallet metric", cmp.Diff(*summary.Wallet, wm, cmp.Comparer(api.CompareTimeRFC3339)))
+	}
+}
+
+func TestLatestContractMetrics(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// record metrics for two contracts
+	fcid1, fcid2 := types.FileContractID{1}, types.FileContractID{2}
+	hour := int64(time.Hour / time.Millisecond)
+	for _, m := range []api.ContractMetric{
+		{Timestamp: api.TimeRFC3339(time.UnixMilli(hour)), ContractID: fcid1, UploadSpending: types.NewCurrency64(1)},
+		{Timestamp: api.TimeRFC3339(time.UnixMilli(2 * hour)), ContractID: fcid1, UploadSpending: types.NewCurrency64(2)},
+		{Timestamp: api.TimeRFC3339(time.UnixMilli(4 * hour)), ContractID: fcid1, UploadSpending: types.NewCurrency64(3)},
+		{Timestamp: api.TimeRFC3339(time.UnixMilli(4 * hour)), ContractID: fcid2, UploadSpending: types.NewCurrency64(4)},
+	} {
+		if err := ss.RecordContractMetric(context.Background(), m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// assert only the latest metric before the given time is returned
+	metrics, err := ss.LatestContractMetrics(context.Background(), time.UnixMilli(3*hour))
+	if err != nil {
+		t.Fatal(err)
+	} else if len(metrics) != 1 {
+		t.Fatalf("expected 1 metric, got %v", len(metrics))
+	} else if metrics[0].ContractID != fcid1 || !metrics[0].UploadSpending.Equals(types.NewCurrency64(2)) {
+		t.Fatalf("unexpected metric %+v", metrics[0])
+	}
+
+	// assert both contracts are returned if the time is after all metrics
+	metrics, err = ss.LatestContractMetrics(context.Background(), time.UnixMilli(5*hour))
+	if err != nil {
+		t.Fatal(err)
+	} else if len(metrics) != 2 {
+		t.Fatalf("expected 2 metrics, got %v", len(metrics))
+	}
+	for _, m := range metrics {
+		if m.ContractID == fcid1 && !m.UploadSpending.Equals(types.NewCurrency64(3)) {
+			t.Fatalf("unexpected metric %+v", m)
+		} else if m.ContractID == fcid2 && !m.UploadSpending.Equals(types.NewCurrency64(4)) {
+			t.Fatalf("unexpected metric %+v", m)
+		}
+	}
+}
@@ -4,16 +4,22 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"time"
 
 	"go.sia.tech/renterd/api"
 	"go.sia.tech/renterd/object"
 	sql "go.sia.tech/renterd/stores/sql"
+	"go.uber.org/zap"
 )
 
-func (s *SQLStore) CreateMultipartUpload(ctx context.Context, bucket, key string, ec object.EncryptionKey, mimeType string, metadata api.ObjectUserMetadata) (api.MultipartCreateResponse, error) {
+// multipartUploadExpiryInterval is the interval at which expired multipart
+// uploads are aborted.
+const multipartUploadExpiryInterval = time.Hour
+
+func (s *SQLStore) CreateMultipartUpload(ctx context.Context, bucket, key string, ec object.EncryptionKey, mimeType string, metadata api.ObjectUserMetadata, ttl time.Duration) (api.MultipartCreateResponse, error) {
 	var uploadID string
 	err := s.db.Transaction(ctx, func(tx sql.DatabaseTx) (err error) {
-		uploadID, err = tx.InsertMultipartUpload(ctx, bucket, key, ec, mimeType, metadata)
+		uploadID, err = tx.InsertMultipartUpload(ctx, bucket, key, ec, mimeType, metadata, ttl)
 		return
 	})
 	if err != nil {
@@ -65,6 +71,40 @@ func (s *SQLStore) AbortMultipartUpload(ctx context.Context, bucket, key string,
 	return nil
 }
 
+// ExpireMultipartUploads aborts all multipart uploads whose TTL expired as well
+// as uploads without a TTL that were created more than olderThan ago.
+func (s *SQLStore) ExpireMultipartUploads(ctx context.Context, olderThan time.Duration) (n int, err error) {
+	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) (err error) {
+		n, err = tx.ExpireMultipartUploads(ctx, olderThan)
+		return
+	})
+	if err != nil {
+		return 0, err
+	} else if n > 0 {
+		s.triggerSlabPruning()
+	}
+	return n, nil
+}
+
+func (s *SQLStore) expireMultipartUploadsLoop(olderThan time.Duration) {
+	t := time.NewTicker(multipartUploadExpiryInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+		case <-s.shutdownCtx.Done():
+			return
+		}
+
+		if n, err := s.ExpireMultipartUploads(s.shutdownCtx, olderThan); err != nil {
+			s.logger.Errorw("failed to expire multipart uploads", zap.Error(err))
+		} else if n > 0 {
+			s.logger.Infow("expired multipart uploads", "expired", n)
+		}
+	}
+}
+
 func (s *SQLStore) CompleteMultipartUpload(ctx context.Context, bucket, key string, uploadID string, parts []api.MultipartCompletedPart, opts api.CompleteMultipartOptions) (_ api.MultipartCompleteResponse, err error) {
 	// Sanity check input parts.
 	if !sort.SliceIsSorted(parts, func(i, j int) bool {
@@ -80,19 +120,27 @@ func (s *SQLStore) CompleteMultipartUpload(ctx context.Context, bucket, key stri
 
 	var eTag string
 	var prune bool
-	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
-		// Delete potentially existing object.
-		prune, err = tx.DeleteObject(ctx, bucket, key)
+	err = s.transactionWithEvents(ctx, func(tx sql.DatabaseTx) ([]Event, error) {
+		// Delete or archive potentially existing object.
+		var versioned bool
+		prune, versioned, err = replaceObject(ctx, tx, bucket, key)
 		if err != nil {
-			return fmt.Errorf("failed to delete object: %w", err)
+			return nil, fmt.Errorf("failed to replace object: %w", err)
 		}
 
 		// Complete upload
 		eTag, err = tx.CompleteMultipartUpload(ctx, bucket, key, uploadID, parts, opts)
 		if err != nil {
-			return fmt.Errorf("failed to complete multipart upload: %w", err)
+			return nil, fmt.Errorf("failed to complete multipart upload: %w", err)
+		}
+
+		// Version it if necessary.
+		if versioned {
+			if _, err := tx.NewObjectVersion(ctx, bucket, key); err != nil {
+				return nil, fmt.Errorf("failed to version object: %w", err)
+			}
 		}
-		return nil
+		return []Event{ObjectCreatedEvent{Bucket: bucket, Key: key, ETag: eTag, Timestamp: time.Now()}}, nil
 	})
 	if err != nil {
 		return api.MultipartCompleteResponse{}, err
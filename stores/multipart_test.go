@@ -3,6 +3,7 @@ package stores
 import (
 	"context"
 	"encoding/hex"
+	"errors"
 	"reflect"
 	"sort"
 	"strings"
@@ -47,7 +48,7 @@ func TestMultipartUploadWithUploadPackingRegression(t *testing.T) {
 	totalSize := int64(nParts * partSize)
 
 	// Upload parts until we have enough data for 2 buffers.
-	resp, err := ss.CreateMultipartUpload(ctx, testBucket, objName, object.NoOpKey, testMimeType, testMetadata)
+	resp, err := ss.CreateMultipartUpload(ctx, testBucket, objName, object.NoOpKey, testMimeType, testMetadata, 0)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -184,15 +185,15 @@ func TestMultipartUploads(t *testing.T) {
 	defer ss.Close()
 
 	// create 3 multipart uploads, the first 2 have the same path
-	resp1, err := ss.CreateMultipartUpload(context.Background(), testBucket, "/foo", object.NoOpKey, testMimeType, testMetadata)
+	resp1, err := ss.CreateMultipartUpload(context.Background(), testBucket, "/foo", object.NoOpKey, testMimeType, testMetadata, 0)
 	if err != nil {
 		t.Fatal(err)
 	}
-	resp2, err := ss.CreateMultipartUpload(context.Background(), testBucket, "/foo", object.NoOpKey, testMimeType, testMetadata)
+	resp2, err := ss.CreateMultipartUpload(context.Background(), testBucket, "/foo", object.NoOpKey, testMimeType, testMetadata, 0)
 	if err != nil {
 		t.Fatal(err)
 	}
-	resp3, err := ss.CreateMultipartUpload(context.Background(), testBucket, "/foo2", object.NoOpKey, testMimeType, testMetadata)
+	resp3, err := ss.CreateMultipartUpload(context.Background(), testBucket, "/foo2", object.NoOpKey, testMimeType, testMetadata, 0)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -272,16 +273,70 @@ func TestMultipartUploads(t *testing.T) {
 	}
 }
 
+func TestExpireMultipartUploads(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+	ctx := context.Background()
+
+	// create an upload with a short TTL, one without a TTL and one with a
+	// long TTL
+	short, err := ss.CreateMultipartUpload(ctx, testBucket, "/short", object.NoOpKey, testMimeType, testMetadata, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	noTTL, err := ss.CreateMultipartUpload(ctx, testBucket, "/nottl", object.NoOpKey, testMimeType, testMetadata, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	long, err := ss.CreateMultipartUpload(ctx, testBucket, "/long", object.NoOpKey, testMimeType, testMetadata, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	// helper to assert whether an upload exists
+	assertExists := func(uploadID string, exists bool) {
+		t.Helper()
+		_, err := ss.MultipartUpload(ctx, uploadID)
+		if exists && err != nil {
+			t.Fatal(err)
+		} else if !exists && !errors.Is(err, api.ErrMultipartUploadNotFound) {
+			t.Fatal("expected upload to be expired", err)
+		}
+	}
+
+	// expire uploads without a threshold, only the upload with the short TTL
+	// should be expired
+	if n, err := ss.ExpireMultipartUploads(ctx, 0); err != nil {
+		t.Fatal(err)
+	} else if n != 1 {
+		t.Fatalf("expected 1 expired upload, got %v", n)
+	}
+	assertExists(short.UploadID, false)
+	assertExists(noTTL.UploadID, true)
+	assertExists(long.UploadID, true)
+
+	// expire uploads older than 1ms, the upload with the long TTL should be
+	// kept since its TTL takes precedence
+	if n, err := ss.ExpireMultipartUploads(ctx, time.Millisecond); err != nil {
+		t.Fatal(err)
+	} else if n != 1 {
+		t.Fatalf("expected 1 expired upload, got %v", n)
+	}
+	assertExists(noTTL.UploadID, false)
+	assertExists(long.UploadID, true)
+}
+
 func TestMultipartUploadEmptyObjects(t *testing.T) {
 	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
 	defer ss.Close()
 
 	// create 2 multipart parts
-	resp1, err := ss.CreateMultipartUpload(context.Background(), testBucket, "/foo1", object.NoOpKey, testMimeType, testMetadata)
+	resp1, err := ss.CreateMultipartUpload(context.Background(), testBucket, "/foo1", object.NoOpKey, testMimeType, testMetadata, 0)
 	if err != nil {
 		t.Fatal(err)
 	}
-	resp2, err := ss.CreateMultipartUpload(context.Background(), testBucket, "/foo2", object.NoOpKey, testMimeType, testMetadata)
+	resp2, err := ss.CreateMultipartUpload(context.Background(), testBucket, "/foo2", object.NoOpKey, testMimeType, testMetadata, 0)
 	if err != nil {
 		t.Fatal(err)
 	}
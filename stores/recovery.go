@@ -0,0 +1,41 @@
+package stores
+
+import (
+	"context"
+	"fmt"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/stores/sql"
+)
+
+// recoveryBatchSize is the number of sector roots matched against the slabs in
+// a single transaction
+const recoveryBatchSize = 1000
+
+// RecoverObjects matches the given sector roots, which are fetched from the
+// hosts, back to the slabs that contain them. Every object referencing a
+// matched slab is recorded in the recovered_objects table, objects that were
+// deleted but still have noncurrent versions referencing the slab are recorded
+// as deleted. It returns the number of matched roots and newly recovered and
+// deleted objects.
+func (s *SQLStore) RecoverObjects(ctx context.Context, roots []types.Hash256) (matched, recovered, deleted uint64, _ error) {
+	for len(roots) > 0 {
+		batch := roots
+		if len(batch) > recoveryBatchSize {
+			batch = batch[:recoveryBatchSize]
+		}
+		roots = roots[len(batch):]
+
+		var m, r, d int64
+		if err := s.db.Transaction(ctx, func(tx sql.DatabaseTx) (err error) {
+			m, r, d, err = tx.RecoverObjects(ctx, batch)
+			return
+		}); err != nil {
+			return matched, recovered, deleted, fmt.Errorf("failed to recover objects: %w", err)
+		}
+		matched += uint64(m)
+		recovered += uint64(r)
+		deleted += uint64(d)
+	}
+	return
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
@@ -25,10 +26,11 @@ var (
 )
 
 type SlabBuffer struct {
-	dbID     uint
-	filename string
-	slabKey  object.EncryptionKey
-	maxSize  int64
+	dbID      uint
+	createdAt time.Time
+	filename  string
+	slabKey   object.EncryptionKey
+	maxSize   int64
 
 	mu          sync.Mutex
 	file        *os.File
@@ -46,6 +48,10 @@ type SlabBufferManager struct {
 	dir                             string
 	logger                          *zap.SugaredLogger
 
+	// appendMu is held for reading while data is appended to the buffers
+	// and for writing while the buffers are defragmented
+	appendMu sync.RWMutex
+
 	mu                sync.Mutex
 	completeBuffers   map[bufferGroupID][]*SlabBuffer
 	incompleteBuffers map[bufferGroupID][]*SlabBuffer
@@ -105,14 +111,20 @@ func newSlabBufferManager(ctx context.Context, a alerts.Alerter, db sql.Database
 			continue
 		}
 
-		// Create the slab buffer.
+		// Create the slab buffer, buffers without a creation time are
+		// considered to be created on startup.
+		createdAt := buffer.CreatedAt
+		if createdAt.IsZero() {
+			createdAt = time.Now()
+		}
 		sb := &SlabBuffer{
-			dbID:     uint(buffer.ID),
-			filename: buffer.Filename,
-			slabKey:  buffer.Key,
-			maxSize:  int64(bufferedSlabSize(buffer.MinShards)),
-			file:     file,
-			size:     buffer.Size,
+			dbID:      uint(buffer.ID),
+			createdAt: createdAt,
+			filename:  buffer.Filename,
+			slabKey:   buffer.Key,
+			maxSize:   int64(bufferedSlabSize(buffer.MinShards)),
+			file:      file,
+			size:      buffer.Size,
 		}
 		// Add the buffer to the manager.
 		gid := bufferGID(buffer.MinShards, buffer.TotalShards)
@@ -149,6 +161,9 @@ func (mgr *SlabBufferManager) Close() error {
 }
 
 func (mgr *SlabBufferManager) AddPartialSlab(ctx context.Context, data []byte, minShards, totalShards uint8) (_ []object.SlabSlice, _ int64, err error) {
+	mgr.appendMu.RLock()
+	defer mgr.appendMu.RUnlock()
+
 	gid := bufferGID(minShards, totalShards)
 
 	// Sanity check input.
@@ -228,6 +243,144 @@ func (mgr *SlabBufferManager) AddPartialSlab(ctx context.Context, data []byte, m
 	return slabs, mgr.BufferSize(gid), nil
 }
 
+// DefragSlabBuffer merges incomplete buffers with the same shard
+// configuration by moving the data of smaller buffers into the fullest buffer
+// that still has enough space left, reducing the number of partial slabs that
+// need to be uploaded. Buffers are always moved as a whole so slices never
+// have to be split. It returns the number of buffers that were merged into
+// other buffers and the number of bytes that were moved.
+func (mgr *SlabBufferManager) DefragSlabBuffer(ctx context.Context) (merged int, moved int64, _ error) {
+	// Block appends while we move data around.
+	mgr.appendMu.Lock()
+	defer mgr.appendMu.Unlock()
+
+	mgr.mu.Lock()
+	groups := make(map[bufferGroupID][]*SlabBuffer)
+	for gid, buffers := range mgr.incompleteBuffers {
+		if len(buffers) > 1 {
+			groups[gid] = append([]*SlabBuffer{}, buffers...)
+		}
+	}
+	mgr.mu.Unlock()
+
+	for gid, buffers := range groups {
+		// Start with the smallest buffers to move as little data as possible.
+		sort.Slice(buffers, func(i, j int) bool {
+			return buffers[i].size < buffers[j].size
+		})
+
+		for i, src := range buffers {
+			var dst *SlabBuffer
+			for _, b := range buffers[i+1:] {
+				if b == nil || b.size+src.size > b.maxSize {
+					continue
+				} else if isCompleteBuffer(b.size, b.maxSize, mgr.bufferedSlabCompletionThreshold) {
+					continue
+				} else if dst == nil || b.size > dst.size {
+					dst = b
+				}
+			}
+			if dst == nil {
+				continue
+			}
+
+			size := src.size
+			if err := mgr.mergeBuffers(ctx, gid, dst, src); err != nil {
+				return merged, moved, fmt.Errorf("failed to merge buffer %v into %v: %w", src.filename, dst.filename, err)
+			}
+			buffers[i] = nil
+			merged++
+			moved += size
+		}
+	}
+	return merged, moved, nil
+}
+
+// FlushBuffers marks all incomplete, non-empty buffers that were created
+// before the given time as complete, which makes them available for upload.
+// It returns the number of flushed buffers per shard configuration.
+func (mgr *SlabBufferManager) FlushBuffers(createdBefore time.Time) (flushed []api.EventSlabBufferFlush) {
+	// Block appends so we don't flush a buffer while data is appended to it.
+	mgr.appendMu.Lock()
+	defer mgr.appendMu.Unlock()
+
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+
+	for gid, buffers := range mgr.incompleteBuffers {
+		var remaining []*SlabBuffer
+		for _, buffer := range buffers {
+			buffer.mu.Lock()
+			flush := buffer.size > 0 && buffer.createdAt.Before(createdBefore)
+			buffer.mu.Unlock()
+			if flush {
+				mgr.completeBuffers[gid] = append(mgr.completeBuffers[gid], buffer)
+			} else {
+				remaining = append(remaining, buffer)
+			}
+		}
+		if n := len(buffers) - len(remaining); n > 0 {
+			mgr.incompleteBuffers[gid] = remaining
+			flushed = append(flushed, api.EventSlabBufferFlush{
+				MinShards:   gid[0],
+				TotalShards: gid[1],
+				Buffers:     n,
+				Timestamp:   time.Now(),
+			})
+		}
+	}
+	return flushed
+}
+
+func (mgr *SlabBufferManager) mergeBuffers(ctx context.Context, gid bufferGroupID, dst, src *SlabBuffer) error {
+	// Append the data of the source buffer to the destination buffer. If we
+	// fail to update the database afterwards the appended data is overwritten
+	// by the next append.
+	data := make([]byte, src.size)
+	if _, err := src.file.ReadAt(data, 0); err != nil {
+		return fmt.Errorf("failed to read source buffer: %w", err)
+	} else if _, err := dst.file.WriteAt(data, dst.size); err != nil {
+		return fmt.Errorf("failed to write destination buffer: %w", err)
+	} else if err := dst.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync destination buffer: %w", err)
+	}
+
+	// Point the slices of the source buffer to the destination buffer.
+	if err := mgr.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+		return tx.MergeSlabBuffers(ctx, int64(src.dbID), int64(dst.dbID), dst.size)
+	}); err != nil {
+		return err
+	}
+
+	dst.mu.Lock()
+	dst.size += src.size
+	complete := isCompleteBuffer(dst.size, dst.maxSize, mgr.bufferedSlabCompletionThreshold)
+	dst.mu.Unlock()
+
+	// Remove the source buffer.
+	mgr.mu.Lock()
+	buffers := mgr.incompleteBuffers[gid]
+	for i := range buffers {
+		if buffers[i] == src {
+			mgr.incompleteBuffers[gid] = append(buffers[:i], buffers[i+1:]...)
+			break
+		}
+	}
+	delete(mgr.buffersByKey, src.slabKey.String())
+	mgr.mu.Unlock()
+
+	if err := src.file.Close(); err != nil {
+		mgr.logger.Errorf("failed to close buffer %v: %v", src.filename, err)
+	} else if err := os.RemoveAll(filepath.Join(mgr.dir, src.filename)); err != nil {
+		mgr.logger.Errorf("failed to remove buffer %v: %v", src.filename, err)
+	}
+
+	if complete {
+		return mgr.markBufferComplete(dst, gid)
+	}
+	return nil
+}
+
 func (mgr *SlabBufferManager) BufferSize(gid bufferGroupID) (total int64) {
 	mgr.mu.Lock()
 	defer mgr.mu.Unlock()
@@ -470,10 +623,11 @@ func createSlabBuffer(ctx context.Context, tx sql.DatabaseTx, dir string, minSha
 		return nil, fmt.Errorf("failed to insert buffered slab: %w", err)
 	}
 	return &SlabBuffer{
-		dbID:     uint(bufferedSlabID),
-		filename: fileName,
-		slabKey:  ec,
-		maxSize:  int64(bufferedSlabSize(minShards)),
-		file:     file,
+		dbID:      uint(bufferedSlabID),
+		createdAt: time.Now(),
+		filename:  fileName,
+		slabKey:   ec,
+		maxSize:   int64(bufferedSlabSize(minShards)),
+		file:      file,
 	}, err
 }
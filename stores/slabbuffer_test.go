@@ -1,10 +1,16 @@
 package stores
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"go.sia.tech/renterd/object"
+	"go.sia.tech/renterd/stores/sql"
 	"lukechampine.com/frand"
 )
 
@@ -101,3 +107,147 @@ func TestMarkBufferCompleteTwice(t *testing.T) {
 		t.Fatal("expected error marking buffer complete twice", err)
 	}
 }
+
+func TestDefragSlabBuffer(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+	mgr := ss.slabBufferMgr
+	gid := bufferGID(1, 2)
+
+	// add a partial slab to a buffer
+	addPartialSlab := func(data []byte) []object.SlabSlice {
+		t.Helper()
+		slices, _, err := ss.AddPartialSlab(context.Background(), data, 1, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return slices
+	}
+	small, large := frand.Bytes(100), frand.Bytes(200)
+	slicesSmall := addPartialSlab(small)
+
+	// hide the first buffer from the manager to force a second buffer to be
+	// created
+	first := mgr.incompleteBuffers[gid]
+	mgr.incompleteBuffers[gid] = nil
+	slicesLarge := addPartialSlab(large)
+	mgr.incompleteBuffers[gid] = append(first, mgr.incompleteBuffers[gid]...)
+	if len(mgr.incompleteBuffers[gid]) != 2 {
+		t.Fatalf("expected 2 incomplete buffers, got %v", len(mgr.incompleteBuffers[gid]))
+	}
+	smallBuffer := mgr.incompleteBuffers[gid][0]
+
+	// add objects referencing both buffers
+	if _, err := ss.addTestObject("/small", object.Object{Key: object.GenerateEncryptionKey(object.EncryptionKeyTypeSalted), Slabs: slicesSmall}); err != nil {
+		t.Fatal(err)
+	} else if _, err := ss.addTestObject("/large", object.Object{Key: object.GenerateEncryptionKey(object.EncryptionKeyTypeSalted), Slabs: slicesLarge}); err != nil {
+		t.Fatal(err)
+	}
+
+	// defragment the buffer, the small buffer should be merged into the
+	// large one
+	if n, err := ss.DefragSlabBuffer(context.Background()); err != nil {
+		t.Fatal(err)
+	} else if n != 1 {
+		t.Fatalf("expected 1 merged buffer, got %v", n)
+	} else if len(mgr.incompleteBuffers[gid]) != 1 {
+		t.Fatalf("expected 1 incomplete buffer, got %v", len(mgr.incompleteBuffers[gid]))
+	} else if buffer := mgr.incompleteBuffers[gid][0]; buffer.size != int64(len(small)+len(large)) {
+		t.Fatalf("unexpected buffer size %v", buffer.size)
+	} else if _, err := os.Stat(filepath.Join(mgr.dir, smallBuffer.filename)); !os.IsNotExist(err) {
+		t.Fatal("expected small buffer to be removed from disk", err)
+	} else if runs, moved := ss.SlabBufferDefragStats(); runs != 1 || moved != uint64(len(small)) {
+		t.Fatalf("unexpected stats %v %v", runs, moved)
+	}
+
+	// assert both objects point to the remaining buffer and their data is
+	// intact
+	for key, data := range map[string][]byte{"/small": small, "/large": large} {
+		obj, err := ss.Object(context.Background(), testBucket, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		slice := obj.Slabs[0]
+		if slice.EncryptionKey.String() != mgr.incompleteBuffers[gid][0].slabKey.String() {
+			t.Fatal("unexpected slab key")
+		} else if partial, err := ss.FetchPartialSlab(context.Background(), slice.EncryptionKey, slice.Offset, slice.Length); err != nil {
+			t.Fatal(err)
+		} else if !bytes.Equal(partial, data) {
+			t.Fatal("data mismatch", key)
+		}
+	}
+
+	// assert the buffer is loaded correctly on startup
+	buffers, _, err := func() (buffers []sql.LoadedSlabBuffer, orphans []string, err error) {
+		err = ss.db.Transaction(context.Background(), func(tx sql.DatabaseTx) error {
+			buffers, orphans, err = tx.LoadSlabBuffers(context.Background())
+			return err
+		})
+		return
+	}()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(buffers) != 1 || buffers[0].Size != int64(len(small)+len(large)) {
+		t.Fatal("unexpected buffers", buffers)
+	}
+}
+
+func TestFlushSlabBuffers(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add a partial slab to create an incomplete buffer
+	minShards, totalShards := uint8(1), uint8(2)
+	if _, _, err := ss.AddPartialSlab(context.Background(), frand.Bytes(100), minShards, totalShards); err != nil {
+		t.Fatal(err)
+	}
+
+	// assert the buffer isn't ready for upload
+	assertPackedSlabs := func(n int) {
+		t.Helper()
+		slabs, err := ss.PackedSlabsForUpload(context.Background(), 0, minShards, totalShards, -1)
+		if err != nil {
+			t.Fatal(err)
+		} else if len(slabs) != n {
+			t.Fatalf("expected %v packed slabs, got %v", n, len(slabs))
+		}
+	}
+	assertPackedSlabs(0)
+
+	// flush buffers created before the buffer was created
+	if flushed, err := ss.FlushSlabBuffers(context.Background(), time.Now().Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	} else if len(flushed) != 0 {
+		t.Fatalf("expected no flushed buffers, got %v", flushed)
+	}
+	assertPackedSlabs(0)
+
+	// reload the buffers to assert the creation time is persisted
+	mgr, err := newSlabBufferManager(context.Background(), ss.alerts, ss.db, ss.logger.Desugar(), 0, ss.slabBufferMgr.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mgr.Close()
+	if flushed := mgr.FlushBuffers(time.Now().Add(-time.Hour)); len(flushed) != 0 {
+		t.Fatalf("expected no flushed buffers, got %v", flushed)
+	}
+
+	// flush buffers created before now
+	if flushed, err := ss.FlushSlabBuffers(context.Background(), time.Now()); err != nil {
+		t.Fatal(err)
+	} else if len(flushed) != 1 {
+		t.Fatalf("expected 1 flush event, got %v", len(flushed))
+	} else if flushed[0].MinShards != minShards || flushed[0].TotalShards != totalShards || flushed[0].Buffers != 1 {
+		t.Fatalf("unexpected flush event, %+v", flushed[0])
+	}
+
+	// assert the buffer is ready for upload and new data goes into a new buffer
+	assertPackedSlabs(1)
+	if _, _, err := ss.AddPartialSlab(context.Background(), frand.Bytes(100), minShards, totalShards); err != nil {
+		t.Fatal(err)
+	} else if buffers, err := ss.SlabBuffers(context.Background()); err != nil {
+		t.Fatal(err)
+	} else if len(buffers) != 2 {
+		t.Fatalf("expected 2 buffers, got %v", len(buffers))
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"math"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.sia.tech/core/types"
@@ -25,6 +26,10 @@ type (
 		AnnouncementMaxAge            time.Duration
 		WalletAddress                 types.Address
 		SlabBufferCompletionThreshold int64
+		SlabBufferDefragInterval      time.Duration
+		MultipartUploadExpiry         time.Duration
+		LifecycleRulesInterval        time.Duration
+		WALCompactionThreshold        int64
 		Logger                        *zap.Logger
 		LongQueryDuration             time.Duration
 		LongTxDuration                time.Duration
@@ -56,7 +61,13 @@ type (
 		slabPruneSigChan chan struct{}
 		wg               sync.WaitGroup
 
+		slabBufferDefragRuns     atomic.Uint64
+		slabBufferBytesOptimized atomic.Uint64
+
+		events *eventQueue
+
 		mu           sync.Mutex
+		eventSink    EventSink
 		lastPrunedAt time.Time
 		closed       bool
 	}
@@ -99,6 +110,8 @@ func NewSQLStore(cfg Config) (*SQLStore, error) {
 		settings:      make(map[string]string),
 		walletAddress: cfg.WalletAddress,
 
+		events: newEventQueue(),
+
 		slabPruneSigChan: make(chan struct{}, 1),
 		lastPrunedAt:     time.Now(),
 
@@ -113,6 +126,47 @@ func NewSQLStore(cfg Config) (*SQLStore, error) {
 	if err := ss.initSlabPruning(); err != nil {
 		return nil, err
 	}
+
+	// start event publishing loop
+	ss.wg.Add(1)
+	go func() {
+		ss.publishEventsLoop()
+		ss.wg.Done()
+	}()
+
+	// start slab buffer defragmentation loop
+	if cfg.SlabBufferDefragInterval > 0 {
+		ss.wg.Add(1)
+		go func() {
+			ss.defragSlabBufferLoop(cfg.SlabBufferDefragInterval)
+			ss.wg.Done()
+		}()
+	}
+
+	// start multipart upload expiry loop
+	ss.wg.Add(1)
+	go func() {
+		ss.expireMultipartUploadsLoop(cfg.MultipartUploadExpiry)
+		ss.wg.Done()
+	}()
+
+	// start lifecycle rules loop
+	if cfg.LifecycleRulesInterval > 0 {
+		ss.wg.Add(1)
+		go func() {
+			ss.applyLifecycleRulesLoop(cfg.LifecycleRulesInterval)
+			ss.wg.Done()
+		}()
+	}
+
+	// start WAL compaction loop
+	if cfg.WALCompactionThreshold > 0 {
+		ss.wg.Add(1)
+		go func() {
+			ss.compactLoop(cfg.WALCompactionThreshold)
+			ss.wg.Done()
+		}()
+	}
 	return ss, nil
 }
 
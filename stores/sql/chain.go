@@ -3,8 +3,10 @@ package sql
 import (
 	"context"
 	dsql "database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"go.sia.tech/core/types"
 	"go.sia.tech/coreutils/wallet"
@@ -71,12 +73,117 @@ func UpdateContractRevision(ctx context.Context, tx sql.Tx, fcid types.FileContr
 	return nil
 }
 
+// UpdateContractProofHeight updates the proof height of the contract, a proof
+// height of 0 indicates the contract's resolution was reverted. The contract's
+// proof confirmations are reset accordingly.
 func UpdateContractProofHeight(ctx context.Context, tx sql.Tx, fcid types.FileContractID, proofHeight uint64, l *zap.SugaredLogger) error {
 	l.Debugw("update contract proof height", "fcid", fcid, "proof_height", proofHeight)
-	_, err := tx.Exec(ctx, `UPDATE contracts SET proof_height = ? WHERE fcid = ?`, proofHeight, FileContractID(fcid))
+
+	var confirmations uint64
+	if proofHeight > 0 {
+		confirmations = 1
+	}
+	_, err := tx.Exec(ctx, `UPDATE contracts SET proof_height = ?, proof_confirmations = ? WHERE fcid = ?`, proofHeight, confirmations, FileContractID(fcid))
 	return err
 }
 
+// UpdateProofConfirmations recomputes the proof confirmations of all resolved
+// contracts that were resolved less than depth blocks before the given block
+// height. Contracts that reached the confirmation depth are not updated
+// anymore, unless a reorg reverts the chain to a height within the depth.
+func UpdateProofConfirmations(ctx context.Context, tx sql.Tx, blockHeight, depth uint64, l *zap.SugaredLogger) error {
+	l.Debugw("update proof confirmations", "block_height", blockHeight, "depth", depth)
+
+	if res, err := tx.Exec(ctx,
+		"UPDATE contracts SET proof_confirmations = ? - proof_height + 1 WHERE proof_confirmations > 0 AND proof_height <= ? AND proof_height + ? > ?",
+		blockHeight,
+		blockHeight,
+		depth,
+		blockHeight,
+	); err != nil {
+		return fmt.Errorf("failed to update proof confirmations: %w", err)
+	} else if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	} else if n > 0 {
+		l.Debugw(fmt.Sprintf("updated proof confirmations of %d contracts", n), "block_height", blockHeight)
+	}
+	return nil
+}
+
+// RecordStorageProof stores the transaction containing the storage proof of
+// the given contract, any previously stored proof is replaced.
+func RecordStorageProof(ctx context.Context, tx sql.Tx, fcid types.FileContractID, proof api.ContractStorageProof, l *zap.SugaredLogger) error {
+	l.Debugw("record storage proof", "fcid", fcid, "block_height", proof.BlockHeight, "block_id", proof.BlockID)
+
+	var txn, v2Txn []byte
+	var err error
+	if proof.Transaction != nil {
+		txn, err = json.Marshal(proof.Transaction)
+	} else if proof.V2Transaction != nil {
+		v2Txn, err = json.Marshal(proof.V2Transaction)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode storage proof transaction: %w", err)
+	}
+
+	if err := RemoveStorageProof(ctx, tx, fcid, l); err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO contract_storage_proofs (created_at, db_contract_id, block_height, block_id, txn, v2_txn)
+		SELECT ?, c.id, ?, ?, ?, ? FROM contracts c WHERE c.fcid = ?
+	`, time.Now(), proof.BlockHeight, Hash256(proof.BlockID), txn, v2Txn, FileContractID(fcid))
+	if err != nil {
+		return fmt.Errorf("failed to insert storage proof: %w", err)
+	}
+	return nil
+}
+
+// RemoveStorageProof removes the storage proof of the given contract, this is
+// called when the block containing the proof is reverted.
+func RemoveStorageProof(ctx context.Context, tx sql.Tx, fcid types.FileContractID, l *zap.SugaredLogger) error {
+	l.Debugw("remove storage proof", "fcid", fcid)
+
+	_, err := tx.Exec(ctx, `
+		DELETE FROM contract_storage_proofs
+		WHERE db_contract_id = (SELECT id FROM contracts WHERE fcid = ?)
+	`, FileContractID(fcid))
+	if err != nil {
+		return fmt.Errorf("failed to remove storage proof: %w", err)
+	}
+	return nil
+}
+
+// StorageProof returns the transaction containing the storage proof of the
+// given contract.
+func StorageProof(ctx context.Context, tx sql.Tx, fcid types.FileContractID) (api.ContractStorageProof, error) {
+	var proof api.ContractStorageProof
+	var txn, v2Txn []byte
+	err := tx.QueryRow(ctx, `
+		SELECT sp.block_height, sp.block_id, sp.txn, sp.v2_txn
+		FROM contract_storage_proofs sp
+		INNER JOIN contracts c ON sp.db_contract_id = c.id
+		WHERE c.fcid = ?
+	`, FileContractID(fcid)).Scan(&proof.BlockHeight, (*Hash256)(&proof.BlockID), &txn, &v2Txn)
+	if errors.Is(err, dsql.ErrNoRows) {
+		return api.ContractStorageProof{}, api.ErrStorageProofNotFound
+	} else if err != nil {
+		return api.ContractStorageProof{}, fmt.Errorf("failed to fetch storage proof: %w", err)
+	}
+
+	if len(txn) > 0 {
+		proof.Transaction = new(types.Transaction)
+		err = json.Unmarshal(txn, proof.Transaction)
+	} else if len(v2Txn) > 0 {
+		proof.V2Transaction = new(types.V2Transaction)
+		err = json.Unmarshal(v2Txn, proof.V2Transaction)
+	}
+	if err != nil {
+		return api.ContractStorageProof{}, fmt.Errorf("failed to decode storage proof transaction: %w", err)
+	}
+	return proof, nil
+}
+
 func UpdateContractState(ctx context.Context, tx sql.Tx, fcid types.FileContractID, state api.ContractState, l *zap.SugaredLogger) error {
 	l.Debugw("update contract state", "fcid", fcid, "state", state)
 
@@ -88,23 +195,48 @@ func UpdateContractState(ctx context.Context, tx sql.Tx, fcid types.FileContract
 	return err
 }
 
-func UpdateFailedContracts(ctx context.Context, tx sql.Tx, blockHeight uint64, l *zap.SugaredLogger) error {
+// UpdateFailedContracts marks all active contracts whose proof window ended at
+// or before the given block height as failed and returns their IDs.
+func UpdateFailedContracts(ctx context.Context, tx sql.Tx, blockHeight uint64, l *zap.SugaredLogger) ([]types.FileContractID, error) {
 	l.Debugw("update failed contracts", "block_height", blockHeight)
 
-	if res, err := tx.Exec(ctx,
+	// fetch the contracts that failed
+	rows, err := tx.Query(ctx,
+		"SELECT fcid FROM contracts WHERE window_end <= ? AND state = ?",
+		blockHeight,
+		ContractStateFromString(api.ContractStateActive),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch failed contracts: %w", err)
+	}
+	defer rows.Close()
+
+	var fcids []types.FileContractID
+	for rows.Next() {
+		var fcid FileContractID
+		if err := rows.Scan(&fcid); err != nil {
+			return nil, fmt.Errorf("failed to scan contract id: %w", err)
+		}
+		fcids = append(fcids, types.FileContractID(fcid))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch failed contracts: %w", err)
+	} else if len(fcids) == 0 {
+		return nil, nil
+	}
+
+	// mark them as failed
+	if _, err := tx.Exec(ctx,
 		"UPDATE contracts SET state = ? WHERE window_end <= ? AND state = ?",
 		ContractStateFromString(api.ContractStateFailed),
 		blockHeight,
 		ContractStateFromString(api.ContractStateActive),
 	); err != nil {
-		return fmt.Errorf("failed to update failed contracts: %w", err)
-	} else if n, err := res.RowsAffected(); err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	} else if n > 0 {
-		l.Debugw(fmt.Sprintf("marked %d active contracts as failed", n), "window_end", blockHeight)
+		return nil, fmt.Errorf("failed to update failed contracts: %w", err)
 	}
+	l.Debugw(fmt.Sprintf("marked %d active contracts as failed", len(fcids)), "window_end", blockHeight)
 
-	return nil
+	return fcids, nil
 }
 
 // UpdateWalletSiacoinElementProofs updates the proofs of all state elements
@@ -24,14 +24,17 @@ type (
 		IsKnownContract(fcid types.FileContractID) (bool, error)
 		PruneFileContractElements(threshold uint64) error
 		RecordContractRenewal(old, new types.FileContractID) error
+		RecordStorageProof(fcid types.FileContractID, proof api.ContractStorageProof) error
+		RemoveStorageProof(fcid types.FileContractID) error
 		UpdateFileContractElements([]types.V2FileContractElement) error
 		UpdateChainIndex(index types.ChainIndex) error
 		UpdateFileContractElementProofs(updater wallet.ProofUpdater) error
 		UpdateContractProofHeight(fcid types.FileContractID, proofHeight uint64) error
 		UpdateContractRevision(fcid types.FileContractID, revisionHeight, revisionNumber, size uint64) error
 		UpdateContractState(fcid types.FileContractID, state api.ContractState) error
-		UpdateFailedContracts(blockHeight uint64) error
+		UpdateFailedContracts(blockHeight uint64) ([]types.FileContractID, error)
 		UpdateHost(hk types.PublicKey, v1Addr string, v2Ha chain.V2HostAnnouncement, bh uint64, blockID types.BlockID, ts time.Time) error
+		UpdateProofConfirmations(blockHeight, depth uint64) error
 
 		wallet.UpdateTx
 	}
@@ -70,6 +73,10 @@ type (
 		// exists, it is updated.
 		AddWebhook(ctx context.Context, wh webhooks.Webhook) error
 
+		// AddWebhookDeadLetter adds an event that couldn't be delivered to the
+		// given webhook to the dead letter queue.
+		AddWebhookDeadLetter(ctx context.Context, dl webhooks.DeadLetter) error
+
 		// AncestorContracts returns all ancestor contracts of the contract up
 		// until the given start height.
 		AncestorContracts(ctx context.Context, id types.FileContractID, startHeight uint64) ([]api.ContractMetadata, error)
@@ -78,6 +85,10 @@ type (
 		// archived ones.
 		ArchiveContract(ctx context.Context, fcid types.FileContractID, reason string) error
 
+		// ArchiveObject turns the current version of an object into a
+		// noncurrent version and returns true if the object exists.
+		ArchiveObject(ctx context.Context, bucket, key string) (bool, error)
+
 		// AutopilotConfig returns the autopilot configuration.
 		AutopilotConfig(ctx context.Context) (api.AutopilotConfig, error)
 
@@ -111,6 +122,10 @@ type (
 		// opts argument can be used to filter the result.
 		Contracts(ctx context.Context, opts api.ContractsOpts) ([]api.ContractMetadata, error)
 
+		// ContractsExpiring returns all active contracts whose window ends
+		// within the given number of blocks of the current chain tip.
+		ContractsExpiring(ctx context.Context, withinBlocks uint64) ([]api.ContractMetadata, error)
+
 		// ContractSize returns the size of the contract with the given ID as
 		// well as the estimated number of bytes that can be pruned from it.
 		ContractSize(ctx context.Context, id types.FileContractID) (api.ContractSize, error)
@@ -133,6 +148,11 @@ type (
 		// api.ErrBucketNotFound.
 		DeleteBucket(ctx context.Context, bucket string) error
 
+		// DeleteHost deletes the host with the given public key. Its
+		// contracts have to be archived beforehand. Returns
+		// api.ErrHostNotFound if the host doesn't exist.
+		DeleteHost(ctx context.Context, hk types.PublicKey) error
+
 		// DeleteHostSector deletes all contract sector links that a host has
 		// with the given root incrementing the lost sector count in the
 		// process.
@@ -146,6 +166,19 @@ type (
 		// prefix and returns 'true' if any object was deleted.
 		DeleteObjects(ctx context.Context, bucket, prefix string, limit int64) (bool, error)
 
+		// DeleteObjectsGlob deletes a batch of objects whose key matches the
+		// given glob pattern and returns the number of deleted objects.
+		DeleteObjectsGlob(ctx context.Context, bucket, glob string, limit int64) (int64, error)
+
+		// DeleteExpiredObjects deletes a batch of objects starting with the
+		// given prefix that were created before the given time and returns
+		// the number of deleted objects.
+		DeleteExpiredObjects(ctx context.Context, bucket, prefix string, createdBefore time.Time, limit int64) (int64, error)
+
+		// DeleteObjectVersion permanently deletes the version of an object
+		// with the given version ID and returns false if it doesn't exist.
+		DeleteObjectVersion(ctx context.Context, bucket, key, versionID string) (bool, error)
+
 		// DeleteSetting deletes the setting with the given key.
 		DeleteSetting(ctx context.Context, key string) error
 
@@ -154,10 +187,29 @@ type (
 		// webhooks.ErrWebhookNotFound is returned.
 		DeleteWebhook(ctx context.Context, wh webhooks.Webhook) error
 
+		// DeleteWebhookDeadLetter removes the dead letter with the given id from
+		// the dead letter queue. If the dead letter doesn't exist,
+		// webhooks.ErrDeadLetterNotFound is returned.
+		DeleteWebhookDeadLetter(ctx context.Context, id int64) error
+
+		// ExpireMultipartUploads deletes all multipart uploads whose TTL
+		// expired as well as uploads without a TTL that were created more than
+		// olderThan ago. An olderThan of 0 only expires uploads with a TTL. It
+		// returns the number of deleted uploads.
+		ExpireMultipartUploads(ctx context.Context, olderThan time.Duration) (int, error)
+
+		// ExpiredObjects returns the keys of a batch of objects starting with
+		// the given prefix that were created before the given time.
+		ExpiredObjects(ctx context.Context, bucket, prefix string, createdBefore time.Time, limit int64) ([]string, error)
+
 		// FileContractElement returns the up-to-date file contract element for
 		// a given contract id.
 		FileContractElement(ctx context.Context, fcid types.FileContractID) (types.V2FileContractElement, error)
 
+		// FindAlternativeContracts returns the ids of all active contracts
+		// that store the sector with the given root.
+		FindAlternativeContracts(ctx context.Context, root types.Hash256) ([]types.FileContractID, error)
+
 		// Hosts returns a list of hosts that match the provided filters
 		Hosts(ctx context.Context, opts api.HostOptions) ([]api.Host, error)
 
@@ -168,6 +220,19 @@ type (
 		// HostBlocklist returns the list of host addresses on the blocklist.
 		HostBlocklist(ctx context.Context) ([]string, error)
 
+		// HostBenchmarks returns the most recent benchmarks of the given
+		// host, ordered from newest to oldest. A negative limit returns all
+		// benchmarks.
+		HostBenchmarks(ctx context.Context, hk types.PublicKey, limit int) ([]api.HostBenchmark, error)
+
+		// HostScanHistory returns the most recent scans of the given host,
+		// ordered from newest to oldest. A negative limit returns all scans.
+		HostScanHistory(ctx context.Context, hk types.PublicKey, limit int) ([]api.HostScan, error)
+
+		// HostPriceHistory returns the price changes of all hosts that
+		// changed their prices since the given time.
+		HostPriceHistory(ctx context.Context, since time.Time) (map[types.PublicKey][]api.HostPriceChange, error)
+
 		// InitAutopilotConfig initializes the autopilot config in the database.
 		InitAutopilotConfig(ctx context.Context) error
 
@@ -177,12 +242,19 @@ type (
 		// that was created.
 		InsertBufferedSlab(ctx context.Context, fileName string, ec object.EncryptionKey, minShards, totalShards uint8) (int64, error)
 
+		// InsertDeleteMarker adds a delete marker for an object and returns
+		// its version ID.
+		InsertDeleteMarker(ctx context.Context, bucket, key string) (string, error)
+
 		// InsertMultipartUpload creates a new multipart upload and returns a
-		// unique upload ID.
-		InsertMultipartUpload(ctx context.Context, bucket, key string, ec object.EncryptionKey, mimeType string, metadata api.ObjectUserMetadata) (string, error)
+		// unique upload ID. If ttl is non-zero, the upload expires after the
+		// given duration.
+		InsertMultipartUpload(ctx context.Context, bucket, key string, ec object.EncryptionKey, mimeType string, metadata api.ObjectUserMetadata, ttl time.Duration) (string, error)
 
-		// InsertObject inserts a new object into the database.
-		InsertObject(ctx context.Context, bucket, key string, o object.Object, mimeType, eTag string, md api.ObjectUserMetadata) error
+		// InsertObject inserts a new object into the database. If
+		// ifNotExists is true, api.ErrObjectExists is returned if an object
+		// with the same key already exists.
+		InsertObject(ctx context.Context, bucket, key string, o object.Object, mimeType, eTag string, md api.ObjectUserMetadata, ifNotExists bool) error
 
 		// InvalidateSlabHealthByFCID invalidates the health of all slabs that
 		// are associated with any of the provided contracts.
@@ -202,6 +274,11 @@ type (
 		// The returned string contains the filename of the slab buffer on disk.
 		MarkPackedSlabUploaded(ctx context.Context, slab api.UploadedPackedSlab) (string, error)
 
+		// MergeSlabBuffers moves the slices of the source buffer to the
+		// destination buffer, shifting their offsets by the given offset, and
+		// removes the source buffer.
+		MergeSlabBuffers(ctx context.Context, srcID, dstID, offset int64) error
+
 		// MultipartUpload returns the multipart upload with the given ID or
 		// api.ErrMultipartUploadNotFound if the upload doesn't exist.
 		MultipartUpload(ctx context.Context, uploadID string) (api.MultipartUpload, error)
@@ -213,6 +290,10 @@ type (
 		// MultipartUploads returns a list of all multipart uploads.
 		MultipartUploads(ctx context.Context, bucket, prefix, keyMarker, uploadIDMarker string, limit int) (api.MultipartListUploadsResponse, error)
 
+		// NewObjectVersion assigns a new version ID to the current version of
+		// an object and returns it.
+		NewObjectVersion(ctx context.Context, bucket, key string) (string, error)
+
 		// Object returns an object from the database.
 		Object(ctx context.Context, bucket, key string) (api.Object, error)
 
@@ -222,9 +303,39 @@ type (
 		// ObjectMetadata returns an object's metadata.
 		ObjectMetadata(ctx context.Context, bucket, key string) (api.Object, error)
 
+		// ObjectKeys returns the keys of a batch of objects starting with the
+		// given prefix.
+		ObjectKeys(ctx context.Context, bucket, prefix string, limit int64) ([]string, error)
+
+		// ObjectKeysGlob returns the keys of a batch of objects whose key
+		// matches the given glob pattern.
+		ObjectKeysGlob(ctx context.Context, bucket, glob string, limit int64) ([]string, error)
+
+		// ObjectTags returns the tags of an object.
+		ObjectTags(ctx context.Context, bucket, key string) (api.ObjectTags, error)
+
+		// ObjectVersion returns the version of an object with the given
+		// version ID.
+		ObjectVersion(ctx context.Context, bucket, key, versionID string) (api.Object, error)
+
+		// ObjectVersions returns all versions of an object, newest first.
+		ObjectVersions(ctx context.Context, bucket, key string) ([]api.ObjectVersion, error)
+
+		// ObjectsTags returns the tags of the objects with the given keys,
+		// objects without tags are omitted.
+		ObjectsTags(ctx context.Context, bucket string, keys []string) (map[string]api.ObjectTags, error)
+
 		// ObjectsStats returns overall stats about stored objects
 		ObjectsStats(ctx context.Context, opts api.ObjectsStatsOpts) (api.ObjectsStatsResponse, error)
 
+		// ObjectsStatsPerHost returns the number of sectors and their size
+		// stored on every host.
+		ObjectsStatsPerHost(ctx context.Context, opts api.ObjectsStatsOpts) (map[types.PublicKey]api.HostStorageStats, error)
+
+		// ObjectsLostOnArchival returns up to limit objects that would become
+		// inaccessible if the given contracts were archived.
+		ObjectsLostOnArchival(ctx context.Context, fcids []types.FileContractID, limit int) ([]api.ObjectMetadata, error)
+
 		// PeerBanned returns true if the peer is banned.
 		PeerBanned(ctx context.Context, addr string) (bool, error)
 
@@ -246,6 +357,10 @@ type (
 		// or slab buffer.
 		PruneSlabs(ctx context.Context, limit int64) (int64, error)
 
+		// PruneWebhookDeadLetters deletes all dead letters that expired before
+		// the given time and returns the number of deleted dead letters.
+		PruneWebhookDeadLetters(ctx context.Context, now time.Time) (int64, error)
+
 		// PutContract inserts the contract if it does not exist, otherwise it
 		// will overwrite all fields.
 		PutContract(ctx context.Context, c api.ContractMetadata) error
@@ -253,6 +368,14 @@ type (
 		// RecordContractSpending records new spending for a contract
 		RecordContractSpending(ctx context.Context, fcid types.FileContractID, revisionNumber, size uint64, newSpending api.ContractSpending) error
 
+		// RecordSlabFailure sets the time of the slab's last failed migration
+		// to the current time.
+		RecordSlabFailure(ctx context.Context, key object.EncryptionKey) error
+
+		// RecordHostBenchmark adds a benchmark to the given host's benchmark
+		// history.
+		RecordHostBenchmark(ctx context.Context, hk types.PublicKey, benchmark api.HostBenchmark) error
+
 		// RecordHostScans records the results of host scans in the database
 		// such as recording the settings and price table of a host in case of
 		// success and updating the uptime and downtime of a host.
@@ -261,10 +384,19 @@ type (
 		// therefore only useful for gouging checks.
 		RecordHostScans(ctx context.Context, scans []api.HostScan) error
 
+		// RecoverObjects matches the given sector roots to the slabs that
+		// contain them and records the objects referencing those slabs in the
+		// recovered_objects table. Objects that were deleted but whose
+		// noncurrent versions still reference the slabs are recorded as
+		// deleted. It returns the number of matched roots and the number of
+		// newly recovered and deleted objects.
+		RecoverObjects(ctx context.Context, roots []types.Hash256) (matched, recovered, deleted int64, err error)
+
 		// RemoveOfflineHosts removes all hosts that have been offline for
 		// longer than maxDownTime and been scanned at least minRecentFailures
-		// times. The contracts of those hosts are also removed.
-		RemoveOfflineHosts(ctx context.Context, minRecentFailures uint64, maxDownTime time.Duration) (int64, error)
+		// times. The active contracts of those hosts are archived and their
+		// IDs are returned alongside the number of removed hosts.
+		RemoveOfflineHosts(ctx context.Context, minRecentFailures uint64, maxDownTime time.Duration) (int64, []types.FileContractID, error)
 
 		// RenameObject renames an object in the database from keyOld to keyNew
 		// and the new directory dirID. returns api.ErrObjectExists if the an
@@ -282,6 +414,10 @@ type (
 		// returned.
 		RenameObjects(ctx context.Context, bucket, prefixOld, prefixNew string, force bool) error
 
+		// RenameObjectVersions moves the noncurrent versions and delete
+		// markers of an object from keyOld to keyNew.
+		RenameObjectVersions(ctx context.Context, bucket, keyOld, keyNew string) error
+
 		// RenewedContract returns the metadata of the contract that was renewed
 		// from the specified contract or ErrContractNotFound otherwise.
 		RenewedContract(ctx context.Context, renewedFrom types.FileContractID) (api.ContractMetadata, error)
@@ -302,12 +438,17 @@ type (
 		// Slab returns the slab with the given ID or api.ErrSlabNotFound.
 		Slab(ctx context.Context, key object.EncryptionKey) (object.Slab, error)
 
+		// StorageProof returns the transaction containing the storage proof
+		// of the given contract or api.ErrStorageProofNotFound.
+		StorageProof(ctx context.Context, fcid types.FileContractID) (api.ContractStorageProof, error)
+
 		// Tip returns the sync height.
 		Tip(ctx context.Context) (types.ChainIndex, error)
 
-		// UnhealthySlabs returns up to 'limit' slabs belonging to the contract
-		// set 'set' with a health smaller than or equal to 'healthCutoff'
-		UnhealthySlabs(ctx context.Context, healthCutoff float64, limit int) ([]api.UnhealthySlab, error)
+		// UnhealthySlabs returns up to 'limit' slabs with a health smaller
+		// than or equal to 'healthCutoff'. If 'maxLastFailure' is non-zero,
+		// slabs whose migration failed within that duration are skipped.
+		UnhealthySlabs(ctx context.Context, healthCutoff float64, maxLastFailure time.Duration, limit int) ([]api.UnhealthySlab, error)
 
 		// UnspentSiacoinElements returns all wallet outputs in the database.
 		UnspentSiacoinElements(ctx context.Context) ([]types.SiacoinElement, error)
@@ -334,6 +475,12 @@ type (
 		// UpdateHostCheck updates the host check for the given host.
 		UpdateHostCheck(ctx context.Context, hk types.PublicKey, hc api.HostChecks) error
 
+		// UpdateHostChecks updates the host checks for the given hosts.
+		UpdateHostChecks(ctx context.Context, checks map[types.PublicKey]api.HostChecks) error
+
+		// UpdateObjectTags replaces the tags of an object.
+		UpdateObjectTags(ctx context.Context, bucket, key string, tags api.ObjectTags) error
+
 		// UpdatePeerInfo updates the metadata for the specified peer.
 		UpdatePeerInfo(ctx context.Context, addr string, fn func(*syncer.PeerInfo)) error
 
@@ -353,6 +500,10 @@ type (
 		// the health of the updated slabs becomes invalid
 		UpdateSlabHealth(ctx context.Context, limit int64, minValidity, maxValidity time.Duration) (int64, error)
 
+		// UpdateWebhookDeadLetter updates the failure count, last error and
+		// expiry of the given dead letter.
+		UpdateWebhookDeadLetter(ctx context.Context, dl webhooks.DeadLetter) error
+
 		// UpsertContractSectors ensures the given contract-sector links are
 		// present in the database.
 		UpsertContractSectors(ctx context.Context, contractSectors []ContractSector) error
@@ -362,12 +513,24 @@ type (
 		// not blocked, not offline, etc.
 		UsableHosts(ctx context.Context) ([]HostInfo, error)
 
-		// WalletEvents returns all wallet events in the database.
-		WalletEvents(ctx context.Context, offset, limit int) ([]wallet.Event, error)
+		// WalletEvents returns the wallet events in the database that match
+		// the given options, ordered by timestamp, descending.
+		WalletEvents(ctx context.Context, opts api.WalletEventsOpts) ([]wallet.Event, error)
 
 		// WalletEventCount returns the total number of events in the database.
 		WalletEventCount(ctx context.Context) (uint64, error)
 
+		// WebhookDeadLetter returns the dead letter with the given id or
+		// webhooks.ErrDeadLetterNotFound if it doesn't exist.
+		WebhookDeadLetter(ctx context.Context, id int64) (webhooks.DeadLetter, error)
+
+		// WebhookDeadLetterCount returns the number of dead letters in the
+		// dead letter queue.
+		WebhookDeadLetterCount(ctx context.Context) (uint64, error)
+
+		// WebhookDeadLetters returns all dead letters in the dead letter queue.
+		WebhookDeadLetters(ctx context.Context) ([]webhooks.DeadLetter, error)
+
 		// Webhooks returns all registered webhooks.
 		Webhooks(ctx context.Context) ([]webhooks.Webhook, error)
 	}
@@ -394,6 +557,14 @@ type (
 		// time range and options.
 		ContractPruneMetrics(ctx context.Context, start time.Time, n uint64, interval time.Duration, opts api.ContractPruneMetricsQueryOpts) ([]api.ContractPruneMetric, error)
 
+		// LatestContractMetrics returns the most recent contract metric of
+		// every contract that was recorded before the given time.
+		LatestContractMetrics(ctx context.Context, before time.Time) ([]api.ContractMetric, error)
+
+		// MetricsSummary returns the most recent value of every metric type,
+		// contract metrics are limited to the given contracts.
+		MetricsSummary(ctx context.Context, fcids []types.FileContractID) (api.MetricsSummary, error)
+
 		// PruneMetrics deletes metrics of a certain type older than the given
 		// cutoff time.
 		PruneMetrics(ctx context.Context, metric string, cutoff time.Time) error
@@ -404,15 +575,23 @@ type (
 		// RecordContractPruneMetric records contract prune metrics.
 		RecordContractPruneMetric(ctx context.Context, metrics ...api.ContractPruneMetric) error
 
+		// RecordSlabDownloadMetric records slab download metrics.
+		RecordSlabDownloadMetric(ctx context.Context, metrics ...api.SlabDownloadMetric) error
+
 		// RecordWalletMetric records wallet metrics.
 		RecordWalletMetric(ctx context.Context, metrics ...api.WalletMetric) error
 
+		// SlabDownloadMetrics returns slab download metrics for the given time
+		// range
+		SlabDownloadMetrics(ctx context.Context, start time.Time, n uint64, interval time.Duration, opts api.SlabDownloadMetricsQueryOpts) ([]api.SlabDownloadMetric, error)
+
 		// WalletMetrics returns wallet metrics for the given time range
 		WalletMetrics(ctx context.Context, start time.Time, n uint64, interval time.Duration, opts api.WalletMetricsQueryOpts) ([]api.WalletMetric, error)
 	}
 
 	LoadedSlabBuffer struct {
 		ID          int64
+		CreatedAt   time.Time
 		Filename    string
 		Key         object.EncryptionKey
 		MinShards   uint8
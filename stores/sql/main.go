@@ -31,6 +31,30 @@ import (
 	"lukechampine.com/frand"
 )
 
+// hostPriceHistoryRetention is the amount of time price changes are kept in a
+// host's price history.
+const hostPriceHistoryRetention = 30 * 24 * time.Hour
+
+// hostScanHistoryRetention is the amount of time scans are kept in a host's
+// scan history.
+const hostScanHistoryRetention = 7 * 24 * time.Hour
+
+// hostScansBatchSize is the number of host scans that are recorded using a
+// single query.
+const hostScansBatchSize = 500
+
+// objectTagsBatchSize is the number of objects for which tags are fetched
+// using a single query.
+const objectTagsBatchSize = 1000
+
+// objectLastAccessedInterval is the minimum time between two updates of an
+// object's last accessed time.
+const objectLastAccessedInterval = time.Hour
+
+// hostBlockedExpr is a WHERE expression that evaluates to true if the host
+// 'h' matches any entry of the blocklist, including CIDR entries.
+const hostBlockedExpr = "(EXISTS (SELECT 1 FROM host_blocklist_entry_hosts hbeh WHERE hbeh.db_host_id = h.id) OR EXISTS (SELECT 1 FROM host_blocklist_cidr_hosts hbch WHERE hbch.db_host_id = h.id))"
+
 var (
 	ErrNegativeOffset  = errors.New("offset can not be negative")
 	ErrSettingNotFound = errors.New("setting not found")
@@ -76,6 +100,10 @@ type (
 		SelectObjectMetadataExpr() string
 
 		UpsertContractSectors(ctx context.Context, contractSectors []ContractSector) error
+
+		// UpsertHostScans updates the given hosts with the results of their
+		// scans using a single query.
+		UpsertHostScans(ctx context.Context, scans []api.HostScan) error
 	}
 )
 
@@ -112,6 +140,30 @@ func AbortMultipartUpload(ctx context.Context, tx sql.Tx, bucket, key string, up
 	return errors.New("failed to delete multipart upload for unknown reason")
 }
 
+func ExpireMultipartUploads(ctx context.Context, tx sql.Tx, olderThan time.Duration) (int, error) {
+	now := time.Now()
+	whereExpr := "expires_at <= ?"
+	args := []any{UnixTimeMS(now)}
+	if olderThan > 0 {
+		whereExpr += " OR (expires_at IS NULL AND created_at < ?)"
+		args = append(args, now.Add(-olderThan))
+	}
+
+	res, err := tx.Exec(ctx, fmt.Sprintf("DELETE FROM multipart_uploads WHERE %s", whereExpr), args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired multipart uploads: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch rows affected: %w", err)
+	}
+	return int(n), nil
+}
+
+func ExpiredObjects(ctx context.Context, tx sql.Tx, bucket, prefix string, createdBefore time.Time, limit int64) ([]string, error) {
+	return objectKeysWithPrefix(ctx, tx, bucket, prefix, "o.created_at < ?", []any{createdBefore}, limit)
+}
+
 func Accounts(ctx context.Context, tx sql.Tx, owner string) ([]api.Account, error) {
 	var whereExpr string
 	var args []any
@@ -151,9 +203,10 @@ func AncestorContracts(ctx context.Context, tx sql.Tx, fcid types.FileContractID
 		)
 		SELECT
 			c.fcid, c.host_id, c.host_key, c.v2,
-			c.archival_reason, c.proof_height, c.renewed_from, c.renewed_to, c.revision_height, c.revision_number, c.size, c.start_height, c.state, c.usability, c.window_start, c.window_end,
-			c.contract_price, c.initial_renter_funds,
-			c.delete_spending, c.fund_account_spending, c.sector_roots_spending, c.upload_spending
+			c.archival_reason, c.proof_confirmations, c.proof_height, c.renewed_from, c.renewed_to, c.revision_height, c.revision_number, c.size, c.start_height, c.state, c.usability, c.window_start, c.window_end,
+			c.contract_price, c.initial_renter_funds, COALESCE(c.storage_price, '0'), COALESCE(c.upload_price, '0'), COALESCE(c.download_price, '0'),
+			c.delete_spending, c.fund_account_spending, c.sector_roots_spending, c.upload_spending,
+			c.account_funding_fees, c.price_table_fees, c.sector_upload_fees
 		FROM contracts AS c
 		WHERE start_height >= ? AND archival_reason IS NOT NULL
 		ORDER BY start_height DESC
@@ -174,6 +227,63 @@ func AncestorContracts(ctx context.Context, tx sql.Tx, fcid types.FileContractID
 	return
 }
 
+// ObjectsLostOnArchival returns the objects that are currently recoverable but
+// would become inaccessible if the given contracts were archived, meaning at
+// least one of their slabs would be left with fewer than min_shards sectors
+// stored on the remaining contracts.
+func ObjectsLostOnArchival(ctx context.Context, tx sql.Tx, fcids []types.FileContractID, limit int) ([]api.ObjectMetadata, error) {
+	if len(fcids) == 0 {
+		return nil, nil
+	}
+
+	var args []any
+	for _, fcid := range fcids {
+		args = append(args, FileContractID(fcid))
+	}
+	args = append(args, limit)
+
+	rows, err := tx.Query(ctx, fmt.Sprintf(`
+		SELECT b.name, o.object_id
+		FROM objects o
+		INNER JOIN buckets b ON b.id = o.db_bucket_id
+		WHERE o.object_id IS NOT NULL AND EXISTS (
+			SELECT 1
+			FROM slices sli
+			INNER JOIN slabs sla ON sla.id = sli.db_slab_id
+			WHERE sli.db_object_id = o.id AND sla.db_buffered_slab_id IS NULL
+			AND (
+				SELECT COUNT(DISTINCT se.id)
+				FROM sectors se
+				INNER JOIN contract_sectors cs ON cs.db_sector_id = se.id
+				WHERE se.db_slab_id = sla.id
+			) >= sla.min_shards
+			AND (
+				SELECT COUNT(DISTINCT se.id)
+				FROM sectors se
+				INNER JOIN contract_sectors cs ON cs.db_sector_id = se.id
+				INNER JOIN contracts c ON c.id = cs.db_contract_id
+				WHERE se.db_slab_id = sla.id AND c.fcid NOT IN (%s)
+			) < sla.min_shards
+		)
+		ORDER BY b.name, o.object_id
+		LIMIT ?
+	`, strings.Repeat("?, ", len(fcids)-1)+"?"), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch objects: %w", err)
+	}
+	defer rows.Close()
+
+	var objects []api.ObjectMetadata
+	for rows.Next() {
+		var om api.ObjectMetadata
+		if err := rows.Scan(&om.Bucket, &om.Key); err != nil {
+			return nil, fmt.Errorf("failed to scan object: %w", err)
+		}
+		objects = append(objects, om)
+	}
+	return objects, rows.Err()
+}
+
 func ArchiveContract(ctx context.Context, tx sql.Tx, fcid types.FileContractID, reason string) error {
 	// validate reason
 	if reason == "" {
@@ -208,6 +318,57 @@ func ArchiveContract(ctx context.Context, tx sql.Tx, fcid types.FileContractID,
 	return nil
 }
 
+// ArchiveObject turns the current version of an object into a noncurrent
+// version. The object is kept but no longer referenced by its key, which frees
+// up the key for a new version. It returns false if the object doesn't exist.
+func ArchiveObject(ctx context.Context, tx sql.Tx, bucket, key string) (bool, error) {
+	var objID, bucketID int64
+	var versionID NullableString
+	err := tx.QueryRow(ctx, `
+		SELECT o.id, o.db_bucket_id, o.version_id
+		FROM objects o
+		INNER JOIN buckets b ON b.id = o.db_bucket_id
+		WHERE o.object_id = ? AND b.name = ?
+	`, key, bucket).Scan(&objID, &bucketID, &versionID)
+	if errors.Is(err, dsql.ErrNoRows) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to fetch object: %w", err)
+	}
+
+	// objects created before versioning was enabled don't have a version yet
+	version := string(versionID)
+	if version == "" {
+		version = newVersionID()
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE objects SET object_id = NULL, version_id = ? WHERE id = ?", version, objID)
+	if err != nil {
+		return false, fmt.Errorf("failed to archive object: %w", err)
+	}
+	_, err = tx.Exec(ctx, "INSERT INTO object_versions (created_at, db_bucket_id, object_key, version_id, db_object_id) VALUES (?, ?, ?, ?, ?)",
+		time.Now(), bucketID, key, version, objID)
+	if err != nil {
+		return false, fmt.Errorf("failed to insert object version: %w", err)
+	}
+	return true, nil
+}
+
+// RenameObjectVersions moves the noncurrent versions and delete markers of an
+// object from keyOld to keyNew, so they remain part of the renamed object's
+// history.
+func RenameObjectVersions(ctx context.Context, tx sql.Tx, bucket, keyOld, keyNew string) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE object_versions
+		SET object_key = ?
+		WHERE object_key = ? AND db_bucket_id = (SELECT id FROM buckets WHERE buckets.name = ?)
+	`, keyNew, keyOld, bucket)
+	if err != nil {
+		return fmt.Errorf("failed to rename object versions: %w", err)
+	}
+	return nil
+}
+
 func AutopilotConfig(ctx context.Context, tx sql.Tx) (cfg api.AutopilotConfig, err error) {
 	err = tx.QueryRow(ctx, `
 SELECT
@@ -219,9 +380,11 @@ SELECT
 	contracts_upload,
 	contracts_storage,
 	contracts_prune,
+	contracts_auto_size,
 	hosts_max_downtime_hours,
 	hosts_min_protocol_version,
-	hosts_max_consecutive_scan_failures
+	hosts_max_consecutive_scan_failures,
+	price_renegotiation_enabled
 FROM autopilot_config
 WHERE id = ?`, sql.AutopilotID).Scan(
 		&cfg.Enabled,
@@ -232,9 +395,11 @@ WHERE id = ?`, sql.AutopilotID).Scan(
 		&cfg.Contracts.Upload,
 		&cfg.Contracts.Storage,
 		&cfg.Contracts.Prune,
+		&cfg.Contracts.AutoSizeContractSet,
 		&cfg.Hosts.MaxDowntimeHours,
 		&cfg.Hosts.MinProtocolVersion,
 		&cfg.Hosts.MaxConsecutiveScanFailures,
+		&cfg.PriceRenegotiationEnabled,
 	)
 	return
 }
@@ -324,6 +489,14 @@ func Contracts(ctx context.Context, tx sql.Tx, opts api.ContractsOpts) ([]api.Co
 	return QueryContracts(ctx, tx, whereExprs, whereArgs)
 }
 
+func ContractsExpiring(ctx context.Context, tx sql.Tx, withinBlocks uint64) ([]api.ContractMetadata, error) {
+	tip, err := Tip(ctx, tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch chain tip: %w", err)
+	}
+	return QueryContracts(ctx, tx, []string{"c.archival_reason IS NULL", "c.window_end <= ?"}, []any{tip.Height + withinBlocks})
+}
+
 func ContractSize(ctx context.Context, tx sql.Tx, id types.FileContractID) (api.ContractSize, error) {
 	var contractID, size uint64
 	if err := tx.QueryRow(ctx, "SELECT id, size FROM contracts WHERE fcid = ? AND archival_reason IS NULL", FileContractID(id)).
@@ -488,12 +661,16 @@ func DeleteBucket(ctx context.Context, tx sql.Tx, bucket string) error {
 		return fmt.Errorf("failed to fetch bucket id: %w", err)
 	}
 	var empty bool
-	err = tx.QueryRow(ctx, "SELECT NOT EXISTS(SELECT 1 FROM objects WHERE db_bucket_id = ?)", id).Scan(&empty)
+	err = tx.QueryRow(ctx, "SELECT NOT EXISTS(SELECT 1 FROM objects WHERE db_bucket_id = ? AND object_id IS NOT NULL)", id).Scan(&empty)
 	if err != nil {
 		return fmt.Errorf("failed to check if bucket is empty: %w", err)
 	} else if !empty {
 		return api.ErrBucketNotEmpty
 	}
+	_, err = tx.Exec(ctx, "DELETE FROM objects WHERE db_bucket_id = ? AND object_id IS NULL", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete noncurrent object versions: %w", err)
+	}
 	_, err = tx.Exec(ctx, "DELETE FROM buckets WHERE id = ?", id)
 	if err != nil {
 		return fmt.Errorf("failed to delete bucket: %w", err)
@@ -501,6 +678,39 @@ func DeleteBucket(ctx context.Context, tx sql.Tx, bucket string) error {
 	return nil
 }
 
+func DeleteHost(ctx context.Context, tx sql.Tx, hk types.PublicKey) error {
+	var hostID int64
+	err := tx.QueryRow(ctx, "SELECT id FROM hosts WHERE public_key = ?", PublicKey(hk)).Scan(&hostID)
+	if errors.Is(err, dsql.ErrNoRows) {
+		return api.ErrHostNotFound
+	} else if err != nil {
+		return fmt.Errorf("failed to fetch host id: %w", err)
+	}
+
+	// the host's active contracts have to be archived before it's deleted
+	var active int64
+	err = tx.QueryRow(ctx, "SELECT COUNT(*) FROM contracts WHERE host_id = ? AND archival_reason IS NULL", hostID).Scan(&active)
+	if err != nil {
+		return fmt.Errorf("failed to count active contracts: %w", err)
+	} else if active > 0 {
+		return fmt.Errorf("host has %d active contracts", active)
+	}
+
+	// unlink its archived contracts, they keep the host key
+	_, err = tx.Exec(ctx, "UPDATE contracts SET host_id = NULL WHERE host_id = ?", hostID)
+	if err != nil {
+		return fmt.Errorf("failed to unlink contracts: %w", err)
+	}
+
+	// delete the host, its sectors, checks, addresses and price history are
+	// removed through cascading deletes
+	_, err = tx.Exec(ctx, "DELETE FROM hosts WHERE id = ?", hostID)
+	if err != nil {
+		return fmt.Errorf("failed to delete host: %w", err)
+	}
+	return nil
+}
+
 func DeleteHostSector(ctx context.Context, tx sql.Tx, hk types.PublicKey, root types.Hash256) (int, error) {
 	// fetch sector id
 	var sectorID int64
@@ -575,6 +785,78 @@ func DeleteMetadata(ctx context.Context, tx sql.Tx, objID int64) error {
 	return err
 }
 
+// DeleteObjectVersion permanently deletes the version of an object with the
+// given version ID, which is either the current version, a noncurrent version
+// or a delete marker. If the current version or the latest delete marker is
+// deleted, the most recent noncurrent version becomes the current version
+// again. It returns false if the version doesn't exist.
+func DeleteObjectVersion(ctx context.Context, tx sql.Tx, bucket, key, versionID string) (bool, error) {
+	// delete the current version
+	res, err := tx.Exec(ctx, `
+		DELETE FROM objects
+		WHERE object_id = ? AND version_id = ? AND db_bucket_id = (SELECT id FROM buckets WHERE buckets.name = ?)
+	`, key, versionID, bucket)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete current version: %w", err)
+	} else if n, err := res.RowsAffected(); err != nil {
+		return false, fmt.Errorf("failed to fetch rows affected: %w", err)
+	} else if n == 0 {
+		// delete a noncurrent version or delete marker
+		var id int64
+		var objID dsql.NullInt64
+		err := tx.QueryRow(ctx, `
+			SELECT v.id, v.db_object_id
+			FROM object_versions v
+			INNER JOIN buckets b ON b.id = v.db_bucket_id
+			WHERE v.object_key = ? AND v.version_id = ? AND b.name = ?
+		`, key, versionID, bucket).Scan(&id, &objID)
+		if errors.Is(err, dsql.ErrNoRows) {
+			return false, nil
+		} else if err != nil {
+			return false, fmt.Errorf("failed to fetch object version: %w", err)
+		}
+
+		// deleting the object cascades to the version
+		if objID.Valid {
+			_, err = tx.Exec(ctx, "DELETE FROM objects WHERE id = ?", objID.Int64)
+		} else {
+			_, err = tx.Exec(ctx, "DELETE FROM object_versions WHERE id = ?", id)
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to delete object version: %w", err)
+		}
+	}
+
+	// if there's no current version, restore the latest version unless it's a
+	// delete marker
+	var exists bool
+	if err := tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM objects WHERE object_id = ? AND db_bucket_id = (SELECT id FROM buckets WHERE buckets.name = ?))", key, bucket).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check whether object exists: %w", err)
+	} else if exists {
+		return true, nil
+	}
+	var id int64
+	var objID dsql.NullInt64
+	err = tx.QueryRow(ctx, `
+		SELECT v.id, v.db_object_id
+		FROM object_versions v
+		INNER JOIN buckets b ON b.id = v.db_bucket_id
+		WHERE v.object_key = ? AND b.name = ?
+		ORDER BY v.id DESC
+		LIMIT 1
+	`, key, bucket).Scan(&id, &objID)
+	if errors.Is(err, dsql.ErrNoRows) || (err == nil && !objID.Valid) {
+		return true, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to fetch latest object version: %w", err)
+	} else if _, err := tx.Exec(ctx, "DELETE FROM object_versions WHERE id = ?", id); err != nil {
+		return false, fmt.Errorf("failed to delete object version: %w", err)
+	} else if _, err := tx.Exec(ctx, "UPDATE objects SET object_id = ? WHERE id = ?", key, objID.Int64); err != nil {
+		return false, fmt.Errorf("failed to restore object version: %w", err)
+	}
+	return true, nil
+}
+
 func DeleteSetting(ctx context.Context, tx sql.Tx, key string) error {
 	if _, err := tx.Exec(ctx, "DELETE FROM settings WHERE `key` = ?", key); err != nil {
 		return fmt.Errorf("failed to delete setting '%s': %w", key, err)
@@ -594,6 +876,42 @@ func DeleteWebhook(ctx context.Context, tx sql.Tx, wh webhooks.Webhook) error {
 	return nil
 }
 
+func DeleteWebhookDeadLetter(ctx context.Context, tx sql.Tx, id int64) error {
+	res, err := tx.Exec(ctx, "DELETE FROM webhook_dead_letters WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete dead letter: %w", err)
+	} else if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	} else if n == 0 {
+		return webhooks.ErrDeadLetterNotFound
+	}
+	return nil
+}
+
+func FindAlternativeContracts(ctx context.Context, tx sql.Tx, root types.Hash256) ([]types.FileContractID, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT c.fcid
+		FROM sectors s
+		INNER JOIN contract_sectors cs ON cs.db_sector_id = s.id
+		INNER JOIN contracts c ON c.id = cs.db_contract_id
+		WHERE s.root = ? AND c.archival_reason IS NULL
+	`, Hash256(root))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch contracts: %w", err)
+	}
+	defer rows.Close()
+
+	var fcids []types.FileContractID
+	for rows.Next() {
+		var fcid types.FileContractID
+		if err := rows.Scan((*FileContractID)(&fcid)); err != nil {
+			return nil, fmt.Errorf("failed to scan contract id: %w", err)
+		}
+		fcids = append(fcids, fcid)
+	}
+	return fcids, rows.Err()
+}
+
 func FetchUsedContracts(ctx context.Context, tx sql.Tx, fcids []types.FileContractID) (map[types.FileContractID]UsedContract, error) {
 	if len(fcids) == 0 {
 		return make(map[types.FileContractID]UsedContract), nil
@@ -677,7 +995,7 @@ func HostAllowlist(ctx context.Context, tx sql.Tx) ([]types.PublicKey, error) {
 }
 
 func HostBlocklist(ctx context.Context, tx sql.Tx) ([]string, error) {
-	rows, err := tx.Query(ctx, "SELECT entry FROM host_blocklist_entries")
+	rows, err := tx.Query(ctx, "SELECT entry FROM host_blocklist_entries UNION ALL SELECT entry FROM host_blocklist_cidrs")
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch host blocklist: %w", err)
 	}
@@ -694,15 +1012,145 @@ func HostBlocklist(ctx context.Context, tx sql.Tx) ([]string, error) {
 	return blocklist, nil
 }
 
-func Hosts(ctx context.Context, tx sql.Tx, opts api.HostOptions) ([]api.Host, error) {
-	if opts.Offset < 0 {
-		return nil, ErrNegativeOffset
+// SplitBlocklistEntries splits the given blocklist entries into regular
+// entries and CIDR entries, the latter are normalised to the subnet they
+// describe.
+func SplitBlocklistEntries(entries []string) (hosts, cidrs []string) {
+	for _, entry := range entries {
+		if _, subnet, err := net.ParseCIDR(entry); err == nil {
+			cidrs = append(cidrs, subnet.String())
+		} else {
+			hosts = append(hosts, entry)
+		}
+	}
+	return
+}
+
+// BlockHostsInCIDR adds all hosts that announced an IP address within the
+// given CIDR, or whose address resolved to an IP address within the CIDR when
+// they were last scanned, to the blocklist entry with the given id.
+func BlockHostsInCIDR(ctx context.Context, tx sql.Tx, cidrID int64, cidr string) error {
+	_, subnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return fmt.Errorf("invalid CIDR '%s': %w", cidr, err)
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT id, net_address FROM hosts
+		UNION ALL SELECT id, resolved_addresses FROM hosts
+		UNION ALL SELECT db_host_id, net_address FROM host_addresses`)
+	if err != nil {
+		return fmt.Errorf("failed to fetch host addresses: %w", err)
+	}
+	defer rows.Close()
+
+	blocked := make(map[int64]struct{})
+	for rows.Next() {
+		var hostID int64
+		var addr dsql.NullString
+		if err := rows.Scan(&hostID, &addr); err != nil {
+			return fmt.Errorf("failed to scan host address: %w", err)
+		} else if subnetContainsAny(subnet, strings.Split(addr.String, ",")) {
+			blocked[hostID] = struct{}{}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to fetch host addresses: %w", err)
+	}
+	rows.Close()
+
+	if _, err := tx.Exec(ctx, "DELETE FROM host_blocklist_cidr_hosts WHERE db_blocklist_cidr_id = ?", cidrID); err != nil {
+		return fmt.Errorf("failed to remove hosts from blocklist: %w", err)
+	}
+	for hostID := range blocked {
+		if _, err := tx.Exec(ctx, "INSERT INTO host_blocklist_cidr_hosts (db_blocklist_cidr_id, db_host_id) VALUES (?, ?)", cidrID, hostID); err != nil {
+			return fmt.Errorf("failed to insert host into blocklist: %w", err)
+		}
+	}
+	return nil
+}
+
+// UpdateHostBlocklistCIDRs updates the CIDR blocklist entries the host with
+// the given id is blocked by, using the addresses it announced itself with and
+// the IP addresses those resolved to when it was last scanned.
+func UpdateHostBlocklistCIDRs(ctx context.Context, tx sql.Tx, hostID int64) error {
+	rows, err := tx.Query(ctx, `
+		SELECT net_address FROM hosts WHERE id = ?
+		UNION ALL SELECT resolved_addresses FROM hosts WHERE id = ?
+		UNION ALL SELECT net_address FROM host_addresses WHERE db_host_id = ?`, hostID, hostID, hostID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch host addresses: %w", err)
+	}
+	defer rows.Close()
+
+	var addrs []string
+	for rows.Next() {
+		var addr dsql.NullString
+		if err := rows.Scan(&addr); err != nil {
+			return fmt.Errorf("failed to scan host address: %w", err)
+		}
+		addrs = append(addrs, strings.Split(addr.String, ",")...)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to fetch host addresses: %w", err)
+	}
+	rows.Close()
+
+	rows, err = tx.Query(ctx, "SELECT id, entry FROM host_blocklist_cidrs")
+	if err != nil {
+		return fmt.Errorf("failed to fetch CIDR block list: %w", err)
+	}
+	defer rows.Close()
+
+	var blocked []int64
+	for rows.Next() {
+		var id int64
+		var entry string
+		if err := rows.Scan(&id, &entry); err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+		_, subnet, err := net.ParseCIDR(entry)
+		if err != nil {
+			continue
+		} else if subnetContainsAny(subnet, addrs) {
+			blocked = append(blocked, id)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to fetch CIDR block list: %w", err)
+	}
+	rows.Close()
+
+	if _, err := tx.Exec(ctx, "DELETE FROM host_blocklist_cidr_hosts WHERE db_host_id = ?", hostID); err != nil {
+		return fmt.Errorf("failed to remove host from blocklist: %w", err)
+	}
+	for _, id := range blocked {
+		if _, err := tx.Exec(ctx, "INSERT INTO host_blocklist_cidr_hosts (db_blocklist_cidr_id, db_host_id) VALUES (?, ?)", id, hostID); err != nil {
+			return fmt.Errorf("failed to insert host into blocklist: %w", err)
+		}
+	}
+	return nil
+}
+
+// subnetContainsAny returns true if any of the given addresses, which may or
+// may not contain a port, is an IP address within the given subnet.
+func subnetContainsAny(subnet *net.IPNet, addrs []string) bool {
+	for _, addr := range addrs {
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			addr = host
+		}
+		if ip := net.ParseIP(addr); ip != nil && subnet.Contains(ip) {
+			return true
+		}
 	}
+	return false
+}
 
+func Hosts(ctx context.Context, tx sql.Tx, opts api.HostOptions) ([]api.Host, error) {
 	var hasAllowlist, hasBlocklist bool
 	if err := tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM host_allowlist_entries)").Scan(&hasAllowlist); err != nil {
 		return nil, fmt.Errorf("failed to check for allowlist: %w", err)
-	} else if err := tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM host_blocklist_entries)").Scan(&hasBlocklist); err != nil {
+	} else if err := tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM host_blocklist_entries) OR EXISTS (SELECT 1 FROM host_blocklist_cidrs)").Scan(&hasBlocklist); err != nil {
 		return nil, fmt.Errorf("failed to check for blocklist: %w", err)
 	}
 
@@ -725,14 +1173,14 @@ func Hosts(ctx context.Context, tx sql.Tx, opts api.HostOptions) ([]api.Host, er
 			whereExprs = append(whereExprs, "EXISTS (SELECT 1 FROM host_allowlist_entry_hosts hbeh WHERE hbeh.db_host_id = h.id)")
 		}
 		if hasBlocklist {
-			whereExprs = append(whereExprs, "NOT EXISTS (SELECT 1 FROM host_blocklist_entry_hosts hbeh WHERE hbeh.db_host_id = h.id)")
+			whereExprs = append(whereExprs, "NOT "+hostBlockedExpr)
 		}
 	case api.HostFilterModeBlocked:
 		if hasAllowlist {
 			whereExprs = append(whereExprs, "NOT EXISTS (SELECT 1 FROM host_allowlist_entry_hosts hbeh WHERE hbeh.db_host_id = h.id)")
 		}
 		if hasBlocklist {
-			whereExprs = append(whereExprs, "EXISTS (SELECT 1 FROM host_blocklist_entry_hosts hbeh WHERE hbeh.db_host_id = h.id)")
+			whereExprs = append(whereExprs, hostBlockedExpr)
 		}
 		if !hasAllowlist && !hasBlocklist {
 			// if neither an allowlist nor a blocklist exist, all hosts are
@@ -765,6 +1213,8 @@ func Hosts(ctx context.Context, tx sql.Tx, opts api.HostOptions) ([]api.Host, er
 			whereExprs = append(whereExprs, "EXISTS (SELECT 1 FROM hosts h2 INNER JOIN host_checks hc ON hc.db_host_id = h2.id AND h2.id = h.id WHERE (hc.usability_blocked = 0 AND hc.usability_offline = 0 AND hc.usability_low_score = 0 AND hc.usability_redundant_ip = 0 AND hc.usability_gouging = 0 AND hc.usability_low_max_duration = 0 AND hc.usability_not_accepting_contracts = 0 AND hc.usability_not_announced = 0 AND hc.usability_not_completing_scan = 0))")
 		case api.UsabilityFilterModeUnusable:
 			whereExprs = append(whereExprs, "EXISTS (SELECT 1 FROM hosts h2 INNER JOIN host_checks hc ON hc.db_host_id = h2.id AND h2.id = h.id WHERE (hc.usability_blocked = 1 OR hc.usability_offline = 1 OR hc.usability_low_score = 1 OR hc.usability_redundant_ip = 1 OR hc.usability_gouging = 1 OR hc.usability_low_max_duration = 1 OR hc.usability_not_accepting_contracts = 1 OR hc.usability_not_announced = 1 OR hc.usability_not_completing_scan = 1))")
+		case api.UsabilityFilterModeRHP3:
+			whereExprs = append(whereExprs, "h.scanned = 1 AND h.siamux_reachable = 1")
 		}
 	}
 
@@ -774,11 +1224,21 @@ func Hosts(ctx context.Context, tx sql.Tx, opts api.HostOptions) ([]api.Host, er
 		args = append(args, UnixTimeMS(opts.MaxLastScan))
 	}
 
-	// offset + limit
+	// filter cursor
+	if opts.Cursor != "" {
+		hk, err := api.ParseHostCursor(opts.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		whereExprs = append(whereExprs, "h.public_key > ?")
+		args = append(args, PublicKey(hk))
+	}
+
+	// limit
 	if opts.Limit == -1 {
 		opts.Limit = math.MaxInt64
 	}
-	offsetLimitStr := fmt.Sprintf("LIMIT %d OFFSET %d", opts.Limit, opts.Offset)
+	limitStr := fmt.Sprintf("LIMIT %d", opts.Limit)
 
 	// fetch stored data for each host
 	rows, err := tx.Query(ctx, "SELECT host_key, SUM(size) FROM contracts WHERE archival_reason IS NULL GROUP BY host_key")
@@ -803,10 +1263,10 @@ func Hosts(ctx context.Context, tx sql.Tx, opts api.HostOptions) ([]api.Host, er
 		blockedExprs = append(blockedExprs, "NOT EXISTS (SELECT 1 FROM host_allowlist_entry_hosts hbeh WHERE hbeh.db_host_id = h.id)")
 	}
 	if hasBlocklist {
-		blockedExprs = append(blockedExprs, "EXISTS (SELECT 1 FROM host_blocklist_entry_hosts hbeh WHERE hbeh.db_host_id = h.id)")
+		blockedExprs = append(blockedExprs, hostBlockedExpr)
 	}
 
-	var orderByExpr string
+	orderByExpr := "ORDER BY h.public_key"
 	var blockedExpr string
 	if len(blockedExprs) > 0 {
 		blockedExpr = strings.Join(blockedExprs, " OR ")
@@ -841,6 +1301,7 @@ SELECT
 	h.failed_interactions,
 	COALESCE(h.lost_sectors, 0),
 	h.scanned,
+	COALESCE(h.siamux_reachable, 0),
 
 	%s,
 
@@ -865,12 +1326,16 @@ SELECT
 	COALESCE(hc.gouging_download_err, ""),
 	COALESCE(hc.gouging_gouging_err, ""),
 	COALESCE(hc.gouging_prune_err, ""),
-	COALESCE(hc.gouging_upload_err, "")
+	COALESCE(hc.gouging_upload_err, ""),
+
+	COALESCE(hc.formation_backoff_count, 0),
+	COALESCE(hc.unstable_pricing, 0),
+	COALESCE(hc.geo_score, 1)
 FROM hosts h
 LEFT JOIN host_checks hc ON hc.db_host_id = h.id
 %s
 %s
-%s`, blockedExpr, whereExpr, orderByExpr, offsetLimitStr), args...)
+%s`, blockedExpr, whereExpr, orderByExpr, limitStr), args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch hosts: %w", err)
 	}
@@ -887,11 +1352,11 @@ LEFT JOIN host_checks hc ON hc.db_host_id = h.id
 			(*HostSettings)(&h.Settings), (*V2HostSettings)(&h.V2Settings), &h.Interactions.TotalScans, (*UnixTimeMS)(&h.Interactions.LastScan), &h.Interactions.LastScanSuccess,
 			&h.Interactions.SecondToLastScanSuccess, (*DurationMS)(&h.Interactions.Uptime), (*DurationMS)(&h.Interactions.Downtime),
 			&h.Interactions.SuccessfulInteractions, &h.Interactions.FailedInteractions, &h.Interactions.LostSectors,
-			&h.Scanned, &h.Blocked, &h.Checks.UsabilityBreakdown.Blocked, &h.Checks.UsabilityBreakdown.Offline, &h.Checks.UsabilityBreakdown.LowScore, &h.Checks.UsabilityBreakdown.RedundantIP,
+			&h.Scanned, &h.SiaMuxReachable, &h.Blocked, &h.Checks.UsabilityBreakdown.Blocked, &h.Checks.UsabilityBreakdown.Offline, &h.Checks.UsabilityBreakdown.LowScore, &h.Checks.UsabilityBreakdown.RedundantIP,
 			&h.Checks.UsabilityBreakdown.Gouging, &h.Checks.UsabilityBreakdown.LowMaxDuration, &h.Checks.UsabilityBreakdown.NotAcceptingContracts, &h.Checks.UsabilityBreakdown.NotAnnounced, &h.Checks.UsabilityBreakdown.NotCompletingScan,
 			&h.Checks.ScoreBreakdown.Age, &h.Checks.ScoreBreakdown.Collateral, &h.Checks.ScoreBreakdown.Interactions, &h.Checks.ScoreBreakdown.StorageRemaining, &h.Checks.ScoreBreakdown.Uptime,
 			&h.Checks.ScoreBreakdown.Version, &h.Checks.ScoreBreakdown.Prices, &h.Checks.GougingBreakdown.DownloadErr, &h.Checks.GougingBreakdown.GougingErr,
-			&h.Checks.GougingBreakdown.PruneErr, &h.Checks.GougingBreakdown.UploadErr)
+			&h.Checks.GougingBreakdown.PruneErr, &h.Checks.GougingBreakdown.UploadErr, &h.Checks.FormationBackoffCount, &h.Checks.UnstablePricing, &h.Checks.GeoScore)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan host: %w", err)
 		}
@@ -918,6 +1383,21 @@ LEFT JOIN host_checks hc ON hc.db_host_id = h.id
 	if err != nil {
 		return nil, err
 	}
+
+	// fill in the allocated storage for hosts that have been checked
+	for i := range hosts {
+		if hosts[i].Checks.IsZero() {
+			continue
+		}
+		totalStorage := hosts[i].Settings.TotalStorage
+		if hosts[i].IsV2() {
+			totalStorage = hosts[i].V2Settings.TotalStorage
+		}
+		hosts[i].Checks.AllocatedStorage = hosts[i].StoredData
+		if totalStorage > 0 {
+			hosts[i].Checks.AllocatedStoragePct = float64(hosts[i].StoredData) / float64(totalStorage) * 100
+		}
+	}
 	return hosts, nil
 }
 
@@ -943,6 +1423,26 @@ func InsertBufferedSlab(ctx context.Context, tx sql.Tx, fileName string, ec obje
 	return bufferedSlabID, nil
 }
 
+// InsertDeleteMarker adds a delete marker for the object with the given key
+// and returns its version ID.
+func InsertDeleteMarker(ctx context.Context, tx sql.Tx, bucket, key string) (string, error) {
+	versionID := newVersionID()
+	res, err := tx.Exec(ctx, `
+		INSERT INTO object_versions (created_at, db_bucket_id, object_key, version_id, db_object_id)
+		SELECT ?, b.id, ?, ?, NULL
+		FROM buckets b
+		WHERE b.name = ?
+	`, time.Now(), key, versionID, bucket)
+	if err != nil {
+		return "", fmt.Errorf("failed to insert delete marker: %w", err)
+	} else if n, err := res.RowsAffected(); err != nil {
+		return "", fmt.Errorf("failed to fetch rows affected: %w", err)
+	} else if n == 0 {
+		return "", api.ErrBucketNotFound
+	}
+	return versionID, nil
+}
+
 func InsertMetadata(ctx context.Context, tx sql.Tx, objID, muID *int64, md api.ObjectUserMetadata) error {
 	if len(md) == 0 {
 		return nil
@@ -963,7 +1463,7 @@ func InsertMetadata(ctx context.Context, tx sql.Tx, objID, muID *int64, md api.O
 	return nil
 }
 
-func InsertMultipartUpload(ctx context.Context, tx sql.Tx, bucket, key string, ec object.EncryptionKey, mimeType string, metadata api.ObjectUserMetadata) (string, error) {
+func InsertMultipartUpload(ctx context.Context, tx sql.Tx, bucket, key string, ec object.EncryptionKey, mimeType string, metadata api.ObjectUserMetadata, ttl time.Duration) (string, error) {
 	// fetch bucket id
 	var bucketID int64
 	err := tx.QueryRow(ctx, "SELECT id FROM buckets WHERE buckets.name = ?", bucket).
@@ -974,14 +1474,21 @@ func InsertMultipartUpload(ctx context.Context, tx sql.Tx, bucket, key string, e
 		return "", fmt.Errorf("failed to fetch bucket id: %w", err)
 	}
 
+	// determine expiry
+	now := time.Now()
+	var expiresAt any
+	if ttl > 0 {
+		expiresAt = UnixTimeMS(now.Add(ttl))
+	}
+
 	// insert multipart upload
 	uploadIDEntropy := frand.Entropy256()
 	uploadID := hex.EncodeToString(uploadIDEntropy[:])
 	var muID int64
 	res, err := tx.Exec(ctx, `
-		INSERT INTO multipart_uploads (created_at, `+"`key`"+`, upload_id, object_id, db_bucket_id, mime_type)
-		VALUES (?, ?, ?, ?, ?, ?)
-	`, time.Now(), EncryptionKey(ec), uploadID, key, bucketID, mimeType)
+		INSERT INTO multipart_uploads (created_at, `+"`key`"+`, upload_id, object_id, db_bucket_id, mime_type, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, now, EncryptionKey(ec), uploadID, key, bucketID, mimeType, expiresAt)
 	if err != nil {
 		return "", fmt.Errorf("failed to create multipart upload: %w", err)
 	} else if muID, err = res.LastInsertId(); err != nil {
@@ -1011,10 +1518,38 @@ func InsertObject(ctx context.Context, tx sql.Tx, key string, bucketID, size int
 	return res.LastInsertId()
 }
 
+// InsertObjectIfNotExists inserts a new object unless an object with the same
+// key already exists in the bucket, in which case api.ErrObjectExists is
+// returned. The existence check and the insert happen in a single statement
+// to avoid races between concurrent uploaders.
+func InsertObjectIfNotExists(ctx context.Context, tx sql.Tx, key string, bucketID, size int64, ec object.EncryptionKey, mimeType, eTag string) (int64, error) {
+	res, err := tx.Exec(ctx, `INSERT INTO objects (created_at, object_id, db_bucket_id, `+"`key`"+`, size, mime_type, etag)
+						SELECT ?, ?, b.id, ?, ?, ?, ?
+						FROM buckets b
+						WHERE b.id = ? AND NOT EXISTS (SELECT 1 FROM objects o WHERE o.db_bucket_id = ? AND o.object_id = ?)`,
+		time.Now(),
+		key,
+		EncryptionKey(ec),
+		size,
+		mimeType,
+		eTag,
+		bucketID,
+		bucketID,
+		key)
+	if err != nil {
+		return 0, err
+	} else if n, err := res.RowsAffected(); err != nil {
+		return 0, err
+	} else if n == 0 {
+		return 0, api.ErrObjectExists
+	}
+	return res.LastInsertId()
+}
+
 func LoadSlabBuffers(ctx context.Context, tx sql.Tx) (bufferedSlabs []LoadedSlabBuffer, orphanedBuffers []string, err error) {
 	// collect all buffers
 	rows, err := tx.Query(ctx, `
-			SELECT bs.id, bs.filename, sla.key, sla.min_shards, sla.total_shards
+			SELECT bs.id, bs.created_at, bs.filename, sla.key, sla.min_shards, sla.total_shards
 			FROM buffered_slabs bs
 			INNER JOIN slabs sla ON sla.db_buffered_slab_id = bs.id
 		`)
@@ -1025,9 +1560,11 @@ func LoadSlabBuffers(ctx context.Context, tx sql.Tx) (bufferedSlabs []LoadedSlab
 
 	for rows.Next() {
 		var bs LoadedSlabBuffer
-		if err := rows.Scan(&bs.ID, &bs.Filename, (*EncryptionKey)(&bs.Key), &bs.MinShards, &bs.TotalShards); err != nil {
+		var createdAt dsql.NullTime
+		if err := rows.Scan(&bs.ID, &createdAt, &bs.Filename, (*EncryptionKey)(&bs.Key), &bs.MinShards, &bs.TotalShards); err != nil {
 			return nil, nil, fmt.Errorf("failed to scan buffered slab: %w", err)
 		}
+		bs.CreatedAt = createdAt.Time
 		bufferedSlabs = append(bufferedSlabs, bs)
 	}
 
@@ -1382,6 +1919,25 @@ func MultipartUploadForCompletion(ctx context.Context, tx sql.Tx, bucket, key, u
 	return mpu, neededParts, size, eTag, nil
 }
 
+// NewObjectVersion assigns a new version ID to the current version of an
+// object and returns it.
+func NewObjectVersion(ctx context.Context, tx sql.Tx, bucket, key string) (string, error) {
+	versionID := newVersionID()
+	res, err := tx.Exec(ctx, `
+		UPDATE objects
+		SET version_id = ?
+		WHERE object_id = ? AND db_bucket_id = (SELECT id FROM buckets WHERE buckets.name = ?)
+	`, versionID, key, bucket)
+	if err != nil {
+		return "", fmt.Errorf("failed to update object version: %w", err)
+	} else if n, err := res.RowsAffected(); err != nil {
+		return "", fmt.Errorf("failed to fetch rows affected: %w", err)
+	} else if n == 0 {
+		return "", api.ErrObjectNotFound
+	}
+	return versionID, nil
+}
+
 func NormalizePeer(peer string) (string, error) {
 	host, _, err := net.SplitHostPort(peer)
 	if err != nil {
@@ -1414,23 +1970,92 @@ func NormalizePeer(peer string) (string, error) {
 	return normalized.String(), nil
 }
 
-func Objects(ctx context.Context, tx Tx, bucket, prefix, substring, delim, sortBy, sortDir, marker string, limit int, slabEncryptionKey object.EncryptionKey) (resp api.ObjectsResponse, err error) {
-	switch delim {
-	case "":
-		resp, err = listObjectsNoDelim(ctx, tx, bucket, prefix, substring, sortBy, sortDir, marker, limit, slabEncryptionKey)
-	case "/":
-		resp, err = listObjectsSlashDelim(ctx, tx, bucket, prefix, sortBy, sortDir, marker, limit, slabEncryptionKey)
-	default:
-		err = fmt.Errorf("unsupported delimiter: '%s'", delim)
-	}
-	return
+// ObjectKeys returns the keys of up to limit objects whose key starts with the
+// given prefix.
+func ObjectKeys(ctx context.Context, tx sql.Tx, bucket, prefix string, limit int64) ([]string, error) {
+	return objectKeysWithPrefix(ctx, tx, bucket, prefix, "", nil, limit)
 }
 
-func ObjectMetadata(ctx context.Context, tx Tx, bucket, key string) (api.Object, error) {
-	// fetch object id
-	var objID int64
-	if err := tx.QueryRow(ctx, `
-		SELECT o.id
+// ObjectTags returns the tags of the object with given key.
+func ObjectTags(ctx context.Context, tx sql.Tx, bucket, key string) (api.ObjectTags, error) {
+	objID, err := objectID(ctx, tx, bucket, key)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx, "SELECT `key`, value FROM object_tags WHERE db_object_id = ?", objID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch object tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := make(api.ObjectTags)
+	for rows.Next() {
+		var k, v string
+		if err := rows.Scan(&k, &v); err != nil {
+			return nil, fmt.Errorf("failed to scan object tag: %w", err)
+		}
+		tags[k] = v
+	}
+	return tags, rows.Err()
+}
+
+// ObjectsTags returns the tags of the objects with given keys, objects without
+// tags are omitted from the result.
+func ObjectsTags(ctx context.Context, tx sql.Tx, bucket string, keys []string) (map[string]api.ObjectTags, error) {
+	tags := make(map[string]api.ObjectTags)
+	for i := 0; i < len(keys); i += objectTagsBatchSize {
+		batch := keys[i:min(i+objectTagsBatchSize, len(keys))]
+
+		args := []any{bucket}
+		for _, key := range batch {
+			args = append(args, key)
+		}
+		rows, err := tx.Query(ctx, fmt.Sprintf(`
+			SELECT o.object_id, t.key, t.value
+			FROM object_tags t
+			INNER JOIN objects o ON o.id = t.db_object_id
+			INNER JOIN buckets b ON b.id = o.db_bucket_id
+			WHERE b.name = ? AND o.object_id IN (%s)
+		`, strings.Repeat("?, ", len(batch)-1)+"?"), args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch object tags: %w", err)
+		}
+		for rows.Next() {
+			var key, k, v string
+			if err := rows.Scan(&key, &k, &v); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan object tag: %w", err)
+			}
+			if _, ok := tags[key]; !ok {
+				tags[key] = make(api.ObjectTags)
+			}
+			tags[key][k] = v
+		}
+		if err := errors.Join(rows.Err(), rows.Close()); err != nil {
+			return nil, fmt.Errorf("failed to fetch object tags: %w", err)
+		}
+	}
+	return tags, nil
+}
+
+func Objects(ctx context.Context, tx Tx, bucket, prefix, substring, delim, sortBy, sortDir, marker string, limit int, slabEncryptionKey object.EncryptionKey) (resp api.ObjectsResponse, err error) {
+	switch delim {
+	case "":
+		resp, err = listObjectsNoDelim(ctx, tx, bucket, prefix, substring, sortBy, sortDir, marker, limit, slabEncryptionKey)
+	case "/":
+		resp, err = listObjectsSlashDelim(ctx, tx, bucket, prefix, sortBy, sortDir, marker, limit, slabEncryptionKey)
+	default:
+		err = fmt.Errorf("unsupported delimiter: '%s'", delim)
+	}
+	return
+}
+
+func ObjectMetadata(ctx context.Context, tx Tx, bucket, key string) (api.Object, error) {
+	// fetch object id
+	var objID int64
+	if err := tx.QueryRow(ctx, `
+		SELECT o.id
 		FROM objects o
 		INNER JOIN buckets b ON b.id = o.db_bucket_id
 		WHERE o.object_id = ? AND b.name = ?
@@ -1441,12 +2066,13 @@ func ObjectMetadata(ctx context.Context, tx Tx, bucket, key string) (api.Object,
 	}
 
 	// fetch metadata
+	var versionID NullableString
 	om, err := tx.ScanObjectMetadata(tx.QueryRow(ctx, fmt.Sprintf(`
-		SELECT %s
+		SELECT %s, o.version_id
 		FROM objects o
 		INNER JOIN buckets b ON b.id = o.db_bucket_id
 		WHERE o.id = ?
-	`, tx.SelectObjectMetadataExpr()), objID))
+	`, tx.SelectObjectMetadataExpr()), objID), &versionID)
 	if err != nil {
 		return api.Object{}, fmt.Errorf("failed to fetch object metadata: %w", err)
 	}
@@ -1475,6 +2101,7 @@ func ObjectMetadata(ctx context.Context, tx Tx, bucket, key string) (api.Object,
 
 	return api.Object{
 		Metadata:       metadata,
+		VersionID:      string(versionID),
 		ObjectMetadata: om,
 		Object:         nil, // only return metadata
 	}, nil
@@ -1496,10 +2123,14 @@ func ObjectsStats(ctx context.Context, tx sql.Tx, opts api.ObjectsStatsOpts) (ap
 		args = append(args, bucketID)
 	}
 
-	// objects stats
+	// objects stats, noncurrent versions are not included
+	objectsExpr := "WHERE object_id IS NOT NULL"
+	if bucketExpr != "" {
+		objectsExpr = bucketExpr + " AND object_id IS NOT NULL"
+	}
 	var numObjects, totalObjectsSize uint64
 	var minHealth float64
-	err := tx.QueryRow(ctx, "SELECT COUNT(*), COALESCE(MIN(health), 1), COALESCE(SUM(size), 0) FROM objects "+bucketExpr, args...).
+	err := tx.QueryRow(ctx, "SELECT COUNT(*), COALESCE(MIN(health), 1), COALESCE(SUM(size), 0) FROM objects "+objectsExpr, args...).
 		Scan(&numObjects, &minHealth, &totalObjectsSize)
 	if err != nil {
 		return api.ObjectsStatsResponse{}, fmt.Errorf("failed to fetch objects stats: %w", err)
@@ -1559,6 +2190,56 @@ func ObjectsStats(ctx context.Context, tx sql.Tx, opts api.ObjectsStatsOpts) (ap
 	}, nil
 }
 
+func ObjectsStatsPerHost(ctx context.Context, tx sql.Tx, opts api.ObjectsStatsOpts) (map[types.PublicKey]api.HostStorageStats, error) {
+	var whereExpr string
+	var args []any
+	if opts.Bucket != "" {
+		var bucketID int64
+		err := tx.QueryRow(ctx, "SELECT id FROM buckets WHERE name = ?", opts.Bucket).
+			Scan(&bucketID)
+		if errors.Is(err, dsql.ErrNoRows) {
+			return nil, api.ErrBucketNotFound
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to fetch bucket id: %w", err)
+		}
+		whereExpr = `
+			WHERE EXISTS (
+				SELECT 1 FROM slices sli
+				INNER JOIN objects o ON o.id = sli.db_object_id AND o.db_bucket_id = ?
+				WHERE sli.db_slab_id = s.db_slab_id
+			)
+		`
+		args = append(args, bucketID)
+	}
+
+	rows, err := tx.Query(ctx, fmt.Sprintf(`
+		SELECT h.public_key, COUNT(*)
+		FROM sectors s
+		INNER JOIN host_sectors hs ON hs.db_sector_id = s.id
+		INNER JOIN hosts h ON h.id = hs.db_host_id
+		%s
+		GROUP BY h.public_key
+	`, whereExpr), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch per host sector stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := make(map[types.PublicKey]api.HostStorageStats)
+	for rows.Next() {
+		var hk PublicKey
+		var numSectors uint64
+		if err := rows.Scan(&hk, &numSectors); err != nil {
+			return nil, fmt.Errorf("failed to scan per host sector stats: %w", err)
+		}
+		stats[types.PublicKey(hk)] = api.HostStorageStats{
+			NumSectors:       numSectors,
+			TotalSectorsSize: numSectors * rhpv2.SectorSize,
+		}
+	}
+	return stats, rows.Err()
+}
+
 func PeerBanned(ctx context.Context, tx sql.Tx, addr string) (bool, error) {
 	// normalize the address to a CIDR
 	netCIDR, err := NormalizePeer(addr)
@@ -1631,97 +2312,465 @@ func Peers(ctx context.Context, tx sql.Tx) ([]syncer.PeerInfo, error) {
 	return peers, nil
 }
 
-func RecordHostScans(ctx context.Context, tx sql.Tx, scans []api.HostScan) error {
-	if len(scans) == 0 {
-		return nil
+// HostPriceHistory returns the price changes of all hosts that changed their
+// prices since the given time. For every host the most recent entry before
+// that time is included as well so the first change can be compared against
+// the previous prices.
+func HostPriceHistory(ctx context.Context, tx sql.Tx, since time.Time) (map[types.PublicKey][]api.HostPriceChange, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT h.public_key, hph.timestamp, hph.upload_price, hph.download_price
+		FROM host_price_history hph
+		INNER JOIN hosts h ON h.id = hph.db_host_id
+		WHERE hph.db_host_id IN (SELECT db_host_id FROM host_price_history WHERE timestamp >= ?) AND (
+			hph.timestamp >= ? OR hph.id = (
+				SELECT MAX(prev.id)
+				FROM host_price_history prev
+				WHERE prev.db_host_id = hph.db_host_id AND prev.timestamp < ?
+			)
+		)
+		ORDER BY hph.db_host_id, hph.timestamp, hph.id
+	`, UnixTimeMS(since), UnixTimeMS(since), UnixTimeMS(since))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch host price history: %w", err)
 	}
-	// NOTE: The order of the assignments in the UPDATE statement is important
-	// for MySQL compatibility. e.g. second_to_last_scan_success must be set
-	// before last_scan_success.
-	stmt, err := tx.Prepare(ctx, `
-		UPDATE hosts SET
-		scanned = scanned OR ?,
-		total_scans = total_scans + 1,
-		second_to_last_scan_success = last_scan_success,
-		last_scan_success = ?,
-		recent_downtime = CASE WHEN ? AND last_scan > 0 AND last_scan < ? THEN recent_downtime + ? - last_scan ELSE CASE WHEN ? THEN 0 ELSE recent_downtime END END,
-		recent_scan_failures = CASE WHEN ? THEN 0 ELSE recent_scan_failures + 1 END,
-		downtime = CASE WHEN ? AND last_scan > 0 AND last_scan < ? THEN downtime + ? - last_scan ELSE downtime END,
-		uptime = CASE WHEN ? AND last_scan > 0 AND last_scan < ? THEN uptime + ? - last_scan ELSE uptime END,
-		last_scan = ?,
-		settings = CASE WHEN ? THEN ? ELSE settings END,
-		v2_settings = CASE WHEN ? THEN ? ELSE v2_settings END,
-		price_table = CASE WHEN ? THEN ? ELSE price_table END,
-		price_table_expiry = CASE WHEN ? THEN ? ELSE price_table_expiry END,
-		successful_interactions = CASE WHEN ? THEN successful_interactions + 1 ELSE successful_interactions END,
-		failed_interactions = CASE WHEN ? THEN failed_interactions + 1 ELSE failed_interactions END
-		WHERE public_key = ?
+	defer rows.Close()
+
+	history := make(map[types.PublicKey][]api.HostPriceChange)
+	for rows.Next() {
+		var hk PublicKey
+		var change api.HostPriceChange
+		if err := rows.Scan(&hk, (*UnixTimeMS)(&change.Timestamp), (*Currency)(&change.UploadPrice), (*Currency)(&change.DownloadPrice)); err != nil {
+			return nil, fmt.Errorf("failed to scan host price change: %w", err)
+		}
+		history[types.PublicKey(hk)] = append(history[types.PublicKey(hk)], change)
+	}
+	return history, rows.Err()
+}
+
+func RecordHostScans(ctx context.Context, tx Tx, scans []api.HostScan) error {
+	for len(scans) > 0 {
+		batch := scans[:min(len(scans), hostScansBatchSize)]
+		scans = scans[len(batch):]
+
+		// fetch the ids of the scanned hosts, scans for unknown hosts are
+		// ignored
+		hostIDs, err := hostIDs(ctx, tx, batch)
+		if err != nil {
+			return err
+		}
+		var known []api.HostScan
+		for _, scan := range batch {
+			if _, ok := hostIDs[scan.HostKey]; ok {
+				known = append(known, scan)
+			}
+		}
+		if len(known) == 0 {
+			continue
+		}
+
+		// update the hosts
+		if err := tx.UpsertHostScans(ctx, known); err != nil {
+			return fmt.Errorf("failed to update hosts with scans: %w", err)
+		}
+
+		// record price changes
+		if err := recordHostPriceChanges(ctx, tx, hostIDs, known); err != nil {
+			return err
+		}
+
+		// record scan history
+		if err := recordHostScanHistory(ctx, tx, hostIDs, known); err != nil {
+			return err
+		}
+
+		// update the resolved addresses
+		if err := updateHostResolvedAddresses(ctx, tx, hostIDs, known); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateHostResolvedAddresses stores the IP addresses the hosts' addresses
+// resolved to during the given scans and updates the CIDR blocklist entries
+// the hosts are blocked by if they changed.
+func updateHostResolvedAddresses(ctx context.Context, tx sql.Tx, hostIDs map[types.PublicKey]int64, scans []api.HostScan) error {
+	updateStmt, err := tx.Prepare(ctx, "UPDATE hosts SET resolved_addresses = ? WHERE id = ? AND resolved_addresses <> ?")
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement to update resolved addresses: %w", err)
+	}
+	defer updateStmt.Close()
+
+	for _, scan := range scans {
+		if len(scan.ResolvedAddresses) == 0 {
+			continue
+		}
+		resolved := strings.Join(scan.ResolvedAddresses, ",")
+		hostID := hostIDs[scan.HostKey]
+		if res, err := updateStmt.Exec(ctx, resolved, hostID, resolved); err != nil {
+			return fmt.Errorf("failed to update resolved addresses: %w", err)
+		} else if n, err := res.RowsAffected(); err != nil {
+			return fmt.Errorf("failed to fetch rows affected: %w", err)
+		} else if n == 0 {
+			continue // unchanged
+		} else if err := UpdateHostBlocklistCIDRs(ctx, tx, hostID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hostIDs returns the ids of the hosts referenced by the given scans.
+func hostIDs(ctx context.Context, tx sql.Tx, scans []api.HostScan) (map[types.PublicKey]int64, error) {
+	args := make([]any, len(scans))
+	for i, scan := range scans {
+		args[i] = PublicKey(scan.HostKey)
+	}
+	rows, err := tx.Query(ctx, fmt.Sprintf("SELECT id, public_key FROM hosts WHERE public_key IN (%s)",
+		strings.Repeat("?, ", len(args)-1)+"?"), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch host ids: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make(map[types.PublicKey]int64, len(scans))
+	for rows.Next() {
+		var id int64
+		var hk PublicKey
+		if err := rows.Scan(&id, &hk); err != nil {
+			return nil, fmt.Errorf("failed to scan host id: %w", err)
+		}
+		ids[types.PublicKey(hk)] = id
+	}
+	return ids, rows.Err()
+}
+
+// recordHostPriceChanges adds an entry to a host's price history for every
+// successful scan where the upload or download price differs from the most
+// recent entry. Entries older than hostPriceHistoryRetention are pruned.
+func recordHostPriceChanges(ctx context.Context, tx sql.Tx, hostIDs map[types.PublicKey]int64, scans []api.HostScan) error {
+	latestStmt, err := tx.Prepare(ctx, `
+		SELECT upload_price, download_price
+		FROM host_price_history
+		WHERE db_host_id = ?
+		ORDER BY timestamp DESC, id DESC
+		LIMIT 1
 	`)
 	if err != nil {
-		return fmt.Errorf("failed to prepare statement to update host with scan: %w", err)
+		return fmt.Errorf("failed to prepare statement to fetch latest host price: %w", err)
 	}
-	defer stmt.Close()
+	defer latestStmt.Close()
+
+	insertStmt, err := tx.Prepare(ctx, `
+		INSERT INTO host_price_history (created_at, db_host_id, timestamp, upload_price, download_price)
+		VALUES (?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement to insert host price change: %w", err)
+	}
+	defer insertStmt.Close()
+
+	pruneStmt, err := tx.Prepare(ctx, "DELETE FROM host_price_history WHERE db_host_id = ? AND timestamp < ?")
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement to prune host price history: %w", err)
+	}
+	defer pruneStmt.Close()
+
+	for _, scan := range scans {
+		if !scan.Success {
+			continue
+		}
+		hostID := hostIDs[scan.HostKey]
+
+		// v2 hosts don't have a price table
+		upload, download := scan.PriceTable.UploadBandwidthCost, scan.PriceTable.DownloadBandwidthCost
+		if scan.PriceTable == (rhpv3.HostPriceTable{}) {
+			upload, download = scan.V2Settings.Prices.IngressPrice, scan.V2Settings.Prices.EgressPrice
+		}
+
+		var prevUpload, prevDownload Currency
+		err := latestStmt.QueryRow(ctx, hostID).Scan(&prevUpload, &prevDownload)
+		if err != nil && !errors.Is(err, dsql.ErrNoRows) {
+			return fmt.Errorf("failed to fetch latest host price: %w", err)
+		} else if err == nil && types.Currency(prevUpload).Equals(upload) && types.Currency(prevDownload).Equals(download) {
+			continue
+		}
+
+		if _, err := insertStmt.Exec(ctx, time.Now(), hostID, UnixTimeMS(scan.Timestamp), Currency(upload), Currency(download)); err != nil {
+			return fmt.Errorf("failed to insert host price change: %w", err)
+		} else if _, err := pruneStmt.Exec(ctx, hostID, UnixTimeMS(scan.Timestamp.Add(-hostPriceHistoryRetention))); err != nil {
+			return fmt.Errorf("failed to prune host price history: %w", err)
+		}
+	}
+	return nil
+}
+
+// recordHostScanHistory adds an entry to a host's scan history for every scan.
+// Entries older than hostScanHistoryRetention are pruned.
+func recordHostScanHistory(ctx context.Context, tx sql.Tx, hostIDs map[types.PublicKey]int64, scans []api.HostScan) error {
+	insertStmt, err := tx.Prepare(ctx, `
+		INSERT INTO host_scans (created_at, db_host_id, timestamp, success, siamux_reachable, latency, rpc_latency, error, settings, v2_settings, price_table)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement to insert host scan: %w", err)
+	}
+	defer insertStmt.Close()
+
+	pruneStmt, err := tx.Prepare(ctx, "DELETE FROM host_scans WHERE db_host_id = ? AND timestamp < ?")
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement to prune host scans: %w", err)
+	}
+	defer pruneStmt.Close()
 
 	now := time.Now()
 	for _, scan := range scans {
-		scanTime := scan.Timestamp.UnixMilli()
-		_, err = stmt.Exec(ctx,
-			scan.Success,                                    // scanned
-			scan.Success,                                    // last_scan_success
-			!scan.Success, scanTime, scanTime, scan.Success, // recent_downtime
-			scan.Success,                      // recent_scan_failures
-			!scan.Success, scanTime, scanTime, // downtime
-			scan.Success, scanTime, scanTime, // uptime
-			scanTime,                                  // last_scan
-			scan.Success, HostSettings(scan.Settings), // settings
-			scan.Success, V2HostSettings(scan.V2Settings), // settings
-			scan.Success, PriceTable(scan.PriceTable), // price_table
-			scan.Success, now, // price_table_expiry
-			scan.Success,  // successful_interactions
-			!scan.Success, // failed_interactions
-			PublicKey(scan.HostKey),
+		hostID := hostIDs[scan.HostKey]
+
+		// settings and price tables are only stored for successful scans
+		var settings HostSettings
+		var v2Settings V2HostSettings
+		var pt PriceTable
+		if scan.Success {
+			settings = HostSettings(scan.Settings)
+			v2Settings = V2HostSettings(scan.V2Settings)
+			pt = PriceTable(scan.PriceTable)
+		}
+
+		if _, err := insertStmt.Exec(ctx, now, hostID, UnixTimeMS(scan.Timestamp), scan.Success, scan.SiaMuxReachable, DurationMS(scan.Latency), DurationMS(scan.RPCLatency), NullableString(scan.Error), settings, v2Settings, pt); err != nil {
+			return fmt.Errorf("failed to insert host scan: %w", err)
+		} else if _, err := pruneStmt.Exec(ctx, hostID, UnixTimeMS(scan.Timestamp.Add(-hostScanHistoryRetention))); err != nil {
+			return fmt.Errorf("failed to prune host scans: %w", err)
+		}
+	}
+	return nil
+}
+
+// RecordHostBenchmark adds a benchmark to the given host's benchmark history.
+// Entries older than hostScanHistoryRetention are pruned.
+func RecordHostBenchmark(ctx context.Context, tx sql.Tx, hk types.PublicKey, benchmark api.HostBenchmark) error {
+	var hostID int64
+	if err := tx.QueryRow(ctx, "SELECT id FROM hosts WHERE public_key = ?", PublicKey(hk)).Scan(&hostID); errors.Is(err, dsql.ErrNoRows) {
+		return api.ErrHostNotFound
+	} else if err != nil {
+		return fmt.Errorf("failed to fetch host id: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO host_benchmarks (created_at, db_host_id, timestamp, upload_mbps, download_mbps, ttfb)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, time.Now(), hostID, UnixTimeMS(benchmark.Timestamp), benchmark.UploadMBps, benchmark.DownloadMBps, DurationMS(benchmark.TTFB)); err != nil {
+		return fmt.Errorf("failed to insert host benchmark: %w", err)
+	} else if _, err := tx.Exec(ctx, "DELETE FROM host_benchmarks WHERE db_host_id = ? AND timestamp < ?", hostID, UnixTimeMS(benchmark.Timestamp.Add(-hostScanHistoryRetention))); err != nil {
+		return fmt.Errorf("failed to prune host benchmarks: %w", err)
+	}
+	return nil
+}
+
+// HostBenchmarks returns the most recent benchmarks of the given host, ordered
+// from newest to oldest. A negative limit returns all benchmarks.
+func HostBenchmarks(ctx context.Context, tx sql.Tx, hk types.PublicKey, limit int) ([]api.HostBenchmark, error) {
+	var hostID int64
+	if err := tx.QueryRow(ctx, "SELECT id FROM hosts WHERE public_key = ?", PublicKey(hk)).Scan(&hostID); errors.Is(err, dsql.ErrNoRows) {
+		return nil, api.ErrHostNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to fetch host id: %w", err)
+	}
+
+	if limit < 0 {
+		limit = math.MaxInt64
+	}
+	rows, err := tx.Query(ctx, `
+		SELECT timestamp, upload_mbps, download_mbps, ttfb
+		FROM host_benchmarks
+		WHERE db_host_id = ?
+		ORDER BY timestamp DESC, id DESC
+		LIMIT ?
+	`, hostID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch host benchmarks: %w", err)
+	}
+	defer rows.Close()
+
+	benchmarks := make([]api.HostBenchmark, 0)
+	for rows.Next() {
+		var benchmark api.HostBenchmark
+		var timestamp UnixTimeMS
+		var ttfb DurationMS
+		if err := rows.Scan(&timestamp, &benchmark.UploadMBps, &benchmark.DownloadMBps, &ttfb); err != nil {
+			return nil, fmt.Errorf("failed to scan host benchmark: %w", err)
+		}
+		benchmark.Timestamp = time.Time(timestamp)
+		benchmark.TTFB = api.DurationMS(ttfb)
+		benchmarks = append(benchmarks, benchmark)
+	}
+	return benchmarks, rows.Err()
+}
+
+// HostScanHistory returns the most recent scans of the given host, ordered
+// from newest to oldest.
+func HostScanHistory(ctx context.Context, tx sql.Tx, hk types.PublicKey, limit int) ([]api.HostScan, error) {
+	var hostID int64
+	if err := tx.QueryRow(ctx, "SELECT id FROM hosts WHERE public_key = ?", PublicKey(hk)).Scan(&hostID); errors.Is(err, dsql.ErrNoRows) {
+		return nil, api.ErrHostNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to fetch host id: %w", err)
+	}
+
+	if limit < 0 {
+		limit = math.MaxInt64
+	}
+	rows, err := tx.Query(ctx, `
+		SELECT timestamp, success, siamux_reachable, latency, rpc_latency, error, settings, v2_settings, price_table
+		FROM host_scans
+		WHERE db_host_id = ?
+		ORDER BY timestamp DESC, id DESC
+		LIMIT ?
+	`, hostID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch host scans: %w", err)
+	}
+	defer rows.Close()
+
+	scans := make([]api.HostScan, 0)
+	for rows.Next() {
+		scan := api.HostScan{HostKey: hk}
+		var timestamp UnixTimeMS
+		var latency, rpcLatency DurationMS
+		var scanErr NullableString
+		if err := rows.Scan(&timestamp, &scan.Success, &scan.SiaMuxReachable, &latency, &rpcLatency, &scanErr, (*HostSettings)(&scan.Settings), (*V2HostSettings)(&scan.V2Settings), (*PriceTable)(&scan.PriceTable)); err != nil {
+			return nil, fmt.Errorf("failed to scan host scan: %w", err)
+		}
+		scan.Timestamp = time.Time(timestamp)
+		scan.Latency = api.DurationMS(latency)
+		scan.RPCLatency = api.DurationMS(rpcLatency)
+		scan.Error = string(scanErr)
+		scans = append(scans, scan)
+	}
+	return scans, rows.Err()
+}
+
+func RecoverObjects(ctx context.Context, tx sql.Tx, roots []types.Hash256) (matched, recovered, deleted int64, err error) {
+	if len(roots) == 0 {
+		return 0, 0, 0, nil
+	}
+
+	// prepare statement to find the slab of a sector
+	querySlabStmt, err := tx.Prepare(ctx, "SELECT db_slab_id FROM sectors WHERE root = ?")
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to prepare statement to query slab: %w", err)
+	}
+	defer querySlabStmt.Close()
+
+	// prepare statement to record the objects referencing a slab that
+	// weren't recovered yet
+	insertRecoveredStmt, err := tx.Prepare(ctx, `INSERT INTO recovered_objects (created_at, db_bucket_id, object_key, size, deleted)
+		SELECT DISTINCT ?, o.db_bucket_id, o.object_id, o.size, FALSE
+		FROM slices sl
+		INNER JOIN objects o ON o.id = sl.db_object_id
+		WHERE sl.db_slab_id = ? AND o.object_id IS NOT NULL AND NOT EXISTS (
+			SELECT 1
+			FROM recovered_objects ro
+			WHERE ro.db_bucket_id = o.db_bucket_id AND ro.object_key = o.object_id
+		)`)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to prepare statement to insert recovered objects: %w", err)
+	}
+	defer insertRecoveredStmt.Close()
+
+	// prepare statement to record the deleted objects referencing a slab,
+	// these are objects without a current version whose noncurrent versions
+	// still reference the slab
+	insertDeletedStmt, err := tx.Prepare(ctx, `INSERT INTO recovered_objects (created_at, db_bucket_id, object_key, size, deleted)
+		SELECT ?, v.db_bucket_id, v.object_key, MAX(o.size), TRUE
+		FROM slices sl
+		INNER JOIN objects o ON o.id = sl.db_object_id
+		INNER JOIN object_versions v ON v.db_object_id = o.id
+		WHERE sl.db_slab_id = ? AND NOT EXISTS (
+			SELECT 1
+			FROM objects cur
+			WHERE cur.db_bucket_id = v.db_bucket_id AND cur.object_id = v.object_key
+		) AND NOT EXISTS (
+			SELECT 1
+			FROM recovered_objects ro
+			WHERE ro.db_bucket_id = v.db_bucket_id AND ro.object_key = v.object_key
 		)
+		GROUP BY v.db_bucket_id, v.object_key`)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to prepare statement to insert deleted objects: %w", err)
+	}
+	defer insertDeletedStmt.Close()
+
+	for _, root := range roots {
+		var slabID int64
+		if err := querySlabStmt.QueryRow(ctx, Hash256(root)).Scan(&slabID); errors.Is(err, dsql.ErrNoRows) {
+			continue
+		} else if err != nil {
+			return 0, 0, 0, fmt.Errorf("failed to query slab of sector %v: %w", root, err)
+		}
+		matched++
+
+		res, err := insertRecoveredStmt.Exec(ctx, time.Now(), slabID)
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("failed to insert recovered objects: %w", err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		recovered += n
+
+		res, err = insertDeletedStmt.Exec(ctx, time.Now(), slabID)
 		if err != nil {
-			return fmt.Errorf("failed to update host with scan: %w", err)
+			return 0, 0, 0, fmt.Errorf("failed to insert deleted objects: %w", err)
 		}
+		n, err = res.RowsAffected()
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		deleted += n
 	}
-	return nil
+	return
 }
 
-func RemoveOfflineHosts(ctx context.Context, tx sql.Tx, minRecentFailures uint64, maxDownTime time.Duration) (int64, error) {
-	// fetch contracts belonging to offline hosts
+func RemoveOfflineHosts(ctx context.Context, tx sql.Tx, minRecentFailures uint64, maxDownTime time.Duration) (removed int64, archived []types.FileContractID, _ error) {
+	// fetch active contracts belonging to offline hosts
 	rows, err := tx.Query(ctx, `
 SELECT fcid
 FROM contracts c
 INNER JOIN hosts h ON h.public_key = c.host_key
-WHERE h.recent_downtime >= ? AND h.recent_scan_failures >= ?`, DurationMS(maxDownTime), minRecentFailures)
+WHERE c.archival_reason IS NULL AND h.recent_downtime >= ? AND h.recent_scan_failures >= ?`, DurationMS(maxDownTime), minRecentFailures)
 	if err != nil {
-		return 0, fmt.Errorf("failed to fetch contracts: %w", err)
+		return 0, nil, fmt.Errorf("failed to fetch contracts: %w", err)
 	}
 	defer rows.Close()
 
-	var fcids []types.FileContractID
 	for rows.Next() {
 		var fcid FileContractID
 		if err := rows.Scan(&fcid); err != nil {
-			return 0, fmt.Errorf("failed to scan contract: %w", err)
+			return 0, nil, fmt.Errorf("failed to scan contract: %w", err)
 		}
-		fcids = append(fcids, types.FileContractID(fcid))
+		archived = append(archived, types.FileContractID(fcid))
+	}
+	if err := rows.Err(); err != nil {
+		return 0, nil, fmt.Errorf("failed to fetch contracts: %w", err)
 	}
+	rows.Close()
 
 	// archive those contracts
-	for _, fcid := range fcids {
+	for _, fcid := range archived {
 		if err := ArchiveContract(ctx, tx, fcid, api.ContractArchivalReasonHostPruned); err != nil {
-			return 0, fmt.Errorf("failed to archive contract %v: %w", fcid, err)
+			return 0, nil, fmt.Errorf("failed to archive contract %v: %w", fcid, err)
 		}
 	}
 
 	// delete hosts
 	res, err := tx.Exec(ctx, `DELETE FROM hosts WHERE recent_downtime >= ? AND recent_scan_failures >= ?`, DurationMS(maxDownTime), minRecentFailures)
 	if err != nil {
-		return 0, fmt.Errorf("failed to delete hosts: %w", err)
+		return 0, nil, fmt.Errorf("failed to delete hosts: %w", err)
 	}
-	return res.RowsAffected()
+	removed, err = res.RowsAffected()
+	return removed, archived, err
 }
 
 func QueryContracts(ctx context.Context, tx sql.Tx, whereExprs []string, whereArgs []any) ([]api.ContractMetadata, error) {
@@ -1733,9 +2782,10 @@ func QueryContracts(ctx context.Context, tx sql.Tx, whereExprs []string, whereAr
 	rows, err := tx.Query(ctx, fmt.Sprintf(`
 SELECT
 	c.fcid, c.host_id, c.host_key, c.v2,
-	c.archival_reason, c.proof_height, c.renewed_from, c.renewed_to, c.revision_height, c.revision_number, c.size, c.start_height, c.state, c.usability, c.window_start, c.window_end,
-	c.contract_price, c.initial_renter_funds,
-	c.delete_spending, c.fund_account_spending, c.sector_roots_spending, c.upload_spending
+	c.archival_reason, c.proof_confirmations, c.proof_height, c.renewed_from, c.renewed_to, c.revision_height, c.revision_number, c.size, c.start_height, c.state, c.usability, c.window_start, c.window_end,
+	c.contract_price, c.initial_renter_funds, COALESCE(c.storage_price, '0'), COALESCE(c.upload_price, '0'), COALESCE(c.download_price, '0'),
+	c.delete_spending, c.fund_account_spending, c.sector_roots_spending, c.upload_spending,
+	c.account_funding_fees, c.price_table_fees, c.sector_upload_fees
 FROM contracts AS c
 %s
 ORDER BY c.id ASC`, whereExpr), whereArgs...)
@@ -1791,6 +2841,8 @@ func ResetChainState(ctx context.Context, tx sql.Tx) error {
 		return err
 	} else if _, err := tx.Exec(ctx, "DELETE FROM contract_elements"); err != nil {
 		return err
+	} else if _, err := tx.Exec(ctx, "DELETE FROM contract_storage_proofs"); err != nil {
+		return err
 	}
 	return nil
 }
@@ -1819,10 +2871,10 @@ func Slab(ctx context.Context, tx sql.Tx, key object.EncryptionKey) (object.Slab
 	var slabID int64
 	slab := object.Slab{EncryptionKey: key}
 	err := tx.QueryRow(ctx, `
-		SELECT id, health, min_shards
+		SELECT id, health, min_shards, checksum_algorithm, checksums
 		FROM slabs sla
 		WHERE sla.key = ?
-	`, EncryptionKey(key)).Scan(&slabID, &slab.Health, &slab.MinShards)
+	`, EncryptionKey(key)).Scan(&slabID, &slab.Health, &slab.MinShards, &slab.ChecksumAlgorithm, (*Checksums)(&slab.Checksums))
 	if errors.Is(err, dsql.ErrNoRows) {
 		return object.Slab{}, api.ErrSlabNotFound
 	} else if err != nil {
@@ -1949,14 +3001,47 @@ func Tip(ctx context.Context, tx sql.Tx) (types.ChainIndex, error) {
 	}, nil
 }
 
-func UnhealthySlabs(ctx context.Context, tx sql.Tx, healthCutoff float64, limit int) ([]api.UnhealthySlab, error) {
+func RecordSlabFailure(ctx context.Context, tx sql.Tx, key object.EncryptionKey) error {
+	res, err := tx.Exec(ctx, "UPDATE slabs SET last_failure = ? WHERE `key` = ?", time.Now().Unix(), EncryptionKey(key))
+	if err != nil {
+		return fmt.Errorf("failed to record slab failure: %w", err)
+	} else if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	} else if n == 0 {
+		return api.ErrSlabNotFound
+	}
+	return nil
+}
+
+func UnhealthySlabs(ctx context.Context, tx sql.Tx, healthCutoff float64, maxLastFailure time.Duration, limit int) ([]api.UnhealthySlab, error) {
+	// slabs that failed to migrate within 'maxLastFailure' are skipped
+	var failedBefore int64
+	if maxLastFailure > 0 {
+		failedBefore = time.Now().Add(-maxLastFailure).Unix()
+	} else {
+		failedBefore = math.MaxInt64
+	}
+
+	// the least healthy slabs are selected, within that selection slabs are
+	// ordered by priority which is the time the most recently accessed object
+	// referencing the slab was accessed, the priority is only computed for the
+	// selected slabs
 	rows, err := tx.Query(ctx, `
-		SELECT sla.key, sla.health
-		FROM slabs sla
-		WHERE sla.health <= ? AND sla.health_valid_until > ? AND sla.db_buffered_slab_id IS NULL
-		ORDER BY sla.health ASC
-		LIMIT ?
-	`, healthCutoff, time.Now().Unix(), limit)
+		SELECT u.key, u.health, COALESCE((
+			SELECT MAX(o.last_accessed)
+			FROM slices sli
+			INNER JOIN objects o ON o.id = sli.db_object_id
+			WHERE sli.db_slab_id = u.id
+		), 0) AS priority
+		FROM (
+			SELECT sla.id, sla.key, sla.health
+			FROM slabs sla
+			WHERE sla.health <= ? AND sla.health_valid_until > ? AND sla.db_buffered_slab_id IS NULL AND sla.last_failure < ?
+			ORDER BY sla.health ASC, sla.id ASC
+			LIMIT ?
+		) u
+		ORDER BY priority DESC, u.health ASC, u.id ASC
+	`, healthCutoff, time.Now().Unix(), failedBefore, limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch unhealthy slabs: %w", err)
 	}
@@ -1965,7 +3050,7 @@ func UnhealthySlabs(ctx context.Context, tx sql.Tx, healthCutoff float64, limit
 	var slabs []api.UnhealthySlab
 	for rows.Next() {
 		var slab api.UnhealthySlab
-		if err := rows.Scan((*EncryptionKey)(&slab.EncryptionKey), &slab.Health); err != nil {
+		if err := rows.Scan((*EncryptionKey)(&slab.EncryptionKey), &slab.Health, &slab.Priority); err != nil {
 			return nil, fmt.Errorf("failed to scan unhealthy slab: %w", err)
 		}
 		slabs = append(slabs, slab)
@@ -2009,14 +3094,16 @@ func UpdateContract(ctx context.Context, tx sql.Tx, fcid types.FileContractID, c
 	_, err := tx.Exec(ctx, `
 UPDATE contracts SET
 	created_at = ?, fcid = ?,
-	proof_height = ?, renewed_from = ?, revision_height = ?, revision_number = ?, size = ?, start_height = ?, state = ?, usability = ?, window_start = ?, window_end = ?,
-	contract_price = ?, initial_renter_funds = ?,
-	delete_spending = ?, fund_account_spending = ?, sector_roots_spending = ?, upload_spending = ?
+	proof_confirmations = ?, proof_height = ?, renewed_from = ?, revision_height = ?, revision_number = ?, size = ?, start_height = ?, state = ?, usability = ?, window_start = ?, window_end = ?,
+	contract_price = ?, initial_renter_funds = ?, storage_price = ?, upload_price = ?, download_price = ?,
+	delete_spending = ?, fund_account_spending = ?, sector_roots_spending = ?, upload_spending = ?,
+	account_funding_fees = ?, price_table_fees = ?, sector_upload_fees = ?
 WHERE fcid = ?`,
 		time.Now(), FileContractID(c.ID),
-		0, FileContractID(c.RenewedFrom), 0, fmt.Sprint(c.RevisionNumber), c.Size, c.StartHeight, state, usability, c.WindowStart, c.WindowEnd,
-		Currency(c.ContractPrice), Currency(c.InitialRenterFunds),
+		0, 0, FileContractID(c.RenewedFrom), 0, fmt.Sprint(c.RevisionNumber), c.Size, c.StartHeight, state, usability, c.WindowStart, c.WindowEnd,
+		Currency(c.ContractPrice), Currency(c.InitialRenterFunds), Currency(c.StoragePrice), Currency(c.UploadPrice), Currency(c.DownloadPrice),
 		ZeroCurrency, ZeroCurrency, ZeroCurrency, ZeroCurrency,
+		ZeroCurrency, ZeroCurrency, ZeroCurrency,
 		FileContractID(c.RenewedFrom),
 	)
 	if err != nil {
@@ -2049,9 +3136,11 @@ SET enabled = ?,
 	contracts_upload = ?,
 	contracts_storage = ?,
 	contracts_prune = ?,
+	contracts_auto_size = ?,
 	hosts_max_downtime_hours = ?,
 	hosts_min_protocol_version = ?,
-	hosts_max_consecutive_scan_failures = ?
+	hosts_max_consecutive_scan_failures = ?,
+	price_renegotiation_enabled = ?
 WHERE id = ?`,
 		cfg.Enabled,
 		cfg.Contracts.Amount,
@@ -2061,13 +3150,42 @@ WHERE id = ?`,
 		cfg.Contracts.Upload,
 		cfg.Contracts.Storage,
 		cfg.Contracts.Prune,
+		cfg.Contracts.AutoSizeContractSet,
 		cfg.Hosts.MaxDowntimeHours,
 		cfg.Hosts.MinProtocolVersion,
 		cfg.Hosts.MaxConsecutiveScanFailures,
+		cfg.PriceRenegotiationEnabled,
 		sql.AutopilotID)
 	return err
 }
 
+// UpdateObjectTags replaces the tags of the object with given key.
+func UpdateObjectTags(ctx context.Context, tx sql.Tx, bucket, key string, tags api.ObjectTags) error {
+	objID, err := objectID(ctx, tx, bucket, key)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, "DELETE FROM object_tags WHERE db_object_id = ?", objID); err != nil {
+		return fmt.Errorf("failed to delete object tags: %w", err)
+	} else if len(tags) == 0 {
+		return nil
+	}
+
+	insertStmt, err := tx.Prepare(ctx, "INSERT INTO object_tags (created_at, db_object_id, `key`, value) VALUES (?, ?, ?, ?)")
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement to insert object tags: %w", err)
+	}
+	defer insertStmt.Close()
+
+	for k, v := range tags {
+		if _, err := insertStmt.Exec(ctx, time.Now(), objID, k, v); err != nil {
+			return fmt.Errorf("failed to insert object tag: %w", err)
+		}
+	}
+	return nil
+}
+
 func UpdatePeerInfo(ctx context.Context, tx sql.Tx, addr string, fn func(*syncer.PeerInfo)) error {
 	info, err := PeerInfo(ctx, tx, addr)
 	if err != nil {
@@ -2159,6 +3277,92 @@ func UpdateSlab(ctx context.Context, tx Tx, key object.EncryptionKey, updated []
 	return tx.UpsertContractSectors(ctx, upsert)
 }
 
+func AddWebhookDeadLetter(ctx context.Context, tx sql.Tx, dl webhooks.DeadLetter) error {
+	event, err := json.Marshal(dl.Event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	res, err := tx.Exec(ctx, `
+INSERT INTO webhook_dead_letters (created_at, db_webhook_id, event, failure_count, last_error, expires_at)
+SELECT ?, w.id, ?, ?, ?, ?
+FROM webhooks w
+WHERE w.module = ? AND w.event = ? AND w.url = ?`,
+		time.Now(), string(event), dl.FailureCount, dl.LastError, UnixTimeMS(dl.ExpiresAt), dl.Webhook.Module, dl.Webhook.Event, dl.Webhook.URL)
+	if err != nil {
+		return fmt.Errorf("failed to insert dead letter: %w", err)
+	} else if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	} else if n == 0 {
+		return webhooks.ErrWebhookNotFound
+	}
+	return nil
+}
+
+func PruneWebhookDeadLetters(ctx context.Context, tx sql.Tx, now time.Time) (int64, error) {
+	res, err := tx.Exec(ctx, "DELETE FROM webhook_dead_letters WHERE expires_at <= ?", UnixTimeMS(now))
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune dead letters: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+func UpdateWebhookDeadLetter(ctx context.Context, tx sql.Tx, dl webhooks.DeadLetter) error {
+	res, err := tx.Exec(ctx, "UPDATE webhook_dead_letters SET failure_count = ?, last_error = ?, expires_at = ? WHERE id = ?",
+		dl.FailureCount, dl.LastError, UnixTimeMS(dl.ExpiresAt), dl.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update dead letter: %w", err)
+	} else if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	} else if n == 0 {
+		return webhooks.ErrDeadLetterNotFound
+	}
+	return nil
+}
+
+func WebhookDeadLetter(ctx context.Context, tx sql.Tx, id int64) (webhooks.DeadLetter, error) {
+	dl, err := scanWebhookDeadLetter(tx.QueryRow(ctx, `
+SELECT dl.id, w.module, w.event, w.url, w.headers, dl.event, dl.failure_count, dl.last_error, dl.expires_at
+FROM webhook_dead_letters dl
+INNER JOIN webhooks w ON w.id = dl.db_webhook_id
+WHERE dl.id = ?`, id))
+	if errors.Is(err, dsql.ErrNoRows) {
+		return webhooks.DeadLetter{}, webhooks.ErrDeadLetterNotFound
+	} else if err != nil {
+		return webhooks.DeadLetter{}, fmt.Errorf("failed to fetch dead letter: %w", err)
+	}
+	return dl, nil
+}
+
+func WebhookDeadLetterCount(ctx context.Context, tx sql.Tx) (count uint64, err error) {
+	if err := tx.QueryRow(ctx, "SELECT COUNT(*) FROM webhook_dead_letters").Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count dead letters: %w", err)
+	}
+	return count, nil
+}
+
+func WebhookDeadLetters(ctx context.Context, tx sql.Tx) ([]webhooks.DeadLetter, error) {
+	rows, err := tx.Query(ctx, `
+SELECT dl.id, w.module, w.event, w.url, w.headers, dl.event, dl.failure_count, dl.last_error, dl.expires_at
+FROM webhook_dead_letters dl
+INNER JOIN webhooks w ON w.id = dl.db_webhook_id
+ORDER BY dl.id ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	dls := make([]webhooks.DeadLetter, 0)
+	for rows.Next() {
+		dl, err := scanWebhookDeadLetter(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan dead letter: %w", err)
+		}
+		dls = append(dls, dl)
+	}
+	return dls, nil
+}
+
 func Webhooks(ctx context.Context, tx sql.Tx) ([]webhooks.Webhook, error) {
 	rows, err := tx.Query(ctx, "SELECT module, event, url, headers FROM webhooks")
 	if err != nil {
@@ -2209,10 +3413,10 @@ func UsableHosts(ctx context.Context, tx sql.Tx) ([]HostInfo, error) {
 
 	// exclude blocked hosts
 	var hasBlocklist bool
-	if err := tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM host_blocklist_entries)").Scan(&hasBlocklist); err != nil {
+	if err := tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM host_blocklist_entries) OR EXISTS (SELECT 1 FROM host_blocklist_cidrs)").Scan(&hasBlocklist); err != nil {
 		return nil, fmt.Errorf("failed to check for blocklist: %w", err)
 	} else if hasBlocklist {
-		whereExprs = append(whereExprs, "NOT EXISTS (SELECT 1 FROM host_blocklist_entry_hosts hbeh WHERE hbeh.db_host_id = h.id)")
+		whereExprs = append(whereExprs, "NOT "+hostBlockedExpr)
 	}
 
 	// only include usable hosts
@@ -2240,6 +3444,7 @@ EXISTS (
 	h.public_key,
 	COALESCE(h.net_address, ""),
 	COALESCE(h.settings->>'$.siamuxport', "") AS siamux_port,
+	h.siamux_reachable,
 	h.price_table,
 	h.settings,
 	h.v2_settings
@@ -2259,18 +3464,22 @@ EXISTS (
 		var hostID int64
 		var hk PublicKey
 		var addr, port string
+		var siamuxReachable dsql.NullBool
 		var pt PriceTable
 		var hs HostSettings
 		var v2Hs V2HostSettings
-		err := rows.Scan(&hostID, &hk, &addr, &port, &pt, &hs, &v2Hs)
+		err := rows.Scan(&hostID, &hk, &addr, &port, &siamuxReachable, &pt, &hs, &v2Hs)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan host: %w", err)
 		}
 
-		// exclude hosts with invalid address
+		// exclude hosts with invalid address, fall back to the host's net
+		// address if its SiaMux port was found to be unreachable
 		var siamuxAddr string
 		host, _, err := net.SplitHostPort(addr)
-		if err == nil {
+		if err == nil && siamuxReachable.Valid && !siamuxReachable.Bool {
+			siamuxAddr = addr
+		} else if err == nil {
 			siamuxAddr = net.JoinHostPort(host, port)
 		}
 
@@ -2298,12 +3507,43 @@ EXISTS (
 	return hosts, nil
 }
 
-func WalletEvents(ctx context.Context, tx sql.Tx, offset, limit int) (events []wallet.Event, _ error) {
+func WalletEvents(ctx context.Context, tx sql.Tx, opts api.WalletEventsOpts) (events []wallet.Event, _ error) {
+	limit := opts.Limit
 	if limit == 0 || limit == -1 {
 		limit = math.MaxInt64
 	}
 
-	rows, err := tx.Query(ctx, "SELECT event_id, block_id, height, inflow, outflow, type, data, maturity_height, timestamp FROM wallet_events ORDER BY timestamp DESC LIMIT ? OFFSET ?", limit, offset)
+	var whereExprs []string
+	var args []any
+	if !opts.Since.IsZero() {
+		whereExprs = append(whereExprs, "timestamp >= ?")
+		args = append(args, UnixTimeMS(opts.Since))
+	}
+	if !opts.Until.IsZero() {
+		whereExprs = append(whereExprs, "timestamp < ?")
+		args = append(args, UnixTimeMS(opts.Until))
+	}
+	if !opts.MinAmount.IsZero() {
+		// amounts are stored as decimal strings without leading zeros, so
+		// comparing their lengths first gives us a numeric comparison
+		minAmount := opts.MinAmount.ExactString()
+		whereExprs = append(whereExprs, `(
+	LENGTH(inflow) > ? OR (LENGTH(inflow) = ? AND inflow >= ?) OR
+	LENGTH(outflow) > ? OR (LENGTH(outflow) = ? AND outflow >= ?)
+)`)
+		args = append(args, len(minAmount), len(minAmount), minAmount, len(minAmount), len(minAmount), minAmount)
+	}
+	if opts.Type != "" {
+		whereExprs = append(whereExprs, "type = ?")
+		args = append(args, opts.Type)
+	}
+
+	var whereExpr string
+	if len(whereExprs) > 0 {
+		whereExpr = "WHERE " + strings.Join(whereExprs, " AND ")
+	}
+
+	rows, err := tx.Query(ctx, fmt.Sprintf("SELECT event_id, block_id, height, inflow, outflow, type, data, maturity_height, timestamp FROM wallet_events %s ORDER BY timestamp DESC LIMIT ? OFFSET ?", whereExpr), append(args, limit, opts.Offset)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch wallet events: %w", err)
 	}
@@ -2358,6 +3598,20 @@ func scanMultipartUpload(s Scanner) (resp api.MultipartUpload, _ error) {
 	return
 }
 
+func scanWebhookDeadLetter(s Scanner) (dl webhooks.DeadLetter, _ error) {
+	var headers, event string
+	var expiresAt UnixTimeMS
+	if err := s.Scan(&dl.ID, &dl.Webhook.Module, &dl.Webhook.Event, &dl.Webhook.URL, &headers, &event, &dl.FailureCount, &dl.LastError, &expiresAt); err != nil {
+		return webhooks.DeadLetter{}, err
+	} else if err := json.Unmarshal([]byte(headers), &dl.Webhook.Headers); err != nil {
+		return webhooks.DeadLetter{}, fmt.Errorf("failed to unmarshal headers: %w", err)
+	} else if err := json.Unmarshal([]byte(event), &dl.Event); err != nil {
+		return webhooks.DeadLetter{}, fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+	dl.ExpiresAt = time.Time(expiresAt)
+	return dl, nil
+}
+
 func scanWalletEvent(s Scanner) (wallet.Event, error) {
 	var blockID, eventID Hash256
 	var height, maturityHeight uint64
@@ -2434,6 +3688,29 @@ func scanSiacoinStateElement(s Scanner) (se SiacoinStateElement, err error) {
 	return
 }
 
+func MergeSlabBuffers(ctx context.Context, tx Tx, srcID, dstID, offset int64) error {
+	// fetch the slabs of both buffers
+	var srcSlabID, dstSlabID int64
+	if err := tx.QueryRow(ctx, "SELECT id FROM slabs WHERE db_buffered_slab_id = ?", srcID).Scan(&srcSlabID); err != nil {
+		return fmt.Errorf("failed to fetch source slab: %w", err)
+	} else if err := tx.QueryRow(ctx, "SELECT id FROM slabs WHERE db_buffered_slab_id = ?", dstID).Scan(&dstSlabID); err != nil {
+		return fmt.Errorf("failed to fetch destination slab: %w", err)
+	}
+
+	// move the slices
+	if _, err := tx.Exec(ctx, "UPDATE slices SET db_slab_id = ?, offset = offset + ? WHERE db_slab_id = ?", dstSlabID, offset, srcSlabID); err != nil {
+		return fmt.Errorf("failed to move slices: %w", err)
+	}
+
+	// delete the source slab and buffer
+	if _, err := tx.Exec(ctx, "DELETE FROM slabs WHERE id = ?", srcSlabID); err != nil {
+		return fmt.Errorf("failed to delete source slab: %w", err)
+	} else if _, err := tx.Exec(ctx, "DELETE FROM buffered_slabs WHERE id = ?", srcID); err != nil {
+		return fmt.Errorf("failed to delete source buffer: %w", err)
+	}
+	return nil
+}
+
 func MarkPackedSlabUploaded(ctx context.Context, tx Tx, slab api.UploadedPackedSlab) (string, error) {
 	// fetch relevant slab info
 	var slabID, bufferedSlabID int64
@@ -2448,8 +3725,8 @@ func MarkPackedSlabUploaded(ctx context.Context, tx Tx, slab api.UploadedPackedS
 		return "", fmt.Errorf("failed to fetch slab id: %w", err)
 	}
 
-	// set 'db_buffered_slab_id' to NULL
-	if _, err := tx.Exec(ctx, "UPDATE slabs SET db_buffered_slab_id = NULL WHERE id = ?", slabID); err != nil {
+	// set 'db_buffered_slab_id' to NULL and store the checksums
+	if _, err := tx.Exec(ctx, "UPDATE slabs SET db_buffered_slab_id = NULL, checksum_algorithm = ?, checksums = ? WHERE id = ?", slab.ChecksumAlgorithm, Checksums(slab.Checksums), slabID); err != nil {
 		return "", fmt.Errorf("failed to update slab: %w", err)
 	}
 
@@ -2535,6 +3812,18 @@ func RecordContractSpending(ctx context.Context, tx Tx, fcid types.FileContractI
 		updateKeys = append(updateKeys, "sector_roots_spending = ?")
 		updateValues = append(updateValues, Currency(newSpending.SectorRoots))
 	}
+	if !newSpending.AccountFundingFees.IsZero() {
+		updateKeys = append(updateKeys, "account_funding_fees = ?")
+		updateValues = append(updateValues, Currency(newSpending.AccountFundingFees))
+	}
+	if !newSpending.PriceTableFees.IsZero() {
+		updateKeys = append(updateKeys, "price_table_fees = ?")
+		updateValues = append(updateValues, Currency(newSpending.PriceTableFees))
+	}
+	if !newSpending.SectorUploadFees.IsZero() {
+		updateKeys = append(updateKeys, "sector_upload_fees = ?")
+		updateValues = append(updateValues, Currency(newSpending.SectorUploadFees))
+	}
 	updateKeys = append(updateKeys, "revision_number = ?", "size = ?")
 	updateValues = append(updateValues, revisionNumber, size)
 
@@ -2551,23 +3840,115 @@ func RecordContractSpending(ctx context.Context, tx Tx, fcid types.FileContractI
 }
 
 func Object(ctx context.Context, tx Tx, bucket, key string) (api.Object, error) {
+	return fetchObject(ctx, tx, "objects", "o.object_id = ? AND b.name = ?", key, bucket)
+}
+
+// ObjectVersion returns the version of an object with the given version ID,
+// which is either its current version or one of its noncurrent versions.
+func ObjectVersion(ctx context.Context, tx Tx, bucket, key, versionID string) (api.Object, error) {
+	o, err := fetchObject(ctx, tx, "objects", "o.object_id = ? AND b.name = ? AND o.version_id = ?", key, bucket, versionID)
+	if !errors.Is(err, api.ErrObjectNotFound) {
+		return o, err
+	}
+
+	// noncurrent versions aren't referenced by their key, so we take the key
+	// from the version
+	return fetchObject(ctx, tx, `(
+		SELECT o.id, o.created_at, o.db_bucket_id, v.object_key AS object_id, o.`+"`key`"+`, o.health, o.size, o.mime_type, o.etag, o.version_id, o.last_accessed
+		FROM objects o
+		INNER JOIN object_versions v ON v.db_object_id = o.id
+	)`, "o.object_id = ? AND b.name = ? AND o.version_id = ?", key, bucket, versionID)
+}
+
+// ObjectVersions returns all versions of an object, ordered from newest to
+// oldest. The current version of an object that was created before versioning
+// was enabled on its bucket has no version ID.
+func ObjectVersions(ctx context.Context, tx sql.Tx, bucket, key string) ([]api.ObjectVersion, error) {
+	// fetch current version
+	var versions []api.ObjectVersion
+	var current api.ObjectVersion
+	err := tx.QueryRow(ctx, `
+		SELECT COALESCE(o.version_id, ''), o.etag, o.created_at, o.size
+		FROM objects o
+		INNER JOIN buckets b ON b.id = o.db_bucket_id
+		WHERE o.object_id = ? AND b.name = ?
+	`, key, bucket).Scan(&current.VersionID, &current.ETag, (*time.Time)(&current.ModTime), &current.Size)
+	if err == nil {
+		current.IsLatest = true
+		versions = append(versions, current)
+	} else if !errors.Is(err, dsql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to fetch current version: %w", err)
+	}
+
+	// fetch noncurrent versions and delete markers
+	rows, err := tx.Query(ctx, `
+		SELECT v.version_id, v.created_at, o.created_at, COALESCE(o.etag, ''), COALESCE(o.size, 0)
+		FROM object_versions v
+		INNER JOIN buckets b ON b.id = v.db_bucket_id
+		LEFT JOIN objects o ON o.id = v.db_object_id
+		WHERE v.object_key = ? AND b.name = ?
+		ORDER BY v.id DESC
+	`, key, bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch object versions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var v api.ObjectVersion
+		var createdAt time.Time
+		var objCreatedAt dsql.NullTime
+		if err := rows.Scan(&v.VersionID, &createdAt, &objCreatedAt, &v.ETag, &v.Size); err != nil {
+			return nil, fmt.Errorf("failed to scan object version: %w", err)
+		}
+		if objCreatedAt.Valid {
+			v.ModTime = api.TimeRFC3339(objCreatedAt.Time)
+		} else {
+			v.IsDeleteMarker = true
+			v.ModTime = api.TimeRFC3339(createdAt)
+		}
+		v.IsLatest = len(versions) == 0
+		versions = append(versions, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	} else if len(versions) == 0 {
+		return nil, api.ErrObjectNotFound
+	}
+	return versions, nil
+}
+
+// fetchObject fetches the object matching the given where expression from the
+// given table expression, the latter is aliased as 'o'.
+func fetchObject(ctx context.Context, tx Tx, objectsExpr, whereExpr string, args ...any) (api.Object, error) {
 	/// fetch object metadata
 	row := tx.QueryRow(ctx, fmt.Sprintf(`
-		SELECT %s, o.id, o.key
-		FROM objects o
+		SELECT %s, o.id, o.key, o.version_id, o.last_accessed
+		FROM %s o
 		INNER JOIN buckets b ON o.db_bucket_id = b.id
-		WHERE o.object_id = ? AND b.name = ?
+		WHERE %s
 	`,
-		tx.SelectObjectMetadataExpr()), key, bucket)
+		tx.SelectObjectMetadataExpr(), objectsExpr, whereExpr), args...)
 	var objID int64
 	var ec object.EncryptionKey
-	om, err := tx.ScanObjectMetadata(row, &objID, (*EncryptionKey)(&ec))
+	var versionID NullableString
+	var lastAccessed int64
+	om, err := tx.ScanObjectMetadata(row, &objID, (*EncryptionKey)(&ec), &versionID, &lastAccessed)
 	if errors.Is(err, dsql.ErrNoRows) {
 		return api.Object{}, api.ErrObjectNotFound
 	} else if err != nil {
 		return api.Object{}, err
 	}
 
+	// update the time the object was last accessed, it's used to prioritize
+	// the repair of its slabs so it's only updated once per interval to avoid
+	// turning every read into a write
+	if now := time.Now(); now.Sub(time.Unix(lastAccessed, 0)) >= objectLastAccessedInterval {
+		if _, err := tx.Exec(ctx, "UPDATE objects SET last_accessed = ? WHERE id = ?", now.Unix(), objID); err != nil {
+			return api.Object{}, fmt.Errorf("failed to update last accessed time: %w", err)
+		}
+	}
+
 	// fetch user metadata
 	rows, err := tx.Query(ctx, `
 		SELECT oum.key, oum.value
@@ -2620,6 +4001,7 @@ func Object(ctx context.Context, tx Tx, bucket, key string) (api.Object, error)
 
 	return api.Object{
 		Metadata:       oum,
+		VersionID:      string(versionID),
 		ObjectMetadata: om,
 		Object: &object.Object{
 			Key:   ec,
@@ -2685,7 +4067,8 @@ func listObjectsNoDelim(ctx context.Context, tx Tx, bucket, prefix, substring, s
 		sortDir = api.SortDirAsc
 	}
 
-	var whereExprs []string
+	// exclude noncurrent versions
+	whereExprs := []string{"o.object_id IS NOT NULL"}
 	var whereArgs []any
 
 	// apply bucket
@@ -2999,3 +4382,83 @@ func listObjectsSlashDelim(ctx context.Context, tx Tx, bucket, prefix, sortBy, s
 		Objects:    objects,
 	}, nil
 }
+
+func objectID(ctx context.Context, tx sql.Tx, bucket, key string) (int64, error) {
+	var objID int64
+	if err := tx.QueryRow(ctx, `
+		SELECT o.id
+		FROM objects o
+		INNER JOIN buckets b ON b.id = o.db_bucket_id
+		WHERE o.object_id = ? AND b.name = ?
+	`, key, bucket).Scan(&objID); errors.Is(err, dsql.ErrNoRows) {
+		return 0, api.ErrObjectNotFound
+	} else if err != nil {
+		return 0, fmt.Errorf("failed to fetch object id: %w", err)
+	}
+	return objID, nil
+}
+
+// GlobToLike converts a glob pattern, where '*' matches any sequence of
+// characters and '?' matches a single character, into a LIKE pattern that uses
+// '\' as the escape character.
+func GlobToLike(glob string) string {
+	var sb strings.Builder
+	for _, r := range glob {
+		switch r {
+		case '\\', '%', '_':
+			sb.WriteRune('\\')
+			sb.WriteRune(r)
+		case '*':
+			sb.WriteRune('%')
+		case '?':
+			sb.WriteRune('_')
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// newVersionID returns a random version ID for an object.
+func newVersionID() string {
+	return hex.EncodeToString(frand.Bytes(16))
+}
+
+// objectKeysWithPrefix returns the keys of a batch of objects starting with the
+// given prefix that match the optional where expression.
+func objectKeysWithPrefix(ctx context.Context, tx sql.Tx, bucket, prefix, whereExpr string, whereArgs []any, limit int64) ([]string, error) {
+	if whereExpr != "" {
+		whereExpr = "AND " + whereExpr
+	}
+	args := []any{prefix + "%", utf8.RuneCountInString(prefix), prefix}
+	args = append(args, whereArgs...)
+	args = append(args, bucket, limit)
+
+	return QueryObjectKeys(ctx, tx, fmt.Sprintf(`
+		SELECT o.object_id
+		FROM objects o
+		INNER JOIN buckets b ON b.id = o.db_bucket_id
+		WHERE o.object_id LIKE ? AND SUBSTR(o.object_id, 1, ?) = ? %s AND b.name = ?
+		LIMIT ?
+	`, whereExpr), args...)
+}
+
+// QueryObjectKeys runs the given query, which selects a single object_id
+// column, and returns the object keys.
+func QueryObjectKeys(ctx context.Context, tx sql.Tx, query string, args ...any) ([]string, error) {
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch object keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("failed to scan object key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
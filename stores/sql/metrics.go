@@ -2,6 +2,7 @@ package sql
 
 import (
 	"context"
+	dsql "database/sql"
 	"errors"
 	"fmt"
 	"math"
@@ -99,6 +100,167 @@ func ContractPruneMetrics(ctx context.Context, tx sql.Tx, start time.Time, n uin
 	})
 }
 
+// LatestContractMetrics returns the most recent contract metric of every
+// contract that was recorded before the given time.
+func LatestContractMetrics(ctx context.Context, tx sql.Tx, before time.Time) ([]api.ContractMetric, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT c.fcid, c.host, c.timestamp, c.revision_number, c.remaining_collateral_lo, c.remaining_collateral_hi, c.remaining_funds_lo, c.remaining_funds_hi, c.upload_spending_lo, c.upload_spending_hi, c.fund_account_spending_lo, c.fund_account_spending_hi, c.delete_spending_lo, c.delete_spending_hi, c.sector_roots_spending_lo, c.sector_roots_spending_hi
+		FROM contracts c
+		INNER JOIN (SELECT MAX(id) AS id FROM contracts WHERE timestamp < ? GROUP BY fcid) latest ON c.id = latest.id
+	`, UnixTimeMS(before))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch contract metrics: %w", err)
+	}
+	defer rows.Close()
+
+	var metrics []api.ContractMetric
+	for rows.Next() {
+		var m api.ContractMetric
+		var timestamp UnixTimeMS
+		var revisionNumber Unsigned64
+		if err := rows.Scan(
+			(*FileContractID)(&m.ContractID),
+			(*PublicKey)(&m.HostKey),
+			&timestamp,
+			&revisionNumber,
+			(*Unsigned64)(&m.RemainingCollateral.Lo), (*Unsigned64)(&m.RemainingCollateral.Hi),
+			(*Unsigned64)(&m.RemainingFunds.Lo), (*Unsigned64)(&m.RemainingFunds.Hi),
+			(*Unsigned64)(&m.UploadSpending.Lo), (*Unsigned64)(&m.UploadSpending.Hi),
+			(*Unsigned64)(&m.FundAccountSpending.Lo), (*Unsigned64)(&m.FundAccountSpending.Hi),
+			(*Unsigned64)(&m.DeleteSpending.Lo), (*Unsigned64)(&m.DeleteSpending.Hi),
+			(*Unsigned64)(&m.SectorRootsSpending.Lo), (*Unsigned64)(&m.SectorRootsSpending.Hi),
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan contract metric: %w", err)
+		}
+		m.Timestamp = api.TimeRFC3339(timestamp)
+		m.RevisionNumber = uint64(revisionNumber)
+		metrics = append(metrics, m)
+	}
+	return metrics, rows.Err()
+}
+
+// MetricsSummary sums up the most recent contract and contract prune metric of
+// the given contracts and fetches the most recent wallet metric. Metrics of
+// contracts that aren't in the list, e.g. expired or archived contracts, are
+// ignored.
+func MetricsSummary(ctx context.Context, tx sql.Tx, fcids []types.FileContractID) (summary api.MetricsSummary, err error) {
+	include := make(map[FileContractID]struct{}, len(fcids))
+	for _, fcid := range fcids {
+		include[FileContractID(fcid)] = struct{}{}
+	}
+
+	// sum up the most recent contract metric of every contract
+	rows, err := tx.Query(ctx, `
+		SELECT c.fcid, c.timestamp, c.remaining_collateral_lo, c.remaining_collateral_hi, c.remaining_funds_lo, c.remaining_funds_hi, c.upload_spending_lo, c.upload_spending_hi, c.fund_account_spending_lo, c.fund_account_spending_hi, c.delete_spending_lo, c.delete_spending_hi, c.sector_roots_spending_lo, c.sector_roots_spending_hi
+		FROM contracts c
+		INNER JOIN (SELECT fcid, MAX(timestamp) AS timestamp FROM contracts GROUP BY fcid) latest ON c.fcid = latest.fcid AND c.timestamp = latest.timestamp
+	`)
+	if err != nil {
+		return api.MetricsSummary{}, fmt.Errorf("failed to fetch contract metrics: %w", err)
+	}
+	defer rows.Close()
+
+	seen := make(map[FileContractID]struct{})
+	for rows.Next() {
+		var fcid FileContractID
+		var timestamp UnixTimeMS
+		var m api.ContractMetric
+		if err := rows.Scan(
+			&fcid,
+			&timestamp,
+			(*Unsigned64)(&m.RemainingCollateral.Lo), (*Unsigned64)(&m.RemainingCollateral.Hi),
+			(*Unsigned64)(&m.RemainingFunds.Lo), (*Unsigned64)(&m.RemainingFunds.Hi),
+			(*Unsigned64)(&m.UploadSpending.Lo), (*Unsigned64)(&m.UploadSpending.Hi),
+			(*Unsigned64)(&m.FundAccountSpending.Lo), (*Unsigned64)(&m.FundAccountSpending.Hi),
+			(*Unsigned64)(&m.DeleteSpending.Lo), (*Unsigned64)(&m.DeleteSpending.Hi),
+			(*Unsigned64)(&m.SectorRootsSpending.Lo), (*Unsigned64)(&m.SectorRootsSpending.Hi),
+		); err != nil {
+			return api.MetricsSummary{}, fmt.Errorf("failed to scan contract metric: %w", err)
+		} else if _, ok := include[fcid]; !ok {
+			continue
+		} else if _, ok := seen[fcid]; ok {
+			continue
+		}
+		seen[fcid] = struct{}{}
+
+		cs := &summary.Contract
+		cs.Contracts++
+		if time.Time(timestamp).After(cs.Timestamp.Std()) {
+			cs.Timestamp = api.TimeRFC3339(timestamp)
+		}
+		cs.RemainingCollateral, _ = cs.RemainingCollateral.AddWithOverflow(m.RemainingCollateral)
+		cs.RemainingFunds, _ = cs.RemainingFunds.AddWithOverflow(m.RemainingFunds)
+		cs.UploadSpending, _ = cs.UploadSpending.AddWithOverflow(m.UploadSpending)
+		cs.FundAccountSpending, _ = cs.FundAccountSpending.AddWithOverflow(m.FundAccountSpending)
+		cs.DeleteSpending, _ = cs.DeleteSpending.AddWithOverflow(m.DeleteSpending)
+		cs.SectorRootsSpending, _ = cs.SectorRootsSpending.AddWithOverflow(m.SectorRootsSpending)
+	}
+	if err := rows.Err(); err != nil {
+		return api.MetricsSummary{}, err
+	}
+	rows.Close()
+
+	// sum up the most recent prune metric of every contract
+	rows, err = tx.Query(ctx, `
+		SELECT cp.fcid, cp.timestamp, cp.pruned, cp.remaining
+		FROM contract_prunes cp
+		INNER JOIN (SELECT fcid, MAX(timestamp) AS timestamp FROM contract_prunes GROUP BY fcid) latest ON cp.fcid = latest.fcid AND cp.timestamp = latest.timestamp
+	`)
+	if err != nil {
+		return api.MetricsSummary{}, fmt.Errorf("failed to fetch contract prune metrics: %w", err)
+	}
+	defer rows.Close()
+
+	seen = make(map[FileContractID]struct{})
+	for rows.Next() {
+		var fcid FileContractID
+		var timestamp UnixTimeMS
+		var pruned, remaining Unsigned64
+		if err := rows.Scan(&fcid, &timestamp, &pruned, &remaining); err != nil {
+			return api.MetricsSummary{}, fmt.Errorf("failed to scan contract prune metric: %w", err)
+		} else if _, ok := include[fcid]; !ok {
+			continue
+		} else if _, ok := seen[fcid]; ok {
+			continue
+		}
+		seen[fcid] = struct{}{}
+
+		ps := &summary.ContractPrune
+		ps.Contracts++
+		if time.Time(timestamp).After(ps.Timestamp.Std()) {
+			ps.Timestamp = api.TimeRFC3339(timestamp)
+		}
+		ps.Pruned += uint64(pruned)
+		ps.Remaining += uint64(remaining)
+	}
+	if err := rows.Err(); err != nil {
+		return api.MetricsSummary{}, err
+	}
+
+	// fetch the most recent wallet metric
+	var wm api.WalletMetric
+	var timestamp UnixTimeMS
+	err = tx.QueryRow(ctx, `
+		SELECT timestamp, confirmed_lo, confirmed_hi, spendable_lo, spendable_hi, unconfirmed_lo, unconfirmed_hi, immature_lo, immature_hi
+		FROM wallets
+		ORDER BY timestamp DESC
+		LIMIT 1
+	`).Scan(
+		&timestamp,
+		(*Unsigned64)(&wm.Confirmed.Lo), (*Unsigned64)(&wm.Confirmed.Hi),
+		(*Unsigned64)(&wm.Spendable.Lo), (*Unsigned64)(&wm.Spendable.Hi),
+		(*Unsigned64)(&wm.Unconfirmed.Lo), (*Unsigned64)(&wm.Unconfirmed.Hi),
+		(*Unsigned64)(&wm.Immature.Lo), (*Unsigned64)(&wm.Immature.Hi),
+	)
+	if err != nil && !errors.Is(err, dsql.ErrNoRows) {
+		return api.MetricsSummary{}, fmt.Errorf("failed to fetch wallet metric: %w", err)
+	} else if err == nil {
+		wm.Timestamp = api.TimeRFC3339(timestamp)
+		summary.Wallet = &wm
+	}
+	return summary, nil
+}
+
 func PruneMetrics(ctx context.Context, tx sql.Tx, metric string, cutoff time.Time) error {
 	if metric == "" {
 		return errors.New("metric must be set")
@@ -114,6 +276,8 @@ func PruneMetrics(ctx context.Context, tx sql.Tx, metric string, cutoff time.Tim
 		table = "contracts"
 	case api.MetricPerformance:
 		table = "performance"
+	case api.MetricSlabDownload:
+		table = "slab_downloads"
 	case api.MetricWallet:
 		table = "wallets"
 	default:
@@ -186,23 +350,91 @@ func RecordContractPruneMetric(ctx context.Context, tx sql.Tx, metrics ...api.Co
 	}
 	defer insertStmt.Close()
 
+	selectStmt, err := tx.Prepare(ctx, "SELECT id, pruned, duration FROM contract_prunes WHERE fcid = ? AND timestamp >= ? AND timestamp < ? ORDER BY timestamp DESC LIMIT 1")
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement to fetch contract prune metric: %w", err)
+	}
+	defer selectStmt.Close()
+
+	updateStmt, err := tx.Prepare(ctx, "UPDATE contract_prunes SET timestamp = ?, host_version = ?, pruned = ?, remaining = ?, duration = ? WHERE id = ?")
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement to update contract prune metric: %w", err)
+	}
+	defer updateStmt.Close()
+
 	for _, metric := range metrics {
-		res, err := insertStmt.Exec(ctx,
-			time.Now().UTC(),
-			UnixTimeMS(metric.Timestamp),
+		// accumulate the metric into an existing metric for the same contract
+		// that was recorded within the same 5' window, pruning a contract
+		// happens in batches so this avoids a row per batch
+		intervalStart := metric.Timestamp.Std().Truncate(contractMetricGranularity)
+		intervalEnd := intervalStart.Add(contractMetricGranularity)
+
+		var id int64
+		var pruned Unsigned64
+		var duration DurationMS
+		err := selectStmt.QueryRow(ctx,
 			FileContractID(metric.ContractID),
-			PublicKey(metric.HostKey),
+			UnixTimeMS(intervalStart),
+			UnixTimeMS(intervalEnd),
+		).Scan(&id, &pruned, &duration)
+		if err != nil && !errors.Is(err, dsql.ErrNoRows) {
+			return fmt.Errorf("failed to fetch contract prune metric: %w", err)
+		}
+
+		if errors.Is(err, dsql.ErrNoRows) {
+			res, err := insertStmt.Exec(ctx,
+				time.Now().UTC(),
+				UnixTimeMS(metric.Timestamp),
+				FileContractID(metric.ContractID),
+				PublicKey(metric.HostKey),
+				metric.HostVersion,
+				Unsigned64(metric.Pruned),
+				Unsigned64(metric.Remaining),
+				(DurationMS)(metric.Duration),
+			)
+			if err != nil {
+				return fmt.Errorf("failed to insert contract prune metric: %w", err)
+			} else if n, err := res.RowsAffected(); err != nil {
+				return fmt.Errorf("failed to get rows affected: %w", err)
+			} else if n == 0 {
+				return fmt.Errorf("failed to insert contract prune metric: no rows affected")
+			}
+		} else if _, err := updateStmt.Exec(ctx,
+			UnixTimeMS(metric.Timestamp),
 			metric.HostVersion,
-			Unsigned64(metric.Pruned),
+			pruned+Unsigned64(metric.Pruned),
 			Unsigned64(metric.Remaining),
-			(DurationMS)(metric.Duration),
+			duration+DurationMS(metric.Duration),
+			id,
+		); err != nil {
+			return fmt.Errorf("failed to update contract prune metric: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func RecordSlabDownloadMetric(ctx context.Context, tx sql.Tx, metrics ...api.SlabDownloadMetric) error {
+	insertStmt, err := tx.Prepare(ctx, "INSERT INTO slab_downloads (created_at, timestamp, slab_key_hash, duration, success) VALUES (?, ?, ?, ?, ?)")
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement to insert slab download metric: %w", err)
+	}
+	defer insertStmt.Close()
+
+	for _, metric := range metrics {
+		res, err := insertStmt.Exec(ctx,
+			time.Now().UTC(),
+			UnixTimeMS(metric.Timestamp),
+			Hash256(metric.SlabKeyHash),
+			DurationMS(metric.Duration),
+			metric.Success,
 		)
 		if err != nil {
-			return fmt.Errorf("failed to insert contract prune metric: %w", err)
+			return fmt.Errorf("failed to insert slab download metric: %w", err)
 		} else if n, err := res.RowsAffected(); err != nil {
 			return fmt.Errorf("failed to get rows affected: %w", err)
 		} else if n == 0 {
-			return fmt.Errorf("failed to insert contract prune metric: no rows affected")
+			return fmt.Errorf("failed to insert slab download metric: no rows affected")
 		}
 	}
 
@@ -210,7 +442,7 @@ func RecordContractPruneMetric(ctx context.Context, tx sql.Tx, metrics ...api.Co
 }
 
 func RecordWalletMetric(ctx context.Context, tx sql.Tx, metrics ...api.WalletMetric) error {
-	insertStmt, err := tx.Prepare(ctx, "INSERT INTO wallets (created_at, timestamp, confirmed_lo, confirmed_hi, spendable_lo, spendable_hi, unconfirmed_lo, unconfirmed_hi, immature_hi, immature_lo) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	insertStmt, err := tx.Prepare(ctx, "INSERT INTO wallets (created_at, timestamp, confirmed_lo, confirmed_hi, spendable_lo, spendable_hi, unconfirmed_lo, unconfirmed_hi, immature_lo, immature_hi) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
 	if err != nil {
 		return fmt.Errorf("failed to prepare statement to insert wallet metric: %w", err)
 	}
@@ -241,6 +473,46 @@ func RecordWalletMetric(ctx context.Context, tx sql.Tx, metrics ...api.WalletMet
 	return nil
 }
 
+// SlabDownloadMetrics returns every slab download recorded within the n
+// intervals following start. Unlike other metrics, slab downloads aren't
+// sampled per interval since computing per-slab latencies and success rates
+// requires all of them.
+func SlabDownloadMetrics(ctx context.Context, tx sql.Tx, start time.Time, n uint64, interval time.Duration, opts api.SlabDownloadMetricsQueryOpts) ([]api.SlabDownloadMetric, error) {
+	if n > api.MetricMaxIntervals {
+		return nil, api.ErrMaxIntervalsExceeded
+	}
+
+	whereExpr := "timestamp >= ? AND timestamp < ?"
+	args := []any{UnixTimeMS(start), UnixTimeMS(start.Add(time.Duration(n) * interval))}
+	if opts.SlabKeyHash != (types.Hash256{}) {
+		whereExpr += " AND slab_key_hash = ?"
+		args = append(args, Hash256(opts.SlabKeyHash))
+	}
+
+	rows, err := tx.Query(ctx, fmt.Sprintf(`
+		SELECT timestamp, slab_key_hash, duration, success
+		FROM slab_downloads
+		WHERE %s
+		ORDER BY timestamp ASC, id ASC
+	`, whereExpr), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch slab download metrics: %w", err)
+	}
+	defer rows.Close()
+
+	var metrics []api.SlabDownloadMetric
+	for rows.Next() {
+		var m api.SlabDownloadMetric
+		var timestamp UnixTimeMS
+		if err := rows.Scan(&timestamp, (*Hash256)(&m.SlabKeyHash), (*DurationMS)(&m.Duration), &m.Success); err != nil {
+			return nil, fmt.Errorf("failed to scan slab download metric: %w", err)
+		}
+		m.Timestamp = api.TimeRFC3339(timestamp)
+		metrics = append(metrics, m)
+	}
+	return metrics, rows.Err()
+}
+
 func WalletMetrics(ctx context.Context, tx sql.Tx, start time.Time, n uint64, interval time.Duration, opts api.WalletMetricsQueryOpts) ([]api.WalletMetric, error) {
 	return queryPeriods(ctx, tx, start, n, interval, opts, func(rows *sql.LoggedRows) (m api.WalletMetric, err error) {
 		var placeHolder int64
@@ -200,6 +200,10 @@ func (tx *MainDatabaseTx) AddWebhook(ctx context.Context, wh webhooks.Webhook) e
 	return nil
 }
 
+func (tx *MainDatabaseTx) AddWebhookDeadLetter(ctx context.Context, dl webhooks.DeadLetter) error {
+	return ssql.AddWebhookDeadLetter(ctx, tx, dl)
+}
+
 func (tx *MainDatabaseTx) AncestorContracts(ctx context.Context, fcid types.FileContractID, startHeight uint64) ([]api.ContractMetadata, error) {
 	return ssql.AncestorContracts(ctx, tx, fcid, startHeight)
 }
@@ -208,6 +212,10 @@ func (tx *MainDatabaseTx) ArchiveContract(ctx context.Context, fcid types.FileCo
 	return ssql.ArchiveContract(ctx, tx, fcid, reason)
 }
 
+func (tx *MainDatabaseTx) ArchiveObject(ctx context.Context, bucket, key string) (bool, error) {
+	return ssql.ArchiveObject(ctx, tx, bucket, key)
+}
+
 func (tx *MainDatabaseTx) AutopilotConfig(ctx context.Context) (api.AutopilotConfig, error) {
 	return ssql.AutopilotConfig(ctx, tx)
 }
@@ -309,6 +317,10 @@ func (tx *MainDatabaseTx) Contracts(ctx context.Context, opts api.ContractsOpts)
 	return ssql.Contracts(ctx, tx, opts)
 }
 
+func (tx *MainDatabaseTx) ContractsExpiring(ctx context.Context, withinBlocks uint64) ([]api.ContractMetadata, error) {
+	return ssql.ContractsExpiring(ctx, tx, withinBlocks)
+}
+
 func (tx *MainDatabaseTx) ContractSize(ctx context.Context, id types.FileContractID) (api.ContractSize, error) {
 	return ssql.ContractSize(ctx, tx, id)
 }
@@ -338,10 +350,18 @@ func (tx *MainDatabaseTx) CreateBucket(ctx context.Context, bucket string, bp ap
 	return nil
 }
 
+func (tx *MainDatabaseTx) DeleteHost(ctx context.Context, hk types.PublicKey) error {
+	return ssql.DeleteHost(ctx, tx, hk)
+}
+
 func (tx *MainDatabaseTx) DeleteHostSector(ctx context.Context, hk types.PublicKey, root types.Hash256) (int, error) {
 	return ssql.DeleteHostSector(ctx, tx, hk, root)
 }
 
+func (tx *MainDatabaseTx) DeleteObjectVersion(ctx context.Context, bucket, key, versionID string) (bool, error) {
+	return ssql.DeleteObjectVersion(ctx, tx, bucket, key, versionID)
+}
+
 func (tx *MainDatabaseTx) DeleteSetting(ctx context.Context, key string) error {
 	return ssql.DeleteSetting(ctx, tx, key)
 }
@@ -350,10 +370,26 @@ func (tx *MainDatabaseTx) DeleteWebhook(ctx context.Context, wh webhooks.Webhook
 	return ssql.DeleteWebhook(ctx, tx, wh)
 }
 
+func (tx *MainDatabaseTx) DeleteWebhookDeadLetter(ctx context.Context, id int64) error {
+	return ssql.DeleteWebhookDeadLetter(ctx, tx, id)
+}
+
+func (tx *MainDatabaseTx) ExpireMultipartUploads(ctx context.Context, olderThan time.Duration) (int, error) {
+	return ssql.ExpireMultipartUploads(ctx, tx, olderThan)
+}
+
+func (tx *MainDatabaseTx) ExpiredObjects(ctx context.Context, bucket, prefix string, createdBefore time.Time, limit int64) ([]string, error) {
+	return ssql.ExpiredObjects(ctx, tx, bucket, prefix, createdBefore, limit)
+}
+
 func (tx *MainDatabaseTx) FileContractElement(ctx context.Context, fcid types.FileContractID) (types.V2FileContractElement, error) {
 	return ssql.FileContractElement(ctx, tx, fcid)
 }
 
+func (tx *MainDatabaseTx) FindAlternativeContracts(ctx context.Context, root types.Hash256) ([]types.FileContractID, error) {
+	return ssql.FindAlternativeContracts(ctx, tx, root)
+}
+
 func (tx *MainDatabaseTx) DeleteBucket(ctx context.Context, bucket string) error {
 	return ssql.DeleteBucket(ctx, tx, bucket)
 }
@@ -401,6 +437,44 @@ func (tx *MainDatabaseTx) DeleteObjects(ctx context.Context, bucket string, key
 	}
 }
 
+func (tx *MainDatabaseTx) DeleteExpiredObjects(ctx context.Context, bucket, prefix string, createdBefore time.Time, limit int64) (int64, error) {
+	resp, err := tx.Exec(ctx, `
+	DELETE o
+	FROM objects o
+	JOIN (
+		SELECT id
+		FROM objects
+		WHERE object_id LIKE ? AND created_at < ? AND db_bucket_id = (
+		    SELECT id FROM buckets WHERE buckets.name = ?
+		)
+		LIMIT ?
+	) AS limited ON o.id = limited.id`,
+		prefix+"%", createdBefore, bucket, limit)
+	if err != nil {
+		return 0, err
+	}
+	return resp.RowsAffected()
+}
+
+func (tx *MainDatabaseTx) DeleteObjectsGlob(ctx context.Context, bucket string, glob string, limit int64) (int64, error) {
+	resp, err := tx.Exec(ctx, `
+	DELETE o
+	FROM objects o
+	JOIN (
+		SELECT id
+		FROM objects
+		WHERE object_id LIKE ? AND db_bucket_id = (
+		    SELECT id FROM buckets WHERE buckets.name = ?
+		)
+		LIMIT ?
+	) AS limited ON o.id = limited.id`,
+		ssql.GlobToLike(glob), bucket, limit)
+	if err != nil {
+		return 0, err
+	}
+	return resp.RowsAffected()
+}
+
 func (tx *MainDatabaseTx) HostAllowlist(ctx context.Context) ([]types.PublicKey, error) {
 	return ssql.HostAllowlist(ctx, tx)
 }
@@ -409,6 +483,18 @@ func (tx *MainDatabaseTx) HostBlocklist(ctx context.Context) ([]string, error) {
 	return ssql.HostBlocklist(ctx, tx)
 }
 
+func (tx *MainDatabaseTx) HostBenchmarks(ctx context.Context, hk types.PublicKey, limit int) ([]api.HostBenchmark, error) {
+	return ssql.HostBenchmarks(ctx, tx, hk, limit)
+}
+
+func (tx *MainDatabaseTx) HostScanHistory(ctx context.Context, hk types.PublicKey, limit int) ([]api.HostScan, error) {
+	return ssql.HostScanHistory(ctx, tx, hk, limit)
+}
+
+func (tx *MainDatabaseTx) HostPriceHistory(ctx context.Context, since time.Time) (map[types.PublicKey][]api.HostPriceChange, error) {
+	return ssql.HostPriceHistory(ctx, tx, since)
+}
+
 func (tx *MainDatabaseTx) Hosts(ctx context.Context, opts api.HostOptions) ([]api.Host, error) {
 	return ssql.Hosts(ctx, tx, opts)
 }
@@ -449,11 +535,15 @@ func (tx *MainDatabaseTx) InsertBufferedSlab(ctx context.Context, fileName strin
 	return ssql.InsertBufferedSlab(ctx, tx, fileName, ec, minShards, totalShards)
 }
 
-func (tx *MainDatabaseTx) InsertMultipartUpload(ctx context.Context, bucket, key string, ec object.EncryptionKey, mimeType string, metadata api.ObjectUserMetadata) (string, error) {
-	return ssql.InsertMultipartUpload(ctx, tx, bucket, key, ec, mimeType, metadata)
+func (tx *MainDatabaseTx) InsertDeleteMarker(ctx context.Context, bucket, key string) (string, error) {
+	return ssql.InsertDeleteMarker(ctx, tx, bucket, key)
+}
+
+func (tx *MainDatabaseTx) InsertMultipartUpload(ctx context.Context, bucket, key string, ec object.EncryptionKey, mimeType string, metadata api.ObjectUserMetadata, ttl time.Duration) (string, error) {
+	return ssql.InsertMultipartUpload(ctx, tx, bucket, key, ec, mimeType, metadata, ttl)
 }
 
-func (tx *MainDatabaseTx) InsertObject(ctx context.Context, bucket, key string, o object.Object, mimeType, eTag string, md api.ObjectUserMetadata) error {
+func (tx *MainDatabaseTx) InsertObject(ctx context.Context, bucket, key string, o object.Object, mimeType, eTag string, md api.ObjectUserMetadata, ifNotExists bool) error {
 	// get bucket id
 	var bucketID int64
 	err := tx.QueryRow(ctx, "SELECT id FROM buckets WHERE buckets.name = ?", bucket).Scan(&bucketID)
@@ -464,7 +554,16 @@ func (tx *MainDatabaseTx) InsertObject(ctx context.Context, bucket, key string,
 	}
 
 	// insert object
-	objID, err := ssql.InsertObject(ctx, tx, key, bucketID, o.TotalSize(), o.Key, mimeType, eTag)
+	var objID int64
+	if ifNotExists {
+		objID, err = ssql.InsertObjectIfNotExists(ctx, tx, key, bucketID, o.TotalSize(), o.Key, mimeType, eTag)
+		if err != nil && strings.Contains(err.Error(), "Duplicate entry") {
+			// a concurrent upload inserted the object first
+			err = api.ErrObjectExists
+		}
+	} else {
+		objID, err = ssql.InsertObject(ctx, tx, key, bucketID, o.TotalSize(), o.Key, mimeType, eTag)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to insert object: %w", err)
 	}
@@ -612,6 +711,10 @@ func (tx *MainDatabaseTx) MarkPackedSlabUploaded(ctx context.Context, slab api.U
 	return ssql.MarkPackedSlabUploaded(ctx, tx, slab)
 }
 
+func (tx *MainDatabaseTx) MergeSlabBuffers(ctx context.Context, srcID, dstID, offset int64) error {
+	return ssql.MergeSlabBuffers(ctx, tx, srcID, dstID, offset)
+}
+
 func (tx *MainDatabaseTx) MultipartUpload(ctx context.Context, uploadID string) (api.MultipartUpload, error) {
 	return ssql.MultipartUpload(ctx, tx, uploadID)
 }
@@ -624,6 +727,10 @@ func (tx *MainDatabaseTx) MultipartUploads(ctx context.Context, bucket, prefix,
 	return ssql.MultipartUploads(ctx, tx, bucket, prefix, keyMarker, uploadIDMarker, limit)
 }
 
+func (tx *MainDatabaseTx) NewObjectVersion(ctx context.Context, bucket, key string) (string, error) {
+	return ssql.NewObjectVersion(ctx, tx, bucket, key)
+}
+
 func (tx *MainDatabaseTx) Object(ctx context.Context, bucket, key string) (api.Object, error) {
 	return ssql.Object(ctx, tx, bucket, key)
 }
@@ -636,10 +743,48 @@ func (tx *MainDatabaseTx) ObjectMetadata(ctx context.Context, bucket, key string
 	return ssql.ObjectMetadata(ctx, tx, bucket, key)
 }
 
+func (tx *MainDatabaseTx) ObjectKeys(ctx context.Context, bucket, prefix string, limit int64) ([]string, error) {
+	return ssql.ObjectKeys(ctx, tx, bucket, prefix, limit)
+}
+
+func (tx *MainDatabaseTx) ObjectKeysGlob(ctx context.Context, bucket, glob string, limit int64) ([]string, error) {
+	return ssql.QueryObjectKeys(ctx, tx, `
+		SELECT o.object_id
+		FROM objects o
+		INNER JOIN buckets b ON b.id = o.db_bucket_id
+		WHERE o.object_id LIKE ? AND b.name = ?
+		LIMIT ?
+	`, ssql.GlobToLike(glob), bucket, limit)
+}
+
+func (tx *MainDatabaseTx) ObjectTags(ctx context.Context, bucket, key string) (api.ObjectTags, error) {
+	return ssql.ObjectTags(ctx, tx, bucket, key)
+}
+
+func (tx *MainDatabaseTx) ObjectVersion(ctx context.Context, bucket, key, versionID string) (api.Object, error) {
+	return ssql.ObjectVersion(ctx, tx, bucket, key, versionID)
+}
+
+func (tx *MainDatabaseTx) ObjectVersions(ctx context.Context, bucket, key string) ([]api.ObjectVersion, error) {
+	return ssql.ObjectVersions(ctx, tx, bucket, key)
+}
+
+func (tx *MainDatabaseTx) ObjectsTags(ctx context.Context, bucket string, keys []string) (map[string]api.ObjectTags, error) {
+	return ssql.ObjectsTags(ctx, tx, bucket, keys)
+}
+
+func (tx *MainDatabaseTx) ObjectsLostOnArchival(ctx context.Context, fcids []types.FileContractID, limit int) ([]api.ObjectMetadata, error) {
+	return ssql.ObjectsLostOnArchival(ctx, tx, fcids, limit)
+}
+
 func (tx *MainDatabaseTx) ObjectsStats(ctx context.Context, opts api.ObjectsStatsOpts) (api.ObjectsStatsResponse, error) {
 	return ssql.ObjectsStats(ctx, tx, opts)
 }
 
+func (tx *MainDatabaseTx) ObjectsStatsPerHost(ctx context.Context, opts api.ObjectsStatsOpts) (map[types.PublicKey]api.HostStorageStats, error) {
+	return ssql.ObjectsStatsPerHost(ctx, tx, opts)
+}
+
 func (tx *MainDatabaseTx) PeerBanned(ctx context.Context, addr string) (bool, error) {
 	return ssql.PeerBanned(ctx, tx, addr)
 }
@@ -738,6 +883,10 @@ func (tx *MainDatabaseTx) PruneSlabs(ctx context.Context, limit int64) (int64, e
 	return res.RowsAffected()
 }
 
+func (tx *MainDatabaseTx) PruneWebhookDeadLetters(ctx context.Context, now time.Time) (int64, error) {
+	return ssql.PruneWebhookDeadLetters(ctx, tx, now)
+}
+
 func (tx *MainDatabaseTx) PutContract(ctx context.Context, c api.ContractMetadata) error {
 	// validate metadata
 	var state ssql.ContractState
@@ -765,23 +914,34 @@ func (tx *MainDatabaseTx) PutContract(ctx context.Context, c api.ContractMetadat
 	_, err = tx.Exec(ctx, `
 INSERT INTO contracts (
 	created_at, fcid, host_id, host_key, v2,
-	archival_reason, proof_height, renewed_from, renewed_to, revision_height, revision_number, size, start_height, state, usability, window_start, window_end,
-	contract_price, initial_renter_funds,
-	delete_spending, fund_account_spending, sector_roots_spending, upload_spending
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	archival_reason, proof_confirmations, proof_height, renewed_from, renewed_to, revision_height, revision_number, size, start_height, state, usability, window_start, window_end,
+	contract_price, initial_renter_funds, storage_price, upload_price, download_price,
+	delete_spending, fund_account_spending, sector_roots_spending, upload_spending,
+	account_funding_fees, price_table_fees, sector_upload_fees
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 ON DUPLICATE KEY UPDATE
 	created_at = VALUES(created_at), fcid = VALUES(fcid), host_id = VALUES(host_id), host_key = VALUES(host_key), v2 = VALUES(v2),
-	archival_reason = VALUES(archival_reason), proof_height = VALUES(proof_height), renewed_from = VALUES(renewed_from), renewed_to = VALUES(renewed_to), revision_height = VALUES(revision_height), revision_number = VALUES(revision_number), size = VALUES(size), start_height = VALUES(start_height), state = VALUES(state), usability = VALUES(usability), window_start = VALUES(window_start), window_end = VALUES(window_end),
-	contract_price = VALUES(contract_price), initial_renter_funds = VALUES(initial_renter_funds),
-	delete_spending = VALUES(delete_spending), fund_account_spending = VALUES(fund_account_spending), sector_roots_spending = VALUES(sector_roots_spending), upload_spending = VALUES(upload_spending)`,
+	archival_reason = VALUES(archival_reason), proof_confirmations = VALUES(proof_confirmations), proof_height = VALUES(proof_height), renewed_from = VALUES(renewed_from), renewed_to = VALUES(renewed_to), revision_height = VALUES(revision_height), revision_number = VALUES(revision_number), size = VALUES(size), start_height = VALUES(start_height), state = VALUES(state), usability = VALUES(usability), window_start = VALUES(window_start), window_end = VALUES(window_end),
+	contract_price = VALUES(contract_price), initial_renter_funds = VALUES(initial_renter_funds), storage_price = VALUES(storage_price), upload_price = VALUES(upload_price), download_price = VALUES(download_price),
+	delete_spending = VALUES(delete_spending), fund_account_spending = VALUES(fund_account_spending), sector_roots_spending = VALUES(sector_roots_spending), upload_spending = VALUES(upload_spending),
+	account_funding_fees = VALUES(account_funding_fees), price_table_fees = VALUES(price_table_fees), sector_upload_fees = VALUES(sector_upload_fees)`,
 		time.Now(), ssql.FileContractID(c.ID), hostID, ssql.PublicKey(c.HostKey), c.V2,
-		ssql.NullableString(c.ArchivalReason), c.ProofHeight, ssql.FileContractID(c.RenewedFrom), ssql.FileContractID(c.RenewedTo), c.RevisionHeight, c.RevisionNumber, c.Size, c.StartHeight, state, usability, c.WindowStart, c.WindowEnd,
-		ssql.Currency(c.ContractPrice), ssql.Currency(c.InitialRenterFunds),
+		ssql.NullableString(c.ArchivalReason), c.ProofConfirmations, c.ProofHeight, ssql.FileContractID(c.RenewedFrom), ssql.FileContractID(c.RenewedTo), c.RevisionHeight, c.RevisionNumber, c.Size, c.StartHeight, state, usability, c.WindowStart, c.WindowEnd,
+		ssql.Currency(c.ContractPrice), ssql.Currency(c.InitialRenterFunds), ssql.Currency(c.StoragePrice), ssql.Currency(c.UploadPrice), ssql.Currency(c.DownloadPrice),
 		ssql.Currency(c.Spending.Deletions), ssql.Currency(c.Spending.FundAccount), ssql.Currency(c.Spending.SectorRoots), ssql.Currency(c.Spending.Uploads),
+		ssql.Currency(c.Spending.AccountFundingFees), ssql.Currency(c.Spending.PriceTableFees), ssql.Currency(c.Spending.SectorUploadFees),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to update contract: %w", err)
 	}
+
+	// archived contracts don't store any sectors
+	if c.ArchivalReason != "" {
+		_, err = tx.Exec(ctx, "DELETE FROM contract_sectors WHERE db_contract_id IN (SELECT id FROM contracts WHERE fcid = ?)", ssql.FileContractID(c.ID))
+		if err != nil {
+			return fmt.Errorf("failed to delete contract_sectors: %w", err)
+		}
+	}
 	return nil
 }
 
@@ -789,11 +949,23 @@ func (tx *MainDatabaseTx) RecordContractSpending(ctx context.Context, fcid types
 	return ssql.RecordContractSpending(ctx, tx, fcid, revisionNumber, size, newSpending)
 }
 
+func (tx *MainDatabaseTx) RecordSlabFailure(ctx context.Context, key object.EncryptionKey) error {
+	return ssql.RecordSlabFailure(ctx, tx, key)
+}
+
+func (tx *MainDatabaseTx) RecordHostBenchmark(ctx context.Context, hk types.PublicKey, benchmark api.HostBenchmark) error {
+	return ssql.RecordHostBenchmark(ctx, tx, hk, benchmark)
+}
+
 func (tx *MainDatabaseTx) RecordHostScans(ctx context.Context, scans []api.HostScan) error {
 	return ssql.RecordHostScans(ctx, tx, scans)
 }
 
-func (tx *MainDatabaseTx) RemoveOfflineHosts(ctx context.Context, minRecentFailures uint64, maxDownTime time.Duration) (int64, error) {
+func (tx *MainDatabaseTx) RecoverObjects(ctx context.Context, roots []types.Hash256) (matched, recovered, deleted int64, err error) {
+	return ssql.RecoverObjects(ctx, tx, roots)
+}
+
+func (tx *MainDatabaseTx) RemoveOfflineHosts(ctx context.Context, minRecentFailures uint64, maxDownTime time.Duration) (int64, []types.FileContractID, error) {
 	return ssql.RemoveOfflineHosts(ctx, tx, minRecentFailures, maxDownTime)
 }
 
@@ -879,6 +1051,10 @@ func (tx *MainDatabaseTx) RenameObjects(ctx context.Context, bucket, prefixOld,
 	return nil
 }
 
+func (tx *MainDatabaseTx) RenameObjectVersions(ctx context.Context, bucket, keyOld, keyNew string) error {
+	return ssql.RenameObjectVersions(ctx, tx, bucket, keyOld, keyNew)
+}
+
 func (tx *MainDatabaseTx) RenewedContract(ctx context.Context, renewedFrom types.FileContractID) (api.ContractMetadata, error) {
 	return ssql.RenewedContract(ctx, tx, renewedFrom)
 }
@@ -941,12 +1117,16 @@ func (tx *MainDatabaseTx) Slab(ctx context.Context, key object.EncryptionKey) (o
 	return ssql.Slab(ctx, tx, key)
 }
 
+func (tx *MainDatabaseTx) StorageProof(ctx context.Context, fcid types.FileContractID) (api.ContractStorageProof, error) {
+	return ssql.StorageProof(ctx, tx, fcid)
+}
+
 func (tx *MainDatabaseTx) Tip(ctx context.Context) (types.ChainIndex, error) {
 	return ssql.Tip(ctx, tx.Tx)
 }
 
-func (tx *MainDatabaseTx) UnhealthySlabs(ctx context.Context, healthCutoff float64, limit int) ([]api.UnhealthySlab, error) {
-	return ssql.UnhealthySlabs(ctx, tx, healthCutoff, limit)
+func (tx *MainDatabaseTx) UnhealthySlabs(ctx context.Context, healthCutoff float64, maxLastFailure time.Duration, limit int) ([]api.UnhealthySlab, error) {
+	return ssql.UnhealthySlabs(ctx, tx, healthCutoff, maxLastFailure, limit)
 }
 
 func (tx *MainDatabaseTx) UnspentSiacoinElements(ctx context.Context) (elements []types.SiacoinElement, err error) {
@@ -1025,9 +1205,15 @@ func (tx *MainDatabaseTx) UpdateHostBlocklistEntries(ctx context.Context, add, r
 	if clear {
 		if _, err := tx.Exec(ctx, "DELETE FROM host_blocklist_entries"); err != nil {
 			return fmt.Errorf("failed to clear host blocklist entries: %w", err)
+		} else if _, err := tx.Exec(ctx, "DELETE FROM host_blocklist_cidrs"); err != nil {
+			return fmt.Errorf("failed to clear host blocklist CIDRs: %w", err)
 		}
 	}
 
+	// CIDR entries are stored separately
+	add, addCIDRs := ssql.SplitBlocklistEntries(add)
+	remove, removeCIDRs := ssql.SplitBlocklistEntries(remove)
+
 	if len(add) > 0 {
 		insertStmt, err := tx.Prepare(ctx, "INSERT INTO host_blocklist_entries (entry) VALUES (?) ON DUPLICATE KEY UPDATE id = last_insert_id(id)")
 		if err != nil {
@@ -1080,18 +1266,58 @@ func (tx *MainDatabaseTx) UpdateHostBlocklistEntries(ctx context.Context, add, r
 			}
 		}
 	}
+
+	if len(addCIDRs) > 0 {
+		insertStmt, err := tx.Prepare(ctx, "INSERT INTO host_blocklist_cidrs (entry) VALUES (?) ON DUPLICATE KEY UPDATE id = last_insert_id(id)")
+		if err != nil {
+			return fmt.Errorf("failed to prepare insert statement: %w", err)
+		}
+		defer insertStmt.Close()
+
+		for _, cidr := range addCIDRs {
+			if res, err := insertStmt.Exec(ctx, cidr); err != nil {
+				return fmt.Errorf("failed to insert host blocklist CIDR: %w", err)
+			} else if cidrID, err := res.LastInsertId(); err != nil {
+				return fmt.Errorf("failed to fetch host blocklist CIDR id: %w", err)
+			} else if err := ssql.BlockHostsInCIDR(ctx, tx, cidrID, cidr); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !clear && len(removeCIDRs) > 0 {
+		deleteStmt, err := tx.Prepare(ctx, "DELETE FROM host_blocklist_cidrs WHERE entry = ?")
+		if err != nil {
+			return fmt.Errorf("failed to prepare delete statement: %w", err)
+		}
+		defer deleteStmt.Close()
+
+		for _, cidr := range removeCIDRs {
+			if _, err := deleteStmt.Exec(ctx, cidr); err != nil {
+				return fmt.Errorf("failed to delete host blocklist CIDR: %w", err)
+			}
+		}
+	}
 	return nil
 }
 
 func (tx *MainDatabaseTx) UpdateHostCheck(ctx context.Context, hk types.PublicKey, hc api.HostChecks) error {
-	_, err := tx.Exec(ctx, `
+	return tx.UpdateHostChecks(ctx, map[types.PublicKey]api.HostChecks{hk: hc})
+}
+
+func (tx *MainDatabaseTx) UpdateHostChecks(ctx context.Context, checks map[types.PublicKey]api.HostChecks) error {
+	if len(checks) == 0 {
+		return nil
+	}
+
+	stmt, err := tx.Prepare(ctx, `
 		INSERT INTO host_checks (created_at, db_host_id, usability_blocked, usability_offline, usability_low_score,
 			usability_redundant_ip, usability_gouging, usability_low_max_duration, usability_not_accepting_contracts, usability_not_announced, usability_not_completing_scan,
 			score_age, score_collateral, score_interactions, score_storage_remaining, score_uptime, score_version, score_prices,
-			gouging_download_err, gouging_gouging_err, gouging_prune_err, gouging_upload_err)
+			gouging_download_err, gouging_gouging_err, gouging_prune_err, gouging_upload_err, formation_backoff_count, unstable_pricing, geo_score)
 	    VALUES (?,
 			(SELECT id FROM hosts WHERE public_key = ?),
-			?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON DUPLICATE KEY UPDATE
 			created_at = VALUES(created_at), db_host_id = VALUES(db_host_id),
 			usability_blocked = VALUES(usability_blocked), usability_offline = VALUES(usability_offline), usability_low_score = VALUES(usability_low_score),
@@ -1100,18 +1326,34 @@ func (tx *MainDatabaseTx) UpdateHostCheck(ctx context.Context, hk types.PublicKe
 			score_age = VALUES(score_age), score_collateral = VALUES(score_collateral), score_interactions = VALUES(score_interactions),
 			score_storage_remaining = VALUES(score_storage_remaining), score_uptime = VALUES(score_uptime), score_version = VALUES(score_version),
 			score_prices = VALUES(score_prices), gouging_download_err = VALUES(gouging_download_err),
-			gouging_gouging_err = VALUES(gouging_gouging_err), gouging_prune_err = VALUES(gouging_prune_err), gouging_upload_err = VALUES(gouging_upload_err)
-	`, time.Now(), ssql.PublicKey(hk), hc.UsabilityBreakdown.Blocked, hc.UsabilityBreakdown.Offline, hc.UsabilityBreakdown.LowScore,
-		hc.UsabilityBreakdown.RedundantIP, hc.UsabilityBreakdown.Gouging, hc.UsabilityBreakdown.LowMaxDuration, hc.UsabilityBreakdown.NotAcceptingContracts, hc.UsabilityBreakdown.NotAnnounced, hc.UsabilityBreakdown.NotCompletingScan,
-		hc.ScoreBreakdown.Age, hc.ScoreBreakdown.Collateral, hc.ScoreBreakdown.Interactions, hc.ScoreBreakdown.StorageRemaining, hc.ScoreBreakdown.Uptime, hc.ScoreBreakdown.Version, hc.ScoreBreakdown.Prices,
-		hc.GougingBreakdown.DownloadErr, hc.GougingBreakdown.GougingErr, hc.GougingBreakdown.PruneErr, hc.GougingBreakdown.UploadErr,
-	)
+			gouging_gouging_err = VALUES(gouging_gouging_err), gouging_prune_err = VALUES(gouging_prune_err), gouging_upload_err = VALUES(gouging_upload_err),
+			formation_backoff_count = VALUES(formation_backoff_count), unstable_pricing = VALUES(unstable_pricing),
+			geo_score = VALUES(geo_score)
+	`)
 	if err != nil {
-		return fmt.Errorf("failed to insert host check: %w", err)
+		return fmt.Errorf("failed to prepare statement to insert host check: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now()
+	for hk, hc := range checks {
+		_, err := stmt.Exec(ctx, now, ssql.PublicKey(hk), hc.UsabilityBreakdown.Blocked, hc.UsabilityBreakdown.Offline, hc.UsabilityBreakdown.LowScore,
+			hc.UsabilityBreakdown.RedundantIP, hc.UsabilityBreakdown.Gouging, hc.UsabilityBreakdown.LowMaxDuration, hc.UsabilityBreakdown.NotAcceptingContracts, hc.UsabilityBreakdown.NotAnnounced, hc.UsabilityBreakdown.NotCompletingScan,
+			hc.ScoreBreakdown.Age, hc.ScoreBreakdown.Collateral, hc.ScoreBreakdown.Interactions, hc.ScoreBreakdown.StorageRemaining, hc.ScoreBreakdown.Uptime, hc.ScoreBreakdown.Version, hc.ScoreBreakdown.Prices,
+			hc.GougingBreakdown.DownloadErr, hc.GougingBreakdown.GougingErr, hc.GougingBreakdown.PruneErr, hc.GougingBreakdown.UploadErr,
+			hc.FormationBackoffCount, hc.UnstablePricing, hc.GeoScore,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert host check for host %v: %w", hk, err)
+		}
 	}
 	return nil
 }
 
+func (tx *MainDatabaseTx) UpdateObjectTags(ctx context.Context, bucket, key string, tags api.ObjectTags) error {
+	return ssql.UpdateObjectTags(ctx, tx, bucket, key, tags)
+}
+
 func (tx *MainDatabaseTx) UpdatePeerInfo(ctx context.Context, addr string, fn func(*syncer.PeerInfo)) error {
 	return ssql.UpdatePeerInfo(ctx, tx, addr, fn)
 }
@@ -1195,18 +1437,114 @@ func (tx *MainDatabaseTx) UpsertContractSectors(ctx context.Context, contractSec
 	return nil
 }
 
+func (tx *MainDatabaseTx) UpsertHostScans(ctx context.Context, scans []api.HostScan) error {
+	if len(scans) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	placeholders := make([]string, 0, len(scans))
+	args := make([]any, 0, len(scans)*18)
+	for _, scan := range scans {
+		placeholders = append(placeholders, "(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+
+		// settings and price tables are only updated on success, so we
+		// avoid encoding them for failed scans
+		var successful, failed int
+		var settings ssql.HostSettings
+		var v2Settings ssql.V2HostSettings
+		var pt ssql.PriceTable
+		if scan.Success {
+			successful = 1
+			settings = ssql.HostSettings(scan.Settings)
+			v2Settings = ssql.V2HostSettings(scan.V2Settings)
+			pt = ssql.PriceTable(scan.PriceTable)
+		} else {
+			failed = 1
+		}
+		args = append(args,
+			now,                          // created_at
+			ssql.PublicKey(scan.HostKey), // public_key
+			scan.Success,                 // scanned
+			1,                            // total_scans
+			false,                        // second_to_last_scan_success
+			scan.Success,                 // last_scan_success
+			0,                            // recent_downtime
+			failed,                       // recent_scan_failures
+			0,                            // downtime
+			0,                            // uptime
+			scan.Timestamp.UnixMilli(),   // last_scan
+			scan.SiaMuxReachable,         // siamux_reachable
+			settings,                     // settings
+			v2Settings,                   // v2_settings
+			pt,                           // price_table
+			now,                          // price_table_expiry
+			successful,                   // successful_interactions
+			failed,                       // failed_interactions
+		)
+	}
+
+	// NOTE: The order of the assignments in the UPDATE statement is important
+	// since MySQL evaluates them from left to right and unqualified columns
+	// refer to the updated row. e.g. second_to_last_scan_success must be set
+	// before last_scan_success.
+	_, err := tx.Exec(ctx, fmt.Sprintf(`
+		INSERT INTO hosts (created_at, public_key, scanned, total_scans, second_to_last_scan_success, last_scan_success,
+			recent_downtime, recent_scan_failures, downtime, uptime, last_scan, siamux_reachable, settings, v2_settings,
+			price_table, price_table_expiry, successful_interactions, failed_interactions)
+		VALUES %s
+		ON DUPLICATE KEY UPDATE
+			scanned = scanned OR VALUES(scanned),
+			total_scans = total_scans + 1,
+			second_to_last_scan_success = last_scan_success,
+			last_scan_success = VALUES(last_scan_success),
+			recent_downtime = CASE WHEN NOT VALUES(last_scan_success) AND last_scan > 0 AND last_scan < VALUES(last_scan) THEN recent_downtime + VALUES(last_scan) - last_scan ELSE CASE WHEN VALUES(last_scan_success) THEN 0 ELSE recent_downtime END END,
+			recent_scan_failures = CASE WHEN VALUES(last_scan_success) THEN 0 ELSE recent_scan_failures + 1 END,
+			downtime = CASE WHEN NOT VALUES(last_scan_success) AND last_scan > 0 AND last_scan < VALUES(last_scan) THEN downtime + VALUES(last_scan) - last_scan ELSE downtime END,
+			uptime = CASE WHEN VALUES(last_scan_success) AND last_scan > 0 AND last_scan < VALUES(last_scan) THEN uptime + VALUES(last_scan) - last_scan ELSE uptime END,
+			last_scan = VALUES(last_scan),
+			siamux_reachable = COALESCE(VALUES(siamux_reachable), siamux_reachable),
+			settings = CASE WHEN VALUES(last_scan_success) THEN VALUES(settings) ELSE settings END,
+			v2_settings = CASE WHEN VALUES(last_scan_success) THEN VALUES(v2_settings) ELSE v2_settings END,
+			price_table = CASE WHEN VALUES(last_scan_success) THEN VALUES(price_table) ELSE price_table END,
+			price_table_expiry = CASE WHEN VALUES(last_scan_success) THEN VALUES(price_table_expiry) ELSE price_table_expiry END,
+			successful_interactions = successful_interactions + VALUES(successful_interactions),
+			failed_interactions = failed_interactions + VALUES(failed_interactions)
+	`, strings.Join(placeholders, ", ")), args...)
+	if err != nil {
+		return fmt.Errorf("failed to upsert host scans: %w", err)
+	}
+	return nil
+}
+
 func (tx *MainDatabaseTx) UsableHosts(ctx context.Context) ([]ssql.HostInfo, error) {
 	return ssql.UsableHosts(ctx, tx)
 }
 
-func (tx *MainDatabaseTx) WalletEvents(ctx context.Context, offset, limit int) ([]wallet.Event, error) {
-	return ssql.WalletEvents(ctx, tx.Tx, offset, limit)
+func (tx *MainDatabaseTx) WalletEvents(ctx context.Context, opts api.WalletEventsOpts) ([]wallet.Event, error) {
+	return ssql.WalletEvents(ctx, tx.Tx, opts)
 }
 
 func (tx *MainDatabaseTx) WalletEventCount(ctx context.Context) (count uint64, err error) {
 	return ssql.WalletEventCount(ctx, tx.Tx)
 }
 
+func (tx *MainDatabaseTx) UpdateWebhookDeadLetter(ctx context.Context, dl webhooks.DeadLetter) error {
+	return ssql.UpdateWebhookDeadLetter(ctx, tx, dl)
+}
+
+func (tx *MainDatabaseTx) WebhookDeadLetter(ctx context.Context, id int64) (webhooks.DeadLetter, error) {
+	return ssql.WebhookDeadLetter(ctx, tx, id)
+}
+
+func (tx *MainDatabaseTx) WebhookDeadLetterCount(ctx context.Context) (uint64, error) {
+	return ssql.WebhookDeadLetterCount(ctx, tx)
+}
+
+func (tx *MainDatabaseTx) WebhookDeadLetters(ctx context.Context) ([]webhooks.DeadLetter, error) {
+	return ssql.WebhookDeadLetters(ctx, tx)
+}
+
 func (tx *MainDatabaseTx) Webhooks(ctx context.Context) ([]webhooks.Webhook, error) {
 	return ssql.Webhooks(ctx, tx)
 }
@@ -1224,8 +1562,8 @@ func (tx *MainDatabaseTx) insertSlabs(ctx context.Context, objID, partID *int64,
 	}
 
 	// insert slabs
-	insertSlabStmt, err := tx.Prepare(ctx, `INSERT INTO slabs (created_at, `+"`key`"+`, min_shards, total_shards)
-						VALUES (?, ?, ?, ?)
+	insertSlabStmt, err := tx.Prepare(ctx, `INSERT INTO slabs (created_at, `+"`key`"+`, min_shards, total_shards, checksum_algorithm, checksums)
+						VALUES (?, ?, ?, ?, ?, ?)
 						ON DUPLICATE KEY UPDATE id = last_insert_id(id)`)
 	if err != nil {
 		return fmt.Errorf("failed to prepare statement to insert slab: %w", err)
@@ -1245,6 +1583,8 @@ func (tx *MainDatabaseTx) insertSlabs(ctx context.Context, objID, partID *int64,
 			ssql.EncryptionKey(slices[i].EncryptionKey),
 			slices[i].MinShards,
 			uint8(len(slices[i].Shards)),
+			slices[i].ChecksumAlgorithm,
+			ssql.Checksums(slices[i].Checksums),
 		)
 		if err != nil {
 			return fmt.Errorf("failed to insert slab: %w", err)
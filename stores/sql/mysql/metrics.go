@@ -7,6 +7,7 @@ import (
 
 	dsql "database/sql"
 
+	"go.sia.tech/core/types"
 	"go.sia.tech/renterd/api"
 	"go.sia.tech/renterd/internal/sql"
 	ssql "go.sia.tech/renterd/stores/sql"
@@ -85,6 +86,10 @@ func (tx *MetricsDatabaseTx) ContractPruneMetrics(ctx context.Context, start tim
 	return ssql.ContractPruneMetrics(ctx, tx, start, n, interval, opts)
 }
 
+func (tx *MetricsDatabaseTx) LatestContractMetrics(ctx context.Context, before time.Time) ([]api.ContractMetric, error) {
+	return ssql.LatestContractMetrics(ctx, tx, before)
+}
+
 func (tx *MetricsDatabaseTx) PruneMetrics(ctx context.Context, metric string, cutoff time.Time) error {
 	return ssql.PruneMetrics(ctx, tx, metric, cutoff)
 }
@@ -97,10 +102,22 @@ func (tx *MetricsDatabaseTx) RecordContractPruneMetric(ctx context.Context, metr
 	return ssql.RecordContractPruneMetric(ctx, tx, metrics...)
 }
 
+func (tx *MetricsDatabaseTx) RecordSlabDownloadMetric(ctx context.Context, metrics ...api.SlabDownloadMetric) error {
+	return ssql.RecordSlabDownloadMetric(ctx, tx, metrics...)
+}
+
 func (tx *MetricsDatabaseTx) RecordWalletMetric(ctx context.Context, metrics ...api.WalletMetric) error {
 	return ssql.RecordWalletMetric(ctx, tx, metrics...)
 }
 
+func (tx *MetricsDatabaseTx) MetricsSummary(ctx context.Context, fcids []types.FileContractID) (api.MetricsSummary, error) {
+	return ssql.MetricsSummary(ctx, tx, fcids)
+}
+
+func (tx *MetricsDatabaseTx) SlabDownloadMetrics(ctx context.Context, start time.Time, n uint64, interval time.Duration, opts api.SlabDownloadMetricsQueryOpts) ([]api.SlabDownloadMetric, error) {
+	return ssql.SlabDownloadMetrics(ctx, tx, start, n, interval, opts)
+}
+
 func (tx *MetricsDatabaseTx) WalletMetrics(ctx context.Context, start time.Time, n uint64, interval time.Duration, opts api.WalletMetricsQueryOpts) ([]api.WalletMetric, error) {
 	return ssql.WalletMetrics(ctx, tx, start, n, interval, opts)
 }
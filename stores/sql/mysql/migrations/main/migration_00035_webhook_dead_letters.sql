@@ -0,0 +1,14 @@
+-- dbWebhookDeadLetter
+CREATE TABLE `webhook_dead_letters` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) DEFAULT NULL,
+  `db_webhook_id` bigint unsigned NOT NULL,
+  `event` longtext NOT NULL,
+  `failure_count` bigint unsigned NOT NULL DEFAULT 0,
+  `last_error` longtext NOT NULL,
+  `expires_at` bigint NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_webhook_dead_letters_db_webhook_id` (`db_webhook_id`),
+  KEY `idx_webhook_dead_letters_expires_at` (`expires_at`),
+  CONSTRAINT `fk_webhook_dead_letters_db_webhook` FOREIGN KEY (`db_webhook_id`) REFERENCES `webhooks` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1 @@
+ALTER TABLE `host_checks` ADD COLUMN `formation_backoff_count` bigint unsigned NOT NULL DEFAULT 0;
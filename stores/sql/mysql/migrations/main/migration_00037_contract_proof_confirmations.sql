@@ -0,0 +1,2 @@
+ALTER TABLE `contracts` ADD COLUMN `proof_confirmations` bigint unsigned NOT NULL DEFAULT 0;
+UPDATE `contracts` SET `proof_confirmations` = LEAST((SELECT `height` FROM `consensus_infos` WHERE `id` = 1) - `proof_height` + 1, 144) WHERE `proof_height` > 0 AND `proof_height` <= (SELECT `height` FROM `consensus_infos` WHERE `id` = 1);
@@ -0,0 +1 @@
+ALTER TABLE `autopilot_config` ADD COLUMN `contracts_auto_size` boolean NOT NULL DEFAULT false;
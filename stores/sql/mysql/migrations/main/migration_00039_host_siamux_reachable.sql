@@ -0,0 +1 @@
+ALTER TABLE `hosts` ADD COLUMN `siamux_reachable` tinyint(1) DEFAULT NULL;
@@ -0,0 +1,2 @@
+CREATE INDEX `idx_contract_sectors_db_contract_id_db_sector_id` ON `contract_sectors`(`db_contract_id`,`db_sector_id`);
+ALTER TABLE `contract_sectors` DROP INDEX `idx_contract_sectors_db_contract_id`;
@@ -0,0 +1,2 @@
+ALTER TABLE `contracts` ADD COLUMN `account_funding_fees` longtext, ADD COLUMN `price_table_fees` longtext, ADD COLUMN `sector_upload_fees` longtext;
+UPDATE `contracts` SET `account_funding_fees` = '0', `price_table_fees` = '0', `sector_upload_fees` = '0';
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS `contract_storage_proofs` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) DEFAULT NULL,
+  `db_contract_id` bigint unsigned NOT NULL,
+  `block_height` bigint unsigned NOT NULL,
+  `block_id` varbinary(32) NOT NULL,
+  `txn` longblob,
+  `v2_txn` longblob,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_contract_storage_proofs_db_contract_id` (`db_contract_id`),
+  CONSTRAINT `fk_contract_storage_proofs_contracts` FOREIGN KEY (`db_contract_id`) REFERENCES `contracts` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1 @@
+ALTER TABLE `objects` ADD COLUMN `last_accessed` bigint NOT NULL DEFAULT 0;
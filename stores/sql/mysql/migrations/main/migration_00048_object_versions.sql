@@ -0,0 +1,15 @@
+ALTER TABLE `objects` ADD COLUMN `version_id` varchar(64) DEFAULT NULL;
+
+CREATE TABLE IF NOT EXISTS `object_versions` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) DEFAULT NULL,
+  `db_bucket_id` bigint unsigned NOT NULL,
+  `object_key` varchar(766) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL,
+  `version_id` varchar(64) NOT NULL,
+  `db_object_id` bigint unsigned DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_object_versions_version_id` (`version_id`),
+  KEY `idx_object_versions_bucket_object_key` (`db_bucket_id`,`object_key`),
+  CONSTRAINT `fk_object_versions_db_bucket` FOREIGN KEY (`db_bucket_id`) REFERENCES `buckets` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_object_versions_db_object` FOREIGN KEY (`db_object_id`) REFERENCES `objects` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS `host_scans` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) DEFAULT NULL,
+  `db_host_id` bigint unsigned NOT NULL,
+  `timestamp` bigint NOT NULL,
+  `success` boolean NOT NULL,
+  `siamux_reachable` boolean DEFAULT NULL,
+  `latency` bigint NOT NULL,
+  `error` longtext,
+  `settings` JSON,
+  `v2_settings` JSON,
+  `price_table` longtext,
+  PRIMARY KEY (`id`),
+  KEY `idx_host_scans_db_host_id_timestamp` (`db_host_id`,`timestamp`),
+  CONSTRAINT `fk_host_scans_db_host` FOREIGN KEY (`db_host_id`) REFERENCES `hosts` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,2 @@
+ALTER TABLE `slabs` ADD COLUMN `checksum_algorithm` varchar(16) NOT NULL DEFAULT '';
+ALTER TABLE `slabs` ADD COLUMN `checksums` blob DEFAULT NULL;
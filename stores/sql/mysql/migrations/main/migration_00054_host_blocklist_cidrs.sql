@@ -0,0 +1,22 @@
+-- dbBlocklistCIDR
+CREATE TABLE `host_blocklist_cidrs` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) DEFAULT NULL,
+  `entry` varchar(191) NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `entry` (`entry`),
+  KEY `idx_host_blocklist_cidrs_entry` (`entry`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- dbBlocklistCIDR <-> dbHost
+CREATE TABLE `host_blocklist_cidr_hosts` (
+  `db_blocklist_cidr_id` bigint unsigned NOT NULL,
+  `db_host_id` bigint unsigned NOT NULL,
+  PRIMARY KEY (`db_blocklist_cidr_id`,`db_host_id`),
+  KEY `idx_host_blocklist_cidr_hosts_db_host_id` (`db_host_id`),
+  CONSTRAINT `fk_host_blocklist_cidr_hosts_db_blocklist_cidr` FOREIGN KEY (`db_blocklist_cidr_id`) REFERENCES `host_blocklist_cidrs` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_host_blocklist_cidr_hosts_db_host` FOREIGN KEY (`db_host_id`) REFERENCES `hosts` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- IP addresses a host's address resolved to when it was last scanned
+ALTER TABLE `hosts` ADD COLUMN `resolved_addresses` varchar(255) NOT NULL DEFAULT '';
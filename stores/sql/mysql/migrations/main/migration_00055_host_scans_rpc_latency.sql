@@ -0,0 +1 @@
+ALTER TABLE `host_scans` ADD COLUMN `rpc_latency` bigint NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+ALTER TABLE `contracts` ADD COLUMN `storage_price` longtext;
+ALTER TABLE `contracts` ADD COLUMN `upload_price` longtext;
+ALTER TABLE `contracts` ADD COLUMN `download_price` longtext;
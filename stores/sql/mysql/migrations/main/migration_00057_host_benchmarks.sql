@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS `host_benchmarks` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) DEFAULT NULL,
+  `db_host_id` bigint unsigned NOT NULL,
+  `timestamp` bigint NOT NULL,
+  `upload_mbps` double NOT NULL,
+  `download_mbps` double NOT NULL,
+  `ttfb` bigint NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_host_benchmarks_db_host_id_timestamp` (`db_host_id`,`timestamp`),
+  CONSTRAINT `fk_host_benchmarks_db_host` FOREIGN KEY (`db_host_id`) REFERENCES `hosts` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -40,6 +40,8 @@ CREATE TABLE `hosts` (
   `last_scan_success` tinyint(1) DEFAULT NULL,
   `second_to_last_scan_success` tinyint(1) DEFAULT NULL,
   `scanned` tinyint(1) DEFAULT NULL,
+  `siamux_reachable` tinyint(1) DEFAULT NULL,
+  `resolved_addresses` varchar(255) NOT NULL DEFAULT '',
   `uptime` bigint DEFAULT NULL,
   `downtime` bigint DEFAULT NULL,
   `recent_downtime` bigint DEFAULT NULL,
@@ -69,6 +71,7 @@ CREATE TABLE `contracts` (
   `v2` boolean NOT NULL,
 
   `archival_reason` varchar(191) DEFAULT NULL,
+  `proof_confirmations` bigint unsigned NOT NULL DEFAULT 0,
   `proof_height` bigint unsigned DEFAULT '0',
   `renewed_from` varbinary(32) DEFAULT NULL,
   `renewed_to` varbinary(32) DEFAULT NULL,
@@ -83,11 +86,18 @@ CREATE TABLE `contracts` (
 
   `contract_price` longtext,
   `initial_renter_funds` longtext,
+  `storage_price` longtext,
+  `upload_price` longtext,
+  `download_price` longtext,
 
   `delete_spending` longtext,
   `fund_account_spending` longtext,
   `sector_roots_spending` longtext,
   `upload_spending` longtext,
+
+  `account_funding_fees` longtext,
+  `price_table_fees` longtext,
+  `sector_upload_fees` longtext,
   PRIMARY KEY (`id`),
   UNIQUE KEY `fcid` (`fcid`),
   KEY `idx_contracts_archival_reason` (`archival_reason`),
@@ -116,6 +126,9 @@ CREATE TABLE `slabs` (
   `key` binary(33) NOT NULL,
   `min_shards` tinyint unsigned DEFAULT NULL,
   `total_shards` tinyint unsigned DEFAULT NULL,
+  `last_failure` bigint NOT NULL DEFAULT '0',
+  `checksum_algorithm` varchar(16) NOT NULL DEFAULT '',
+  `checksums` blob DEFAULT NULL,
   PRIMARY KEY (`id`),
   UNIQUE KEY `key` (`key`),
   KEY `idx_slabs_min_shards` (`min_shards`),
@@ -148,7 +161,7 @@ CREATE TABLE `contract_sectors` (
   `db_contract_id` bigint unsigned NOT NULL,
   PRIMARY KEY (`db_sector_id`,`db_contract_id`),
   KEY `idx_contract_sectors_db_sector_id` (`db_sector_id`),
-  KEY `idx_contract_sectors_db_contract_id` (`db_contract_id`),
+  KEY `idx_contract_sectors_db_contract_id_db_sector_id` (`db_contract_id`,`db_sector_id`),
   CONSTRAINT `fk_contract_sectors_db_contract` FOREIGN KEY (`db_contract_id`) REFERENCES `contracts` (`id`) ON DELETE CASCADE,
   CONSTRAINT `fk_contract_sectors_db_sector` FOREIGN KEY (`db_sector_id`) REFERENCES `sectors` (`id`) ON DELETE CASCADE
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -235,6 +248,26 @@ CREATE TABLE `host_blocklist_entry_hosts` (
   CONSTRAINT `fk_host_blocklist_entry_hosts_db_host` FOREIGN KEY (`db_host_id`) REFERENCES `hosts` (`id`) ON DELETE CASCADE
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
 
+-- dbBlocklistCIDR
+CREATE TABLE `host_blocklist_cidrs` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) DEFAULT NULL,
+  `entry` varchar(191) NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `entry` (`entry`),
+  KEY `idx_host_blocklist_cidrs_entry` (`entry`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- dbBlocklistCIDR <-> dbHost
+CREATE TABLE `host_blocklist_cidr_hosts` (
+  `db_blocklist_cidr_id` bigint unsigned NOT NULL,
+  `db_host_id` bigint unsigned NOT NULL,
+  PRIMARY KEY (`db_blocklist_cidr_id`,`db_host_id`),
+  KEY `idx_host_blocklist_cidr_hosts_db_host_id` (`db_host_id`),
+  CONSTRAINT `fk_host_blocklist_cidr_hosts_db_blocklist_cidr` FOREIGN KEY (`db_blocklist_cidr_id`) REFERENCES `host_blocklist_cidrs` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_host_blocklist_cidr_hosts_db_host` FOREIGN KEY (`db_host_id`) REFERENCES `hosts` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
 -- dbMultipartUpload
 CREATE TABLE `multipart_uploads` (
   `id` bigint unsigned NOT NULL AUTO_INCREMENT,
@@ -244,11 +277,13 @@ CREATE TABLE `multipart_uploads` (
   `object_id` varchar(766) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin DEFAULT NULL,
   `db_bucket_id` bigint unsigned NOT NULL,
   `mime_type` varchar(191) DEFAULT NULL,
+  `expires_at` bigint DEFAULT NULL,
   PRIMARY KEY (`id`),
   UNIQUE KEY `idx_multipart_uploads_upload_id` (`upload_id`),
   KEY `idx_multipart_uploads_object_id` (`object_id`),
   KEY `idx_multipart_uploads_db_bucket_id` (`db_bucket_id`),
   KEY `idx_multipart_uploads_mime_type` (`mime_type`),
+  KEY `idx_multipart_uploads_expires_at` (`expires_at`),
   CONSTRAINT `fk_multipart_uploads_db_bucket` FOREIGN KEY (`db_bucket_id`) REFERENCES `buckets` (`id`) ON DELETE CASCADE
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
 
@@ -278,6 +313,8 @@ CREATE TABLE `objects` (
   `size` bigint DEFAULT NULL,
   `mime_type` longtext,
   `etag` varchar(191) DEFAULT NULL,
+  `last_accessed` bigint NOT NULL DEFAULT 0,
+  `version_id` varchar(64) DEFAULT NULL,
   PRIMARY KEY (`id`),
   UNIQUE KEY `idx_object_bucket` (`db_bucket_id`,`object_id`),
   KEY `idx_objects_db_bucket_id` (`db_bucket_id`),
@@ -289,6 +326,65 @@ CREATE TABLE `objects` (
   CONSTRAINT `fk_objects_db_bucket` FOREIGN KEY (`db_bucket_id`) REFERENCES `buckets` (`id`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
 
+-- dbHostPriceHistory
+CREATE TABLE `host_price_history` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) DEFAULT NULL,
+  `db_host_id` bigint unsigned NOT NULL,
+  `timestamp` bigint NOT NULL,
+  `upload_price` longtext NOT NULL,
+  `download_price` longtext NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_host_price_history_db_host_id_timestamp` (`db_host_id`,`timestamp`),
+  CONSTRAINT `fk_host_price_history_db_host` FOREIGN KEY (`db_host_id`) REFERENCES `hosts` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- dbHostScan
+CREATE TABLE `host_scans` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) DEFAULT NULL,
+  `db_host_id` bigint unsigned NOT NULL,
+  `timestamp` bigint NOT NULL,
+  `success` boolean NOT NULL,
+  `siamux_reachable` boolean DEFAULT NULL,
+  `latency` bigint NOT NULL,
+  `error` longtext,
+  `settings` JSON,
+  `v2_settings` JSON,
+  `price_table` longtext,
+  `rpc_latency` bigint NOT NULL DEFAULT 0,
+  PRIMARY KEY (`id`),
+  KEY `idx_host_scans_db_host_id_timestamp` (`db_host_id`,`timestamp`),
+  CONSTRAINT `fk_host_scans_db_host` FOREIGN KEY (`db_host_id`) REFERENCES `hosts` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- dbHostBenchmark
+CREATE TABLE `host_benchmarks` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) DEFAULT NULL,
+  `db_host_id` bigint unsigned NOT NULL,
+  `timestamp` bigint NOT NULL,
+  `upload_mbps` double NOT NULL,
+  `download_mbps` double NOT NULL,
+  `ttfb` bigint NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_host_benchmarks_db_host_id_timestamp` (`db_host_id`,`timestamp`),
+  CONSTRAINT `fk_host_benchmarks_db_host` FOREIGN KEY (`db_host_id`) REFERENCES `hosts` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- dbRecoveredObject
+CREATE TABLE `recovered_objects` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) DEFAULT NULL,
+  `db_bucket_id` bigint unsigned NOT NULL,
+  `object_key` varchar(766) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL,
+  `size` bigint DEFAULT NULL,
+  `deleted` tinyint(1) NOT NULL DEFAULT 0,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_recovered_objects_bucket_object_key` (`db_bucket_id`,`object_key`),
+  CONSTRAINT `fk_recovered_objects_db_bucket` FOREIGN KEY (`db_bucket_id`) REFERENCES `buckets` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
 -- dbSetting
 CREATE TABLE `settings` (
   `id` bigint unsigned NOT NULL AUTO_INCREMENT,
@@ -346,6 +442,33 @@ CREATE TABLE `object_user_metadata` (
   CONSTRAINT `fk_multipart_upload_user_metadata` FOREIGN KEY (`db_multipart_upload_id`) REFERENCES `multipart_uploads` (`id`) ON DELETE SET NULL
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
 
+-- dbObjectTag
+CREATE TABLE `object_tags` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) DEFAULT NULL,
+  `db_object_id` bigint unsigned NOT NULL,
+  `key` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL,
+  `value` varchar(256) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_object_tags_key` (`db_object_id`,`key`),
+  CONSTRAINT `fk_object_tags_db_object` FOREIGN KEY (`db_object_id`) REFERENCES `objects` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- dbObjectVersion
+CREATE TABLE `object_versions` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) DEFAULT NULL,
+  `db_bucket_id` bigint unsigned NOT NULL,
+  `object_key` varchar(766) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL,
+  `version_id` varchar(64) NOT NULL,
+  `db_object_id` bigint unsigned DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_object_versions_version_id` (`version_id`),
+  KEY `idx_object_versions_bucket_object_key` (`db_bucket_id`,`object_key`),
+  CONSTRAINT `fk_object_versions_db_bucket` FOREIGN KEY (`db_bucket_id`) REFERENCES `buckets` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_object_versions_db_object` FOREIGN KEY (`db_object_id`) REFERENCES `objects` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
 -- dbHostCheck
 CREATE TABLE `host_checks` (
   `id` bigint unsigned NOT NULL AUTO_INCREMENT,
@@ -376,6 +499,10 @@ CREATE TABLE `host_checks` (
   `gouging_prune_err` text,
   `gouging_upload_err` text,
 
+  `formation_backoff_count` bigint unsigned NOT NULL DEFAULT 0,
+  `unstable_pricing` boolean NOT NULL DEFAULT false,
+  `geo_score` double NOT NULL DEFAULT 1,
+
   PRIMARY KEY (`id`),
   UNIQUE KEY `idx_host_checks_id` (`db_host_id`),
   INDEX `idx_host_checks_usability_blocked` (`usability_blocked`),
@@ -472,6 +599,20 @@ CREATE TABLE `contract_elements` (
   CONSTRAINT `fk_contract_elements_contracts` FOREIGN KEY (`db_contract_id`) REFERENCES `contracts` (`id`) ON DELETE CASCADE
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
 
+-- contract storage proofs
+CREATE TABLE `contract_storage_proofs` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) DEFAULT NULL,
+  `db_contract_id` bigint unsigned NOT NULL,
+  `block_height` bigint unsigned NOT NULL,
+  `block_id` varbinary(32) NOT NULL,
+  `txn` longblob,
+  `v2_txn` longblob,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_contract_storage_proofs_db_contract_id` (`db_contract_id`),
+  CONSTRAINT `fk_contract_storage_proofs_contracts` FOREIGN KEY (`db_contract_id`) REFERENCES `contracts` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
 -- autopilot config
 CREATE TABLE `autopilot_config` (
   `id` bigint unsigned NOT NULL DEFAULT 1,
@@ -485,11 +626,29 @@ CREATE TABLE `autopilot_config` (
   `contracts_upload` bigint unsigned DEFAULT NULL,
   `contracts_storage` bigint unsigned DEFAULT NULL,
   `contracts_prune` boolean NOT NULL DEFAULT false,
+  `contracts_auto_size` boolean NOT NULL DEFAULT false,
 
   `hosts_max_downtime_hours` bigint unsigned DEFAULT NULL,
   `hosts_min_protocol_version` varchar(191) DEFAULT NULL,
   `hosts_max_consecutive_scan_failures` bigint unsigned DEFAULT NULL,
 
+  `price_renegotiation_enabled` boolean NOT NULL DEFAULT false,
+
   PRIMARY KEY (`id`),
   CHECK (`id` = 1)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- dbWebhookDeadLetter
+CREATE TABLE `webhook_dead_letters` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) DEFAULT NULL,
+  `db_webhook_id` bigint unsigned NOT NULL,
+  `event` longtext NOT NULL,
+  `failure_count` bigint unsigned NOT NULL DEFAULT 0,
+  `last_error` longtext NOT NULL,
+  `expires_at` bigint NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_webhook_dead_letters_db_webhook_id` (`db_webhook_id`),
+  KEY `idx_webhook_dead_letters_expires_at` (`expires_at`),
+  CONSTRAINT `fk_webhook_dead_letters_db_webhook` FOREIGN KEY (`db_webhook_id`) REFERENCES `webhooks` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
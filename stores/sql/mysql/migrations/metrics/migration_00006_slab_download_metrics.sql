@@ -0,0 +1,13 @@
+-- dbSlabDownloadMetric
+DROP TABLE IF EXISTS `slab_downloads`;
+CREATE TABLE `slab_downloads` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) DEFAULT NULL,
+  `timestamp` bigint NOT NULL,
+  `slab_key_hash` binary(32) NOT NULL,
+  `duration` bigint NOT NULL,
+  `success` tinyint(1) NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_slab_downloads_timestamp` (`timestamp`),
+  KEY `idx_slab_downloads_slab_key_hash` (`slab_key_hash`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -16,36 +16,46 @@ type ContractRow struct {
 	V2      bool
 
 	// state fields
-	ArchivalReason NullableString
-	ProofHeight    uint64
-	RenewedFrom    FileContractID
-	RenewedTo      FileContractID
-	RevisionHeight uint64
-	RevisionNumber uint64
-	Size           uint64
-	StartHeight    uint64
-	State          ContractState
-	Usability      ContractUsability
-	WindowStart    uint64
-	WindowEnd      uint64
+	ArchivalReason     NullableString
+	ProofConfirmations uint64
+	ProofHeight        uint64
+	RenewedFrom        FileContractID
+	RenewedTo          FileContractID
+	RevisionHeight     uint64
+	RevisionNumber     uint64
+	Size               uint64
+	StartHeight        uint64
+	State              ContractState
+	Usability          ContractUsability
+	WindowStart        uint64
+	WindowEnd          uint64
 
 	// cost fields
 	ContractPrice      Currency
 	InitialRenterFunds Currency
+	StoragePrice       Currency
+	UploadPrice        Currency
+	DownloadPrice      Currency
 
 	// spending fields
 	DeleteSpending      Currency
 	FundAccountSpending Currency
 	SectorRootsSpending Currency
 	UploadSpending      Currency
+
+	// fee fields
+	AccountFundingFees Currency
+	PriceTableFees     Currency
+	SectorUploadFees   Currency
 }
 
 func (r *ContractRow) Scan(s Scanner) error {
 	return s.Scan(
 		&r.FCID, &r.HostID, &r.HostKey, &r.V2,
-		&r.ArchivalReason, &r.ProofHeight, &r.RenewedFrom, &r.RenewedTo, &r.RevisionHeight, &r.RevisionNumber, &r.Size, &r.StartHeight, &r.State, &r.Usability, &r.WindowStart, &r.WindowEnd,
-		&r.ContractPrice, &r.InitialRenterFunds,
+		&r.ArchivalReason, &r.ProofConfirmations, &r.ProofHeight, &r.RenewedFrom, &r.RenewedTo, &r.RevisionHeight, &r.RevisionNumber, &r.Size, &r.StartHeight, &r.State, &r.Usability, &r.WindowStart, &r.WindowEnd,
+		&r.ContractPrice, &r.InitialRenterFunds, &r.StoragePrice, &r.UploadPrice, &r.DownloadPrice,
 		&r.DeleteSpending, &r.FundAccountSpending, &r.SectorRootsSpending, &r.UploadSpending,
+		&r.AccountFundingFees, &r.PriceTableFees, &r.SectorUploadFees,
 	)
 }
 
@@ -55,6 +65,10 @@ func (r *ContractRow) ContractMetadata() api.ContractMetadata {
 		FundAccount: types.Currency(r.FundAccountSpending),
 		Deletions:   types.Currency(r.DeleteSpending),
 		SectorRoots: types.Currency(r.SectorRootsSpending),
+
+		AccountFundingFees: types.Currency(r.AccountFundingFees),
+		PriceTableFees:     types.Currency(r.PriceTableFees),
+		SectorUploadFees:   types.Currency(r.SectorUploadFees),
 	}
 
 	return api.ContractMetadata{
@@ -64,19 +78,23 @@ func (r *ContractRow) ContractMetadata() api.ContractMetadata {
 
 		ContractPrice:      types.Currency(r.ContractPrice),
 		InitialRenterFunds: types.Currency(r.InitialRenterFunds),
+		StoragePrice:       types.Currency(r.StoragePrice),
+		UploadPrice:        types.Currency(r.UploadPrice),
+		DownloadPrice:      types.Currency(r.DownloadPrice),
 
-		ArchivalReason: string(r.ArchivalReason),
-		ProofHeight:    r.ProofHeight,
-		RenewedFrom:    types.FileContractID(r.RenewedFrom),
-		RenewedTo:      types.FileContractID(r.RenewedTo),
-		RevisionHeight: r.RevisionHeight,
-		RevisionNumber: r.RevisionNumber,
-		Size:           r.Size,
-		Spending:       spending,
-		StartHeight:    r.StartHeight,
-		State:          r.State.String(),
-		Usability:      r.Usability.String(),
-		WindowStart:    r.WindowStart,
-		WindowEnd:      r.WindowEnd,
+		ArchivalReason:     string(r.ArchivalReason),
+		ProofConfirmations: r.ProofConfirmations,
+		ProofHeight:        r.ProofHeight,
+		RenewedFrom:        types.FileContractID(r.RenewedFrom),
+		RenewedTo:          types.FileContractID(r.RenewedTo),
+		RevisionHeight:     r.RevisionHeight,
+		RevisionNumber:     r.RevisionNumber,
+		Size:               r.Size,
+		Spending:           spending,
+		StartHeight:        r.StartHeight,
+		State:              r.State.String(),
+		Usability:          r.Usability.String(),
+		WindowStart:        r.WindowStart,
+		WindowEnd:          r.WindowEnd,
 	}
 }
@@ -229,6 +229,14 @@ func (c chainUpdateTx) PruneFileContractElements(threshold uint64) error {
 	return ssql.PruneFileContractElements(c.ctx, c.tx, threshold)
 }
 
+func (c chainUpdateTx) RecordStorageProof(fcid types.FileContractID, proof api.ContractStorageProof) error {
+	return ssql.RecordStorageProof(c.ctx, c.tx, fcid, proof, c.l)
+}
+
+func (c chainUpdateTx) RemoveStorageProof(fcid types.FileContractID) error {
+	return ssql.RemoveStorageProof(c.ctx, c.tx, fcid, c.l)
+}
+
 func (c chainUpdateTx) RecordContractRenewal(oldFCID, newFCID types.FileContractID) error {
 	return ssql.RecordContractRenewal(c.ctx, c.tx, oldFCID, newFCID)
 }
@@ -271,10 +279,14 @@ func (c chainUpdateTx) UpdateFileContractElementProofs(updater wallet.ProofUpdat
 	return ssql.UpdateFileContractElementProofs(c.ctx, c.tx, updater)
 }
 
-func (c chainUpdateTx) UpdateFailedContracts(blockHeight uint64) error {
+func (c chainUpdateTx) UpdateFailedContracts(blockHeight uint64) ([]types.FileContractID, error) {
 	return ssql.UpdateFailedContracts(c.ctx, c.tx, blockHeight, c.l)
 }
 
+func (c chainUpdateTx) UpdateProofConfirmations(blockHeight, depth uint64) error {
+	return ssql.UpdateProofConfirmations(c.ctx, c.tx, blockHeight, depth, c.l)
+}
+
 func (c chainUpdateTx) UpdateHost(hk types.PublicKey, v1Addr string, v2Ha chain.V2HostAnnouncement, bh uint64, blockID types.BlockID, ts time.Time) error { //
 	c.l.Debugw("update host", "hk", hk, "netaddress", v1Addr)
 
@@ -429,6 +441,11 @@ func (c chainUpdateTx) UpdateHost(hk types.PublicKey, v1Addr string, v2Ha chain.
 		}
 	}
 
+	// update CIDR blocklist
+	if err := ssql.UpdateHostBlocklistCIDRs(c.ctx, c.tx, hostID); err != nil {
+		return err
+	}
+
 	return nil
 }
 
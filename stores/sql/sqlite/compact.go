@@ -0,0 +1,107 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"go.sia.tech/renterd/internal/sql"
+)
+
+// checkpointWAL copies all frames of the WAL into the database and truncates
+// the WAL file to zero bytes.
+func checkpointWAL(ctx context.Context, db *sql.DB) error {
+	var busy, log, checkpointed int64
+	if err := db.QueryRow(ctx, "PRAGMA wal_checkpoint(TRUNCATE)").Scan(&busy, &log, &checkpointed); err != nil {
+		return fmt.Errorf("failed to checkpoint WAL: %w", err)
+	} else if busy != 0 {
+		return errors.New("failed to checkpoint WAL: checkpoint was blocked by another connection")
+	}
+	return nil
+}
+
+// compactDB checkpoints and truncates the WAL and rebuilds the database to
+// release unused pages. The database is rebuilt using VACUUM which copies the
+// database into a temporary file and atomically replaces the database's
+// content with it. Unlike replacing the database file ourselves this is safe
+// while other connections to the database are open. Since rebuilding the
+// database writes every page to the WAL, the WAL is truncated again
+// afterwards.
+func compactDB(ctx context.Context, db *sql.DB) error {
+	if err := checkpointWAL(ctx, db); err != nil {
+		return err
+	} else if _, err := db.Exec(ctx, "VACUUM"); err != nil {
+		return fmt.Errorf("failed to vacuum database: %w", err)
+	}
+	return checkpointWAL(ctx, db)
+}
+
+// vacuumDB updates the query planner's statistics using PRAGMA optimize and
+// then compacts the database to reclaim the space of deleted rows.
+func vacuumDB(ctx context.Context, db *sql.DB) error {
+	if _, err := db.Exec(ctx, "PRAGMA optimize"); err != nil {
+		return fmt.Errorf("failed to optimize database: %w", err)
+	}
+	return compactDB(ctx, db)
+}
+
+// walSize returns the size of the database's WAL file in bytes. In-memory
+// databases don't have a WAL and always return 0.
+func walSize(ctx context.Context, db *sql.DB) (int64, error) {
+	var seq int64
+	var name, path string
+	if err := db.QueryRow(ctx, "SELECT seq, name, file FROM pragma_database_list WHERE name = 'main'").Scan(&seq, &name, &path); err != nil {
+		return 0, fmt.Errorf("failed to fetch database path: %w", err)
+	} else if path == "" {
+		return 0, nil
+	}
+
+	fi, err := os.Stat(path + "-wal")
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	} else if err != nil {
+		return 0, fmt.Errorf("failed to stat WAL file: %w", err)
+	}
+	return fi.Size(), nil
+}
+
+// CheckpointWAL copies the WAL into the database and truncates it.
+func (s *MainDatabase) CheckpointWAL(ctx context.Context) error {
+	return checkpointWAL(ctx, s.db)
+}
+
+// Compact truncates the WAL and rebuilds the database.
+func (s *MainDatabase) Compact(ctx context.Context) error {
+	return compactDB(ctx, s.db)
+}
+
+// Vacuum optimizes and rebuilds the database.
+func (s *MainDatabase) Vacuum(ctx context.Context) error {
+	return vacuumDB(ctx, s.db)
+}
+
+// WALSize returns the size of the database's WAL file in bytes.
+func (s *MainDatabase) WALSize(ctx context.Context) (int64, error) {
+	return walSize(ctx, s.db)
+}
+
+// CheckpointWAL copies the WAL into the database and truncates it.
+func (s *MetricsDatabase) CheckpointWAL(ctx context.Context) error {
+	return checkpointWAL(ctx, s.db)
+}
+
+// Compact truncates the WAL and rebuilds the database.
+func (s *MetricsDatabase) Compact(ctx context.Context) error {
+	return compactDB(ctx, s.db)
+}
+
+// Vacuum optimizes and rebuilds the database.
+func (s *MetricsDatabase) Vacuum(ctx context.Context) error {
+	return vacuumDB(ctx, s.db)
+}
+
+// WALSize returns the size of the database's WAL file in bytes.
+func (s *MetricsDatabase) WALSize(ctx context.Context) (int64, error) {
+	return walSize(ctx, s.db)
+}
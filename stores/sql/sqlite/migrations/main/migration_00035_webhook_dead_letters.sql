@@ -0,0 +1,4 @@
+-- dbWebhookDeadLetter
+CREATE TABLE `webhook_dead_letters` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`db_webhook_id` integer NOT NULL,`event` text NOT NULL,`failure_count` integer NOT NULL DEFAULT 0,`last_error` text NOT NULL DEFAULT '',`expires_at` BIGINT NOT NULL,CONSTRAINT `fk_webhook_dead_letters_db_webhook` FOREIGN KEY (`db_webhook_id`) REFERENCES `webhooks`(`id`) ON DELETE CASCADE);
+CREATE INDEX `idx_webhook_dead_letters_db_webhook_id` ON `webhook_dead_letters`(`db_webhook_id`);
+CREATE INDEX `idx_webhook_dead_letters_expires_at` ON `webhook_dead_letters`(`expires_at`);
@@ -0,0 +1 @@
+ALTER TABLE autopilot_config ADD COLUMN contracts_auto_size integer NOT NULL DEFAULT 0;
@@ -0,0 +1 @@
+ALTER TABLE autopilot_config ADD COLUMN price_renegotiation_enabled integer NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS `idx_contract_sectors_db_contract_id`;
+CREATE INDEX IF NOT EXISTS `idx_contract_sectors_db_contract_id_db_sector_id` ON `contract_sectors`(`db_contract_id`,`db_sector_id`);
@@ -0,0 +1,2 @@
+CREATE TABLE `recovered_objects` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`db_bucket_id` integer NOT NULL,`object_key` text NOT NULL,`size` integer,`deleted` integer NOT NULL DEFAULT 0,CONSTRAINT `fk_recovered_objects_db_bucket` FOREIGN KEY (`db_bucket_id`) REFERENCES `buckets`(`id`) ON DELETE CASCADE);
+CREATE UNIQUE INDEX `idx_recovered_objects_bucket_object_key` ON `recovered_objects`(`db_bucket_id`,`object_key`);
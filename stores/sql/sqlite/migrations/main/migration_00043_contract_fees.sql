@@ -0,0 +1,4 @@
+ALTER TABLE contracts ADD COLUMN `account_funding_fees` text;
+ALTER TABLE contracts ADD COLUMN `price_table_fees` text;
+ALTER TABLE contracts ADD COLUMN `sector_upload_fees` text;
+UPDATE contracts SET account_funding_fees = '0', price_table_fees = '0', sector_upload_fees = '0';
@@ -0,0 +1,3 @@
+CREATE TABLE `host_price_history` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`db_host_id` integer NOT NULL,`timestamp` BIGINT NOT NULL,`upload_price` text NOT NULL,`download_price` text NOT NULL,CONSTRAINT `fk_host_price_history_db_host` FOREIGN KEY (`db_host_id`) REFERENCES `hosts`(`id`) ON DELETE CASCADE);
+CREATE INDEX `idx_host_price_history_db_host_id_timestamp` ON `host_price_history`(`db_host_id`,`timestamp`);
+ALTER TABLE `host_checks` ADD COLUMN `unstable_pricing` INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+CREATE TABLE `contract_storage_proofs` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`db_contract_id` integer NOT NULL,`block_height` integer NOT NULL,`block_id` blob NOT NULL,`txn` longblob,`v2_txn` longblob,CONSTRAINT `fk_contract_storage_proofs_contracts` FOREIGN KEY (`db_contract_id`) REFERENCES `contracts`(`id`) ON DELETE CASCADE);
+CREATE UNIQUE INDEX `idx_contract_storage_proofs_db_contract_id` ON `contract_storage_proofs`(`db_contract_id`);
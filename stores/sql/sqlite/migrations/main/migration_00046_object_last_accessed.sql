@@ -0,0 +1 @@
+ALTER TABLE `objects` ADD COLUMN `last_accessed` integer NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+CREATE TABLE `object_tags` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`db_object_id` integer NOT NULL,`key` text NOT NULL,`value` text NOT NULL,CONSTRAINT `fk_object_tags_db_object` FOREIGN KEY (`db_object_id`) REFERENCES `objects`(`id`) ON DELETE CASCADE);
+CREATE UNIQUE INDEX `idx_object_tags_key` ON `object_tags`(`db_object_id`,`key`);
@@ -0,0 +1,5 @@
+ALTER TABLE `objects` ADD COLUMN `version_id` text;
+
+CREATE TABLE `object_versions` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`db_bucket_id` integer NOT NULL,`object_key` text NOT NULL,`version_id` text NOT NULL,`db_object_id` integer,CONSTRAINT `fk_object_versions_db_bucket` FOREIGN KEY (`db_bucket_id`) REFERENCES `buckets`(`id`) ON DELETE CASCADE,CONSTRAINT `fk_object_versions_db_object` FOREIGN KEY (`db_object_id`) REFERENCES `objects`(`id`) ON DELETE CASCADE);
+CREATE UNIQUE INDEX `idx_object_versions_version_id` ON `object_versions`(`version_id`);
+CREATE INDEX `idx_object_versions_bucket_object_key` ON `object_versions`(`db_bucket_id`,`object_key`);
@@ -0,0 +1 @@
+ALTER TABLE `host_checks` ADD COLUMN `geo_score` REAL NOT NULL DEFAULT 1;
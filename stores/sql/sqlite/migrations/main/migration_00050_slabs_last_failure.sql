@@ -0,0 +1 @@
+ALTER TABLE `slabs` ADD COLUMN `last_failure` integer NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+CREATE TABLE `host_scans` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`db_host_id` integer NOT NULL,`timestamp` BIGINT NOT NULL,`success` numeric NOT NULL,`siamux_reachable` numeric,`latency` BIGINT NOT NULL,`error` text,`settings` text,`v2_settings` text,`price_table` text,CONSTRAINT `fk_host_scans_db_host` FOREIGN KEY (`db_host_id`) REFERENCES `hosts`(`id`) ON DELETE CASCADE);
+CREATE INDEX `idx_host_scans_db_host_id_timestamp` ON `host_scans`(`db_host_id`,`timestamp`);
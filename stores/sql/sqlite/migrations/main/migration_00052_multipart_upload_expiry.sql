@@ -0,0 +1,2 @@
+ALTER TABLE `multipart_uploads` ADD COLUMN `expires_at` BIGINT DEFAULT NULL;
+CREATE INDEX `idx_multipart_uploads_expires_at` ON `multipart_uploads`(`expires_at`);
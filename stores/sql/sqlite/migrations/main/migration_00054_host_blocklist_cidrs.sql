@@ -0,0 +1,10 @@
+-- dbBlocklistCIDR
+CREATE TABLE `host_blocklist_cidrs` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`entry` text NOT NULL UNIQUE);
+CREATE INDEX `idx_host_blocklist_cidrs_entry` ON `host_blocklist_cidrs`(`entry`);
+
+-- dbBlocklistCIDR <-> dbHost
+CREATE TABLE `host_blocklist_cidr_hosts` (`db_blocklist_cidr_id` integer,`db_host_id` integer,PRIMARY KEY (`db_blocklist_cidr_id`,`db_host_id`),CONSTRAINT `fk_host_blocklist_cidr_hosts_db_blocklist_cidr` FOREIGN KEY (`db_blocklist_cidr_id`) REFERENCES `host_blocklist_cidrs`(`id`) ON DELETE CASCADE,CONSTRAINT `fk_host_blocklist_cidr_hosts_db_host` FOREIGN KEY (`db_host_id`) REFERENCES `hosts`(`id`) ON DELETE CASCADE);
+CREATE INDEX `idx_host_blocklist_cidr_hosts_db_host_id` ON `host_blocklist_cidr_hosts`(`db_host_id`);
+
+-- IP addresses a host's address resolved to when it was last scanned
+ALTER TABLE `hosts` ADD COLUMN `resolved_addresses` text NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+ALTER TABLE `contracts` ADD COLUMN `storage_price` text;
+ALTER TABLE `contracts` ADD COLUMN `upload_price` text;
+ALTER TABLE `contracts` ADD COLUMN `download_price` text;
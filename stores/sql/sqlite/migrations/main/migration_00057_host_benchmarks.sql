@@ -0,0 +1,2 @@
+CREATE TABLE IF NOT EXISTS `host_benchmarks` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`db_host_id` integer NOT NULL,`timestamp` BIGINT NOT NULL,`upload_mbps` REAL NOT NULL,`download_mbps` REAL NOT NULL,`ttfb` BIGINT NOT NULL,CONSTRAINT `fk_host_benchmarks_db_host` FOREIGN KEY (`db_host_id`) REFERENCES `hosts`(`id`) ON DELETE CASCADE);
+CREATE INDEX IF NOT EXISTS `idx_host_benchmarks_db_host_id_timestamp` ON `host_benchmarks`(`db_host_id`,`timestamp`);
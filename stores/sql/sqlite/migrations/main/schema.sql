@@ -12,6 +12,8 @@ CREATE TABLE `hosts` (
 `last_scan_success` numeric,
 `second_to_last_scan_success` numeric,
 `scanned` numeric,
+`siamux_reachable` numeric DEFAULT NULL,
+`resolved_addresses` text NOT NULL DEFAULT '',
 `uptime` integer,
 `downtime` integer,
 `recent_downtime` integer,
@@ -29,7 +31,7 @@ CREATE INDEX `idx_hosts_public_key` ON `hosts`(`public_key`);
 CREATE INDEX `idx_hosts_net_address` ON `hosts`(`net_address`);
 
 -- dbContract
-CREATE TABLE contracts (`id` integer PRIMARY KEY AUTOINCREMENT, `created_at` datetime, `fcid` blob NOT NULL UNIQUE, `host_id` integer, `host_key` blob NOT NULL,`v2` INTEGER NOT NULL, `archival_reason` text DEFAULT NULL, `proof_height` integer DEFAULT 0, `renewed_from` blob, `renewed_to` blob, `revision_height` integer DEFAULT 0, `revision_number` text NOT NULL DEFAULT "0", `size` integer, `start_height` integer NOT NULL, `state` integer NOT NULL DEFAULT 0, `usability` integer NOT NULL, `window_start` integer NOT NULL DEFAULT 0, `window_end` integer NOT NULL DEFAULT 0, `contract_price` text, `initial_renter_funds` text, `delete_spending` text, `fund_account_spending` text, `sector_roots_spending` text, `upload_spending` text, CONSTRAINT `fk_contracts_host` FOREIGN KEY (`host_id`) REFERENCES `hosts`(`id`));
+CREATE TABLE contracts (`id` integer PRIMARY KEY AUTOINCREMENT, `created_at` datetime, `fcid` blob NOT NULL UNIQUE, `host_id` integer, `host_key` blob NOT NULL,`v2` INTEGER NOT NULL, `archival_reason` text DEFAULT NULL, `proof_confirmations` INTEGER NOT NULL DEFAULT 0, `proof_height` integer DEFAULT 0, `renewed_from` blob, `renewed_to` blob, `revision_height` integer DEFAULT 0, `revision_number` text NOT NULL DEFAULT "0", `size` integer, `start_height` integer NOT NULL, `state` integer NOT NULL DEFAULT 0, `usability` integer NOT NULL, `window_start` integer NOT NULL DEFAULT 0, `window_end` integer NOT NULL DEFAULT 0, `contract_price` text, `initial_renter_funds` text, `storage_price` text, `upload_price` text, `download_price` text, `delete_spending` text, `fund_account_spending` text, `sector_roots_spending` text, `upload_spending` text, `account_funding_fees` text, `price_table_fees` text, `sector_upload_fees` text, CONSTRAINT `fk_contracts_host` FOREIGN KEY (`host_id`) REFERENCES `hosts`(`id`));
 CREATE INDEX `idx_contracts_archival_reason` ON `contracts`(`archival_reason`);
 CREATE INDEX `idx_contracts_fcid` ON `contracts`(`fcid`);
 CREATE INDEX `idx_contracts_host_id` ON `contracts`(`host_id`);
@@ -49,7 +51,7 @@ CREATE TABLE `buckets` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` date
 CREATE INDEX `idx_buckets_name` ON `buckets`(`name`);
 
 -- dbObject
-CREATE TABLE `objects` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`db_bucket_id` integer NOT NULL, `object_id` text,`key` blob,`health` real NOT NULL DEFAULT 1,`size` integer,`mime_type` text,`etag` text,CONSTRAINT `fk_objects_db_bucket` FOREIGN KEY (`db_bucket_id`) REFERENCES `buckets`(`id`));
+CREATE TABLE `objects` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`db_bucket_id` integer NOT NULL, `object_id` text,`key` blob,`health` real NOT NULL DEFAULT 1,`size` integer,`mime_type` text,`etag` text,`last_accessed` integer NOT NULL DEFAULT 0,`version_id` text,CONSTRAINT `fk_objects_db_bucket` FOREIGN KEY (`db_bucket_id`) REFERENCES `buckets`(`id`));
 CREATE INDEX `idx_objects_db_bucket_id` ON `objects`(`db_bucket_id`);
 CREATE INDEX `idx_objects_etag` ON `objects`(`etag`);
 CREATE INDEX `idx_objects_health` ON `objects`(`health`);
@@ -58,18 +60,35 @@ CREATE INDEX `idx_objects_size` ON `objects`(`size`);
 CREATE UNIQUE INDEX `idx_object_bucket` ON `objects`(`db_bucket_id`,`object_id`);
 CREATE INDEX `idx_objects_created_at` ON `objects`(`created_at`);
 
+-- dbHostPriceHistory
+CREATE TABLE `host_price_history` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`db_host_id` integer NOT NULL,`timestamp` BIGINT NOT NULL,`upload_price` text NOT NULL,`download_price` text NOT NULL,CONSTRAINT `fk_host_price_history_db_host` FOREIGN KEY (`db_host_id`) REFERENCES `hosts`(`id`) ON DELETE CASCADE);
+CREATE INDEX `idx_host_price_history_db_host_id_timestamp` ON `host_price_history`(`db_host_id`,`timestamp`);
+
+-- dbHostScan
+CREATE TABLE `host_scans` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`db_host_id` integer NOT NULL,`timestamp` BIGINT NOT NULL,`success` numeric NOT NULL,`siamux_reachable` numeric,`latency` BIGINT NOT NULL,`error` text,`settings` text,`v2_settings` text,`price_table` text,`rpc_latency` BIGINT NOT NULL DEFAULT 0,CONSTRAINT `fk_host_scans_db_host` FOREIGN KEY (`db_host_id`) REFERENCES `hosts`(`id`) ON DELETE CASCADE);
+CREATE INDEX `idx_host_scans_db_host_id_timestamp` ON `host_scans`(`db_host_id`,`timestamp`);
+
+-- dbHostBenchmark
+CREATE TABLE `host_benchmarks` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`db_host_id` integer NOT NULL,`timestamp` BIGINT NOT NULL,`upload_mbps` REAL NOT NULL,`download_mbps` REAL NOT NULL,`ttfb` BIGINT NOT NULL,CONSTRAINT `fk_host_benchmarks_db_host` FOREIGN KEY (`db_host_id`) REFERENCES `hosts`(`id`) ON DELETE CASCADE);
+CREATE INDEX `idx_host_benchmarks_db_host_id_timestamp` ON `host_benchmarks`(`db_host_id`,`timestamp`);
+
+-- dbRecoveredObject
+CREATE TABLE `recovered_objects` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`db_bucket_id` integer NOT NULL,`object_key` text NOT NULL,`size` integer,`deleted` integer NOT NULL DEFAULT 0,CONSTRAINT `fk_recovered_objects_db_bucket` FOREIGN KEY (`db_bucket_id`) REFERENCES `buckets`(`id`) ON DELETE CASCADE);
+CREATE UNIQUE INDEX `idx_recovered_objects_bucket_object_key` ON `recovered_objects`(`db_bucket_id`,`object_key`);
+
 -- dbMultipartUpload
-CREATE TABLE `multipart_uploads` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`key` blob,`upload_id` text NOT NULL,`object_id` text NOT NULL,`db_bucket_id` integer NOT NULL,`mime_type` text,CONSTRAINT `fk_multipart_uploads_db_bucket` FOREIGN KEY (`db_bucket_id`) REFERENCES `buckets`(`id`) ON DELETE CASCADE);
+CREATE TABLE `multipart_uploads` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`key` blob,`upload_id` text NOT NULL,`object_id` text NOT NULL,`db_bucket_id` integer NOT NULL,`mime_type` text,`expires_at` BIGINT DEFAULT NULL,CONSTRAINT `fk_multipart_uploads_db_bucket` FOREIGN KEY (`db_bucket_id`) REFERENCES `buckets`(`id`) ON DELETE CASCADE);
 CREATE INDEX `idx_multipart_uploads_mime_type` ON `multipart_uploads`(`mime_type`);
 CREATE INDEX `idx_multipart_uploads_db_bucket_id` ON `multipart_uploads`(`db_bucket_id`);
 CREATE INDEX `idx_multipart_uploads_object_id` ON `multipart_uploads`(`object_id`);
 CREATE UNIQUE INDEX `idx_multipart_uploads_upload_id` ON `multipart_uploads`(`upload_id`);
+CREATE INDEX `idx_multipart_uploads_expires_at` ON `multipart_uploads`(`expires_at`);
 
 -- dbBufferedSlab
 CREATE TABLE `buffered_slabs` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`filename` text);
 
 -- dbSlab
-CREATE TABLE `slabs` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`db_buffered_slab_id` integer DEFAULT NULL,`health` real NOT NULL DEFAULT 1,`health_valid_until` integer NOT NULL DEFAULT 0,`key` blob NOT NULL UNIQUE,`min_shards` integer,`total_shards` integer,CONSTRAINT `fk_buffered_slabs_db_slab` FOREIGN KEY (`db_buffered_slab_id`) REFERENCES `buffered_slabs`(`id`));
+CREATE TABLE `slabs` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`db_buffered_slab_id` integer DEFAULT NULL,`health` real NOT NULL DEFAULT 1,`health_valid_until` integer NOT NULL DEFAULT 0,`key` blob NOT NULL UNIQUE,`min_shards` integer,`total_shards` integer,`last_failure` integer NOT NULL DEFAULT 0,`checksum_algorithm` text NOT NULL DEFAULT '',`checksums` blob DEFAULT NULL,CONSTRAINT `fk_buffered_slabs_db_slab` FOREIGN KEY (`db_buffered_slab_id`) REFERENCES `buffered_slabs`(`id`));
 CREATE INDEX `idx_slabs_total_shards` ON `slabs`(`total_shards`);
 CREATE INDEX `idx_slabs_min_shards` ON `slabs`(`min_shards`);
 CREATE INDEX `idx_slabs_health_valid_until` ON `slabs`(`health_valid_until`);
@@ -85,7 +104,7 @@ CREATE INDEX `idx_sectors_root` ON `sectors`(`root`);
 
 -- dbContract <-> dbSector
 CREATE TABLE `contract_sectors` (`db_sector_id` integer,`db_contract_id` integer,PRIMARY KEY (`db_sector_id`,`db_contract_id`),CONSTRAINT `fk_contract_sectors_db_sector` FOREIGN KEY (`db_sector_id`) REFERENCES `sectors`(`id`) ON DELETE CASCADE,CONSTRAINT `fk_contract_sectors_db_contract` FOREIGN KEY (`db_contract_id`) REFERENCES `contracts`(`id`) ON DELETE CASCADE);
-CREATE INDEX `idx_contract_sectors_db_contract_id` ON `contract_sectors`(`db_contract_id`);
+CREATE INDEX `idx_contract_sectors_db_contract_id_db_sector_id` ON `contract_sectors`(`db_contract_id`,`db_sector_id`);
 CREATE INDEX `idx_contract_sectors_db_sector_id` ON `contract_sectors`(`db_sector_id`);
 
 -- dbHost <-> dbSector
@@ -129,6 +148,14 @@ CREATE INDEX `idx_host_blocklist_entries_entry` ON `host_blocklist_entries`(`ent
 CREATE TABLE `host_blocklist_entry_hosts` (`db_blocklist_entry_id` integer,`db_host_id` integer,PRIMARY KEY (`db_blocklist_entry_id`,`db_host_id`),CONSTRAINT `fk_host_blocklist_entry_hosts_db_blocklist_entry` FOREIGN KEY (`db_blocklist_entry_id`) REFERENCES `host_blocklist_entries`(`id`) ON DELETE CASCADE,CONSTRAINT `fk_host_blocklist_entry_hosts_db_host` FOREIGN KEY (`db_host_id`) REFERENCES `hosts`(`id`) ON DELETE CASCADE);
 CREATE INDEX `idx_host_blocklist_entry_hosts_db_host_id` ON `host_blocklist_entry_hosts`(`db_host_id`);
 
+-- dbBlocklistCIDR
+CREATE TABLE `host_blocklist_cidrs` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`entry` text NOT NULL UNIQUE);
+CREATE INDEX `idx_host_blocklist_cidrs_entry` ON `host_blocklist_cidrs`(`entry`);
+
+-- dbBlocklistCIDR <-> dbHost
+CREATE TABLE `host_blocklist_cidr_hosts` (`db_blocklist_cidr_id` integer,`db_host_id` integer,PRIMARY KEY (`db_blocklist_cidr_id`,`db_host_id`),CONSTRAINT `fk_host_blocklist_cidr_hosts_db_blocklist_cidr` FOREIGN KEY (`db_blocklist_cidr_id`) REFERENCES `host_blocklist_cidrs`(`id`) ON DELETE CASCADE,CONSTRAINT `fk_host_blocklist_cidr_hosts_db_host` FOREIGN KEY (`db_host_id`) REFERENCES `hosts`(`id`) ON DELETE CASCADE);
+CREATE INDEX `idx_host_blocklist_cidr_hosts_db_host_id` ON `host_blocklist_cidr_hosts`(`db_host_id`);
+
 -- dbAllowlistEntry
 CREATE TABLE `host_allowlist_entries` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`entry` blob NOT NULL UNIQUE);
 CREATE INDEX `idx_host_allowlist_entries_entry` ON `host_allowlist_entries`(`entry`);
@@ -154,6 +181,15 @@ CREATE UNIQUE INDEX `idx_module_event_url` ON `webhooks`(`module`,`event`,`url`)
 CREATE TABLE `object_user_metadata` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`db_object_id` integer DEFAULT NULL,`db_multipart_upload_id` integer DEFAULT NULL,`key` text NOT NULL,`value` text, CONSTRAINT `fk_object_user_metadata` FOREIGN KEY (`db_object_id`) REFERENCES `objects` (`id`) ON DELETE CASCADE, CONSTRAINT `fk_multipart_upload_user_metadata` FOREIGN KEY (`db_multipart_upload_id`) REFERENCES `multipart_uploads` (`id`) ON DELETE SET NULL);
 CREATE UNIQUE INDEX `idx_object_user_metadata_key` ON `object_user_metadata`(`db_object_id`,`db_multipart_upload_id`,`key`);
 
+-- dbObjectTag
+CREATE TABLE `object_tags` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`db_object_id` integer NOT NULL,`key` text NOT NULL,`value` text NOT NULL,CONSTRAINT `fk_object_tags_db_object` FOREIGN KEY (`db_object_id`) REFERENCES `objects`(`id`) ON DELETE CASCADE);
+CREATE UNIQUE INDEX `idx_object_tags_key` ON `object_tags`(`db_object_id`,`key`);
+
+-- dbObjectVersion
+CREATE TABLE `object_versions` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`db_bucket_id` integer NOT NULL,`object_key` text NOT NULL,`version_id` text NOT NULL,`db_object_id` integer,CONSTRAINT `fk_object_versions_db_bucket` FOREIGN KEY (`db_bucket_id`) REFERENCES `buckets`(`id`) ON DELETE CASCADE,CONSTRAINT `fk_object_versions_db_object` FOREIGN KEY (`db_object_id`) REFERENCES `objects`(`id`) ON DELETE CASCADE);
+CREATE UNIQUE INDEX `idx_object_versions_version_id` ON `object_versions`(`version_id`);
+CREATE INDEX `idx_object_versions_bucket_object_key` ON `object_versions`(`db_bucket_id`,`object_key`);
+
 -- dbHostCheck
 CREATE TABLE `host_checks` (
 `id` INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -179,6 +215,9 @@ CREATE TABLE `host_checks` (
 `gouging_gouging_err` TEXT,
 `gouging_prune_err` TEXT,
 `gouging_upload_err` TEXT,
+`formation_backoff_count` INTEGER NOT NULL DEFAULT 0,
+`unstable_pricing` INTEGER NOT NULL DEFAULT 0,
+`geo_score` REAL NOT NULL DEFAULT 1,
 FOREIGN KEY (`db_host_id`) REFERENCES `hosts` (`id`) ON DELETE CASCADE);
 CREATE UNIQUE INDEX `idx_host_checks_id` ON `host_checks` (`db_host_id`);
 CREATE INDEX `idx_host_checks_usability_blocked` ON `host_checks` (`usability_blocked`);
@@ -231,5 +270,14 @@ CREATE TABLE `contract_elements` (
     CONSTRAINT `fk_contract_elements_contracts` FOREIGN KEY (`db_contract_id`) REFERENCES `contracts`(`id`) ON DELETE CASCADE);
 CREATE UNIQUE INDEX `idx_contract_elements_db_contract_id` ON `contract_elements`(`db_contract_id`);
 
+-- contract storage proofs
+CREATE TABLE `contract_storage_proofs` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`db_contract_id` integer NOT NULL,`block_height` integer NOT NULL,`block_id` blob NOT NULL,`txn` longblob,`v2_txn` longblob,CONSTRAINT `fk_contract_storage_proofs_contracts` FOREIGN KEY (`db_contract_id`) REFERENCES `contracts`(`id`) ON DELETE CASCADE);
+CREATE UNIQUE INDEX `idx_contract_storage_proofs_db_contract_id` ON `contract_storage_proofs`(`db_contract_id`);
+
 -- autopilot config
-CREATE TABLE autopilot_config (id INTEGER PRIMARY KEY CHECK (id = 1), created_at datetime, enabled integer NOT NULL DEFAULT 0, contracts_amount integer, contracts_period integer, contracts_renew_window integer, contracts_download integer, contracts_upload integer, contracts_storage integer, contracts_prune integer NOT NULL DEFAULT 0, hosts_max_downtime_hours integer, hosts_min_protocol_version text, hosts_max_consecutive_scan_failures integer);
+CREATE TABLE autopilot_config (id INTEGER PRIMARY KEY CHECK (id = 1), created_at datetime, enabled integer NOT NULL DEFAULT 0, contracts_amount integer, contracts_period integer, contracts_renew_window integer, contracts_download integer, contracts_upload integer, contracts_storage integer, contracts_prune integer NOT NULL DEFAULT 0, contracts_auto_size integer NOT NULL DEFAULT 0, hosts_max_downtime_hours integer, hosts_min_protocol_version text, hosts_max_consecutive_scan_failures integer, price_renegotiation_enabled integer NOT NULL DEFAULT 0);
+
+-- dbWebhookDeadLetter
+CREATE TABLE `webhook_dead_letters` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`db_webhook_id` integer NOT NULL,`event` text NOT NULL,`failure_count` integer NOT NULL DEFAULT 0,`last_error` text NOT NULL DEFAULT '',`expires_at` BIGINT NOT NULL,CONSTRAINT `fk_webhook_dead_letters_db_webhook` FOREIGN KEY (`db_webhook_id`) REFERENCES `webhooks`(`id`) ON DELETE CASCADE);
+CREATE INDEX `idx_webhook_dead_letters_db_webhook_id` ON `webhook_dead_letters`(`db_webhook_id`);
+CREATE INDEX `idx_webhook_dead_letters_expires_at` ON `webhook_dead_letters`(`expires_at`);
@@ -0,0 +1,5 @@
+-- dbSlabDownloadMetric
+DROP TABLE IF EXISTS `slab_downloads`;
+CREATE TABLE `slab_downloads` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`timestamp` BIGINT NOT NULL,`slab_key_hash` blob NOT NULL,`duration` integer NOT NULL,`success` numeric NOT NULL);
+CREATE INDEX `idx_slab_downloads_timestamp` ON `slab_downloads`(`timestamp`);
+CREATE INDEX `idx_slab_downloads_slab_key_hash` ON `slab_downloads`(`slab_key_hash`);
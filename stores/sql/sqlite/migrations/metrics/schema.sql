@@ -29,3 +29,8 @@ CREATE INDEX `idx_spendable` ON `wallets`(`spendable_lo`,`spendable_hi`);
 CREATE INDEX `idx_confirmed` ON `wallets`(`confirmed_lo`,`confirmed_hi`);
 CREATE INDEX `idx_wallets_immature` ON `wallets`(`immature_lo`,`immature_hi`);
 CREATE INDEX `idx_wallets_timestamp` ON `wallets`(`timestamp`);
+
+-- dbSlabDownloadMetric
+CREATE TABLE `slab_downloads` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`timestamp` BIGINT NOT NULL,`slab_key_hash` blob NOT NULL,`duration` integer NOT NULL,`success` numeric NOT NULL);
+CREATE INDEX `idx_slab_downloads_timestamp` ON `slab_downloads`(`timestamp`);
+CREATE INDEX `idx_slab_downloads_slab_key_hash` ON `slab_downloads`(`slab_key_hash`);
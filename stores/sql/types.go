@@ -35,6 +35,7 @@ type (
 	BCurrency      types.Currency
 	BigInt         big.Int
 	BusSetting     string
+	Checksums      []types.Hash256
 	Currency       types.Currency
 	FileContractID types.FileContractID
 	Hash256        types.Hash256
@@ -72,6 +73,7 @@ var (
 	_ scannerValuer = (*BCurrency)(nil)
 	_ scannerValuer = (*BigInt)(nil)
 	_ scannerValuer = (*BusSetting)(nil)
+	_ scannerValuer = (*Checksums)(nil)
 	_ scannerValuer = (*Currency)(nil)
 	_ scannerValuer = (*FileContractID)(nil)
 	_ scannerValuer = (*Hash256)(nil)
@@ -284,6 +286,38 @@ func (mp MerkleProof) Value() (driver.Value, error) {
 	return b, nil
 }
 
+// Scan scans value into Checksums, implements sql.Scanner interface.
+func (c *Checksums) Scan(value interface{}) error {
+	if value == nil {
+		*c = nil
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return errors.New(fmt.Sprint("failed to unmarshal Checksums value:", value))
+	} else if len(b)%proofHashSize != 0 {
+		return fmt.Errorf("failed to unmarshal Checksums value due to invalid number of bytes %v: %v", len(b), value)
+	}
+
+	*c = make([]types.Hash256, len(b)/proofHashSize)
+	for i := range *c {
+		copy((*c)[i][:], b[i*proofHashSize:])
+	}
+	return nil
+}
+
+// Value returns a Checksums value, implements driver.Valuer interface.
+func (c Checksums) Value() (driver.Value, error) {
+	if len(c) == 0 {
+		return nil, nil
+	}
+	b := make([]byte, len(c)*proofHashSize)
+	for i, h := range c {
+		copy(b[i*proofHashSize:], h[:])
+	}
+	return b, nil
+}
+
 // String implements fmt.Stringer to prevent the key from getting leaked in
 // logs.
 func (k EncryptionKey) String() string {
@@ -5,6 +5,7 @@ import (
 
 	"go.sia.tech/core/types"
 	"go.sia.tech/coreutils/wallet"
+	"go.sia.tech/renterd/api"
 	"go.sia.tech/renterd/stores/sql"
 )
 
@@ -34,8 +35,17 @@ func (s *SQLStore) UnspentSiacoinElements() (elements []types.SiacoinElement, er
 // WalletEvents returns a paginated list of events, ordered by maturity height,
 // descending. If no more events are available, (nil, nil) is returned.
 func (s *SQLStore) WalletEvents(offset, limit int) (events []wallet.Event, err error) {
-	err = s.db.Transaction(context.Background(), func(tx sql.DatabaseTx) (err error) {
-		events, err = tx.WalletEvents(context.Background(), offset, limit)
+	return s.FilteredWalletEvents(context.Background(), api.WalletEventsOpts{
+		Offset: offset,
+		Limit:  limit,
+	})
+}
+
+// FilteredWalletEvents returns a paginated list of events that match the
+// given filters, ordered by timestamp, descending.
+func (s *SQLStore) FilteredWalletEvents(ctx context.Context, opts api.WalletEventsOpts) (events []wallet.Event, err error) {
+	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) (err error) {
+		events, err = tx.WalletEvents(ctx, opts)
 		return
 	})
 	return
@@ -0,0 +1,83 @@
+package stores
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.sia.tech/core/types"
+	"go.sia.tech/coreutils/wallet"
+	"go.sia.tech/renterd/api"
+	"go.sia.tech/renterd/stores/sql"
+)
+
+func TestFilteredWalletEvents(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	// add events with increasing timestamps in a single block
+	now := time.Now().Round(time.Millisecond)
+	payout := func(id byte, value types.Currency, ts time.Time) wallet.Event {
+		return wallet.Event{
+			ID:    types.Hash256{id},
+			Index: types.ChainIndex{Height: 1},
+			Type:  wallet.EventTypeMinerPayout,
+			Data: wallet.EventPayout{
+				SiacoinElement: types.SiacoinElement{SiacoinOutput: types.SiacoinOutput{Value: value}},
+			},
+			Timestamp: ts,
+		}
+	}
+	events := []wallet.Event{
+		payout(1, types.Siacoins(1), now.Add(-3*time.Hour)),
+		payout(2, types.Siacoins(9), now.Add(-2*time.Hour)),
+		payout(3, types.Siacoins(10), now.Add(-time.Hour)),
+		{
+			ID:        types.Hash256{4},
+			Index:     types.ChainIndex{Height: 1},
+			Type:      wallet.EventTypeV2Transaction,
+			Data:      wallet.EventV2Transaction{},
+			Timestamp: now,
+		},
+	}
+	if err := ss.ProcessChainUpdate(context.Background(), func(tx sql.ChainUpdateTx) error {
+		return tx.WalletApplyIndex(types.ChainIndex{Height: 1}, nil, nil, events, time.Now())
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	assertEvents := func(opts api.WalletEventsOpts, expected ...byte) {
+		t.Helper()
+		events, err := ss.FilteredWalletEvents(context.Background(), opts)
+		if err != nil {
+			t.Fatal(err)
+		} else if len(events) != len(expected) {
+			t.Fatalf("expected %d events, got %d", len(expected), len(events))
+		}
+		for i, e := range events {
+			if e.ID != (types.Hash256{expected[i]}) {
+				t.Fatalf("unexpected event at index %d: %v", i, e.ID)
+			}
+		}
+	}
+
+	// assert events are sorted by timestamp, descending
+	assertEvents(api.WalletEventsOpts{}, 4, 3, 2, 1)
+	assertEvents(api.WalletEventsOpts{Offset: 1, Limit: 2}, 3, 2)
+
+	// assert the time range is applied
+	assertEvents(api.WalletEventsOpts{Since: now.Add(-2 * time.Hour)}, 4, 3, 2)
+	assertEvents(api.WalletEventsOpts{Until: now.Add(-2 * time.Hour)}, 1)
+	assertEvents(api.WalletEventsOpts{Since: now.Add(-3 * time.Hour), Until: now}, 3, 2, 1)
+
+	// assert the amount is compared numerically, 10SC has more digits than
+	// 9SC but is lexicographically smaller
+	assertEvents(api.WalletEventsOpts{MinAmount: types.Siacoins(9)}, 3, 2)
+	assertEvents(api.WalletEventsOpts{MinAmount: types.Siacoins(10)}, 3)
+
+	// assert the type is applied
+	assertEvents(api.WalletEventsOpts{Type: wallet.EventTypeV2Transaction}, 4)
+
+	// assert filters are combined
+	assertEvents(api.WalletEventsOpts{Type: wallet.EventTypeMinerPayout, MinAmount: types.Siacoins(5), Limit: 1}, 3)
+}
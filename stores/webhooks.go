@@ -2,9 +2,23 @@ package stores
 
 import (
 	"context"
+	"fmt"
+	"time"
 
+	"go.sia.tech/renterd/alerts"
 	sql "go.sia.tech/renterd/stores/sql"
 	"go.sia.tech/renterd/webhooks"
+	"lukechampine.com/frand"
+)
+
+const (
+	// webhookDeadLetterAlertThreshold is the number of dead letters in the
+	// queue after which an alert is registered.
+	webhookDeadLetterAlertThreshold = 100
+)
+
+var (
+	webhookDeadLettersAlertID = frand.Entropy256()
 )
 
 func (s *SQLStore) AddWebhook(ctx context.Context, wh webhooks.Webhook) error {
@@ -26,3 +40,94 @@ func (s *SQLStore) Webhooks(ctx context.Context) (whs []webhooks.Webhook, err er
 	})
 	return
 }
+
+func (s *SQLStore) AddWebhookDeadLetter(ctx context.Context, dl webhooks.DeadLetter) error {
+	var depth uint64
+	err := s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+		err := tx.AddWebhookDeadLetter(ctx, dl)
+		if err != nil {
+			return err
+		}
+		depth, err = tx.WebhookDeadLetterCount(ctx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	s.updateWebhookDeadLettersAlert(ctx, depth)
+	return nil
+}
+
+func (s *SQLStore) DeleteWebhookDeadLetter(ctx context.Context, id int64) error {
+	var depth uint64
+	err := s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+		err := tx.DeleteWebhookDeadLetter(ctx, id)
+		if err != nil {
+			return err
+		}
+		depth, err = tx.WebhookDeadLetterCount(ctx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	s.updateWebhookDeadLettersAlert(ctx, depth)
+	return nil
+}
+
+func (s *SQLStore) PruneWebhookDeadLetters(ctx context.Context, now time.Time) (n int64, err error) {
+	var depth uint64
+	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+		n, err = tx.PruneWebhookDeadLetters(ctx, now)
+		if err != nil {
+			return err
+		}
+		depth, err = tx.WebhookDeadLetterCount(ctx)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	s.updateWebhookDeadLettersAlert(ctx, depth)
+	return
+}
+
+func (s *SQLStore) UpdateWebhookDeadLetter(ctx context.Context, dl webhooks.DeadLetter) error {
+	return s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+		return tx.UpdateWebhookDeadLetter(ctx, dl)
+	})
+}
+
+func (s *SQLStore) WebhookDeadLetter(ctx context.Context, id int64) (dl webhooks.DeadLetter, err error) {
+	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+		dl, err = tx.WebhookDeadLetter(ctx, id)
+		return err
+	})
+	return
+}
+
+func (s *SQLStore) WebhookDeadLetters(ctx context.Context) (dls []webhooks.DeadLetter, err error) {
+	err = s.db.Transaction(ctx, func(tx sql.DatabaseTx) error {
+		dls, err = tx.WebhookDeadLetters(ctx)
+		return err
+	})
+	return
+}
+
+func (s *SQLStore) updateWebhookDeadLettersAlert(ctx context.Context, depth uint64) {
+	if depth <= webhookDeadLetterAlertThreshold {
+		s.alerts.DismissAlerts(ctx, webhookDeadLettersAlertID)
+		return
+	}
+	s.alerts.RegisterAlert(ctx, alerts.Alert{
+		ID:       webhookDeadLettersAlertID,
+		Severity: alerts.SeverityWarning,
+		Message:  "Webhook dead letter queue is growing",
+		Data: map[string]any{
+			"depth":     depth,
+			"threshold": webhookDeadLetterAlertThreshold,
+			"hint":      fmt.Sprintf("There are %d webhook events that could not be delivered, make sure the registered webhooks are reachable", depth),
+		},
+		Timestamp: time.Now(),
+	})
+}
@@ -2,7 +2,9 @@ package stores
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"go.sia.tech/renterd/webhooks"
@@ -83,3 +85,82 @@ func TestWebhooks(t *testing.T) {
 		t.Fatal("unexpected webhook", cmp.Diff(whs[0], wh2))
 	}
 }
+
+func TestWebhookDeadLetters(t *testing.T) {
+	ss := newTestSQLStore(t, defaultTestSQLStoreConfig)
+	defer ss.Close()
+
+	wh := webhooks.Webhook{
+		Module:  "foo",
+		Event:   "bar",
+		URL:     "http://example.com",
+		Headers: map[string]string{},
+	}
+	dl := webhooks.DeadLetter{
+		Webhook:      wh,
+		Event:        webhooks.Event{Module: "foo", Event: "bar", Payload: "baz"},
+		FailureCount: 3,
+		LastError:    "connection refused",
+		ExpiresAt:    time.Now().Add(time.Hour).Round(time.Millisecond),
+	}
+
+	// adding a dead letter for an unknown webhook should fail
+	if err := ss.AddWebhookDeadLetter(context.Background(), dl); !errors.Is(err, webhooks.ErrWebhookNotFound) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// add the webhook and the dead letter
+	if err := ss.AddWebhook(context.Background(), wh); err != nil {
+		t.Fatal(err)
+	} else if err := ss.AddWebhookDeadLetter(context.Background(), dl); err != nil {
+		t.Fatal(err)
+	}
+	dls, err := ss.WebhookDeadLetters(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	} else if len(dls) != 1 {
+		t.Fatal("expected 1 dead letter", len(dls))
+	}
+	dl.ID = dls[0].ID
+	if !cmp.Equal(dls[0], dl) {
+		t.Fatal("unexpected dead letter", cmp.Diff(dls[0], dl))
+	}
+
+	// update it
+	dl.FailureCount++
+	dl.LastError = "timeout"
+	if err := ss.UpdateWebhookDeadLetter(context.Background(), dl); err != nil {
+		t.Fatal(err)
+	} else if got, err := ss.WebhookDeadLetter(context.Background(), dl.ID); err != nil {
+		t.Fatal(err)
+	} else if !cmp.Equal(got, dl) {
+		t.Fatal("unexpected dead letter", cmp.Diff(got, dl))
+	}
+
+	// pruning before the expiry should be a no-op
+	if n, err := ss.PruneWebhookDeadLetters(context.Background(), time.Now()); err != nil {
+		t.Fatal(err)
+	} else if n != 0 {
+		t.Fatal("expected no dead letters to be pruned", n)
+	}
+
+	// pruning after the expiry should remove it
+	if n, err := ss.PruneWebhookDeadLetters(context.Background(), dl.ExpiresAt); err != nil {
+		t.Fatal(err)
+	} else if n != 1 {
+		t.Fatal("expected 1 dead letter to be pruned", n)
+	} else if _, err := ss.WebhookDeadLetter(context.Background(), dl.ID); !errors.Is(err, webhooks.ErrDeadLetterNotFound) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// deleting the webhook should remove its dead letters
+	if err := ss.AddWebhookDeadLetter(context.Background(), dl); err != nil {
+		t.Fatal(err)
+	} else if err := ss.DeleteWebhook(context.Background(), wh); err != nil {
+		t.Fatal(err)
+	} else if dls, err := ss.WebhookDeadLetters(context.Background()); err != nil {
+		t.Fatal(err)
+	} else if len(dls) != 0 {
+		t.Fatal("expected no dead letters", len(dls))
+	}
+}
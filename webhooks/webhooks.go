@@ -15,13 +15,28 @@ import (
 	"go.uber.org/zap"
 )
 
-var ErrWebhookNotFound = errors.New("Webhook not found")
+var (
+	ErrDeadLetterNotFound = errors.New("dead letter not found")
+	ErrWebhookNotFound    = errors.New("Webhook not found")
+
+	// errDeliveryAborted is the error recorded for events that are added to
+	// the dead letter queue because they couldn't be delivered before
+	// shutting down.
+	errDeliveryAborted = errors.New("delivery aborted due to shutdown")
+)
 
 type (
 	WebhookStore interface {
 		DeleteWebhook(ctx context.Context, wh Webhook) error
 		AddWebhook(ctx context.Context, wh Webhook) error
 		Webhooks(ctx context.Context) ([]Webhook, error)
+
+		AddWebhookDeadLetter(ctx context.Context, dl DeadLetter) error
+		DeleteWebhookDeadLetter(ctx context.Context, id int64) error
+		PruneWebhookDeadLetters(ctx context.Context, now time.Time) (int64, error)
+		UpdateWebhookDeadLetter(ctx context.Context, dl DeadLetter) error
+		WebhookDeadLetter(ctx context.Context, id int64) (DeadLetter, error)
+		WebhookDeadLetters(ctx context.Context) ([]DeadLetter, error)
 	}
 
 	Broadcaster interface {
@@ -44,6 +59,18 @@ func (NoopBroadcaster) BroadcastAction(_ context.Context, _ Event) error { retur
 const (
 	webhookTimeout   = 10 * time.Second
 	WebhookEventPing = "ping"
+
+	// DefaultDeadLetterTTL is the default amount of time a failed delivery is
+	// kept in the dead letter queue before it is purged.
+	DefaultDeadLetterTTL = 7 * 24 * time.Hour
+
+	deadLetterPruneInterval = time.Hour
+	deliveryMaxAttempts     = 3
+	deliveryRetryInterval   = 5 * time.Second
+
+	// subscriberBufferSize is the number of events buffered for an in-process
+	// subscriber before events are dropped.
+	subscriberBufferSize = 100
 )
 
 type (
@@ -65,19 +92,45 @@ type (
 		Event   string      `json:"event"`
 		Payload interface{} `json:"payload,omitempty"`
 	}
+
+	// DeadLetter describes an event that couldn't be delivered to a webhook
+	// after exhausting all delivery attempts.
+	DeadLetter struct {
+		ID           int64     `json:"id"`
+		Webhook      Webhook   `json:"webhook"`
+		Event        Event     `json:"event"`
+		FailureCount int       `json:"failureCount"`
+		LastError    string    `json:"lastError"`
+		ExpiresAt    time.Time `json:"expiresAt"`
+	}
 )
 
 type Manager struct {
-	logger *zap.SugaredLogger
-	wg     sync.WaitGroup
-	store  WebhookStore
+	deadLetterTTL time.Duration
+	logger        *zap.SugaredLogger
+	wg            sync.WaitGroup
+	queuesWG      sync.WaitGroup
+	store         WebhookStore
+
+	// deliveryCtx is cancelled when the queues failed to drain before the
+	// shutdown deadline, the remaining events are added to the dead letter
+	// queue
+	deliveryCtx       context.Context
+	deliveryCtxCancel context.CancelFunc
 
 	shutdownCtx       context.Context
 	shutdownCtxCancel context.CancelFunc
 
-	mu       sync.Mutex
-	queues   map[string]*eventQueue // URL -> queue
-	webhooks map[string]Webhook
+	mu               sync.Mutex
+	queues           map[string]*eventQueue // URL -> queue
+	webhooks         map[string]Webhook
+	subscribers      map[uint64]subscriber
+	nextSubscriberID uint64
+}
+
+type subscriber struct {
+	filter Webhook
+	c      chan Event
 }
 
 type eventQueue struct {
@@ -86,14 +139,35 @@ type eventQueue struct {
 	headers map[string]string
 	url     string
 
+	deadLetterFn func(Webhook, Event, int, error)
+
 	mu           sync.Mutex
 	isDequeueing bool
-	events       []Event
+	events       []queuedEvent
+}
+
+type queuedEvent struct {
+	hook  Webhook
+	event Event
 }
 
 func (m *Manager) BroadcastAction(_ context.Context, event Event) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+
+	// notify in-process subscribers, a slow subscriber mustn't block the
+	// broadcast so events are dropped if its buffer is full
+	for _, sub := range m.subscribers {
+		if !sub.filter.Matches(event) {
+			continue
+		}
+		select {
+		case sub.c <- event:
+		default:
+			m.logger.Warnf("dropping event %v for slow subscriber", event.String())
+		}
+	}
+
 	for _, hook := range m.webhooks {
 		if !hook.Matches(event) {
 			continue
@@ -103,23 +177,25 @@ func (m *Manager) BroadcastAction(_ context.Context, event Event) error {
 		queue, exists := m.queues[hook.URL]
 		if !exists {
 			queue = &eventQueue{
-				ctx:     m.shutdownCtx,
+				ctx:     m.deliveryCtx,
 				logger:  m.logger,
 				headers: hook.Headers,
 				url:     hook.URL,
+
+				deadLetterFn: m.addDeadLetter,
 			}
 			m.queues[hook.URL] = queue
 		}
 
 		// Add event and launch goroutine to start dequeueing if necessary.
 		queue.mu.Lock()
-		queue.events = append(queue.events, event)
+		queue.events = append(queue.events, queuedEvent{hook: hook, event: event})
 		if !queue.isDequeueing {
 			queue.isDequeueing = true
-			m.wg.Add(1)
+			m.queuesWG.Add(1)
 			go func() {
 				queue.dequeue()
-				m.wg.Done()
+				m.queuesWG.Done()
 			}()
 		}
 		queue.mu.Unlock()
@@ -127,6 +203,11 @@ func (m *Manager) BroadcastAction(_ context.Context, event Event) error {
 	return nil
 }
 
+// DeadLetters returns all events that couldn't be delivered to their webhook.
+func (m *Manager) DeadLetters(ctx context.Context) ([]DeadLetter, error) {
+	return m.store.WebhookDeadLetters(ctx)
+}
+
 func (m *Manager) Delete(ctx context.Context, wh Webhook) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -179,23 +260,122 @@ func (m *Manager) Register(ctx context.Context, wh Webhook) error {
 	return nil
 }
 
+// Subscribe returns a channel that receives all events of the given module that
+// are broadcast from now on, an empty event matches all events of the module.
+// The returned function unsubscribes and closes the channel, the channel is
+// also closed when the manager is shut down.
+func (m *Manager) Subscribe(module, event string) (<-chan Event, func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := m.nextSubscriberID
+	m.nextSubscriberID++
+	sub := subscriber{
+		filter: Webhook{Module: module, Event: event},
+		c:      make(chan Event, subscriberBufferSize),
+	}
+	m.subscribers[id] = sub
+
+	return sub.c, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if _, ok := m.subscribers[id]; ok {
+			delete(m.subscribers, id)
+			close(sub.c)
+		}
+	}
+}
+
+// RetryDeadLetter attempts to deliver the dead letter with the given id. On
+// success the dead letter is removed from the queue, otherwise its failure
+// count and last error are updated.
+func (m *Manager) RetryDeadLetter(ctx context.Context, id int64) error {
+	dl, err := m.store.WebhookDeadLetter(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = sendEvent(ctx, dl.Webhook.URL, dl.Webhook.Headers, dl.Event)
+	if err != nil {
+		dl.FailureCount++
+		dl.LastError = err.Error()
+		dl.ExpiresAt = time.Now().Add(m.deadLetterTTL)
+		if err := m.store.UpdateWebhookDeadLetter(ctx, dl); err != nil {
+			m.logger.Errorf("failed to update dead letter %v: %v", id, err)
+		}
+		return fmt.Errorf("failed to deliver dead letter %v: %w", id, err)
+	}
+	return m.store.DeleteWebhookDeadLetter(ctx, id)
+}
+
+// Shutdown closes all subscriptions and waits for the queued events to be
+// delivered. Events that are still queued when the context is done are added
+// to the dead letter queue instead.
 func (m *Manager) Shutdown(ctx context.Context) error {
-	m.shutdownCtxCancel()
+	// close all subscriptions
+	m.mu.Lock()
+	for id, sub := range m.subscribers {
+		delete(m.subscribers, id)
+		close(sub.c)
+	}
+	m.mu.Unlock()
 
-	waitChan := make(chan struct{})
+	// drain the queues
+	drained := make(chan struct{})
 	go func() {
-		m.wg.Wait()
-		close(waitChan)
+		m.queuesWG.Wait()
+		close(drained)
 	}()
 
 	select {
 	case <-ctx.Done():
-		return ctx.Err()
-	case <-waitChan:
+		m.logger.Warn("failed to deliver all queued events before shutting down, remaining events are added to the dead letter queue")
+		m.deliveryCtxCancel()
+		<-drained
+	case <-drained:
+		m.deliveryCtxCancel()
 	}
+
+	m.shutdownCtxCancel()
+	m.wg.Wait()
 	return nil
 }
 
+func (m *Manager) addDeadLetter(hook Webhook, event Event, failures int, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+
+	if err := m.store.AddWebhookDeadLetter(ctx, DeadLetter{
+		Webhook:      hook,
+		Event:        event,
+		FailureCount: failures,
+		LastError:    err.Error(),
+		ExpiresAt:    time.Now().Add(m.deadLetterTTL),
+	}); err != nil {
+		m.logger.Errorf("failed to add Webhook event %v to the dead letter queue: %v", event.String(), err)
+	}
+}
+
+func (m *Manager) pruneDeadLettersLoop() {
+	t := time.NewTicker(deadLetterPruneInterval)
+	defer t.Stop()
+
+	for {
+		n, err := m.store.PruneWebhookDeadLetters(m.shutdownCtx, time.Now())
+		if err != nil && !errors.Is(err, context.Canceled) {
+			m.logger.Errorf("failed to prune dead letters: %v", err)
+		} else if n > 0 {
+			m.logger.Debugf("pruned %d expired dead letters", n)
+		}
+
+		select {
+		case <-m.shutdownCtx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
 func (a Event) String() string {
 	return a.Module + "." + a.Event
 }
@@ -212,9 +392,28 @@ func (q *eventQueue) dequeue() {
 		q.events = q.events[1:]
 		q.mu.Unlock()
 
-		err := sendEvent(q.ctx, q.url, q.headers, next)
+		var err error
+		var failures int
+		for failures < deliveryMaxAttempts {
+			if failures > 0 {
+				select {
+				case <-q.ctx.Done():
+				case <-time.After(deliveryRetryInterval * time.Duration(failures)):
+				}
+			}
+			if q.ctx.Err() != nil {
+				if err == nil {
+					err = errDeliveryAborted
+				}
+				break
+			} else if err = sendEvent(q.ctx, q.url, q.headers, next.event); err == nil {
+				break
+			}
+			failures++
+		}
 		if err != nil {
-			q.logger.Errorf("failed to send Webhook event %v to %v: %v", next.String(), q.url, err)
+			q.logger.Errorf("failed to send Webhook event %v to %v: %v", next.event.String(), q.url, err)
+			q.deadLetterFn(next.hook, next.event, failures, err)
 		}
 	}
 }
@@ -230,17 +429,27 @@ func (w Webhook) String() string {
 	return fmt.Sprintf("%v.%v.%v", w.URL, w.Module, w.Event)
 }
 
-func NewManager(store WebhookStore, logger *zap.Logger) (*Manager, error) {
+func NewManager(store WebhookStore, deadLetterTTL time.Duration, logger *zap.Logger) (*Manager, error) {
+	if deadLetterTTL == 0 {
+		deadLetterTTL = DefaultDeadLetterTTL
+	}
+
+	deliveryCtx, deliveryCtxCancel := context.WithCancel(context.Background())
 	shutdownCtx, shutdownCtxCancel := context.WithCancel(context.Background())
 	m := &Manager{
-		logger: logger.Named("webhooks").Sugar(),
-		store:  store,
+		deadLetterTTL: deadLetterTTL,
+		logger:        logger.Named("webhooks").Sugar(),
+		store:         store,
+
+		deliveryCtx:       deliveryCtx,
+		deliveryCtxCancel: deliveryCtxCancel,
 
 		shutdownCtx:       shutdownCtx,
 		shutdownCtxCancel: shutdownCtxCancel,
 
-		queues:   make(map[string]*eventQueue),
-		webhooks: make(map[string]Webhook),
+		queues:      make(map[string]*eventQueue),
+		webhooks:    make(map[string]Webhook),
+		subscribers: make(map[uint64]subscriber),
 	}
 	hooks, err := store.Webhooks(shutdownCtx)
 	if err != nil {
@@ -249,6 +458,12 @@ func NewManager(store WebhookStore, logger *zap.Logger) (*Manager, error) {
 	for _, hook := range hooks {
 		m.webhooks[hook.String()] = hook
 	}
+
+	m.wg.Add(1)
+	go func() {
+		m.pruneDeadLettersLoop()
+		m.wg.Done()
+	}()
 	return m, nil
 }
 
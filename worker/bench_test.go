@@ -33,7 +33,7 @@ func BenchmarkDownloaderSingleObject(b *testing.B) {
 	w.AddHosts(up.RS.TotalShards)
 
 	data := bytes.NewReader(frand.Bytes(int(up.RS.SlabSizeNoRedundancy())))
-	_, _, err := w.uploadManager.Upload(context.Background(), data, w.UploadHosts(), up)
+	_, _, _, err := w.uploadManager.Upload(context.Background(), data, w.UploadHosts(), up)
 	if err != nil {
 		b.Fatal(err)
 	}
@@ -69,7 +69,7 @@ func BenchmarkUploaderSingleObject(b *testing.B) {
 	b.SetBytes(int64(rhpv2.SectorSize * up.RS.MinShards))
 	b.ResetTimer()
 
-	_, _, err := w.uploadManager.Upload(context.Background(), data, w.UploadHosts(), up)
+	_, _, _, err := w.uploadManager.Upload(context.Background(), data, w.UploadHosts(), up)
 	if err != nil {
 		b.Fatal(err)
 	}
@@ -93,7 +93,7 @@ func BenchmarkUploaderMultiObject(b *testing.B) {
 
 	for i := 0; i < b.N; i++ {
 		data := io.LimitReader(&zeroReader{}, int64(rhpv2.SectorSize*up.RS.MinShards))
-		_, _, err := w.uploadManager.Upload(context.Background(), data, w.UploadHosts(), up)
+		_, _, _, err := w.uploadManager.Upload(context.Background(), data, w.UploadHosts(), up)
 		if err != nil {
 			b.Fatal(err)
 		}
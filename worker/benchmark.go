@@ -0,0 +1,119 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	rhpv2 "go.sia.tech/core/rhp/v2"
+	"go.sia.tech/core/types"
+	"go.sia.tech/renterd/api"
+	"go.sia.tech/renterd/internal/gouging"
+	"go.uber.org/zap"
+	"lukechampine.com/frand"
+)
+
+type (
+	// ttfbWriter is an io.Writer that records the time of the first write.
+	ttfbWriter struct {
+		w     io.Writer
+		first time.Time
+	}
+)
+
+func (tw *ttfbWriter) Write(p []byte) (int, error) {
+	if tw.first.IsZero() && len(p) > 0 {
+		tw.first = time.Now()
+	}
+	return tw.w.Write(p)
+}
+
+// benchmarkHost uploads a random sector to the host and downloads it again to
+// measure the host's throughput and time to first byte. Successful benchmarks
+// are recorded in the host's benchmark history. Failed benchmarks are not
+// recorded since they might be our fault, e.g. due to an empty account. The
+// uploaded sector isn't referenced by any slab and will eventually be pruned
+// from the contract.
+func (w *Worker) benchmarkHost(ctx context.Context, hk types.PublicKey) (api.HostBenchmarkResult, error) {
+	// fetch the host
+	h, err := w.bus.Host(ctx, hk)
+	if err != nil {
+		return api.HostBenchmarkResult{}, fmt.Errorf("couldn't fetch host: %w", err)
+	}
+
+	// find a contract to upload the sector to
+	contracts, err := w.bus.Contracts(ctx, api.ContractsOpts{FilterMode: api.ContractFilterModeGood})
+	if err != nil {
+		return api.HostBenchmarkResult{}, fmt.Errorf("couldn't fetch contracts from bus: %w", err)
+	}
+	var fcid types.FileContractID
+	for _, c := range contracts {
+		if c.HostKey == hk {
+			fcid = c.ID
+			break
+		}
+	}
+	if fcid == (types.FileContractID{}) {
+		return api.HostBenchmarkResult{}, fmt.Errorf("%w: no good contract with host %v", api.ErrContractNotFound, hk)
+	}
+
+	// attach gouging checker
+	gp, err := w.bus.GougingParams(ctx)
+	if err != nil {
+		return api.HostBenchmarkResult{}, fmt.Errorf("couldn't get gouging parameters; %w", err)
+	}
+	ctx = gouging.WithChecker(ctx, w.bus, gp)
+
+	// prepare a random sector
+	var sector [rhpv2.SectorSize]byte
+	frand.Read(sector[:])
+	root := rhpv2.SectorRoot(&sector)
+
+	// upload the sector
+	var uploadDuration time.Duration
+	err = w.withContractLock(ctx, fcid, lockingPriorityBenchmark, func() error {
+		start := time.Now()
+		if err := w.hostManager.Uploader(h.Info(), fcid).UploadSector(ctx, root, &sector); err != nil {
+			return err
+		}
+		uploadDuration = time.Since(start)
+		return nil
+	})
+	if err != nil {
+		return api.HostBenchmarkResult{}, fmt.Errorf("failed to upload sector: %w", err)
+	}
+
+	// download the sector
+	tw := &ttfbWriter{w: io.Discard}
+	start := time.Now()
+	if err := w.hostManager.Downloader(h.Info()).DownloadSector(ctx, tw, root, 0, rhpv2.SectorSize); err != nil {
+		return api.HostBenchmarkResult{}, fmt.Errorf("failed to download sector: %w", err)
+	}
+	downloadDuration := time.Since(start)
+
+	res := api.HostBenchmarkResult{
+		UploadMBps:   mbps(rhpv2.SectorSize, uploadDuration),
+		DownloadMBps: mbps(rhpv2.SectorSize, downloadDuration),
+		TTFB:         api.DurationMS(tw.first.Sub(start)),
+	}
+
+	// record the benchmark
+	if err := w.bus.RecordHostBenchmark(ctx, hk, api.HostBenchmark{
+		Timestamp:    time.Now(),
+		UploadMBps:   res.UploadMBps,
+		DownloadMBps: res.DownloadMBps,
+		TTFB:         res.TTFB,
+	}); err != nil {
+		w.logger.With(zap.Error(err)).Errorw("failed to record host benchmark", "hostKey", hk)
+	}
+	return res, nil
+}
+
+// mbps returns the throughput in megabytes per second.
+func mbps(n uint64, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(n) / 1e6 / d.Seconds()
+}
@@ -141,6 +141,33 @@ func (c *Client) GetObject(ctx context.Context, bucket, key string, opts api.Dow
 	}, nil
 }
 
+// RepairStatus returns the progress of the worker's sector verification.
+func (c *Client) RepairStatus(ctx context.Context) (resp api.SectorRepairStatus, err error) {
+	err = c.c.WithContext(ctx).GET("/repair/status", &resp)
+	return
+}
+
+// BenchmarkHost uploads and downloads a single sector to and from the given
+// host to measure its throughput.
+func (c *Client) BenchmarkHost(ctx context.Context, hostKey types.PublicKey) (res api.HostBenchmarkResult, err error) {
+	err = c.c.WithContext(ctx).POST(fmt.Sprintf("/host/%s/benchmark", hostKey), nil, &res)
+	return
+}
+
+// UpdateHostBandwidthLimits updates the bandwidth limits for connections with
+// the given host, limits are in bytes per second and 0 means unlimited.
+func (c *Client) UpdateHostBandwidthLimits(ctx context.Context, hostKey types.PublicKey, limits api.HostBandwidthLimits) (err error) {
+	err = c.c.WithContext(ctx).PUT(fmt.Sprintf("/hosts/%s/bandwidth", hostKey), limits)
+	return
+}
+
+// DialerCacheStats returns the stats of the worker dialer's cache of resolved
+// host IPs.
+func (c *Client) DialerCacheStats(ctx context.Context) (resp api.DialerCacheStats, err error) {
+	err = c.c.WithContext(ctx).GET("/dialer/cache", &resp)
+	return
+}
+
 // Memory requests the /memory endpoint.
 func (c *Client) Memory(ctx context.Context) (resp api.MemoryResponse, err error) {
 	err = c.c.WithContext(ctx).GET("/memory", &resp)
@@ -226,6 +253,13 @@ func (c *Client) UploadObject(ctx context.Context, r io.Reader, bucket, key stri
 	return &api.UploadObjectResponse{ETag: header.Get("ETag")}, nil
 }
 
+// UploadLatency returns the latency percentiles of every stage in the upload
+// pipeline.
+func (c *Client) UploadLatency(ctx context.Context) (resp api.UploadLatencyResponse, err error) {
+	err = c.c.WithContext(ctx).GET("/upload/latency", &resp)
+	return
+}
+
 // UploadStats returns the upload stats.
 func (c *Client) UploadStats() (resp api.UploadStatsResponse, err error) {
 	err = c.c.GET("/stats/uploads", &resp)
@@ -235,6 +269,7 @@ func (c *Client) UploadStats() (resp api.UploadStatsResponse, err error) {
 func (c *Client) object(ctx context.Context, bucket, key string, opts api.DownloadObjectOptions) (_ io.ReadCloser, _ http.Header, err error) {
 	values := url.Values{}
 	values.Set("bucket", bucket)
+	opts.Apply(values)
 	key += "?" + values.Encode()
 
 	c.c.Custom("GET", fmt.Sprintf("/object/%s", key), nil, (*[]api.ObjectMetadata)(nil))
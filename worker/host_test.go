@@ -28,6 +28,7 @@ type (
 		hptFn       func() api.HostPriceTable
 		pFn         func() rhpv4.HostPrices
 		uploadDelay time.Duration
+		uploadErrFn func() error
 	}
 
 	testHostManager struct {
@@ -124,11 +125,23 @@ func (h *testHost) DownloadSector(ctx context.Context, w io.Writer, root types.H
 	if offset+length > rhpv2.SectorSize {
 		return mocks.ErrSectorOutOfBounds
 	}
-	_, err := w.Write(sector[offset : offset+length])
+
+	// serve a range proof and verify it like a real download would
+	data := sector[offset : offset+length]
+	proof := rhpv2.BuildProof(sector, offset/rhpv2.LeafSize, (offset+length)/rhpv2.LeafSize, nil)
+	if err := rhp3.VerifyRangeProof(data, proof, offset, length, root); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
 	return err
 }
 
 func (h *testHost) UploadSector(ctx context.Context, sectorRoot types.Hash256, sector *[rhpv2.SectorSize]byte) error {
+	if h.uploadErrFn != nil {
+		if err := h.uploadErrFn(); err != nil {
+			return err
+		}
+	}
 	h.Contract.AddSector(sectorRoot, sector)
 	if h.uploadDelay > 0 {
 		select {
@@ -0,0 +1,182 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	rhpv2 "go.sia.tech/core/rhp/v2"
+	"go.sia.tech/core/types"
+	rhp4 "go.sia.tech/coreutils/rhp/v4"
+	"go.sia.tech/renterd/api"
+	"go.sia.tech/renterd/internal/gouging"
+	"go.sia.tech/renterd/internal/hosts"
+	rhp3 "go.sia.tech/renterd/internal/rhp/v3"
+	"go.sia.tech/renterd/internal/utils"
+	"go.uber.org/zap"
+	"lukechampine.com/frand"
+)
+
+// sectorVerificationBatchSize is the number of sectors that are downloaded
+// and verified every time the sector verifier runs.
+const sectorVerificationBatchSize = 10
+
+type (
+	// sectorVerifier periodically downloads a random sample of sectors from
+	// the hosts we have contracts with and verifies them against their Merkle
+	// root. Sectors that are corrupted or lost are marked as lost on the host,
+	// which lowers the health of the slabs they belong to and causes them to
+	// be migrated.
+	sectorVerifier struct {
+		bus      Bus
+		hm       hosts.Manager
+		interval time.Duration
+		logger   *zap.SugaredLogger
+
+		mu     sync.Mutex
+		status api.SectorRepairStatus
+	}
+)
+
+// VerifySector returns true if the Merkle root of the given sector data
+// matches the given root.
+func VerifySector(root types.Hash256, data *[rhpv2.SectorSize]byte) bool {
+	return rhpv2.SectorRoot(data) == root
+}
+
+func newSectorVerifier(b Bus, hm hosts.Manager, interval time.Duration, logger *zap.Logger) *sectorVerifier {
+	return &sectorVerifier{
+		bus:      b,
+		hm:       hm,
+		interval: interval,
+		logger:   logger.Named("sectorverifier").Sugar(),
+
+		status: api.SectorRepairStatus{
+			Enabled: interval > 0,
+		},
+	}
+}
+
+// Run verifies a batch of sectors every interval until the context is
+// cancelled. It returns immediately if the interval is zero.
+func (sv *sectorVerifier) Run(ctx context.Context) {
+	if sv.interval <= 0 {
+		return
+	}
+
+	t := time.NewTicker(sv.interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+
+		if err := sv.verifySectors(ctx); errors.Is(err, context.Canceled) {
+			return
+		} else if err != nil {
+			sv.logger.Errorw("failed to verify sectors", zap.Error(err))
+		}
+	}
+}
+
+// Status returns the progress of the sector verification.
+func (sv *sectorVerifier) Status() api.SectorRepairStatus {
+	sv.mu.Lock()
+	defer sv.mu.Unlock()
+	return sv.status
+}
+
+// verifySectors downloads and verifies a random sample of the sectors stored
+// in a random contract with a usable host.
+func (sv *sectorVerifier) verifySectors(ctx context.Context) error {
+	// attach gouging checker
+	gp, err := sv.bus.GougingParams(ctx)
+	if err != nil {
+		return fmt.Errorf("couldn't get gouging parameters; %w", err)
+	}
+	ctx = gouging.WithChecker(ctx, sv.bus, gp)
+
+	// fetch usable hosts
+	usableHosts, err := sv.bus.UsableHosts(ctx)
+	if err != nil {
+		return fmt.Errorf("couldn't fetch usable hosts: %w", err)
+	}
+	usable := make(map[types.PublicKey]api.HostInfo)
+	for _, h := range usableHosts {
+		usable[h.PublicKey] = h
+	}
+
+	// pick a random contract with a usable host
+	contracts, err := sv.bus.Contracts(ctx, api.ContractsOpts{FilterMode: api.ContractFilterModeGood})
+	if err != nil {
+		return fmt.Errorf("couldn't fetch contracts: %w", err)
+	}
+	var candidates []api.ContractMetadata
+	for _, c := range contracts {
+		if _, ok := usable[c.HostKey]; ok {
+			candidates = append(candidates, c)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	c := candidates[frand.Intn(len(candidates))]
+
+	// sample the contract's sectors
+	roots, err := sv.bus.ContractRoots(ctx, c.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't fetch contract roots: %w", err)
+	}
+	sampled := make(map[int]struct{})
+	for len(sampled) < min(len(roots), sectorVerificationBatchSize) {
+		sampled[frand.Intn(len(roots))] = struct{}{}
+	}
+
+	// verify the sectors
+	downloader := sv.hm.Downloader(usable[c.HostKey])
+	var verified, corrupted, failed uint64
+	for i := range sampled {
+		root := roots[i]
+		buf := bytes.NewBuffer(make([]byte, 0, rhpv2.SectorSize))
+		err := downloader.DownloadSector(ctx, buf, root, 0, rhpv2.SectorSize)
+		if errors.Is(err, context.Canceled) {
+			return err
+		} else if err != nil && !rhp3.IsSectorNotFound(err) && !isInvalidProof(err) {
+			sv.logger.Debugw("failed to download sector", "hk", c.HostKey, "root", root, zap.Error(err))
+			failed++
+			continue
+		} else if err == nil && buf.Len() == rhpv2.SectorSize && VerifySector(root, (*[rhpv2.SectorSize]byte)(buf.Bytes())) {
+			verified++
+			continue
+		}
+
+		// the sector is either lost or corrupted, mark it as lost to trigger
+		// a migration of the slab it belongs to
+		sv.logger.Warnw("sector failed verification", "hk", c.HostKey, "fcid", c.ID, "root", root, "lost", rhp3.IsSectorNotFound(err))
+		corrupted++
+		if err := sv.bus.DeleteHostSector(ctx, c.HostKey, root); err != nil {
+			sv.logger.Errorw("failed to mark sector as lost", "hk", c.HostKey, "root", root, zap.Error(err))
+		}
+	}
+
+	// update the status
+	sv.mu.Lock()
+	sv.status.LastVerification = api.TimeRFC3339(time.Now())
+	sv.status.SectorsVerified += verified
+	sv.status.SectorsCorrupted += corrupted
+	sv.status.SectorsFailed += failed
+	sv.mu.Unlock()
+	return nil
+}
+
+// isInvalidProof returns true if the error indicates that the data returned by
+// a host doesn't match the root of the requested sector.
+func isInvalidProof(err error) bool {
+	return rhp3.IsInvalidProof(err) || utils.IsErr(err, rhp4.ErrInvalidProof)
+}
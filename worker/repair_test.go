@@ -0,0 +1,48 @@
+package worker
+
+import (
+	"context"
+	"testing"
+
+	"lukechampine.com/frand"
+)
+
+func TestVerifySectors(t *testing.T) {
+	// create test worker
+	w := newTestWorker(t, newTestWorkerCfg())
+
+	// add a host and store a healthy and a corrupted sector
+	h := w.AddHost()
+	sector, root := newTestSector()
+	h.AddSector(root, sector)
+
+	corrupted, corruptedRoot := newTestSector()
+	h.AddSector(corruptedRoot, corrupted)
+	corrupted[frand.Intn(len(corrupted))]++
+
+	// assert VerifySector detects the corruption
+	if !VerifySector(root, sector) {
+		t.Fatal("expected sector to be valid")
+	} else if VerifySector(corruptedRoot, corrupted) {
+		t.Fatal("expected sector to be invalid")
+	}
+
+	// verify the sectors
+	if err := w.sectorVerifier.verifySectors(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// assert the status
+	status := w.sectorVerifier.Status()
+	if status.Enabled {
+		t.Fatal("expected verification to be disabled")
+	} else if status.LastVerification.Std().IsZero() {
+		t.Fatal("expected last verification to be set")
+	} else if status.SectorsVerified != 1 {
+		t.Fatal("expected 1 verified sector", status.SectorsVerified)
+	} else if status.SectorsCorrupted != 1 {
+		t.Fatal("expected 1 corrupted sector", status.SectorsCorrupted)
+	} else if status.SectorsFailed != 0 {
+		t.Fatal("expected no failed sectors", status.SectorsFailed)
+	}
+}
@@ -30,10 +30,14 @@ type (
 		CreateBucket            bool
 		BucketExists            bool
 		DeleteBucket            bool
+		GetBucketLifecycle      bool
+		PutBucketLifecycle      bool
 		GetObject               bool
+		GetObjectTagging        bool
 		HeadObject              bool
 		DeleteObject            bool
 		PutObject               bool
+		PutObjectTagging        bool
 		DeleteMulti             bool
 		CopyObject              bool
 		CreateMultipartUpload   bool
@@ -59,10 +63,14 @@ var (
 		CreateBucket:            true,
 		BucketExists:            true,
 		DeleteBucket:            true,
+		GetBucketLifecycle:      true,
+		PutBucketLifecycle:      true,
 		GetObject:               true,
+		GetObjectTagging:        true,
 		HeadObject:              true,
 		DeleteObject:            true,
 		PutObject:               true,
+		PutObjectTagging:        true,
 		DeleteMulti:             true,
 		CopyObject:              true,
 		CreateMultipartUpload:   true,
@@ -7,7 +7,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"go.sia.tech/gofakes3"
 	"go.sia.tech/renterd/api"
@@ -21,6 +23,11 @@ const (
 
 	// maxKeysDefault is the default maxKeys value used in the AWS SDK
 	maxKeysDefault = 1000
+
+	// multipartUploadTTLHeader is a non-standard header that sets the TTL of a
+	// multipart upload in seconds, uploads that aren't completed within their
+	// TTL are aborted.
+	multipartUploadTTLHeader = "X-Amz-Sia-Multipart-Upload-Ttl"
 )
 
 var (
@@ -347,9 +354,19 @@ func (s *s3) PutObject(ctx context.Context, bucketName, key string, meta map[str
 		return gofakes3.PutObjectResult{}, gofakes3.ErrorMessage(gofakes3.ErrInternal, err.Error())
 	}
 
+	// fetch the version of the uploaded object, it's only set if versioning
+	// is enabled on the bucket
+	//
+	// NOTE: if the object was overwritten in the meantime, this might return
+	// the version of the newer object
+	obj, err := s.b.Object(ctx, bucketName, "/"+key, api.GetObjectOptions{OnlyMetadata: true, IgnoreSymlinks: true})
+	if err != nil {
+		s.logger.Warnw("failed to fetch version of uploaded object", "bucket", bucketName, "key", key, zap.Error(err))
+	}
+
 	return gofakes3.PutObjectResult{
 		ETag:      api.FormatETag(ur.ETag),
-		VersionID: "", // not supported
+		VersionID: gofakes3.VersionID(obj.VersionID),
 	}, nil
 }
 
@@ -376,10 +393,13 @@ func (s *s3) DeleteMulti(ctx context.Context, bucketName string, objects ...stri
 func (s *s3) CopyObject(ctx context.Context, srcBucket, srcKey, dstBucket, dstKey string, meta map[string]string) (gofakes3.CopyObjectResult, error) {
 	convertToSiaMetadataHeaders(meta)
 	obj, err := s.b.CopyObject(ctx, srcBucket, dstBucket, "/"+srcKey, "/"+dstKey, api.CopyObjectOptions{
-		MimeType: meta["Content-Type"],
-		Metadata: api.ExtractObjectUserMetadataFrom(meta),
+		MimeType:          meta["Content-Type"],
+		Metadata:          api.ExtractObjectUserMetadataFrom(meta),
+		IfSourceETagMatch: parseCopySourceIfMatch(meta),
 	})
-	if err != nil {
+	if utils.IsErr(err, api.ErrPreconditionFailed) {
+		return gofakes3.CopyObjectResult{}, copyPreconditionFailed(ctx, err.Error())
+	} else if err != nil {
 		return gofakes3.CopyObjectResult{}, gofakes3.ErrorMessage(gofakes3.ErrInternal, err.Error())
 	}
 
@@ -390,11 +410,21 @@ func (s *s3) CopyObject(ctx context.Context, srcBucket, srcKey, dstBucket, dstKe
 }
 
 func (s *s3) CreateMultipartUpload(ctx context.Context, bucket, key string, meta map[string]string) (gofakes3.UploadID, error) {
+	var ttl time.Duration
+	if v, ok := meta[multipartUploadTTLHeader]; ok {
+		secs, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return "", gofakes3.ErrorMessagef(gofakes3.ErrInvalidArgument, "invalid %s header '%s'", multipartUploadTTLHeader, v)
+		}
+		ttl = time.Duration(secs) * time.Second
+	}
+
 	convertToSiaMetadataHeaders(meta)
 	resp, err := s.b.CreateMultipartUpload(ctx, bucket, "/"+key, api.CreateMultipartOptions{
 		DisableClientSideEncryption: true,
 		MimeType:                    meta["Content-Type"],
 		Metadata:                    api.ExtractObjectUserMetadataFrom(meta),
+		TTL:                         ttl,
 	})
 	if err != nil {
 		return "", gofakes3.ErrorMessage(gofakes3.ErrInternal, err.Error())
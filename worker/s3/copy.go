@@ -0,0 +1,69 @@
+package s3
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"go.sia.tech/gofakes3"
+)
+
+// errPreconditionFailed is the S3 error code returned when the precondition of
+// a conditional copy isn't met, gofakes3 doesn't define it.
+const errPreconditionFailed gofakes3.ErrorCode = "PreconditionFailed"
+
+type (
+	preconditionFailedKey struct{}
+
+	// copyResponseWriter replaces the status code of a response with 412
+	// Precondition Failed if the backend marked the copy's precondition as
+	// failed. gofakes3 responds with 500 for error codes it doesn't know.
+	copyResponseWriter struct {
+		http.ResponseWriter
+		failed *atomic.Bool
+	}
+)
+
+func (w *copyResponseWriter) WriteHeader(code int) {
+	if code == http.StatusInternalServerError && w.failed.Load() {
+		code = http.StatusPreconditionFailed
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// newCopyPreconditionHandler returns a handler that passes all requests on to
+// the given handler, conditional copy requests are served with a response
+// writer that responds with the correct status code if the copy's
+// precondition failed.
+func newCopyPreconditionHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPut || req.Header.Get("X-Amz-Copy-Source-If-Match") == "" {
+			next.ServeHTTP(w, req)
+			return
+		}
+		failed := new(atomic.Bool)
+		ctx := context.WithValue(req.Context(), preconditionFailedKey{}, failed)
+		next.ServeHTTP(&copyResponseWriter{ResponseWriter: w, failed: failed}, req.WithContext(ctx))
+	})
+}
+
+// copyPreconditionFailed returns an error for a copy whose precondition failed
+// and marks the precondition as failed in the given context.
+func copyPreconditionFailed(ctx context.Context, message string) error {
+	if failed, ok := ctx.Value(preconditionFailedKey{}).(*atomic.Bool); ok {
+		failed.Store(true)
+	}
+	return gofakes3.ErrorMessage(errPreconditionFailed, message)
+}
+
+// parseCopySourceIfMatch returns the ETag of the x-amz-copy-source-if-match
+// header in the given metadata. A wildcard matches any ETag, in which case an
+// empty string is returned.
+func parseCopySourceIfMatch(meta map[string]string) string {
+	etag := strings.Trim(strings.TrimSpace(meta["X-Amz-Copy-Source-If-Match"]), `"`)
+	if etag == "*" {
+		return ""
+	}
+	return etag
+}
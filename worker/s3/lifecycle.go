@@ -0,0 +1,238 @@
+package s3
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"strings"
+
+	"go.sia.tech/gofakes3"
+	"go.sia.tech/renterd/api"
+	"go.sia.tech/renterd/internal/utils"
+)
+
+// maxLifecycleBodySize is the maximum size of a PutBucketLifecycleConfiguration
+// request body.
+const maxLifecycleBodySize = 1 << 16 // 64 KiB
+
+const (
+	lifecycleStatusEnabled  = "Enabled"
+	lifecycleStatusDisabled = "Disabled"
+)
+
+type (
+	// lifecycleBackend is implemented by backends that support bucket
+	// lifecycle configurations, which gofakes3 doesn't route to the backend
+	// itself.
+	lifecycleBackend interface {
+		GetBucketLifecycleConfiguration(ctx context.Context, bucket string) ([]api.LifecycleRule, error)
+		PutBucketLifecycleConfiguration(ctx context.Context, bucket string, rules []api.LifecycleRule) error
+	}
+
+	// lifecycleConfiguration is the XML representation of a bucket's
+	// lifecycle configuration used by the GetBucketLifecycleConfiguration and
+	// PutBucketLifecycleConfiguration requests.
+	lifecycleConfiguration struct {
+		XMLName xml.Name        `xml:"LifecycleConfiguration"`
+		Xmlns   string          `xml:"xmlns,attr,omitempty"`
+		Rules   []lifecycleRule `xml:"Rule"`
+	}
+
+	lifecycleRule struct {
+		ID         string               `xml:"ID,omitempty"`
+		Filter     *lifecycleFilter     `xml:"Filter,omitempty"`
+		Prefix     *string              `xml:"Prefix,omitempty"` // deprecated, replaced by Filter
+		Status     string               `xml:"Status"`
+		Expiration *lifecycleExpiration `xml:"Expiration,omitempty"`
+
+		// Unsupported collects the elements of a rule we don't support,
+		// e.g. transitions or noncurrent version expirations
+		Unsupported []lifecycleElement `xml:",any"`
+	}
+
+	lifecycleFilter struct {
+		Prefix string `xml:"Prefix"`
+
+		// Unsupported collects the elements of a filter we don't support,
+		// e.g. tags, size limits or And conditions
+		Unsupported []lifecycleElement `xml:",any"`
+	}
+
+	// lifecycleElement is an arbitrary XML element of a lifecycle
+	// configuration.
+	lifecycleElement struct {
+		XMLName xml.Name
+	}
+
+	lifecycleExpiration struct {
+		Days int `xml:"Days,omitempty"`
+	}
+)
+
+var (
+	_ lifecycleBackend = (*s3)(nil)
+	_ lifecycleBackend = (*authenticatedBackend)(nil)
+)
+
+// GetBucketLifecycleConfiguration returns the lifecycle rules of a bucket.
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketLifecycleConfiguration.html
+func (s *s3) GetBucketLifecycleConfiguration(ctx context.Context, bucket string) ([]api.LifecycleRule, error) {
+	b, err := s.b.Bucket(ctx, bucket)
+	if utils.IsErr(err, api.ErrBucketNotFound) {
+		return nil, gofakes3.BucketNotFound(bucket)
+	} else if err != nil {
+		return nil, gofakes3.ErrorMessage(gofakes3.ErrInternal, err.Error())
+	}
+	return b.Policy.LifecycleRules, nil
+}
+
+// PutBucketLifecycleConfiguration replaces the lifecycle rules of a bucket.
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketLifecycleConfiguration.html
+func (s *s3) PutBucketLifecycleConfiguration(ctx context.Context, bucket string, rules []api.LifecycleRule) error {
+	b, err := s.b.Bucket(ctx, bucket)
+	if utils.IsErr(err, api.ErrBucketNotFound) {
+		return gofakes3.BucketNotFound(bucket)
+	} else if err != nil {
+		return gofakes3.ErrorMessage(gofakes3.ErrInternal, err.Error())
+	}
+
+	b.Policy.LifecycleRules = rules
+	if err := b.Policy.Validate(); err != nil {
+		return gofakes3.ErrorMessage(gofakes3.ErrInvalidArgument, err.Error())
+	} else if err := s.b.UpdateBucketPolicy(ctx, bucket, b.Policy); utils.IsErr(err, api.ErrBucketNotFound) {
+		return gofakes3.BucketNotFound(bucket)
+	} else if err != nil {
+		return gofakes3.ErrorMessage(gofakes3.ErrInternal, err.Error())
+	}
+	return nil
+}
+
+func (b *authenticatedBackend) GetBucketLifecycleConfiguration(ctx context.Context, bucket string) ([]api.LifecycleRule, error) {
+	if !b.permsFromCtx(ctx, bucket).GetBucketLifecycle {
+		return nil, gofakes3.ErrAccessDenied
+	}
+	return b.backend.GetBucketLifecycleConfiguration(ctx, bucket)
+}
+
+func (b *authenticatedBackend) PutBucketLifecycleConfiguration(ctx context.Context, bucket string, rules []api.LifecycleRule) error {
+	if !b.permsFromCtx(ctx, bucket).PutBucketLifecycle {
+		return gofakes3.ErrAccessDenied
+	}
+	return b.backend.PutBucketLifecycleConfiguration(ctx, bucket, rules)
+}
+
+// newLifecycleHandler returns a handler that serves bucket lifecycle requests
+// using the given backend and passes all other requests on to the given
+// handler. This is necessary since gofakes3 doesn't support lifecycle
+// configurations and would otherwise treat them as regular bucket requests.
+func newLifecycleHandler(backend lifecycleBackend, next http.Handler, authMiddleware func(http.Handler) http.Handler, opts Opts) http.Handler {
+	var lifecycle http.Handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		bucket, _ := bucketAndKey(req, opts)
+		if err := serveLifecycle(backend, w, req, bucket); err != nil {
+			writeErrorResponse(w, req, err)
+		}
+	})
+	if authMiddleware != nil {
+		lifecycle = authMiddleware(lifecycle)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if _, ok := req.URL.Query()["lifecycle"]; !ok {
+			next.ServeHTTP(w, req)
+			return
+		} else if bucket, key := bucketAndKey(req, opts); bucket == "" || key != "" {
+			next.ServeHTTP(w, req)
+			return
+		}
+		lifecycle.ServeHTTP(w, req)
+	})
+}
+
+func serveLifecycle(backend lifecycleBackend, w http.ResponseWriter, req *http.Request, bucket string) error {
+	switch req.Method {
+	case http.MethodGet:
+		rules, err := backend.GetBucketLifecycleConfiguration(req.Context(), bucket)
+		if err != nil {
+			return err
+		}
+		resp := lifecycleConfiguration{Xmlns: "http://s3.amazonaws.com/doc/2006-03-01/", Rules: []lifecycleRule{}}
+		for _, rule := range rules {
+			status := lifecycleStatusEnabled
+			if rule.Disabled {
+				status = lifecycleStatusDisabled
+			}
+			resp.Rules = append(resp.Rules, lifecycleRule{
+				Filter:     &lifecycleFilter{Prefix: strings.TrimPrefix(rule.Prefix, "/")},
+				Status:     status,
+				Expiration: &lifecycleExpiration{Days: rule.ExpiryDays},
+			})
+		}
+		w.Header().Set("Content-Type", "application/xml")
+		_, _ = w.Write([]byte(xml.Header))
+		return xml.NewEncoder(w).Encode(resp)
+	case http.MethodPut:
+		body, err := io.ReadAll(io.LimitReader(req.Body, maxLifecycleBodySize))
+		if err != nil {
+			return gofakes3.ErrorMessage(gofakes3.ErrInternal, err.Error())
+		}
+		var cfg lifecycleConfiguration
+		if err := xml.Unmarshal(body, &cfg); err != nil {
+			return gofakes3.ErrMalformedXML
+		}
+		rules, err := parseLifecycleRules(cfg)
+		if err != nil {
+			return err
+		}
+		if err := backend.PutBucketLifecycleConfiguration(req.Context(), bucket, rules); err != nil {
+			return err
+		}
+		w.WriteHeader(http.StatusOK)
+		return nil
+	case http.MethodDelete:
+		if err := backend.PutBucketLifecycleConfiguration(req.Context(), bucket, nil); err != nil {
+			return err
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	default:
+		return gofakes3.ErrMethodNotAllowed
+	}
+}
+
+// parseLifecycleRules converts the rules of the given configuration to
+// lifecycle rules. Disabled rules are kept so they are returned by subsequent
+// GetBucketLifecycleConfiguration requests. Only expiration rules with a prefix
+// filter are supported, any other rule is rejected rather than ignored since
+// ignoring a filter would widen the set of objects that expire.
+func parseLifecycleRules(cfg lifecycleConfiguration) ([]api.LifecycleRule, error) {
+	var rules []api.LifecycleRule
+	for _, rule := range cfg.Rules {
+		switch rule.Status {
+		case lifecycleStatusEnabled, lifecycleStatusDisabled:
+		default:
+			return nil, gofakes3.ErrMalformedXML
+		}
+
+		if rule.Expiration == nil || rule.Expiration.Days <= 0 {
+			return nil, gofakes3.ErrorMessage(gofakes3.ErrNotImplemented, "only expiration rules with a number of days are supported")
+		} else if len(rule.Unsupported) > 0 {
+			return nil, gofakes3.ErrorMessagef(gofakes3.ErrNotImplemented, "lifecycle rule element '%s' is not supported", rule.Unsupported[0].XMLName.Local)
+		} else if rule.Filter != nil && len(rule.Filter.Unsupported) > 0 {
+			return nil, gofakes3.ErrorMessagef(gofakes3.ErrNotImplemented, "only prefix filters are supported, got '%s'", rule.Filter.Unsupported[0].XMLName.Local)
+		}
+
+		var prefix string
+		if rule.Filter != nil {
+			prefix = rule.Filter.Prefix
+		} else if rule.Prefix != nil {
+			prefix = *rule.Prefix
+		}
+		rules = append(rules, api.LifecycleRule{
+			Prefix:     "/" + prefix,
+			ExpiryDays: rule.Expiration.Days,
+			Disabled:   rule.Status == lifecycleStatusDisabled,
+		})
+	}
+	return rules, nil
+}
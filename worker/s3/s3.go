@@ -29,11 +29,15 @@ type Bus interface {
 	CreateBucket(ctx context.Context, bucketName string, opts api.CreateBucketOptions) error
 	DeleteBucket(ctx context.Context, bucketName string) error
 	ListBuckets(ctx context.Context) (buckets []api.Bucket, err error)
+	UpdateBucketPolicy(ctx context.Context, bucketName string, policy api.BucketPolicy) error
 
 	AddObject(ctx context.Context, bucket, key string, o object.Object, opts api.AddObjectOptions) (err error)
 	CopyObject(ctx context.Context, srcBucket, dstBucket, srcKey, dstKey string, opts api.CopyObjectOptions) (om api.ObjectMetadata, err error)
 	DeleteObject(ctx context.Context, bucket, key string) (err error)
+	Object(ctx context.Context, bucket, key string, opts api.GetObjectOptions) (res api.Object, err error)
 	Objects(ctx context.Context, prefix string, opts api.ListObjectOptions) (resp api.ObjectsResponse, err error)
+	ObjectTags(ctx context.Context, bucket, key string) (tags api.ObjectTags, err error)
+	UpdateObjectTags(ctx context.Context, bucket, key string, tags api.ObjectTags) (err error)
 
 	AbortMultipartUpload(ctx context.Context, bucket, key string, uploadID string) (err error)
 	CompleteMultipartUpload(ctx context.Context, bucket, key, uploadID string, parts []api.MultipartCompletedPart, opts api.CompleteMultipartOptions) (_ api.MultipartCompleteResponse, err error)
@@ -75,8 +79,15 @@ func New(b Bus, w Worker, logger *zap.Logger, opts Opts) (http.Handler, error) {
 		logger: logger.Sugar(),
 	}
 	backend := gofakes3.Backend(s3Backend)
+	tagger := taggingBackend(s3Backend)
+	lifecycler := lifecycleBackend(s3Backend)
+	var authMiddleware func(http.Handler) http.Handler
 	if !opts.AuthDisabled {
-		backend = newAuthenticatedBackend(s3Backend)
+		authBackend := newAuthenticatedBackend(s3Backend)
+		backend = authBackend
+		tagger = authBackend
+		lifecycler = authBackend
+		authMiddleware = authBackend.AuthenticationMiddleware
 	}
 	faker, err := gofakes3.New(
 		backend,
@@ -89,7 +100,8 @@ func New(b Bus, w Worker, logger *zap.Logger, opts Opts) (http.Handler, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create s3 server: %w", err)
 	}
-	return faker.Server(), nil
+	handler := newTaggingHandler(tagger, newCopyPreconditionHandler(faker.Server()), authMiddleware, opts)
+	return newLifecycleHandler(lifecycler, handler, authMiddleware, opts), nil
 }
 
 // Parsev4AuthKeys parses a list of accessKey-secretKey pairs and returns a map
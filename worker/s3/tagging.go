@@ -0,0 +1,223 @@
+package s3
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"go.sia.tech/gofakes3"
+	"go.sia.tech/renterd/api"
+	"go.sia.tech/renterd/internal/utils"
+)
+
+// maxTaggingBodySize is the maximum size of a PutObjectTagging request body.
+const maxTaggingBodySize = 1 << 16 // 64 KiB
+
+type (
+	// taggingBackend is implemented by backends that support object tagging,
+	// which gofakes3 doesn't route to the backend itself.
+	taggingBackend interface {
+		GetObjectTagging(ctx context.Context, bucket, key string) (api.ObjectTags, error)
+		PutObjectTagging(ctx context.Context, bucket, key string, tags api.ObjectTags) error
+		DeleteObjectTagging(ctx context.Context, bucket, key string) error
+	}
+
+	// tagging is the XML representation of an object's tag set used by the
+	// GetObjectTagging and PutObjectTagging requests.
+	tagging struct {
+		XMLName xml.Name `xml:"Tagging"`
+		Xmlns   string   `xml:"xmlns,attr,omitempty"`
+		TagSet  []tag    `xml:"TagSet>Tag"`
+	}
+
+	tag struct {
+		Key   string `xml:"Key"`
+		Value string `xml:"Value"`
+	}
+)
+
+var (
+	_ taggingBackend = (*s3)(nil)
+	_ taggingBackend = (*authenticatedBackend)(nil)
+)
+
+// GetObjectTagging returns the tags of an object.
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetObjectTagging.html
+func (s *s3) GetObjectTagging(ctx context.Context, bucket, key string) (api.ObjectTags, error) {
+	tags, err := s.b.ObjectTags(ctx, bucket, "/"+key)
+	if utils.IsErr(err, api.ErrObjectNotFound) {
+		return nil, gofakes3.KeyNotFound(key)
+	} else if err != nil {
+		return nil, gofakes3.ErrorMessage(gofakes3.ErrInternal, err.Error())
+	}
+	return tags, nil
+}
+
+// PutObjectTagging replaces the tags of an object.
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutObjectTagging.html
+func (s *s3) PutObjectTagging(ctx context.Context, bucket, key string, tags api.ObjectTags) error {
+	if err := tags.Validate(); err != nil {
+		return gofakes3.ErrorMessage(gofakes3.ErrInvalidArgument, err.Error())
+	}
+	err := s.b.UpdateObjectTags(ctx, bucket, "/"+key, tags)
+	if utils.IsErr(err, api.ErrObjectNotFound) {
+		return gofakes3.KeyNotFound(key)
+	} else if err != nil {
+		return gofakes3.ErrorMessage(gofakes3.ErrInternal, err.Error())
+	}
+	return nil
+}
+
+// DeleteObjectTagging removes all tags of an object.
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteObjectTagging.html
+func (s *s3) DeleteObjectTagging(ctx context.Context, bucket, key string) error {
+	return s.PutObjectTagging(ctx, bucket, key, nil)
+}
+
+func (b *authenticatedBackend) GetObjectTagging(ctx context.Context, bucket, key string) (api.ObjectTags, error) {
+	if !b.permsFromCtx(ctx, bucket).GetObjectTagging {
+		return nil, gofakes3.ErrAccessDenied
+	}
+	return b.backend.GetObjectTagging(ctx, bucket, key)
+}
+
+func (b *authenticatedBackend) PutObjectTagging(ctx context.Context, bucket, key string, tags api.ObjectTags) error {
+	if !b.permsFromCtx(ctx, bucket).PutObjectTagging {
+		return gofakes3.ErrAccessDenied
+	}
+	return b.backend.PutObjectTagging(ctx, bucket, key, tags)
+}
+
+func (b *authenticatedBackend) DeleteObjectTagging(ctx context.Context, bucket, key string) error {
+	if !b.permsFromCtx(ctx, bucket).PutObjectTagging {
+		return gofakes3.ErrAccessDenied
+	}
+	return b.backend.DeleteObjectTagging(ctx, bucket, key)
+}
+
+// newTaggingHandler returns a handler that serves object tagging requests
+// using the given backend and passes all other requests on to the given
+// handler. This is necessary since gofakes3 doesn't support tagging and would
+// otherwise treat a tagging request as a regular object request.
+func newTaggingHandler(backend taggingBackend, next http.Handler, authMiddleware func(http.Handler) http.Handler, opts Opts) http.Handler {
+	var tagging http.Handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		bucket, key := bucketAndKey(req, opts)
+		if err := serveTagging(backend, w, req, bucket, key); err != nil {
+			writeErrorResponse(w, req, err)
+		}
+	})
+	if authMiddleware != nil {
+		tagging = authMiddleware(tagging)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if _, ok := req.URL.Query()["tagging"]; !ok {
+			next.ServeHTTP(w, req)
+			return
+		} else if _, key := bucketAndKey(req, opts); key == "" {
+			next.ServeHTTP(w, req) // bucket tagging is not supported
+			return
+		}
+		tagging.ServeHTTP(w, req)
+	})
+}
+
+func serveTagging(backend taggingBackend, w http.ResponseWriter, req *http.Request, bucket, key string) error {
+	switch req.Method {
+	case http.MethodGet:
+		tags, err := backend.GetObjectTagging(req.Context(), bucket, key)
+		if err != nil {
+			return err
+		}
+		resp := tagging{Xmlns: "http://s3.amazonaws.com/doc/2006-03-01/", TagSet: []tag{}}
+		for k, v := range tags {
+			resp.TagSet = append(resp.TagSet, tag{Key: k, Value: v})
+		}
+		sort.Slice(resp.TagSet, func(i, j int) bool {
+			return resp.TagSet[i].Key < resp.TagSet[j].Key
+		})
+		w.Header().Set("Content-Type", "application/xml")
+		_, _ = w.Write([]byte(xml.Header))
+		return xml.NewEncoder(w).Encode(resp)
+	case http.MethodPut:
+		body, err := io.ReadAll(io.LimitReader(req.Body, maxTaggingBodySize))
+		if err != nil {
+			return gofakes3.ErrorMessage(gofakes3.ErrInternal, err.Error())
+		}
+		var t tagging
+		if err := xml.Unmarshal(body, &t); err != nil {
+			return gofakes3.ErrMalformedXML
+		}
+		tags := make(api.ObjectTags, len(t.TagSet))
+		for _, tag := range t.TagSet {
+			if _, exists := tags[tag.Key]; exists {
+				return gofakes3.ErrorMessage(gofakes3.ErrInvalidArgument, "duplicate tag key '"+tag.Key+"'")
+			}
+			tags[tag.Key] = tag.Value
+		}
+		if err := backend.PutObjectTagging(req.Context(), bucket, key, tags); err != nil {
+			return err
+		}
+		w.WriteHeader(http.StatusOK)
+		return nil
+	case http.MethodDelete:
+		if err := backend.DeleteObjectTagging(req.Context(), bucket, key); err != nil {
+			return err
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	default:
+		return gofakes3.ErrMethodNotAllowed
+	}
+}
+
+func writeErrorResponse(w http.ResponseWriter, req *http.Request, err error) {
+	var resp gofakes3.Error
+	if !errors.As(err, &resp) {
+		resp = &gofakes3.ErrorResponse{Code: gofakes3.ErrInternal, Message: "Internal Error"}
+	} else if code, ok := resp.(gofakes3.ErrorCode); ok {
+		resp = &gofakes3.ErrorResponse{Code: code, Message: code.Message()}
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(resp.ErrorCode().Status())
+	if req.Method != http.MethodHead {
+		_, _ = w.Write([]byte(xml.Header))
+		_ = xml.NewEncoder(w).Encode(resp)
+	}
+}
+
+// bucketAndKey extracts the bucket and object key from the request, taking
+// into account virtual-host-style requests the same way gofakes3 does.
+func bucketAndKey(req *http.Request, opts Opts) (bucket, key string) {
+	path := strings.TrimPrefix(req.URL.Path, "/")
+	if hostBucket, ok := bucketFromHost(req.Host, opts); ok {
+		return hostBucket, path
+	}
+	parts := strings.SplitN(path, "/", 2)
+	if len(parts) == 2 {
+		key = parts[1]
+	}
+	return parts[0], key
+}
+
+func bucketFromHost(host string, opts Opts) (string, bool) {
+	if len(opts.HostBucketBases) > 0 {
+		for _, base := range opts.HostBucketBases {
+			base = "." + strings.Trim(base, ".")
+			if !strings.HasSuffix(host, base) {
+				continue
+			} else if bucket := host[:len(host)-len(base)]; !strings.Contains(bucket, ".") {
+				return bucket, true
+			}
+		}
+		return "", false
+	} else if opts.HostBucketEnabled {
+		return strings.SplitN(host, ".", 2)[0], true
+	}
+	return "", false
+}
@@ -20,9 +20,11 @@ import (
 const (
 	defaultPackedSlabsLockDuration  = 10 * time.Minute
 	defaultPackedSlabsUploadTimeout = 10 * time.Minute
+
+	slabBufferFlushEventStreamRetryInterval = 10 * time.Second
 )
 
-func (w *Worker) upload(ctx context.Context, bucket, key string, rs api.RedundancySettings, r io.Reader, hosts []upload.HostInfo, opts ...upload.Option) (_ string, err error) {
+func (w *Worker) upload(ctx context.Context, bucket, key string, rs api.RedundancySettings, r io.Reader, hosts []upload.HostInfo, opts ...upload.Option) (_ string, _ api.UploadStageTelemetry, err error) {
 	// apply the options
 	up := upload.DefaultParameters(bucket, key, rs)
 	for _, opt := range opts {
@@ -43,19 +45,20 @@ func (w *Worker) upload(ctx context.Context, bucket, key string, rs api.Redundan
 	}
 
 	// perform the upload
-	bufferSizeLimitReached, eTag, err := w.uploadManager.Upload(ctx, r, hosts, up)
+	bufferSizeLimitReached, eTag, telemetry, err := w.uploadManager.Upload(ctx, r, hosts, up)
 	if err != nil {
-		return "", err
+		return "", api.UploadStageTelemetry{}, err
 	}
 
 	// return early if worker was shut down or if we don't have to consider
 	// packed uploads
 	if w.isStopped() || !up.Packing {
-		return eTag, nil
+		return eTag, telemetry, nil
 	}
 
 	// try and upload one slab synchronously
 	if bufferSizeLimitReached {
+		start := time.Now()
 		mem := w.uploadManager.AcquireMemory(ctx, up.RS.SlabSize())
 		if mem != nil {
 			defer mem.Release()
@@ -71,12 +74,38 @@ func (w *Worker) upload(ctx context.Context, bucket, key string, rs api.Redundan
 				}
 			}
 		}
+		telemetry.SlabPackingMs += time.Since(start).Milliseconds()
 	}
 
 	// make sure there's a goroutine uploading any packed slabs
 	go w.threadedUploadPackedSlabs(up.RS)
 
-	return eTag, nil
+	return eTag, telemetry, nil
+}
+
+// threadedUploadFlushedSlabs uploads the packed slabs of slab buffers the bus
+// flushed because they weren't completed in time. Without it, flushed buffers
+// are only uploaded once new data with the same redundancy is uploaded.
+func (w *Worker) threadedUploadFlushedSlabs() {
+	for {
+		events, err := w.bus.SlabBufferFlushEventStream(w.shutdownCtx)
+		if err == nil {
+			for e := range events {
+				go w.threadedUploadPackedSlabs(api.RedundancySettings{
+					MinShards:   int(e.MinShards),
+					TotalShards: int(e.TotalShards),
+				})
+			}
+			return // the stream is closed on shutdown
+		}
+		w.logger.Debugw("failed to subscribe to slab buffer flush events", zap.Error(err))
+
+		select {
+		case <-w.shutdownCtx.Done():
+			return
+		case <-time.After(slabBufferFlushEventStreamRetryInterval):
+		}
+	}
 }
 
 func (w *Worker) threadedUploadPackedSlabs(rs api.RedundancySettings) {
@@ -191,7 +220,7 @@ func (w *Worker) uploadPackedSlab(ctx context.Context, mem memory.Memory, ps api
 	ctx = gouging.WithChecker(ctx, w.bus, up.GougingParams)
 
 	// upload packed slab
-	err = w.uploadManager.UploadPackedSlab(ctx, rs, ps, mem, contracts, up.CurrentHeight)
+	err = w.uploadManager.UploadPackedSlab(ctx, rs, ps, mem, contracts, up.CurrentHeight, up.ChecksumAlgorithm)
 	if err != nil {
 		return fmt.Errorf("couldn't upload packed slab, err: %v", err)
 	}
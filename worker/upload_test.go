@@ -5,6 +5,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -13,6 +16,7 @@ import (
 	"go.sia.tech/renterd/api"
 	"go.sia.tech/renterd/internal/download"
 	"go.sia.tech/renterd/internal/test"
+	"go.sia.tech/renterd/internal/test/mocks"
 	"go.sia.tech/renterd/internal/upload"
 	"go.sia.tech/renterd/object"
 	"lukechampine.com/frand"
@@ -42,7 +46,7 @@ func TestUpload(t *testing.T) {
 	params := testParameters(t.Name())
 
 	// upload data
-	_, _, err := ul.Upload(context.Background(), bytes.NewReader(data), w.UploadHosts(), params)
+	_, _, _, err := ul.Upload(context.Background(), bytes.NewReader(data), w.UploadHosts(), params)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -123,7 +127,7 @@ func TestUpload(t *testing.T) {
 
 	// try and upload into a bucket that does not exist
 	params.Bucket = "doesnotexist"
-	_, _, err = ul.Upload(context.Background(), bytes.NewReader(data), w.UploadHosts(), params)
+	_, _, _, err = ul.Upload(context.Background(), bytes.NewReader(data), w.UploadHosts(), params)
 	if !errors.Is(err, api.ErrBucketNotFound) {
 		t.Fatal("expected bucket not found error", err)
 	}
@@ -131,12 +135,60 @@ func TestUpload(t *testing.T) {
 	// upload data using a cancelled context - assert we don't hang
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
-	_, _, err = ul.Upload(ctx, bytes.NewReader(data), w.UploadHosts(), params)
+	_, _, _, err = ul.Upload(ctx, bytes.NewReader(data), w.UploadHosts(), params)
 	if err == nil || !errors.Is(err, upload.ErrUploadCancelled) {
 		t.Fatal(err)
 	}
 }
 
+func TestDownloadAlternativeContracts(t *testing.T) {
+	// create test worker
+	w := newTestWorker(t, newTestWorkerCfg())
+
+	// add hosts to worker
+	w.AddHosts(testRedundancySettings.TotalShards)
+
+	// convenience variables
+	os := w.os
+	dl := w.downloadManager
+	ul := w.uploadManager
+
+	// create test data
+	data := frand.Bytes(128)
+
+	// upload data
+	params := testParameters(t.Name())
+	_, _, _, err := ul.Upload(context.Background(), bytes.NewReader(data), w.UploadHosts(), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// grab the object
+	o, err := os.Object(context.Background(), testBucket, t.Name(), api.GetObjectOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// add a host that doesn't store any sectors and make it the only host the
+	// object knows of, the hosts that actually store the sectors can only be
+	// found by looking up alternative contracts
+	bad := w.AddHost()
+	for i := range o.Object.Slabs[0].Shards {
+		o.Object.Slabs[0].Shards[i].Contracts = map[types.PublicKey][]types.FileContractID{
+			bad.PublicKey(): {bad.ID()},
+		}
+	}
+
+	// download the data and assert it matches
+	var buf bytes.Buffer
+	err = dl.DownloadObject(context.Background(), &buf, *o.Object, 0, uint64(o.Size), w.UsableHosts())
+	if err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(data, buf.Bytes()) {
+		t.Fatal("data mismatch")
+	}
+}
+
 func TestUploadPackedSlab(t *testing.T) {
 	// create test worker
 	w := newTestWorker(t, newTestWorkerCfg())
@@ -158,7 +210,7 @@ func TestUploadPackedSlab(t *testing.T) {
 	data := frand.Bytes(128)
 
 	// upload data
-	_, _, err := ul.Upload(context.Background(), bytes.NewReader(data), w.UploadHosts(), params)
+	_, _, _, err := ul.Upload(context.Background(), bytes.NewReader(data), w.UploadHosts(), params)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -194,7 +246,7 @@ func TestUploadPackedSlab(t *testing.T) {
 
 	// upload the packed slab
 	mem := mm.AcquireMemory(context.Background(), params.RS.SlabSize())
-	err = ul.UploadPackedSlab(context.Background(), params.RS, ps, mem, w.UploadHosts(), 0)
+	err = ul.UploadPackedSlab(context.Background(), params.RS, ps, mem, w.UploadHosts(), 0, "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -231,7 +283,7 @@ func TestUploadPackedSlab(t *testing.T) {
 	uploadBytes := func(n int) {
 		t.Helper()
 		params.Key = fmt.Sprintf("%s_%d", t.Name(), c)
-		_, err := w.upload(context.Background(), params.Bucket, params.Key, testRedundancySettings, bytes.NewReader(frand.Bytes(n)), w.UploadHosts(), upload.WithPacking(true))
+		_, _, err := w.upload(context.Background(), params.Bucket, params.Key, testRedundancySettings, bytes.NewReader(frand.Bytes(n)), w.UploadHosts(), upload.WithPacking(true))
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -294,7 +346,7 @@ func TestMigrateLostSector(t *testing.T) {
 	params := testParameters(t.Name())
 
 	// upload data
-	_, _, err := ul.Upload(context.Background(), bytes.NewReader(data), w.UploadHosts(), params)
+	_, _, _, err := ul.Upload(context.Background(), bytes.NewReader(data), w.UploadHosts(), params)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -390,6 +442,78 @@ func TestMigrateLostSector(t *testing.T) {
 	}
 }
 
+func TestUploadMemoryPressure(t *testing.T) {
+	// create test worker with only enough upload memory for a single slab
+	slabSize := testRedundancySettings.SlabSize()
+	mm := mocks.NewMemoryManagerWithCapacity(slabSize)
+	w := newTestWorkerWithUploadMemory(t, newTestWorkerCfg(), mm)
+
+	// add hosts to worker
+	w.AddHosts(testRedundancySettings.TotalShards * 2)
+
+	// convenience variables
+	os := w.os
+	dl := w.downloadManager
+	ul := w.uploadManager
+
+	// upload multiple multi-slab objects concurrently, every slab has to wait
+	// for the memory of the previous one to be released
+	const numUploads = 2
+	data := make([][]byte, numUploads)
+	errChan := make(chan error, numUploads)
+	for i := range data {
+		data[i] = frand.Bytes(int(testRedundancySettings.SlabSizeNoRedundancy() + 128))
+		go func(i int) {
+			params := testParameters(fmt.Sprintf("%s_%d", t.Name(), i))
+			_, _, _, err := ul.Upload(context.Background(), bytes.NewReader(data[i]), w.UploadHosts(), params)
+			errChan <- err
+		}(i)
+	}
+
+	// assert all uploads finish
+	for i := 0; i < numUploads; i++ {
+		select {
+		case err := <-errChan:
+			if err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(30 * time.Second):
+			t.Fatal("uploads didn't finish, possible deadlock")
+		}
+	}
+
+	// assert all memory was released
+	if status := mm.Status(); status.Available != slabSize {
+		t.Fatalf("expected all memory to be released, %v != %v", status.Available, slabSize)
+	}
+
+	// download the data and assert it matches
+	for i := range data {
+		o, err := os.Object(context.Background(), testBucket, fmt.Sprintf("%s_%d", t.Name(), i), api.GetObjectOptions{})
+		if err != nil {
+			t.Fatal(err)
+		} else if len(o.Object.Slabs) != 2 {
+			t.Fatalf("expected 2 slabs, got %v", len(o.Object.Slabs))
+		}
+		var buf bytes.Buffer
+		if err := dl.DownloadObject(context.Background(), &buf, *o.Object, 0, uint64(o.Size), w.UsableHosts()); err != nil {
+			t.Fatal(err)
+		} else if !bytes.Equal(data[i], buf.Bytes()) {
+			t.Fatal("data mismatch")
+		}
+	}
+
+	// assert an upload that is blocked on memory can be cancelled
+	mem := mm.AcquireMemory(context.Background(), slabSize)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, _, _, err := ul.Upload(ctx, bytes.NewReader(data[0]), w.UploadHosts(), testParameters(t.Name()))
+	if !errors.Is(err, upload.ErrUploadCancelled) {
+		t.Fatal("expected upload to be cancelled", err)
+	}
+	mem.Release()
+}
+
 func TestUploadShards(t *testing.T) {
 	// create test worker
 	w := newTestWorker(t, newTestWorkerCfg())
@@ -410,7 +534,7 @@ func TestUploadShards(t *testing.T) {
 	params := testParameters(t.Name())
 
 	// upload data
-	_, _, err := ul.Upload(context.Background(), bytes.NewReader(data), w.UploadHosts(), params)
+	_, _, _, err := ul.Upload(context.Background(), bytes.NewReader(data), w.UploadHosts(), params)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -558,7 +682,7 @@ func TestUploadSingleSectorSlowHosts(t *testing.T) {
 	params.RS.TotalShards = totalShards
 
 	// upload data
-	_, _, err := w.uploadManager.Upload(context.Background(), bytes.NewReader(data), w.UploadHosts(), params)
+	_, _, _, err := w.uploadManager.Upload(context.Background(), bytes.NewReader(data), w.UploadHosts(), params)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -587,7 +711,7 @@ func TestUploadRegression(t *testing.T) {
 	// upload data
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 	defer cancel()
-	_, err := w.upload(ctx, params.Bucket, params.Key, testRedundancySettings, bytes.NewReader(data), w.UploadHosts())
+	_, _, err := w.upload(ctx, params.Bucket, params.Key, testRedundancySettings, bytes.NewReader(data), w.UploadHosts())
 	if !errors.Is(err, upload.ErrUploadCancelled) {
 		t.Fatal(err)
 	}
@@ -596,7 +720,7 @@ func TestUploadRegression(t *testing.T) {
 	unblock()
 
 	// upload data
-	_, err = w.upload(context.Background(), params.Bucket, params.Key, testRedundancySettings, bytes.NewReader(data), w.UploadHosts())
+	_, _, err = w.upload(context.Background(), params.Bucket, params.Key, testRedundancySettings, bytes.NewReader(data), w.UploadHosts())
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -617,6 +741,97 @@ func TestUploadRegression(t *testing.T) {
 	}
 }
 
+func TestUploadRetryPolicy(t *testing.T) {
+	// create test worker
+	w := newTestWorker(t, newTestWorkerCfg())
+
+	// add hosts to worker, the first uploads fail
+	var numFailures atomic.Int64
+	hosts := w.AddHosts(testRedundancySettings.TotalShards * 2)
+	for _, h := range hosts {
+		h.uploadErrFn = func() error {
+			if numFailures.Add(-1) >= 0 {
+				return errors.New("upload failed")
+			}
+			return nil
+		}
+	}
+
+	// create test data
+	data := frand.Bytes(128)
+
+	// create upload params
+	params := testParameters(t.Name())
+	params.RetryPolicy = api.UploadRetryPolicy{
+		InitialBackoff: api.DurationMS(10 * time.Millisecond),
+		MaxBackoff:     api.DurationMS(100 * time.Millisecond),
+	}
+
+	// fail two uploads, assert the upload succeeds and the retries are tracked
+	numFailures.Store(2)
+	_, _, telemetry, err := w.uploadManager.Upload(context.Background(), bytes.NewReader(data), w.UploadHosts(), params)
+	if err != nil {
+		t.Fatal(err)
+	} else if telemetry.SectorUploadRetries != 2 {
+		t.Fatalf("expected 2 retries, got %v", telemetry.SectorUploadRetries)
+	}
+
+	// fail all uploads and only allow a single attempt, assert no shard was
+	// retried on the remaining hosts
+	numFailures.Store(math.MaxInt64)
+	params.RetryPolicy.MaxAttempts = 1
+	_, _, _, err = w.uploadManager.Upload(context.Background(), bytes.NewReader(data), w.UploadHosts(), params)
+	if err == nil {
+		t.Fatal("expected upload to fail")
+	} else if !strings.Contains(err.Error(), fmt.Sprintf("launched=%d ", testRedundancySettings.TotalShards)) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// allow two attempts, assert every shard was retried once
+	params.RetryPolicy.MaxAttempts = 2
+	_, _, _, err = w.uploadManager.Upload(context.Background(), bytes.NewReader(data), w.UploadHosts(), params)
+	if err == nil {
+		t.Fatal("expected upload to fail")
+	} else if !strings.Contains(err.Error(), fmt.Sprintf("launched=%d ", 2*testRedundancySettings.TotalShards)) {
+		t.Fatal("unexpected error", err)
+	}
+}
+
+func TestUploadChecksums(t *testing.T) {
+	// create test worker
+	w := newTestWorker(t, newTestWorkerCfg())
+
+	// add hosts to worker
+	w.AddHosts(testRedundancySettings.TotalShards)
+
+	// upload data with BLAKE3 checksums
+	params := testParameters(t.Name())
+	params.ChecksumAlgorithm = object.ChecksumAlgorithmBLAKE3
+	_, _, _, err := w.uploadManager.Upload(context.Background(), bytes.NewReader(frand.Bytes(128)), w.UploadHosts(), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// assert the slab has a checksum for every shard
+	o, err := w.os.Object(context.Background(), testBucket, t.Name(), api.GetObjectOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	slab := o.Object.Slabs[0].Slab
+	if slab.ChecksumAlgorithm != object.ChecksumAlgorithmBLAKE3 {
+		t.Fatal("unexpected checksum algorithm", slab.ChecksumAlgorithm)
+	} else if len(slab.Checksums) != testRedundancySettings.TotalShards {
+		t.Fatal("unexpected number of checksums", len(slab.Checksums))
+	}
+
+	// assert unknown algorithms are rejected
+	params.ChecksumAlgorithm = "sha256"
+	_, _, _, err = w.uploadManager.Upload(context.Background(), bytes.NewReader(frand.Bytes(128)), w.UploadHosts(), params)
+	if err == nil {
+		t.Fatal("expected upload to fail")
+	}
+}
+
 func testParameters(key string) upload.Parameters {
 	return upload.Parameters{
 		Bucket: testBucket,
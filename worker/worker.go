@@ -46,6 +46,7 @@ import (
 const (
 	defaultRevisionFetchTimeout = 30 * time.Second
 
+	lockingPriorityBenchmark              = 5
 	lockingPrioritySyncing                = 30
 	lockingPriorityActiveContractRevision = 100
 )
@@ -77,6 +78,7 @@ type (
 		ContractLocker
 		ContractStore
 		HostStore
+		MetricsStore
 		ObjectStore
 		SettingStore
 		WebhookStore
@@ -100,13 +102,19 @@ type (
 		RecordContractSpending(ctx context.Context, records []api.ContractSpendingRecord) error
 
 		Host(ctx context.Context, hostKey types.PublicKey) (api.Host, error)
+		RecordHostBenchmark(ctx context.Context, hostKey types.PublicKey, benchmark api.HostBenchmark) error
 		UsableHosts(ctx context.Context) ([]api.HostInfo, error)
 	}
 
+	MetricsStore interface {
+		RecordSlabDownloadMetric(ctx context.Context, metrics ...api.SlabDownloadMetric) error
+	}
+
 	ObjectStore interface {
 		// NOTE: used for download
 		DeleteHostSector(ctx context.Context, hk types.PublicKey, root types.Hash256) error
 		FetchPartialSlab(ctx context.Context, key object.EncryptionKey, offset, length uint32) ([]byte, error)
+		FindAlternativeContracts(ctx context.Context, root types.Hash256) ([]types.FileContractID, error)
 		Slab(ctx context.Context, key object.EncryptionKey) (object.Slab, error)
 
 		// NOTE: used for upload
@@ -127,6 +135,7 @@ type (
 		MultipartUpload(ctx context.Context, uploadID string) (resp api.MultipartUpload, err error)
 		PackedSlabsForUpload(ctx context.Context, lockingDuration time.Duration, minShards, totalShards uint8, limit int) ([]api.PackedSlab, error)
 		RemoveObjects(ctx context.Context, bucket, prefix string) error
+		SlabBufferFlushEventStream(ctx context.Context) (<-chan api.EventSlabBufferFlush, error)
 	}
 
 	SettingStore interface {
@@ -153,6 +162,8 @@ type Worker struct {
 	rhp2Client *rhp2.Client
 	rhp3Client *rhp3.Client
 	rhp4Client *rhp4.Client
+	bandwidth  *rhp.BandwidthLimiter
+	dialer     *rhp.FallbackDialer
 
 	id        string
 	bus       Bus
@@ -170,6 +181,8 @@ type Worker struct {
 	uploadingPackedSlabs map[string]struct{}
 
 	contractSpendingRecorder contracts.SpendingRecorder
+	downloadMetricsRecorder  download.MetricsRecorder
+	sectorVerifier           *sectorVerifier
 
 	shutdownCtx       context.Context
 	shutdownCtxCancel context.CancelFunc
@@ -235,15 +248,27 @@ func (w *Worker) downloadsStatsHandlerGET(jc jape.Context) {
 	})
 }
 
+func (w *Worker) uploadLatencyHandlerGET(jc jape.Context) {
+	api.WriteResponse(jc, w.uploadManager.Latency())
+}
+
 func (w *Worker) uploadsStatsHandlerGET(jc jape.Context) {
 	stats := w.uploadManager.Stats()
 
 	// prepare upload stats
 	var uss []api.UploaderStats
 	for hk, mbps := range stats.UploadSpeedsMBPS {
+		var preflightFailures map[string]uint64
+		for reason, n := range stats.PreflightFailures[hk] {
+			if preflightFailures == nil {
+				preflightFailures = make(map[string]uint64)
+			}
+			preflightFailures[string(reason)] = n
+		}
 		uss = append(uss, api.UploaderStats{
 			HostKey:                  hk,
 			AvgSectorUploadSpeedMBPS: mbps,
+			PreflightFailures:        preflightFailures,
 		})
 	}
 	sort.SliceStable(uss, func(i, j int) bool {
@@ -286,9 +311,15 @@ func (w *Worker) objectHandlerHEAD(jc jape.Context) {
 	// parse key
 	path := jc.PathParam("key")
 
+	followSymlinks := true
+	if jc.DecodeForm("followsymlinks", &followSymlinks) != nil {
+		return
+	}
+
 	// fetch object metadata
 	hor, err := w.HeadObject(jc.Request.Context(), bucket, path, api.HeadObjectOptions{
-		Range: &dr,
+		IgnoreSymlinks: !followSymlinks,
+		Range:          &dr,
 	})
 	if utils.IsErr(err, api.ErrObjectNotFound) {
 		jc.Error(err, http.StatusNotFound)
@@ -336,8 +367,14 @@ func (w *Worker) objectHandlerGET(jc jape.Context) {
 		return
 	}
 
+	followSymlinks := true
+	if jc.DecodeForm("followsymlinks", &followSymlinks) != nil {
+		return
+	}
+
 	gor, err := w.GetObject(ctx, bucket, key, api.DownloadObjectOptions{
-		Range: &dr,
+		IgnoreSymlinks: !followSymlinks,
+		Range:          &dr,
 	})
 	if utils.IsErr(err, api.ErrObjectNotFound) {
 		jc.Error(err, http.StatusNotFound)
@@ -529,6 +566,10 @@ func (w *Worker) objectsRemoveHandlerPOST(jc jape.Context) {
 	jc.Check("couldn't remove objects", w.bus.RemoveObjects(jc.Request.Context(), orr.Bucket, orr.Prefix))
 }
 
+func (w *Worker) dialerCacheHandlerGET(jc jape.Context) {
+	jc.Encode(w.dialer.CacheStats())
+}
+
 func (w *Worker) memoryGET(jc jape.Context) {
 	api.WriteResponse(jc, api.MemoryResponse{
 		Download: w.downloadManager.MemoryStatus(),
@@ -563,6 +604,37 @@ func (w *Worker) accountsResetDriftHandlerPOST(jc jape.Context) {
 	}
 }
 
+func (w *Worker) hostsBandwidthHandlerPUT(jc jape.Context) {
+	var hostKey types.PublicKey
+	if jc.DecodeParam("hostkey", &hostKey) != nil {
+		return
+	}
+	var limits api.HostBandwidthLimits
+	if jc.Decode(&limits) != nil {
+		return
+	}
+	w.bandwidth.SetLimits(hostKey, limits)
+}
+
+func (w *Worker) repairStatusHandlerGET(jc jape.Context) {
+	jc.Encode(w.sectorVerifier.Status())
+}
+
+func (w *Worker) hostBenchmarkHandlerPOST(jc jape.Context) {
+	var hostKey types.PublicKey
+	if jc.DecodeParam("hostkey", &hostKey) != nil {
+		return
+	}
+	res, err := w.benchmarkHost(jc.Request.Context(), hostKey)
+	if utils.IsErr(err, api.ErrHostNotFound) || utils.IsErr(err, api.ErrContractNotFound) {
+		jc.Error(err, http.StatusNotFound)
+		return
+	} else if jc.Check("couldn't benchmark host", err) != nil {
+		return
+	}
+	jc.Encode(res)
+}
+
 func (w *Worker) stateHandlerGET(jc jape.Context) {
 	jc.Encode(api.WorkerStateResponse{
 		ID:        w.id,
@@ -606,9 +678,18 @@ func New(cfg config.Worker, masterKey [32]byte, b Bus, l *zap.Logger) (*Worker,
 	a := alerts.WithOrigin(b, fmt.Sprintf("worker.%s", cfg.ID))
 	shutdownCtx, shutdownCancel := context.WithCancel(context.Background())
 
-	dialer := rhp.NewFallbackDialer(b, net.Dialer{}, l)
+	dialer := rhp.NewFallbackDialer(b, net.Dialer{}, rhp.BandwidthConfig{
+		Default: api.HostBandwidthLimits{
+			Upload:   cfg.UploadBandwidthLimit,
+			Download: cfg.DownloadBandwidthLimit,
+		},
+	}, rhp.DefaultHostCacheTTL, l)
+	go dialer.SweepCache(shutdownCtx)
+
 	w := &Worker{
 		alerts:               a,
+		bandwidth:            dialer.BandwidthLimiter(),
+		dialer:               dialer,
 		cache:                iworker.NewCache(b, cfg.CacheExpiry, l),
 		id:                   cfg.ID,
 		bus:                  b,
@@ -634,11 +715,16 @@ func New(cfg config.Worker, masterKey [32]byte, b Bus, l *zap.Logger) (*Worker,
 	w.hostManager = hm
 
 	dlmm := memory.NewManager(cfg.UploadMaxMemory, l.Named("uploadmanager"))
-	w.downloadManager = download.NewManager(w.shutdownCtx, &uploadKey, hm, dlmm, w.bus, cfg.UploadMaxOverdrive, cfg.UploadOverdriveTimeout, l)
+	w.downloadMetricsRecorder = download.NewMetricsRecorder(w.shutdownCtx, w.bus, cfg.BusFlushInterval, l)
+	w.downloadManager = download.NewManager(w.shutdownCtx, &uploadKey, hm, dlmm, w.downloadMetricsRecorder, w.bus, cfg.UploadMaxOverdrive, cfg.UploadOverdriveTimeout, l)
 
 	ulmm := memory.NewManager(cfg.UploadMaxMemory, l.Named("uploadmanager"))
 	w.uploadManager = upload.NewManager(w.shutdownCtx, &uploadKey, hm, ulmm, w.bus, w.bus, w.bus, cfg.UploadMaxOverdrive, cfg.UploadOverdriveTimeout, l)
 
+	w.sectorVerifier = newSectorVerifier(w.bus, hm, cfg.SectorVerificationInterval, l)
+	go w.sectorVerifier.Run(w.shutdownCtx)
+	go w.threadedUploadFlushedSlabs()
+
 	return w, nil
 }
 
@@ -649,10 +735,17 @@ func (w *Worker) Handler() http.Handler {
 		"GET    /account/:hostkey":       w.accountHandlerGET,
 		"POST   /account/:id/resetdrift": w.accountsResetDriftHandlerPOST,
 
+		"GET    /dialer/cache": w.dialerCacheHandlerGET,
+
+		"POST   /host/:hostkey/benchmark":  w.hostBenchmarkHandlerPOST,
+		"PUT    /hosts/:hostkey/bandwidth": w.hostsBandwidthHandlerPUT,
+
 		"GET    /memory": w.memoryGET,
 
 		"PUT    /multipart/*key": w.multipartUploadHandlerPUT,
 
+		"GET    /repair/status": w.repairStatusHandlerGET,
+
 		"HEAD   /object/*key":    w.objectHandlerHEAD,
 		"GET    /object/*key":    w.objectHandlerGET,
 		"PUT    /object/*key":    w.objectHandlerPUT,
@@ -663,6 +756,8 @@ func (w *Worker) Handler() http.Handler {
 
 		"GET    /stats/downloads": w.downloadsStatsHandlerGET,
 		"GET    /stats/uploads":   w.uploadsStatsHandlerGET,
+
+		"GET    /upload/latency": w.uploadLatencyHandlerGET,
 	})
 }
 
@@ -680,6 +775,7 @@ func (w *Worker) Shutdown(ctx context.Context) error {
 
 	// stop recorders
 	w.contractSpendingRecorder.Stop(ctx)
+	w.downloadMetricsRecorder.Stop(ctx)
 
 	return nil
 }
@@ -687,7 +783,8 @@ func (w *Worker) Shutdown(ctx context.Context) error {
 func (w *Worker) headObject(ctx context.Context, bucket, key string, onlyMetadata bool, opts api.HeadObjectOptions) (*api.HeadObjectResponse, api.Object, error) {
 	// fetch object
 	res, err := w.bus.Object(ctx, bucket, key, api.GetObjectOptions{
-		OnlyMetadata: onlyMetadata,
+		OnlyMetadata:   onlyMetadata,
+		IgnoreSymlinks: opts.IgnoreSymlinks,
 	})
 	if err != nil {
 		return nil, api.Object{}, fmt.Errorf("couldn't fetch object: %w", err)
@@ -746,7 +843,8 @@ func (w *Worker) FundAccount(ctx context.Context, fcid types.FileContractID, hk
 func (w *Worker) GetObject(ctx context.Context, bucket, key string, opts api.DownloadObjectOptions) (*api.GetObjectResponse, error) {
 	// head object
 	hor, res, err := w.headObject(ctx, bucket, key, false, api.HeadObjectOptions{
-		Range: opts.Range,
+		IgnoreSymlinks: opts.IgnoreSymlinks,
+		Range:          opts.Range,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("couldn't fetch object: %w", err)
@@ -857,10 +955,12 @@ func (w *Worker) UploadObject(ctx context.Context, r io.Reader, bucket, key stri
 	}
 
 	// upload
-	eTag, err := w.upload(ctx, bucket, key, up.RedundancySettings, r, contracts,
+	eTag, telemetry, err := w.upload(ctx, bucket, key, up.RedundancySettings, r, contracts,
 		upload.WithBlockHeight(up.CurrentHeight),
 		upload.WithMimeType(opts.MimeType),
 		upload.WithPacking(up.UploadPacking),
+		upload.WithRetryPolicy(up.RetryPolicy),
+		upload.WithChecksumAlgorithm(up.ChecksumAlgorithm),
 		upload.WithObjectUserMetadata(opts.Metadata),
 	)
 	if err != nil {
@@ -871,7 +971,8 @@ func (w *Worker) UploadObject(ctx context.Context, r io.Reader, bucket, key stri
 		return nil, fmt.Errorf("couldn't upload object: %w", err)
 	}
 	return &api.UploadObjectResponse{
-		ETag: eTag,
+		ETag:      eTag,
+		Telemetry: telemetry,
 	}, nil
 }
 
@@ -895,6 +996,8 @@ func (w *Worker) UploadMultipartUploadPart(ctx context.Context, r io.Reader, buc
 	uploadOpts := []upload.Option{
 		upload.WithBlockHeight(up.CurrentHeight),
 		upload.WithPacking(up.UploadPacking),
+		upload.WithRetryPolicy(up.RetryPolicy),
+		upload.WithChecksumAlgorithm(up.ChecksumAlgorithm),
 		upload.WithCustomKey(mu.EncryptionKey),
 		upload.WithPartNumber(partNumber),
 		upload.WithUploadID(uploadID),
@@ -916,7 +1019,7 @@ func (w *Worker) UploadMultipartUploadPart(ctx context.Context, r io.Reader, buc
 	}
 
 	// upload
-	eTag, err := w.upload(ctx, bucket, path, up.RedundancySettings, r, contracts, uploadOpts...)
+	eTag, telemetry, err := w.upload(ctx, bucket, path, up.RedundancySettings, r, contracts, uploadOpts...)
 	if err != nil {
 		w.logger.With(zap.Error(err)).With("path", path).With("bucket", bucket).Error("failed to upload object")
 		if !errors.Is(err, ErrShuttingDown) && !errors.Is(err, upload.ErrUploadCancelled) && !errors.Is(err, context.Canceled) {
@@ -925,7 +1028,8 @@ func (w *Worker) UploadMultipartUploadPart(ctx context.Context, r io.Reader, buc
 		return nil, fmt.Errorf("couldn't upload object: %w", err)
 	}
 	return &api.UploadMultipartUploadPartResponse{
-		ETag: eTag,
+		ETag:      eTag,
+		Telemetry: telemetry,
 	}, nil
 }
 
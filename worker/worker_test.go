@@ -36,6 +36,12 @@ type (
 )
 
 func newTestWorker(t test.TestingCommon, cfg config.Worker) *testWorker {
+	return newTestWorkerWithUploadMemory(t, cfg, mocks.NewMemoryManager())
+}
+
+// newTestWorkerWithUploadMemory creates a test worker that uses the given
+// memory manager for uploads, which allows for simulating memory pressure.
+func newTestWorkerWithUploadMemory(t test.TestingCommon, cfg config.Worker, ulmm *mocks.MemoryManager) *testWorker {
 	// create bus dependencies
 	cs := mocks.NewContractStore()
 	os := mocks.NewObjectStore(testBucket, cs)
@@ -44,7 +50,6 @@ func newTestWorker(t test.TestingCommon, cfg config.Worker) *testWorker {
 	// create worker dependencies
 	b := mocks.NewBus(cs, hs, os)
 	dlmm := mocks.NewMemoryManager()
-	ulmm := mocks.NewMemoryManager()
 
 	// create worker
 	mk := utils.MasterKey(blake2b.Sum256([]byte("testwork")))
@@ -56,8 +61,9 @@ func newTestWorker(t test.TestingCommon, cfg config.Worker) *testWorker {
 	// override managers
 	hm := newTestHostManager(t)
 	uploadKey := mk.DeriveUploadKey()
-	w.downloadManager = download.NewManager(context.Background(), &uploadKey, hm, dlmm, b, cfg.DownloadMaxOverdrive, cfg.DownloadOverdriveTimeout, zap.NewNop())
+	w.downloadManager = download.NewManager(context.Background(), &uploadKey, hm, dlmm, w.downloadMetricsRecorder, b, cfg.DownloadMaxOverdrive, cfg.DownloadOverdriveTimeout, zap.NewNop())
 	w.uploadManager = upload.NewManager(context.Background(), &uploadKey, hm, ulmm, b, b, b, cfg.UploadMaxMemory, cfg.UploadOverdriveTimeout, zap.NewNop())
+	w.sectorVerifier = newSectorVerifier(b, hm, cfg.SectorVerificationInterval, zap.NewNop())
 
 	return &testWorker{
 		test.NewTT(t),